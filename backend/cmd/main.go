@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"flutter-bengkel/internal/config"
 	"flutter-bengkel/internal/database"
@@ -90,6 +92,13 @@ func main() {
 	// Setup routes
 	h.SetupRoutes(api)
 
+	// Background jobs
+	stopJobs := make(chan struct{})
+	go svc.Notification.RunDispatcher(stopJobs)
+	go svc.Event.RunDispatcher(stopJobs)
+	go svc.Webhook.RunDispatcher(stopJobs)
+	svc.Scheduler.Start()
+
 	// Graceful shutdown
 	go func() {
 		if err := app.Listen(":" + cfg.Server.Port); err != nil {
@@ -106,8 +115,19 @@ func main() {
 	<-quit
 
 	log.Println("🛑 Shutting down server...")
+	close(stopJobs)
+	// Event streams stay open until their clients leave; end them so
+	// Shutdown is not left waiting on them
+	svc.Realtime.Close()
 	if err := app.Shutdown(); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
+
+	// Let running scheduled jobs finish before closing the database
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Scheduler.ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+	if err := svc.Scheduler.Stop(ctx); err != nil {
+		log.Printf("Warning: scheduled jobs cancelled before finishing: %v", err)
+	}
 	log.Println("✅ Server exited")
 }
\ No newline at end of file
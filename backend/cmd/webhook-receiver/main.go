@@ -0,0 +1,75 @@
+// Command webhook-receiver is a local stand-in for a webhook consumer. It
+// verifies signatures, logs every delivery and can be told to fail so retry
+// and redelivery behaviour can be exercised during development:
+//
+//	go run ./cmd/webhook-receiver -secret <subscription secret> -fail-first 2
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"flutter-bengkel/internal/webhooks"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	secret := flag.String("secret", "", "subscription secret used to verify signatures (skipped when empty)")
+	status := flag.Int("status", http.StatusOK, "status code returned for accepted deliveries")
+	failFirst := flag.Int("fail-first", 0, "respond 500 to this many deliveries before accepting")
+	tolerance := flag.Duration("tolerance", 5*time.Minute, "maximum age of the signed timestamp")
+	flag.Parse()
+
+	var mu sync.Mutex
+	failures := 0
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		event := r.Header.Get(webhooks.HeaderEvent)
+		delivery := r.Header.Get(webhooks.HeaderDelivery)
+
+		if *secret != "" {
+			err := webhooks.Verify(*secret, r.Header.Get(webhooks.HeaderSignature),
+				r.Header.Get(webhooks.HeaderTimestamp), body, *tolerance)
+			if err != nil {
+				log.Printf("rejected delivery %s (%s): %v", delivery, event, err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		mu.Lock()
+		fail := failures < *failFirst
+		if fail {
+			failures++
+		}
+		mu.Unlock()
+
+		if fail {
+			log.Printf("failing delivery %s (%s) on purpose", delivery, event)
+			http.Error(w, "simulated failure", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("delivery %s (%s): %s", delivery, event, body)
+		w.WriteHeader(*status)
+		w.Write([]byte(`{"received":true}`))
+	})
+
+	log.Printf("Webhook receiver listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
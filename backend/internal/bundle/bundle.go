@@ -0,0 +1,71 @@
+// Package bundle prices service packages and spreads a package's price over
+// its component lines.
+package bundle
+
+import "github.com/shopspring/decimal"
+
+// Pricing rules
+const (
+	PricingFixed    = "fixed"
+	PricingDiscount = "discount"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Component is one service or product in a package, at its list price
+type Component struct {
+	Quantity  decimal.Decimal
+	ListPrice decimal.Decimal
+}
+
+// ListAmount is what one package's components cost bought separately
+func ListAmount(components []Component) decimal.Decimal {
+	total := decimal.Zero
+	for _, component := range components {
+		total = total.Add(component.Quantity.Mul(component.ListPrice))
+	}
+
+	return total.Round(2)
+}
+
+// Price is what one package sells for. A fixed package has its own price; a
+// discount package takes its percentage off the components' list amount.
+func Price(pricingType string, packagePrice, discountPercent, listAmount decimal.Decimal) decimal.Decimal {
+	if pricingType == PricingDiscount {
+		return listAmount.Sub(listAmount.Mul(discountPercent).Div(hundred).Round(2))
+	}
+
+	return packagePrice.Round(2)
+}
+
+// Allocate splits amount over lines in proportion to their weights, the
+// lines' list amounts. Lines share equally when none has a weight. Rounding
+// goes to the heaviest line so the shares add up to amount exactly.
+func Allocate(amount decimal.Decimal, weights []decimal.Decimal) []decimal.Decimal {
+	shares := make([]decimal.Decimal, len(weights))
+	if len(weights) == 0 {
+		return shares
+	}
+
+	total := decimal.Zero
+	heaviest := 0
+	for i, weight := range weights {
+		total = total.Add(weight)
+		if weight.GreaterThan(weights[heaviest]) {
+			heaviest = i
+		}
+	}
+
+	allocated := decimal.Zero
+	for i, weight := range weights {
+		if total.IsPositive() {
+			shares[i] = amount.Mul(weight).Div(total).Round(2)
+		} else {
+			shares[i] = amount.Div(decimal.NewFromInt(int64(len(weights)))).Round(2)
+		}
+		allocated = allocated.Add(shares[i])
+	}
+	shares[heaviest] = shares[heaviest].Add(amount.Sub(allocated))
+
+	return shares
+}
@@ -9,10 +9,17 @@ import (
 )
 
 type Config struct {
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Server   ServerConfig
-	CORS     CORSConfig
+	Database     DatabaseConfig
+	JWT          JWTConfig
+	Server       ServerConfig
+	CORS         CORSConfig
+	Queue        QueueConfig
+	Reminder     ReminderConfig
+	Notification NotificationConfig
+	Events       EventsConfig
+	Webhook      WebhookConfig
+	Scheduler    SchedulerConfig
+	Shift        ShiftConfig
 }
 
 type DatabaseConfig struct {
@@ -39,6 +46,58 @@ type CORSConfig struct {
 	AllowedOrigins string
 }
 
+type QueueConfig struct {
+	DefaultJobDurationMinutes int
+}
+
+type NotificationConfig struct {
+	Transport               string // log or file
+	FilePath                string
+	DefaultLanguage         string
+	MaxAttempts             int
+	RetryBaseSeconds        int
+	DispatchIntervalSeconds int
+}
+
+type EventsConfig struct {
+	RetryBaseSeconds        int
+	DispatchIntervalSeconds int
+}
+
+type WebhookConfig struct {
+	TimeoutSeconds          int
+	MaxAttempts             int
+	RetryBaseSeconds        int
+	DispatchIntervalSeconds int
+}
+
+type SchedulerConfig struct {
+	Enabled                bool
+	Timezone               string
+	ShutdownTimeoutSeconds int
+	RetentionDays          int
+	ReminderScanSchedule   string
+	OverdueSchedule        string
+	CashSummarySchedule    string
+	LoyaltyExpirySchedule  string
+	MembershipSchedule     string
+	StoredValueSchedule    string
+	PurgeSchedule          string
+}
+
+type ShiftConfig struct {
+	RequiredForPayments bool
+}
+
+type ReminderConfig struct {
+	ServiceDueWindowDays       int
+	ServiceDueWindowKm         int
+	DefaultServiceIntervalDays int
+	DefaultServiceIntervalKm   int
+	InsuranceWindowDays        int
+	RegistrationWindowDays     int
+}
+
 func LoadConfig() *Config {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
@@ -66,6 +125,51 @@ func LoadConfig() *Config {
 		CORS: CORSConfig{
 			AllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000"),
 		},
+		Queue: QueueConfig{
+			DefaultJobDurationMinutes: getEnvAsInt("QUEUE_DEFAULT_JOB_DURATION_MINUTES", 60),
+		},
+		Reminder: ReminderConfig{
+			ServiceDueWindowDays:       getEnvAsInt("REMINDER_SERVICE_DUE_WINDOW_DAYS", 14),
+			ServiceDueWindowKm:         getEnvAsInt("REMINDER_SERVICE_DUE_WINDOW_KM", 500),
+			DefaultServiceIntervalDays: getEnvAsInt("REMINDER_DEFAULT_SERVICE_INTERVAL_DAYS", 180),
+			DefaultServiceIntervalKm:   getEnvAsInt("REMINDER_DEFAULT_SERVICE_INTERVAL_KM", 5000),
+			InsuranceWindowDays:        getEnvAsInt("REMINDER_INSURANCE_WINDOW_DAYS", 30),
+			RegistrationWindowDays:     getEnvAsInt("REMINDER_REGISTRATION_WINDOW_DAYS", 30),
+		},
+		Notification: NotificationConfig{
+			Transport:               getEnv("NOTIFICATION_TRANSPORT", "log"),
+			FilePath:                getEnv("NOTIFICATION_FILE_PATH", "storage/notifications.log"),
+			DefaultLanguage:         getEnv("NOTIFICATION_DEFAULT_LANGUAGE", "id"),
+			MaxAttempts:             getEnvAsInt("NOTIFICATION_MAX_ATTEMPTS", 5),
+			RetryBaseSeconds:        getEnvAsInt("NOTIFICATION_RETRY_BASE_SECONDS", 60),
+			DispatchIntervalSeconds: getEnvAsInt("NOTIFICATION_DISPATCH_INTERVAL_SECONDS", 15),
+		},
+		Events: EventsConfig{
+			RetryBaseSeconds:        getEnvAsInt("EVENTS_RETRY_BASE_SECONDS", 30),
+			DispatchIntervalSeconds: getEnvAsInt("EVENTS_DISPATCH_INTERVAL_SECONDS", 5),
+		},
+		Webhook: WebhookConfig{
+			TimeoutSeconds:          getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+			MaxAttempts:             getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryBaseSeconds:        getEnvAsInt("WEBHOOK_RETRY_BASE_SECONDS", 30),
+			DispatchIntervalSeconds: getEnvAsInt("WEBHOOK_DISPATCH_INTERVAL_SECONDS", 10),
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:                getEnv("SCHEDULER_ENABLED", "true") == "true",
+			Timezone:               getEnv("SCHEDULER_TIMEZONE", "Asia/Jakarta"),
+			ShutdownTimeoutSeconds: getEnvAsInt("SCHEDULER_SHUTDOWN_TIMEOUT_SECONDS", 30),
+			RetentionDays:          getEnvAsInt("SCHEDULER_RETENTION_DAYS", 30),
+			ReminderScanSchedule:   getEnv("SCHEDULER_REMINDER_SCAN", "0 7 * * *"),
+			OverdueSchedule:        getEnv("SCHEDULER_MARK_OVERDUE", "5 0 * * *"),
+			CashSummarySchedule:    getEnv("SCHEDULER_CASH_SUMMARY", "30 0 * * *"),
+			LoyaltyExpirySchedule:  getEnv("SCHEDULER_LOYALTY_EXPIRY", "15 1 * * *"),
+			MembershipSchedule:     getEnv("SCHEDULER_MEMBERSHIP_EVALUATION", "45 1 * * *"),
+			StoredValueSchedule:    getEnv("SCHEDULER_STORED_VALUE_EXPIRY", "20 1 * * *"),
+			PurgeSchedule:          getEnv("SCHEDULER_PURGE", "0 3 * * 0"),
+		},
+		Shift: ShiftConfig{
+			RequiredForPayments: getEnv("SHIFT_REQUIRED_FOR_PAYMENTS", "false") == "true",
+		},
 	}
 }
 
@@ -0,0 +1,45 @@
+// Package costing values stock at its moving average cost and works out the
+// gross margin of what is sold.
+package costing
+
+import "github.com/shopspring/decimal"
+
+var hundred = decimal.NewFromInt(100)
+
+// WeightedAverage is the cost of one unit once quantity units bought at
+// unitCost join stock units held at cost. Stock at or below zero has no
+// value left to average with, so the goods received set the cost.
+func WeightedAverage(stock, cost, quantity, unitCost decimal.Decimal) decimal.Decimal {
+	if !stock.IsPositive() {
+		return unitCost.Round(2)
+	}
+
+	total := stock.Add(quantity)
+	if !total.IsPositive() {
+		return cost.Round(2)
+	}
+
+	return stock.Mul(cost).Add(quantity.Mul(unitCost)).Div(total).Round(2)
+}
+
+// Margin is what was sold less what it cost
+type Margin struct {
+	Revenue decimal.Decimal
+	Cost    decimal.Decimal
+	Amount  decimal.Decimal
+	Percent decimal.Decimal // of revenue; zero when nothing was earned
+}
+
+// NewMargin works out the margin on revenue at a cost
+func NewMargin(revenue, cost decimal.Decimal) Margin {
+	margin := Margin{
+		Revenue: revenue.Round(2),
+		Cost:    cost.Round(2),
+	}
+	margin.Amount = margin.Revenue.Sub(margin.Cost)
+	if margin.Revenue.IsPositive() {
+		margin.Percent = margin.Amount.Mul(hundred).Div(margin.Revenue).Round(2)
+	}
+
+	return margin
+}
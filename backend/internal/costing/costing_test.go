@@ -0,0 +1,71 @@
+package costing
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func d(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func TestWeightedAverage(t *testing.T) {
+	tests := []struct {
+		name     string
+		stock    string
+		cost     string
+		quantity string
+		unitCost string
+		want     string
+	}{
+		{name: "averages stock on hand with goods received", stock: "10", cost: "100", quantity: "10", unitCost: "200", want: "150"},
+		{name: "weights by quantity", stock: "3", cost: "10", quantity: "1", unitCost: "11", want: "10.25"},
+		{name: "rounds to the cent", stock: "3", cost: "10", quantity: "4", unitCost: "10.01", want: "10.01"},
+		{name: "no stock takes the goods' cost", stock: "0", cost: "100", quantity: "5", unitCost: "120", want: "120"},
+		{name: "negative stock takes the goods' cost", stock: "-3", cost: "100", quantity: "5", unitCost: "130", want: "130"},
+		{name: "goods' cost is rounded too", stock: "0", cost: "0", quantity: "1", unitCost: "12.345", want: "12.35"},
+		{name: "nothing received keeps the cost", stock: "5", cost: "100", quantity: "0", unitCost: "500", want: "100"},
+		{name: "free goods lower the cost", stock: "1", cost: "90", quantity: "2", unitCost: "0", want: "30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WeightedAverage(d(tt.stock), d(tt.cost), d(tt.quantity), d(tt.unitCost))
+			if !got.Equal(d(tt.want)) {
+				t.Errorf("WeightedAverage() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMargin(t *testing.T) {
+	tests := []struct {
+		name    string
+		revenue string
+		cost    string
+		amount  string
+		percent string
+	}{
+		{name: "margin is a share of revenue", revenue: "1000", cost: "600", amount: "400", percent: "40"},
+		{name: "percent is rounded to the cent", revenue: "300", cost: "200", amount: "100", percent: "33.33"},
+		{name: "selling below cost is negative", revenue: "100", cost: "150", amount: "-50", percent: "-50"},
+		{name: "no revenue has no percent", revenue: "0", cost: "50", amount: "-50", percent: "0"},
+		{name: "amounts are rounded before subtracting", revenue: "100.005", cost: "0.004", amount: "100.01", percent: "100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewMargin(d(tt.revenue), d(tt.cost))
+			if !got.Amount.Equal(d(tt.amount)) {
+				t.Errorf("Amount = %s, want %s", got.Amount, tt.amount)
+			}
+			if !got.Percent.Equal(d(tt.percent)) {
+				t.Errorf("Percent = %s, want %s", got.Percent, tt.percent)
+			}
+			if !got.Revenue.Sub(got.Cost).Equal(got.Amount) {
+				t.Errorf("Revenue - Cost = %s, want %s", got.Revenue.Sub(got.Cost), got.Amount)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+// Package events defines the domain events recorded in the outbox and the
+// bus that routes them to in-process subscribers.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Domain event types
+const (
+	ServiceJobStatusChanged = "service_job.status_changed"
+	TransactionPosted       = "transaction.posted"
+	TransactionVoided       = "transaction.voided"
+	PaymentReceived         = "payment.received"
+	PaymentReversed         = "payment.reversed"
+	CheckoutCompleted       = "checkout.completed"
+	SalesReturned           = "sales.returned"
+	RefundIssued            = "refund.issued"
+	CreditNoteIssued        = "credit_note.issued"
+	CreditNoteApplied       = "credit_note.applied"
+	StoredValuePosted       = "stored_value.posted"
+	VehicleSold             = "vehicle.sold"
+	VehiclePurchased        = "vehicle.purchased"
+	StockLow                = "stock.low"
+	GoodsReceived           = "goods.received"
+)
+
+// Types lists every domain event type
+var Types = []string{
+	ServiceJobStatusChanged,
+	TransactionPosted,
+	TransactionVoided,
+	PaymentReceived,
+	PaymentReversed,
+	CheckoutCompleted,
+	SalesReturned,
+	RefundIssued,
+	CreditNoteIssued,
+	CreditNoteApplied,
+	StoredValuePosted,
+	VehicleSold,
+	VehiclePurchased,
+	StockLow,
+	GoodsReceived,
+}
+
+// Event is a domain event as stored in the outbox
+type Event struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	OutletID      *int64          `json:"outlet_id"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+// Decode unmarshals the event payload into v
+func (e Event) Decode(v interface{}) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode %s payload: %w", e.Type, err)
+	}
+	return nil
+}
+
+// ServiceJobStatusChangedPayload is recorded when a job moves to a new status
+type ServiceJobStatusChangedPayload struct {
+	JobID          int64  `json:"job_id"`
+	JobNumber      string `json:"job_number"`
+	OutletID       int64  `json:"outlet_id"`
+	PreviousStatus string `json:"previous_status"`
+	Status         string `json:"status"`
+	ChangedBy      int64  `json:"changed_by"`
+	Notes          string `json:"notes"`
+}
+
+// TransactionPostedPayload is recorded when a sales transaction is created
+type TransactionPostedPayload struct {
+	TransactionID     int64   `json:"transaction_id"`
+	TransactionNumber string  `json:"transaction_number"`
+	TransactionType   string  `json:"transaction_type"`
+	OutletID          int64   `json:"outlet_id"`
+	CustomerID        *int64  `json:"customer_id"`
+	ServiceJobID      *int64  `json:"service_job_id"`
+	SubtotalAmount    float64 `json:"subtotal_amount"`
+	DiscountAmount    float64 `json:"discount_amount"`
+	TaxAmount         float64 `json:"tax_amount"`
+	TotalAmount       float64 `json:"total_amount"`
+	UserID            int64   `json:"user_id"`
+}
+
+// TransactionVoidedPayload is recorded when a sales transaction is voided
+type TransactionVoidedPayload struct {
+	TransactionID     int64  `json:"transaction_id"`
+	TransactionNumber string `json:"transaction_number"`
+	TransactionType   string `json:"transaction_type"`
+	OutletID          int64  `json:"outlet_id"`
+	CustomerID        *int64 `json:"customer_id"`
+	TotalAmount       string `json:"total_amount"`
+	RefundedAmount    string `json:"refunded_amount"`
+	CreditRestored    string `json:"credit_restored"`
+	Reason            string `json:"reason"`
+	VoidedBy          int64  `json:"voided_by"`
+}
+
+// PaymentReceivedPayload is recorded when a payment is taken against a transaction
+type PaymentReceivedPayload struct {
+	PaymentID         int64   `json:"payment_id"`
+	PaymentNumber     string  `json:"payment_number"`
+	PaymentMethodID   int64   `json:"payment_method_id"`
+	TransactionID     int64   `json:"transaction_id"`
+	TransactionNumber string  `json:"transaction_number"`
+	OutletID          int64   `json:"outlet_id"`
+	CustomerID        *int64  `json:"customer_id"`
+	ServiceJobID      *int64  `json:"service_job_id"`
+	Amount            float64 `json:"amount"`
+	Remaining         float64 `json:"remaining"`
+	PaymentStatus     string  `json:"payment_status"`
+	CheckoutID        *int64  `json:"checkout_id,omitempty"` // set when the payment is one tender of a checkout
+}
+
+// PaymentReversedPayload is recorded when a payment taken by mistake is
+// deleted
+type PaymentReversedPayload struct {
+	PaymentID         int64  `json:"payment_id"`
+	PaymentNumber     string `json:"payment_number"`
+	TransactionID     int64  `json:"transaction_id"`
+	TransactionNumber string `json:"transaction_number"`
+	OutletID          int64  `json:"outlet_id"`
+	Amount            string `json:"amount"`
+	PaymentStatus     string `json:"payment_status"`
+	ReversedBy        int64  `json:"reversed_by"`
+}
+
+// SalesReturnedPayload is recorded when sold items are returned
+type SalesReturnedPayload struct {
+	ReturnID          int64  `json:"return_id"`
+	ReturnNumber      string `json:"return_number"`
+	TransactionID     int64  `json:"transaction_id"`
+	TransactionNumber string `json:"transaction_number"`
+	OutletID          int64  `json:"outlet_id"`
+	CustomerID        *int64 `json:"customer_id"`
+	TotalAmount       string `json:"total_amount"`
+	Restock           bool   `json:"restock"`
+	Settlement        string `json:"settlement"`
+	PaymentStatus     string `json:"payment_status"`
+}
+
+// CheckoutCompletedPayload is recorded when a transaction is settled with
+// several tenders at once. Each tender is also recorded as a PaymentReceived
+// event.
+type CheckoutCompletedPayload struct {
+	CheckoutID        int64                   `json:"checkout_id"`
+	CheckoutNumber    string                  `json:"checkout_number"`
+	TransactionID     int64                   `json:"transaction_id"`
+	TransactionNumber string                  `json:"transaction_number"`
+	OutletID          int64                   `json:"outlet_id"`
+	CustomerID        *int64                  `json:"customer_id"`
+	ServiceJobID      *int64                  `json:"service_job_id"`
+	TenderedAmount    string                  `json:"tendered_amount"`
+	AppliedAmount     string                  `json:"applied_amount"`
+	ChangeAmount      string                  `json:"change_amount"`
+	Remaining         string                  `json:"remaining"`
+	PaymentStatus     string                  `json:"payment_status"`
+	Tenders           []CheckoutTenderPayload `json:"tenders"`
+}
+
+// CheckoutTenderPayload is one tender of a completed checkout
+type CheckoutTenderPayload struct {
+	PaymentID         int64  `json:"payment_id"`
+	PaymentNumber     string `json:"payment_number"`
+	PaymentMethodID   int64  `json:"payment_method_id"`
+	PaymentMethodName string `json:"payment_method_name"`
+	TenderedAmount    string `json:"tendered_amount"`
+	AppliedAmount     string `json:"applied_amount"`
+	ChangeAmount      string `json:"change_amount"`
+}
+
+// RefundIssuedPayload is recorded when money is paid back to a customer
+type RefundIssuedPayload struct {
+	RefundID          int64  `json:"refund_id"`
+	RefundNumber      string `json:"refund_number"`
+	TransactionID     int64  `json:"transaction_id"`
+	TransactionNumber string `json:"transaction_number"`
+	PaymentID         *int64 `json:"payment_id"`
+	ReturnID          *int64 `json:"return_id"`
+	OutletID          int64  `json:"outlet_id"`
+	CustomerID        *int64 `json:"customer_id"`
+	PaymentMethodID   int64  `json:"payment_method_id"`
+	Amount            string `json:"amount"`
+	Reason            string `json:"reason"`
+	PaymentStatus     string `json:"payment_status"`
+}
+
+// CreditNoteIssuedPayload is recorded when a customer is given store credit
+type CreditNoteIssuedPayload struct {
+	CreditNoteID     int64  `json:"credit_note_id"`
+	CreditNoteNumber string `json:"credit_note_number"`
+	CustomerID       int64  `json:"customer_id"`
+	OutletID         int64  `json:"outlet_id"`
+	TransactionID    int64  `json:"transaction_id"`
+	ReturnID         *int64 `json:"return_id"`
+	Amount           string `json:"amount"`
+}
+
+// CreditNoteAppliedPayload is recorded when store credit pays for a
+// transaction
+type CreditNoteAppliedPayload struct {
+	ApplicationID     int64  `json:"application_id"`
+	CreditNoteID      int64  `json:"credit_note_id"`
+	CreditNoteNumber  string `json:"credit_note_number"`
+	CustomerID        int64  `json:"customer_id"`
+	TransactionID     int64  `json:"transaction_id"`
+	TransactionNumber string `json:"transaction_number"`
+	OutletID          int64  `json:"outlet_id"`
+	Amount            string `json:"amount"`
+	RemainingCredit   string `json:"remaining_credit"`
+	PaymentStatus     string `json:"payment_status"`
+}
+
+// StoredValuePostedPayload is recorded for every top-up, redemption,
+// reversal and expiry of a deposit or voucher. Amount is signed.
+type StoredValuePostedPayload struct {
+	EntryID       int64   `json:"entry_id"`
+	AccountID     int64   `json:"account_id"`
+	AccountType   string  `json:"account_type"`
+	Code          *string `json:"code"`
+	CustomerID    *int64  `json:"customer_id"`
+	EntryType     string  `json:"entry_type"`
+	OutletID      int64   `json:"outlet_id"`
+	TransactionID *int64  `json:"transaction_id"`
+	Amount        string  `json:"amount"`
+	Balance       string  `json:"balance"`
+}
+
+// VehicleSoldPayload is recorded when a vehicle from inventory is sold
+type VehicleSoldPayload struct {
+	SaleID           int64  `json:"sale_id"`
+	InventoryID      int64  `json:"inventory_id"`
+	CustomerID       int64  `json:"customer_id"`
+	SalesPersonID    int64  `json:"sales_person_id"`
+	OutletID         int64  `json:"outlet_id"`
+	SellingPrice     string `json:"selling_price"`
+	CommissionRate   string `json:"commission_rate"`
+	CommissionAmount string `json:"commission_amount"`
+	PaymentType      string `json:"payment_type"`
+}
+
+// VehiclePurchasedPayload is recorded when a vehicle is bought into
+// trading inventory
+type VehiclePurchasedPayload struct {
+	PurchaseID    int64  `json:"purchase_id"`
+	InventoryID   int64  `json:"inventory_id"`
+	CustomerID    int64  `json:"customer_id"`
+	OutletID      int64  `json:"outlet_id"`
+	PlateNumber   string `json:"plate_number"`
+	PurchasePrice string `json:"purchase_price"`
+	PaymentMethod string `json:"payment_method"`
+}
+
+// StockLowPayload is recorded when a product's stock falls to or below its
+// minimum level
+type StockLowPayload struct {
+	ProductID     int64  `json:"product_id"`
+	ProductCode   string `json:"product_code"`
+	Name          string `json:"name"`
+	StockQuantity int    `json:"stock_quantity"`
+	MinStockLevel int    `json:"min_stock_level"`
+}
+
+// GoodsReceivedPayload is recorded when goods are received into stock
+type GoodsReceivedPayload struct {
+	ReceiptID       int64  `json:"receipt_id"`
+	ReceiptNumber   string `json:"receipt_number"`
+	OutletID        int64  `json:"outlet_id"`
+	SupplierID      *int64 `json:"supplier_id"`
+	PurchaseOrderID *int64 `json:"purchase_order_id"`
+	TotalCost       string `json:"total_cost"`
+}
+
+// Handler processes a single event. Handlers must be idempotent: an event is
+// delivered at least once and may be redelivered after a failure or crash.
+type Handler func(ctx context.Context, event Event) error
+
+type subscription struct {
+	name    string
+	handler Handler
+}
+
+// Bus routes events to named subscribers by event type
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions map[string][]subscription
+}
+
+// NewBus creates an empty event bus
+func NewBus() *Bus {
+	return &Bus{subscriptions: make(map[string][]subscription)}
+}
+
+// Subscribe registers handler under name for the given event types. The
+// name identifies the subscriber in delivery records, so it must stay stable
+// across releases.
+func (b *Bus) Subscribe(name string, handler Handler, types ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, eventType := range types {
+		b.subscriptions[eventType] = append(b.subscriptions[eventType], subscription{name: name, handler: handler})
+	}
+}
+
+// Subscribers returns the names of subscribers registered for an event type
+func (b *Bus) Subscribers(eventType string) []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	names := make([]string, 0, len(b.subscriptions[eventType]))
+	for _, sub := range b.subscriptions[eventType] {
+		names = append(names, sub.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Deliver hands event to every subscriber not listed in delivered and
+// returns the per-subscriber outcome. A panicking handler counts as failed.
+func (b *Bus) Deliver(ctx context.Context, event Event, delivered map[string]bool) map[string]error {
+	b.mu.RLock()
+	subs := append([]subscription(nil), b.subscriptions[event.Type]...)
+	b.mu.RUnlock()
+
+	results := make(map[string]error, len(subs))
+	for _, sub := range subs {
+		if delivered[sub.name] {
+			continue
+		}
+		results[sub.name] = invoke(ctx, sub.handler, event)
+	}
+	return results
+}
+
+func invoke(ctx context.Context, handler Handler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("subscriber panicked: %v", r)
+		}
+	}()
+	return handler(ctx, event)
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupApprovalRoutes sets up approval request, threshold and approver PIN
+// routes
+func (h *Handlers) setupApprovalRoutes(approvals fiber.Router) {
+	approvals.Get("/", h.requirePermission("approvals.read"), h.getApprovals)
+
+	// Thresholds per role
+	approvals.Get("/thresholds", h.requirePermission("approvals.read"), h.getApprovalThresholds)
+	approvals.Put("/thresholds/:role_id", h.requirePermission("approvals.manage"), h.setApprovalThreshold)
+	approvals.Delete("/thresholds/:role_id", h.requirePermission("approvals.manage"), h.deleteApprovalThreshold)
+
+	// Approver PIN
+	approvals.Put("/pin", h.requirePermission("approvals.approve"), h.setApprovalPIN)
+
+	// Decisions are made in the app with approvals.approve, or on the
+	// requester's terminal with an approver's PIN
+	approvals.Get("/:id", h.requirePermission("approvals.read"), h.getApprovalByID)
+	approvals.Post("/:id/approve", h.requirePermission("approvals.read"), h.approveRequest)
+	approvals.Post("/:id/reject", h.requirePermission("approvals.read"), h.rejectRequest)
+}
+
+// @Summary Get approval requests
+// @Description Get discounts and price overrides held for approval, newest first
+// @Tags Approvals
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param status query string false "pending, approved, rejected or cancelled"
+// @Param request_type query string false "discount or price_override"
+// @Param transaction_id query int false "Transaction ID"
+// @Param service_job_id query int false "Service job ID"
+// @Param requested_by query int false "Requesting user ID"
+// @Param date_from query string false "From date (YYYY-MM-DD)"
+// @Param date_to query string false "To date (YYYY-MM-DD)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.ApprovalRequest}
+// @Failure 500 {object} models.Response
+// @Router /approvals [get]
+func (h *Handlers) getApprovals(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.ApprovalFilter{
+		OutletID:      h.resolveOutletID(c, claims),
+		Status:        c.Query("status", ""),
+		RequestType:   c.Query("request_type", ""),
+		TransactionID: queryID(c, "transaction_id"),
+		ServiceJobID:  queryID(c, "service_job_id"),
+		RequestedBy:   queryID(c, "requested_by"),
+	}
+	filter.DateFrom, filter.DateTo = parseDateRange(c)
+
+	approvals, meta, err := h.services.Approval.List(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get approval requests",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Approval requests retrieved successfully",
+		Data:    approvals,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get approval request
+// @Description Get an approval request with its decision
+// @Tags Approvals
+// @Security Bearer
+// @Param id path int true "Approval request ID"
+// @Success 200 {object} models.Response{data=models.ApprovalRequest}
+// @Failure 404 {object} models.Response
+// @Router /approvals/{id} [get]
+func (h *Handlers) getApprovalByID(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid approval request ID",
+		})
+	}
+
+	approval, err := h.services.Approval.GetByID(int64(id))
+	if err != nil || !h.outletInScope(claims, approval.OutletID) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Approval request not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Approval request retrieved successfully",
+		Data:    approval,
+	})
+}
+
+// @Summary Approve request
+// @Description Approve a discount or price override. Approvers decide in the app; on the requester's terminal an approver enters their username and PIN instead. Nobody approves their own request.
+// @Tags Approvals
+// @Security Bearer
+// @Param id path int true "Approval request ID"
+// @Param request body models.ApprovalDecisionRequest false "Approver PIN and notes"
+// @Success 200 {object} models.Response{data=models.ApprovalRequest}
+// @Failure 400 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Router /approvals/{id}/approve [post]
+func (h *Handlers) approveRequest(c *fiber.Ctx) error {
+	return h.decideRequest(c, true)
+}
+
+// @Summary Reject request
+// @Description Reject a discount or price override. A rejected transaction has to be voided; a rejected service job line goes back to its list price.
+// @Tags Approvals
+// @Security Bearer
+// @Param id path int true "Approval request ID"
+// @Param request body models.ApprovalDecisionRequest false "Approver PIN and notes"
+// @Success 200 {object} models.Response{data=models.ApprovalRequest}
+// @Failure 400 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Router /approvals/{id}/reject [post]
+func (h *Handlers) rejectRequest(c *fiber.Ctx) error {
+	return h.decideRequest(c, false)
+}
+
+func (h *Handlers) decideRequest(c *fiber.Ctx, approve bool) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid approval request ID",
+		})
+	}
+
+	var req models.ApprovalDecisionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+				Success: false,
+				Message: "Invalid request body",
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+
+	var approval *models.ApprovalRequest
+	if req.Username != "" || req.PIN != "" {
+		approval, err = h.services.Approval.DecideWithPIN(int64(id), approve, &req, claims.UserID, outletID)
+	} else {
+		if !hasPermission(claims, "approvals.approve") {
+			return c.Status(fiber.StatusForbidden).JSON(models.Response{
+				Success: false,
+				Message: "An approver's username and PIN are required",
+			})
+		}
+		approval, err = h.services.Approval.Decide(int64(id), approve, req.Notes, claims.UserID, outletID)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to decide approval request",
+			Error:   err.Error(),
+		})
+	}
+
+	message := "Request rejected"
+	if approve {
+		message = "Request approved"
+	}
+	return c.JSON(models.Response{
+		Success: true,
+		Message: message,
+		Data:    approval,
+	})
+}
+
+// @Summary Get approval thresholds
+// @Description Get how far each limited role may discount or price below list without approval. Roles not listed are not limited.
+// @Tags Approvals
+// @Security Bearer
+// @Success 200 {object} models.Response{data=[]models.ApprovalThreshold}
+// @Failure 500 {object} models.Response
+// @Router /approvals/thresholds [get]
+func (h *Handlers) getApprovalThresholds(c *fiber.Ctx) error {
+	thresholds, err := h.services.Approval.ListThresholds()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get approval thresholds",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Approval thresholds retrieved successfully",
+		Data:    thresholds,
+	})
+}
+
+// @Summary Set approval threshold
+// @Description Set the largest discount, as a percentage of the invoice, and the largest cut below list price a role may give without approval
+// @Tags Approvals
+// @Security Bearer
+// @Param role_id path int true "Role ID"
+// @Param request body models.UpdateApprovalThresholdRequest true "Threshold"
+// @Success 200 {object} models.Response{data=models.ApprovalThreshold}
+// @Failure 400 {object} models.Response
+// @Router /approvals/thresholds/{role_id} [put]
+func (h *Handlers) setApprovalThreshold(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	roleID, err := c.ParamsInt("role_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid role ID",
+		})
+	}
+
+	var req models.UpdateApprovalThresholdRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	threshold, err := h.services.Approval.SetThreshold(int64(roleID), &req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to set approval threshold",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Approval threshold set successfully",
+		Data:    threshold,
+	})
+}
+
+// @Summary Remove approval threshold
+// @Description Stop limiting a role's discounts and price overrides
+// @Tags Approvals
+// @Security Bearer
+// @Param role_id path int true "Role ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /approvals/thresholds/{role_id} [delete]
+func (h *Handlers) deleteApprovalThreshold(c *fiber.Ctx) error {
+	roleID, err := c.ParamsInt("role_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid role ID",
+		})
+	}
+
+	if err := h.services.Approval.DeleteThreshold(int64(roleID)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Failed to remove approval threshold",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Approval threshold removed successfully",
+	})
+}
+
+// @Summary Set approval PIN
+// @Description Set the PIN the logged in approver enters on other users' terminals, confirmed with their password
+// @Tags Approvals
+// @Security Bearer
+// @Param request body models.SetApprovalPINRequest true "Password and PIN"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Router /approvals/pin [put]
+func (h *Handlers) setApprovalPIN(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.SetApprovalPINRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if err := h.services.Approval.SetPIN(claims.UserID, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to set approval PIN",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Approval PIN set successfully",
+	})
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"flutter-bengkel/internal/middleware"
 	"flutter-bengkel/internal/models"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
 )
 
 // setupServiceRoutes sets up service management routes
@@ -29,10 +31,12 @@ func (h *Handlers) setupProductRoutes(products fiber.Router) {
 // setupTransactionRoutes sets up transaction management routes
 func (h *Handlers) setupTransactionRoutes(transactions fiber.Router) {
 	transactions.Get("/", h.requirePermission("transactions.read"), h.getTransactions)
+	transactions.Get("/exceptions", h.requirePermission("transactions.exceptions"), h.getExceptionReport)
 	transactions.Get("/:id", h.requirePermission("transactions.read"), h.getTransactionByID)
+	transactions.Get("/:id/balance", h.requirePermission("transactions.read"), h.getTransactionBalance)
 	transactions.Post("/", h.requirePermission("transactions.create"), h.createTransaction)
 	transactions.Put("/:id", h.requirePermission("transactions.update"), h.updateTransaction)
-	transactions.Delete("/:id", h.requirePermission("transactions.delete"), h.deleteTransaction)
+	transactions.Post("/:id/void", h.requirePermission("transactions.void"), h.voidTransaction)
 }
 
 // setupPaymentRoutes sets up payment management routes
@@ -107,6 +111,14 @@ func (h *Handlers) getServiceByID(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) createService(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
 	var req models.Service
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
@@ -116,7 +128,7 @@ func (h *Handlers) createService(c *fiber.Ctx) error {
 		})
 	}
 
-	service, err := h.services.Service.Create(&req)
+	service, err := h.services.Service.Create(&req, claims.UserID)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
 			Success: false,
@@ -133,6 +145,14 @@ func (h *Handlers) createService(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) updateService(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
 	id, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
@@ -150,7 +170,7 @@ func (h *Handlers) updateService(c *fiber.Ctx) error {
 		})
 	}
 
-	service, err := h.services.Service.Update(int64(id), &req)
+	service, err := h.services.Service.Update(int64(id), &req, claims.UserID)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
 			Success: false,
@@ -250,6 +270,14 @@ func (h *Handlers) getProductByID(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) createProduct(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
 	var req models.Product
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
@@ -259,7 +287,7 @@ func (h *Handlers) createProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	product, err := h.services.Product.Create(&req)
+	product, err := h.services.Product.Create(&req, claims.UserID)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
 			Success: false,
@@ -276,6 +304,14 @@ func (h *Handlers) createProduct(c *fiber.Ctx) error {
 }
 
 func (h *Handlers) updateProduct(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
 	id, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
@@ -293,7 +329,7 @@ func (h *Handlers) updateProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	product, err := h.services.Product.Update(int64(id), &req)
+	product, err := h.services.Product.Update(int64(id), &req, claims.UserID)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
 			Success: false,
@@ -342,8 +378,9 @@ func (h *Handlers) updateProductStock(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		Quantity  int    `json:"quantity"`
-		Operation string `json:"operation"` // "add" or "subtract"
+		Quantity  int              `json:"quantity"`
+		Operation string           `json:"operation"` // "add" or "subtract"
+		UnitCost  *decimal.Decimal `json:"unit_cost"` // what one unit added cost
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
@@ -353,7 +390,7 @@ func (h *Handlers) updateProductStock(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.services.Product.UpdateStock(int64(id), req.Quantity, req.Operation); err != nil {
+	if err := h.services.Product.UpdateStock(int64(id), req.Quantity, req.Operation, req.UnitCost); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
 			Success: false,
 			Message: "Failed to update product stock",
@@ -411,26 +448,124 @@ func (h *Handlers) updateTransaction(c *fiber.Ctx) error {
 	return c.JSON(models.Response{Success: true, Message: "Update transaction endpoint"})
 }
 
-func (h *Handlers) deleteTransaction(c *fiber.Ctx) error {
-	// Implementation for transaction deletion
-	return c.JSON(models.Response{Success: true, Message: "Delete transaction endpoint"})
-}
-
-// Payment handlers (simplified)
+// Payment handlers
 func (h *Handlers) getPayments(c *fiber.Ctx) error {
-	return c.JSON(models.Response{Success: true, Message: "Payments endpoint"})
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	var transactionID *int64
+	if transactionIDParam := c.QueryInt("transaction_id", 0); transactionIDParam > 0 {
+		id := int64(transactionIDParam)
+		transactionID = &id
+	}
+
+	payments, meta, err := h.services.Payment.List(page, limit, transactionID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get payments",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Payments retrieved successfully",
+		Data:    payments,
+		Meta:    *meta,
+	})
 }
 
 func (h *Handlers) getPaymentByID(c *fiber.Ctx) error {
-	return c.JSON(models.Response{Success: true, Message: "Payment by ID endpoint"})
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid payment ID",
+		})
+	}
+
+	payment, err := h.services.Payment.GetByID(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Payment not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Payment retrieved successfully",
+		Data:    payment,
+	})
 }
 
 func (h *Handlers) createPayment(c *fiber.Ctx) error {
-	return c.JSON(models.Response{Success: true, Message: "Create payment endpoint"})
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.CreatePaymentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	// Recorded in the user's open cashier shift, if any
+	payment, err := h.services.Payment.Create(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create payment",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Payment created successfully",
+		Data:    payment,
+	})
 }
 
 func (h *Handlers) deletePayment(c *fiber.Ctx) error {
-	return c.JSON(models.Response{Success: true, Message: "Delete payment endpoint"})
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid payment ID",
+		})
+	}
+
+	// Reverses the payment and recalculates the transaction's payment status
+	if err := h.services.Payment.Delete(int64(id), claims.UserID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to delete payment",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Payment deleted successfully",
+	})
 }
 
 // Master data handlers
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"time"
+
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupCashRoutes sets up cash flow and end-of-day closing routes
+func (h *Handlers) setupCashRoutes(cashGroup fiber.Router) {
+	cashGroup.Get("/flows", h.requirePermission("cash.read"), h.getCashFlows)
+	cashGroup.Get("/summaries", h.requirePermission("cash.read"), h.getDailyCashSummaries)
+	cashGroup.Get("/days/:date", h.requirePermission("cash.read"), h.getCashDay)
+	cashGroup.Post("/closings", h.requirePermission("cash.close"), h.closeCashDay)
+}
+
+// setupPayableRoutes sets up supplier payable routes
+func (h *Handlers) setupPayableRoutes(payables fiber.Router) {
+	payables.Get("/", h.requirePermission("payables.read"), h.getPayables)
+	payables.Get("/:id", h.requirePermission("payables.read"), h.getPayable)
+	payables.Post("/:id/payments", h.requirePermission("payables.pay"), h.paySupplier)
+}
+
+// @Summary Get cash flows
+// @Description Get recorded cash flows, newest first. Non-admin users see their own outlet only.
+// @Tags Cash
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Filter by outlet (Super Admin only)"
+// @Param flow_type query string false "Filter by direction (inflow, outflow)"
+// @Param category query string false "Filter by category (sales, refund, supplier_payment, vehicle_purchase, vehicle_sale)"
+// @Param reference_type query string false "Filter by source document type"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.CashFlow}
+// @Failure 500 {object} models.Response
+// @Router /cash/flows [get]
+func (h *Handlers) getCashFlows(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.CashFlowFilter{
+		OutletID:      h.resolveOutletID(c, claims),
+		FlowType:      c.Query("flow_type", ""),
+		Category:      c.Query("category", ""),
+		ReferenceType: c.Query("reference_type", ""),
+	}
+	if value := c.Query("date_from"); value != "" {
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			filter.DateFrom = &date
+		}
+	}
+	if value := c.Query("date_to"); value != "" {
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			filter.DateTo = &date
+		}
+	}
+
+	flows, meta, err := h.services.Cash.ListCashFlows(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get cash flows",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Cash flows retrieved successfully",
+		Data:    flows,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get daily cash summaries
+// @Description Get stored daily cash summaries, newest first
+// @Tags Cash
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Filter by outlet (Super Admin only)"
+// @Param status query string false "Filter by status (open, closed)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.DailyCashSummary}
+// @Failure 500 {object} models.Response
+// @Router /cash/summaries [get]
+func (h *Handlers) getDailyCashSummaries(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	summaries, meta, err := h.services.Cash.ListDailySummaries(page, limit, h.resolveOutletID(c, claims), c.Query("status", ""))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get daily cash summaries",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Daily cash summaries retrieved successfully",
+		Data:    summaries,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get cash day
+// @Description Get an outlet's cash position for a day: the final summary once closed, otherwise the running totals
+// @Tags Cash
+// @Security Bearer
+// @Param date path string true "Date (YYYY-MM-DD)"
+// @Param outlet_id query int false "Outlet (Super Admin only)"
+// @Success 200 {object} models.Response{data=models.DailyCashSummary}
+// @Failure 400 {object} models.Response
+// @Router /cash/days/{date} [get]
+func (h *Handlers) getCashDay(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	date, err := time.Parse("2006-01-02", c.Params("date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid date format. Use YYYY-MM-DD",
+		})
+	}
+
+	outletID := h.resolveOutletID(c, claims)
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Outlet is required",
+		})
+	}
+
+	summary, err := h.services.Cash.GetDay(*outletID, date)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get cash day",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Cash day retrieved successfully",
+		Data:    summary,
+	})
+}
+
+// @Summary Close cash day
+// @Description Close an outlet's cash for a day. The day is locked and its closing balance carries forward as the next opening balance. Days must be closed in order.
+// @Tags Cash
+// @Security Bearer
+// @Param request body models.CloseCashDayRequest true "Day to close"
+// @Success 201 {object} models.Response{data=models.DailyCashSummary}
+// @Failure 400 {object} models.Response
+// @Router /cash/closings [post]
+func (h *Handlers) closeCashDay(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.CloseCashDayRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid date format. Use YYYY-MM-DD",
+		})
+	}
+
+	outletID := claims.OutletID
+	if claims.RoleID == 1 && req.OutletID != nil { // Super Admin
+		outletID = req.OutletID
+	}
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Outlet is required",
+		})
+	}
+
+	summary, err := h.services.Cash.CloseDay(*outletID, date, claims.UserID, req.Notes)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to close cash day",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Cash day closed successfully",
+		Data:    summary,
+	})
+}
+
+// @Summary Get payables
+// @Description Get supplier payables ordered by due date
+// @Tags Payables
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param supplier_id query int false "Filter by supplier"
+// @Param status query string false "Filter by status (outstanding, partial, paid, overdue)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.AccountsPayable}
+// @Failure 500 {object} models.Response
+// @Router /payables [get]
+func (h *Handlers) getPayables(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.AccountsPayableFilter{
+		Status: c.Query("status", ""),
+	}
+	if supplierID := c.QueryInt("supplier_id", 0); supplierID > 0 {
+		id := int64(supplierID)
+		filter.SupplierID = &id
+	}
+
+	payables, meta, err := h.services.Cash.ListPayables(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get payables",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Payables retrieved successfully",
+		Data:    payables,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get payable
+// @Description Get a supplier payable with its payments
+// @Tags Payables
+// @Security Bearer
+// @Param id path int true "Payable ID"
+// @Success 200 {object} models.Response{data=models.AccountsPayable}
+// @Failure 404 {object} models.Response
+// @Router /payables/{id} [get]
+func (h *Handlers) getPayable(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid payable ID",
+		})
+	}
+
+	payable, err := h.services.Cash.GetPayable(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Payable not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Payable retrieved successfully",
+		Data:    payable,
+	})
+}
+
+// @Summary Pay supplier
+// @Description Record a payment against a supplier payable. The payment is recorded as a cash outflow of the paying outlet.
+// @Tags Payables
+// @Security Bearer
+// @Param id path int true "Payable ID"
+// @Param request body models.CreatePayablePaymentRequest true "Payment"
+// @Success 201 {object} models.Response{data=models.PayablePayment}
+// @Failure 400 {object} models.Response
+// @Router /payables/{id}/payments [post]
+func (h *Handlers) paySupplier(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid payable ID",
+		})
+	}
+
+	var req models.CreatePayablePaymentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID := claims.OutletID
+	if claims.RoleID == 1 && req.OutletID != nil { // Super Admin
+		outletID = req.OutletID
+	}
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Outlet is required",
+		})
+	}
+
+	payment, err := h.services.Cash.PaySupplier(int64(id), &req, *outletID, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to pay supplier",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Supplier payment recorded successfully",
+		Data:    payment,
+	})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupCheckoutRoutes sets up split tender checkout routes
+func (h *Handlers) setupCheckoutRoutes(checkouts fiber.Router) {
+	checkouts.Get("/", h.requirePermission("transactions.read"), h.getCheckouts)
+	checkouts.Get("/:id", h.requirePermission("transactions.read"), h.getCheckoutByID)
+	checkouts.Post("/", h.requirePermission("transactions.create"), h.createCheckout)
+}
+
+// @Summary Get checkouts
+// @Description Get checkouts, newest first. Non-admin users see their own outlet only.
+// @Tags Payments
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Filter by outlet (Super Admin only)"
+// @Param transaction_id query int false "Filter by transaction"
+// @Param shift_id query int false "Filter by cashier shift"
+// @Param date_from query string false "Taken on or after (YYYY-MM-DD)"
+// @Param date_to query string false "Taken on or before (YYYY-MM-DD)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.Checkout}
+// @Failure 500 {object} models.Response
+// @Router /checkouts [get]
+func (h *Handlers) getCheckouts(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.CheckoutFilter{
+		OutletID:      h.resolveOutletID(c, claims),
+		TransactionID: queryID(c, "transaction_id"),
+		ShiftID:       queryID(c, "shift_id"),
+	}
+	filter.DateFrom, filter.DateTo = parseDateRange(c)
+
+	checkouts, meta, err := h.services.Payment.ListCheckouts(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get checkouts",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Checkouts retrieved successfully",
+		Data:    checkouts,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get checkout receipt
+// @Description Get a checkout as its receipt: every tender with the amount tendered, applied and given back as change, and the transaction's balance
+// @Tags Payments
+// @Security Bearer
+// @Param id path int true "Checkout ID"
+// @Success 200 {object} models.Response{data=models.Checkout}
+// @Failure 404 {object} models.Response
+// @Router /checkouts/{id} [get]
+func (h *Handlers) getCheckoutByID(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid checkout ID",
+		})
+	}
+
+	checkout, err := h.services.Payment.GetCheckout(int64(id))
+	if err != nil || !h.outletInScope(claims, checkout.OutletID) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Checkout not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Checkout retrieved successfully",
+		Data:    checkout,
+	})
+}
+
+// @Summary Create checkout
+// @Description Settle a transaction with one or more tenders (cash, card, transfer, e-wallet) in one call. Non-cash tenders are charged exactly and cannot exceed what is due; cash covers the rest and the excess is returned as change. Each tender is recorded as a payment in the cashier's open shift.
+// @Tags Payments
+// @Security Bearer
+// @Param request body models.CreateCheckoutRequest true "Checkout"
+// @Success 201 {object} models.Response{data=models.Checkout}
+// @Failure 400 {object} models.Response
+// @Router /checkouts [post]
+func (h *Handlers) createCheckout(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.CreateCheckoutRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if _, ok := h.transactionInScope(claims, req.TransactionID); !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Transaction not found",
+		})
+	}
+
+	checkout, err := h.services.Payment.Checkout(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create checkout",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Checkout created successfully",
+		Data:    checkout,
+	})
+}
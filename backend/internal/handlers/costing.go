@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupGoodsReceiptRoutes sets up goods receipt routes
+func (h *Handlers) setupGoodsReceiptRoutes(receipts fiber.Router) {
+	receipts.Get("/", h.requirePermission("goods_receipts.read"), h.getGoodsReceipts)
+	receipts.Get("/:id", h.requirePermission("goods_receipts.read"), h.getGoodsReceiptByID)
+	receipts.Post("/", h.requirePermission("goods_receipts.create"), h.createGoodsReceipt)
+}
+
+// setupMarginRoutes sets up gross margin report routes
+func (h *Handlers) setupMarginRoutes(margins fiber.Router) {
+	margins.Get("/transactions", h.requirePermission("margins.read"), h.getTransactionMargins)
+	margins.Get("/transactions/:id", h.requirePermission("margins.read"), h.getTransactionMargin)
+	margins.Get("/service-jobs/:id", h.requirePermission("margins.read"), h.getServiceJobMargin)
+	margins.Get("/products", h.requirePermission("margins.read"), h.getProductMargins)
+	margins.Get("/outlets", h.requirePermission("margins.read"), h.getOutletMargins)
+}
+
+// @Summary Get goods receipts
+// @Description Get goods received into stock, newest first
+// @Tags Goods Receipts
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param supplier_id query int false "Supplier ID"
+// @Param purchase_order_id query int false "Purchase order ID"
+// @Param product_id query int false "Receipts of a product"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.GoodsReceipt}
+// @Failure 500 {object} models.Response
+// @Router /goods-receipts [get]
+func (h *Handlers) getGoodsReceipts(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+	from, to := parseDateRange(c)
+
+	filter := &models.GoodsReceiptFilter{
+		OutletID:        h.resolveOutletID(c, claims),
+		SupplierID:      queryID(c, "supplier_id"),
+		PurchaseOrderID: queryID(c, "purchase_order_id"),
+		ProductID:       queryID(c, "product_id"),
+		DateFrom:        from,
+		DateTo:          to,
+	}
+
+	receipts, meta, err := h.services.Costing.ListReceipts(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get goods receipts",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Goods receipts retrieved successfully",
+		Data:    receipts,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get goods receipt
+// @Description Get a goods receipt with each product's stock and cost before and its weighted average cost after
+// @Tags Goods Receipts
+// @Security Bearer
+// @Param id path int true "Goods receipt ID"
+// @Success 200 {object} models.Response{data=models.GoodsReceipt}
+// @Failure 404 {object} models.Response
+// @Router /goods-receipts/{id} [get]
+func (h *Handlers) getGoodsReceiptByID(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid goods receipt ID",
+		})
+	}
+
+	scope, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusForbidden).JSON(models.Response{
+			Success: false,
+			Message: "Access denied",
+		})
+	}
+
+	receipt, err := h.services.Costing.GetReceipt(int64(id), scope)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Goods receipt not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Goods receipt retrieved successfully",
+		Data:    receipt,
+	})
+}
+
+// @Summary Receive goods
+// @Description Receive goods into the outlet's stock, against a purchase order or not. Each product's cost price moves to the weighted average of the stock on hand at its old cost and the goods received at their unit cost.
+// @Tags Goods Receipts
+// @Security Bearer
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param request body models.GoodsReceiptRequest true "Goods received"
+// @Success 201 {object} models.Response{data=models.GoodsReceipt}
+// @Failure 400 {object} models.Response
+// @Router /goods-receipts [post]
+func (h *Handlers) createGoodsReceipt(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.GoodsReceiptRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID := h.resolveOutletID(c, claims)
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Outlet is required",
+		})
+	}
+
+	receipt, err := h.services.Costing.CreateReceipt(&req, *outletID, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to receive goods",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Goods received successfully",
+		Data:    receipt,
+	})
+}
+
+// @Summary Get transaction margins
+// @Description Get the gross margin of each transaction over a period, newest first. Revenue is before tax and net of returns; cost is what each line's products cost when sold.
+// @Tags Margins
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param product_id query int false "Transactions selling a product"
+// @Param date_from query string false "Start date (YYYY-MM-DD), defaults to the first of the month"
+// @Param date_to query string false "End date (YYYY-MM-DD), defaults to the end of the month"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.DocumentMargin}
+// @Failure 400 {object} models.Response
+// @Router /margins/transactions [get]
+func (h *Handlers) getTransactionMargins(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	from, to, err := parseLedgerPeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.MarginFilter{
+		OutletID:  h.resolveOutletID(c, claims),
+		ProductID: queryID(c, "product_id"),
+		DateFrom:  from,
+		DateTo:    to,
+	}
+
+	margins, meta, err := h.services.Costing.ListTransactionMargins(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get transaction margins",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Transaction margins retrieved successfully",
+		Data:    margins,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get transaction margin
+// @Description Get a transaction's gross margin line by line
+// @Tags Margins
+// @Security Bearer
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} models.Response{data=models.DocumentMargin}
+// @Failure 404 {object} models.Response
+// @Router /margins/transactions/{id} [get]
+func (h *Handlers) getTransactionMargin(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid transaction ID",
+		})
+	}
+
+	scope, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusForbidden).JSON(models.Response{
+			Success: false,
+			Message: "Access denied",
+		})
+	}
+
+	margin, err := h.services.Costing.GetTransactionMargin(int64(id), scope)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Transaction not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Transaction margin retrieved successfully",
+		Data:    margin,
+	})
+}
+
+// @Summary Get service job margin
+// @Description Get a service job's gross margin line by line
+// @Tags Margins
+// @Security Bearer
+// @Param id path int true "Service Job ID"
+// @Success 200 {object} models.Response{data=models.DocumentMargin}
+// @Failure 404 {object} models.Response
+// @Router /margins/service-jobs/{id} [get]
+func (h *Handlers) getServiceJobMargin(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid service job ID",
+		})
+	}
+
+	scope, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusForbidden).JSON(models.Response{
+			Success: false,
+			Message: "Access denied",
+		})
+	}
+
+	margin, err := h.services.Costing.GetServiceJobMargin(int64(id), scope)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Service job not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Service job margin retrieved successfully",
+		Data:    margin,
+	})
+}
+
+// @Summary Get product margins
+// @Description Get what each product sold for over a period against what it cost, highest margin first
+// @Tags Margins
+// @Security Bearer
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param product_id query int false "Product ID"
+// @Param date_from query string false "Start date (YYYY-MM-DD), defaults to the first of the month"
+// @Param date_to query string false "End date (YYYY-MM-DD), defaults to the end of the month"
+// @Success 200 {object} models.Response{data=[]models.ProductMargin}
+// @Failure 400 {object} models.Response
+// @Router /margins/products [get]
+func (h *Handlers) getProductMargins(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	from, to, err := parseLedgerPeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	filter := &models.MarginFilter{
+		OutletID:  h.resolveOutletID(c, claims),
+		ProductID: queryID(c, "product_id"),
+		DateFrom:  from,
+		DateTo:    to,
+	}
+
+	margins, err := h.services.Costing.GetProductMargins(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get product margins",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Product margins retrieved successfully",
+		Data:    margins,
+	})
+}
+
+// @Summary Get outlet margins
+// @Description Get each outlet's sales over a period against what they cost
+// @Tags Margins
+// @Security Bearer
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param date_from query string false "Start date (YYYY-MM-DD), defaults to the first of the month"
+// @Param date_to query string false "End date (YYYY-MM-DD), defaults to the end of the month"
+// @Success 200 {object} models.Response{data=[]models.OutletMargin}
+// @Failure 400 {object} models.Response
+// @Router /margins/outlets [get]
+func (h *Handlers) getOutletMargins(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	from, to, err := parseLedgerPeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	filter := &models.MarginFilter{
+		OutletID: h.resolveOutletID(c, claims),
+		DateFrom: from,
+		DateTo:   to,
+	}
+
+	margins, err := h.services.Costing.GetOutletMargins(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get outlet margins",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Outlet margins retrieved successfully",
+		Data:    margins,
+	})
+}
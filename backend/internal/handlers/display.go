@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"strings"
+
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/realtime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// displayBoardEvent is the stream event name carrying a full board snapshot
+const displayBoardEvent = "board"
+
+// setupPublicDisplayRoutes sets up unauthenticated display board routes
+func (h *Handlers) setupPublicDisplayRoutes(display fiber.Router) {
+	display.Get("/:token/queue", h.getDisplayBoard)
+	display.Get("/:token/stream", h.streamDisplayBoard)
+}
+
+// setupDisplayTokenRoutes sets up display token management routes
+func (h *Handlers) setupDisplayTokenRoutes(displays fiber.Router) {
+	displays.Get("/", h.requirePermission("displays.read"), h.getDisplayTokens)
+	displays.Post("/", h.requirePermission("displays.create"), h.createDisplayToken)
+	displays.Delete("/:id", h.requirePermission("displays.delete"), h.revokeDisplayToken)
+}
+
+// @Summary Get display board
+// @Description Get the now serving and upcoming queue numbers for the outlet bound to a display token, with masked plate numbers
+// @Tags Display
+// @Param token path string true "Display token"
+// @Success 200 {object} models.Response{data=models.DisplayBoard}
+// @Failure 401 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /public/display/{token}/queue [get]
+func (h *Handlers) getDisplayBoard(c *fiber.Ctx) error {
+	display, err := h.services.Display.Authenticate(c.Params("token"))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Invalid display token",
+		})
+	}
+
+	board, err := h.services.Display.GetBoard(display)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get display board",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Display board retrieved successfully",
+		Data:    board,
+	})
+}
+
+// @Summary Stream display board
+// @Description Server-Sent Events stream that pushes a fresh board snapshot whenever the outlet queue changes. The stream ends once the token is revoked.
+// @Tags Display
+// @Produce text/event-stream
+// @Param token path string true "Display token"
+// @Success 200 {string} string "event stream"
+// @Failure 401 {object} models.Response
+// @Router /public/display/{token}/stream [get]
+func (h *Handlers) streamDisplayBoard(c *fiber.Ctx) error {
+	display, err := h.services.Display.Authenticate(c.Params("token"))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Invalid display token",
+		})
+	}
+
+	board, err := h.services.Display.GetBoard(display)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get display board",
+			Error:   err.Error(),
+		})
+	}
+
+	// Only queue snapshots are forwarded, and only after masking
+	sub := h.services.Realtime.Subscribe(&display.OutletID, []string{"service_jobs.read"})
+	initial := []sseMessage{{Event: displayBoardEvent, Data: board}}
+
+	// A revoked token ends the stream at the next heartbeat. The stream
+	// outlives the request, so the token is copied out of it.
+	token := strings.Clone(c.Params("token"))
+	alive := func() bool {
+		_, err := h.services.Display.Authenticate(token)
+		return err == nil
+	}
+
+	h.writeEventStream(c, sub, initial, alive, func(event realtime.Event) (sseMessage, bool) {
+		if event.Type != realtime.EventQueueUpdated {
+			return sseMessage{}, false
+		}
+
+		queue, ok := event.Data.(*models.OutletQueue)
+		if !ok {
+			return sseMessage{}, false
+		}
+
+		return sseMessage{
+			ID:    event.ID,
+			Event: displayBoardEvent,
+			Data:  h.services.Display.BuildBoard(display, queue),
+		}, true
+	})
+
+	return nil
+}
+
+// @Summary Get display tokens
+// @Description Get waiting room display tokens for the user's outlet (all outlets for Super Admin)
+// @Tags Display
+// @Security Bearer
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Success 200 {object} models.Response{data=[]models.OutletDisplayToken}
+// @Failure 500 {object} models.Response
+// @Router /display-tokens [get]
+func (h *Handlers) getDisplayTokens(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	outletID := h.resolveOutletID(c, claims)
+	if outletID == nil && claims.RoleID != 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+
+	tokens, err := h.services.Display.ListTokens(outletID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get display tokens",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Display tokens retrieved successfully",
+		Data:    tokens,
+	})
+}
+
+// @Summary Create display token
+// @Description Create a token that lets a waiting room display read an outlet's queue without logging in. Only a hash of the token is stored, so this is the only response that includes it.
+// @Tags Display
+// @Security Bearer
+// @Param request body models.CreateDisplayTokenRequest true "Display token data"
+// @Success 201 {object} models.Response{data=models.OutletDisplayToken}
+// @Failure 400 {object} models.Response
+// @Router /display-tokens [post]
+func (h *Handlers) createDisplayToken(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.CreateDisplayTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID := claims.OutletID
+	if claims.RoleID == 1 && req.OutletID != nil { // Super Admin
+		outletID = req.OutletID
+	}
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Outlet ID is required",
+		})
+	}
+
+	display, err := h.services.Display.CreateToken(&req, *outletID, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create display token",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Display token created successfully",
+		Data:    display,
+	})
+}
+
+// @Summary Revoke display token
+// @Description Revoke a display token so the screen using it stops receiving the queue
+// @Tags Display
+// @Security Bearer
+// @Param id path int true "Display token ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /display-tokens/{id} [delete]
+func (h *Handlers) revokeDisplayToken(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid display token ID",
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+
+	if err := h.services.Display.RevokeToken(int64(id), outletID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Failed to revoke display token",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Display token revoked successfully",
+	})
+}
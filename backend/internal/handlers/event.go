@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupDomainEventRoutes sets up domain event outbox routes
+func (h *Handlers) setupDomainEventRoutes(domainEvents fiber.Router) {
+	domainEvents.Get("/", h.requirePermission("events.read"), h.getDomainEvents)
+	domainEvents.Get("/:id", h.requirePermission("events.read"), h.getDomainEvent)
+	domainEvents.Post("/:id/retry", h.requirePermission("events.update"), h.retryDomainEvent)
+}
+
+// @Summary Get domain events
+// @Description Get the domain event outbox with dispatch status
+// @Tags Domain Events
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param event_type query string false "Filter by event type (service_job.status_changed, transaction.posted, payment.received, vehicle.sold, stock.low)"
+// @Param status query string false "Filter by status (pending, processing, processed, failed)"
+// @Param aggregate_type query string false "Filter by aggregate type (service_job, transaction, payment, vehicle_sale, product)"
+// @Param aggregate_id query int false "Filter by aggregate ID"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.DomainEvent}
+// @Failure 500 {object} models.Response
+// @Router /domain-events [get]
+func (h *Handlers) getDomainEvents(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.DomainEventFilter{
+		OutletID:      h.resolveOutletID(c, claims),
+		EventType:     c.Query("event_type", ""),
+		Status:        c.Query("status", ""),
+		AggregateType: c.Query("aggregate_type", ""),
+	}
+	if aggregateID := c.QueryInt("aggregate_id", 0); aggregateID > 0 {
+		id := int64(aggregateID)
+		filter.AggregateID = &id
+	}
+
+	domainEvents, meta, err := h.services.Event.List(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get domain events",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Domain events retrieved successfully",
+		Data:    domainEvents,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get domain event
+// @Description Get a domain event with the subscribers that have handled it
+// @Tags Domain Events
+// @Security Bearer
+// @Param id path int true "Event ID"
+// @Success 200 {object} models.Response{data=models.DomainEvent}
+// @Failure 404 {object} models.Response
+// @Router /domain-events/{id} [get]
+func (h *Handlers) getDomainEvent(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid event ID",
+		})
+	}
+
+	event, err := h.services.Event.GetByID(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Domain event not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Domain event retrieved successfully",
+		Data:    event,
+	})
+}
+
+// @Summary Retry domain event
+// @Description Queue a failed domain event for another dispatch to the subscribers that have not handled it
+// @Tags Domain Events
+// @Security Bearer
+// @Param id path int true "Event ID"
+// @Success 200 {object} models.Response{data=models.DomainEvent}
+// @Failure 400 {object} models.Response
+// @Router /domain-events/{id}/retry [post]
+func (h *Handlers) retryDomainEvent(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid event ID",
+		})
+	}
+
+	event, err := h.services.Event.Retry(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to retry domain event",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Domain event queued for retry",
+		Data:    event,
+	})
+}
@@ -27,6 +27,14 @@ func (h *Handlers) SetupRoutes(api fiber.Router) {
 	auth := api.Group("/auth")
 	h.setupAuthRoutes(auth)
 
+	// Public display board routes (display token, no login required)
+	publicDisplay := api.Group("/public/display")
+	h.setupPublicDisplayRoutes(publicDisplay)
+
+	// Public job tracking routes (tracking token, no login required)
+	publicTrack := api.Group("/public/track")
+	h.setupPublicTrackingRoutes(publicTrack)
+
 	// Protected routes
 	protected := api.Use(h.jwtMiddleware())
 
@@ -69,4 +77,104 @@ func (h *Handlers) SetupRoutes(api fiber.Router) {
 	// Master data routes
 	masterData := protected.Group("/master-data")
 	h.setupMasterDataRoutes(masterData)
+
+	// Real-time event stream routes
+	events := protected.Group("/events")
+	h.setupRealtimeRoutes(events)
+
+	// Display token management routes
+	displayTokens := protected.Group("/display-tokens")
+	h.setupDisplayTokenRoutes(displayTokens)
+
+	// Vehicle reminder routes
+	reminders := protected.Group("/reminders")
+	h.setupReminderRoutes(reminders)
+
+	// Service interval routes
+	serviceIntervals := protected.Group("/service-intervals")
+	h.setupServiceIntervalRoutes(serviceIntervals)
+
+	// Notification routes
+	notifications := protected.Group("/notifications")
+	h.setupNotificationRoutes(notifications)
+
+	// Domain event outbox routes
+	domainEvents := protected.Group("/domain-events")
+	h.setupDomainEventRoutes(domainEvents)
+
+	// Webhook routes
+	webhooks := protected.Group("/webhooks")
+	h.setupWebhookRoutes(webhooks)
+
+	// Background job scheduler routes
+	schedulerGroup := protected.Group("/scheduler")
+	h.setupSchedulerRoutes(schedulerGroup)
+
+	// General ledger routes
+	ledgerGroup := protected.Group("/ledger")
+	h.setupLedgerRoutes(ledgerGroup)
+
+	// Cash flow and closing routes
+	cashGroup := protected.Group("/cash")
+	h.setupCashRoutes(cashGroup)
+
+	// Supplier payable routes
+	payables := protected.Group("/payables")
+	h.setupPayableRoutes(payables)
+
+	// Cashier shift routes
+	shifts := protected.Group("/shifts")
+	h.setupShiftRoutes(shifts)
+
+	// Return, refund and credit note routes
+	returns := protected.Group("/returns")
+	h.setupReturnRoutes(returns)
+	refunds := protected.Group("/refunds")
+	h.setupRefundRoutes(refunds)
+	creditNotes := protected.Group("/credit-notes")
+	h.setupCreditNoteRoutes(creditNotes)
+
+	// Split tender checkout routes
+	checkouts := protected.Group("/checkouts")
+	h.setupCheckoutRoutes(checkouts)
+
+	// Tax routes
+	taxes := protected.Group("/taxes")
+	h.setupTaxRoutes(taxes)
+
+	// Promotion routes
+	promotions := protected.Group("/promotions")
+	h.setupPromotionRoutes(promotions)
+
+	// Approval routes
+	approvals := protected.Group("/approvals")
+	h.setupApprovalRoutes(approvals)
+
+	// Loyalty routes
+	loyalty := protected.Group("/loyalty")
+	h.setupLoyaltyRoutes(loyalty)
+
+	// Membership routes
+	memberships := protected.Group("/memberships")
+	h.setupMembershipRoutes(memberships)
+
+	// Stored value routes
+	storedValue := protected.Group("/stored-value")
+	h.setupStoredValueRoutes(storedValue)
+
+	// Service package routes
+	servicePackages := protected.Group("/service-packages")
+	h.setupServicePackageRoutes(servicePackages)
+
+	// Price list routes
+	priceLists := protected.Group("/price-lists")
+	h.setupPriceListRoutes(priceLists)
+
+	// Goods receipt routes
+	goodsReceipts := protected.Group("/goods-receipts")
+	h.setupGoodsReceiptRoutes(goodsReceipts)
+
+	// Margin routes
+	margins := protected.Group("/margins")
+	h.setupMarginRoutes(margins)
 }
\ No newline at end of file
@@ -0,0 +1,477 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupLedgerRoutes sets up general ledger routes
+func (h *Handlers) setupLedgerRoutes(ledgerGroup fiber.Router) {
+	// Chart of accounts
+	ledgerGroup.Get("/accounts", h.requirePermission("ledger.read"), h.getGLAccounts)
+	ledgerGroup.Post("/accounts", h.requirePermission("ledger.create"), h.createGLAccount)
+	ledgerGroup.Put("/accounts/:id", h.requirePermission("ledger.update"), h.updateGLAccount)
+	ledgerGroup.Delete("/accounts/:id", h.requirePermission("ledger.delete"), h.deleteGLAccount)
+
+	// Journal entries
+	ledgerGroup.Get("/journals", h.requirePermission("ledger.read"), h.getJournalEntries)
+	ledgerGroup.Get("/journals/:id", h.requirePermission("ledger.read"), h.getJournalEntry)
+	ledgerGroup.Post("/journals", h.requirePermission("ledger.create"), h.createJournalEntry)
+
+	// Reports
+	ledgerGroup.Get("/reports/trial-balance", h.requirePermission("ledger.read"), h.getTrialBalance)
+	ledgerGroup.Get("/reports/general-ledger", h.requirePermission("ledger.read"), h.getGeneralLedger)
+
+	ledgerGroup.Post("/backfill", h.requirePermission("ledger.update"), h.backfillLedger)
+}
+
+// parseLedgerPeriod reads date_from and date_to, defaulting to the current month
+func parseLedgerPeriod(c *fiber.Ctx) (from, to time.Time, err error) {
+	now := time.Now()
+	from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to = from.AddDate(0, 1, -1)
+
+	if value := c.Query("date_from"); value != "" {
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			return from, to, fmt.Errorf("invalid date_from, expected YYYY-MM-DD")
+		}
+	}
+	if value := c.Query("date_to"); value != "" {
+		if to, err = time.Parse("2006-01-02", value); err != nil {
+			return from, to, fmt.Errorf("invalid date_to, expected YYYY-MM-DD")
+		}
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("date_to cannot be before date_from")
+	}
+
+	return from, to, nil
+}
+
+// @Summary Get chart of accounts
+// @Description Get the chart of accounts ordered by code
+// @Tags Ledger
+// @Security Bearer
+// @Param include_inactive query bool false "Include inactive accounts"
+// @Success 200 {object} models.Response{data=[]models.GLAccount}
+// @Failure 500 {object} models.Response
+// @Router /ledger/accounts [get]
+func (h *Handlers) getGLAccounts(c *fiber.Ctx) error {
+	accounts, err := h.services.Ledger.ListAccounts(c.QueryBool("include_inactive", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get accounts",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Accounts retrieved successfully",
+		Data:    accounts,
+	})
+}
+
+// @Summary Create account
+// @Description Add an account to the chart of accounts. The normal balance defaults from the account type.
+// @Tags Ledger
+// @Security Bearer
+// @Param request body models.CreateGLAccountRequest true "Account"
+// @Success 201 {object} models.Response{data=models.GLAccount}
+// @Failure 400 {object} models.Response
+// @Router /ledger/accounts [post]
+func (h *Handlers) createGLAccount(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.CreateGLAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	account, err := h.services.Ledger.CreateAccount(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create account",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Account created successfully",
+		Data:    account,
+	})
+}
+
+// @Summary Update account
+// @Description Update an account's name, parent, description or active flag. System accounts cannot be deactivated.
+// @Tags Ledger
+// @Security Bearer
+// @Param id path int true "Account ID"
+// @Param request body models.UpdateGLAccountRequest true "Account changes"
+// @Success 200 {object} models.Response{data=models.GLAccount}
+// @Failure 400 {object} models.Response
+// @Router /ledger/accounts/{id} [put]
+func (h *Handlers) updateGLAccount(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid account ID",
+		})
+	}
+
+	var req models.UpdateGLAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	account, err := h.services.Ledger.UpdateAccount(int64(id), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update account",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Account updated successfully",
+		Data:    account,
+	})
+}
+
+// @Summary Delete account
+// @Description Delete an account. System accounts and accounts with postings or sub-accounts cannot be deleted.
+// @Tags Ledger
+// @Security Bearer
+// @Param id path int true "Account ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Router /ledger/accounts/{id} [delete]
+func (h *Handlers) deleteGLAccount(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid account ID",
+		})
+	}
+
+	if err := h.services.Ledger.DeleteAccount(int64(id)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to delete account",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Account deleted successfully",
+	})
+}
+
+// @Summary Get journal entries
+// @Description Get journal entries, newest first. Non-admin users see their own outlet only.
+// @Tags Ledger
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Filter by outlet (Super Admin only)"
+// @Param source_type query string false "Filter by source (manual, transaction, payment, vehicle_purchase, vehicle_sale)"
+// @Param account_id query int false "Only entries posting to this account"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.JournalEntry}
+// @Failure 500 {object} models.Response
+// @Router /ledger/journals [get]
+func (h *Handlers) getJournalEntries(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.JournalEntryFilter{
+		OutletID:   h.resolveOutletID(c, claims),
+		SourceType: c.Query("source_type", ""),
+	}
+	if accountID := c.QueryInt("account_id", 0); accountID > 0 {
+		id := int64(accountID)
+		filter.AccountID = &id
+	}
+	if value := c.Query("date_from"); value != "" {
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			filter.DateFrom = &date
+		}
+	}
+	if value := c.Query("date_to"); value != "" {
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			filter.DateTo = &date
+		}
+	}
+
+	entries, meta, err := h.services.Ledger.ListEntries(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get journal entries",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Journal entries retrieved successfully",
+		Data:    entries,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get journal entry
+// @Description Get a journal entry with its lines
+// @Tags Ledger
+// @Security Bearer
+// @Param id path int true "Entry ID"
+// @Success 200 {object} models.Response{data=models.JournalEntry}
+// @Failure 404 {object} models.Response
+// @Router /ledger/journals/{id} [get]
+func (h *Handlers) getJournalEntry(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid entry ID",
+		})
+	}
+
+	entry, err := h.services.Ledger.GetEntry(int64(id))
+	if err == nil {
+		if scope, ok := h.outletScope(claims); !ok || (scope != nil && *scope != entry.OutletID) {
+			err = fmt.Errorf("journal entry not found")
+		}
+	}
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Journal entry not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Journal entry retrieved successfully",
+		Data:    entry,
+	})
+}
+
+// @Summary Create manual journal entry
+// @Description Post a manual journal entry such as an opening balance or adjustment. Debits and credits must balance.
+// @Tags Ledger
+// @Security Bearer
+// @Param request body models.CreateJournalEntryRequest true "Journal entry"
+// @Success 201 {object} models.Response{data=models.JournalEntry}
+// @Failure 400 {object} models.Response
+// @Router /ledger/journals [post]
+func (h *Handlers) createJournalEntry(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.CreateJournalEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID := claims.OutletID
+	if claims.RoleID == 1 && req.OutletID != nil { // Super Admin
+		outletID = req.OutletID
+	}
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Outlet is required",
+		})
+	}
+
+	entry, err := h.services.Ledger.CreateEntry(&req, *outletID, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create journal entry",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Journal entry created successfully",
+		Data:    entry,
+	})
+}
+
+// @Summary Get trial balance
+// @Description Get each account's opening balance, movements and closing debit or credit balance for a period. Defaults to the current month.
+// @Tags Ledger
+// @Security Bearer
+// @Param outlet_id query int false "Outlet (Super Admin only, all outlets when omitted)"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} models.Response{data=models.TrialBalance}
+// @Failure 400 {object} models.Response
+// @Router /ledger/reports/trial-balance [get]
+func (h *Handlers) getTrialBalance(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	from, to, err := parseLedgerPeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid period",
+			Error:   err.Error(),
+		})
+	}
+
+	report, err := h.services.Ledger.TrialBalance(h.resolveOutletID(c, claims), from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get trial balance",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Trial balance retrieved successfully",
+		Data:    report,
+	})
+}
+
+// @Summary Get general ledger
+// @Description Get the postings to an account over a period with a running balance. Defaults to the current month.
+// @Tags Ledger
+// @Security Bearer
+// @Param account_id query int true "Account ID"
+// @Param outlet_id query int false "Outlet (Super Admin only, all outlets when omitted)"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} models.Response{data=models.GeneralLedger}
+// @Failure 400 {object} models.Response
+// @Router /ledger/reports/general-ledger [get]
+func (h *Handlers) getGeneralLedger(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	accountID := c.QueryInt("account_id", 0)
+	if accountID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "account_id is required",
+		})
+	}
+
+	from, to, err := parseLedgerPeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid period",
+			Error:   err.Error(),
+		})
+	}
+
+	report, err := h.services.Ledger.GeneralLedger(int64(accountID), h.resolveOutletID(c, claims), from, to)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get general ledger",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "General ledger retrieved successfully",
+		Data:    report,
+	})
+}
+
+// @Summary Backfill ledger
+// @Description Post every transaction, payment and vehicle purchase or sale that has no journal entry yet
+// @Tags Ledger
+// @Security Bearer
+// @Success 200 {object} models.Response{data=models.LedgerBackfillResult}
+// @Failure 500 {object} models.Response
+// @Router /ledger/backfill [post]
+func (h *Handlers) backfillLedger(c *fiber.Ctx) error {
+	result, err := h.services.Ledger.Backfill()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to backfill ledger",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Ledger backfill completed",
+		Data:    result,
+	})
+}
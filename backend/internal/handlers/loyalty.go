@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupLoyaltyRoutes sets up loyalty settings, earn rule and customer points
+// routes. Points are redeemed through payments with the loyalty points
+// payment method.
+func (h *Handlers) setupLoyaltyRoutes(loyalty fiber.Router) {
+	loyalty.Get("/settings", h.requirePermission("loyalty.read"), h.getLoyaltySettings)
+	loyalty.Put("/settings", h.requirePermission("loyalty.manage"), h.updateLoyaltySettings)
+
+	// Earn rules per transaction type
+	loyalty.Get("/rules", h.requirePermission("loyalty.read"), h.getLoyaltyRules)
+	loyalty.Post("/rules", h.requirePermission("loyalty.manage"), h.createLoyaltyRule)
+	loyalty.Put("/rules/:id", h.requirePermission("loyalty.manage"), h.updateLoyaltyRule)
+	loyalty.Delete("/rules/:id", h.requirePermission("loyalty.manage"), h.deleteLoyaltyRule)
+
+	// Customer balances and statements
+	loyalty.Get("/customers/:customer_id", h.requirePermission("loyalty.read"), h.getLoyaltyAccount)
+	loyalty.Get("/customers/:customer_id/entries", h.requirePermission("loyalty.read"), h.getLoyaltyEntries)
+	loyalty.Post("/customers/:customer_id/adjustments", h.requirePermission("loyalty.adjust"), h.adjustLoyaltyPoints)
+}
+
+// @Summary Get loyalty settings
+// @Description Get what a point is worth when redeemed, the minimum to redeem and how long points last
+// @Tags Loyalty
+// @Security Bearer
+// @Success 200 {object} models.Response{data=models.LoyaltySettings}
+// @Failure 500 {object} models.Response
+// @Router /loyalty/settings [get]
+func (h *Handlers) getLoyaltySettings(c *fiber.Ctx) error {
+	settings, err := h.services.Loyalty.GetSettings()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get loyalty settings",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Loyalty settings retrieved successfully",
+		Data:    settings,
+	})
+}
+
+// @Summary Update loyalty settings
+// @Description Enable or disable the program and set the point value, minimum redemption and expiry in months (0 for never). A new expiry applies to points posted from now on.
+// @Tags Loyalty
+// @Security Bearer
+// @Param request body models.UpdateLoyaltySettingsRequest true "Settings"
+// @Success 200 {object} models.Response{data=models.LoyaltySettings}
+// @Failure 400 {object} models.Response
+// @Router /loyalty/settings [put]
+func (h *Handlers) updateLoyaltySettings(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.UpdateLoyaltySettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	settings, err := h.services.Loyalty.UpdateSettings(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update loyalty settings",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Loyalty settings updated successfully",
+		Data:    settings,
+	})
+}
+
+// @Summary Get loyalty earn rules
+// @Description Get the points each transaction type earns per Rupiah paid
+// @Tags Loyalty
+// @Security Bearer
+// @Success 200 {object} models.Response{data=[]models.LoyaltyEarnRule}
+// @Failure 500 {object} models.Response
+// @Router /loyalty/rules [get]
+func (h *Handlers) getLoyaltyRules(c *fiber.Ctx) error {
+	rules, err := h.services.Loyalty.ListRules()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get loyalty earn rules",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Loyalty earn rules retrieved successfully",
+		Data:    rules,
+	})
+}
+
+// @Summary Create loyalty earn rule
+// @Description Earn points for every spend_amount Rupiah paid on a transaction type, once at least min_amount is paid. Each transaction type has one rule.
+// @Tags Loyalty
+// @Security Bearer
+// @Param request body models.LoyaltyEarnRuleRequest true "Earn rule"
+// @Success 201 {object} models.Response{data=models.LoyaltyEarnRule}
+// @Failure 400 {object} models.Response
+// @Router /loyalty/rules [post]
+func (h *Handlers) createLoyaltyRule(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.LoyaltyEarnRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	rule, err := h.services.Loyalty.CreateRule(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create loyalty earn rule",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Loyalty earn rule created successfully",
+		Data:    rule,
+	})
+}
+
+// @Summary Update loyalty earn rule
+// @Description Change an earn rule. Transactions that already earned keep the rate they earned at.
+// @Tags Loyalty
+// @Security Bearer
+// @Param id path int true "Rule ID"
+// @Param request body models.LoyaltyEarnRuleRequest true "Earn rule"
+// @Success 200 {object} models.Response{data=models.LoyaltyEarnRule}
+// @Failure 400 {object} models.Response
+// @Router /loyalty/rules/{id} [put]
+func (h *Handlers) updateLoyaltyRule(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid rule ID",
+		})
+	}
+
+	var req models.LoyaltyEarnRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	rule, err := h.services.Loyalty.UpdateRule(int64(id), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update loyalty earn rule",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Loyalty earn rule updated successfully",
+		Data:    rule,
+	})
+}
+
+// @Summary Delete loyalty earn rule
+// @Description Stop a transaction type from earning points
+// @Tags Loyalty
+// @Security Bearer
+// @Param id path int true "Rule ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /loyalty/rules/{id} [delete]
+func (h *Handlers) deleteLoyaltyRule(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid rule ID",
+		})
+	}
+
+	if err := h.services.Loyalty.DeleteRule(int64(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Failed to delete loyalty earn rule",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Loyalty earn rule deleted successfully",
+	})
+}
+
+// @Summary Get customer loyalty account
+// @Description Get a customer's points balance, what it is worth when redeemed and the points that expire next
+// @Tags Loyalty
+// @Security Bearer
+// @Param customer_id path int true "Customer ID"
+// @Success 200 {object} models.Response{data=models.LoyaltyAccount}
+// @Failure 404 {object} models.Response
+// @Router /loyalty/customers/{customer_id} [get]
+func (h *Handlers) getLoyaltyAccount(c *fiber.Ctx) error {
+	customerID, err := c.ParamsInt("customer_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid customer ID",
+		})
+	}
+
+	account, err := h.services.Loyalty.GetAccount(int64(customerID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Loyalty account not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Loyalty account retrieved successfully",
+		Data:    account,
+	})
+}
+
+// @Summary Get customer points statement
+// @Description Get a customer's points ledger, newest first, with the balance after each entry
+// @Tags Loyalty
+// @Security Bearer
+// @Param customer_id path int true "Customer ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} models.PaginatedResponse{data=[]models.LoyaltyPointEntry}
+// @Failure 500 {object} models.Response
+// @Router /loyalty/customers/{customer_id}/entries [get]
+func (h *Handlers) getLoyaltyEntries(c *fiber.Ctx) error {
+	customerID, err := c.ParamsInt("customer_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid customer ID",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	entries, meta, err := h.services.Loyalty.ListEntries(int64(customerID), page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get points statement",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Points statement retrieved successfully",
+		Data:    entries,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Adjust customer loyalty points
+// @Description Add points (positive) or take them off (negative) with a reason. Added points expire like earned ones.
+// @Tags Loyalty
+// @Security Bearer
+// @Param customer_id path int true "Customer ID"
+// @Param request body models.LoyaltyAdjustmentRequest true "Adjustment"
+// @Success 200 {object} models.Response{data=models.LoyaltyAccount}
+// @Failure 400 {object} models.Response
+// @Router /loyalty/customers/{customer_id}/adjustments [post]
+func (h *Handlers) adjustLoyaltyPoints(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	customerID, err := c.ParamsInt("customer_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid customer ID",
+		})
+	}
+
+	var req models.LoyaltyAdjustmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	account, err := h.services.Loyalty.Adjust(int64(customerID), &req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to adjust loyalty points",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Loyalty points adjusted successfully",
+		Data:    account,
+	})
+}
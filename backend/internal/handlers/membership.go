@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupMembershipRoutes sets up membership tier and customer membership
+// routes. Customers are evaluated nightly and moved up as soon as a payment
+// lifts their spend into a higher tier.
+func (h *Handlers) setupMembershipRoutes(memberships fiber.Router) {
+	memberships.Get("/tiers", h.requirePermission("memberships.read"), h.getMembershipTiers)
+	memberships.Post("/tiers", h.requirePermission("memberships.manage"), h.createMembershipTier)
+	memberships.Put("/tiers/:id", h.requirePermission("memberships.manage"), h.updateMembershipTier)
+	memberships.Delete("/tiers/:id", h.requirePermission("memberships.manage"), h.deleteMembershipTier)
+	memberships.Post("/evaluate", h.requirePermission("memberships.manage"), h.evaluateMemberships)
+
+	// Customer tiers and their history
+	memberships.Get("/customers/:customer_id", h.requirePermission("memberships.read"), h.getCustomerMembership)
+	memberships.Get("/customers/:customer_id/history", h.requirePermission("memberships.read"), h.getCustomerTierHistory)
+	memberships.Post("/customers/:customer_id/evaluate", h.requirePermission("memberships.manage"), h.evaluateCustomerMembership)
+}
+
+// @Summary Get membership tiers
+// @Description Get the tiers by the spend they need, with their benefits and member counts
+// @Tags Memberships
+// @Security Bearer
+// @Success 200 {object} models.Response{data=[]models.MembershipTier}
+// @Failure 500 {object} models.Response
+// @Router /memberships/tiers [get]
+func (h *Handlers) getMembershipTiers(c *fiber.Ctx) error {
+	tiers, err := h.services.Membership.ListTiers()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get membership tiers",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Membership tiers retrieved successfully",
+		Data:    tiers,
+	})
+}
+
+// @Summary Create membership tier
+// @Description Add a tier reached by spending min_spend over 12 months. Members get discount_percent off service and sparepart invoices, earn points times point_multiplier and, with priority_queue, go ahead in the service queue.
+// @Tags Memberships
+// @Security Bearer
+// @Param request body models.MembershipTierRequest true "Tier"
+// @Success 201 {object} models.Response{data=models.MembershipTier}
+// @Failure 400 {object} models.Response
+// @Router /memberships/tiers [post]
+func (h *Handlers) createMembershipTier(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.MembershipTierRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	tier, err := h.services.Membership.CreateTier(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create membership tier",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Membership tier created successfully",
+		Data:    tier,
+	})
+}
+
+// @Summary Update membership tier
+// @Description Change a tier. New benefits apply to invoices from now on; members move to match a new min_spend at the next evaluation.
+// @Tags Memberships
+// @Security Bearer
+// @Param id path int true "Tier ID"
+// @Param request body models.MembershipTierRequest true "Tier"
+// @Success 200 {object} models.Response{data=models.MembershipTier}
+// @Failure 400 {object} models.Response
+// @Router /memberships/tiers/{id} [put]
+func (h *Handlers) updateMembershipTier(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid tier ID",
+		})
+	}
+
+	var req models.MembershipTierRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	tier, err := h.services.Membership.UpdateTier(int64(id), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update membership tier",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Membership tier updated successfully",
+		Data:    tier,
+	})
+}
+
+// @Summary Delete membership tier
+// @Description Delete a tier nobody was ever on. Tiers with members or history can only be deactivated.
+// @Tags Memberships
+// @Security Bearer
+// @Param id path int true "Tier ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Router /memberships/tiers/{id} [delete]
+func (h *Handlers) deleteMembershipTier(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid tier ID",
+		})
+	}
+
+	if err := h.services.Membership.DeleteTier(int64(id)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to delete membership tier",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Membership tier deleted successfully",
+	})
+}
+
+// @Summary Evaluate all memberships
+// @Description Re-evaluate every customer now, as the nightly job does, moving them up or down by their rolling 12-month spend
+// @Tags Memberships
+// @Security Bearer
+// @Success 200 {object} models.Response{data=models.MembershipEvaluationResult}
+// @Failure 500 {object} models.Response
+// @Router /memberships/evaluate [post]
+func (h *Handlers) evaluateMemberships(c *fiber.Ctx) error {
+	result, err := h.services.Membership.EvaluateAll()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to evaluate memberships",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Memberships evaluated successfully",
+		Data:    result,
+	})
+}
+
+// @Summary Get customer membership
+// @Description Get a customer's tier and benefits, their rolling 12-month spend and what they still need to spend for the next tier
+// @Tags Memberships
+// @Security Bearer
+// @Param customer_id path int true "Customer ID"
+// @Success 200 {object} models.Response{data=models.CustomerMembership}
+// @Failure 404 {object} models.Response
+// @Router /memberships/customers/{customer_id} [get]
+func (h *Handlers) getCustomerMembership(c *fiber.Ctx) error {
+	customerID, err := c.ParamsInt("customer_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid customer ID",
+		})
+	}
+
+	membership, err := h.services.Membership.GetCustomerMembership(int64(customerID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Customer membership not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Customer membership retrieved successfully",
+		Data:    membership,
+	})
+}
+
+// @Summary Get customer tier history
+// @Description Get a customer's tier upgrades and downgrades, newest first, with the spend each was decided on
+// @Tags Memberships
+// @Security Bearer
+// @Param customer_id path int true "Customer ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} models.PaginatedResponse{data=[]models.CustomerTierChange}
+// @Failure 500 {object} models.Response
+// @Router /memberships/customers/{customer_id}/history [get]
+func (h *Handlers) getCustomerTierHistory(c *fiber.Ctx) error {
+	customerID, err := c.ParamsInt("customer_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid customer ID",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	history, meta, err := h.services.Membership.ListHistory(int64(customerID), page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get tier history",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Tier history retrieved successfully",
+		Data:    history,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Evaluate customer membership
+// @Description Re-evaluate one customer now, moving them up or down by their rolling 12-month spend
+// @Tags Memberships
+// @Security Bearer
+// @Param customer_id path int true "Customer ID"
+// @Success 200 {object} models.Response{data=models.CustomerMembership}
+// @Failure 400 {object} models.Response
+// @Router /memberships/customers/{customer_id}/evaluate [post]
+func (h *Handlers) evaluateCustomerMembership(c *fiber.Ctx) error {
+	customerID, err := c.ParamsInt("customer_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid customer ID",
+		})
+	}
+
+	membership, err := h.services.Membership.EvaluateCustomer(int64(customerID))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to evaluate customer membership",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Customer membership evaluated successfully",
+		Data:    membership,
+	})
+}
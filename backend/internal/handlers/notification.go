@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupNotificationRoutes sets up notification outbox and inbox routes
+func (h *Handlers) setupNotificationRoutes(notifications fiber.Router) {
+	notifications.Get("/inbox", h.getNotificationInbox)
+	notifications.Put("/inbox/:id/read", h.markNotificationRead)
+	notifications.Get("/", h.requirePermission("notifications.read"), h.getNotifications)
+	notifications.Post("/:id/retry", h.requirePermission("notifications.update"), h.retryNotification)
+}
+
+// @Summary Get notifications
+// @Description Get the notification outbox with delivery status
+// @Tags Notifications
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param status query string false "Filter by status (pending, sending, sent, failed)"
+// @Param channel query string false "Filter by channel (whatsapp, sms, email, in_app)"
+// @Param customer_id query int false "Filter by customer ID"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.Notification}
+// @Failure 500 {object} models.Response
+// @Router /notifications [get]
+func (h *Handlers) getNotifications(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.NotificationFilter{
+		OutletID: h.resolveOutletID(c, claims),
+		Status:   c.Query("status", ""),
+		Channel:  c.Query("channel", ""),
+	}
+	if customerID := c.QueryInt("customer_id", 0); customerID > 0 {
+		id := int64(customerID)
+		filter.CustomerID = &id
+	}
+
+	notifications, meta, err := h.services.Notification.List(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get notifications",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Notifications retrieved successfully",
+		Data:    notifications,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Retry notification
+// @Description Queue a failed notification for another delivery attempt
+// @Tags Notifications
+// @Security Bearer
+// @Param id path int true "Notification ID"
+// @Success 200 {object} models.Response{data=models.Notification}
+// @Failure 400 {object} models.Response
+// @Router /notifications/{id}/retry [post]
+func (h *Handlers) retryNotification(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid notification ID",
+		})
+	}
+
+	notification, err := h.services.Notification.Retry(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to retry notification",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Notification queued for retry",
+		Data:    notification,
+	})
+}
+
+// @Summary Get my notifications
+// @Description Get the current user's in-app notifications
+// @Tags Notifications
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param unread query bool false "Only unread notifications"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.Notification}
+// @Failure 500 {object} models.Response
+// @Router /notifications/inbox [get]
+func (h *Handlers) getNotificationInbox(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+	unreadOnly := c.QueryBool("unread", false)
+
+	notifications, meta, err := h.services.Notification.Inbox(claims.UserID, unreadOnly, page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get notifications",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Notifications retrieved successfully",
+		Data:    notifications,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Mark notification read
+// @Description Mark one of the current user's in-app notifications as read
+// @Tags Notifications
+// @Security Bearer
+// @Param id path int true "Notification ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /notifications/inbox/{id}/read [put]
+func (h *Handlers) markNotificationRead(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid notification ID",
+		})
+	}
+
+	if err := h.services.Notification.MarkRead(int64(id), claims.UserID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Failed to mark notification as read",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Notification marked as read",
+	})
+}
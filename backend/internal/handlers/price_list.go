@@ -0,0 +1,584 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupPriceListRoutes sets up price list routes
+func (h *Handlers) setupPriceListRoutes(priceLists fiber.Router) {
+	priceLists.Get("/", h.requirePermission("price_lists.read"), h.getPriceLists)
+	priceLists.Get("/resolve", h.requirePermission("price_lists.read"), h.resolvePrice)
+	priceLists.Get("/history", h.requirePermission("price_lists.read"), h.getPriceHistory)
+	priceLists.Get("/:id", h.requirePermission("price_lists.read"), h.getPriceListByID)
+	priceLists.Post("/", h.requirePermission("price_lists.manage"), h.createPriceList)
+	priceLists.Put("/:id", h.requirePermission("price_lists.manage"), h.updatePriceList)
+	priceLists.Get("/:id/items", h.requirePermission("price_lists.read"), h.getPriceListItems)
+	priceLists.Put("/:id/items", h.requirePermission("price_lists.manage"), h.setPriceListItem)
+	priceLists.Delete("/:id/items/:item_id", h.requirePermission("price_lists.manage"), h.deletePriceListItem)
+	priceLists.Post("/:id/customers", h.requirePermission("price_lists.manage"), h.assignPriceListCustomer)
+	priceLists.Delete("/:id/customers/:customer_id", h.requirePermission("price_lists.manage"), h.unassignPriceListCustomer)
+}
+
+// priceListInScope reports whether an outlet user may change a price list.
+// Chain-wide lists are left to Super Admin.
+func (h *Handlers) priceListInScope(claims *models.Claims, priceListID int64) bool {
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return false
+	}
+	if outletID == nil {
+		return true
+	}
+
+	priceList, err := h.services.PriceList.GetByID(priceListID)
+	return err == nil && priceList.OutletID != nil && *priceList.OutletID == *outletID
+}
+
+// @Summary Get price lists
+// @Description Get the price lists that can apply at the user's outlet, most important first
+// @Tags Price Lists
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param customer_type query string false "Customer type (individual, corporate)"
+// @Param customer_id query int false "Lists negotiated for a customer"
+// @Param active_only query bool false "Only active lists"
+// @Param search query string false "Search by code or name"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.PriceList}
+// @Failure 500 {object} models.Response
+// @Router /price-lists [get]
+func (h *Handlers) getPriceLists(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.PriceListFilter{
+		OutletID:     h.resolveOutletID(c, claims),
+		CustomerType: c.Query("customer_type", ""),
+		CustomerID:   queryID(c, "customer_id"),
+		ActiveOnly:   c.QueryBool("active_only", false),
+		Search:       c.Query("search", ""),
+	}
+
+	priceLists, meta, err := h.services.PriceList.List(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get price lists",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Price lists retrieved successfully",
+		Data:    priceLists,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get price list
+// @Description Get a price list by ID with the customers it is negotiated for
+// @Tags Price Lists
+// @Security Bearer
+// @Param id path int true "Price list ID"
+// @Success 200 {object} models.Response{data=models.PriceList}
+// @Failure 404 {object} models.Response
+// @Router /price-lists/{id} [get]
+func (h *Handlers) getPriceListByID(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid price list ID",
+		})
+	}
+
+	priceList, err := h.services.PriceList.GetByID(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Price list not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Price list retrieved successfully",
+		Data:    priceList,
+	})
+}
+
+// @Summary Create price list
+// @Description Create a price list limited to an outlet, a customer type and a validity period, any of which may be left open. Outlet users can only create lists for their own outlet.
+// @Tags Price Lists
+// @Security Bearer
+// @Param request body models.PriceListRequest true "Price list"
+// @Success 201 {object} models.Response{data=models.PriceList}
+// @Failure 400 {object} models.Response
+// @Router /price-lists [post]
+func (h *Handlers) createPriceList(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.PriceListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+	if outletID != nil {
+		req.OutletID = outletID
+	}
+
+	priceList, err := h.services.PriceList.Create(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create price list",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Price list created successfully",
+		Data:    priceList,
+	})
+}
+
+// @Summary Update price list
+// @Description Update a price list. Lines already priced from it keep their prices.
+// @Tags Price Lists
+// @Security Bearer
+// @Param id path int true "Price list ID"
+// @Param request body models.PriceListRequest true "Price list"
+// @Success 200 {object} models.Response{data=models.PriceList}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /price-lists/{id} [put]
+func (h *Handlers) updatePriceList(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid price list ID",
+		})
+	}
+
+	var req models.PriceListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if !h.priceListInScope(claims, int64(id)) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Price list not found",
+		})
+	}
+	if outletID, _ := h.outletScope(claims); outletID != nil {
+		req.OutletID = outletID
+	}
+
+	priceList, err := h.services.PriceList.Update(int64(id), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update price list",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Price list updated successfully",
+		Data:    priceList,
+	})
+}
+
+// @Summary Get price list items
+// @Description Get the products and services on a price list with their own prices alongside
+// @Tags Price Lists
+// @Security Bearer
+// @Param id path int true "Price list ID"
+// @Success 200 {object} models.Response{data=[]models.PriceListItem}
+// @Failure 404 {object} models.Response
+// @Router /price-lists/{id}/items [get]
+func (h *Handlers) getPriceListItems(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid price list ID",
+		})
+	}
+
+	items, err := h.services.PriceList.ListItems(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Price list not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Price list items retrieved successfully",
+		Data:    items,
+	})
+}
+
+// @Summary Set price list item
+// @Description Put a product or service on a price list at a price, or change its price there. The change is recorded in the item's price history.
+// @Tags Price Lists
+// @Security Bearer
+// @Param id path int true "Price list ID"
+// @Param request body models.SetPriceListItemRequest true "Item price"
+// @Success 200 {object} models.Response{data=models.PriceListItem}
+// @Failure 400 {object} models.Response
+// @Router /price-lists/{id}/items [put]
+func (h *Handlers) setPriceListItem(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid price list ID",
+		})
+	}
+
+	var req models.SetPriceListItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if !h.priceListInScope(claims, int64(id)) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Price list not found",
+		})
+	}
+
+	item, err := h.services.PriceList.SetItem(int64(id), &req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to set price list item",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Price list item saved successfully",
+		Data:    item,
+	})
+}
+
+// @Summary Delete price list item
+// @Description Take a product or service off a price list so it sells at its own price again
+// @Tags Price Lists
+// @Security Bearer
+// @Param id path int true "Price list ID"
+// @Param item_id path int true "Price list item ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Router /price-lists/{id}/items/{item_id} [delete]
+func (h *Handlers) deletePriceListItem(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid price list ID",
+		})
+	}
+
+	itemID, err := c.ParamsInt("item_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid price list item ID",
+		})
+	}
+
+	if !h.priceListInScope(claims, int64(id)) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Price list not found",
+		})
+	}
+
+	if err := h.services.PriceList.DeleteItem(int64(id), int64(itemID), claims.UserID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to delete price list item",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Price list item deleted successfully",
+	})
+}
+
+// @Summary Assign customer to price list
+// @Description Negotiate a price list for a customer. A list with customers applies to them only, ahead of lists for their customer type or outlet.
+// @Tags Price Lists
+// @Security Bearer
+// @Param id path int true "Price list ID"
+// @Param request body models.AssignPriceListCustomerRequest true "Customer"
+// @Success 200 {object} models.Response{data=models.PriceList}
+// @Failure 400 {object} models.Response
+// @Router /price-lists/{id}/customers [post]
+func (h *Handlers) assignPriceListCustomer(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid price list ID",
+		})
+	}
+
+	var req models.AssignPriceListCustomerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if !h.priceListInScope(claims, int64(id)) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Price list not found",
+		})
+	}
+
+	priceList, err := h.services.PriceList.AssignCustomer(int64(id), req.CustomerID, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to assign customer",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Customer assigned successfully",
+		Data:    priceList,
+	})
+}
+
+// @Summary Remove customer from price list
+// @Description Stop a price list applying to a customer
+// @Tags Price Lists
+// @Security Bearer
+// @Param id path int true "Price list ID"
+// @Param customer_id path int true "Customer ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Router /price-lists/{id}/customers/{customer_id} [delete]
+func (h *Handlers) unassignPriceListCustomer(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid price list ID",
+		})
+	}
+
+	customerID, err := c.ParamsInt("customer_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid customer ID",
+		})
+	}
+
+	if !h.priceListInScope(claims, int64(id)) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Price list not found",
+		})
+	}
+
+	if err := h.services.PriceList.UnassignCustomer(int64(id), int64(customerID)); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to remove customer",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Customer removed successfully",
+	})
+}
+
+// @Summary Resolve price
+// @Description Get the price a product or service sells at now for a customer at the user's outlet, and the price list it comes from. Without a customer only lists open to every customer apply.
+// @Tags Price Lists
+// @Security Bearer
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param customer_id query int false "Customer ID"
+// @Param product_id query int false "Product ID"
+// @Param service_id query int false "Service ID"
+// @Success 200 {object} models.Response{data=models.ResolvedPrice}
+// @Failure 400 {object} models.Response
+// @Router /price-lists/resolve [get]
+func (h *Handlers) resolvePrice(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	outletID := h.resolveOutletID(c, claims)
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "outlet_id is required",
+		})
+	}
+
+	resolved, err := h.services.PriceList.Resolve(queryID(c, "customer_id"), *outletID,
+		queryID(c, "product_id"), queryID(c, "service_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to resolve price",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Price resolved successfully",
+		Data:    resolved,
+	})
+}
+
+// @Summary Get price history
+// @Description Get the changes to products' and services' own prices and to their prices on price lists, newest first
+// @Tags Price Lists
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param product_id query int false "Product ID"
+// @Param service_id query int false "Service ID"
+// @Param price_list_id query int false "Price list ID"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.PriceHistory}
+// @Failure 500 {object} models.Response
+// @Router /price-lists/history [get]
+func (h *Handlers) getPriceHistory(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	from, to := parseDateRange(c)
+	filter := &models.PriceHistoryFilter{
+		ProductID:   queryID(c, "product_id"),
+		ServiceID:   queryID(c, "service_id"),
+		PriceListID: queryID(c, "price_list_id"),
+		DateFrom:    from,
+		DateTo:      to,
+	}
+
+	history, meta, err := h.services.PriceList.ListHistory(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get price history",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Price history retrieved successfully",
+		Data:    history,
+		Meta:    *meta,
+	})
+}
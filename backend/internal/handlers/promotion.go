@@ -0,0 +1,545 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupPromotionRoutes sets up promotion, coupon and redemption routes
+func (h *Handlers) setupPromotionRoutes(promotions fiber.Router) {
+	promotions.Get("/", h.requirePermission("promotions.read"), h.getPromotions)
+	promotions.Get("/redemptions", h.requirePermission("promotions.read"), h.getPromotionRedemptions)
+	promotions.Put("/coupons/:coupon_id", h.requirePermission("promotions.manage"), h.updateCoupon)
+	promotions.Get("/:id", h.requirePermission("promotions.read"), h.getPromotionByID)
+	promotions.Post("/", h.requirePermission("promotions.manage"), h.createPromotion)
+	promotions.Put("/:id", h.requirePermission("promotions.manage"), h.updatePromotion)
+
+	// Coupons
+	promotions.Get("/:id/coupons", h.requirePermission("promotions.read"), h.getCoupons)
+	promotions.Post("/:id/coupons", h.requirePermission("promotions.manage"), h.createCoupon)
+}
+
+// promotionInScope reports whether an outlet user may change a promotion.
+// Chain-wide promotions are left to Super Admin.
+func (h *Handlers) promotionInScope(claims *models.Claims, promotionID int64) bool {
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return false
+	}
+	if outletID == nil {
+		return true
+	}
+
+	p, err := h.services.Promotion.GetByID(promotionID)
+	return err == nil && p.OutletID != nil && *p.OutletID == *outletID
+}
+
+// @Summary Get promotions
+// @Description Get promotions available at the user's outlet, highest priority first
+// @Tags Promotions
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param active_only query bool false "Only active promotions"
+// @Param requires_coupon query bool false "Only promotions that do or do not need a coupon"
+// @Param search query string false "Search by name"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.Promotion}
+// @Failure 500 {object} models.Response
+// @Router /promotions [get]
+func (h *Handlers) getPromotions(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.PromotionFilter{
+		OutletID:   h.resolveOutletID(c, claims),
+		ActiveOnly: c.QueryBool("active_only", false),
+		Search:     c.Query("search", ""),
+	}
+	if c.Query("requires_coupon") != "" {
+		requiresCoupon := c.QueryBool("requires_coupon")
+		filter.RequiresCoupon = &requiresCoupon
+	}
+
+	promotions, meta, err := h.services.Promotion.List(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get promotions",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Promotions retrieved successfully",
+		Data:    promotions,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get promotion
+// @Description Get a promotion by ID with how many times it has been used
+// @Tags Promotions
+// @Security Bearer
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} models.Response{data=models.Promotion}
+// @Failure 404 {object} models.Response
+// @Router /promotions/{id} [get]
+func (h *Handlers) getPromotionByID(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid promotion ID",
+		})
+	}
+
+	p, err := h.services.Promotion.GetByID(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Promotion not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Promotion retrieved successfully",
+		Data:    p,
+	})
+}
+
+// @Summary Create promotion
+// @Description Create a percentage or fixed promotion on lines or the whole invoice. Promotions without requires_coupon apply automatically; outlet users can only create promotions for their own outlet.
+// @Tags Promotions
+// @Security Bearer
+// @Param request body models.PromotionRequest true "Promotion"
+// @Success 201 {object} models.Response{data=models.Promotion}
+// @Failure 400 {object} models.Response
+// @Router /promotions [post]
+func (h *Handlers) createPromotion(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.PromotionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+	if outletID != nil {
+		req.OutletID = outletID
+	}
+
+	p, err := h.services.Promotion.Create(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create promotion",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Promotion created successfully",
+		Data:    p,
+	})
+}
+
+// @Summary Update promotion
+// @Description Update a promotion. Transactions already created keep the discounts they were given.
+// @Tags Promotions
+// @Security Bearer
+// @Param id path int true "Promotion ID"
+// @Param request body models.PromotionRequest true "Promotion"
+// @Success 200 {object} models.Response{data=models.Promotion}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /promotions/{id} [put]
+func (h *Handlers) updatePromotion(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid promotion ID",
+		})
+	}
+
+	var req models.PromotionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if !h.promotionInScope(claims, int64(id)) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Promotion not found",
+		})
+	}
+	if outletID, _ := h.outletScope(claims); outletID != nil {
+		req.OutletID = outletID
+	}
+
+	p, err := h.services.Promotion.Update(int64(id), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update promotion",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Promotion updated successfully",
+		Data:    p,
+	})
+}
+
+// @Summary Get coupons
+// @Description Get the coupon codes of a promotion with how many times each has been used
+// @Tags Promotions
+// @Security Bearer
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} models.Response{data=[]models.Coupon}
+// @Failure 404 {object} models.Response
+// @Router /promotions/{id}/coupons [get]
+func (h *Handlers) getCoupons(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid promotion ID",
+		})
+	}
+
+	coupons, err := h.services.Promotion.ListCoupons(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Promotion not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Coupons retrieved successfully",
+		Data:    coupons,
+	})
+}
+
+// @Summary Create coupon
+// @Description Add a coupon code to a promotion that requires one. A random code is generated when none is given.
+// @Tags Promotions
+// @Security Bearer
+// @Param id path int true "Promotion ID"
+// @Param request body models.CreateCouponRequest true "Coupon"
+// @Success 201 {object} models.Response{data=models.Coupon}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /promotions/{id}/coupons [post]
+func (h *Handlers) createCoupon(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid promotion ID",
+		})
+	}
+
+	var req models.CreateCouponRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if !h.promotionInScope(claims, int64(id)) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Promotion not found",
+		})
+	}
+
+	coupon, err := h.services.Promotion.CreateCoupon(int64(id), &req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create coupon",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Coupon created successfully",
+		Data:    coupon,
+	})
+}
+
+// @Summary Update coupon
+// @Description Enable or disable a coupon code
+// @Tags Promotions
+// @Security Bearer
+// @Param coupon_id path int true "Coupon ID"
+// @Param request body models.UpdateCouponRequest true "Coupon"
+// @Success 200 {object} models.Response{data=models.Coupon}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /promotions/coupons/{coupon_id} [put]
+func (h *Handlers) updateCoupon(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("coupon_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid coupon ID",
+		})
+	}
+
+	var req models.UpdateCouponRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	coupon, err := h.services.Promotion.GetCoupon(int64(id))
+	if err != nil || !h.promotionInScope(claims, coupon.PromotionID) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Coupon not found",
+		})
+	}
+
+	coupon, err = h.services.Promotion.UpdateCoupon(int64(id), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update coupon",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Coupon updated successfully",
+		Data:    coupon,
+	})
+}
+
+// @Summary Get promotion redemptions
+// @Description Get the discounts promotions gave on transactions, newest first
+// @Tags Promotions
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param promotion_id query int false "Promotion ID"
+// @Param customer_id query int false "Customer ID"
+// @Param date_from query string false "From date (YYYY-MM-DD)"
+// @Param date_to query string false "To date (YYYY-MM-DD)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.PromotionRedemption}
+// @Failure 500 {object} models.Response
+// @Router /promotions/redemptions [get]
+func (h *Handlers) getPromotionRedemptions(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.RedemptionFilter{
+		PromotionID: queryID(c, "promotion_id"),
+		OutletID:    h.resolveOutletID(c, claims),
+		CustomerID:  queryID(c, "customer_id"),
+	}
+	filter.DateFrom, filter.DateTo = parseDateRange(c)
+
+	redemptions, meta, err := h.services.Promotion.ListRedemptions(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get promotion redemptions",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Promotion redemptions retrieved successfully",
+		Data:    redemptions,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Apply coupon to service job
+// @Description Put a coupon on a service job and reprice it. The coupon must discount the job's current lines and is used up when the job is invoiced.
+// @Tags Service Jobs
+// @Security Bearer
+// @Param id path int true "Service Job ID"
+// @Param request body models.ApplyCouponRequest true "Coupon"
+// @Success 200 {object} models.Response{data=models.ServiceJob}
+// @Failure 400 {object} models.Response
+// @Router /service-jobs/{id}/coupon [put]
+func (h *Handlers) applyServiceJobCoupon(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid service job ID",
+		})
+	}
+
+	var req models.ApplyCouponRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+
+	serviceJob, err := h.services.ServiceJob.ApplyCoupon(int64(id), req.CouponCode, outletID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to apply coupon",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Coupon applied successfully",
+		Data:    serviceJob,
+	})
+}
+
+// @Summary Remove coupon from service job
+// @Description Take the coupon off a service job and reprice it
+// @Tags Service Jobs
+// @Security Bearer
+// @Param id path int true "Service Job ID"
+// @Success 200 {object} models.Response{data=models.ServiceJob}
+// @Failure 400 {object} models.Response
+// @Router /service-jobs/{id}/coupon [delete]
+func (h *Handlers) removeServiceJobCoupon(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid service job ID",
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+
+	serviceJob, err := h.services.ServiceJob.RemoveCoupon(int64(id), outletID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to remove coupon",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Coupon removed successfully",
+		Data:    serviceJob,
+	})
+}
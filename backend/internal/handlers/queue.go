@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Get service queue
+// @Description Get the priority-ordered service queue for an outlet with estimated start and finish times
+// @Tags Service Jobs
+// @Security Bearer
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Success 200 {object} models.Response{data=models.OutletQueue}
+// @Failure 400 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Router /service-jobs/queue [get]
+func (h *Handlers) getServiceQueue(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	outletID := h.resolveOutletID(c, claims)
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Outlet ID is required",
+		})
+	}
+
+	queue, err := h.services.Queue.GetOutletQueue(*outletID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get service queue",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Service queue retrieved successfully",
+		Data:    queue,
+	})
+}
+
+// @Summary Get service job ETA
+// @Description Get queue position and estimated start and finish time for a service job
+// @Tags Service Jobs
+// @Security Bearer
+// @Param id path int true "Service Job ID"
+// @Success 200 {object} models.Response{data=models.QueueEntry}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /service-jobs/{id}/eta [get]
+func (h *Handlers) getServiceJobETA(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid service job ID",
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+
+	eta, err := h.services.Queue.GetJobETA(int64(id), outletID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get service job ETA",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Service job ETA retrieved successfully",
+		Data:    eta,
+	})
+}
+
+// resolveOutletID returns the outlet the request is scoped to, allowing
+// Super Admin to pick any outlet via the outlet_id query parameter
+func (h *Handlers) resolveOutletID(c *fiber.Ctx, claims *models.Claims) *int64 {
+	outletID := claims.OutletID
+
+	if claims.RoleID == 1 { // Super Admin
+		if outletIDParam := c.QueryInt("outlet_id", 0); outletIDParam > 0 {
+			id := int64(outletIDParam)
+			outletID = &id
+		}
+	}
+
+	return outletID
+}
+
+// outletScope returns the outlet a user's changes are restricted to, or nil
+// for Super Admin. ok is false for non-admin users without an outlet.
+func (h *Handlers) outletScope(claims *models.Claims) (outletID *int64, ok bool) {
+	if claims.RoleID == 1 { // Super Admin
+		return nil, true
+	}
+
+	return claims.OutletID, claims.OutletID != nil
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/realtime"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// sseHeartbeatInterval keeps idle connections open through proxies
+const sseHeartbeatInterval = 15 * time.Second
+
+// sseMessage is a single frame written to an event stream
+type sseMessage struct {
+	ID    int64
+	Event string
+	Data  interface{}
+}
+
+// setupRealtimeRoutes sets up server push routes
+func (h *Handlers) setupRealtimeRoutes(events fiber.Router) {
+	events.Get("/stream", h.streamEvents)
+}
+
+// @Summary Stream real-time events
+// @Description Server-Sent Events stream of job, queue and payment changes for the user's outlet, filtered by the user's permissions
+// @Tags Realtime
+// @Security Bearer
+// @Produce text/event-stream
+// @Param outlet_id query int false "Outlet ID (Super Admin only, omit for all outlets)"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} models.Response
+// @Router /events/stream [get]
+func (h *Handlers) streamEvents(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var outletID *int64
+	if claims.RoleID == 1 { // Super Admin sees every outlet unless one is requested
+		if outletIDParam := c.QueryInt("outlet_id", 0); outletIDParam > 0 {
+			id := int64(outletIDParam)
+			outletID = &id
+		}
+	} else {
+		if claims.OutletID == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+				Success: false,
+				Message: "User must be assigned to an outlet",
+			})
+		}
+		outletID = claims.OutletID
+	}
+
+	permissions := claims.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	sub := h.services.Realtime.Subscribe(outletID, permissions)
+	h.writeEventStream(c, sub, nil, nil, func(event realtime.Event) (sseMessage, bool) {
+		return sseMessage{ID: event.ID, Event: event.Type, Data: event}, true
+	})
+
+	return nil
+}
+
+// writeEventStream switches the response to text/event-stream, writes the
+// initial messages and then forwards subscriber events through render until
+// the client disconnects or the hub closes the subscriber on shutdown.
+// Events render rejects are skipped. A non-nil alive is asked on every
+// heartbeat whether the stream may stay open.
+func (h *Handlers) writeEventStream(c *fiber.Ctx, sub *realtime.Subscriber, initial []sseMessage, alive func() bool, render func(realtime.Event) (sseMessage, bool)) {
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	hub := h.services.Realtime
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer hub.Unsubscribe(sub)
+
+		heartbeat := time.NewTicker(sseHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		// Tell the client the stream is open
+		fmt.Fprintf(w, "retry: 3000\n: connected\n\n")
+		for _, message := range initial {
+			writeSSEMessage(w, message)
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+
+				message, ok := render(event)
+				if !ok {
+					continue
+				}
+
+				writeSSEMessage(w, message)
+			case <-heartbeat.C:
+				if alive != nil && !alive() {
+					return
+				}
+				fmt.Fprintf(w, ": ping\n\n")
+			}
+
+			// A failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+// writeSSEMessage writes one event frame, omitting the id line when unset
+func writeSSEMessage(w *bufio.Writer, message sseMessage) {
+	payload, err := json.Marshal(message.Data)
+	if err != nil {
+		return
+	}
+
+	if message.ID > 0 {
+		fmt.Fprintf(w, "id: %d\n", message.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Event, payload)
+}
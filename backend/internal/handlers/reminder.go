@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupReminderRoutes sets up vehicle reminder routes
+func (h *Handlers) setupReminderRoutes(reminders fiber.Router) {
+	reminders.Get("/", h.requirePermission("reminders.read"), h.getReminders)
+	reminders.Post("/scan", h.requirePermission("reminders.update"), h.scanReminders)
+	reminders.Put("/:id/status", h.requirePermission("reminders.update"), h.updateReminderStatus)
+}
+
+// setupServiceIntervalRoutes sets up service interval routes
+func (h *Handlers) setupServiceIntervalRoutes(intervals fiber.Router) {
+	intervals.Get("/", h.requirePermission("services.read"), h.getServiceIntervals)
+	intervals.Post("/", h.requirePermission("services.update"), h.createServiceInterval)
+	intervals.Put("/:id", h.requirePermission("services.update"), h.updateServiceInterval)
+	intervals.Delete("/:id", h.requirePermission("services.delete"), h.deleteServiceInterval)
+}
+
+// @Summary Get vehicle reminders
+// @Description Get the open reminder worklist for the user's outlet
+// @Tags Reminders
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param type query string false "Reminder type"
+// @Param status query string false "Reminder status (defaults to open reminders)"
+// @Param due_within query int false "Only reminders due within this many days"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.VehicleReminder}
+// @Failure 500 {object} models.Response
+// @Router /reminders [get]
+func (h *Handlers) getReminders(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.ReminderFilter{
+		OutletID:     h.resolveOutletID(c, claims),
+		ReminderType: c.Query("type", ""),
+		Status:       c.Query("status", ""),
+	}
+	if dueWithin := c.QueryInt("due_within", -1); dueWithin >= 0 {
+		filter.DueWithin = &dueWithin
+	}
+
+	reminders, meta, err := h.services.Reminder.List(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get reminders",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Reminders retrieved successfully",
+		Data:    reminders,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Run reminder scan
+// @Description Generate reminders for vehicles coming due now instead of waiting for the scheduled scan
+// @Tags Reminders
+// @Security Bearer
+// @Success 200 {object} models.Response{data=models.ReminderScanResult}
+// @Failure 500 {object} models.Response
+// @Router /reminders/scan [post]
+func (h *Handlers) scanReminders(c *fiber.Ctx) error {
+	result, err := h.services.Reminder.Scan()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to scan reminders",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Reminder scan completed successfully",
+		Data:    result,
+	})
+}
+
+// @Summary Update reminder status
+// @Description Mark a reminder as contacted or dismissed, or record a renewed insurance or registration (STNK) with its new expiry date
+// @Tags Reminders
+// @Security Bearer
+// @Param id path int true "Reminder ID"
+// @Param request body models.UpdateReminderStatusRequest true "Reminder status data"
+// @Success 200 {object} models.Response{data=models.VehicleReminder}
+// @Failure 400 {object} models.Response
+// @Router /reminders/{id}/status [put]
+func (h *Handlers) updateReminderStatus(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid reminder ID",
+		})
+	}
+
+	var req models.UpdateReminderStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+
+	reminder, err := h.services.Reminder.UpdateStatus(int64(id), &req, outletID, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update reminder status",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Reminder status updated successfully",
+		Data:    reminder,
+	})
+}
+
+// @Summary Get service intervals
+// @Description Get the configured service intervals per service and service category
+// @Tags Reminders
+// @Security Bearer
+// @Success 200 {object} models.Response{data=[]models.ServiceInterval}
+// @Failure 500 {object} models.Response
+// @Router /service-intervals [get]
+func (h *Handlers) getServiceIntervals(c *fiber.Ctx) error {
+	intervals, err := h.services.Reminder.ListIntervals()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get service intervals",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Service intervals retrieved successfully",
+		Data:    intervals,
+	})
+}
+
+// @Summary Create service interval
+// @Description Set how many days or kilometres after a service (or any service in a category) the vehicle is due again
+// @Tags Reminders
+// @Security Bearer
+// @Param request body models.CreateServiceIntervalRequest true "Service interval data"
+// @Success 201 {object} models.Response{data=models.ServiceInterval}
+// @Failure 400 {object} models.Response
+// @Router /service-intervals [post]
+func (h *Handlers) createServiceInterval(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.CreateServiceIntervalRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	interval, err := h.services.Reminder.CreateInterval(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create service interval",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Service interval created successfully",
+		Data:    interval,
+	})
+}
+
+// @Summary Update service interval
+// @Description Update a service interval
+// @Tags Reminders
+// @Security Bearer
+// @Param id path int true "Service Interval ID"
+// @Param request body models.UpdateServiceIntervalRequest true "Service interval data"
+// @Success 200 {object} models.Response{data=models.ServiceInterval}
+// @Failure 400 {object} models.Response
+// @Router /service-intervals/{id} [put]
+func (h *Handlers) updateServiceInterval(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid service interval ID",
+		})
+	}
+
+	var req models.UpdateServiceIntervalRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	interval, err := h.services.Reminder.UpdateInterval(int64(id), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update service interval",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Service interval updated successfully",
+		Data:    interval,
+	})
+}
+
+// @Summary Delete service interval
+// @Description Delete a service interval
+// @Tags Reminders
+// @Security Bearer
+// @Param id path int true "Service Interval ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /service-intervals/{id} [delete]
+func (h *Handlers) deleteServiceInterval(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid service interval ID",
+		})
+	}
+
+	if err := h.services.Reminder.DeleteInterval(int64(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Failed to delete service interval",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Service interval deleted successfully",
+	})
+}
@@ -0,0 +1,538 @@
+package handlers
+
+import (
+	"time"
+
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupReturnRoutes sets up sales return routes
+func (h *Handlers) setupReturnRoutes(returns fiber.Router) {
+	returns.Get("/", h.requirePermission("returns.read"), h.getSalesReturns)
+	returns.Get("/:id", h.requirePermission("returns.read"), h.getSalesReturnByID)
+	returns.Post("/", h.requirePermission("returns.create"), h.createSalesReturn)
+}
+
+// setupRefundRoutes sets up refund routes
+func (h *Handlers) setupRefundRoutes(refunds fiber.Router) {
+	refunds.Get("/", h.requirePermission("returns.read"), h.getRefunds)
+	refunds.Get("/:id", h.requirePermission("returns.read"), h.getRefundByID)
+	refunds.Post("/", h.requirePermission("returns.create"), h.createRefund)
+}
+
+// setupCreditNoteRoutes sets up credit note routes
+func (h *Handlers) setupCreditNoteRoutes(creditNotes fiber.Router) {
+	creditNotes.Get("/", h.requirePermission("returns.read"), h.getCreditNotes)
+	creditNotes.Get("/:id", h.requirePermission("returns.read"), h.getCreditNoteByID)
+	creditNotes.Post("/:id/apply", h.requirePermission("returns.create"), h.applyCreditNote)
+}
+
+// outletInScope reports whether the user may work on a document of the
+// outlet: Super Admin works on every outlet, other users their own outlet
+func (h *Handlers) outletInScope(claims *models.Claims, outletID int64) bool {
+	scope, ok := h.outletScope(claims)
+	if !ok {
+		return false
+	}
+	return scope == nil || *scope == outletID
+}
+
+// transactionInScope loads the balance of a transaction the user may work on
+func (h *Handlers) transactionInScope(claims *models.Claims, transactionID int64) (*models.TransactionBalance, bool) {
+	balance, err := h.services.Return.GetBalance(transactionID)
+	if err != nil || !h.outletInScope(claims, balance.OutletID) {
+		return nil, false
+	}
+	return balance, true
+}
+
+// parseDateRange reads date_from and date_to (YYYY-MM-DD) query parameters
+func parseDateRange(c *fiber.Ctx) (from, to *time.Time) {
+	if value := c.Query("date_from"); value != "" {
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			from = &date
+		}
+	}
+	if value := c.Query("date_to"); value != "" {
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			to = &date
+		}
+	}
+	return from, to
+}
+
+// queryID reads an optional positive ID query parameter
+func queryID(c *fiber.Ctx, key string) *int64 {
+	if value := c.QueryInt(key, 0); value > 0 {
+		id := int64(value)
+		return &id
+	}
+	return nil
+}
+
+// @Summary Get transaction balance
+// @Description Get what a transaction is owed after returns and what has been paid towards it after refunds and store credit
+// @Tags Transactions
+// @Security Bearer
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} models.Response{data=models.TransactionBalance}
+// @Failure 404 {object} models.Response
+// @Router /transactions/{id}/balance [get]
+func (h *Handlers) getTransactionBalance(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid transaction ID",
+		})
+	}
+
+	balance, ok := h.transactionInScope(claims, int64(id))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Transaction not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Transaction balance retrieved successfully",
+		Data:    balance,
+	})
+}
+
+// @Summary Get sales returns
+// @Description Get sales returns, newest first. Non-admin users see their own outlet only.
+// @Tags Returns
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Filter by outlet (Super Admin only)"
+// @Param transaction_id query int false "Filter by transaction"
+// @Param customer_id query int false "Filter by customer"
+// @Param date_from query string false "Returned on or after (YYYY-MM-DD)"
+// @Param date_to query string false "Returned on or before (YYYY-MM-DD)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.SalesReturn}
+// @Failure 500 {object} models.Response
+// @Router /returns [get]
+func (h *Handlers) getSalesReturns(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.SalesReturnFilter{
+		OutletID:      h.resolveOutletID(c, claims),
+		TransactionID: queryID(c, "transaction_id"),
+		CustomerID:    queryID(c, "customer_id"),
+	}
+	filter.DateFrom, filter.DateTo = parseDateRange(c)
+
+	returns, meta, err := h.services.Return.ListReturns(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get returns",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Returns retrieved successfully",
+		Data:    returns,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get sales return
+// @Description Get a sales return with its items and the refund or credit note that settled it
+// @Tags Returns
+// @Security Bearer
+// @Param id path int true "Return ID"
+// @Success 200 {object} models.Response{data=models.SalesReturn}
+// @Failure 404 {object} models.Response
+// @Router /returns/{id} [get]
+func (h *Handlers) getSalesReturnByID(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid return ID",
+		})
+	}
+
+	ret, err := h.services.Return.GetReturn(int64(id))
+	if err != nil || !h.outletInScope(claims, ret.OutletID) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Return not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Return retrieved successfully",
+		Data:    ret,
+	})
+}
+
+// @Summary Create sales return
+// @Description Return sold spareparts. The returned value takes its share of the transaction's discount and tax. Items are restocked unless restock is false. What the customer already paid for the items is settled by refund or credit note, and the transaction's payment status is recalculated.
+// @Tags Returns
+// @Security Bearer
+// @Param request body models.CreateSalesReturnRequest true "Returned items"
+// @Success 201 {object} models.Response{data=models.SalesReturn}
+// @Failure 400 {object} models.Response
+// @Router /returns [post]
+func (h *Handlers) createSalesReturn(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.CreateSalesReturnRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if _, ok := h.transactionInScope(claims, req.TransactionID); !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Transaction not found",
+		})
+	}
+
+	// Refunds are paid out of the user's open cashier shift, if any
+	ret, err := h.services.Return.CreateReturn(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create return",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Return created successfully",
+		Data:    ret,
+	})
+}
+
+// @Summary Get refunds
+// @Description Get refunds, newest first. Non-admin users see their own outlet only.
+// @Tags Returns
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Filter by outlet (Super Admin only)"
+// @Param transaction_id query int false "Filter by transaction"
+// @Param payment_id query int false "Filter by payment"
+// @Param date_from query string false "Refunded on or after (YYYY-MM-DD)"
+// @Param date_to query string false "Refunded on or before (YYYY-MM-DD)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.Refund}
+// @Failure 500 {object} models.Response
+// @Router /refunds [get]
+func (h *Handlers) getRefunds(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.RefundFilter{
+		OutletID:      h.resolveOutletID(c, claims),
+		TransactionID: queryID(c, "transaction_id"),
+		PaymentID:     queryID(c, "payment_id"),
+	}
+	filter.DateFrom, filter.DateTo = parseDateRange(c)
+
+	refunds, meta, err := h.services.Return.ListRefunds(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get refunds",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Refunds retrieved successfully",
+		Data:    refunds,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get refund
+// @Description Get a refund
+// @Tags Returns
+// @Security Bearer
+// @Param id path int true "Refund ID"
+// @Success 200 {object} models.Response{data=models.Refund}
+// @Failure 404 {object} models.Response
+// @Router /refunds/{id} [get]
+func (h *Handlers) getRefundByID(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid refund ID",
+		})
+	}
+
+	refund, err := h.services.Return.GetRefund(int64(id))
+	if err != nil || !h.outletInScope(claims, refund.OutletID) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Refund not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Refund retrieved successfully",
+		Data:    refund,
+	})
+}
+
+// @Summary Create refund
+// @Description Pay money back to a customer with a reason and payment method, optionally against one of the transaction's payments. A refund cannot exceed what has been paid, and the transaction's payment status is recalculated.
+// @Tags Returns
+// @Security Bearer
+// @Param request body models.CreateRefundRequest true "Refund"
+// @Success 201 {object} models.Response{data=models.Refund}
+// @Failure 400 {object} models.Response
+// @Router /refunds [post]
+func (h *Handlers) createRefund(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.CreateRefundRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if _, ok := h.transactionInScope(claims, req.TransactionID); !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Transaction not found",
+		})
+	}
+
+	refund, err := h.services.Return.CreateRefund(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create refund",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Refund created successfully",
+		Data:    refund,
+	})
+}
+
+// @Summary Get credit notes
+// @Description Get credit notes, newest first. Non-admin users see their own outlet only.
+// @Tags Returns
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Filter by outlet (Super Admin only)"
+// @Param customer_id query int false "Filter by customer"
+// @Param status query string false "Filter by status (open, applied)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.CreditNote}
+// @Failure 500 {object} models.Response
+// @Router /credit-notes [get]
+func (h *Handlers) getCreditNotes(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.CreditNoteFilter{
+		OutletID:   h.resolveOutletID(c, claims),
+		CustomerID: queryID(c, "customer_id"),
+		Status:     c.Query("status", ""),
+	}
+
+	notes, meta, err := h.services.Return.ListCreditNotes(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get credit notes",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Credit notes retrieved successfully",
+		Data:    notes,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get credit note
+// @Description Get a credit note with the transactions it has been applied to
+// @Tags Returns
+// @Security Bearer
+// @Param id path int true "Credit note ID"
+// @Success 200 {object} models.Response{data=models.CreditNote}
+// @Failure 404 {object} models.Response
+// @Router /credit-notes/{id} [get]
+func (h *Handlers) getCreditNoteByID(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid credit note ID",
+		})
+	}
+
+	note, err := h.services.Return.GetCreditNote(int64(id))
+	if err != nil || !h.outletInScope(claims, note.OutletID) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Credit note not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Credit note retrieved successfully",
+		Data:    note,
+	})
+}
+
+// @Summary Apply credit note
+// @Description Pay a transaction of the same customer with store credit. Without an amount, applies the lesser of the remaining credit and the amount outstanding. Store credit can be used at any outlet.
+// @Tags Returns
+// @Security Bearer
+// @Param id path int true "Credit note ID"
+// @Param request body models.ApplyCreditNoteRequest true "Transaction to pay"
+// @Success 200 {object} models.Response{data=models.CreditNote}
+// @Failure 400 {object} models.Response
+// @Router /credit-notes/{id}/apply [post]
+func (h *Handlers) applyCreditNote(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid credit note ID",
+		})
+	}
+
+	var req models.ApplyCreditNoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if _, ok := h.transactionInScope(claims, req.TransactionID); !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Transaction not found",
+		})
+	}
+
+	note, err := h.services.Return.ApplyCreditNote(int64(id), &req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to apply credit note",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Credit note applied successfully",
+		Data:    note,
+	})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupSchedulerRoutes sets up background job scheduler routes
+func (h *Handlers) setupSchedulerRoutes(schedulerGroup fiber.Router) {
+	schedulerGroup.Get("/jobs", h.requirePermission("scheduler.read"), h.getSchedulerJobs)
+	schedulerGroup.Post("/jobs/:name/run", h.requirePermission("scheduler.update"), h.runSchedulerJob)
+	schedulerGroup.Get("/runs", h.requirePermission("scheduler.read"), h.getSchedulerRuns)
+}
+
+// @Summary Get scheduled jobs
+// @Description Get the registered background jobs with their schedule, next run and last run
+// @Tags Scheduler
+// @Security Bearer
+// @Success 200 {object} models.Response{data=[]models.SchedulerJob}
+// @Failure 500 {object} models.Response
+// @Router /scheduler/jobs [get]
+func (h *Handlers) getSchedulerJobs(c *fiber.Ctx) error {
+	jobs, err := h.services.Scheduler.Jobs()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get scheduled jobs",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Scheduled jobs retrieved successfully",
+		Data:    jobs,
+	})
+}
+
+// @Summary Run scheduled job
+// @Description Start a background job now, outside its schedule. The run is skipped if another instance is running the job.
+// @Tags Scheduler
+// @Security Bearer
+// @Param name path string true "Job name"
+// @Success 202 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Router /scheduler/jobs/{name}/run [post]
+func (h *Handlers) runSchedulerJob(c *fiber.Ctx) error {
+	if err := h.services.Scheduler.RunNow(c.Params("name")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to run scheduled job",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(models.Response{
+		Success: true,
+		Message: "Scheduled job started",
+	})
+}
+
+// @Summary Get scheduled job runs
+// @Description Get the background job run history with duration and errors
+// @Tags Scheduler
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param job_name query string false "Filter by job name"
+// @Param status query string false "Filter by status (running, succeeded, failed)"
+// @Param trigger query string false "Filter by trigger (schedule, manual)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.SchedulerRun}
+// @Failure 500 {object} models.Response
+// @Router /scheduler/runs [get]
+func (h *Handlers) getSchedulerRuns(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.SchedulerRunFilter{
+		JobName: c.Query("job_name", ""),
+		Status:  c.Query("status", ""),
+		Trigger: c.Query("trigger", ""),
+	}
+
+	runs, meta, err := h.services.Scheduler.Runs(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get scheduled job runs",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Scheduled job runs retrieved successfully",
+		Data:    runs,
+		Meta:    *meta,
+	})
+}
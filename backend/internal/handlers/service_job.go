@@ -10,11 +10,19 @@ import (
 // setupServiceJobRoutes sets up service job management routes
 func (h *Handlers) setupServiceJobRoutes(serviceJobs fiber.Router) {
 	serviceJobs.Get("/", h.requirePermission("service_jobs.read"), h.getServiceJobs)
+	serviceJobs.Get("/queue", h.requirePermission("service_jobs.read"), h.getServiceQueue)
 	serviceJobs.Get("/:id", h.requirePermission("service_jobs.read"), h.getServiceJobByID)
+	serviceJobs.Get("/:id/eta", h.requirePermission("service_jobs.read"), h.getServiceJobETA)
 	serviceJobs.Post("/", h.requirePermission("service_jobs.create"), h.createServiceJob)
 	serviceJobs.Put("/:id", h.requirePermission("service_jobs.update"), h.updateServiceJob)
 	serviceJobs.Put("/:id/status", h.requirePermission("service_jobs.update"), h.updateServiceJobStatus)
 	serviceJobs.Delete("/:id", h.requirePermission("service_jobs.delete"), h.deleteServiceJob)
+	serviceJobs.Post("/:id/tracking-token", h.requirePermission("service_jobs.update"), h.regenerateTrackingToken)
+	serviceJobs.Delete("/:id/tracking-token", h.requirePermission("service_jobs.update"), h.revokeTrackingToken)
+	serviceJobs.Put("/:id/coupon", h.requirePermission("service_jobs.update"), h.applyServiceJobCoupon)
+	serviceJobs.Delete("/:id/coupon", h.requirePermission("service_jobs.update"), h.removeServiceJobCoupon)
+	serviceJobs.Post("/:id/packages", h.requirePermission("service_jobs.update"), h.addServiceJobPackage)
+	serviceJobs.Delete("/:id/packages/:package_line_id", h.requirePermission("service_jobs.update"), h.removeServiceJobPackage)
 	
 	// Service job details
 	serviceJobs.Get("/:id/details", h.requirePermission("service_jobs.read"), h.getServiceJobDetails)
@@ -325,7 +333,7 @@ func (h *Handlers) getServiceJobDetails(c *fiber.Ctx) error {
 }
 
 // @Summary Add service job detail
-// @Description Add a new detail to a service job
+// @Description Add a new detail to a service job. A line without a unit price takes the price resolved for the job's customer and outlet from the price lists. A price further below that price than the user's role allows is held for approval.
 // @Tags Service Jobs
 // @Security Bearer
 // @Accept json
@@ -335,6 +343,14 @@ func (h *Handlers) getServiceJobDetails(c *fiber.Ctx) error {
 // @Success 201 {object} models.Response{data=models.ServiceDetail}
 // @Router /service-jobs/{id}/details [post]
 func (h *Handlers) addServiceJobDetail(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
 	id, err := c.ParamsInt("id")
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
@@ -352,7 +368,7 @@ func (h *Handlers) addServiceJobDetail(c *fiber.Ctx) error {
 		})
 	}
 
-	detail, err := h.services.ServiceJob.AddDetail(int64(id), &req)
+	detail, err := h.services.ServiceJob.AddDetail(int64(id), &req, claims.UserID)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
 			Success: false,
@@ -369,7 +385,7 @@ func (h *Handlers) addServiceJobDetail(c *fiber.Ctx) error {
 }
 
 // @Summary Update service job detail
-// @Description Update a service job detail
+// @Description Update a service job detail. A price further below the list price than the user's role allows is held for approval.
 // @Tags Service Jobs
 // @Security Bearer
 // @Accept json
@@ -379,6 +395,14 @@ func (h *Handlers) addServiceJobDetail(c *fiber.Ctx) error {
 // @Success 200 {object} models.Response
 // @Router /service-jobs/details/{detail_id} [put]
 func (h *Handlers) updateServiceJobDetail(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
 	detailID, err := c.ParamsInt("detail_id")
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
@@ -396,7 +420,7 @@ func (h *Handlers) updateServiceJobDetail(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.services.ServiceJob.UpdateDetail(int64(detailID), &req); err != nil {
+	if err := h.services.ServiceJob.UpdateDetail(int64(detailID), &req, claims.UserID); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
 			Success: false,
 			Message: "Failed to update service job detail",
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupServicePackageRoutes sets up service package routes
+func (h *Handlers) setupServicePackageRoutes(packages fiber.Router) {
+	packages.Get("/", h.requirePermission("packages.read"), h.getServicePackages)
+	packages.Get("/sales", h.requirePermission("packages.read"), h.getServicePackageSales)
+	packages.Get("/:id", h.requirePermission("packages.read"), h.getServicePackageByID)
+	packages.Post("/", h.requirePermission("packages.manage"), h.createServicePackage)
+	packages.Put("/:id", h.requirePermission("packages.manage"), h.updateServicePackage)
+}
+
+// servicePackageInScope reports whether an outlet user may change a
+// package. Chain-wide packages are left to Super Admin.
+func (h *Handlers) servicePackageInScope(claims *models.Claims, packageID int64) bool {
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return false
+	}
+	if outletID == nil {
+		return true
+	}
+
+	pkg, err := h.services.ServicePackage.GetByID(packageID)
+	return err == nil && pkg.OutletID != nil && *pkg.OutletID == *outletID
+}
+
+// @Summary Get service packages
+// @Description Get the packages sold at the user's outlet with their components and today's price
+// @Tags Service Packages
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Outlet ID (Super Admin only)"
+// @Param active_only query bool false "Only active packages"
+// @Param search query string false "Search by code or name"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.ServicePackage}
+// @Failure 500 {object} models.Response
+// @Router /service-packages [get]
+func (h *Handlers) getServicePackages(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.ServicePackageFilter{
+		OutletID:   h.resolveOutletID(c, claims),
+		ActiveOnly: c.QueryBool("active_only", false),
+		Search:     c.Query("search", ""),
+	}
+
+	packages, meta, err := h.services.ServicePackage.List(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get service packages",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Service packages retrieved successfully",
+		Data:    packages,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get service package
+// @Description Get a package by ID with its components at today's list prices and what one package sells for
+// @Tags Service Packages
+// @Security Bearer
+// @Param id path int true "Package ID"
+// @Success 200 {object} models.Response{data=models.ServicePackage}
+// @Failure 404 {object} models.Response
+// @Router /service-packages/{id} [get]
+func (h *Handlers) getServicePackageByID(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid package ID",
+		})
+	}
+
+	pkg, err := h.services.ServicePackage.GetByID(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Service package not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Service package retrieved successfully",
+		Data:    pkg,
+	})
+}
+
+// @Summary Create service package
+// @Description Create a package of services and products priced at a fixed bundle price or a percentage off its components' list prices. Outlet users can only create packages for their own outlet.
+// @Tags Service Packages
+// @Security Bearer
+// @Param request body models.ServicePackageRequest true "Package"
+// @Success 201 {object} models.Response{data=models.ServicePackage}
+// @Failure 400 {object} models.Response
+// @Router /service-packages [post]
+func (h *Handlers) createServicePackage(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.ServicePackageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+	if outletID != nil {
+		req.OutletID = outletID
+	}
+
+	pkg, err := h.services.ServicePackage.Create(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create service package",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Service package created successfully",
+		Data:    pkg,
+	})
+}
+
+// @Summary Update service package
+// @Description Update a package and replace its components. Transactions and service jobs it was already added to keep their lines and prices.
+// @Tags Service Packages
+// @Security Bearer
+// @Param id path int true "Package ID"
+// @Param request body models.ServicePackageRequest true "Package"
+// @Success 200 {object} models.Response{data=models.ServicePackage}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /service-packages/{id} [put]
+func (h *Handlers) updateServicePackage(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid package ID",
+		})
+	}
+
+	var req models.ServicePackageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if !h.servicePackageInScope(claims, int64(id)) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Service package not found",
+		})
+	}
+	if outletID, _ := h.outletScope(claims); outletID != nil {
+		req.OutletID = outletID
+	}
+
+	pkg, err := h.services.ServicePackage.Update(int64(id), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update service package",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Service package updated successfully",
+		Data:    pkg,
+	})
+}
+
+// @Summary Get package sales
+// @Description Get the packages sold over a period with what they sold for against their components' list prices, and each component's allocated share. Voided transactions are left out; non-admin users see their own outlet only.
+// @Tags Service Packages
+// @Security Bearer
+// @Param outlet_id query int false "Filter by outlet (Super Admin only)"
+// @Param package_id query int false "Package ID"
+// @Param date_from query string false "Start date (YYYY-MM-DD), defaults to the first of the month"
+// @Param date_to query string false "End date (YYYY-MM-DD), defaults to the end of the month"
+// @Success 200 {object} models.Response{data=[]models.PackageSales}
+// @Failure 400 {object} models.Response
+// @Router /service-packages/sales [get]
+func (h *Handlers) getServicePackageSales(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	from, to, err := parseLedgerPeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	filter := &models.PackageSalesFilter{
+		PackageID: queryID(c, "package_id"),
+		OutletID:  h.resolveOutletID(c, claims),
+		DateFrom:  from,
+		DateTo:    to,
+	}
+
+	sales, err := h.services.ServicePackage.GetSales(filter)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get package sales",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Package sales retrieved successfully",
+		Data:    sales,
+	})
+}
+
+// @Summary Add package to service job
+// @Description Put a package on a service job. It is added as its component lines, each priced at its share of the package in proportion to its list price, and the job is repriced.
+// @Tags Service Jobs
+// @Security Bearer
+// @Param id path int true "Service Job ID"
+// @Param request body models.AddServiceJobPackageRequest true "Package"
+// @Success 200 {object} models.Response{data=models.ServiceJob}
+// @Failure 400 {object} models.Response
+// @Router /service-jobs/{id}/packages [post]
+func (h *Handlers) addServiceJobPackage(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid service job ID",
+		})
+	}
+
+	var req models.AddServiceJobPackageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+
+	serviceJob, err := h.services.ServiceJob.AddPackage(int64(id), &req, outletID, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to add package",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Package added successfully",
+		Data:    serviceJob,
+	})
+}
+
+// @Summary Remove package from service job
+// @Description Take a package and its component lines off a service job and reprice it
+// @Tags Service Jobs
+// @Security Bearer
+// @Param id path int true "Service Job ID"
+// @Param package_line_id path int true "Service job package ID"
+// @Success 200 {object} models.Response{data=models.ServiceJob}
+// @Failure 400 {object} models.Response
+// @Router /service-jobs/{id}/packages/{package_line_id} [delete]
+func (h *Handlers) removeServiceJobPackage(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid service job ID",
+		})
+	}
+
+	packageLineID, err := c.ParamsInt("package_line_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid service job package ID",
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+
+	serviceJob, err := h.services.ServiceJob.RemovePackage(int64(id), int64(packageLineID), outletID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to remove package",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Package removed successfully",
+		Data:    serviceJob,
+	})
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"time"
+
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupShiftRoutes sets up cashier shift routes
+func (h *Handlers) setupShiftRoutes(shifts fiber.Router) {
+	shifts.Get("/", h.requirePermission("shifts.read"), h.getShifts)
+	shifts.Post("/open", h.requirePermission("shifts.open"), h.openShift)
+	shifts.Get("/current", h.requirePermission("shifts.open"), h.getCurrentShift)
+	shifts.Post("/:id/close", h.requirePermission("shifts.close"), h.closeShift)
+	shifts.Post("/:id/approve", h.requirePermission("shifts.approve"), h.approveShift)
+	shifts.Get("/:id/report", h.getShiftReport)
+}
+
+// hasPermission reports whether the user's token carries the permission
+func hasPermission(claims *models.Claims, permission string) bool {
+	for _, p := range claims.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// shiftInScope reports whether the user may see the shift: Super Admin sees
+// every outlet, other users their own outlet
+func (h *Handlers) shiftInScope(claims *models.Claims, shift *models.CashierShift) bool {
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return false
+	}
+	return outletID == nil || *outletID == shift.OutletID
+}
+
+// @Summary Get cashier shifts
+// @Description Get cashier shifts, newest first. Non-admin users see their own outlet only.
+// @Tags Shifts
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param outlet_id query int false "Filter by outlet (Super Admin only)"
+// @Param user_id query int false "Filter by cashier"
+// @Param status query string false "Filter by status (open, closed, approved)"
+// @Param date_from query string false "Opened on or after (YYYY-MM-DD)"
+// @Param date_to query string false "Opened on or before (YYYY-MM-DD)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.CashierShift}
+// @Failure 500 {object} models.Response
+// @Router /shifts [get]
+func (h *Handlers) getShifts(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.ShiftFilter{
+		OutletID: h.resolveOutletID(c, claims),
+		Status:   c.Query("status", ""),
+	}
+	if userIDParam := c.QueryInt("user_id", 0); userIDParam > 0 {
+		userID := int64(userIDParam)
+		filter.UserID = &userID
+	}
+	if value := c.Query("date_from"); value != "" {
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			filter.DateFrom = &date
+		}
+	}
+	if value := c.Query("date_to"); value != "" {
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			filter.DateTo = &date
+		}
+	}
+
+	shifts, meta, err := h.services.Shift.List(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get shifts",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Shifts retrieved successfully",
+		Data:    shifts,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Open cashier shift
+// @Description Open a shift for the current user with the float placed in the drawer. Payments the user takes are recorded in the shift until it is closed.
+// @Tags Shifts
+// @Security Bearer
+// @Param request body models.OpenShiftRequest true "Opening float"
+// @Success 201 {object} models.Response{data=models.CashierShift}
+// @Failure 400 {object} models.Response
+// @Router /shifts/open [post]
+func (h *Handlers) openShift(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.OpenShiftRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID := claims.OutletID
+	if claims.RoleID == 1 && req.OutletID != nil { // Super Admin
+		outletID = req.OutletID
+	}
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Outlet is required",
+		})
+	}
+
+	shift, err := h.services.Shift.Open(&req, *outletID, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to open shift",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Shift opened successfully",
+		Data:    shift,
+	})
+}
+
+// @Summary Get current shift
+// @Description Get the current user's open shift
+// @Tags Shifts
+// @Security Bearer
+// @Success 200 {object} models.Response{data=models.CashierShift}
+// @Failure 404 {object} models.Response
+// @Router /shifts/current [get]
+func (h *Handlers) getCurrentShift(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	shift, err := h.services.Shift.GetCurrent(claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "No open shift",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Shift retrieved successfully",
+		Data:    shift,
+	})
+}
+
+// @Summary Close cashier shift
+// @Description Close a shift with the drawer counted by denomination and the totals of non-cash payment methods. Returns the shift report with expected vs counted variance per payment method. Users close their own shift; approvers may close any shift at their outlet.
+// @Tags Shifts
+// @Security Bearer
+// @Param id path int true "Shift ID"
+// @Param request body models.CloseShiftRequest true "Counted drawer"
+// @Success 200 {object} models.Response{data=models.ShiftReport}
+// @Failure 400 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Router /shifts/{id}/close [post]
+func (h *Handlers) closeShift(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid shift ID",
+		})
+	}
+
+	var req models.CloseShiftRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	shift, err := h.services.Shift.GetByID(int64(id))
+	if err != nil || !h.shiftInScope(claims, shift) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Shift not found",
+		})
+	}
+	if shift.UserID != claims.UserID && !hasPermission(claims, "shifts.approve") {
+		return c.Status(fiber.StatusForbidden).JSON(models.Response{
+			Success: false,
+			Message: "Only the shift's cashier can close it",
+		})
+	}
+
+	report, err := h.services.Shift.Close(shift.ShiftID, &req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to close shift",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Shift closed successfully",
+		Data:    report,
+	})
+}
+
+// @Summary Approve cashier shift
+// @Description Sign off a closed shift and its variance. Cashiers cannot approve their own shift.
+// @Tags Shifts
+// @Security Bearer
+// @Param id path int true "Shift ID"
+// @Param request body models.ApproveShiftRequest false "Approval notes"
+// @Success 200 {object} models.Response{data=models.CashierShift}
+// @Failure 400 {object} models.Response
+// @Router /shifts/{id}/approve [post]
+func (h *Handlers) approveShift(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid shift ID",
+		})
+	}
+
+	var req models.ApproveShiftRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+				Success: false,
+				Message: "Invalid request body",
+				Error:   err.Error(),
+			})
+		}
+	}
+
+	shift, err := h.services.Shift.GetByID(int64(id))
+	if err != nil || !h.shiftInScope(claims, shift) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Shift not found",
+		})
+	}
+
+	shift, err = h.services.Shift.Approve(shift.ShiftID, &req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to approve shift",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Shift approved successfully",
+		Data:    shift,
+	})
+}
+
+// @Summary Get shift report
+// @Description Get a shift's payments, cash reconciliation and expected vs counted totals per payment method. Open shifts show running totals. Cashiers can see their own shifts; shifts.read is needed for others.
+// @Tags Shifts
+// @Security Bearer
+// @Param id path int true "Shift ID"
+// @Success 200 {object} models.Response{data=models.ShiftReport}
+// @Failure 404 {object} models.Response
+// @Router /shifts/{id}/report [get]
+func (h *Handlers) getShiftReport(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid shift ID",
+		})
+	}
+
+	report, err := h.services.Shift.GetReport(int64(id))
+	if err != nil || !h.shiftInScope(claims, &report.Shift) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Shift not found",
+		})
+	}
+	if report.Shift.UserID != claims.UserID && !hasPermission(claims, "shifts.read") {
+		return c.Status(fiber.StatusForbidden).JSON(models.Response{
+			Success: false,
+			Message: "Insufficient permissions",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Shift report retrieved successfully",
+		Data:    report,
+	})
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupStoredValueRoutes sets up customer deposit and gift voucher routes.
+// Both are spent as the "stored_value" payment method: the payment's
+// reference number is the voucher code, or blank to pay from the
+// customer's deposit.
+func (h *Handlers) setupStoredValueRoutes(storedValue fiber.Router) {
+	storedValue.Get("/accounts", h.requirePermission("stored_value.read"), h.getStoredValueAccounts)
+	storedValue.Get("/accounts/:id", h.requirePermission("stored_value.read"), h.getStoredValueAccount)
+	storedValue.Get("/accounts/:id/entries", h.requirePermission("stored_value.read"), h.getStoredValueEntries)
+	storedValue.Post("/accounts/:id/top-ups", h.requirePermission("stored_value.sell"), h.topUpStoredValue)
+
+	// Gift vouchers
+	storedValue.Post("/vouchers", h.requirePermission("stored_value.sell"), h.issueVoucher)
+	storedValue.Get("/vouchers/:code", h.requirePermission("stored_value.read"), h.getVoucher)
+
+	// Customer deposits
+	storedValue.Get("/customers/:customer_id", h.requirePermission("stored_value.read"), h.getCustomerDeposit)
+	storedValue.Post("/customers/:customer_id/top-ups", h.requirePermission("stored_value.sell"), h.topUpCustomerDeposit)
+}
+
+// storedValueOutlet returns the outlet stored value is sold at: the user's
+// own, or the one asked for by Super Admin
+func storedValueOutlet(claims *models.Claims, requested *int64) *int64 {
+	if claims.RoleID == 1 && requested != nil { // Super Admin
+		return requested
+	}
+	return claims.OutletID
+}
+
+// @Summary Get deposits and vouchers
+// @Description Get deposits and vouchers, newest first, with what was topped up, redeemed and lapsed on each
+// @Tags Stored Value
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param account_type query string false "deposit or voucher"
+// @Param customer_id query int false "Customer ID"
+// @Param status query string false "active, used or expired"
+// @Param search query string false "Search by voucher code or customer name"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.StoredValueAccount}
+// @Failure 500 {object} models.Response
+// @Router /stored-value/accounts [get]
+func (h *Handlers) getStoredValueAccounts(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.StoredValueFilter{
+		AccountType: c.Query("account_type", ""),
+		Status:      c.Query("status", ""),
+		Search:      c.Query("search", ""),
+	}
+	if customerID := c.QueryInt("customer_id", 0); customerID > 0 {
+		id := int64(customerID)
+		filter.CustomerID = &id
+	}
+
+	accounts, meta, err := h.services.StoredValue.List(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get deposits and vouchers",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Deposits and vouchers retrieved successfully",
+		Data:    accounts,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get deposit or voucher
+// @Description Get a deposit or voucher by ID with its balance
+// @Tags Stored Value
+// @Security Bearer
+// @Param id path int true "Account ID"
+// @Success 200 {object} models.Response{data=models.StoredValueAccount}
+// @Failure 404 {object} models.Response
+// @Router /stored-value/accounts/{id} [get]
+func (h *Handlers) getStoredValueAccount(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid account ID",
+		})
+	}
+
+	account, err := h.services.StoredValue.GetByID(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Deposit or voucher not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Deposit or voucher retrieved successfully",
+		Data:    account,
+	})
+}
+
+// @Summary Get stored value statement
+// @Description Get the top-ups, redemptions, reversals and expiries of a deposit or voucher, newest first, with the balance after each
+// @Tags Stored Value
+// @Security Bearer
+// @Param id path int true "Account ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} models.PaginatedResponse{data=[]models.StoredValueEntry}
+// @Failure 404 {object} models.Response
+// @Router /stored-value/accounts/{id}/entries [get]
+func (h *Handlers) getStoredValueEntries(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid account ID",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	entries, meta, err := h.services.StoredValue.ListEntries(int64(id), page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get stored value statement",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Stored value statement retrieved successfully",
+		Data:    entries,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Top up deposit or voucher
+// @Description Add value paid in at the outlet to a deposit or multi-use voucher. The money is recorded in the cashier's open shift and held as a liability until spent. expires_at replaces the account's expiry.
+// @Tags Stored Value
+// @Security Bearer
+// @Param id path int true "Account ID"
+// @Param request body models.StoredValueTopUpRequest true "Top-up"
+// @Success 200 {object} models.Response{data=models.StoredValueAccount}
+// @Failure 400 {object} models.Response
+// @Router /stored-value/accounts/{id}/top-ups [post]
+func (h *Handlers) topUpStoredValue(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid account ID",
+		})
+	}
+
+	var req models.StoredValueTopUpRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID := storedValueOutlet(claims, req.OutletID)
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Outlet is required",
+		})
+	}
+
+	account, err := h.services.StoredValue.TopUp(int64(id), &req, *outletID, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to top up",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Topped up successfully",
+		Data:    account,
+	})
+}
+
+// @Summary Issue voucher
+// @Description Sell a gift voucher for the amount paid. A single-use voucher is spent in one payment and what is left of it lapses; a multi-use voucher can be spent in parts and topped up. Without a code one is generated.
+// @Tags Stored Value
+// @Security Bearer
+// @Param request body models.IssueVoucherRequest true "Voucher"
+// @Success 201 {object} models.Response{data=models.StoredValueAccount}
+// @Failure 400 {object} models.Response
+// @Router /stored-value/vouchers [post]
+func (h *Handlers) issueVoucher(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.IssueVoucherRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID := storedValueOutlet(claims, req.OutletID)
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Outlet is required",
+		})
+	}
+
+	voucher, err := h.services.StoredValue.IssueVoucher(&req, *outletID, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to issue voucher",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Voucher issued successfully",
+		Data:    voucher,
+	})
+}
+
+// @Summary Get voucher
+// @Description Look up a voucher by code to check its balance, status and expiry
+// @Tags Stored Value
+// @Security Bearer
+// @Param code path string true "Voucher code"
+// @Success 200 {object} models.Response{data=models.StoredValueAccount}
+// @Failure 404 {object} models.Response
+// @Router /stored-value/vouchers/{code} [get]
+func (h *Handlers) getVoucher(c *fiber.Ctx) error {
+	voucher, err := h.services.StoredValue.GetByCode(c.Params("code"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Voucher not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Voucher retrieved successfully",
+		Data:    voucher,
+	})
+}
+
+// @Summary Get customer deposit
+// @Description Get a customer's deposit and its balance
+// @Tags Stored Value
+// @Security Bearer
+// @Param customer_id path int true "Customer ID"
+// @Success 200 {object} models.Response{data=models.StoredValueAccount}
+// @Failure 404 {object} models.Response
+// @Router /stored-value/customers/{customer_id} [get]
+func (h *Handlers) getCustomerDeposit(c *fiber.Ctx) error {
+	customerID, err := c.ParamsInt("customer_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid customer ID",
+		})
+	}
+
+	deposit, err := h.services.StoredValue.GetDeposit(int64(customerID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Customer deposit not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Customer deposit retrieved successfully",
+		Data:    deposit,
+	})
+}
+
+// @Summary Top up customer deposit
+// @Description Add value paid in at the outlet to a customer's deposit, opening it on their first top-up. The money is recorded in the cashier's open shift and held as a liability until spent.
+// @Tags Stored Value
+// @Security Bearer
+// @Param customer_id path int true "Customer ID"
+// @Param request body models.StoredValueTopUpRequest true "Top-up"
+// @Success 200 {object} models.Response{data=models.StoredValueAccount}
+// @Failure 400 {object} models.Response
+// @Router /stored-value/customers/{customer_id}/top-ups [post]
+func (h *Handlers) topUpCustomerDeposit(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	customerID, err := c.ParamsInt("customer_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid customer ID",
+		})
+	}
+
+	var req models.StoredValueTopUpRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	outletID := storedValueOutlet(claims, req.OutletID)
+	if outletID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Outlet is required",
+		})
+	}
+
+	deposit, err := h.services.StoredValue.TopUpDeposit(int64(customerID), &req, *outletID, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to top up deposit",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Deposit topped up successfully",
+		Data:    deposit,
+	})
+}
@@ -0,0 +1,398 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupTaxRoutes sets up tax code, outlet tax setting and tax report routes
+func (h *Handlers) setupTaxRoutes(taxes fiber.Router) {
+	// Tax codes
+	taxes.Get("/codes", h.requirePermission("taxes.read"), h.getTaxCodes)
+	taxes.Get("/codes/:id", h.requirePermission("taxes.read"), h.getTaxCodeByID)
+	taxes.Post("/codes", h.requirePermission("taxes.manage"), h.createTaxCode)
+	taxes.Put("/codes/:id", h.requirePermission("taxes.manage"), h.updateTaxCode)
+	taxes.Post("/codes/:id/assign", h.requirePermission("taxes.manage"), h.assignTaxCode)
+
+	// Outlet settings
+	taxes.Get("/outlets/:outlet_id/settings", h.requirePermission("taxes.read"), h.getOutletTaxSettings)
+	taxes.Put("/outlets/:outlet_id/settings", h.requirePermission("taxes.manage"), h.updateOutletTaxSettings)
+
+	// Summaries and reports
+	taxes.Get("/transactions/:id/summary", h.requirePermission("transactions.read"), h.getTransactionTaxSummary)
+	taxes.Get("/report", h.requirePermission("taxes.read"), h.getTaxReport)
+}
+
+// @Summary Get tax codes
+// @Description Get tax codes ordered by rate
+// @Tags Taxes
+// @Security Bearer
+// @Param active_only query bool false "Only active tax codes"
+// @Success 200 {object} models.Response{data=[]models.TaxCode}
+// @Failure 500 {object} models.Response
+// @Router /taxes/codes [get]
+func (h *Handlers) getTaxCodes(c *fiber.Ctx) error {
+	codes, err := h.services.Tax.ListCodes(c.QueryBool("active_only", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get tax codes",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Tax codes retrieved successfully",
+		Data:    codes,
+	})
+}
+
+// @Summary Get tax code
+// @Description Get a tax code by ID
+// @Tags Taxes
+// @Security Bearer
+// @Param id path int true "Tax code ID"
+// @Success 200 {object} models.Response{data=models.TaxCode}
+// @Failure 404 {object} models.Response
+// @Router /taxes/codes/{id} [get]
+func (h *Handlers) getTaxCodeByID(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid tax code ID",
+		})
+	}
+
+	code, err := h.services.Tax.GetCode(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Tax code not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Tax code retrieved successfully",
+		Data:    code,
+	})
+}
+
+// @Summary Create tax code
+// @Description Create a tax code. The rate is a percentage; use 0 for exempt items.
+// @Tags Taxes
+// @Security Bearer
+// @Param request body models.CreateTaxCodeRequest true "Tax code"
+// @Success 201 {object} models.Response{data=models.TaxCode}
+// @Failure 400 {object} models.Response
+// @Router /taxes/codes [post]
+func (h *Handlers) createTaxCode(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.CreateTaxCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	code, err := h.services.Tax.CreateCode(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create tax code",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Tax code created successfully",
+		Data:    code,
+	})
+}
+
+// @Summary Update tax code
+// @Description Update a tax code. A new rate applies to documents created afterwards; existing ones keep the rate they were taxed at.
+// @Tags Taxes
+// @Security Bearer
+// @Param id path int true "Tax code ID"
+// @Param request body models.UpdateTaxCodeRequest true "Tax code"
+// @Success 200 {object} models.Response{data=models.TaxCode}
+// @Failure 400 {object} models.Response
+// @Router /taxes/codes/{id} [put]
+func (h *Handlers) updateTaxCode(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid tax code ID",
+		})
+	}
+
+	var req models.UpdateTaxCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	code, err := h.services.Tax.UpdateCode(int64(id), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update tax code",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Tax code updated successfully",
+		Data:    code,
+	})
+}
+
+// @Summary Assign tax code
+// @Description Assign a tax code to products and services
+// @Tags Taxes
+// @Security Bearer
+// @Param id path int true "Tax code ID"
+// @Param request body models.AssignTaxCodeRequest true "Products and services"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Router /taxes/codes/{id}/assign [post]
+func (h *Handlers) assignTaxCode(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid tax code ID",
+		})
+	}
+
+	var req models.AssignTaxCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	updated, err := h.services.Tax.AssignCode(int64(id), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to assign tax code",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Tax code assigned successfully",
+		Data:    fiber.Map{"updated": updated},
+	})
+}
+
+// @Summary Get outlet tax settings
+// @Description Get whether an outlet's prices include tax and the tax code of items without one of their own
+// @Tags Taxes
+// @Security Bearer
+// @Param outlet_id path int true "Outlet ID"
+// @Success 200 {object} models.Response{data=models.OutletTaxSettings}
+// @Failure 404 {object} models.Response
+// @Router /taxes/outlets/{outlet_id}/settings [get]
+func (h *Handlers) getOutletTaxSettings(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	outletID, err := c.ParamsInt("outlet_id")
+	if err != nil || !h.outletInScope(claims, int64(outletID)) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Outlet not found",
+		})
+	}
+
+	settings, err := h.services.Tax.GetOutletSettings(int64(outletID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Outlet not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Outlet tax settings retrieved successfully",
+		Data:    settings,
+	})
+}
+
+// @Summary Update outlet tax settings
+// @Description Switch an outlet between tax-inclusive and tax-exclusive prices and set its default tax code. Existing transactions keep the pricing they were created with.
+// @Tags Taxes
+// @Security Bearer
+// @Param outlet_id path int true "Outlet ID"
+// @Param request body models.UpdateOutletTaxSettingsRequest true "Tax settings"
+// @Success 200 {object} models.Response{data=models.OutletTaxSettings}
+// @Failure 400 {object} models.Response
+// @Router /taxes/outlets/{outlet_id}/settings [put]
+func (h *Handlers) updateOutletTaxSettings(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	outletID, err := c.ParamsInt("outlet_id")
+	if err != nil || !h.outletInScope(claims, int64(outletID)) {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Outlet not found",
+		})
+	}
+
+	var req models.UpdateOutletTaxSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	settings, err := h.services.Tax.UpdateOutletSettings(int64(outletID), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update outlet tax settings",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Outlet tax settings updated successfully",
+		Data:    settings,
+	})
+}
+
+// @Summary Get transaction tax summary
+// @Description Get a transaction's taxable value and tax by tax code, as printed on its invoice
+// @Tags Taxes
+// @Security Bearer
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} models.Response{data=[]models.TaxSummaryLine}
+// @Failure 404 {object} models.Response
+// @Router /taxes/transactions/{id}/summary [get]
+func (h *Handlers) getTransactionTaxSummary(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid transaction ID",
+		})
+	}
+
+	if _, ok := h.transactionInScope(claims, int64(id)); !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Transaction not found",
+		})
+	}
+
+	summary, err := h.services.Tax.GetTransactionSummary(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get tax summary",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Tax summary retrieved successfully",
+		Data:    summary,
+	})
+}
+
+// @Summary Get tax report
+// @Description Get output tax by tax code for a period: sales on their transaction date, less returns on their return date and voids on the day they were voided. Defaults to the current month.
+// @Tags Taxes
+// @Security Bearer
+// @Param outlet_id query int false "Outlet (Super Admin only, all outlets when omitted)"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} models.Response{data=models.TaxReport}
+// @Failure 400 {object} models.Response
+// @Router /taxes/report [get]
+func (h *Handlers) getTaxReport(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	from, to, err := parseLedgerPeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid period",
+			Error:   err.Error(),
+		})
+	}
+
+	report, err := h.services.Tax.GetReport(&models.TaxReportFilter{
+		OutletID: h.resolveOutletID(c, claims),
+		DateFrom: from,
+		DateTo:   to,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get tax report",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Tax report retrieved successfully",
+		Data:    report,
+	})
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupPublicTrackingRoutes sets up unauthenticated job tracking routes
+func (h *Handlers) setupPublicTrackingRoutes(track fiber.Router) {
+	track.Get("/:token", h.getJobTracking)
+}
+
+// @Summary Track service job
+// @Description Get the status timeline, ETA and final amount of a service job from its customer tracking link
+// @Tags Tracking
+// @Param token path string true "Tracking token"
+// @Success 200 {object} models.Response{data=models.JobTracking}
+// @Failure 404 {object} models.Response
+// @Router /public/track/{token} [get]
+func (h *Handlers) getJobTracking(c *fiber.Ctx) error {
+	tracking, err := h.services.Tracking.GetByToken(c.Params("token"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Tracking link not found",
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Service job tracking retrieved successfully",
+		Data:    tracking,
+	})
+}
+
+// @Summary Regenerate tracking token
+// @Description Issue a new customer tracking link for a service job, invalidating the previous one
+// @Tags Service Jobs
+// @Security Bearer
+// @Param id path int true "Service Job ID"
+// @Success 200 {object} models.Response{data=models.JobTrackingLink}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /service-jobs/{id}/tracking-token [post]
+func (h *Handlers) regenerateTrackingToken(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid service job ID",
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+
+	link, err := h.services.Tracking.RegenerateToken(int64(id), outletID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Failed to regenerate tracking token",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Tracking token regenerated successfully",
+		Data:    link,
+	})
+}
+
+// @Summary Revoke tracking token
+// @Description Disable the customer tracking link of a service job
+// @Tags Service Jobs
+// @Security Bearer
+// @Param id path int true "Service Job ID"
+// @Success 200 {object} models.Response
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /service-jobs/{id}/tracking-token [delete]
+func (h *Handlers) revokeTrackingToken(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid service job ID",
+		})
+	}
+
+	outletID, ok := h.outletScope(claims)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "User must be assigned to an outlet",
+		})
+	}
+
+	if err := h.services.Tracking.RevokeToken(int64(id), outletID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Failed to revoke tracking token",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Tracking token revoked successfully",
+	})
+}
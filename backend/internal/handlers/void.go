@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Void transaction
+// @Description Void a transaction instead of deleting it. Products not already returned go back to stock, store credit applied to it goes back to its credit notes and what the customer paid is refunded, by default with the method of the last payment. The transaction keeps its number with payment status void.
+// @Tags Transactions
+// @Security Bearer
+// @Param id path int true "Transaction ID"
+// @Param request body models.VoidTransactionRequest true "Void reason"
+// @Success 200 {object} models.Response{data=models.TransactionVoid}
+// @Failure 400 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /transactions/{id}/void [post]
+func (h *Handlers) voidTransaction(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid transaction ID",
+		})
+	}
+
+	var req models.VoidTransactionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	if _, ok := h.transactionInScope(claims, int64(id)); !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Transaction not found",
+		})
+	}
+
+	// Refunds are paid out of the user's open cashier shift, if any
+	void, err := h.services.Transaction.Void(int64(id), &req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to void transaction",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Transaction voided successfully",
+		Data:    void,
+	})
+}
+
+// @Summary Get exceptions report
+// @Description Get the voids, payment reversals, returns and refunds of a period, newest first, with a count and total per kind. Non-admin users see their own outlet only.
+// @Tags Transactions
+// @Security Bearer
+// @Param outlet_id query int false "Filter by outlet (Super Admin only)"
+// @Param type query string false "Filter by kind (void, payment_reversal, sales_return, refund)"
+// @Param date_from query string false "Start date (YYYY-MM-DD), defaults to the first of the month"
+// @Param date_to query string false "End date (YYYY-MM-DD), defaults to the end of the month"
+// @Success 200 {object} models.Response{data=models.ExceptionReport}
+// @Failure 400 {object} models.Response
+// @Router /transactions/exceptions [get]
+func (h *Handlers) getExceptionReport(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	from, to, err := parseLedgerPeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+
+	filter := &models.ExceptionFilter{
+		OutletID: h.resolveOutletID(c, claims),
+		DateFrom: from,
+		DateTo:   to,
+		Type:     c.Query("type", ""),
+	}
+
+	report, err := h.services.Transaction.GetExceptionReport(filter)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get exceptions report",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Exceptions report retrieved successfully",
+		Data:    report,
+	})
+}
@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"flutter-bengkel/internal/middleware"
+	"flutter-bengkel/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setupWebhookRoutes sets up webhook subscription and delivery log routes
+func (h *Handlers) setupWebhookRoutes(webhooks fiber.Router) {
+	webhooks.Get("/deliveries", h.requirePermission("webhooks.read"), h.getWebhookDeliveries)
+	webhooks.Get("/deliveries/:id", h.requirePermission("webhooks.read"), h.getWebhookDelivery)
+	webhooks.Post("/deliveries/:id/redeliver", h.requirePermission("webhooks.update"), h.redeliverWebhook)
+
+	webhooks.Get("/", h.requirePermission("webhooks.read"), h.getWebhookSubscriptions)
+	webhooks.Post("/", h.requirePermission("webhooks.create"), h.createWebhookSubscription)
+	webhooks.Get("/:id", h.requirePermission("webhooks.read"), h.getWebhookSubscription)
+	webhooks.Put("/:id", h.requirePermission("webhooks.update"), h.updateWebhookSubscription)
+	webhooks.Delete("/:id", h.requirePermission("webhooks.delete"), h.deleteWebhookSubscription)
+	webhooks.Post("/:id/rotate-secret", h.requirePermission("webhooks.update"), h.rotateWebhookSecret)
+	webhooks.Post("/:id/ping", h.requirePermission("webhooks.update"), h.pingWebhook)
+}
+
+// @Summary Get webhook subscriptions
+// @Description Get all webhook subscriptions (secrets are not returned)
+// @Tags Webhooks
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} models.PaginatedResponse{data=[]models.WebhookSubscription}
+// @Failure 500 {object} models.Response
+// @Router /webhooks [get]
+func (h *Handlers) getWebhookSubscriptions(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	subscriptions, meta, err := h.services.Webhook.List(page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get webhook subscriptions",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Webhook subscriptions retrieved successfully",
+		Data:    subscriptions,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Create webhook subscription
+// @Description Subscribe a URL to domain events. The signing secret is generated when omitted and only returned in this response.
+// @Tags Webhooks
+// @Security Bearer
+// @Param request body models.CreateWebhookSubscriptionRequest true "Webhook subscription data"
+// @Success 201 {object} models.Response{data=models.WebhookSubscription}
+// @Failure 400 {object} models.Response
+// @Router /webhooks [post]
+func (h *Handlers) createWebhookSubscription(c *fiber.Ctx) error {
+	claims, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.Response{
+			Success: false,
+			Message: "Unauthorized",
+		})
+	}
+
+	var req models.CreateWebhookSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	subscription, err := h.services.Webhook.Create(&req, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to create webhook subscription",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.Response{
+		Success: true,
+		Message: "Webhook subscription created successfully",
+		Data:    subscription,
+	})
+}
+
+// @Summary Get webhook subscription
+// @Description Get a webhook subscription by ID
+// @Tags Webhooks
+// @Security Bearer
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} models.Response{data=models.WebhookSubscription}
+// @Failure 404 {object} models.Response
+// @Router /webhooks/{id} [get]
+func (h *Handlers) getWebhookSubscription(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid subscription ID",
+		})
+	}
+
+	subscription, err := h.services.Webhook.GetByID(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Webhook subscription not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Webhook subscription retrieved successfully",
+		Data:    subscription,
+	})
+}
+
+// @Summary Update webhook subscription
+// @Description Update a webhook subscription's URL, event types, description or active flag
+// @Tags Webhooks
+// @Security Bearer
+// @Param id path int true "Subscription ID"
+// @Param request body models.UpdateWebhookSubscriptionRequest true "Webhook subscription data"
+// @Success 200 {object} models.Response{data=models.WebhookSubscription}
+// @Failure 400 {object} models.Response
+// @Router /webhooks/{id} [put]
+func (h *Handlers) updateWebhookSubscription(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid subscription ID",
+		})
+	}
+
+	var req models.UpdateWebhookSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid request body",
+			Error:   err.Error(),
+		})
+	}
+
+	subscription, err := h.services.Webhook.Update(int64(id), &req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to update webhook subscription",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Webhook subscription updated successfully",
+		Data:    subscription,
+	})
+}
+
+// @Summary Delete webhook subscription
+// @Description Delete a webhook subscription; pending deliveries are no longer sent
+// @Tags Webhooks
+// @Security Bearer
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Router /webhooks/{id} [delete]
+func (h *Handlers) deleteWebhookSubscription(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid subscription ID",
+		})
+	}
+
+	if err := h.services.Webhook.Delete(int64(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Failed to delete webhook subscription",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Webhook subscription deleted successfully",
+	})
+}
+
+// @Summary Rotate webhook secret
+// @Description Replace a subscription's signing secret. The new secret is only returned in this response.
+// @Tags Webhooks
+// @Security Bearer
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} models.Response{data=models.WebhookSubscription}
+// @Failure 404 {object} models.Response
+// @Router /webhooks/{id}/rotate-secret [post]
+func (h *Handlers) rotateWebhookSecret(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid subscription ID",
+		})
+	}
+
+	subscription, err := h.services.Webhook.RotateSecret(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Failed to rotate webhook secret",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Webhook secret rotated successfully",
+		Data:    subscription,
+	})
+}
+
+// @Summary Ping webhook
+// @Description Queue a signed webhook.ping delivery to test the receiver
+// @Tags Webhooks
+// @Security Bearer
+// @Param id path int true "Subscription ID"
+// @Success 202 {object} models.Response{data=models.WebhookDelivery}
+// @Failure 400 {object} models.Response
+// @Router /webhooks/{id}/ping [post]
+func (h *Handlers) pingWebhook(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid subscription ID",
+		})
+	}
+
+	delivery, err := h.services.Webhook.Ping(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to ping webhook",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(models.Response{
+		Success: true,
+		Message: "Webhook ping queued",
+		Data:    delivery,
+	})
+}
+
+// @Summary Get webhook deliveries
+// @Description Get the webhook delivery log with response codes
+// @Tags Webhooks
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param subscription_id query int false "Filter by subscription ID"
+// @Param event_type query string false "Filter by event type"
+// @Param status query string false "Filter by status (pending, sending, delivered, failed)"
+// @Success 200 {object} models.PaginatedResponse{data=[]models.WebhookDelivery}
+// @Failure 500 {object} models.Response
+// @Router /webhooks/deliveries [get]
+func (h *Handlers) getWebhookDeliveries(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 10)
+
+	filter := &models.WebhookDeliveryFilter{
+		EventType: c.Query("event_type", ""),
+		Status:    c.Query("status", ""),
+	}
+	if subscriptionID := c.QueryInt("subscription_id", 0); subscriptionID > 0 {
+		id := int64(subscriptionID)
+		filter.SubscriptionID = &id
+	}
+
+	deliveries, meta, err := h.services.Webhook.ListDeliveries(page, limit, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.Response{
+			Success: false,
+			Message: "Failed to get webhook deliveries",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.PaginatedResponse{
+		Success: true,
+		Message: "Webhook deliveries retrieved successfully",
+		Data:    deliveries,
+		Meta:    *meta,
+	})
+}
+
+// @Summary Get webhook delivery
+// @Description Get a webhook delivery with every attempt's response code, body and duration
+// @Tags Webhooks
+// @Security Bearer
+// @Param id path int true "Delivery ID"
+// @Success 200 {object} models.Response{data=models.WebhookDelivery}
+// @Failure 404 {object} models.Response
+// @Router /webhooks/deliveries/{id} [get]
+func (h *Handlers) getWebhookDelivery(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid delivery ID",
+		})
+	}
+
+	delivery, err := h.services.Webhook.GetDelivery(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.Response{
+			Success: false,
+			Message: "Webhook delivery not found",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Webhook delivery retrieved successfully",
+		Data:    delivery,
+	})
+}
+
+// @Summary Redeliver webhook
+// @Description Send a failed or delivered webhook again with the same payload
+// @Tags Webhooks
+// @Security Bearer
+// @Param id path int true "Delivery ID"
+// @Success 200 {object} models.Response{data=models.WebhookDelivery}
+// @Failure 400 {object} models.Response
+// @Router /webhooks/deliveries/{id}/redeliver [post]
+func (h *Handlers) redeliverWebhook(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Invalid delivery ID",
+		})
+	}
+
+	delivery, err := h.services.Webhook.Redeliver(int64(id))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.Response{
+			Success: false,
+			Message: "Failed to redeliver webhook",
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(models.Response{
+		Success: true,
+		Message: "Webhook queued for redelivery",
+		Data:    delivery,
+	})
+}
@@ -0,0 +1,176 @@
+// Package ledger builds balanced double-entry journal entries and holds the
+// posting rules that turn operational documents into them.
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// System account codes used by the posting rules. They are seeded with the
+// default chart of accounts.
+const (
+	AccountCash                = "1100"
+	AccountBank                = "1110"
+	AccountCardReceivable      = "1120"
+	AccountEWallet             = "1130"
+	AccountReceivable          = "1200"
+	AccountFinancingReceivable = "1210"
+	AccountPartsInventory      = "1300"
+	AccountVehicleInventory    = "1310"
+	AccountVATInput            = "1400"
+	AccountPayable             = "2100"
+	AccountVATOutput           = "2200"
+	AccountCommissionPayable   = "2300"
+	AccountCustomerCredit      = "2400"
+	AccountStoredValue         = "2500"
+	AccountServiceRevenue      = "4100"
+	AccountPartsRevenue        = "4200"
+	AccountVehicleRevenue      = "4300"
+	AccountLapsedStoredValue   = "4800"
+	AccountSalesDiscount       = "4900"
+	AccountPartsCOGS           = "5100"
+	AccountVehicleCOGS         = "5200"
+	AccountCommissionExpense   = "6100"
+)
+
+// Source types of journal entries
+const (
+	SourceManual          = "manual"
+	SourceTransaction     = "transaction"
+	SourcePayment         = "payment"
+	SourceVehiclePurchase = "vehicle_purchase"
+	SourceVehicleSale     = "vehicle_sale"
+
+	SourceTransactionVoid       = "transaction_void"
+	SourcePaymentReversal       = "payment_reversal"
+	SourceSalesReturn           = "sales_return"
+	SourceRefund                = "refund"
+	SourceCreditNote            = "credit_note"
+	SourceCreditNoteApplication = "credit_note_application"
+	SourceStoredValueEntry      = "stored_value_entry"
+	SourceGoodsReceipt          = "goods_receipt"
+)
+
+// Validation errors
+var (
+	ErrTooFewLines = errors.New("a journal entry needs at least two lines")
+	ErrUnbalanced  = errors.New("journal entry debits and credits must be equal")
+	ErrEmptyEntry  = errors.New("journal entry total must be greater than zero")
+)
+
+// Line is one debit or credit in a journal entry. Lines posted by rules name
+// their account by code; manual lines name it by ID.
+type Line struct {
+	AccountID   int64
+	AccountCode string
+	Description string
+	Debit       decimal.Decimal
+	Credit      decimal.Decimal
+}
+
+// Entry is a journal entry ready to be posted
+type Entry struct {
+	OutletID     int64
+	Date         time.Time
+	Description  string
+	SourceType   string
+	SourceID     *int64
+	SourceNumber string
+	CreatedBy    *int64
+	Lines        []Line
+}
+
+// Debit adds a debit line, skipping zero amounts
+func (e *Entry) Debit(accountCode, description string, amount decimal.Decimal) {
+	e.add(accountCode, description, amount, true)
+}
+
+// Credit adds a credit line, skipping zero amounts
+func (e *Entry) Credit(accountCode, description string, amount decimal.Decimal) {
+	e.add(accountCode, description, amount, false)
+}
+
+func (e *Entry) add(accountCode, description string, amount decimal.Decimal, debit bool) {
+	amount = amount.Round(2)
+	if amount.IsZero() {
+		return
+	}
+	// A negative amount belongs on the other side
+	if amount.IsNegative() {
+		amount, debit = amount.Neg(), !debit
+	}
+
+	line := Line{AccountCode: accountCode, Description: description}
+	if debit {
+		line.Debit = amount
+	} else {
+		line.Credit = amount
+	}
+	e.Lines = append(e.Lines, line)
+}
+
+// Totals returns the sum of debits and credits
+func (e *Entry) Totals() (debit, credit decimal.Decimal) {
+	for _, line := range e.Lines {
+		debit = debit.Add(line.Debit)
+		credit = credit.Add(line.Credit)
+	}
+	return debit, credit
+}
+
+// Validate checks that every line is a positive debit or credit in whole
+// cents and that the entry balances
+func (e *Entry) Validate() error {
+	if len(e.Lines) < 2 {
+		return ErrTooFewLines
+	}
+
+	for i, line := range e.Lines {
+		if line.Debit.IsNegative() || line.Credit.IsNegative() {
+			return fmt.Errorf("line %d: amounts cannot be negative", i+1)
+		}
+		if line.Debit.IsZero() == line.Credit.IsZero() {
+			return fmt.Errorf("line %d: must have either a debit or a credit", i+1)
+		}
+		if !line.Debit.Equal(line.Debit.Round(2)) || !line.Credit.Equal(line.Credit.Round(2)) {
+			return fmt.Errorf("line %d: amounts cannot have more than two decimal places", i+1)
+		}
+		if line.AccountID == 0 && line.AccountCode == "" {
+			return fmt.Errorf("line %d: account is required", i+1)
+		}
+	}
+
+	debit, credit := e.Totals()
+	if !debit.Equal(credit) {
+		return fmt.Errorf("%w: debit %s, credit %s", ErrUnbalanced, debit.StringFixed(2), credit.StringFixed(2))
+	}
+	if !debit.IsPositive() {
+		return ErrEmptyEntry
+	}
+
+	return nil
+}
+
+// PaymentAccount returns the account money moves through for a payment
+// method type. Points redeemed as payment are booked as a sales discount;
+// deposits and vouchers are paid out of the liability held for customers.
+func PaymentAccount(methodType string) string {
+	switch methodType {
+	case "cash":
+		return AccountCash
+	case "credit_card":
+		return AccountCardReceivable
+	case "e_wallet":
+		return AccountEWallet
+	case "loyalty_points":
+		return AccountSalesDiscount
+	case "stored_value":
+		return AccountStoredValue
+	default:
+		return AccountBank
+	}
+}
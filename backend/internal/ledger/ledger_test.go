@@ -0,0 +1,439 @@
+package ledger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func d(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+// net returns what an entry debits an account less what it credits it
+func net(entry *Entry, accountCode string) decimal.Decimal {
+	total := decimal.Zero
+	for _, line := range entry.Lines {
+		if line.AccountCode == accountCode {
+			total = total.Add(line.Debit).Sub(line.Credit)
+		}
+	}
+	return total
+}
+
+func TestEntryLines(t *testing.T) {
+	entry := &Entry{}
+	entry.Debit(AccountCash, "Rounded", d("10.005"))
+	entry.Credit(AccountBank, "Skipped", d("0.004"))
+	entry.Credit(AccountReceivable, "Flipped", d("-5"))
+	entry.Credit(AccountServiceRevenue, "Credit", d("15.01"))
+
+	if len(entry.Lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(entry.Lines))
+	}
+	if !entry.Lines[0].Debit.Equal(d("10.01")) {
+		t.Errorf("debit = %s, want 10.01", entry.Lines[0].Debit)
+	}
+	if !entry.Lines[1].Debit.Equal(d("5")) || !entry.Lines[1].Credit.IsZero() {
+		t.Errorf("negative credit = %+v, want a debit of 5", entry.Lines[1])
+	}
+	if err := entry.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestEntryValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []Line
+		wantErr string
+	}{
+		{
+			name: "balanced",
+			lines: []Line{
+				{AccountCode: AccountCash, Debit: d("100")},
+				{AccountCode: AccountServiceRevenue, Credit: d("100")},
+			},
+		},
+		{
+			name: "manual lines name the account by ID",
+			lines: []Line{
+				{AccountID: 1, Debit: d("100")},
+				{AccountID: 2, Credit: d("60")},
+				{AccountID: 3, Credit: d("40")},
+			},
+		},
+		{
+			name:    "one line",
+			lines:   []Line{{AccountCode: AccountCash, Debit: d("100")}},
+			wantErr: ErrTooFewLines.Error(),
+		},
+		{
+			name: "off by a cent",
+			lines: []Line{
+				{AccountCode: AccountCash, Debit: d("100")},
+				{AccountCode: AccountServiceRevenue, Credit: d("99.99")},
+			},
+			wantErr: ErrUnbalanced.Error(),
+		},
+		{
+			name: "both debit and credit",
+			lines: []Line{
+				{AccountCode: AccountCash, Debit: d("100"), Credit: d("100")},
+				{AccountCode: AccountServiceRevenue, Debit: d("100"), Credit: d("100")},
+			},
+			wantErr: "line 1",
+		},
+		{
+			name: "neither debit nor credit",
+			lines: []Line{
+				{AccountCode: AccountCash},
+				{AccountCode: AccountServiceRevenue},
+			},
+			wantErr: "line 1",
+		},
+		{
+			name: "negative amount",
+			lines: []Line{
+				{AccountCode: AccountCash, Debit: d("-100")},
+				{AccountCode: AccountServiceRevenue, Debit: d("100")},
+			},
+			wantErr: "line 1",
+		},
+		{
+			name: "fractions of a cent",
+			lines: []Line{
+				{AccountCode: AccountCash, Debit: d("100.001")},
+				{AccountCode: AccountServiceRevenue, Credit: d("100.001")},
+			},
+			wantErr: "line 1",
+		},
+		{
+			name: "no account",
+			lines: []Line{
+				{AccountCode: AccountCash, Debit: d("100")},
+				{Credit: d("100")},
+			},
+			wantErr: "line 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &Entry{Lines: tt.lines}
+			err := entry.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	date := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	sale := SalesDocument{
+		TransactionID:     1,
+		TransactionNumber: "TRX-001",
+		TransactionType:   "service",
+		OutletID:          1,
+		Date:              date,
+		ServiceAmount:     d("100.004"),
+		PartsAmount:       d("200.004"),
+		DiscountAmount:    d("10"),
+		TaxAmount:         d("31.9"),
+		TotalAmount:       d("321.91"),
+		PartsCost:         d("120"),
+	}
+
+	tests := []struct {
+		name  string
+		build func() (*Entry, error)
+		// want is the net debit expected on each account
+		want map[string]string
+	}{
+		{
+			name:  "sale absorbs rounding in the largest revenue line",
+			build: func() (*Entry, error) { return SalesEntry(sale) },
+			want: map[string]string{
+				AccountReceivable:     "321.91",
+				AccountSalesDiscount:  "10",
+				AccountServiceRevenue: "-100",
+				AccountPartsRevenue:   "-200.01",
+				AccountVATOutput:      "-31.9",
+				AccountPartsCOGS:      "120",
+				AccountPartsInventory: "-120",
+			},
+		},
+		{
+			name: "vehicle purchase transaction",
+			build: func() (*Entry, error) {
+				return SalesEntry(SalesDocument{TransactionType: "vehicle_purchase", TotalAmount: d("50000000")})
+			},
+			want: map[string]string{
+				AccountVehicleInventory: "50000000",
+				AccountPayable:          "-50000000",
+			},
+		},
+		{
+			name: "void mirrors the sale and returns credit applied",
+			build: func() (*Entry, error) {
+				return TransactionVoidEntry(VoidDocument{
+					Sale:                sale,
+					Date:                date,
+					CreditReversed:      d("20"),
+					PointsReversed:      d("5"),
+					StoredValueReversed: d("30"),
+				})
+			},
+			want: map[string]string{
+				AccountReceivable:     "-266.91",
+				AccountSalesDiscount:  "-15",
+				AccountServiceRevenue: "100",
+				AccountPartsRevenue:   "200.01",
+				AccountVATOutput:      "31.9",
+				AccountPartsCOGS:      "-120",
+				AccountPartsInventory: "120",
+				AccountCustomerCredit: "-20",
+				AccountStoredValue:    "-30",
+			},
+		},
+		{
+			name: "void of a fully returned sale still returns credit",
+			build: func() (*Entry, error) {
+				return TransactionVoidEntry(VoidDocument{Sale: SalesDocument{TransactionType: "sale"}, CreditReversed: d("20")})
+			},
+			want: map[string]string{
+				AccountReceivable:     "20",
+				AccountCustomerCredit: "-20",
+			},
+		},
+		{
+			name: "customer payment",
+			build: func() (*Entry, error) {
+				return PaymentEntry(PaymentDocument{Amount: d("100"), MethodType: "e_wallet"})
+			},
+			want: map[string]string{
+				AccountEWallet:    "100",
+				AccountReceivable: "-100",
+			},
+		},
+		{
+			name: "payment to a vehicle seller",
+			build: func() (*Entry, error) {
+				return PaymentEntry(PaymentDocument{Amount: d("100"), MethodType: "bank_transfer", TransactionType: "vehicle_purchase"})
+			},
+			want: map[string]string{
+				AccountPayable: "100",
+				AccountBank:    "-100",
+			},
+		},
+		{
+			name: "payment reversal",
+			build: func() (*Entry, error) {
+				return PaymentReversalEntry(PaymentDocument{Amount: d("100"), MethodType: "cash"})
+			},
+			want: map[string]string{
+				AccountReceivable: "100",
+				AccountCash:       "-100",
+			},
+		},
+		{
+			name: "restocked return",
+			build: func() (*Entry, error) {
+				return SalesReturnEntry(SalesReturnDocument{
+					SubtotalAmount: d("200"),
+					DiscountAmount: d("20"),
+					TaxAmount:      d("19.8"),
+					TotalAmount:    d("199.8"),
+					CostAmount:     d("80"),
+					Restock:        true,
+				})
+			},
+			want: map[string]string{
+				AccountPartsRevenue:   "200",
+				AccountVATOutput:      "19.8",
+				AccountSalesDiscount:  "-20",
+				AccountReceivable:     "-199.8",
+				AccountPartsInventory: "80",
+				AccountPartsCOGS:      "-80",
+			},
+		},
+		{
+			name: "return not restocked leaves its cost sold",
+			build: func() (*Entry, error) {
+				return SalesReturnEntry(SalesReturnDocument{
+					SubtotalAmount: d("200"),
+					TotalAmount:    d("200"),
+					CostAmount:     d("80"),
+				})
+			},
+			want: map[string]string{
+				AccountPartsRevenue: "200",
+				AccountReceivable:   "-200",
+			},
+		},
+		{
+			name: "refund",
+			build: func() (*Entry, error) {
+				return RefundEntry(RefundDocument{Amount: d("50"), MethodType: "credit_card"})
+			},
+			want: map[string]string{
+				AccountReceivable:     "50",
+				AccountCardReceivable: "-50",
+			},
+		},
+		{
+			name:  "credit note",
+			build: func() (*Entry, error) { return CreditNoteEntry(CreditNoteDocument{Amount: d("25")}) },
+			want: map[string]string{
+				AccountReceivable:     "25",
+				AccountCustomerCredit: "-25",
+			},
+		},
+		{
+			name:  "credit note applied",
+			build: func() (*Entry, error) { return CreditNoteApplicationEntry(CreditNoteDocument{Amount: d("25")}) },
+			want: map[string]string{
+				AccountCustomerCredit: "25",
+				AccountReceivable:     "-25",
+			},
+		},
+		{
+			name: "stored value top-up",
+			build: func() (*Entry, error) {
+				return StoredValueEntry(StoredValueDocument{EntryType: "top_up", Amount: d("500"), MethodType: "cash"})
+			},
+			want: map[string]string{
+				AccountCash:        "500",
+				AccountStoredValue: "-500",
+			},
+		},
+		{
+			name: "stored value lapse",
+			build: func() (*Entry, error) {
+				return StoredValueEntry(StoredValueDocument{EntryType: "expire", Amount: d("500")})
+			},
+			want: map[string]string{
+				AccountStoredValue:       "500",
+				AccountLapsedStoredValue: "-500",
+			},
+		},
+		{
+			name: "stored value lapse reversed",
+			build: func() (*Entry, error) {
+				return StoredValueEntry(StoredValueDocument{EntryType: "expire_reversal", Amount: d("500")})
+			},
+			want: map[string]string{
+				AccountLapsedStoredValue: "500",
+				AccountStoredValue:       "-500",
+			},
+		},
+		{
+			name: "vehicle bought for cash by default",
+			build: func() (*Entry, error) {
+				return VehiclePurchaseEntry(VehiclePurchaseDocument{PurchasePrice: d("15000000")})
+			},
+			want: map[string]string{
+				AccountVehicleInventory: "15000000",
+				AccountCash:             "-15000000",
+			},
+		},
+		{
+			name: "vehicle bought by bank",
+			build: func() (*Entry, error) {
+				return VehiclePurchaseEntry(VehiclePurchaseDocument{PurchasePrice: d("15000000"), PaymentMethod: "transfer"})
+			},
+			want: map[string]string{
+				AccountVehicleInventory: "15000000",
+				AccountBank:             "-15000000",
+			},
+		},
+		{
+			name:  "goods receipt",
+			build: func() (*Entry, error) { return GoodsReceiptEntry(GoodsReceiptDocument{TotalCost: d("750.50")}) },
+			want: map[string]string{
+				AccountPartsInventory: "750.50",
+				AccountPayable:        "-750.50",
+			},
+		},
+		{
+			name: "financed vehicle sale",
+			build: func() (*Entry, error) {
+				return VehicleSaleEntry(VehicleSaleDocument{
+					PaymentType:      "financing",
+					SellingPrice:     d("20000000"),
+					PurchasePrice:    d("15000000"),
+					DownPayment:      d("4000000"),
+					FinancingAmount:  d("15000000"),
+					CommissionAmount: d("250000"),
+				})
+			},
+			want: map[string]string{
+				AccountCash:                "4000000",
+				AccountFinancingReceivable: "15000000",
+				AccountReceivable:          "1000000",
+				AccountVehicleRevenue:      "-20000000",
+				AccountVehicleCOGS:         "15000000",
+				AccountVehicleInventory:    "-15000000",
+				AccountCommissionExpense:   "250000",
+				AccountCommissionPayable:   "-250000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := tt.build()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			debit, credit := entry.Totals()
+			if !debit.Equal(credit) {
+				t.Errorf("debits %s do not equal credits %s", debit, credit)
+			}
+
+			seen := map[string]bool{}
+			for _, line := range entry.Lines {
+				seen[line.AccountCode] = true
+			}
+			for account := range seen {
+				if _, ok := tt.want[account]; !ok {
+					t.Errorf("unexpected posting of %s to %s", net(entry, account), account)
+				}
+			}
+			for account, want := range tt.want {
+				if got := net(entry, account); !got.Equal(d(want)) {
+					t.Errorf("%s = %s, want %s", account, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRulesRejectEmptyDocuments(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() (*Entry, error)
+	}{
+		{name: "payment", build: func() (*Entry, error) { return PaymentEntry(PaymentDocument{MethodType: "cash"}) }},
+		{name: "goods receipt", build: func() (*Entry, error) { return GoodsReceiptEntry(GoodsReceiptDocument{}) }},
+		{name: "unknown stored value entry", build: func() (*Entry, error) {
+			return StoredValueEntry(StoredValueDocument{EntryType: "redeem", Amount: d("10")})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.build(); !errors.Is(err, ErrTooFewLines) {
+				t.Errorf("error = %v, want %v", err, ErrTooFewLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,475 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SalesDocument is a sales transaction with its line totals grouped by the
+// revenue account they post to
+type SalesDocument struct {
+	TransactionID     int64
+	TransactionNumber string
+	TransactionType   string
+	OutletID          int64
+	Date              time.Time
+	ServiceAmount     decimal.Decimal
+	PartsAmount       decimal.Decimal
+	VehicleAmount     decimal.Decimal
+	DiscountAmount    decimal.Decimal
+	TaxAmount         decimal.Decimal
+	TotalAmount       decimal.Decimal
+	// PartsCost is the cost of the products sold, at the unit cost each line
+	// recorded when it was sold
+	PartsCost decimal.Decimal
+}
+
+// VoidDocument is a voided transaction. Sale holds what was still sold when
+// it was voided, net of earlier returns; CreditReversed is the store credit
+// applied to it that went back to its credit notes, and StoredValueReversed
+// what went back to the deposits and vouchers it was paid from.
+type VoidDocument struct {
+	Sale                SalesDocument
+	Date                time.Time
+	CreditReversed      decimal.Decimal
+	PointsReversed      decimal.Decimal
+	StoredValueReversed decimal.Decimal
+}
+
+// PaymentDocument is a payment taken against a transaction
+type PaymentDocument struct {
+	PaymentID         int64
+	PaymentNumber     string
+	TransactionNumber string
+	TransactionType   string
+	OutletID          int64
+	Date              time.Time
+	Amount            decimal.Decimal
+	MethodType        string
+}
+
+// SalesReturnDocument is sold spareparts brought back, with their share of
+// the transaction's discount and tax
+type SalesReturnDocument struct {
+	ReturnID       int64
+	ReturnNumber   string
+	OutletID       int64
+	Date           time.Time
+	SubtotalAmount decimal.Decimal
+	DiscountAmount decimal.Decimal
+	TaxAmount      decimal.Decimal
+	TotalAmount    decimal.Decimal
+	// CostAmount is the cost of the returned products; it goes back to
+	// inventory only when they were restocked
+	CostAmount decimal.Decimal
+	Restock    bool
+}
+
+// RefundDocument is money paid back to a customer
+type RefundDocument struct {
+	RefundID          int64
+	RefundNumber      string
+	TransactionNumber string
+	OutletID          int64
+	Date              time.Time
+	Amount            decimal.Decimal
+	MethodType        string
+}
+
+// CreditNoteDocument is store credit issued to a customer, or applied to one
+// of their transactions
+type CreditNoteDocument struct {
+	ID                int64
+	CreditNoteNumber  string
+	TransactionNumber string
+	OutletID          int64
+	Date              time.Time
+	Amount            decimal.Decimal
+}
+
+// StoredValueDocument is a posting to a customer's deposit or a voucher.
+// Amount is the value moved, whatever its direction; Reference is the
+// voucher code or names the deposit.
+type StoredValueDocument struct {
+	EntryID    int64
+	EntryType  string
+	Reference  string
+	OutletID   int64
+	Date       time.Time
+	Amount     decimal.Decimal
+	MethodType string
+}
+
+// VehiclePurchaseDocument is a vehicle bought into trading inventory
+type VehiclePurchaseDocument struct {
+	PurchaseID    int64
+	OutletID      int64
+	Date          time.Time
+	PlateNumber   string
+	PurchasePrice decimal.Decimal
+	PaymentMethod string
+}
+
+// GoodsReceiptDocument is spareparts received into stock at their cost
+type GoodsReceiptDocument struct {
+	ReceiptID     int64
+	ReceiptNumber string
+	OutletID      int64
+	Date          time.Time
+	TotalCost     decimal.Decimal
+}
+
+// VehicleSaleDocument is a vehicle sold from trading inventory
+type VehicleSaleDocument struct {
+	SaleID           int64
+	OutletID         int64
+	Date             time.Time
+	PlateNumber      string
+	SellingPrice     decimal.Decimal
+	PurchasePrice    decimal.Decimal
+	PaymentType      string
+	DownPayment      decimal.Decimal
+	FinancingAmount  decimal.Decimal
+	CommissionAmount decimal.Decimal
+}
+
+// SalesEntry posts a transaction. Service and sparepart sales recognise
+// revenue against receivables, with discounts and output VAT on their own
+// accounts, and move sold parts from inventory to cost of goods sold.
+// Vehicle purchases recorded as transactions add to vehicle inventory
+// against payables.
+func SalesEntry(doc SalesDocument) (*Entry, error) {
+	id := doc.TransactionID
+	entry := &Entry{
+		OutletID:     doc.OutletID,
+		Date:         doc.Date,
+		Description:  fmt.Sprintf("Transaction %s", doc.TransactionNumber),
+		SourceType:   SourceTransaction,
+		SourceID:     &id,
+		SourceNumber: doc.TransactionNumber,
+	}
+
+	if doc.TransactionType == "vehicle_purchase" {
+		entry.Debit(AccountVehicleInventory, "Vehicle purchased", doc.TotalAmount)
+		entry.Credit(AccountPayable, "Amount owed to seller", doc.TotalAmount)
+		return entry, entry.Validate()
+	}
+
+	revenue := []struct {
+		account string
+		label   string
+		amount  decimal.Decimal
+	}{
+		{AccountServiceRevenue, "Service revenue", doc.ServiceAmount},
+		{AccountPartsRevenue, "Sparepart sales", doc.PartsAmount},
+		{AccountVehicleRevenue, "Vehicle sales", doc.VehicleAmount},
+	}
+
+	// Lines are rounded individually while the header is rounded once, so
+	// any cent difference is absorbed by the largest revenue line
+	largest := 0
+	lineTotal := decimal.Zero
+	for i := range revenue {
+		revenue[i].amount = revenue[i].amount.Round(2)
+		lineTotal = lineTotal.Add(revenue[i].amount)
+		if revenue[i].amount.GreaterThan(revenue[largest].amount) {
+			largest = i
+		}
+	}
+	expected := doc.TotalAmount.Add(doc.DiscountAmount).Sub(doc.TaxAmount)
+	revenue[largest].amount = revenue[largest].amount.Add(expected.Sub(lineTotal))
+
+	entry.Debit(AccountReceivable, "Amount due from customer", doc.TotalAmount)
+	entry.Debit(AccountSalesDiscount, "Discount given", doc.DiscountAmount)
+	for _, r := range revenue {
+		entry.Credit(r.account, r.label, r.amount)
+	}
+	entry.Credit(AccountVATOutput, "Output VAT", doc.TaxAmount)
+
+	entry.Debit(AccountPartsCOGS, "Cost of spareparts sold", doc.PartsCost)
+	entry.Credit(AccountPartsInventory, "Spareparts issued", doc.PartsCost)
+
+	return entry, entry.Validate()
+}
+
+// TransactionVoidEntry posts a voided transaction as the mirror image of
+// the sale still standing, and moves store credit applied to it back to the
+// customer's credit. Refunds of what was paid post on their own.
+func TransactionVoidEntry(doc VoidDocument) (*Entry, error) {
+	sale, err := SalesEntry(doc.Sale)
+	if err != nil && !errors.Is(err, ErrEmptyEntry) && !errors.Is(err, ErrTooFewLines) {
+		return nil, err
+	}
+
+	id := doc.Sale.TransactionID
+	entry := &Entry{
+		OutletID:     doc.Sale.OutletID,
+		Date:         doc.Date,
+		Description:  fmt.Sprintf("Void of transaction %s", doc.Sale.TransactionNumber),
+		SourceType:   SourceTransactionVoid,
+		SourceID:     &id,
+		SourceNumber: doc.Sale.TransactionNumber,
+	}
+
+	for _, line := range sale.Lines {
+		line.Debit, line.Credit = line.Credit, line.Debit
+		entry.Lines = append(entry.Lines, line)
+	}
+
+	entry.Debit(AccountReceivable, "Store credit applied reversed", doc.CreditReversed)
+	entry.Credit(AccountCustomerCredit, "Store credit returned", doc.CreditReversed)
+	entry.Debit(AccountReceivable, "Points redemption reversed", doc.PointsReversed)
+	entry.Credit(AccountSalesDiscount, "Points returned", doc.PointsReversed)
+	entry.Debit(AccountReceivable, "Stored value redemption reversed", doc.StoredValueReversed)
+	entry.Credit(AccountStoredValue, "Deposit and voucher value returned", doc.StoredValueReversed)
+
+	return entry, entry.Validate()
+}
+
+// PaymentEntry posts a payment. Customer payments settle receivables into
+// the payment method's account; payments on vehicle purchase transactions
+// settle payables out of it.
+func PaymentEntry(doc PaymentDocument) (*Entry, error) {
+	id := doc.PaymentID
+	entry := &Entry{
+		OutletID:     doc.OutletID,
+		Date:         doc.Date,
+		Description:  fmt.Sprintf("Payment %s for %s", doc.PaymentNumber, doc.TransactionNumber),
+		SourceType:   SourcePayment,
+		SourceID:     &id,
+		SourceNumber: doc.PaymentNumber,
+	}
+
+	account := PaymentAccount(doc.MethodType)
+	if doc.TransactionType == "vehicle_purchase" {
+		entry.Debit(AccountPayable, "Paid to seller", doc.Amount)
+		entry.Credit(account, "Payment made", doc.Amount)
+	} else {
+		entry.Debit(account, "Payment received", doc.Amount)
+		entry.Credit(AccountReceivable, "Settled by customer", doc.Amount)
+	}
+
+	return entry, entry.Validate()
+}
+
+// PaymentReversalEntry posts a deleted payment as the mirror image of the
+// payment's own entry
+func PaymentReversalEntry(doc PaymentDocument) (*Entry, error) {
+	id := doc.PaymentID
+	entry := &Entry{
+		OutletID:     doc.OutletID,
+		Date:         doc.Date,
+		Description:  fmt.Sprintf("Reversal of payment %s for %s", doc.PaymentNumber, doc.TransactionNumber),
+		SourceType:   SourcePaymentReversal,
+		SourceID:     &id,
+		SourceNumber: doc.PaymentNumber,
+	}
+
+	account := PaymentAccount(doc.MethodType)
+	if doc.TransactionType == "vehicle_purchase" {
+		entry.Debit(account, "Payment reversed", doc.Amount)
+		entry.Credit(AccountPayable, "Owed to seller again", doc.Amount)
+	} else {
+		entry.Debit(AccountReceivable, "Due from customer again", doc.Amount)
+		entry.Credit(account, "Payment reversed", doc.Amount)
+	}
+
+	return entry, entry.Validate()
+}
+
+// SalesReturnEntry posts returned spareparts: the reverse of the sale for
+// the returned share of revenue, discount and VAT, and their cost back to
+// inventory when they are restocked
+func SalesReturnEntry(doc SalesReturnDocument) (*Entry, error) {
+	id := doc.ReturnID
+	entry := &Entry{
+		OutletID:     doc.OutletID,
+		Date:         doc.Date,
+		Description:  fmt.Sprintf("Sales return %s", doc.ReturnNumber),
+		SourceType:   SourceSalesReturn,
+		SourceID:     &id,
+		SourceNumber: doc.ReturnNumber,
+	}
+
+	entry.Debit(AccountPartsRevenue, "Spareparts returned", doc.SubtotalAmount)
+	entry.Debit(AccountVATOutput, "Output VAT reversed", doc.TaxAmount)
+	entry.Credit(AccountSalesDiscount, "Discount reversed", doc.DiscountAmount)
+	entry.Credit(AccountReceivable, "No longer due from customer", doc.TotalAmount)
+
+	if doc.Restock {
+		entry.Debit(AccountPartsInventory, "Spareparts restocked", doc.CostAmount)
+		entry.Credit(AccountPartsCOGS, "Cost of spareparts returned", doc.CostAmount)
+	}
+
+	return entry, entry.Validate()
+}
+
+// RefundEntry posts money paid back to a customer out of the payment
+// method's account
+func RefundEntry(doc RefundDocument) (*Entry, error) {
+	id := doc.RefundID
+	entry := &Entry{
+		OutletID:     doc.OutletID,
+		Date:         doc.Date,
+		Description:  fmt.Sprintf("Refund %s for %s", doc.RefundNumber, doc.TransactionNumber),
+		SourceType:   SourceRefund,
+		SourceID:     &id,
+		SourceNumber: doc.RefundNumber,
+	}
+
+	entry.Debit(AccountReceivable, "Paid back to customer", doc.Amount)
+	entry.Credit(PaymentAccount(doc.MethodType), "Refund paid", doc.Amount)
+
+	return entry, entry.Validate()
+}
+
+// CreditNoteEntry posts store credit issued to a customer as a liability
+func CreditNoteEntry(doc CreditNoteDocument) (*Entry, error) {
+	id := doc.ID
+	entry := &Entry{
+		OutletID:     doc.OutletID,
+		Date:         doc.Date,
+		Description:  fmt.Sprintf("Credit note %s for %s", doc.CreditNoteNumber, doc.TransactionNumber),
+		SourceType:   SourceCreditNote,
+		SourceID:     &id,
+		SourceNumber: doc.CreditNoteNumber,
+	}
+
+	entry.Debit(AccountReceivable, "Overpayment held as credit", doc.Amount)
+	entry.Credit(AccountCustomerCredit, "Store credit issued", doc.Amount)
+
+	return entry, entry.Validate()
+}
+
+// CreditNoteApplicationEntry posts store credit used to settle a
+// transaction
+func CreditNoteApplicationEntry(doc CreditNoteDocument) (*Entry, error) {
+	id := doc.ID
+	entry := &Entry{
+		OutletID:     doc.OutletID,
+		Date:         doc.Date,
+		Description:  fmt.Sprintf("Credit note %s applied to %s", doc.CreditNoteNumber, doc.TransactionNumber),
+		SourceType:   SourceCreditNoteApplication,
+		SourceID:     &id,
+		SourceNumber: doc.CreditNoteNumber,
+	}
+
+	entry.Debit(AccountCustomerCredit, "Store credit used", doc.Amount)
+	entry.Credit(AccountReceivable, "Settled with store credit", doc.Amount)
+
+	return entry, entry.Validate()
+}
+
+// StoredValueEntry posts value paid into a deposit or voucher as a
+// liability, and value that lapsed, or came back from lapsing, against
+// income. Redemptions post with the payments, refunds and voids they belong
+// to, so they have nothing to post here.
+func StoredValueEntry(doc StoredValueDocument) (*Entry, error) {
+	id := doc.EntryID
+	entry := &Entry{
+		OutletID:     doc.OutletID,
+		Date:         doc.Date,
+		SourceType:   SourceStoredValueEntry,
+		SourceID:     &id,
+		SourceNumber: doc.Reference,
+	}
+
+	switch doc.EntryType {
+	case "top_up":
+		entry.Description = fmt.Sprintf("Top-up of %s", doc.Reference)
+		entry.Debit(PaymentAccount(doc.MethodType), "Paid in by customer", doc.Amount)
+		entry.Credit(AccountStoredValue, "Held for customer", doc.Amount)
+	case "expire":
+		entry.Description = fmt.Sprintf("Lapsed balance of %s", doc.Reference)
+		entry.Debit(AccountStoredValue, "No longer held for customer", doc.Amount)
+		entry.Credit(AccountLapsedStoredValue, "Balance lapsed", doc.Amount)
+	case "expire_reversal":
+		entry.Description = fmt.Sprintf("Lapse of %s reversed", doc.Reference)
+		entry.Debit(AccountLapsedStoredValue, "Lapse reversed", doc.Amount)
+		entry.Credit(AccountStoredValue, "Held for customer again", doc.Amount)
+	}
+
+	return entry, entry.Validate()
+}
+
+// VehiclePurchaseEntry posts a vehicle bought into inventory, paid in cash
+// or by bank
+func VehiclePurchaseEntry(doc VehiclePurchaseDocument) (*Entry, error) {
+	id := doc.PurchaseID
+	entry := &Entry{
+		OutletID:     doc.OutletID,
+		Date:         doc.Date,
+		Description:  fmt.Sprintf("Vehicle purchase %s", doc.PlateNumber),
+		SourceType:   SourceVehiclePurchase,
+		SourceID:     &id,
+		SourceNumber: doc.PlateNumber,
+	}
+
+	account := AccountBank
+	if strings.EqualFold(doc.PaymentMethod, "cash") || doc.PaymentMethod == "" {
+		account = AccountCash
+	}
+
+	entry.Debit(AccountVehicleInventory, "Vehicle added to inventory", doc.PurchasePrice)
+	entry.Credit(account, "Paid to seller", doc.PurchasePrice)
+
+	return entry, entry.Validate()
+}
+
+// GoodsReceiptEntry posts spareparts received into inventory against what
+// is owed to the supplier
+func GoodsReceiptEntry(doc GoodsReceiptDocument) (*Entry, error) {
+	id := doc.ReceiptID
+	entry := &Entry{
+		OutletID:     doc.OutletID,
+		Date:         doc.Date,
+		Description:  fmt.Sprintf("Goods receipt %s", doc.ReceiptNumber),
+		SourceType:   SourceGoodsReceipt,
+		SourceID:     &id,
+		SourceNumber: doc.ReceiptNumber,
+	}
+
+	entry.Debit(AccountPartsInventory, "Spareparts received", doc.TotalCost)
+	entry.Credit(AccountPayable, "Owed to supplier", doc.TotalCost)
+
+	return entry, entry.Validate()
+}
+
+// VehicleSaleEntry posts a vehicle sold from inventory: revenue against
+// cash, financing and customer receivables by payment type, the vehicle's
+// purchase price to cost of goods sold, and the salesperson's commission
+func VehicleSaleEntry(doc VehicleSaleDocument) (*Entry, error) {
+	id := doc.SaleID
+	entry := &Entry{
+		OutletID:     doc.OutletID,
+		Date:         doc.Date,
+		Description:  fmt.Sprintf("Vehicle sale %s", doc.PlateNumber),
+		SourceType:   SourceVehicleSale,
+		SourceID:     &id,
+		SourceNumber: doc.PlateNumber,
+	}
+
+	switch doc.PaymentType {
+	case "cash":
+		entry.Debit(AccountCash, "Paid in cash", doc.SellingPrice)
+	case "financing":
+		entry.Debit(AccountCash, "Down payment", doc.DownPayment)
+		entry.Debit(AccountFinancingReceivable, "Due from financing company", doc.FinancingAmount)
+		entry.Debit(AccountReceivable, "Amount due from customer", doc.SellingPrice.Sub(doc.DownPayment).Sub(doc.FinancingAmount))
+	default:
+		entry.Debit(AccountReceivable, "Amount due from customer", doc.SellingPrice)
+	}
+	entry.Credit(AccountVehicleRevenue, "Vehicle sales", doc.SellingPrice)
+
+	entry.Debit(AccountVehicleCOGS, "Cost of vehicle sold", doc.PurchasePrice)
+	entry.Credit(AccountVehicleInventory, "Vehicle removed from inventory", doc.PurchasePrice)
+
+	entry.Debit(AccountCommissionExpense, "Sales commission", doc.CommissionAmount)
+	entry.Credit(AccountCommissionPayable, "Commission owed to salesperson", doc.CommissionAmount)
+
+	return entry, entry.Validate()
+}
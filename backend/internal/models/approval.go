@@ -0,0 +1,107 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ApprovalThreshold - How far a role may discount or override prices
+// without an approver
+type ApprovalThreshold struct {
+	RoleID                  int64           `json:"role_id" db:"role_id"`
+	RoleName                string          `json:"role_name" db:"role_name"`
+	MaxDiscountPercent      decimal.Decimal `json:"max_discount_percent" db:"max_discount_percent"`
+	MaxPriceOverridePercent decimal.Decimal `json:"max_price_override_percent" db:"max_price_override_percent"`
+	UpdatedAt               time.Time       `json:"updated_at" db:"updated_at"`
+	UpdatedBy               *int64          `json:"updated_by,omitempty" db:"updated_by"`
+}
+
+// ApprovalRequest - A discount or price override held for an approver, and
+// the record of the decision
+type ApprovalRequest struct {
+	ApprovalID          int64            `json:"approval_id" db:"approval_id"`
+	RequestType         string           `json:"request_type" db:"request_type"` // discount, price_override
+	Status              string           `json:"status" db:"status"`             // pending, approved, rejected, cancelled
+	OutletID            int64            `json:"outlet_id" db:"outlet_id"`
+	TransactionID       *int64           `json:"transaction_id" db:"transaction_id"`
+	TransactionNumber   *string          `json:"transaction_number" db:"transaction_number"`
+	TransactionDetailID *int64           `json:"transaction_detail_id" db:"transaction_detail_id"`
+	ServiceJobID        *int64           `json:"service_job_id" db:"service_job_id"`
+	JobNumber           *string          `json:"job_number" db:"job_number"`
+	ServiceDetailID     *int64           `json:"service_detail_id" db:"service_detail_id"`
+	ProductID           *int64           `json:"product_id" db:"product_id"`
+	ServiceID           *int64           `json:"service_id" db:"service_id"`
+	ItemName            *string          `json:"item_name" db:"item_name"`
+	ListPrice           *decimal.Decimal `json:"list_price" db:"list_price"`
+	RequestedPrice      *decimal.Decimal `json:"requested_price" db:"requested_price"`
+	SubtotalAmount      *decimal.Decimal `json:"subtotal_amount" db:"subtotal_amount"`
+	DiscountAmount      *decimal.Decimal `json:"discount_amount" db:"discount_amount"`
+	Percent             decimal.Decimal  `json:"percent" db:"percent"` // below list price, or of the subtotal
+	ThresholdPercent    decimal.Decimal  `json:"threshold_percent" db:"threshold_percent"`
+	RequestedBy         int64            `json:"requested_by" db:"requested_by"`
+	RequestedByName     string           `json:"requested_by_name" db:"requested_by_name"`
+	RequestedAt         time.Time        `json:"requested_at" db:"requested_at"`
+	DecidedBy           *int64           `json:"decided_by" db:"decided_by"`
+	DecidedByName       *string          `json:"decided_by_name" db:"decided_by_name"`
+	DecidedAt           *time.Time       `json:"decided_at" db:"decided_at"`
+	DecisionMethod      *string          `json:"decision_method" db:"decision_method"` // in_app, pin
+	DecisionNotes       *string          `json:"decision_notes" db:"decision_notes"`
+
+	// Line the request is for when it is created with its transaction
+	DetailIndex *int `json:"-" db:"-"`
+}
+
+// ApprovalFilter - Filters for listing approval requests
+type ApprovalFilter struct {
+	OutletID      *int64
+	Status        string
+	RequestType   string
+	TransactionID *int64
+	ServiceJobID  *int64
+	RequestedBy   *int64
+	DateFrom      *time.Time
+	DateTo        *time.Time
+}
+
+// UpdateApprovalThresholdRequest - Request for setting a role's threshold
+type UpdateApprovalThresholdRequest struct {
+	MaxDiscountPercent      decimal.Decimal `json:"max_discount_percent"`
+	MaxPriceOverridePercent decimal.Decimal `json:"max_price_override_percent"`
+}
+
+// ApprovalDecisionRequest - Request for approving or rejecting. With a
+// username and PIN the approver decides on the requester's terminal;
+// without them the logged in user decides in the app.
+type ApprovalDecisionRequest struct {
+	Username string `json:"username"`
+	PIN      string `json:"pin"`
+	Notes    string `json:"notes"`
+}
+
+// ApprovalPIN - An approver's PIN and how close it is to being locked
+type ApprovalPIN struct {
+	Hash           *string    `db:"approval_pin_hash"`
+	FailedAttempts int        `db:"approval_pin_failed_attempts"`
+	LockedUntil    *time.Time `db:"approval_pin_locked_until"`
+	Locked         bool       `db:"locked"`
+}
+
+// ApprovalPINAttempt - A PIN entered for an approver on someone's terminal.
+// Attempts for unknown usernames are kept too, without an approver.
+type ApprovalPINAttempt struct {
+	AttemptID   int64     `json:"attempt_id" db:"attempt_id"`
+	ApprovalID  int64     `json:"approval_id" db:"approval_id"`
+	ApproverID  *int64    `json:"approver_id" db:"approver_id"`
+	Username    string    `json:"username" db:"username"`
+	AttemptedBy int64     `json:"attempted_by" db:"attempted_by"`
+	Succeeded   bool      `json:"succeeded" db:"succeeded"`
+	Locked      bool      `json:"locked" db:"locked"` // this attempt locked the PIN, or it was already locked
+	AttemptedAt time.Time `json:"attempted_at" db:"attempted_at"`
+}
+
+// SetApprovalPINRequest - Request for setting the logged in approver's PIN
+type SetApprovalPINRequest struct {
+	Password string `json:"password" validate:"required"`
+	PIN      string `json:"pin" validate:"required"`
+}
@@ -21,6 +21,7 @@ type Service struct {
 	CategoryID        int64   `json:"category_id" db:"category_id" validate:"required"`
 	StandardPrice     float64 `json:"standard_price" db:"standard_price"`
 	EstimatedDuration int     `json:"estimated_duration" db:"estimated_duration"` // in minutes
+	TaxCodeID         *int64  `json:"tax_code_id" db:"tax_code_id"`
 	IsActive          bool    `json:"is_active" db:"is_active"`
 	
 	// Relations
@@ -80,6 +81,7 @@ type Product struct {
 	MaxStockLevel    int     `json:"max_stock_level" db:"max_stock_level"`
 	HasSerialNumber  bool    `json:"has_serial_number" db:"has_serial_number"`
 	IsService        bool    `json:"is_service" db:"is_service"`
+	TaxCodeID        *int64  `json:"tax_code_id" db:"tax_code_id"`
 	IsActive         bool    `json:"is_active" db:"is_active"`
 	
 	// Relations
@@ -115,14 +117,20 @@ type ServiceJob struct {
 	Priority             string    `json:"priority" db:"priority"` // low, normal, high, urgent
 	Status               string    `json:"status" db:"status"`     // pending, in_progress, completed, cancelled, on_hold
 	ProblemDescription   string    `json:"problem_description" db:"problem_description" validate:"required"`
+	EstimatedStart       *time.Time `json:"estimated_start" db:"estimated_start"`
 	EstimatedCompletion  *time.Time `json:"estimated_completion" db:"estimated_completion"`
+	StartedAt            *time.Time `json:"started_at" db:"started_at"`
 	ActualCompletion     *time.Time `json:"actual_completion" db:"actual_completion"`
 	TotalAmount          float64   `json:"total_amount" db:"total_amount"`
 	DiscountAmount       float64   `json:"discount_amount" db:"discount_amount"`
+	MembershipDiscount   float64   `json:"membership_discount" db:"membership_discount"` // taken off after discount_amount
 	TaxAmount            float64   `json:"tax_amount" db:"tax_amount"`
 	FinalAmount          float64   `json:"final_amount" db:"final_amount"`
 	WarrantyPeriodDays   int       `json:"warranty_period_days" db:"warranty_period_days"`
+	Odometer             *int64    `json:"odometer" db:"odometer"`
 	Notes                string    `json:"notes" db:"notes"`
+	TrackingToken        *string   `json:"tracking_token,omitempty" db:"tracking_token"`
+	CouponCode           *string   `json:"coupon_code" db:"coupon_code"`
 	
 	// Relations
 	Customer    *Customer        `json:"customer,omitempty"`
@@ -130,19 +138,33 @@ type ServiceJob struct {
 	Outlet      *Outlet          `json:"outlet,omitempty"`
 	Technician  *User            `json:"technician,omitempty"`
 	Details     []ServiceDetail  `json:"details,omitempty"`
+	TaxSummary  []TaxSummaryLine `json:"tax_summary,omitempty" db:"-"`
+	Packages    []DocumentPackage `json:"packages,omitempty" db:"-"`
 	Histories   []ServiceJobHistory `json:"histories,omitempty"`
 }
 
 // ServiceDetail model
 type ServiceDetail struct {
 	BaseModel
-	ServiceJobID int64   `json:"service_job_id" db:"service_job_id" validate:"required"`
-	ProductID    *int64  `json:"product_id" db:"product_id"`
-	ServiceID    *int64  `json:"service_id" db:"service_id"`
-	Quantity     float64 `json:"quantity" db:"quantity" validate:"required"`
-	UnitPrice    float64 `json:"unit_price" db:"unit_price" validate:"required"`
-	TotalPrice   float64 `json:"total_price" db:"total_price" validate:"required"`
-	Notes        string  `json:"notes" db:"notes"`
+	ServiceJobID      int64    `json:"service_job_id" db:"service_job_id" validate:"required"`
+	ProductID         *int64   `json:"product_id" db:"product_id"`
+	ServiceID         *int64   `json:"service_id" db:"service_id"`
+	Quantity          float64  `json:"quantity" db:"quantity" validate:"required"`
+	UnitPrice         float64  `json:"unit_price" db:"unit_price" validate:"required"`
+	TotalPrice        float64  `json:"total_price" db:"total_price" validate:"required"`
+	TaxCodeID         *int64   `json:"tax_code_id" db:"tax_code_id"`
+	TaxRate           float64  `json:"tax_rate" db:"tax_rate"`
+	NetAmount         float64  `json:"net_amount" db:"net_amount"`
+	DiscountAmount    float64  `json:"discount_amount" db:"discount_amount"`
+	TaxAmount         float64  `json:"tax_amount" db:"tax_amount"`
+	PromotionID       *int64   `json:"promotion_id" db:"promotion_id"`
+	PromotionDiscount float64  `json:"promotion_discount" db:"promotion_discount"` // entered like the prices
+	ApprovalStatus    string   `json:"approval_status" db:"approval_status"`       // approved, pending, rejected
+	Notes             string   `json:"notes" db:"notes"`
+	PackageLineID     *int64   `json:"service_job_package_id" db:"service_job_package_id"` // set on a package's component lines
+	ListPrice         *float64 `json:"list_price" db:"list_price"`                         // the component's own price
+	PriceListID       *int64   `json:"price_list_id" db:"price_list_id"`                   // the price list the price was taken from
+	UnitCost          *float64 `json:"unit_cost" db:"unit_cost"`                           // what one unit of the product cost when sold
 	
 	// Relations
 	ServiceJob *ServiceJob `json:"service_job,omitempty"`
@@ -177,29 +199,52 @@ type Transaction struct {
 	DiscountAmount    float64   `json:"discount_amount" db:"discount_amount"`
 	TaxAmount         float64   `json:"tax_amount" db:"tax_amount"`
 	TotalAmount       float64   `json:"total_amount" db:"total_amount"`
-	PaymentStatus     string    `json:"payment_status" db:"payment_status"` // pending, partial, paid, cancelled
+	PricesIncludeTax  bool      `json:"prices_include_tax" db:"prices_include_tax"`
+	MembershipTierID  *int64    `json:"membership_tier_id" db:"membership_tier_id"`
+	MembershipDiscount float64  `json:"membership_discount" db:"membership_discount"` // part of discount_amount
+	PaymentStatus     string    `json:"payment_status" db:"payment_status"` // pending, partial, paid, refunded, cancelled, void
+	ApprovalStatus    string    `json:"approval_status" db:"approval_status"` // approved, pending, rejected
 	Notes             string    `json:"notes" db:"notes"`
 	TransactionDate   time.Time `json:"transaction_date" db:"transaction_date"`
+	VoidedAt          *time.Time `json:"voided_at,omitempty" db:"voided_at"`
+	VoidedBy          *int64     `json:"voided_by,omitempty" db:"voided_by"`
+	VoidReason        *string    `json:"void_reason,omitempty" db:"void_reason"`
 	
 	// Relations
-	Customer    *Customer           `json:"customer,omitempty"`
-	Outlet      *Outlet             `json:"outlet,omitempty"`
-	User        *User               `json:"user,omitempty"`
-	ServiceJob  *ServiceJob         `json:"service_job,omitempty"`
-	Details     []TransactionDetail `json:"details,omitempty"`
-	Payments    []Payment           `json:"payments,omitempty"`
+	Customer    *Customer             `json:"customer,omitempty"`
+	Outlet      *Outlet               `json:"outlet,omitempty"`
+	User        *User                 `json:"user,omitempty"`
+	ServiceJob  *ServiceJob           `json:"service_job,omitempty"`
+	Details     []TransactionDetail   `json:"details,omitempty"`
+	Payments    []Payment             `json:"payments,omitempty"`
+	TaxSummary  []TaxSummaryLine      `json:"tax_summary,omitempty" db:"-"`
+	Promotions  []PromotionRedemption `json:"promotions,omitempty" db:"-"`
+	Approvals   []ApprovalRequest     `json:"approvals,omitempty" db:"-"`
+	Packages    []DocumentPackage     `json:"packages,omitempty" db:"-"`
 }
 
 // TransactionDetail model
 type TransactionDetail struct {
 	BaseModel
-	TransactionID int64   `json:"transaction_id" db:"transaction_id" validate:"required"`
-	ProductID     *int64  `json:"product_id" db:"product_id"`
-	ServiceID     *int64  `json:"service_id" db:"service_id"`
-	Description   string  `json:"description" db:"description"`
-	Quantity      float64 `json:"quantity" db:"quantity" validate:"required"`
-	UnitPrice     float64 `json:"unit_price" db:"unit_price" validate:"required"`
-	TotalPrice    float64 `json:"total_price" db:"total_price" validate:"required"`
+	TransactionID     int64    `json:"transaction_id" db:"transaction_id" validate:"required"`
+	ProductID         *int64   `json:"product_id" db:"product_id"`
+	ServiceID         *int64   `json:"service_id" db:"service_id"`
+	Description       string   `json:"description" db:"description"`
+	Quantity          float64  `json:"quantity" db:"quantity" validate:"required"`
+	UnitPrice         float64  `json:"unit_price" db:"unit_price" validate:"required"`
+	TotalPrice        float64  `json:"total_price" db:"total_price" validate:"required"` // quantity × unit price, as entered, or a package component's share of the package
+	TaxCodeID         *int64   `json:"tax_code_id" db:"tax_code_id"`
+	TaxRate           float64  `json:"tax_rate" db:"tax_rate"`
+	NetAmount         float64  `json:"net_amount" db:"net_amount"` // before tax and discount
+	DiscountAmount    float64  `json:"discount_amount" db:"discount_amount"`
+	TaxAmount         float64  `json:"tax_amount" db:"tax_amount"`
+	PromotionID       *int64   `json:"promotion_id" db:"promotion_id"`
+	PromotionDiscount float64  `json:"promotion_discount" db:"promotion_discount"`         // entered like the prices
+	PackageLineID     *int64   `json:"transaction_package_id" db:"transaction_package_id"` // set on a package's component lines
+	ListPrice         *float64 `json:"list_price" db:"list_price"`                         // the component's own price
+	PriceListID       *int64   `json:"price_list_id" db:"price_list_id"`                   // the price list the price was taken from
+	UnitCost          *float64 `json:"unit_cost" db:"unit_cost"`                           // what one unit of the product cost when sold
+	PackageIndex      *int     `json:"-" db:"-"`                                           // index into the transaction's packages
 	
 	// Relations
 	Transaction *Transaction `json:"transaction,omitempty"`
@@ -227,10 +272,15 @@ type Payment struct {
 	PaymentDate     time.Time `json:"payment_date" db:"payment_date"`
 	ReferenceNumber string    `json:"reference_number" db:"reference_number"`
 	Notes           string    `json:"notes" db:"notes"`
+	ShiftID         *int64    `json:"shift_id" db:"shift_id"`
+	CheckoutID      *int64    `json:"checkout_id" db:"checkout_id"`
+	TenderedAmount  float64   `json:"tendered_amount" db:"tendered_amount"`
+	ChangeAmount    float64   `json:"change_amount" db:"change_amount"`
+	CreatedBy       *int64    `json:"created_by,omitempty" db:"created_by"`
 	
 	// Relations
-	Transaction   *Transaction   `json:"transaction,omitempty"`
-	PaymentMethod *PaymentMethod `json:"payment_method,omitempty"`
+	Transaction   *Transaction   `json:"transaction,omitempty" db:"-"`
+	PaymentMethod *PaymentMethod `json:"payment_method,omitempty" db:"payment_method"`
 }
 
 // CreateServiceJobRequest
@@ -241,6 +291,7 @@ type CreateServiceJobRequest struct {
 	ProblemDescription string `json:"problem_description" validate:"required"`
 	TechnicianID       *int64 `json:"technician_id"`
 	WarrantyPeriodDays int    `json:"warranty_period_days"`
+	Odometer           *int64 `json:"odometer"`
 	Notes              string `json:"notes"`
 }
 
@@ -252,6 +303,7 @@ type UpdateServiceJobRequest struct {
 	EstimatedCompletion *time.Time `json:"estimated_completion"`
 	ActualCompletion    *time.Time `json:"actual_completion"`
 	WarrantyPeriodDays  int        `json:"warranty_period_days"`
+	Odometer            *int64     `json:"odometer"`
 	Notes               string     `json:"notes"`
 }
 
@@ -260,10 +312,10 @@ type CreateTransactionRequest struct {
 	TransactionType string                      `json:"transaction_type" validate:"required"`
 	CustomerID      *int64                      `json:"customer_id"`
 	ServiceJobID    *int64                      `json:"service_job_id"`
-	DiscountAmount  float64                     `json:"discount_amount"`
-	TaxAmount       float64                     `json:"tax_amount"`
+	DiscountAmount  float64                     `json:"discount_amount"` // entered like the prices: including tax at tax-inclusive outlets
+	CouponCode      string                      `json:"coupon_code"`     // defaults to the service job's coupon
 	Notes           string                      `json:"notes"`
-	Details         []CreateTransactionDetailRequest `json:"details" validate:"required,dive"`
+	Details         []CreateTransactionDetailRequest `json:"details" validate:"dive"` // a service job's own lines are invoiced from the job
 }
 
 // CreateTransactionDetailRequest
@@ -273,13 +325,15 @@ type CreateTransactionDetailRequest struct {
 	Description string  `json:"description"`
 	Quantity    float64 `json:"quantity" validate:"required"`
 	UnitPrice   float64 `json:"unit_price" validate:"required"`
+	TaxCodeID   *int64  `json:"tax_code_id"` // defaults to the product's or service's, then the outlet's
+	PackageID   *int64  `json:"package_id"`  // sells a package: quantity is the number of packages and the line expands into its components
 }
 
 // CreatePaymentRequest
 type CreatePaymentRequest struct {
 	TransactionID   int64   `json:"transaction_id" validate:"required"`
 	PaymentMethodID int64   `json:"payment_method_id" validate:"required"`
-	Amount          float64 `json:"amount" validate:"required"`
+	Amount          float64 `json:"amount" validate:"required,gt=0"`
 	ReferenceNumber string  `json:"reference_number"`
 	Notes           string  `json:"notes"`
 }
\ No newline at end of file
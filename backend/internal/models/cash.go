@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CashFlow - Money moving in or out of an outlet
+type CashFlow struct {
+	FlowID          int64           `json:"flow_id" db:"flow_id"`
+	OutletID        int64           `json:"outlet_id" db:"outlet_id"`
+	FlowType        string          `json:"flow_type" db:"flow_type"` // inflow, outflow
+	Category        string          `json:"category" db:"category"`   // sales, refund, supplier_payment, vehicle_purchase, vehicle_sale
+	Amount          decimal.Decimal `json:"amount" db:"amount"`
+	Description     string          `json:"description" db:"description"`
+	ReferenceType   *string         `json:"reference_type" db:"reference_type"` // payment, refund, payable_payment, vehicle_purchase, vehicle_sale
+	ReferenceID     *int64          `json:"reference_id" db:"reference_id"`
+	PaymentMethodID *int64          `json:"payment_method_id" db:"payment_method_id"`
+	ShiftID         *int64          `json:"shift_id" db:"shift_id"`
+	TransactionDate time.Time       `json:"transaction_date" db:"transaction_date"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+	CreatedBy       *int64          `json:"created_by,omitempty" db:"created_by"`
+}
+
+// CashFlowFilter - Filters for listing cash flows
+type CashFlowFilter struct {
+	OutletID      *int64
+	FlowType      string
+	Category      string
+	ReferenceType string
+	DateFrom      *time.Time
+	DateTo        *time.Time
+}
+
+// DailyCashSummary - An outlet's cash position for one day
+type DailyCashSummary struct {
+	SummaryID      int64           `json:"summary_id" db:"summary_id"`
+	OutletID       int64           `json:"outlet_id" db:"outlet_id"`
+	SummaryDate    time.Time       `json:"summary_date" db:"summary_date"`
+	OpeningBalance decimal.Decimal `json:"opening_balance" db:"opening_balance"`
+	TotalInflow    decimal.Decimal `json:"total_inflow" db:"total_inflow"`
+	TotalOutflow   decimal.Decimal `json:"total_outflow" db:"total_outflow"`
+	ClosingBalance decimal.Decimal `json:"closing_balance" db:"closing_balance"`
+	Status         string          `json:"status" db:"status"` // open, closed
+	ClosedAt       *time.Time      `json:"closed_at" db:"closed_at"`
+	ClosedBy       *int64          `json:"closed_by" db:"closed_by"`
+	Notes          *string         `json:"notes" db:"notes"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// CloseCashDayRequest - Request for closing an outlet's cash for a day
+type CloseCashDayRequest struct {
+	OutletID *int64 `json:"outlet_id"`                // Super Admin only, defaults to the user's outlet
+	Date     string `json:"date" validate:"required"` // YYYY-MM-DD
+	Notes    string `json:"notes"`
+}
+
+// AccountsPayable - Money owed to a supplier
+type AccountsPayable struct {
+	PayableID       int64           `json:"payable_id" db:"payable_id"`
+	APNumber        string          `json:"ap_number" db:"ap_number"`
+	SupplierID      int64           `json:"supplier_id" db:"supplier_id"`
+	SupplierName    string          `json:"supplier_name" db:"supplier_name"`
+	PurchaseOrderID *int64          `json:"purchase_order_id" db:"purchase_order_id"`
+	Amount          decimal.Decimal `json:"amount" db:"amount"`
+	PaidAmount      decimal.Decimal `json:"paid_amount" db:"paid_amount"`
+	RemainingAmount decimal.Decimal `json:"remaining_amount" db:"remaining_amount"`
+	DueDate         time.Time       `json:"due_date" db:"due_date"`
+	Status          string          `json:"status" db:"status"` // outstanding, partial, paid, overdue
+	Notes           *string         `json:"notes" db:"notes"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+
+	Payments []PayablePayment `json:"payments,omitempty" db:"-"`
+}
+
+// AccountsPayableFilter - Filters for listing payables
+type AccountsPayableFilter struct {
+	SupplierID *int64
+	Status     string
+}
+
+// PayablePayment - A payment made to a supplier against a payable
+type PayablePayment struct {
+	PaymentID         int64           `json:"payment_id" db:"payment_id"`
+	AccountsPayableID int64           `json:"accounts_payable_id" db:"accounts_payable_id"`
+	PaymentMethodID   int64           `json:"payment_method_id" db:"payment_method_id"`
+	Amount            decimal.Decimal `json:"amount" db:"amount"`
+	PaymentDate       time.Time       `json:"payment_date" db:"payment_date"`
+	ReferenceNumber   *string         `json:"reference_number" db:"reference_number"`
+	Notes             *string         `json:"notes" db:"notes"`
+	CreatedAt         time.Time       `json:"created_at" db:"created_at"`
+	CreatedBy         *int64          `json:"created_by,omitempty" db:"created_by"`
+}
+
+// CreatePayablePaymentRequest - Request for paying a supplier
+type CreatePayablePaymentRequest struct {
+	OutletID        *int64          `json:"outlet_id"` // Super Admin only; payables from a purchase order use its outlet
+	PaymentMethodID int64           `json:"payment_method_id" validate:"required"`
+	Amount          decimal.Decimal `json:"amount" validate:"required"`
+	ReferenceNumber string          `json:"reference_number"`
+	Notes           string          `json:"notes"`
+}
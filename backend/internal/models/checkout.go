@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Checkout - A transaction settled with one or more tenders in one go
+type Checkout struct {
+	CheckoutID        int64               `json:"checkout_id" db:"checkout_id"`
+	CheckoutNumber    string              `json:"checkout_number" db:"checkout_number"`
+	TransactionID     int64               `json:"transaction_id" db:"transaction_id"`
+	TransactionNumber string              `json:"transaction_number" db:"transaction_number"`
+	OutletID          int64               `json:"outlet_id" db:"outlet_id"`
+	CustomerID        *int64              `json:"customer_id" db:"customer_id"`
+	CustomerName      *string             `json:"customer_name" db:"customer_name"`
+	TotalAmount       decimal.Decimal     `json:"total_amount" db:"total_amount"` // of the transaction
+	TenderedAmount    decimal.Decimal     `json:"tendered_amount" db:"tendered_amount"`
+	AppliedAmount     decimal.Decimal     `json:"applied_amount" db:"applied_amount"`
+	ChangeAmount      decimal.Decimal     `json:"change_amount" db:"change_amount"`
+	ShiftID           *int64              `json:"shift_id" db:"shift_id"`
+	Notes             *string             `json:"notes" db:"notes"`
+	CreatedAt         time.Time           `json:"created_at" db:"created_at"`
+	CreatedBy         *int64              `json:"created_by,omitempty" db:"created_by"`
+	CashierName       *string             `json:"cashier_name" db:"cashier_name"`
+	Tenders           []CheckoutTender    `json:"tenders,omitempty" db:"-"`
+	Balance           *TransactionBalance `json:"balance,omitempty" db:"-"`
+}
+
+// CheckoutTender - One tender of a checkout and the payment it became
+type CheckoutTender struct {
+	PaymentID         int64           `json:"payment_id" db:"payment_id"`
+	PaymentNumber     string          `json:"payment_number" db:"payment_number"`
+	PaymentMethodID   int64           `json:"payment_method_id" db:"payment_method_id"`
+	PaymentMethodName string          `json:"payment_method_name" db:"payment_method_name"`
+	PaymentMethodType string          `json:"payment_method_type" db:"payment_method_type"`
+	TenderedAmount    decimal.Decimal `json:"tendered_amount" db:"tendered_amount"`
+	AppliedAmount     decimal.Decimal `json:"applied_amount" db:"applied_amount"`
+	ChangeAmount      decimal.Decimal `json:"change_amount" db:"change_amount"`
+	ReferenceNumber   *string         `json:"reference_number" db:"reference_number"`
+	Reversed          bool            `json:"reversed" db:"reversed"`
+}
+
+// CheckoutFilter - Filters for listing checkouts
+type CheckoutFilter struct {
+	OutletID      *int64
+	TransactionID *int64
+	ShiftID       *int64
+	DateFrom      *time.Time
+	DateTo        *time.Time
+}
+
+// CheckoutTenderRequest - What the customer handed over with one method
+type CheckoutTenderRequest struct {
+	PaymentMethodID int64           `json:"payment_method_id"`
+	Amount          decimal.Decimal `json:"amount"` // tendered; only cash may exceed what is due
+	ReferenceNumber string          `json:"reference_number"`
+}
+
+// CreateCheckoutRequest - Request for settling a transaction with several tenders
+type CreateCheckoutRequest struct {
+	TransactionID int64                   `json:"transaction_id"`
+	Tenders       []CheckoutTenderRequest `json:"tenders"`
+	Notes         string                  `json:"notes"`
+}
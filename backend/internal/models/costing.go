@@ -0,0 +1,130 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// GoodsReceipt - Goods received into stock, moving their products' cost
+type GoodsReceipt struct {
+	ReceiptID         int64              `json:"receipt_id" db:"receipt_id"`
+	ReceiptNumber     string             `json:"receipt_number" db:"receipt_number"`
+	OutletID          int64              `json:"outlet_id" db:"outlet_id"`
+	SupplierID        *int64             `json:"supplier_id" db:"supplier_id"`
+	SupplierName      *string            `json:"supplier_name" db:"supplier_name"`
+	PurchaseOrderID   *int64             `json:"purchase_order_id" db:"purchase_order_id"`
+	PONumber          *string            `json:"po_number" db:"po_number"`
+	SupplierReference *string            `json:"supplier_reference" db:"supplier_reference"` // the supplier's delivery note or invoice
+	ReceivedAt        time.Time          `json:"received_at" db:"received_at"`
+	TotalCost         decimal.Decimal    `json:"total_cost" db:"total_cost"`
+	Notes             *string            `json:"notes" db:"notes"`
+	Items             []GoodsReceiptItem `json:"items,omitempty" db:"-"`
+	CreatedAt         time.Time          `json:"created_at" db:"created_at"`
+	CreatedBy         *int64             `json:"created_by,omitempty" db:"created_by"`
+}
+
+// GoodsReceiptItem - A product received, with its cost before and after
+type GoodsReceiptItem struct {
+	ItemID                int64           `json:"item_id" db:"item_id"`
+	ReceiptID             int64           `json:"receipt_id" db:"receipt_id"`
+	ProductID             int64           `json:"product_id" db:"product_id"`
+	ProductCode           string          `json:"product_code" db:"product_code"`
+	ProductName           string          `json:"product_name" db:"product_name"`
+	PurchaseOrderDetailID *int64          `json:"purchase_order_detail_id" db:"purchase_order_detail_id"`
+	Quantity              int             `json:"quantity" db:"quantity"`
+	UnitCost              decimal.Decimal `json:"unit_cost" db:"unit_cost"`
+	TotalCost             decimal.Decimal `json:"total_cost" db:"total_cost"`
+	StockBefore           int             `json:"stock_before" db:"stock_before"`
+	CostBefore            decimal.Decimal `json:"cost_before" db:"cost_before"`
+	CostAfter             decimal.Decimal `json:"cost_after" db:"cost_after"` // the weighted average cost
+}
+
+// GoodsReceiptFilter - Filters for listing goods receipts
+type GoodsReceiptFilter struct {
+	OutletID        *int64
+	SupplierID      *int64
+	PurchaseOrderID *int64
+	ProductID       *int64
+	DateFrom        *time.Time
+	DateTo          *time.Time
+}
+
+// GoodsReceiptRequest - Request for receiving goods into stock
+type GoodsReceiptRequest struct {
+	SupplierID        *int64                    `json:"supplier_id"`
+	PurchaseOrderID   *int64                    `json:"purchase_order_id"`
+	SupplierReference string                    `json:"supplier_reference"`
+	Notes             string                    `json:"notes"`
+	Items             []GoodsReceiptItemRequest `json:"items" validate:"required,min=1"`
+}
+
+// GoodsReceiptItemRequest - A product received and what one unit cost
+type GoodsReceiptItemRequest struct {
+	ProductID             int64           `json:"product_id" validate:"required"`
+	PurchaseOrderDetailID *int64          `json:"purchase_order_detail_id"`
+	Quantity              int             `json:"quantity" validate:"required"`
+	UnitCost              decimal.Decimal `json:"unit_cost"`
+}
+
+// MarginFilter - Filters for margin reports
+type MarginFilter struct {
+	OutletID  *int64
+	ProductID *int64
+	DateFrom  time.Time
+	DateTo    time.Time
+}
+
+// MarginLine - A line's revenue before tax against what its products cost
+type MarginLine struct {
+	DetailID      int64            `json:"detail_id" db:"detail_id"`
+	ProductID     *int64           `json:"product_id" db:"product_id"`
+	ServiceID     *int64           `json:"service_id" db:"service_id"`
+	ItemName      string           `json:"item_name" db:"item_name"`
+	Quantity      decimal.Decimal  `json:"quantity" db:"quantity"` // less what was returned
+	UnitCost      *decimal.Decimal `json:"unit_cost" db:"unit_cost"`
+	Revenue       decimal.Decimal  `json:"revenue" db:"revenue"`
+	Cost          decimal.Decimal  `json:"cost" db:"cost"`
+	GrossMargin   decimal.Decimal  `json:"gross_margin" db:"-"`
+	MarginPercent decimal.Decimal  `json:"margin_percent" db:"-"`
+}
+
+// DocumentMargin - The gross margin of a transaction or service job
+type DocumentMargin struct {
+	DocumentID     int64           `json:"document_id" db:"document_id"`
+	DocumentNumber string          `json:"document_number" db:"document_number"`
+	OutletID       int64           `json:"outlet_id" db:"outlet_id"`
+	CustomerName   *string         `json:"customer_name" db:"customer_name"`
+	Date           time.Time       `json:"date" db:"date"`
+	Revenue        decimal.Decimal `json:"revenue" db:"revenue"`
+	Cost           decimal.Decimal `json:"cost" db:"cost"`
+	GrossMargin    decimal.Decimal `json:"gross_margin" db:"-"`
+	MarginPercent  decimal.Decimal `json:"margin_percent" db:"-"`
+	Lines          []MarginLine    `json:"lines,omitempty" db:"-"`
+}
+
+// ProductMargin - What a product sold for over a period against its cost
+type ProductMargin struct {
+	ProductID     int64           `json:"product_id" db:"product_id"`
+	ProductCode   string          `json:"product_code" db:"product_code"`
+	Name          string          `json:"name" db:"name"`
+	CostPrice     decimal.Decimal `json:"cost_price" db:"cost_price"` // today's weighted average cost
+	Quantity      decimal.Decimal `json:"quantity" db:"quantity"`
+	Revenue       decimal.Decimal `json:"revenue" db:"revenue"`
+	Cost          decimal.Decimal `json:"cost" db:"cost"`
+	GrossMargin   decimal.Decimal `json:"gross_margin" db:"-"`
+	MarginPercent decimal.Decimal `json:"margin_percent" db:"-"`
+}
+
+// OutletMargin - An outlet's sales over a period against their cost
+type OutletMargin struct {
+	OutletID         int64           `json:"outlet_id" db:"outlet_id"`
+	OutletName       string          `json:"outlet_name" db:"outlet_name"`
+	TransactionCount int             `json:"transaction_count" db:"transaction_count"`
+	ServiceRevenue   decimal.Decimal `json:"service_revenue" db:"service_revenue"`
+	PartsRevenue     decimal.Decimal `json:"parts_revenue" db:"parts_revenue"`
+	Revenue          decimal.Decimal `json:"revenue" db:"revenue"`
+	Cost             decimal.Decimal `json:"cost" db:"cost"`
+	GrossMargin      decimal.Decimal `json:"gross_margin" db:"-"`
+	MarginPercent    decimal.Decimal `json:"margin_percent" db:"-"`
+}
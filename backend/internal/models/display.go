@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// OutletDisplayToken - Grants a waiting room display read-only access to an outlet's queue
+type OutletDisplayToken struct {
+	DisplayTokenID int64      `json:"display_token_id" db:"display_token_id"`
+	OutletID       int64      `json:"outlet_id" db:"outlet_id"`
+	OutletName     string     `json:"outlet_name,omitempty" db:"outlet_name"`
+	Name           string     `json:"name" db:"name"`
+	Token          string     `json:"token,omitempty" db:"-"` // only returned when created
+	TokenHash      string     `json:"-" db:"token_hash"`
+	IsActive       bool       `json:"is_active" db:"is_active"`
+	LastSeenAt     *time.Time `json:"last_seen_at" db:"last_seen_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	CreatedBy      *int64     `json:"created_by,omitempty" db:"created_by"`
+}
+
+// CreateDisplayTokenRequest - Request for creating a display token
+type CreateDisplayTokenRequest struct {
+	OutletID *int64 `json:"outlet_id"` // Super Admin only, defaults to the user's outlet
+	Name     string `json:"name" validate:"required"`
+}
+
+// DisplayBoardEntry - A queue row safe to show on a public screen
+type DisplayBoardEntry struct {
+	QueueNumber    int       `json:"queue_number"`
+	VehicleNumber  string    `json:"vehicle_number"` // masked
+	Status         string    `json:"status"`
+	EstimatedStart time.Time `json:"estimated_start"`
+}
+
+// DisplayBoard - Now serving and upcoming queue numbers for an outlet display
+type DisplayBoard struct {
+	OutletName  string              `json:"outlet_name"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	NowServing  []DisplayBoardEntry `json:"now_serving"`
+	Upcoming    []DisplayBoardEntry `json:"upcoming"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// JSONPayload - Raw JSON stored in a JSONB column
+type JSONPayload []byte
+
+// Scan copies the column value so it outlives the driver's row buffer
+func (p *JSONPayload) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		*p = append(JSONPayload(nil), v...)
+	case string:
+		*p = JSONPayload(v)
+	case nil:
+		*p = nil
+	default:
+		return fmt.Errorf("cannot scan %T into JSONPayload", src)
+	}
+	return nil
+}
+
+// MarshalJSON embeds the payload as-is
+func (p JSONPayload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+	return p, nil
+}
+
+// DomainEvent - A domain event in the transactional outbox
+type DomainEvent struct {
+	EventID       int64       `json:"event_id" db:"event_id"`
+	EventType     string      `json:"event_type" db:"event_type"`
+	AggregateType string      `json:"aggregate_type" db:"aggregate_type"`
+	AggregateID   int64       `json:"aggregate_id" db:"aggregate_id"`
+	OutletID      *int64      `json:"outlet_id" db:"outlet_id"`
+	Payload       JSONPayload `json:"payload" db:"payload"`
+	Status        string      `json:"status" db:"status"` // pending, processing, processed, failed
+	Attempts      int         `json:"attempts" db:"attempts"`
+	MaxAttempts   int         `json:"max_attempts" db:"max_attempts"`
+	NextAttemptAt time.Time   `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     *string     `json:"last_error" db:"last_error"`
+	ProcessedAt   *time.Time  `json:"processed_at" db:"processed_at"`
+	OccurredAt    time.Time   `json:"occurred_at" db:"occurred_at"`
+	CreatedAt     time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at" db:"updated_at"`
+
+	// Subscribers that have already handled the event
+	DeliveredTo []string `json:"delivered_to,omitempty" db:"-"`
+}
+
+// DomainEventFilter - Filters for the domain event outbox
+type DomainEventFilter struct {
+	OutletID      *int64
+	EventType     string
+	Status        string
+	AggregateType string
+	AggregateID   *int64
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// GLAccount - An account in the chart of accounts
+type GLAccount struct {
+	AccountID     int64      `json:"account_id" db:"account_id"`
+	Code          string     `json:"code" db:"code"`
+	Name          string     `json:"name" db:"name"`
+	AccountType   string     `json:"account_type" db:"account_type"`     // asset, liability, equity, revenue, expense
+	NormalBalance string     `json:"normal_balance" db:"normal_balance"` // debit, credit
+	ParentID      *int64     `json:"parent_id" db:"parent_id"`
+	Description   string     `json:"description" db:"description"`
+	IsSystem      bool       `json:"is_system" db:"is_system"`
+	IsActive      bool       `json:"is_active" db:"is_active"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	CreatedBy     *int64     `json:"created_by,omitempty" db:"created_by"`
+}
+
+// CreateGLAccountRequest - Request for adding an account to the chart of accounts
+type CreateGLAccountRequest struct {
+	Code          string `json:"code" validate:"required"`
+	Name          string `json:"name" validate:"required"`
+	AccountType   string `json:"account_type" validate:"required"`
+	NormalBalance string `json:"normal_balance"` // defaults from the account type
+	ParentID      *int64 `json:"parent_id"`
+	Description   string `json:"description"`
+}
+
+// UpdateGLAccountRequest - Request for updating an account
+type UpdateGLAccountRequest struct {
+	Name        string  `json:"name"`
+	ParentID    *int64  `json:"parent_id"`
+	Description *string `json:"description"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// JournalEntry - A balanced journal entry in the general ledger
+type JournalEntry struct {
+	EntryID      int64           `json:"entry_id" db:"entry_id"`
+	EntryNumber  string          `json:"entry_number" db:"entry_number"`
+	OutletID     int64           `json:"outlet_id" db:"outlet_id"`
+	EntryDate    time.Time       `json:"entry_date" db:"entry_date"`
+	Description  string          `json:"description" db:"description"`
+	SourceType   string          `json:"source_type" db:"source_type"` // manual, transaction, payment, vehicle_purchase, vehicle_sale
+	SourceID     *int64          `json:"source_id" db:"source_id"`
+	SourceNumber *string         `json:"source_number" db:"source_number"`
+	TotalDebit   decimal.Decimal `json:"total_debit" db:"total_debit"`
+	TotalCredit  decimal.Decimal `json:"total_credit" db:"total_credit"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	CreatedBy    *int64          `json:"created_by,omitempty" db:"created_by"`
+
+	Lines []JournalLine `json:"lines,omitempty" db:"-"`
+}
+
+// JournalLine - A debit or credit to one account within a journal entry
+type JournalLine struct {
+	LineID      int64           `json:"line_id" db:"line_id"`
+	EntryID     int64           `json:"entry_id" db:"entry_id"`
+	AccountID   int64           `json:"account_id" db:"account_id"`
+	AccountCode string          `json:"account_code" db:"account_code"`
+	AccountName string          `json:"account_name" db:"account_name"`
+	Description *string         `json:"description" db:"description"`
+	Debit       decimal.Decimal `json:"debit" db:"debit"`
+	Credit      decimal.Decimal `json:"credit" db:"credit"`
+}
+
+// JournalEntryFilter - Filters for listing journal entries
+type JournalEntryFilter struct {
+	OutletID   *int64
+	SourceType string
+	AccountID  *int64
+	DateFrom   *time.Time
+	DateTo     *time.Time
+}
+
+// CreateJournalEntryRequest - Request for posting a manual journal entry
+type CreateJournalEntryRequest struct {
+	OutletID    *int64                     `json:"outlet_id"`                      // Super Admin only, defaults to the user's outlet
+	EntryDate   string                     `json:"entry_date" validate:"required"` // YYYY-MM-DD
+	Description string                     `json:"description" validate:"required"`
+	Lines       []CreateJournalLineRequest `json:"lines" validate:"required"`
+}
+
+// CreateJournalLineRequest - One line of a manual journal entry
+type CreateJournalLineRequest struct {
+	AccountID   int64           `json:"account_id" validate:"required"`
+	Description string          `json:"description"`
+	Debit       decimal.Decimal `json:"debit"`
+	Credit      decimal.Decimal `json:"credit"`
+}
+
+// TrialBalanceRow - An account's balances in a trial balance
+type TrialBalanceRow struct {
+	AccountID      int64           `json:"account_id" db:"account_id"`
+	Code           string          `json:"code" db:"code"`
+	Name           string          `json:"name" db:"name"`
+	AccountType    string          `json:"account_type" db:"account_type"`
+	NormalBalance  string          `json:"normal_balance" db:"normal_balance"`
+	OpeningBalance decimal.Decimal `json:"opening_balance" db:"opening_balance"` // signed in the account's normal direction
+	PeriodDebit    decimal.Decimal `json:"period_debit" db:"period_debit"`
+	PeriodCredit   decimal.Decimal `json:"period_credit" db:"period_credit"`
+	ClosingBalance decimal.Decimal `json:"closing_balance" db:"closing_balance"` // signed in the account's normal direction
+	ClosingDebit   decimal.Decimal `json:"closing_debit" db:"-"`
+	ClosingCredit  decimal.Decimal `json:"closing_credit" db:"-"`
+}
+
+// TrialBalance - Closing balances of every account with activity, per outlet and period
+type TrialBalance struct {
+	OutletID    *int64            `json:"outlet_id"`
+	DateFrom    time.Time         `json:"date_from"`
+	DateTo      time.Time         `json:"date_to"`
+	Rows        []TrialBalanceRow `json:"rows"`
+	TotalDebit  decimal.Decimal   `json:"total_debit"`
+	TotalCredit decimal.Decimal   `json:"total_credit"`
+	IsBalanced  bool              `json:"is_balanced"`
+}
+
+// GeneralLedgerLine - A posting to an account with the running balance
+type GeneralLedgerLine struct {
+	EntryID      int64           `json:"entry_id" db:"entry_id"`
+	EntryNumber  string          `json:"entry_number" db:"entry_number"`
+	EntryDate    time.Time       `json:"entry_date" db:"entry_date"`
+	OutletID     int64           `json:"outlet_id" db:"outlet_id"`
+	Description  string          `json:"description" db:"description"`
+	SourceType   string          `json:"source_type" db:"source_type"`
+	SourceNumber *string         `json:"source_number" db:"source_number"`
+	Debit        decimal.Decimal `json:"debit" db:"debit"`
+	Credit       decimal.Decimal `json:"credit" db:"credit"`
+	Balance      decimal.Decimal `json:"balance" db:"-"`
+}
+
+// GeneralLedger - Postings to one account over a period
+type GeneralLedger struct {
+	Account        GLAccount           `json:"account"`
+	OutletID       *int64              `json:"outlet_id"`
+	DateFrom       time.Time           `json:"date_from"`
+	DateTo         time.Time           `json:"date_to"`
+	OpeningBalance decimal.Decimal     `json:"opening_balance"`
+	Lines          []GeneralLedgerLine `json:"lines"`
+	TotalDebit     decimal.Decimal     `json:"total_debit"`
+	TotalCredit    decimal.Decimal     `json:"total_credit"`
+	ClosingBalance decimal.Decimal     `json:"closing_balance"`
+}
+
+// LedgerBackfillResult - Outcome of posting documents that have no journal entry yet
+type LedgerBackfillResult struct {
+	Posted int      `json:"posted"`
+	Failed int      `json:"failed"`
+	Errors []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LoyaltySettings - How much points are worth and how long they last
+type LoyaltySettings struct {
+	IsEnabled       bool            `json:"is_enabled" db:"is_enabled"`
+	PointValue      decimal.Decimal `json:"point_value" db:"point_value"` // Rupiah per point redeemed
+	MinRedeemPoints int             `json:"min_redeem_points" db:"min_redeem_points"`
+	ExpiryMonths    int             `json:"expiry_months" db:"expiry_months"` // 0 means points never expire
+	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at"`
+	UpdatedBy       *int64          `json:"updated_by,omitempty" db:"updated_by"`
+}
+
+// LoyaltyEarnRule - Points a transaction type earns per Rupiah paid
+type LoyaltyEarnRule struct {
+	RuleID          int64           `json:"rule_id" db:"rule_id"`
+	TransactionType string          `json:"transaction_type" db:"transaction_type"` // service, sparepart_sale, vehicle_sale
+	SpendAmount     decimal.Decimal `json:"spend_amount" db:"spend_amount"`
+	Points          int             `json:"points" db:"points"`
+	MinAmount       decimal.Decimal `json:"min_amount" db:"min_amount"`
+	IsActive        bool            `json:"is_active" db:"is_active"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at"`
+	CreatedBy       *int64          `json:"created_by,omitempty" db:"created_by"`
+}
+
+// LoyaltyPointEntry - One posting to a customer's points ledger
+type LoyaltyPointEntry struct {
+	EntryID           int64           `json:"entry_id" db:"entry_id"`
+	CustomerID        int64           `json:"customer_id" db:"customer_id"`
+	EntryType         string          `json:"entry_type" db:"entry_type"` // earn, earn_reversal, redeem, redeem_reversal, expire, adjust
+	Points            int             `json:"points" db:"points"`
+	Amount            decimal.Decimal `json:"amount" db:"amount"` // Rupiah paid for earned points, or value of redeemed points
+	TransactionID     *int64          `json:"transaction_id" db:"transaction_id"`
+	TransactionNumber *string         `json:"transaction_number" db:"transaction_number"`
+	PaymentID         *int64          `json:"payment_id" db:"payment_id"`
+	RefundID          *int64          `json:"refund_id" db:"refund_id"`
+	ExpiresAt         *time.Time      `json:"expires_at" db:"expires_at"`
+	Description       *string         `json:"description" db:"description"`
+	Balance           int             `json:"balance" db:"balance"` // running balance after the entry
+	CreatedAt         time.Time       `json:"created_at" db:"created_at"`
+	CreatedBy         *int64          `json:"created_by,omitempty" db:"created_by"`
+}
+
+// LoyaltyAccount - A customer's points balance and what it is worth
+type LoyaltyAccount struct {
+	CustomerID      int64           `json:"customer_id" db:"customer_id"`
+	CustomerName    string          `json:"customer_name" db:"customer_name"`
+	Balance         int             `json:"balance" db:"balance"`
+	Earned          int             `json:"earned" db:"earned"`
+	Redeemed        int             `json:"redeemed" db:"redeemed"`
+	Expired         int             `json:"expired" db:"expired"`
+	RedeemableValue decimal.Decimal `json:"redeemable_value" db:"-"`
+	NextExpiry      *time.Time      `json:"next_expiry" db:"-"`
+	ExpiringPoints  int             `json:"expiring_points" db:"-"` // points that lapse at next_expiry
+}
+
+// LoyaltyExpiryResult - Points expired by a run of the expiry job
+type LoyaltyExpiryResult struct {
+	Customers int   `json:"customers"`
+	Points    int64 `json:"points"`
+}
+
+// UpdateLoyaltySettingsRequest - Request for changing the program settings
+type UpdateLoyaltySettingsRequest struct {
+	IsEnabled       bool            `json:"is_enabled"`
+	PointValue      decimal.Decimal `json:"point_value"`
+	MinRedeemPoints int             `json:"min_redeem_points"`
+	ExpiryMonths    int             `json:"expiry_months"`
+}
+
+// LoyaltyEarnRuleRequest - Request for creating or updating an earn rule
+type LoyaltyEarnRuleRequest struct {
+	TransactionType string          `json:"transaction_type" validate:"required"`
+	SpendAmount     decimal.Decimal `json:"spend_amount"`
+	Points          int             `json:"points"`
+	MinAmount       decimal.Decimal `json:"min_amount"`
+	IsActive        *bool           `json:"is_active"`
+}
+
+// LoyaltyAdjustmentRequest - Request for adding or taking off points by hand
+type LoyaltyAdjustmentRequest struct {
+	Points int    `json:"points"`
+	Reason string `json:"reason" validate:"required"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MembershipTier - A tier customers reach by their rolling 12-month spend
+type MembershipTier struct {
+	TierID          int64           `json:"tier_id" db:"tier_id"`
+	Name            string          `json:"name" db:"name"`
+	MinSpend        decimal.Decimal `json:"min_spend" db:"min_spend"`
+	DiscountPercent decimal.Decimal `json:"discount_percent" db:"discount_percent"` // off service and sparepart invoices
+	PointMultiplier decimal.Decimal `json:"point_multiplier" db:"point_multiplier"`
+	PriorityQueue   bool            `json:"priority_queue" db:"priority_queue"`
+	IsActive        bool            `json:"is_active" db:"is_active"`
+	Members         int             `json:"members" db:"members"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at"`
+	CreatedBy       *int64          `json:"created_by,omitempty" db:"created_by"`
+}
+
+// CustomerMembership - A customer's tier, rolling spend and how far the next
+// tier is
+type CustomerMembership struct {
+	CustomerID      int64           `json:"customer_id" db:"customer_id"`
+	CustomerName    string          `json:"customer_name" db:"customer_name"`
+	TierID          *int64          `json:"tier_id" db:"tier_id"`
+	TierName        *string         `json:"tier_name" db:"tier_name"`
+	DiscountPercent decimal.Decimal `json:"discount_percent" db:"discount_percent"`
+	PointMultiplier decimal.Decimal `json:"point_multiplier" db:"point_multiplier"`
+	PriorityQueue   bool            `json:"priority_queue" db:"priority_queue"`
+	RollingSpend    decimal.Decimal `json:"rolling_spend" db:"rolling_spend"` // paid spend over the last 12 months, less returns
+	EvaluatedAt     *time.Time      `json:"evaluated_at" db:"evaluated_at"`
+	NextTier        *MembershipTier `json:"next_tier,omitempty" db:"-"`
+	SpendToNextTier decimal.Decimal `json:"spend_to_next_tier" db:"-"`
+}
+
+// CustomerTierChange - One change of a customer's tier
+type CustomerTierChange struct {
+	HistoryID    int64           `json:"history_id" db:"history_id"`
+	CustomerID   int64           `json:"customer_id" db:"customer_id"`
+	FromTierID   *int64          `json:"from_tier_id" db:"from_tier_id"`
+	FromTierName *string         `json:"from_tier_name" db:"from_tier_name"`
+	ToTierID     *int64          `json:"to_tier_id" db:"to_tier_id"`
+	ToTierName   *string         `json:"to_tier_name" db:"to_tier_name"`
+	ChangeType   string          `json:"change_type" db:"change_type"` // upgrade, downgrade
+	RollingSpend decimal.Decimal `json:"rolling_spend" db:"rolling_spend"`
+	ChangedAt    time.Time       `json:"changed_at" db:"changed_at"`
+}
+
+// MembershipEvaluationResult - Customers whose tier changed in an evaluation
+type MembershipEvaluationResult struct {
+	Customers  int `json:"customers"`
+	Upgraded   int `json:"upgraded"`
+	Downgraded int `json:"downgraded"`
+}
+
+// MembershipTierRequest - Request for creating or updating a tier
+type MembershipTierRequest struct {
+	Name            string          `json:"name" validate:"required"`
+	MinSpend        decimal.Decimal `json:"min_spend"`
+	DiscountPercent decimal.Decimal `json:"discount_percent"`
+	PointMultiplier decimal.Decimal `json:"point_multiplier"`
+	PriorityQueue   bool            `json:"priority_queue"`
+	IsActive        *bool           `json:"is_active"`
+}
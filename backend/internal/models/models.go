@@ -124,6 +124,8 @@ type Customer struct {
 	Gender         string  `json:"gender" db:"gender"`
 	CustomerType   string  `json:"customer_type" db:"customer_type"`
 	LoyaltyPoints  int     `json:"loyalty_points" db:"loyalty_points"`
+	NotificationChannel string `json:"notification_channel" db:"notification_channel"` // whatsapp, sms, email, none
+	Language       string  `json:"language" db:"language"` // id, en
 	Notes          string  `json:"notes" db:"notes"`
 	IsActive       bool    `json:"is_active" db:"is_active"`
 	
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification - A templated message in the delivery outbox
+type Notification struct {
+	NotificationID int64      `json:"notification_id" db:"notification_id"`
+	Channel        string     `json:"channel" db:"channel"` // whatsapp, sms, email, in_app
+	Recipient      string     `json:"recipient" db:"recipient"`
+	CustomerID     *int64     `json:"customer_id" db:"customer_id"`
+	UserID         *int64     `json:"user_id" db:"user_id"`
+	OutletID       *int64     `json:"outlet_id" db:"outlet_id"`
+	Template       string     `json:"template" db:"template"`
+	Language       string     `json:"language" db:"language"`
+	Subject        string     `json:"subject" db:"subject"`
+	Body           string     `json:"body" db:"body"`
+	ReferenceType  *string    `json:"reference_type" db:"reference_type"`
+	ReferenceID    *int64     `json:"reference_id" db:"reference_id"`
+	Status         string     `json:"status" db:"status"` // pending, sending, sent, failed
+	Attempts       int        `json:"attempts" db:"attempts"`
+	MaxAttempts    int        `json:"max_attempts" db:"max_attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError      *string    `json:"last_error" db:"last_error"`
+	SentAt         *time.Time `json:"sent_at" db:"sent_at"`
+	ReadAt         *time.Time `json:"read_at" db:"read_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationContact - How and in which language a customer wants to be reached
+type NotificationContact struct {
+	CustomerID int64   `db:"customer_id"`
+	Name       string  `db:"name"`
+	Phone      string  `db:"phone"`
+	Email      *string `db:"email"`
+	Channel    string  `db:"notification_channel"`
+	Language   string  `db:"language"`
+}
+
+// NotificationFilter - Filters for the notification outbox
+type NotificationFilter struct {
+	OutletID   *int64
+	Status     string
+	Channel    string
+	CustomerID *int64
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceList - Prices for some products and services that replace their own
+// prices for the sales the list applies to
+type PriceList struct {
+	PriceListID  int64               `json:"price_list_id" db:"price_list_id"`
+	Code         string              `json:"code" db:"code"`
+	Name         string              `json:"name" db:"name"`
+	Description  *string             `json:"description" db:"description"`
+	CustomerType *string             `json:"customer_type" db:"customer_type"` // individual, corporate
+	OutletID     *int64              `json:"outlet_id" db:"outlet_id"`
+	ValidFrom    *time.Time          `json:"valid_from" db:"valid_from"`
+	ValidTo      *time.Time          `json:"valid_to" db:"valid_to"`
+	Priority     int                 `json:"priority" db:"priority"`
+	IsActive     bool                `json:"is_active" db:"is_active"`
+	ItemCount    int                 `json:"item_count" db:"item_count"`
+	Customers    []PriceListCustomer `json:"customers" db:"-"` // when any, the list applies to them only
+	CreatedAt    time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at" db:"updated_at"`
+	CreatedBy    *int64              `json:"created_by,omitempty" db:"created_by"`
+}
+
+// PriceListCustomer - A customer a price list is negotiated for
+type PriceListCustomer struct {
+	CustomerID   int64     `json:"customer_id" db:"customer_id"`
+	CustomerCode string    `json:"customer_code" db:"customer_code"`
+	CustomerName string    `json:"customer_name" db:"customer_name"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// PriceListItem - A product's or service's price on a price list
+type PriceListItem struct {
+	ItemID      int64           `json:"item_id" db:"item_id"`
+	PriceListID int64           `json:"price_list_id" db:"price_list_id"`
+	ProductID   *int64          `json:"product_id" db:"product_id"`
+	ServiceID   *int64          `json:"service_id" db:"service_id"`
+	ItemCode    string          `json:"item_code" db:"item_code"`
+	ItemName    string          `json:"item_name" db:"item_name"`
+	ListPrice   decimal.Decimal `json:"list_price" db:"list_price"` // the item's own price
+	Price       decimal.Decimal `json:"price" db:"price"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+	UpdatedBy   *int64          `json:"updated_by,omitempty" db:"updated_by"`
+}
+
+// PriceHistory - A change to an item's own price or to its price on a list
+type PriceHistory struct {
+	HistoryID     int64            `json:"history_id" db:"history_id"`
+	ProductID     *int64           `json:"product_id" db:"product_id"`
+	ServiceID     *int64           `json:"service_id" db:"service_id"`
+	ItemName      string           `json:"item_name" db:"item_name"`
+	PriceListID   *int64           `json:"price_list_id" db:"price_list_id"` // empty for the item's own price
+	PriceListName *string          `json:"price_list_name" db:"price_list_name"`
+	OldPrice      *decimal.Decimal `json:"old_price" db:"old_price"`
+	NewPrice      *decimal.Decimal `json:"new_price" db:"new_price"` // empty when taken off the list
+	ChangedAt     time.Time        `json:"changed_at" db:"changed_at"`
+	ChangedBy     *int64           `json:"changed_by" db:"changed_by"`
+	ChangedByName *string          `json:"changed_by_name" db:"changed_by_name"`
+}
+
+// ResolvedPrice - The price an item sells at for a customer at an outlet
+type ResolvedPrice struct {
+	ProductID     *int64          `json:"product_id"`
+	ServiceID     *int64          `json:"service_id"`
+	ListPrice     decimal.Decimal `json:"list_price"` // the item's own price
+	Price         decimal.Decimal `json:"price"`
+	PriceListID   *int64          `json:"price_list_id"` // empty when no list applies
+	PriceListName *string         `json:"price_list_name"`
+}
+
+// PriceListFilter - Filters for listing price lists
+type PriceListFilter struct {
+	OutletID     *int64
+	CustomerType string
+	CustomerID   *int64
+	ActiveOnly   bool
+	Search       string
+}
+
+// PriceHistoryFilter - Filters for listing price history
+type PriceHistoryFilter struct {
+	ProductID   *int64
+	ServiceID   *int64
+	PriceListID *int64
+	DateFrom    *time.Time
+	DateTo      *time.Time
+}
+
+// PriceListRequest - Request for creating or updating a price list
+type PriceListRequest struct {
+	Code         string     `json:"code"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	CustomerType *string    `json:"customer_type"`
+	OutletID     *int64     `json:"outlet_id"`
+	ValidFrom    *time.Time `json:"valid_from"`
+	ValidTo      *time.Time `json:"valid_to"`
+	Priority     int        `json:"priority"`
+	IsActive     *bool      `json:"is_active"`
+}
+
+// SetPriceListItemRequest - Request for setting an item's price on a list
+type SetPriceListItemRequest struct {
+	ProductID *int64          `json:"product_id"`
+	ServiceID *int64          `json:"service_id"`
+	Price     decimal.Decimal `json:"price"`
+}
+
+// AssignPriceListCustomerRequest - Request for assigning a customer to a list
+type AssignPriceListCustomerRequest struct {
+	CustomerID int64 `json:"customer_id" validate:"required"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Promotion - A discount rule applied automatically or with a coupon when
+// transactions and service job estimates are totalled
+type Promotion struct {
+	PromotionID       int64            `json:"promotion_id" db:"promotion_id"`
+	Name              string           `json:"name" db:"name"`
+	Description       *string          `json:"description" db:"description"`
+	DiscountType      string           `json:"discount_type" db:"discount_type"` // percentage, fixed
+	DiscountValue     decimal.Decimal  `json:"discount_value" db:"discount_value"`
+	Scope             string           `json:"scope" db:"scope"` // line, invoice
+	MinSpend          decimal.Decimal  `json:"min_spend" db:"min_spend"`
+	MaxDiscount       *decimal.Decimal `json:"max_discount" db:"max_discount"`
+	ProductCategoryID *int64           `json:"product_category_id" db:"product_category_id"`
+	ServiceCategoryID *int64           `json:"service_category_id" db:"service_category_id"`
+	OutletID          *int64           `json:"outlet_id" db:"outlet_id"`
+	StartsAt          *time.Time       `json:"starts_at" db:"starts_at"`
+	EndsAt            *time.Time       `json:"ends_at" db:"ends_at"`
+	UsageLimit        *int             `json:"usage_limit" db:"usage_limit"`
+	PerCustomerLimit  *int             `json:"per_customer_limit" db:"per_customer_limit"`
+	RequiresCoupon    bool             `json:"requires_coupon" db:"requires_coupon"`
+	Priority          int              `json:"priority" db:"priority"`
+	IsActive          bool             `json:"is_active" db:"is_active"`
+	UsageCount        int              `json:"usage_count" db:"usage_count"`
+	CreatedAt         time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at" db:"updated_at"`
+	CreatedBy         *int64           `json:"created_by,omitempty" db:"created_by"`
+}
+
+// Coupon - A code that unlocks a promotion
+type Coupon struct {
+	CouponID    int64      `json:"coupon_id" db:"coupon_id"`
+	PromotionID int64      `json:"promotion_id" db:"promotion_id"`
+	Code        string     `json:"code" db:"code"`
+	UsageLimit  *int       `json:"usage_limit" db:"usage_limit"`
+	UsageCount  int        `json:"usage_count" db:"usage_count"`
+	ExpiresAt   *time.Time `json:"expires_at" db:"expires_at"`
+	IsActive    bool       `json:"is_active" db:"is_active"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CreatedBy   *int64     `json:"created_by,omitempty" db:"created_by"`
+}
+
+// PromotionRedemption - A promotion applied to a transaction
+type PromotionRedemption struct {
+	RedemptionID      int64           `json:"redemption_id" db:"redemption_id"`
+	PromotionID       int64           `json:"promotion_id" db:"promotion_id"`
+	PromotionName     string          `json:"promotion_name" db:"promotion_name"`
+	CouponID          *int64          `json:"coupon_id" db:"coupon_id"`
+	CouponCode        *string         `json:"coupon_code" db:"coupon_code"`
+	TransactionID     int64           `json:"transaction_id" db:"transaction_id"`
+	TransactionNumber string          `json:"transaction_number" db:"transaction_number"`
+	OutletID          int64           `json:"outlet_id" db:"outlet_id"`
+	CustomerID        *int64          `json:"customer_id" db:"customer_id"`
+	DiscountAmount    decimal.Decimal `json:"discount_amount" db:"discount_amount"`
+	CreatedAt         time.Time       `json:"created_at" db:"created_at"`
+	ReversedAt        *time.Time      `json:"reversed_at" db:"reversed_at"`
+}
+
+// PromotionFilter - Filters for listing promotions
+type PromotionFilter struct {
+	OutletID       *int64
+	ActiveOnly     bool
+	RequiresCoupon *bool
+	Search         string
+}
+
+// RedemptionFilter - Filters for listing redemptions
+type RedemptionFilter struct {
+	PromotionID *int64
+	OutletID    *int64
+	CustomerID  *int64
+	DateFrom    *time.Time
+	DateTo      *time.Time
+}
+
+// PromotionRequest - Request for creating or updating a promotion
+type PromotionRequest struct {
+	Name              string           `json:"name"`
+	Description       string           `json:"description"`
+	DiscountType      string           `json:"discount_type"`
+	DiscountValue     decimal.Decimal  `json:"discount_value"`
+	Scope             string           `json:"scope"`
+	MinSpend          decimal.Decimal  `json:"min_spend"`
+	MaxDiscount       *decimal.Decimal `json:"max_discount"`
+	ProductCategoryID *int64           `json:"product_category_id"`
+	ServiceCategoryID *int64           `json:"service_category_id"`
+	OutletID          *int64           `json:"outlet_id"`
+	StartsAt          *time.Time       `json:"starts_at"`
+	EndsAt            *time.Time       `json:"ends_at"`
+	UsageLimit        *int             `json:"usage_limit"`
+	PerCustomerLimit  *int             `json:"per_customer_limit"`
+	RequiresCoupon    bool             `json:"requires_coupon"`
+	Priority          int              `json:"priority"`
+	IsActive          *bool            `json:"is_active"`
+}
+
+// CreateCouponRequest - Request for creating a coupon code
+type CreateCouponRequest struct {
+	Code       string     `json:"code"`
+	UsageLimit *int       `json:"usage_limit"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// UpdateCouponRequest - Request for enabling or disabling a coupon
+type UpdateCouponRequest struct {
+	IsActive bool `json:"is_active"`
+}
+
+// ApplyCouponRequest - Request for presenting a coupon on a service job
+type ApplyCouponRequest struct {
+	CouponCode string `json:"coupon_code"`
+}
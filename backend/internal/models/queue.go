@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// Queue priority ranks, lower rank is served first
+var QueuePriorityRank = map[string]int{
+	"urgent": 0,
+	"high":   1,
+	"normal": 2,
+	"low":    3,
+}
+
+// QueueEntry - A pending or in-progress service job with its estimated schedule
+type QueueEntry struct {
+	JobID               int64      `json:"job_id" db:"job_id"`
+	JobNumber           string     `json:"job_number" db:"job_number"`
+	OutletID            int64      `json:"outlet_id" db:"outlet_id"`
+	QueueNumber         int        `json:"queue_number" db:"queue_number"`
+	Priority            string     `json:"priority" db:"priority"`
+	Status              string     `json:"status" db:"status"`
+	TechnicianID        *int64     `json:"technician_id" db:"technician_id"`
+	CustomerName        string     `json:"customer_name" db:"customer_name"`
+	PriorityMember      bool       `json:"priority_member" db:"priority_member"` // on a tier with priority queueing
+	VehicleNumber       string     `json:"vehicle_number" db:"vehicle_number"`
+	ArrivedAt           time.Time  `json:"arrived_at" db:"arrived_at"`
+	StartedAt           *time.Time `json:"started_at" db:"started_at"`
+	DurationMinutes     int        `json:"duration_minutes" db:"duration_minutes"`
+	Position            int        `json:"position"`
+	EstimatedStart      time.Time  `json:"estimated_start"`
+	EstimatedCompletion time.Time  `json:"estimated_completion"`
+	WaitMinutes         int        `json:"wait_minutes"`
+}
+
+// QueueJobLine - A service on a queued job, taking its estimated duration for every unit
+type QueueJobLine struct {
+	JobID             int64   `db:"service_job_id"`
+	EstimatedDuration int     `db:"estimated_duration"` // minutes
+	Quantity          float64 `db:"quantity"`
+}
+
+// OutletQueue - Priority-ordered queue snapshot for an outlet
+type OutletQueue struct {
+	OutletID    int64        `json:"outlet_id"`
+	Technicians int          `json:"technicians"`
+	GeneratedAt time.Time    `json:"generated_at"`
+	InProgress  []QueueEntry `json:"in_progress"`
+	Pending     []QueueEntry `json:"pending"`
+}
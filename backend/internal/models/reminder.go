@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+)
+
+// Vehicle reminder types
+const (
+	ReminderTypeService      = "service"
+	ReminderTypeInsurance    = "insurance"
+	ReminderTypeRegistration = "registration" // STNK
+)
+
+// ServiceInterval - How often a service (or any service in a category) should be repeated
+type ServiceInterval struct {
+	IntervalID        int64      `json:"interval_id" db:"interval_id"`
+	ServiceID         *int64     `json:"service_id" db:"service_id"`
+	ServiceName       *string    `json:"service_name,omitempty" db:"service_name"`
+	ServiceCategoryID *int64     `json:"service_category_id" db:"service_category_id"`
+	CategoryName      *string    `json:"category_name,omitempty" db:"category_name"`
+	IntervalDays      int        `json:"interval_days" db:"interval_days"`
+	IntervalKm        int        `json:"interval_km" db:"interval_km"`
+	IsActive          bool       `json:"is_active" db:"is_active"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	CreatedBy         *int64     `json:"created_by,omitempty" db:"created_by"`
+}
+
+// CreateServiceIntervalRequest - Request for creating a service interval
+type CreateServiceIntervalRequest struct {
+	ServiceID         *int64 `json:"service_id"`
+	ServiceCategoryID *int64 `json:"service_category_id"`
+	IntervalDays      int    `json:"interval_days"`
+	IntervalKm        int    `json:"interval_km"`
+}
+
+// UpdateServiceIntervalRequest - Request for updating a service interval
+type UpdateServiceIntervalRequest struct {
+	IntervalDays int   `json:"interval_days"`
+	IntervalKm   int   `json:"interval_km"`
+	IsActive     *bool `json:"is_active"`
+}
+
+// VehicleServiceSchedule - Service history fields of a vehicle updated when a job completes
+type VehicleServiceSchedule struct {
+	VehicleID          int64      `db:"vehicle_id"`
+	Odometer           *int64     `db:"odometer"`
+	Mileage            int64      `db:"mileage"`
+	LastServiceDate    *time.Time `db:"last_service_date"`
+	NextServiceDate    *time.Time `db:"next_service_date"`
+	NextServiceMileage *int64     `db:"next_service_mileage"`
+}
+
+// VehicleReminder - A vehicle due for attention, worked by front desk
+type VehicleReminder struct {
+	ReminderID    int64      `json:"reminder_id" db:"reminder_id"`
+	VehicleID     int64      `json:"vehicle_id" db:"vehicle_id"`
+	CustomerID    int64      `json:"customer_id" db:"customer_id"`
+	OutletID      *int64     `json:"outlet_id" db:"outlet_id"`
+	ReminderType  string     `json:"reminder_type" db:"reminder_type"`
+	DueDate       *time.Time `json:"due_date" db:"due_date"`
+	DueMileage    *int64     `json:"due_mileage" db:"due_mileage"`
+	Status        string     `json:"status" db:"status"` // pending, notified, contacted, renewed, completed, dismissed
+	NotifiedAt    *time.Time `json:"notified_at" db:"notified_at"`
+	HandledAt     *time.Time `json:"handled_at" db:"handled_at"`
+	HandledBy     *int64     `json:"handled_by" db:"handled_by"`
+	Notes         *string    `json:"notes" db:"notes"`
+	VehicleNumber string     `json:"vehicle_number" db:"vehicle_number"`
+	VehicleBrand  string     `json:"vehicle_brand" db:"vehicle_brand"`
+	VehicleModel  string     `json:"vehicle_model" db:"vehicle_model"`
+	Mileage       int64      `json:"mileage" db:"mileage"`
+	CustomerName  string     `json:"customer_name" db:"customer_name"`
+	CustomerPhone string     `json:"customer_phone" db:"customer_phone"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// UpdateReminderStatusRequest - Request for working a reminder from the front desk
+type UpdateReminderStatusRequest struct {
+	Status       string `json:"status" validate:"required"` // contacted, renewed, dismissed
+	Notes        string `json:"notes"`
+	RenewedUntil string `json:"renewed_until"` // new expiry date (YYYY-MM-DD) for renewed documents
+}
+
+// ReminderFilter - Filters for the reminder worklist
+type ReminderFilter struct {
+	OutletID     *int64
+	ReminderType string
+	Status       string
+	DueWithin    *int // days from today
+}
+
+// ReminderScanResult - Outcome of a reminder scan
+type ReminderScanResult struct {
+	Generated int `json:"generated"`
+	Notified  int `json:"notified"`
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SalesReturn - Sold spareparts brought back by the customer
+type SalesReturn struct {
+	ReturnID          int64             `json:"return_id" db:"return_id"`
+	ReturnNumber      string            `json:"return_number" db:"return_number"`
+	TransactionID     int64             `json:"transaction_id" db:"transaction_id"`
+	TransactionNumber string            `json:"transaction_number" db:"transaction_number"`
+	OutletID          int64             `json:"outlet_id" db:"outlet_id"`
+	CustomerID        *int64            `json:"customer_id" db:"customer_id"`
+	Reason            string            `json:"reason" db:"reason"`
+	Restock           bool              `json:"restock" db:"restock"`
+	SubtotalAmount    decimal.Decimal   `json:"subtotal_amount" db:"subtotal_amount"`
+	DiscountAmount    decimal.Decimal   `json:"discount_amount" db:"discount_amount"`
+	TaxAmount         decimal.Decimal   `json:"tax_amount" db:"tax_amount"`
+	TotalAmount       decimal.Decimal   `json:"total_amount" db:"total_amount"`
+	CostAmount        decimal.Decimal   `json:"cost_amount" db:"cost_amount"`
+	Settlement        string            `json:"settlement" db:"settlement"` // none, refund, credit_note
+	ReturnDate        time.Time         `json:"return_date" db:"return_date"`
+	Notes             *string           `json:"notes" db:"notes"`
+	CreatedAt         time.Time         `json:"created_at" db:"created_at"`
+	CreatedBy         *int64            `json:"created_by,omitempty" db:"created_by"`
+	Items             []SalesReturnItem `json:"items,omitempty" db:"-"`
+	Refund            *Refund           `json:"refund,omitempty" db:"-"`
+	CreditNote        *CreditNote       `json:"credit_note,omitempty" db:"-"`
+}
+
+// SalesReturnItem - A returned transaction line
+type SalesReturnItem struct {
+	ReturnItemID        int64           `json:"return_item_id" db:"return_item_id"`
+	ReturnID            int64           `json:"return_id" db:"return_id"`
+	TransactionDetailID int64           `json:"transaction_detail_id" db:"transaction_detail_id"`
+	ProductID           int64           `json:"product_id" db:"product_id"`
+	ProductName         string          `json:"product_name" db:"product_name"`
+	Quantity            decimal.Decimal `json:"quantity" db:"quantity"`
+	UnitPrice           decimal.Decimal `json:"unit_price" db:"unit_price"`
+	TotalPrice          decimal.Decimal `json:"total_price" db:"total_price"`
+	TaxCodeID           *int64          `json:"tax_code_id" db:"tax_code_id"`
+	TaxRate             decimal.Decimal `json:"tax_rate" db:"tax_rate"`
+	NetAmount           decimal.Decimal `json:"net_amount" db:"net_amount"`
+	DiscountAmount      decimal.Decimal `json:"discount_amount" db:"discount_amount"`
+	TaxAmount           decimal.Decimal `json:"tax_amount" db:"tax_amount"`
+	UnitCost            decimal.Decimal `json:"unit_cost" db:"unit_cost"`
+}
+
+// SalesReturnFilter - Filters for listing returns
+type SalesReturnFilter struct {
+	OutletID      *int64
+	TransactionID *int64
+	CustomerID    *int64
+	DateFrom      *time.Time
+	DateTo        *time.Time
+}
+
+// Refund - Money paid back to a customer
+type Refund struct {
+	RefundID          int64           `json:"refund_id" db:"refund_id"`
+	RefundNumber      string          `json:"refund_number" db:"refund_number"`
+	TransactionID     int64           `json:"transaction_id" db:"transaction_id"`
+	TransactionNumber string          `json:"transaction_number" db:"transaction_number"`
+	OutletID          int64           `json:"outlet_id" db:"outlet_id"`
+	PaymentID         *int64          `json:"payment_id" db:"payment_id"`
+	ReturnID          *int64          `json:"return_id" db:"return_id"`
+	PaymentMethodID   int64           `json:"payment_method_id" db:"payment_method_id"`
+	PaymentMethodName string          `json:"payment_method_name" db:"payment_method_name"`
+	Amount            decimal.Decimal `json:"amount" db:"amount"`
+	Reason            string          `json:"reason" db:"reason"`
+	RefundDate        time.Time       `json:"refund_date" db:"refund_date"`
+	ReferenceNumber   *string         `json:"reference_number" db:"reference_number"`
+	Notes             *string         `json:"notes" db:"notes"`
+	ShiftID           *int64          `json:"shift_id" db:"shift_id"`
+	CreatedAt         time.Time       `json:"created_at" db:"created_at"`
+	CreatedBy         *int64          `json:"created_by,omitempty" db:"created_by"`
+}
+
+// RefundFilter - Filters for listing refunds
+type RefundFilter struct {
+	OutletID      *int64
+	TransactionID *int64
+	PaymentID     *int64
+	DateFrom      *time.Time
+	DateTo        *time.Time
+}
+
+// CreditNote - Store credit owed to a customer
+type CreditNote struct {
+	CreditNoteID      int64                   `json:"credit_note_id" db:"credit_note_id"`
+	CreditNoteNumber  string                  `json:"credit_note_number" db:"credit_note_number"`
+	CustomerID        int64                   `json:"customer_id" db:"customer_id"`
+	CustomerName      string                  `json:"customer_name" db:"customer_name"`
+	OutletID          int64                   `json:"outlet_id" db:"outlet_id"`
+	TransactionID     int64                   `json:"transaction_id" db:"transaction_id"`
+	TransactionNumber string                  `json:"transaction_number" db:"transaction_number"`
+	ReturnID          *int64                  `json:"return_id" db:"return_id"`
+	Amount            decimal.Decimal         `json:"amount" db:"amount"`
+	RemainingAmount   decimal.Decimal         `json:"remaining_amount" db:"remaining_amount"`
+	Status            string                  `json:"status" db:"status"` // open, applied
+	Reason            string                  `json:"reason" db:"reason"`
+	IssueDate         time.Time               `json:"issue_date" db:"issue_date"`
+	CreatedAt         time.Time               `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time               `json:"updated_at" db:"updated_at"`
+	CreatedBy         *int64                  `json:"created_by,omitempty" db:"created_by"`
+	Applications      []CreditNoteApplication `json:"applications,omitempty" db:"-"`
+}
+
+// CreditNoteApplication - Store credit used to pay a transaction
+type CreditNoteApplication struct {
+	ApplicationID     int64           `json:"application_id" db:"application_id"`
+	CreditNoteID      int64           `json:"credit_note_id" db:"credit_note_id"`
+	TransactionID     int64           `json:"transaction_id" db:"transaction_id"`
+	TransactionNumber string          `json:"transaction_number" db:"transaction_number"`
+	Amount            decimal.Decimal `json:"amount" db:"amount"`
+	AppliedAt         time.Time       `json:"applied_at" db:"applied_at"`
+	AppliedBy         *int64          `json:"applied_by,omitempty" db:"applied_by"`
+}
+
+// CreditNoteFilter - Filters for listing credit notes
+type CreditNoteFilter struct {
+	OutletID   *int64
+	CustomerID *int64
+	Status     string
+}
+
+// TransactionBalance - What a transaction is owed after returns, and what
+// has been paid towards it after refunds and store credit
+type TransactionBalance struct {
+	TransactionID     int64           `json:"transaction_id" db:"transaction_id"`
+	TransactionNumber string          `json:"transaction_number" db:"transaction_number"`
+	OutletID          int64           `json:"outlet_id" db:"outlet_id"`
+	CustomerID        *int64          `json:"customer_id" db:"customer_id"`
+	TotalAmount       decimal.Decimal `json:"total_amount" db:"total_amount"`
+	ReturnedAmount    decimal.Decimal `json:"returned_amount" db:"returned_amount"`
+	PaidAmount        decimal.Decimal `json:"paid_amount" db:"paid_amount"`
+	RefundedAmount    decimal.Decimal `json:"refunded_amount" db:"refunded_amount"`
+	CreditIssued      decimal.Decimal `json:"credit_issued" db:"credit_issued"`
+	CreditApplied     decimal.Decimal `json:"credit_applied" db:"credit_applied"`
+	NetAmount         decimal.Decimal `json:"net_amount" db:"-"`
+	NetPaid           decimal.Decimal `json:"net_paid" db:"-"`
+	Outstanding       decimal.Decimal `json:"outstanding" db:"-"`
+	PaymentStatus     string          `json:"payment_status" db:"payment_status"`
+}
+
+// CreateSalesReturnRequest - Request for returning sold items
+type CreateSalesReturnRequest struct {
+	TransactionID   int64                    `json:"transaction_id"`
+	Items           []SalesReturnItemRequest `json:"items"`
+	Reason          string                   `json:"reason"`
+	Restock         *bool                    `json:"restock"`    // defaults to true
+	Settlement      string                   `json:"settlement"` // refund or credit_note, when the customer has paid for the items
+	PaymentMethodID *int64                   `json:"payment_method_id"`
+	Notes           string                   `json:"notes"`
+}
+
+// SalesReturnItemRequest - Quantity of a transaction line to return
+type SalesReturnItemRequest struct {
+	TransactionDetailID int64           `json:"transaction_detail_id"`
+	Quantity            decimal.Decimal `json:"quantity"`
+}
+
+// CreateRefundRequest - Request for paying money back to a customer
+type CreateRefundRequest struct {
+	TransactionID   int64           `json:"transaction_id"`
+	PaymentID       *int64          `json:"payment_id"`
+	PaymentMethodID *int64          `json:"payment_method_id"` // defaults to the payment's method
+	Amount          decimal.Decimal `json:"amount"`
+	Reason          string          `json:"reason"`
+	ReferenceNumber string          `json:"reference_number"`
+	Notes           string          `json:"notes"`
+}
+
+// ApplyCreditNoteRequest - Request for paying a transaction with store credit
+type ApplyCreditNoteRequest struct {
+	TransactionID int64           `json:"transaction_id"`
+	Amount        decimal.Decimal `json:"amount"` // defaults to the lesser of the credit and the amount outstanding
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// SchedulerJob - A registered background job and its next scheduled run
+type SchedulerJob struct {
+	Name        string        `json:"name"`
+	Schedule    string        `json:"schedule"`
+	Description string        `json:"description"`
+	NextRunAt   time.Time     `json:"next_run_at"`
+	Running     bool          `json:"running"`
+	LastRun     *SchedulerRun `json:"last_run"`
+}
+
+// SchedulerRun - One execution of a background job
+type SchedulerRun struct {
+	RunID        int64      `json:"run_id" db:"run_id"`
+	JobName      string     `json:"job_name" db:"job_name"`
+	Trigger      string     `json:"trigger" db:"trigger"` // schedule, manual
+	ScheduledFor time.Time  `json:"scheduled_for" db:"scheduled_for"`
+	Instance     string     `json:"instance" db:"instance"`
+	Status       string     `json:"status" db:"status"` // running, succeeded, failed
+	Summary      *string    `json:"summary" db:"summary"`
+	Error        *string    `json:"error" db:"error"`
+	StartedAt    time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at" db:"finished_at"`
+	DurationMs   *int64     `json:"duration_ms" db:"duration_ms"`
+}
+
+// SchedulerRunFilter - Filters for the job run history
+type SchedulerRunFilter struct {
+	JobName string
+	Status  string
+	Trigger string
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ServicePackage - Services and products sold together at a bundle price
+type ServicePackage struct {
+	PackageID       int64                     `json:"package_id" db:"package_id"`
+	PackageCode     string                    `json:"package_code" db:"package_code"`
+	Name            string                    `json:"name" db:"name"`
+	Description     *string                   `json:"description" db:"description"`
+	PricingType     string                    `json:"pricing_type" db:"pricing_type"` // fixed, discount
+	PackagePrice    *decimal.Decimal          `json:"package_price" db:"package_price"`
+	DiscountPercent *decimal.Decimal          `json:"discount_percent" db:"discount_percent"`
+	OutletID        *int64                    `json:"outlet_id" db:"outlet_id"`
+	IsActive        bool                      `json:"is_active" db:"is_active"`
+	ListAmount      decimal.Decimal           `json:"list_amount" db:"-"` // components at today's list prices
+	Price           decimal.Decimal           `json:"price" db:"-"`       // what one package sells for today
+	CreatedAt       time.Time                 `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time                 `json:"updated_at" db:"updated_at"`
+	CreatedBy       *int64                    `json:"created_by,omitempty" db:"created_by"`
+	Components      []ServicePackageComponent `json:"components,omitempty" db:"-"`
+}
+
+// ServicePackageComponent - A service or product in a package
+type ServicePackageComponent struct {
+	ComponentID int64           `json:"component_id" db:"component_id"`
+	PackageID   int64           `json:"package_id" db:"package_id"`
+	ProductID   *int64          `json:"product_id" db:"product_id"`
+	ServiceID   *int64          `json:"service_id" db:"service_id"`
+	ItemCode    string          `json:"item_code" db:"item_code"`
+	ItemName    string          `json:"item_name" db:"item_name"`
+	Quantity    decimal.Decimal `json:"quantity" db:"quantity"`
+	ListPrice   decimal.Decimal `json:"list_price" db:"list_price"` // the item's current price
+	SortOrder   int             `json:"sort_order" db:"sort_order"`
+}
+
+// DocumentPackage - A package sold on a transaction or put on a service job.
+// Its component lines carry their share of the package amount.
+type DocumentPackage struct {
+	DocumentPackageID int64           `json:"document_package_id" db:"document_package_id"`
+	DocumentID        int64           `json:"document_id" db:"document_id"` // transaction or service job
+	PackageID         int64           `json:"package_id" db:"package_id"`
+	PackageCode       string          `json:"package_code" db:"package_code"`
+	PackageName       string          `json:"package_name" db:"package_name"`
+	Quantity          decimal.Decimal `json:"quantity" db:"quantity"`
+	ListAmount        decimal.Decimal `json:"list_amount" db:"list_amount"`
+	PackageAmount     decimal.Decimal `json:"package_amount" db:"package_amount"`
+	CreatedAt         time.Time       `json:"created_at" db:"created_at"`
+}
+
+// ServicePackageFilter - Filters for listing packages
+type ServicePackageFilter struct {
+	OutletID   *int64
+	ActiveOnly bool
+	Search     string
+}
+
+// PackageSalesFilter - Filters for the package sales report
+type PackageSalesFilter struct {
+	PackageID *int64
+	OutletID  *int64
+	DateFrom  time.Time
+	DateTo    time.Time
+}
+
+// PackageSales - What a package sold for over a period and how its revenue
+// was allocated to its components. Voided transactions are left out.
+type PackageSales struct {
+	PackageID     int64                   `json:"package_id" db:"package_id"`
+	PackageCode   string                  `json:"package_code" db:"package_code"`
+	PackageName   string                  `json:"package_name" db:"package_name"`
+	Quantity      decimal.Decimal         `json:"quantity" db:"quantity"`
+	ListAmount    decimal.Decimal         `json:"list_amount" db:"list_amount"`
+	PackageAmount decimal.Decimal         `json:"package_amount" db:"package_amount"`
+	Components    []PackageComponentSales `json:"components" db:"-"`
+}
+
+// PackageComponentSales - A component's share of a package's sales
+type PackageComponentSales struct {
+	PackageID       int64           `json:"-" db:"package_id"`
+	ProductID       *int64          `json:"product_id" db:"product_id"`
+	ServiceID       *int64          `json:"service_id" db:"service_id"`
+	ItemName        string          `json:"item_name" db:"item_name"`
+	Quantity        decimal.Decimal `json:"quantity" db:"quantity"`
+	ListAmount      decimal.Decimal `json:"list_amount" db:"list_amount"`
+	AllocatedAmount decimal.Decimal `json:"allocated_amount" db:"allocated_amount"`
+}
+
+// ServicePackageRequest - Request for creating or updating a package. The
+// components given replace the package's components.
+type ServicePackageRequest struct {
+	PackageCode     string                           `json:"package_code"`
+	Name            string                           `json:"name"`
+	Description     string                           `json:"description"`
+	PricingType     string                           `json:"pricing_type"`
+	PackagePrice    *decimal.Decimal                 `json:"package_price"`
+	DiscountPercent *decimal.Decimal                 `json:"discount_percent"`
+	OutletID        *int64                           `json:"outlet_id"`
+	IsActive        *bool                            `json:"is_active"`
+	Components      []ServicePackageComponentRequest `json:"components"`
+}
+
+// ServicePackageComponentRequest - A service or product in a package request
+type ServicePackageComponentRequest struct {
+	ProductID *int64          `json:"product_id"`
+	ServiceID *int64          `json:"service_id"`
+	Quantity  decimal.Decimal `json:"quantity"`
+}
+
+// AddServiceJobPackageRequest - Request for putting a package on a service job
+type AddServiceJobPackageRequest struct {
+	PackageID int64           `json:"package_id" validate:"required"`
+	Quantity  decimal.Decimal `json:"quantity"` // defaults to 1
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CashierShift - A cashier's session at the till, from opening float to counted close
+type CashierShift struct {
+	ShiftID       int64            `json:"shift_id" db:"shift_id"`
+	ShiftNumber   string           `json:"shift_number" db:"shift_number"`
+	OutletID      int64            `json:"outlet_id" db:"outlet_id"`
+	UserID        int64            `json:"user_id" db:"user_id"`
+	UserName      string           `json:"user_name" db:"user_name"`
+	Status        string           `json:"status" db:"status"` // open, closed, approved
+	OpeningFloat  decimal.Decimal  `json:"opening_float" db:"opening_float"`
+	OpenedAt      time.Time        `json:"opened_at" db:"opened_at"`
+	OpeningNotes  *string          `json:"opening_notes" db:"opening_notes"`
+	ExpectedCash  *decimal.Decimal `json:"expected_cash" db:"expected_cash"`
+	CountedCash   *decimal.Decimal `json:"counted_cash" db:"counted_cash"`
+	CashVariance  *decimal.Decimal `json:"cash_variance" db:"cash_variance"`
+	ClosedAt      *time.Time       `json:"closed_at" db:"closed_at"`
+	ClosedBy      *int64           `json:"closed_by" db:"closed_by"`
+	ClosingNotes  *string          `json:"closing_notes" db:"closing_notes"`
+	ApprovedAt    *time.Time       `json:"approved_at" db:"approved_at"`
+	ApprovedBy    *int64           `json:"approved_by" db:"approved_by"`
+	ApprovalNotes *string          `json:"approval_notes" db:"approval_notes"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// ShiftFilter - Filters for listing cashier shifts
+type ShiftFilter struct {
+	OutletID *int64
+	UserID   *int64
+	Status   string
+	DateFrom *time.Time
+	DateTo   *time.Time
+}
+
+// ShiftDenomination - Count of one note or coin in the drawer
+type ShiftDenomination struct {
+	Denomination decimal.Decimal `json:"denomination" db:"denomination"`
+	Quantity     int             `json:"quantity" db:"quantity"`
+	Amount       decimal.Decimal `json:"amount" db:"amount"`
+}
+
+// ShiftTender - Expected and counted totals of one payment method in a shift
+type ShiftTender struct {
+	PaymentMethodID   int64           `json:"payment_method_id" db:"payment_method_id"`
+	PaymentMethodName string          `json:"payment_method_name" db:"payment_method_name"`
+	PaymentMethodType string          `json:"payment_method_type" db:"payment_method_type"`
+	PaymentCount      int             `json:"payment_count" db:"payment_count"`
+	ExpectedAmount    decimal.Decimal `json:"expected_amount" db:"expected_amount"`
+	CountedAmount     decimal.Decimal `json:"counted_amount" db:"counted_amount"`
+	Variance          decimal.Decimal `json:"variance" db:"variance"`
+}
+
+// ShiftPayment - A payment taken during a shift
+type ShiftPayment struct {
+	PaymentID         int64           `json:"payment_id" db:"payment_id"`
+	PaymentNumber     string          `json:"payment_number" db:"payment_number"`
+	TransactionNumber string          `json:"transaction_number" db:"transaction_number"`
+	PaymentMethodName string          `json:"payment_method_name" db:"payment_method_name"`
+	PaymentMethodType string          `json:"payment_method_type" db:"payment_method_type"`
+	Amount            decimal.Decimal `json:"amount" db:"amount"`
+	PaymentDate       time.Time       `json:"payment_date" db:"payment_date"`
+}
+
+// ShiftCashSummary - Reconciliation of the cash drawer
+type ShiftCashSummary struct {
+	OpeningFloat decimal.Decimal  `json:"opening_float"`
+	CashIn       decimal.Decimal  `json:"cash_in"`
+	CashOut      decimal.Decimal  `json:"cash_out"`
+	Expected     decimal.Decimal  `json:"expected"`
+	Counted      *decimal.Decimal `json:"counted"`
+	Variance     *decimal.Decimal `json:"variance"`
+}
+
+// ShiftReport - Everything taken and counted in a shift
+type ShiftReport struct {
+	Shift          CashierShift        `json:"shift"`
+	Cash           ShiftCashSummary    `json:"cash"`
+	Denominations  []ShiftDenomination `json:"denominations"`
+	Tenders        []ShiftTender       `json:"tenders"`
+	Payments       []ShiftPayment      `json:"payments"`
+	TotalCollected decimal.Decimal     `json:"total_collected"`
+	TotalVariance  decimal.Decimal     `json:"total_variance"`
+}
+
+// OpenShiftRequest - Request for opening a cashier shift
+type OpenShiftRequest struct {
+	OutletID     *int64          `json:"outlet_id"` // Super Admin only
+	OpeningFloat decimal.Decimal `json:"opening_float"`
+	Notes        string          `json:"notes"`
+}
+
+// CloseShiftRequest - Request for closing a cashier shift with the counted drawer
+type CloseShiftRequest struct {
+	Denominations []ShiftDenominationCount `json:"denominations"`
+	Tenders       []ShiftTenderCount       `json:"tenders"` // non-cash payment methods, e.g. card settlement totals
+	Notes         string                   `json:"notes"`
+}
+
+// ShiftDenominationCount - Number of one note or coin counted
+type ShiftDenominationCount struct {
+	Denomination decimal.Decimal `json:"denomination"`
+	Quantity     int             `json:"quantity"`
+}
+
+// ShiftTenderCount - Counted total of a non-cash payment method
+type ShiftTenderCount struct {
+	PaymentMethodID int64           `json:"payment_method_id"`
+	CountedAmount   decimal.Decimal `json:"counted_amount"`
+}
+
+// ApproveShiftRequest - Request for signing off a closed shift
+type ApproveShiftRequest struct {
+	Notes string `json:"notes"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StoredValueAccount - A customer's prepaid deposit or a gift voucher
+type StoredValueAccount struct {
+	AccountID    int64           `json:"account_id" db:"account_id"`
+	AccountType  string          `json:"account_type" db:"account_type"` // deposit, voucher
+	CustomerID   *int64          `json:"customer_id" db:"customer_id"`
+	CustomerName *string         `json:"customer_name" db:"customer_name"`
+	Code         *string         `json:"code" db:"code"` // vouchers only
+	SingleUse    bool            `json:"single_use" db:"single_use"`
+	Balance      decimal.Decimal `json:"balance" db:"balance"`
+	Status       string          `json:"status" db:"status"` // active, used, expired
+	ExpiresAt    *time.Time      `json:"expires_at" db:"expires_at"`
+	OutletID     int64           `json:"outlet_id" db:"outlet_id"`
+	Notes        *string         `json:"notes" db:"notes"`
+	TopUps       decimal.Decimal `json:"top_ups" db:"top_ups"`
+	Redeemed     decimal.Decimal `json:"redeemed" db:"redeemed"` // net of redemptions given back
+	Expired      decimal.Decimal `json:"expired" db:"expired"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at" db:"updated_at"`
+	CreatedBy    *int64          `json:"created_by,omitempty" db:"created_by"`
+}
+
+// StoredValueEntry - One posting to a deposit or voucher
+type StoredValueEntry struct {
+	EntryID           int64           `json:"entry_id" db:"entry_id"`
+	AccountID         int64           `json:"account_id" db:"account_id"`
+	EntryType         string          `json:"entry_type" db:"entry_type"` // top_up, redeem, redeem_reversal, expire, expire_reversal
+	Amount            decimal.Decimal `json:"amount" db:"amount"`
+	OutletID          int64           `json:"outlet_id" db:"outlet_id"`
+	TransactionID     *int64          `json:"transaction_id" db:"transaction_id"`
+	TransactionNumber *string         `json:"transaction_number" db:"transaction_number"`
+	PaymentID         *int64          `json:"payment_id" db:"payment_id"`
+	RefundID          *int64          `json:"refund_id" db:"refund_id"`
+	PaymentMethodID   *int64          `json:"payment_method_id" db:"payment_method_id"` // what a top-up was paid with
+	ShiftID           *int64          `json:"shift_id" db:"shift_id"`
+	Description       *string         `json:"description" db:"description"`
+	Balance           decimal.Decimal `json:"balance" db:"balance"` // running balance after the entry
+	CreatedAt         time.Time       `json:"created_at" db:"created_at"`
+	CreatedBy         *int64          `json:"created_by,omitempty" db:"created_by"`
+}
+
+// StoredValueFilter - Filters for listing deposits and vouchers
+type StoredValueFilter struct {
+	AccountType string
+	CustomerID  *int64
+	Status      string
+	Search      string
+}
+
+// StoredValueExpiryResult - Balances lapsed by a run of the expiry job
+type StoredValueExpiryResult struct {
+	Accounts int             `json:"accounts"`
+	Amount   decimal.Decimal `json:"amount"`
+}
+
+// StoredValueTopUpRequest - Request for adding value to a deposit or voucher
+type StoredValueTopUpRequest struct {
+	Amount          decimal.Decimal `json:"amount"`
+	PaymentMethodID int64           `json:"payment_method_id" validate:"required"`
+	ExpiresAt       *time.Time      `json:"expires_at"` // replaces the account's expiry when set
+	Notes           *string         `json:"notes"`
+	OutletID        *int64          `json:"outlet_id"` // Super Admin only
+}
+
+// IssueVoucherRequest - Request for selling a gift voucher
+type IssueVoucherRequest struct {
+	Amount          decimal.Decimal `json:"amount"`
+	PaymentMethodID int64           `json:"payment_method_id" validate:"required"`
+	SingleUse       bool            `json:"single_use"`
+	CustomerID      *int64          `json:"customer_id"`
+	Code            string          `json:"code"` // generated when empty
+	ExpiresAt       *time.Time      `json:"expires_at"`
+	Notes           *string         `json:"notes"`
+	OutletID        *int64          `json:"outlet_id"` // Super Admin only
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TaxCode - A sales tax rate assignable to products and services
+type TaxCode struct {
+	TaxCodeID   int64           `json:"tax_code_id" db:"tax_code_id"`
+	Code        string          `json:"code" db:"code"`
+	Name        string          `json:"name" db:"name"`
+	Rate        decimal.Decimal `json:"rate" db:"rate"` // percent
+	Description *string         `json:"description" db:"description"`
+	IsActive    bool            `json:"is_active" db:"is_active"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+	CreatedBy   *int64          `json:"created_by,omitempty" db:"created_by"`
+}
+
+// OutletTaxSettings - How an outlet's prices are entered and the tax code of
+// items without one of their own
+type OutletTaxSettings struct {
+	OutletID         int64    `json:"outlet_id" db:"outlet_id"`
+	OutletName       string   `json:"outlet_name" db:"outlet_name"`
+	PricesIncludeTax bool     `json:"prices_include_tax" db:"prices_include_tax"`
+	DefaultTaxCodeID *int64   `json:"default_tax_code_id" db:"default_tax_code_id"`
+	DefaultTaxCode   *TaxCode `json:"default_tax_code,omitempty" db:"-"`
+}
+
+// TaxSummaryLine - The taxable value and tax of a document for one tax code.
+// Lines without a tax code are grouped with TaxCodeID nil.
+type TaxSummaryLine struct {
+	TaxCodeID     *int64          `json:"tax_code_id" db:"tax_code_id"`
+	Code          string          `json:"code" db:"code"`
+	Name          string          `json:"name" db:"name"`
+	Rate          decimal.Decimal `json:"rate" db:"rate"`
+	TaxableAmount decimal.Decimal `json:"taxable_amount" db:"taxable_amount"`
+	TaxAmount     decimal.Decimal `json:"tax_amount" db:"tax_amount"`
+}
+
+// TaxReportLine - Output tax of a period for one tax code, with returns and
+// voids taken off
+type TaxReportLine struct {
+	TaxCodeID      *int64          `json:"tax_code_id" db:"tax_code_id"`
+	Code           string          `json:"code" db:"code"`
+	Name           string          `json:"name" db:"name"`
+	Rate           decimal.Decimal `json:"rate" db:"rate"`
+	SalesTaxable   decimal.Decimal `json:"sales_taxable" db:"sales_taxable"`
+	SalesTax       decimal.Decimal `json:"sales_tax" db:"sales_tax"`
+	ReturnsTaxable decimal.Decimal `json:"returns_taxable" db:"returns_taxable"`
+	ReturnsTax     decimal.Decimal `json:"returns_tax" db:"returns_tax"`
+	VoidsTaxable   decimal.Decimal `json:"voids_taxable" db:"voids_taxable"`
+	VoidsTax       decimal.Decimal `json:"voids_tax" db:"voids_tax"`
+	NetTaxable     decimal.Decimal `json:"net_taxable" db:"-"`
+	NetTax         decimal.Decimal `json:"net_tax" db:"-"`
+}
+
+// TaxReport - Output tax of a period by tax code
+type TaxReport struct {
+	OutletID   *int64          `json:"outlet_id"`
+	DateFrom   string          `json:"date_from"`
+	DateTo     string          `json:"date_to"`
+	Lines      []TaxReportLine `json:"lines"`
+	NetTaxable decimal.Decimal `json:"net_taxable"`
+	NetTax     decimal.Decimal `json:"net_tax"`
+}
+
+// TaxReportFilter - Filters for the tax report
+type TaxReportFilter struct {
+	OutletID *int64
+	DateFrom time.Time
+	DateTo   time.Time
+}
+
+// CreateTaxCodeRequest - Request for creating a tax code
+type CreateTaxCodeRequest struct {
+	Code        string          `json:"code"`
+	Name        string          `json:"name"`
+	Rate        decimal.Decimal `json:"rate"`
+	Description string          `json:"description"`
+}
+
+// UpdateTaxCodeRequest - Request for updating a tax code. Changing the rate
+// only affects documents created afterwards.
+type UpdateTaxCodeRequest struct {
+	Name        string          `json:"name"`
+	Rate        decimal.Decimal `json:"rate"`
+	Description string          `json:"description"`
+	IsActive    bool            `json:"is_active"`
+}
+
+// AssignTaxCodeRequest - Request for assigning a tax code to products and
+// services
+type AssignTaxCodeRequest struct {
+	ProductIDs []int64 `json:"product_ids"`
+	ServiceIDs []int64 `json:"service_ids"`
+}
+
+// UpdateOutletTaxSettingsRequest - Request for updating an outlet's tax
+// settings
+type UpdateOutletTaxSettingsRequest struct {
+	PricesIncludeTax bool   `json:"prices_include_tax"`
+	DefaultTaxCodeID *int64 `json:"default_tax_code_id"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// JobTrackingStatus - A customer-visible status change, without staff notes
+type JobTrackingStatus struct {
+	Status    string    `json:"status" db:"status"`
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// JobTracking - Public view of a service job behind a tracking link
+type JobTracking struct {
+	JobID               int64               `json:"-" db:"job_id"`
+	JobNumber           string              `json:"job_number" db:"job_number"`
+	OutletName          string              `json:"outlet_name" db:"outlet_name"`
+	VehicleNumber       string              `json:"vehicle_number" db:"vehicle_number"` // masked
+	VehicleBrand        string              `json:"vehicle_brand" db:"vehicle_brand"`
+	VehicleModel        string              `json:"vehicle_model" db:"vehicle_model"`
+	QueueNumber         int                 `json:"queue_number" db:"queue_number"`
+	Status              string              `json:"status" db:"status"`
+	ReceivedAt          time.Time           `json:"received_at" db:"received_at"`
+	EstimatedStart      *time.Time          `json:"estimated_start" db:"estimated_start"`
+	EstimatedCompletion *time.Time          `json:"estimated_completion" db:"estimated_completion"`
+	ActualCompletion    *time.Time          `json:"actual_completion" db:"actual_completion"`
+	QueuePosition       *int                `json:"queue_position,omitempty"`
+	FinalAmount         float64             `json:"final_amount" db:"final_amount"`
+	Timeline            []JobTrackingStatus `json:"timeline"`
+}
+
+// JobTrackingLink - Current tracking token of a service job
+type JobTrackingLink struct {
+	JobID         int64  `json:"job_id"`
+	TrackingToken string `json:"tracking_token"`
+	TrackingPath  string `json:"tracking_path"`
+}
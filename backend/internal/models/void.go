@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// VoidTransactionRequest - Request for voiding a transaction
+type VoidTransactionRequest struct {
+	Reason          string `json:"reason"`
+	PaymentMethodID *int64 `json:"payment_method_id"` // for paying back what was paid; defaults to the last payment's method
+}
+
+// TransactionVoid - What voiding a transaction reversed
+type TransactionVoid struct {
+	TransactionID       int64           `json:"transaction_id"`
+	TransactionNumber   string          `json:"transaction_number"`
+	OutletID            int64           `json:"outlet_id"`
+	TotalAmount         decimal.Decimal `json:"total_amount"`
+	Reason              string          `json:"reason"`
+	VoidedAt            time.Time       `json:"voided_at"`
+	VoidedBy            int64           `json:"voided_by"`
+	RestockedItems      int             `json:"restocked_items"`
+	CreditRestored      decimal.Decimal `json:"credit_restored"`
+	PointsRestored      int             `json:"points_restored"`
+	StoredValueRestored decimal.Decimal `json:"stored_value_restored"` // back to deposits and vouchers
+	Refund              *Refund         `json:"refund,omitempty"`
+}
+
+// ExceptionFilter - Filters for the exceptions report
+type ExceptionFilter struct {
+	OutletID *int64
+	DateFrom time.Time
+	DateTo   time.Time
+	Type     string
+}
+
+// ExceptionItem - A void, payment reversal, return or refund
+type ExceptionItem struct {
+	Type              string          `json:"type" db:"type"` // void, payment_reversal, sales_return, refund
+	ReferenceID       int64           `json:"reference_id" db:"reference_id"`
+	ReferenceNumber   string          `json:"reference_number" db:"reference_number"`
+	TransactionID     int64           `json:"transaction_id" db:"transaction_id"`
+	TransactionNumber string          `json:"transaction_number" db:"transaction_number"`
+	OutletID          int64           `json:"outlet_id" db:"outlet_id"`
+	Amount            decimal.Decimal `json:"amount" db:"amount"`
+	Reason            *string         `json:"reason" db:"reason"`
+	UserID            *int64          `json:"user_id" db:"user_id"`
+	UserName          *string         `json:"user_name" db:"user_name"`
+	OccurredAt        time.Time       `json:"occurred_at" db:"occurred_at"`
+}
+
+// ExceptionSummary - Count and value of one kind of exception
+type ExceptionSummary struct {
+	Type   string          `json:"type"`
+	Count  int             `json:"count"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// ExceptionReport - Voids, payment reversals, returns and refunds in a period
+type ExceptionReport struct {
+	OutletID *int64             `json:"outlet_id"`
+	DateFrom string             `json:"date_from"`
+	DateTo   string             `json:"date_to"`
+	Summary  []ExceptionSummary `json:"summary"`
+	Items    []ExceptionItem    `json:"items"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// WebhookSubscription - An external endpoint that receives signed domain events
+type WebhookSubscription struct {
+	SubscriptionID int64          `json:"subscription_id" db:"subscription_id"`
+	Name           string         `json:"name" db:"name"`
+	URL            string         `json:"url" db:"url"`
+	EventTypes     pq.StringArray `json:"event_types" db:"event_types" swaggertype:"array,string"`
+	Secret         string         `json:"secret,omitempty" db:"secret"` // only returned when created or rotated
+	IsActive       bool           `json:"is_active" db:"is_active"`
+	Description    string         `json:"description" db:"description"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+	DeletedAt      *time.Time     `json:"deleted_at,omitempty" db:"deleted_at"`
+	CreatedBy      *int64         `json:"created_by,omitempty" db:"created_by"`
+}
+
+// CreateWebhookSubscriptionRequest - Request for creating a webhook subscription
+type CreateWebhookSubscriptionRequest struct {
+	Name        string   `json:"name" validate:"required"`
+	URL         string   `json:"url" validate:"required"`
+	EventTypes  []string `json:"event_types" validate:"required"`
+	Secret      string   `json:"secret"` // generated when empty
+	IsActive    *bool    `json:"is_active"`
+	Description string   `json:"description"`
+}
+
+// UpdateWebhookSubscriptionRequest - Request for updating a webhook subscription
+type UpdateWebhookSubscriptionRequest struct {
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"`
+	IsActive    *bool    `json:"is_active"`
+	Description *string  `json:"description"`
+}
+
+// WebhookDelivery - A domain event queued for delivery to one subscription
+type WebhookDelivery struct {
+	DeliveryID     int64       `json:"delivery_id" db:"delivery_id"`
+	SubscriptionID int64       `json:"subscription_id" db:"subscription_id"`
+	EventID        *int64      `json:"event_id" db:"event_id"`
+	EventType      string      `json:"event_type" db:"event_type"`
+	Payload        JSONPayload `json:"payload" db:"payload"`
+	Status         string      `json:"status" db:"status"` // pending, sending, delivered, failed
+	Attempts       int         `json:"attempts" db:"attempts"`
+	MaxAttempts    int         `json:"max_attempts" db:"max_attempts"`
+	NextAttemptAt  time.Time   `json:"next_attempt_at" db:"next_attempt_at"`
+	ResponseCode   *int        `json:"response_code" db:"response_code"`
+	LastError      *string     `json:"last_error" db:"last_error"`
+	DeliveredAt    *time.Time  `json:"delivered_at" db:"delivered_at"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
+
+	// Subscription endpoint, loaded when claiming deliveries for dispatch
+	URL    string `json:"-" db:"url"`
+	Secret string `json:"-" db:"secret"`
+
+	// Requests made for the delivery, loaded for the detail view
+	AttemptLog []WebhookDeliveryAttempt `json:"attempt_log,omitempty" db:"-"`
+}
+
+// WebhookDeliveryAttempt - One HTTP request made for a delivery
+type WebhookDeliveryAttempt struct {
+	AttemptID    int64     `json:"attempt_id" db:"attempt_id"`
+	DeliveryID   int64     `json:"delivery_id" db:"delivery_id"`
+	ResponseCode *int      `json:"response_code" db:"response_code"`
+	ResponseBody *string   `json:"response_body" db:"response_body"`
+	Error        *string   `json:"error" db:"error"`
+	DurationMs   int       `json:"duration_ms" db:"duration_ms"`
+	AttemptedAt  time.Time `json:"attempted_at" db:"attempted_at"`
+}
+
+// WebhookDeliveryFilter - Filters for the webhook delivery log
+type WebhookDeliveryFilter struct {
+	SubscriptionID *int64
+	EventType      string
+	Status         string
+}
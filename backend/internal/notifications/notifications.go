@@ -0,0 +1,72 @@
+// Package notifications renders templated messages and delivers them to
+// customers and staff over pluggable channels.
+package notifications
+
+import (
+	"context"
+	"fmt"
+)
+
+// Channel identifies how a message reaches its recipient
+type Channel string
+
+// Supported channels
+const (
+	ChannelWhatsApp Channel = "whatsapp"
+	ChannelSMS      Channel = "sms"
+	ChannelEmail    Channel = "email"
+	ChannelInApp    Channel = "in_app"
+)
+
+// Valid reports whether c is a supported channel
+func (c Channel) Valid() bool {
+	switch c {
+	case ChannelWhatsApp, ChannelSMS, ChannelEmail, ChannelInApp:
+		return true
+	}
+	return false
+}
+
+// Message is a rendered notification ready for delivery
+type Message struct {
+	ID        int64   `json:"id"`
+	Channel   Channel `json:"channel"`
+	Recipient string  `json:"recipient"` // phone number, email address or user ID for in-app
+	Subject   string  `json:"subject"`
+	Body      string  `json:"body"`
+}
+
+// Transport delivers messages to an external provider
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Router dispatches each message to the transport registered for its channel
+type Router struct {
+	transports map[Channel]Transport
+	fallback   Transport
+}
+
+// NewRouter creates a router that uses fallback for channels without a transport
+func NewRouter(fallback Transport) *Router {
+	return &Router{
+		transports: make(map[Channel]Transport),
+		fallback:   fallback,
+	}
+}
+
+// Register sets the transport for a channel
+func (r *Router) Register(channel Channel, transport Transport) {
+	r.transports[channel] = transport
+}
+
+// Send delivers msg through its channel's transport
+func (r *Router) Send(ctx context.Context, msg Message) error {
+	if transport, ok := r.transports[msg.Channel]; ok {
+		return transport.Send(ctx, msg)
+	}
+	if r.fallback != nil {
+		return r.fallback.Send(ctx, msg)
+	}
+	return fmt.Errorf("no transport registered for channel %s", msg.Channel)
+}
@@ -0,0 +1,104 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"flutter-bengkel/internal/utils"
+)
+
+// Template names
+const (
+	TemplateJobStatusChanged     = "job_status_changed"
+	TemplateTechnicianAssigned   = "technician_assigned"
+	TemplatePaymentReceived      = "payment_received"
+	TemplateCheckoutReceipt      = "checkout_receipt"
+	TemplateReminderService      = "reminder_service"
+	TemplateReminderInsurance    = "reminder_insurance"
+	TemplateReminderRegistration = "reminder_registration"
+)
+
+// Supported languages
+const (
+	LanguageIndonesian = "id"
+	LanguageEnglish    = "en"
+)
+
+//go:embed templates/*/*.tmpl
+var templateFS embed.FS
+
+var templateFuncs = template.FuncMap{
+	"rupiah": utils.FormatCurrency,
+	"date": func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format("02-01-2006")
+	},
+}
+
+// Renderer renders named message templates per language. Each template file
+// defines a "subject" and a "body" block.
+type Renderer struct {
+	defaultLanguage string
+	templates       map[string]*template.Template // keyed by "language/name"
+}
+
+// NewRenderer parses the embedded templates
+func NewRenderer(defaultLanguage string) (*Renderer, error) {
+	files, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification templates: %w", err)
+	}
+
+	renderer := &Renderer{
+		defaultLanguage: defaultLanguage,
+		templates:       make(map[string]*template.Template),
+	}
+
+	for _, languageDir := range files {
+		language := languageDir.Name()
+		entries, err := templateFS.ReadDir(path.Join("templates", language))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s notification templates: %w", language, err)
+		}
+
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), ".tmpl")
+			tmpl, err := template.New(name).Funcs(templateFuncs).ParseFS(templateFS, path.Join("templates", language, entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse notification template %s/%s: %w", language, name, err)
+			}
+			renderer.templates[language+"/"+name] = tmpl
+		}
+	}
+
+	return renderer, nil
+}
+
+// Render returns the subject and body of a template, falling back to the
+// default language when the requested one has no translation
+func (r *Renderer) Render(name, language string, data interface{}) (string, string, error) {
+	tmpl, ok := r.templates[language+"/"+name]
+	if !ok {
+		tmpl, ok = r.templates[r.defaultLanguage+"/"+name]
+	}
+	if !ok {
+		return "", "", fmt.Errorf("notification template %s not found", name)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s body: %w", name, err)
+	}
+
+	return strings.TrimSpace(subject.String()), strings.TrimSpace(body.String()), nil
+}
@@ -0,0 +1,81 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LogTransport writes messages to the application log, for development
+type LogTransport struct{}
+
+// NewLogTransport creates a log-backed transport
+func NewLogTransport() *LogTransport {
+	return &LogTransport{}
+}
+
+// Send logs the message
+func (t *LogTransport) Send(ctx context.Context, msg Message) error {
+	log.Printf("Notification %d via %s to %s: %s | %s", msg.ID, msg.Channel, msg.Recipient, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileTransport appends messages as JSON lines to a file, for development
+type FileTransport struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileTransport creates a file-backed transport writing to path
+func NewFileTransport(path string) *FileTransport {
+	return &FileTransport{path: path}
+}
+
+// Send appends the message to the file
+func (t *FileTransport) Send(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}{msg, time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create notification directory: %w", err)
+	}
+
+	f, err := os.OpenFile(t.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open notification file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+
+	return nil
+}
+
+// InAppTransport accepts in-app messages as delivered, since the stored
+// notification itself is what the user's inbox shows
+type InAppTransport struct{}
+
+// NewInAppTransport creates an in-app transport
+func NewInAppTransport() *InAppTransport {
+	return &InAppTransport{}
+}
+
+// Send is a no-op for in-app messages
+func (t *InAppTransport) Send(ctx context.Context, msg Message) error {
+	return nil
+}
@@ -0,0 +1,161 @@
+// Package promotion works out which promotions apply to a document and what
+// each takes off its lines.
+package promotion
+
+import (
+	"flutter-bengkel/internal/tax"
+
+	"github.com/shopspring/decimal"
+)
+
+// Discount types and scopes
+const (
+	TypePercentage = "percentage"
+	TypeFixed      = "fixed"
+
+	ScopeLine    = "line"
+	ScopeInvoice = "invoice"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Rule is a promotion as evaluated on a document. A rule limited to a
+// product category, a service category or both covers the lines in either;
+// a rule limited to neither covers every line.
+type Rule struct {
+	PromotionID       int64
+	DiscountType      string
+	Value             decimal.Decimal
+	Scope             string
+	MinSpend          decimal.Decimal
+	MaxDiscount       *decimal.Decimal
+	ProductCategoryID *int64
+	ServiceCategoryID *int64
+}
+
+// Line is a document line as entered, with the categories of its product
+// or service
+type Line struct {
+	Amount            decimal.Decimal
+	ProductCategoryID *int64
+	ServiceCategoryID *int64
+}
+
+// Applied is a promotion that took something off the document
+type Applied struct {
+	PromotionID int64
+	Discount    decimal.Decimal
+}
+
+// Result is what the promotions took off each line and which promotion did
+type Result struct {
+	Discounts    []decimal.Decimal
+	PromotionIDs []*int64
+	Applied      []Applied
+}
+
+// Evaluate applies rules in order. Promotions do not stack: a rule only
+// covers lines no earlier rule has discounted, and its min spend counts
+// those lines alone. Callers order the rules by precedence.
+func Evaluate(rules []Rule, lines []Line) *Result {
+	result := &Result{
+		Discounts:    make([]decimal.Decimal, len(lines)),
+		PromotionIDs: make([]*int64, len(lines)),
+	}
+	for i := range lines {
+		result.Discounts[i] = decimal.Zero
+	}
+
+	for _, rule := range rules {
+		discounts := rule.discounts(lines, result.PromotionIDs)
+		total := decimal.Zero
+		for _, discount := range discounts {
+			total = total.Add(discount)
+		}
+		if !total.IsPositive() {
+			continue
+		}
+
+		promotionID := rule.PromotionID
+		for i, discount := range discounts {
+			if discount.IsPositive() {
+				result.Discounts[i] = discount
+				result.PromotionIDs[i] = &promotionID
+			}
+		}
+		result.Applied = append(result.Applied, Applied{PromotionID: promotionID, Discount: total})
+	}
+
+	return result
+}
+
+// Check reports whether a rule would take anything off the lines on its
+// own, as when a coupon is presented
+func Check(rule Rule, lines []Line) bool {
+	for _, discount := range rule.discounts(lines, make([]*int64, len(lines))) {
+		if discount.IsPositive() {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether a rule applies to a line
+func (r Rule) covers(line Line) bool {
+	if r.ProductCategoryID == nil && r.ServiceCategoryID == nil {
+		return true
+	}
+	if r.ProductCategoryID != nil && line.ProductCategoryID != nil && *r.ProductCategoryID == *line.ProductCategoryID {
+		return true
+	}
+	return r.ServiceCategoryID != nil && line.ServiceCategoryID != nil && *r.ServiceCategoryID == *line.ServiceCategoryID
+}
+
+// discounts is what a rule takes off each line not already taken
+func (r Rule) discounts(lines []Line, taken []*int64) []decimal.Decimal {
+	discounts := make([]decimal.Decimal, len(lines))
+	eligible := make([]decimal.Decimal, len(lines))
+	spend := decimal.Zero
+	for i, line := range lines {
+		discounts[i] = decimal.Zero
+		eligible[i] = decimal.Zero
+		if taken[i] == nil && r.covers(line) && line.Amount.IsPositive() {
+			eligible[i] = line.Amount
+			spend = spend.Add(line.Amount)
+		}
+	}
+	if !spend.IsPositive() || spend.LessThan(r.MinSpend) {
+		return discounts
+	}
+
+	if r.Scope == ScopeLine {
+		for i, amount := range eligible {
+			if !amount.IsPositive() {
+				continue
+			}
+			if r.DiscountType == TypePercentage {
+				discounts[i] = amount.Mul(r.Value).Div(hundred).Round(2)
+			} else {
+				discounts[i] = decimal.Min(r.Value, amount)
+			}
+		}
+	} else {
+		total := decimal.Min(r.Value, spend)
+		if r.DiscountType == TypePercentage {
+			total = spend.Mul(r.Value).Div(hundred).Round(2)
+		}
+		discounts = tax.Allocate(total, eligible)
+	}
+
+	if r.MaxDiscount != nil {
+		total := decimal.Zero
+		for _, discount := range discounts {
+			total = total.Add(discount)
+		}
+		if total.GreaterThan(*r.MaxDiscount) {
+			discounts = tax.Allocate(*r.MaxDiscount, discounts)
+		}
+	}
+
+	return discounts
+}
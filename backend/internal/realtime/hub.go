@@ -0,0 +1,145 @@
+package realtime
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types pushed to connected clients
+const (
+	EventServiceJobCreated       = "service_job.created"
+	EventServiceJobStatusChanged = "service_job.status_changed"
+	EventTechnicianAssigned      = "service_job.technician_assigned"
+	EventQueueUpdated            = "queue.updated"
+	EventPaymentReceived         = "payment.received"
+)
+
+// subscriberBuffer is how many events a slow client may lag behind before
+// further events are dropped for it
+const subscriberBuffer = 64
+
+// Event is a single server push message scoped to an outlet
+type Event struct {
+	ID         int64       `json:"id"`
+	Type       string      `json:"type"`
+	OutletID   int64       `json:"outlet_id"`
+	Permission string      `json:"-"` // permission required to receive the event
+	Data       interface{} `json:"data"`
+	Timestamp  time.Time   `json:"timestamp"`
+}
+
+// Publisher is implemented by anything that can fan out events to clients
+type Publisher interface {
+	Publish(event Event)
+}
+
+// Subscriber is a single connected client stream
+type Subscriber struct {
+	outletID    *int64 // nil receives every outlet
+	permissions map[string]bool
+	events      chan Event
+}
+
+// Events returns the channel the subscriber receives events on
+func (s *Subscriber) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscriber) accepts(event Event) bool {
+	if s.outletID != nil && *s.outletID != event.OutletID {
+		return false
+	}
+	if event.Permission != "" && s.permissions != nil && !s.permissions[event.Permission] {
+		return false
+	}
+	return true
+}
+
+// Hub fans out published events to subscribers by outlet and permission
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscriber]struct{}
+	lastID      int64
+	closed      bool
+}
+
+// NewHub creates a new event hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[*Subscriber]struct{}),
+	}
+}
+
+// Subscribe registers a client. A nil outletID receives events for all outlets;
+// a nil permissions slice skips permission filtering.
+func (h *Hub) Subscribe(outletID *int64, permissions []string) *Subscriber {
+	sub := &Subscriber{
+		outletID: outletID,
+		events:   make(chan Event, subscriberBuffer),
+	}
+
+	if permissions != nil {
+		sub.permissions = make(map[string]bool, len(permissions))
+		for _, p := range permissions {
+			sub.permissions[p] = true
+		}
+	}
+
+	h.mu.Lock()
+	if h.closed {
+		close(sub.events)
+	} else {
+		h.subscribers[sub] = struct{}{}
+	}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Unsubscribe removes a client and closes its channel
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+	h.mu.Unlock()
+}
+
+// Close ends every open stream by closing its subscriber's channel, so the
+// server can shut down without waiting for clients to disconnect. Streams
+// opened after Close end straight away.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Publish delivers the event to every matching subscriber without blocking
+func (h *Hub) Publish(event Event) {
+	event.ID = atomic.AddInt64(&h.lastID, 1)
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subscribers {
+		if !sub.accepts(event) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			// Drop for slow clients rather than stall the publisher
+		}
+	}
+}
@@ -0,0 +1,510 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ApprovalRepository defines data access for approval thresholds, approval
+// requests and approver PINs
+type ApprovalRepository interface {
+	ListThresholds() ([]models.ApprovalThreshold, error)
+	GetThreshold(roleID int64) (*models.ApprovalThreshold, error)
+	SetThreshold(threshold *models.ApprovalThreshold) error
+	DeleteThreshold(roleID int64) error
+	List(filter *models.ApprovalFilter, offset, limit int) ([]models.ApprovalRequest, int64, error)
+	GetByID(id int64) (*models.ApprovalRequest, error)
+	ListForTransaction(transactionID int64) ([]models.ApprovalRequest, error)
+	HoldServiceDetail(detailID int64, approval *models.ApprovalRequest) error
+	CountPendingForServiceJob(serviceJobID int64) (int, error)
+	Decide(id int64, status string, decidedBy int64, method, notes string) error
+	SetPINHash(userID int64, hash string) error
+	VerifyPIN(attempt *models.ApprovalPINAttempt, matches func(hash string) bool, maxFailures int, lockout time.Duration) error
+}
+
+type approvalRepository struct {
+	db *sqlx.DB
+}
+
+// NewApprovalRepository creates a new approval repository
+func NewApprovalRepository(db *sqlx.DB) ApprovalRepository {
+	return &approvalRepository{db: db}
+}
+
+const approvalColumns = `
+	a.approval_id, a.request_type, a.status, a.outlet_id, a.transaction_id, t.transaction_number,
+	a.transaction_detail_id, a.service_job_id, sj.job_number, a.service_detail_id, a.product_id,
+	a.service_id, COALESCE(p.name, s.name) AS item_name, a.list_price, a.requested_price,
+	a.subtotal_amount, a.discount_amount, a.percent, a.threshold_percent, a.requested_by,
+	ru.full_name AS requested_by_name, a.requested_at, a.decided_by, du.full_name AS decided_by_name,
+	a.decided_at, a.decision_method, a.decision_notes
+`
+
+const approvalJoins = `
+	FROM approval_requests a
+	JOIN users ru ON ru.user_id = a.requested_by
+	LEFT JOIN users du ON du.user_id = a.decided_by
+	LEFT JOIN transactions t ON t.transaction_id = a.transaction_id
+	LEFT JOIN service_jobs sj ON sj.job_id = a.service_job_id
+	LEFT JOIN products p ON p.product_id = a.product_id
+	LEFT JOIN services s ON s.service_id = a.service_id
+`
+
+func (r *approvalRepository) ListThresholds() ([]models.ApprovalThreshold, error) {
+	var thresholds []models.ApprovalThreshold
+	err := r.db.Select(&thresholds, `
+		SELECT th.role_id, ro.name AS role_name, th.max_discount_percent, th.max_price_override_percent,
+			th.updated_at, th.updated_by
+		FROM approval_thresholds th
+		JOIN roles ro ON ro.role_id = th.role_id
+		ORDER BY th.role_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approval thresholds: %w", err)
+	}
+
+	return thresholds, nil
+}
+
+// GetThreshold returns nil when the role is not limited
+func (r *approvalRepository) GetThreshold(roleID int64) (*models.ApprovalThreshold, error) {
+	var threshold models.ApprovalThreshold
+	err := r.db.Get(&threshold, `
+		SELECT th.role_id, ro.name AS role_name, th.max_discount_percent, th.max_price_override_percent,
+			th.updated_at, th.updated_by
+		FROM approval_thresholds th
+		JOIN roles ro ON ro.role_id = th.role_id
+		WHERE th.role_id = $1
+	`, roleID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval threshold: %w", err)
+	}
+
+	return &threshold, nil
+}
+
+func (r *approvalRepository) SetThreshold(threshold *models.ApprovalThreshold) error {
+	_, err := r.db.Exec(`
+		INSERT INTO approval_thresholds (role_id, max_discount_percent, max_price_override_percent, updated_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (role_id) DO UPDATE SET
+			max_discount_percent = EXCLUDED.max_discount_percent,
+			max_price_override_percent = EXCLUDED.max_price_override_percent,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+	`, threshold.RoleID, threshold.MaxDiscountPercent, threshold.MaxPriceOverridePercent, threshold.UpdatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to set approval threshold: %w", err)
+	}
+
+	return nil
+}
+
+func (r *approvalRepository) DeleteThreshold(roleID int64) error {
+	result, err := r.db.Exec(`DELETE FROM approval_thresholds WHERE role_id = $1`, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete approval threshold: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("approval threshold not found")
+	}
+
+	return nil
+}
+
+func (r *approvalRepository) List(filter *models.ApprovalFilter, offset, limit int) ([]models.ApprovalRequest, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("a.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("a.status = $%d", argIndex))
+		args = append(args, filter.Status)
+		argIndex++
+	}
+
+	if filter.RequestType != "" {
+		conditions = append(conditions, fmt.Sprintf("a.request_type = $%d", argIndex))
+		args = append(args, filter.RequestType)
+		argIndex++
+	}
+
+	if filter.TransactionID != nil {
+		conditions = append(conditions, fmt.Sprintf("a.transaction_id = $%d", argIndex))
+		args = append(args, *filter.TransactionID)
+		argIndex++
+	}
+
+	if filter.ServiceJobID != nil {
+		conditions = append(conditions, fmt.Sprintf("a.service_job_id = $%d", argIndex))
+		args = append(args, *filter.ServiceJobID)
+		argIndex++
+	}
+
+	if filter.RequestedBy != nil {
+		conditions = append(conditions, fmt.Sprintf("a.requested_by = $%d", argIndex))
+		args = append(args, *filter.RequestedBy)
+		argIndex++
+	}
+
+	if filter.DateFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("a.requested_at::date >= $%d", argIndex))
+		args = append(args, filter.DateFrom.Format("2006-01-02"))
+		argIndex++
+	}
+
+	if filter.DateTo != nil {
+		conditions = append(conditions, fmt.Sprintf("a.requested_at::date <= $%d", argIndex))
+		args = append(args, filter.DateTo.Format("2006-01-02"))
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM approval_requests a `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count approval requests: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		%s
+		%s
+		ORDER BY a.requested_at DESC, a.approval_id DESC
+		LIMIT $%d OFFSET $%d
+	`, approvalColumns, approvalJoins, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var approvals []models.ApprovalRequest
+	if err = r.db.Select(&approvals, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list approval requests: %w", err)
+	}
+
+	return approvals, total, nil
+}
+
+func (r *approvalRepository) GetByID(id int64) (*models.ApprovalRequest, error) {
+	var approval models.ApprovalRequest
+	err := r.db.Get(&approval, `SELECT `+approvalColumns+approvalJoins+` WHERE a.approval_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("approval request not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval request: %w", err)
+	}
+
+	return &approval, nil
+}
+
+func (r *approvalRepository) ListForTransaction(transactionID int64) ([]models.ApprovalRequest, error) {
+	var approvals []models.ApprovalRequest
+	err := r.db.Select(&approvals, `
+		SELECT `+approvalColumns+approvalJoins+`
+		WHERE a.transaction_id = $1
+		ORDER BY a.approval_id
+	`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction approval requests: %w", err)
+	}
+
+	return approvals, nil
+}
+
+// HoldServiceDetail replaces the pending request of a service job line.
+// With an approval the line is held until it is decided; without one the
+// line no longer needs approval.
+func (r *approvalRepository) HoldServiceDetail(detailID int64, approval *models.ApprovalRequest) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE approval_requests SET status = 'cancelled'
+		WHERE service_detail_id = $1 AND status = 'pending'
+	`, detailID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel approval requests: %w", err)
+	}
+
+	status := "approved"
+	if approval != nil {
+		status = "pending"
+		approval.ServiceDetailID = &detailID
+		if err = insertApproval(tx, approval); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE service_details SET approval_status = $2 WHERE detail_id = $1`, detailID, status)
+	if err != nil {
+		return fmt.Errorf("failed to update service detail approval: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *approvalRepository) CountPendingForServiceJob(serviceJobID int64) (int, error) {
+	var count int
+	err := r.db.Get(&count, `
+		SELECT COUNT(*) FROM service_details WHERE service_job_id = $1 AND approval_status = 'pending'
+	`, serviceJobID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending service details: %w", err)
+	}
+
+	return count, nil
+}
+
+// Decide approves or rejects a pending request and updates what it held.
+// A transaction is released when none of its requests is pending and none
+// was rejected. A rejected service job line goes back to its list price.
+func (r *approvalRepository) Decide(id int64, status string, decidedBy int64, method, notes string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var approval struct {
+		Status          string   `db:"status"`
+		TransactionID   *int64   `db:"transaction_id"`
+		ServiceDetailID *int64   `db:"service_detail_id"`
+		ListPrice       *float64 `db:"list_price"`
+	}
+	err = tx.Get(&approval, `
+		SELECT status, transaction_id, service_detail_id, list_price
+		FROM approval_requests WHERE approval_id = $1 FOR UPDATE
+	`, id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("approval request not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get approval request: %w", err)
+	}
+	if approval.Status != "pending" {
+		return fmt.Errorf("approval request is already %s", approval.Status)
+	}
+
+	var decisionNotes *string
+	if notes != "" {
+		decisionNotes = &notes
+	}
+	_, err = tx.Exec(`
+		UPDATE approval_requests
+		SET status = $2, decided_by = $3, decided_at = CURRENT_TIMESTAMP, decision_method = $4, decision_notes = $5
+		WHERE approval_id = $1
+	`, id, status, decidedBy, method, decisionNotes)
+	if err != nil {
+		return fmt.Errorf("failed to decide approval request: %w", err)
+	}
+
+	if approval.TransactionID != nil {
+		_, err = tx.Exec(`
+			UPDATE transactions SET approval_status = CASE
+				WHEN EXISTS (SELECT 1 FROM approval_requests
+					WHERE transaction_id = $1 AND status = 'rejected') THEN 'rejected'
+				WHEN EXISTS (SELECT 1 FROM approval_requests
+					WHERE transaction_id = $1 AND status = 'pending') THEN 'pending'
+				ELSE 'approved' END,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE transaction_id = $1
+		`, *approval.TransactionID)
+		if err != nil {
+			return fmt.Errorf("failed to update transaction approval: %w", err)
+		}
+	}
+
+	if approval.ServiceDetailID != nil {
+		if status == "rejected" && approval.ListPrice != nil {
+			_, err = tx.Exec(`
+				UPDATE service_details
+				SET unit_price = $2, total_price = quantity * $2, approval_status = 'rejected'
+				WHERE detail_id = $1
+			`, *approval.ServiceDetailID, *approval.ListPrice)
+		} else {
+			_, err = tx.Exec(`
+				UPDATE service_details SET approval_status = $2 WHERE detail_id = $1
+			`, *approval.ServiceDetailID, status)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update service detail approval: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetPINHash sets an approver's PIN and lifts any lock on the old one
+func (r *approvalRepository) SetPINHash(userID int64, hash string) error {
+	_, err := r.db.Exec(`
+		UPDATE users SET approval_pin_hash = $2, approval_pin_failed_attempts = 0, approval_pin_locked_until = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1
+	`, userID, hash)
+	if err != nil {
+		return fmt.Errorf("failed to set approval PIN: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyPIN checks a PIN entered for an approver, counts it against them and
+// logs the attempt, all while holding the approver's row so attempts made
+// side by side are counted one after another. A correct PIN clears the
+// count; the maxFailures-th wrong one in a row locks the PIN for lockout.
+// Nothing is checked while the PIN is locked, and those attempts are logged
+// without being counted, so they do not extend the lock.
+func (r *approvalRepository) VerifyPIN(attempt *models.ApprovalPINAttempt, matches func(hash string) bool, maxFailures int, lockout time.Duration) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	attempt.Succeeded = false
+	if attempt.ApproverID != nil {
+		var pin models.ApprovalPIN
+		err = tx.Get(&pin, `
+			SELECT approval_pin_hash, approval_pin_failed_attempts, approval_pin_locked_until,
+				COALESCE(approval_pin_locked_until > CURRENT_TIMESTAMP, false) AS locked
+			FROM users WHERE user_id = $1 AND deleted_at IS NULL
+			FOR UPDATE
+		`, *attempt.ApproverID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get approval PIN: %w", err)
+		}
+
+		attempt.Locked = pin.Locked
+		if !pin.Locked {
+			attempt.Succeeded = pin.Hash != nil && matches(*pin.Hash)
+			if err = countPINAttempt(tx, attempt, maxFailures, lockout); err != nil {
+				return err
+			}
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO approval_pin_attempts (approval_id, approver_id, username, attempted_by, succeeded, locked)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING attempt_id, attempted_at
+	`, attempt.ApprovalID, attempt.ApproverID, attempt.Username, attempt.AttemptedBy, attempt.Succeeded,
+		attempt.Locked).Scan(&attempt.AttemptID, &attempt.AttemptedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record approval PIN attempt: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// countPINAttempt updates an unlocked approver's count of wrong PINs,
+// locking the PIN when it reaches maxFailures
+func countPINAttempt(tx *sqlx.Tx, attempt *models.ApprovalPINAttempt, maxFailures int, lockout time.Duration) error {
+	var err error
+	if attempt.Succeeded {
+		_, err = tx.Exec(`
+			UPDATE users SET approval_pin_failed_attempts = 0, approval_pin_locked_until = NULL WHERE user_id = $1
+		`, *attempt.ApproverID)
+	} else {
+		err = tx.QueryRow(`
+			UPDATE users SET
+				approval_pin_failed_attempts = CASE WHEN approval_pin_failed_attempts + 1 >= $2 THEN 0
+					ELSE approval_pin_failed_attempts + 1 END,
+				approval_pin_locked_until = CASE WHEN approval_pin_failed_attempts + 1 >= $2
+					THEN CURRENT_TIMESTAMP + $3 * INTERVAL '1 second' ELSE approval_pin_locked_until END
+			WHERE user_id = $1
+			RETURNING COALESCE(approval_pin_locked_until > CURRENT_TIMESTAMP, false)
+		`, *attempt.ApproverID, maxFailures, int64(lockout.Seconds())).Scan(&attempt.Locked)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to count approval PIN attempt: %w", err)
+	}
+
+	return nil
+}
+
+// insertApproval records a pending approval request
+func insertApproval(tx *sqlx.Tx, approval *models.ApprovalRequest) error {
+	approval.Status = "pending"
+	err := tx.QueryRow(`
+		INSERT INTO approval_requests (request_type, outlet_id, transaction_id, transaction_detail_id,
+			service_job_id, service_detail_id, product_id, service_id, list_price, requested_price,
+			subtotal_amount, discount_amount, percent, threshold_percent, requested_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING approval_id, requested_at
+	`, approval.RequestType, approval.OutletID, approval.TransactionID, approval.TransactionDetailID,
+		approval.ServiceJobID, approval.ServiceDetailID, approval.ProductID, approval.ServiceID,
+		approval.ListPrice, approval.RequestedPrice, approval.SubtotalAmount, approval.DiscountAmount,
+		approval.Percent, approval.ThresholdPercent, approval.RequestedBy).
+		Scan(&approval.ApprovalID, &approval.RequestedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create approval request: %w", err)
+	}
+
+	return nil
+}
+
+// insertApprovals records the requests holding a new transaction, linked to
+// the lines they are for
+func insertApprovals(tx *sqlx.Tx, transaction *models.Transaction) error {
+	for i := range transaction.Approvals {
+		approval := &transaction.Approvals[i]
+		approval.TransactionID = &transaction.ID
+		approval.OutletID = transaction.OutletID
+		approval.RequestedBy = transaction.UserID
+		if approval.DetailIndex != nil {
+			approval.TransactionDetailID = &transaction.Details[*approval.DetailIndex].ID
+		}
+		if err := insertApproval(tx, approval); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cancelApprovals withdraws the pending requests of a transaction that is
+// being voided
+func cancelApprovals(tx *sqlx.Tx, transactionID int64) error {
+	_, err := tx.Exec(`
+		UPDATE approval_requests SET status = 'cancelled'
+		WHERE transaction_id = $1 AND status = 'pending'
+	`, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel approval requests: %w", err)
+	}
+
+	return nil
+}
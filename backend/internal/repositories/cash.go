@@ -0,0 +1,469 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// CashRepository interface defines cash flow, daily closing and supplier payment operations
+type CashRepository interface {
+	ListCashFlows(filter *models.CashFlowFilter, offset, limit int) ([]models.CashFlow, int64, error)
+	ListDailySummaries(outletID *int64, status string, offset, limit int) ([]models.DailyCashSummary, int64, error)
+	GetDailySummary(outletID int64, date time.Time) (*models.DailyCashSummary, error)
+	ComputeDay(outletID int64, date time.Time) (*models.DailyCashSummary, error)
+	CloseDay(outletID int64, date time.Time, closedBy int64, notes string) (*models.DailyCashSummary, error)
+
+	ListPayables(filter *models.AccountsPayableFilter, offset, limit int) ([]models.AccountsPayable, int64, error)
+	GetPayableByID(id int64) (*models.AccountsPayable, error)
+	PaySupplier(payment *models.PayablePayment, outletID int64) error
+}
+
+type cashRepository struct {
+	db *sqlx.DB
+}
+
+// NewCashRepository creates a new cash repository
+func NewCashRepository(db *sqlx.DB) CashRepository {
+	return &cashRepository{db: db}
+}
+
+// recordCashFlow records a cash flow as part of tx, so the money movement is
+// written together with the document it belongs to. Zero amounts are
+// skipped. The cash_flows trigger rejects flows on days already closed.
+func recordCashFlow(tx *sqlx.Tx, flow *models.CashFlow) error {
+	if !flow.Amount.IsPositive() {
+		return nil
+	}
+
+	err := tx.QueryRow(`
+		INSERT INTO cash_flows (outlet_id, flow_type, category, amount, description, reference_type,
+			reference_id, payment_method_id, shift_id, transaction_date, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING flow_id, created_at
+	`, flow.OutletID, flow.FlowType, flow.Category, flow.Amount, flow.Description, flow.ReferenceType,
+		flow.ReferenceID, flow.PaymentMethodID, flow.ShiftID, flow.TransactionDate.Format("2006-01-02"), flow.CreatedBy).
+		Scan(&flow.FlowID, &flow.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record cash flow: %w", err)
+	}
+
+	return nil
+}
+
+const cashFlowColumns = `
+	flow_id, outlet_id, flow_type, category, amount, description, reference_type, reference_id,
+	payment_method_id, shift_id, transaction_date, created_at, created_by
+`
+
+const dailyCashSummaryColumns = `
+	summary_id, outlet_id, summary_date, opening_balance, total_inflow, total_outflow, closing_balance,
+	status, closed_at, closed_by, notes, created_at, updated_at
+`
+
+func (r *cashRepository) ListCashFlows(filter *models.CashFlowFilter, offset, limit int) ([]models.CashFlow, int64, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.FlowType != "" {
+		conditions = append(conditions, fmt.Sprintf("flow_type = $%d", argIndex))
+		args = append(args, filter.FlowType)
+		argIndex++
+	}
+
+	if filter.Category != "" {
+		conditions = append(conditions, fmt.Sprintf("category = $%d", argIndex))
+		args = append(args, filter.Category)
+		argIndex++
+	}
+
+	if filter.ReferenceType != "" {
+		conditions = append(conditions, fmt.Sprintf("reference_type = $%d", argIndex))
+		args = append(args, filter.ReferenceType)
+		argIndex++
+	}
+
+	if filter.DateFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("transaction_date >= $%d", argIndex))
+		args = append(args, filter.DateFrom.Format("2006-01-02"))
+		argIndex++
+	}
+
+	if filter.DateTo != nil {
+		conditions = append(conditions, fmt.Sprintf("transaction_date <= $%d", argIndex))
+		args = append(args, filter.DateTo.Format("2006-01-02"))
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM cash_flows `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count cash flows: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM cash_flows %s ORDER BY transaction_date DESC, flow_id DESC LIMIT $%d OFFSET $%d`,
+		cashFlowColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var flows []models.CashFlow
+	err = r.db.Select(&flows, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list cash flows: %w", err)
+	}
+
+	return flows, total, nil
+}
+
+func (r *cashRepository) ListDailySummaries(outletID *int64, status string, offset, limit int) ([]models.DailyCashSummary, int64, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	argIndex := 1
+
+	if outletID != nil {
+		conditions = append(conditions, fmt.Sprintf("outlet_id = $%d", argIndex))
+		args = append(args, *outletID)
+		argIndex++
+	}
+
+	if status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, status)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM daily_cash_summaries `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count daily cash summaries: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM daily_cash_summaries %s ORDER BY summary_date DESC, outlet_id LIMIT $%d OFFSET $%d`,
+		dailyCashSummaryColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var summaries []models.DailyCashSummary
+	err = r.db.Select(&summaries, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list daily cash summaries: %w", err)
+	}
+
+	return summaries, total, nil
+}
+
+// cashDay is an outlet's cash position for a day computed from its flows
+type cashDay struct {
+	ClosedThrough  *time.Time      `db:"closed_through"`
+	FirstOpenDay   *time.Time      `db:"first_open_day"`
+	OpeningBalance decimal.Decimal `db:"opening_balance"`
+	TotalInflow    decimal.Decimal `db:"total_inflow"`
+	TotalOutflow   decimal.Decimal `db:"total_outflow"`
+}
+
+// computeCashDay opens the day with the last closed balance plus the flows
+// of any days since that are not closed yet
+func computeCashDay(q sqlx.Queryer, outletID int64, date time.Time) (*cashDay, error) {
+	var day cashDay
+	err := sqlx.Get(q, &day, `
+		WITH last_closed AS (
+			SELECT summary_date, closing_balance
+			FROM daily_cash_summaries
+			WHERE outlet_id = $1 AND status = 'closed' AND deleted_at IS NULL
+			ORDER BY summary_date DESC
+			LIMIT 1
+		)
+		SELECT
+			(SELECT summary_date FROM last_closed) AS closed_through,
+			MIN(cf.transaction_date) FILTER (WHERE cf.transaction_date < $2::date) AS first_open_day,
+			COALESCE((SELECT closing_balance FROM last_closed), 0)
+				+ COALESCE(SUM(CASE WHEN cf.flow_type = 'inflow' THEN cf.amount ELSE -cf.amount END)
+					FILTER (WHERE cf.transaction_date < $2::date), 0) AS opening_balance,
+			COALESCE(SUM(cf.amount) FILTER (WHERE cf.transaction_date = $2::date AND cf.flow_type = 'inflow'), 0) AS total_inflow,
+			COALESCE(SUM(cf.amount) FILTER (WHERE cf.transaction_date = $2::date AND cf.flow_type = 'outflow'), 0) AS total_outflow
+		FROM cash_flows cf
+		WHERE cf.outlet_id = $1 AND cf.deleted_at IS NULL AND cf.transaction_date <= $2::date
+			AND cf.transaction_date > COALESCE((SELECT summary_date FROM last_closed), DATE '-infinity')
+	`, outletID, date.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute cash day: %w", err)
+	}
+
+	return &day, nil
+}
+
+// GetDailySummary returns an outlet's stored summary for a day
+func (r *cashRepository) GetDailySummary(outletID int64, date time.Time) (*models.DailyCashSummary, error) {
+	query := fmt.Sprintf(`SELECT %s FROM daily_cash_summaries WHERE outlet_id = $1 AND summary_date = $2 AND deleted_at IS NULL`,
+		dailyCashSummaryColumns)
+
+	var summary models.DailyCashSummary
+	if err := r.db.Get(&summary, query, outletID, date.Format("2006-01-02")); err != nil {
+		return nil, fmt.Errorf("failed to get daily cash summary: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// ComputeDay returns an open day's cash position from its cash flows
+// without writing a summary
+func (r *cashRepository) ComputeDay(outletID int64, date time.Time) (*models.DailyCashSummary, error) {
+	day, err := computeCashDay(r.db, outletID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DailyCashSummary{
+		OutletID:       outletID,
+		SummaryDate:    date,
+		OpeningBalance: day.OpeningBalance,
+		TotalInflow:    day.TotalInflow,
+		TotalOutflow:   day.TotalOutflow,
+		ClosingBalance: day.OpeningBalance.Add(day.TotalInflow).Sub(day.TotalOutflow),
+		Status:         "open",
+	}, nil
+}
+
+// CloseDay writes the outlet's final summary for a day and locks it. Days
+// must be closed in order: earlier days with cash flows have to be closed
+// first so the balance carried forward is final.
+func (r *cashRepository) CloseDay(outletID int64, date time.Time, closedBy int64, notes string) (*models.DailyCashSummary, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`SELECT cash_lock_outlet($1)`, outletID); err != nil {
+		return nil, fmt.Errorf("failed to lock outlet cash: %w", err)
+	}
+
+	day, err := computeCashDay(tx, outletID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if day.ClosedThrough != nil && !date.After(*day.ClosedThrough) {
+		return nil, fmt.Errorf("cash is already closed through %s", day.ClosedThrough.Format("2006-01-02"))
+	}
+	if day.FirstOpenDay != nil {
+		return nil, fmt.Errorf("cash for %s must be closed first", day.FirstOpenDay.Format("2006-01-02"))
+	}
+
+	// Cash still in an unreconciled drawer would be missing from the day
+	var openShift string
+	err = tx.Get(&openShift, `
+		SELECT shift_number FROM cashier_shifts
+		WHERE outlet_id = $1 AND status = 'open' AND opened_at::date <= $2
+		ORDER BY opened_at LIMIT 1
+	`, outletID, date.Format("2006-01-02"))
+	if err == nil {
+		return nil, fmt.Errorf("cashier shift %s must be closed first", openShift)
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check open cashier shifts: %w", err)
+	}
+
+	closing := day.OpeningBalance.Add(day.TotalInflow).Sub(day.TotalOutflow)
+
+	var summary models.DailyCashSummary
+	err = tx.Get(&summary, `
+		INSERT INTO daily_cash_summaries (outlet_id, summary_date, opening_balance, total_inflow, total_outflow,
+			closing_balance, status, closed_at, closed_by, notes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, 'closed', CURRENT_TIMESTAMP, $7, NULLIF($8, ''), $7)
+		ON CONFLICT (outlet_id, summary_date) DO UPDATE
+		SET opening_balance = EXCLUDED.opening_balance,
+			total_inflow = EXCLUDED.total_inflow,
+			total_outflow = EXCLUDED.total_outflow,
+			closing_balance = EXCLUDED.closing_balance,
+			status = 'closed',
+			closed_at = EXCLUDED.closed_at,
+			closed_by = EXCLUDED.closed_by,
+			notes = EXCLUDED.notes,
+			deleted_at = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING `+dailyCashSummaryColumns,
+		outletID, date.Format("2006-01-02"), day.OpeningBalance, day.TotalInflow, day.TotalOutflow,
+		closing, closedBy, notes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close cash day: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit cash closing: %w", err)
+	}
+
+	return &summary, nil
+}
+
+const accountsPayableColumns = `
+	ap.payable_id, ap.ap_number, ap.supplier_id, s.name AS supplier_name, ap.purchase_order_id,
+	ap.amount, ap.paid_amount, ap.remaining_amount, ap.due_date, ap.status, ap.notes, ap.created_at
+`
+
+func (r *cashRepository) ListPayables(filter *models.AccountsPayableFilter, offset, limit int) ([]models.AccountsPayable, int64, error) {
+	conditions := []string{"ap.deleted_at IS NULL"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.SupplierID != nil {
+		conditions = append(conditions, fmt.Sprintf("ap.supplier_id = $%d", argIndex))
+		args = append(args, *filter.SupplierID)
+		argIndex++
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("ap.status = $%d", argIndex))
+		args = append(args, filter.Status)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM accounts_payables ap `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count payables: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s FROM accounts_payables ap
+		JOIN suppliers s ON s.supplier_id = ap.supplier_id
+		%s ORDER BY ap.due_date, ap.payable_id LIMIT $%d OFFSET $%d
+	`, accountsPayableColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var payables []models.AccountsPayable
+	err = r.db.Select(&payables, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list payables: %w", err)
+	}
+
+	return payables, total, nil
+}
+
+func (r *cashRepository) GetPayableByID(id int64) (*models.AccountsPayable, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM accounts_payables ap
+		JOIN suppliers s ON s.supplier_id = ap.supplier_id
+		WHERE ap.payable_id = $1 AND ap.deleted_at IS NULL
+	`, accountsPayableColumns)
+
+	var payable models.AccountsPayable
+	if err := r.db.Get(&payable, query, id); err != nil {
+		return nil, fmt.Errorf("failed to get payable: %w", err)
+	}
+
+	err := r.db.Select(&payable.Payments, `
+		SELECT payment_id, accounts_payable_id, payment_method_id, amount, payment_date,
+			reference_number, notes, created_at, created_by
+		FROM payable_payments
+		WHERE accounts_payable_id = $1 AND deleted_at IS NULL
+		ORDER BY payment_date, payment_id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payable payments: %w", err)
+	}
+
+	return &payable, nil
+}
+
+// PaySupplier records a payment against a payable, updates its balance and
+// status and records the cash outflow in one database transaction. Payables
+// raised from a purchase order pay out of the order's outlet.
+func (r *cashRepository) PaySupplier(payment *models.PayablePayment, outletID int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var payable struct {
+		APNumber        string          `db:"ap_number"`
+		SupplierName    string          `db:"supplier_name"`
+		RemainingAmount decimal.Decimal `db:"remaining_amount"`
+		OrderOutletID   *int64          `db:"order_outlet_id"`
+	}
+	err = tx.Get(&payable, `
+		SELECT ap.ap_number, s.name AS supplier_name, ap.remaining_amount, po.outlet_id AS order_outlet_id
+		FROM accounts_payables ap
+		JOIN suppliers s ON s.supplier_id = ap.supplier_id
+		LEFT JOIN purchase_orders po ON po.po_id = ap.purchase_order_id
+		WHERE ap.payable_id = $1 AND ap.deleted_at IS NULL
+		FOR UPDATE OF ap
+	`, payment.AccountsPayableID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("payable not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get payable: %w", err)
+	}
+
+	if payment.Amount.GreaterThan(payable.RemainingAmount) {
+		return fmt.Errorf("payment amount exceeds remaining amount %s", payable.RemainingAmount.StringFixed(2))
+	}
+	if payable.OrderOutletID != nil {
+		outletID = *payable.OrderOutletID
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO payable_payments (accounts_payable_id, payment_method_id, amount, payment_date,
+			reference_number, notes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING payment_id, created_at
+	`, payment.AccountsPayableID, payment.PaymentMethodID, payment.Amount, payment.PaymentDate,
+		payment.ReferenceNumber, payment.Notes, payment.CreatedBy).Scan(&payment.PaymentID, &payment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create payable payment: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE accounts_payables
+		SET paid_amount = paid_amount + $2,
+			remaining_amount = remaining_amount - $2,
+			status = CASE WHEN remaining_amount - $2 <= 0 THEN 'paid' ELSE 'partial' END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE payable_id = $1
+	`, payment.AccountsPayableID, payment.Amount)
+	if err != nil {
+		return fmt.Errorf("failed to update payable: %w", err)
+	}
+
+	referenceType := "payable_payment"
+	err = recordCashFlow(tx, &models.CashFlow{
+		OutletID:        outletID,
+		FlowType:        "outflow",
+		Category:        "supplier_payment",
+		Amount:          payment.Amount,
+		Description:     fmt.Sprintf("Payment to %s for %s", payable.SupplierName, payable.APNumber),
+		ReferenceType:   &referenceType,
+		ReferenceID:     &payment.PaymentID,
+		PaymentMethodID: &payment.PaymentMethodID,
+		TransactionDate: payment.PaymentDate,
+		CreatedBy:       payment.CreatedBy,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"flutter-bengkel/internal/events"
+	"flutter-bengkel/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Checkout settles a transaction with several tenders in one database
+// transaction. Cards, transfers and e-wallets are charged exactly what they
+// tendered and cannot exceed what is due; cash covers the rest and what it
+// tendered beyond that is handed back as change. Each tender becomes a
+// payment of its applied amount.
+func (r *paymentRepository) Checkout(checkout *models.Checkout, tenders []models.CheckoutTenderRequest) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	transaction, err := lockPaymentTransaction(tx, checkout.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	balance, err := transactionBalance(tx, checkout.TransactionID)
+	if err != nil {
+		return err
+	}
+	if !balance.Outstanding.IsPositive() {
+		return fmt.Errorf("transaction %s has nothing left to pay", transaction.TransactionNumber)
+	}
+
+	type paymentMethod struct {
+		Name string `db:"name"`
+		Type string `db:"type"`
+	}
+	methods := make([]paymentMethod, len(tenders))
+	for i, tender := range tenders {
+		err = tx.Get(&methods[i], `
+			SELECT name, type FROM payment_methods
+			WHERE method_id = $1 AND is_active = TRUE AND deleted_at IS NULL
+		`, tender.PaymentMethodID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("payment method %d not found", tender.PaymentMethodID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get payment method: %w", err)
+		}
+	}
+
+	// Non-cash tenders are applied first, in full
+	applied := make([]decimal.Decimal, len(tenders))
+	remaining := balance.Outstanding
+	for i, tender := range tenders {
+		if methods[i].Type == "cash" {
+			continue
+		}
+		if tender.Amount.GreaterThan(remaining) {
+			return fmt.Errorf("%s tender of %s exceeds the %s left to pay; only cash can be over-tendered",
+				methods[i].Name, tender.Amount.StringFixed(2), remaining.StringFixed(2))
+		}
+		applied[i] = tender.Amount
+		remaining = remaining.Sub(tender.Amount)
+	}
+	for i, tender := range tenders {
+		if methods[i].Type != "cash" {
+			continue
+		}
+		applied[i] = decimal.Min(tender.Amount, remaining)
+		if !applied[i].IsPositive() {
+			return fmt.Errorf("%s tender is not needed; the other tenders already cover the %s due",
+				methods[i].Name, balance.Outstanding.StringFixed(2))
+		}
+		remaining = remaining.Sub(applied[i])
+	}
+
+	checkout.OutletID = transaction.OutletID
+	checkout.TransactionNumber = transaction.TransactionNumber
+	checkout.CustomerID = transaction.CustomerID
+	checkout.TotalAmount = transaction.TotalAmount
+	checkout.TenderedAmount = decimal.Zero
+	checkout.AppliedAmount = decimal.Zero
+	for i, tender := range tenders {
+		checkout.TenderedAmount = checkout.TenderedAmount.Add(tender.Amount)
+		checkout.AppliedAmount = checkout.AppliedAmount.Add(applied[i])
+	}
+	checkout.ChangeAmount = checkout.TenderedAmount.Sub(checkout.AppliedAmount)
+
+	err = tx.QueryRow(`
+		INSERT INTO payment_checkouts (checkout_number, transaction_id, outlet_id, tendered_amount, applied_amount,
+			change_amount, shift_id, notes, created_by)
+		VALUES ('CO' || to_char(CURRENT_DATE, 'YYYYMMDD') || '-' || LPAD(nextval('payment_checkout_number_seq')::text, 4, '0'),
+			$1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING checkout_id, checkout_number, created_at
+	`, checkout.TransactionID, checkout.OutletID, checkout.TenderedAmount, checkout.AppliedAmount,
+		checkout.ChangeAmount, checkout.ShiftID, checkout.Notes, checkout.CreatedBy).
+		Scan(&checkout.CheckoutID, &checkout.CheckoutNumber, &checkout.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create checkout: %w", err)
+	}
+
+	var notes string
+	if checkout.Notes != nil {
+		notes = *checkout.Notes
+	}
+
+	payments := make([]*models.Payment, len(tenders))
+	checkout.Tenders = make([]models.CheckoutTender, len(tenders))
+	for i, tender := range tenders {
+		paymentNumber, err := nextPaymentNumber(tx)
+		if err != nil {
+			return err
+		}
+
+		payments[i] = &models.Payment{
+			PaymentNumber:   paymentNumber,
+			TransactionID:   checkout.TransactionID,
+			PaymentMethodID: tender.PaymentMethodID,
+			Amount:          applied[i].InexactFloat64(),
+			TenderedAmount:  tender.Amount.InexactFloat64(),
+			ChangeAmount:    tender.Amount.Sub(applied[i]).InexactFloat64(),
+			PaymentDate:     checkout.CreatedAt,
+			ReferenceNumber: tender.ReferenceNumber,
+			Notes:           notes,
+			ShiftID:         checkout.ShiftID,
+			CheckoutID:      &checkout.CheckoutID,
+			CreatedBy:       checkout.CreatedBy,
+		}
+		if err = insertPayment(tx, payments[i], transaction); err != nil {
+			return err
+		}
+
+		checkout.Tenders[i] = models.CheckoutTender{
+			PaymentID:         payments[i].ID,
+			PaymentNumber:     paymentNumber,
+			PaymentMethodID:   tender.PaymentMethodID,
+			PaymentMethodName: methods[i].Name,
+			PaymentMethodType: methods[i].Type,
+			TenderedAmount:    tender.Amount,
+			AppliedAmount:     applied[i],
+			ChangeAmount:      tender.Amount.Sub(applied[i]),
+		}
+		if tender.ReferenceNumber != "" {
+			checkout.Tenders[i].ReferenceNumber = &payments[i].ReferenceNumber
+		}
+	}
+
+	balance, err = updatePaymentStatus(tx, checkout.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	payload := events.CheckoutCompletedPayload{
+		CheckoutID:        checkout.CheckoutID,
+		CheckoutNumber:    checkout.CheckoutNumber,
+		TransactionID:     checkout.TransactionID,
+		TransactionNumber: transaction.TransactionNumber,
+		OutletID:          transaction.OutletID,
+		CustomerID:        transaction.CustomerID,
+		ServiceJobID:      transaction.ServiceJobID,
+		TenderedAmount:    checkout.TenderedAmount.StringFixed(2),
+		AppliedAmount:     checkout.AppliedAmount.StringFixed(2),
+		ChangeAmount:      checkout.ChangeAmount.StringFixed(2),
+		Remaining:         balance.Outstanding.StringFixed(2),
+		PaymentStatus:     balance.PaymentStatus,
+	}
+	for i, payment := range payments {
+		if err = appendPaymentReceived(tx, payment, transaction, balance); err != nil {
+			return err
+		}
+
+		tender := checkout.Tenders[i]
+		payload.Tenders = append(payload.Tenders, events.CheckoutTenderPayload{
+			PaymentID:         tender.PaymentID,
+			PaymentNumber:     tender.PaymentNumber,
+			PaymentMethodID:   tender.PaymentMethodID,
+			PaymentMethodName: tender.PaymentMethodName,
+			TenderedAmount:    tender.TenderedAmount.StringFixed(2),
+			AppliedAmount:     tender.AppliedAmount.StringFixed(2),
+			ChangeAmount:      tender.ChangeAmount.StringFixed(2),
+		})
+	}
+
+	err = appendEvent(tx, events.CheckoutCompleted, "checkout", checkout.CheckoutID, &transaction.OutletID, payload)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+const checkoutColumns = `
+	co.checkout_id, co.checkout_number, co.transaction_id, t.transaction_number, co.outlet_id,
+	t.customer_id, c.name AS customer_name, t.total_amount, co.tendered_amount, co.applied_amount,
+	co.change_amount, co.shift_id, co.notes, co.created_at, co.created_by, u.full_name AS cashier_name
+`
+
+const checkoutJoins = `
+	FROM payment_checkouts co
+	JOIN transactions t ON t.transaction_id = co.transaction_id
+	LEFT JOIN customers c ON c.customer_id = t.customer_id
+	LEFT JOIN users u ON u.user_id = co.created_by
+`
+
+// GetCheckoutByID returns a checkout as its receipt: every tender with what
+// was tendered, applied and given as change, and the transaction's balance.
+// Tenders whose payment was reversed later are kept and marked.
+func (r *paymentRepository) GetCheckoutByID(id int64) (*models.Checkout, error) {
+	var checkout models.Checkout
+	err := r.db.Get(&checkout, `SELECT `+checkoutColumns+checkoutJoins+` WHERE co.checkout_id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkout: %w", err)
+	}
+
+	err = r.db.Select(&checkout.Tenders, `
+		SELECT p.payment_id, p.payment_number, p.payment_method_id, pm.name AS payment_method_name,
+			pm.type AS payment_method_type, p.tendered_amount, p.amount AS applied_amount, p.change_amount,
+			p.reference_number, p.deleted_at IS NOT NULL AS reversed
+		FROM payments p
+		JOIN payment_methods pm ON pm.method_id = p.payment_method_id
+		WHERE p.checkout_id = $1
+		ORDER BY p.payment_id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkout tenders: %w", err)
+	}
+
+	checkout.Balance, err = transactionBalance(r.db, checkout.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &checkout, nil
+}
+
+func (r *paymentRepository) ListCheckouts(filter *models.CheckoutFilter, offset, limit int) ([]models.Checkout, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("co.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.TransactionID != nil {
+		conditions = append(conditions, fmt.Sprintf("co.transaction_id = $%d", argIndex))
+		args = append(args, *filter.TransactionID)
+		argIndex++
+	}
+
+	if filter.ShiftID != nil {
+		conditions = append(conditions, fmt.Sprintf("co.shift_id = $%d", argIndex))
+		args = append(args, *filter.ShiftID)
+		argIndex++
+	}
+
+	if filter.DateFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("co.created_at::date >= $%d", argIndex))
+		args = append(args, filter.DateFrom.Format("2006-01-02"))
+		argIndex++
+	}
+
+	if filter.DateTo != nil {
+		conditions = append(conditions, fmt.Sprintf("co.created_at::date <= $%d", argIndex))
+		args = append(args, filter.DateTo.Format("2006-01-02"))
+		argIndex++
+	}
+
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM payment_checkouts co`+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count checkouts: %w", err)
+	}
+
+	query := `SELECT ` + checkoutColumns + checkoutJoins + whereClause +
+		fmt.Sprintf(` ORDER BY co.created_at DESC, co.checkout_id DESC LIMIT $%d OFFSET $%d`, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var checkouts []models.Checkout
+	err = r.db.Select(&checkouts, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list checkouts: %w", err)
+	}
+
+	return checkouts, total, nil
+}
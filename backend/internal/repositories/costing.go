@@ -0,0 +1,530 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"flutter-bengkel/internal/costing"
+	"flutter-bengkel/internal/events"
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// CostingRepository defines data access for goods receipts, which move
+// products' moving average cost, and for the gross margin of sales
+type CostingRepository interface {
+	ListReceipts(filter *models.GoodsReceiptFilter, offset, limit int) ([]models.GoodsReceipt, int64, error)
+	GetReceipt(id int64) (*models.GoodsReceipt, error)
+	CreateReceipt(receipt *models.GoodsReceipt) error
+
+	ListTransactionMargins(filter *models.MarginFilter, offset, limit int) ([]models.DocumentMargin, int64, error)
+	GetTransactionMargin(transactionID int64) (*models.DocumentMargin, error)
+	GetServiceJobMargin(serviceJobID int64) (*models.DocumentMargin, error)
+	GetProductMargins(filter *models.MarginFilter) ([]models.ProductMargin, error)
+	GetOutletMargins(filter *models.MarginFilter) ([]models.OutletMargin, error)
+}
+
+type costingRepository struct {
+	db *sqlx.DB
+}
+
+// NewCostingRepository creates a new costing repository
+func NewCostingRepository(db *sqlx.DB) CostingRepository {
+	return &costingRepository{db: db}
+}
+
+const goodsReceiptColumns = `
+	gr.receipt_id, gr.receipt_number, gr.outlet_id, gr.supplier_id, s.name AS supplier_name, gr.purchase_order_id,
+	po.po_number, gr.supplier_reference, gr.received_at, gr.total_cost, gr.notes, gr.created_at, gr.created_by
+`
+
+const goodsReceiptJoins = `
+	FROM goods_receipts gr
+	LEFT JOIN suppliers s ON s.supplier_id = gr.supplier_id
+	LEFT JOIN purchase_orders po ON po.po_id = gr.purchase_order_id
+`
+
+// transactionMarginLines is every line of a transaction with its revenue
+// before tax and its cost, both net of what was returned. Each line is costed
+// at the unit cost it recorded when it was sold.
+const transactionMarginLines = `
+	SELECT d.transaction_id, d.detail_id, d.product_id, d.service_id,
+		COALESCE(p.name, s.name, d.description, '') AS item_name,
+		d.quantity - COALESCE(ri.quantity, 0) AS quantity, d.unit_cost,
+		COALESCE(d.net_amount, 0) - COALESCE(d.discount_amount, 0) - COALESCE(ri.net, 0) + COALESCE(ri.discount, 0) AS revenue,
+		ROUND((d.quantity - COALESCE(ri.quantity, 0)) * COALESCE(d.unit_cost, 0), 2) AS cost
+	FROM transaction_details d
+	LEFT JOIN products p ON p.product_id = d.product_id
+	LEFT JOIN services s ON s.service_id = d.service_id
+	LEFT JOIN (
+		SELECT transaction_detail_id, SUM(quantity) AS quantity, SUM(net_amount) AS net, SUM(discount_amount) AS discount
+		FROM sales_return_items GROUP BY transaction_detail_id
+	) ri ON ri.transaction_detail_id = d.detail_id
+	WHERE d.deleted_at IS NULL
+`
+
+// marginTransactions are the sales margins are reported on: service and
+// sparepart sales that were not voided. Vehicle trading has its own report.
+const marginTransactions = `t.deleted_at IS NULL AND t.voided_at IS NULL AND t.transaction_type IN ('service', 'sparepart_sale')`
+
+func (r *costingRepository) ListReceipts(filter *models.GoodsReceiptFilter, offset, limit int) ([]models.GoodsReceipt, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("gr.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.SupplierID != nil {
+		conditions = append(conditions, fmt.Sprintf("gr.supplier_id = $%d", argIndex))
+		args = append(args, *filter.SupplierID)
+		argIndex++
+	}
+
+	if filter.PurchaseOrderID != nil {
+		conditions = append(conditions, fmt.Sprintf("gr.purchase_order_id = $%d", argIndex))
+		args = append(args, *filter.PurchaseOrderID)
+		argIndex++
+	}
+
+	if filter.ProductID != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM goods_receipt_items gri WHERE gri.receipt_id = gr.receipt_id AND gri.product_id = $%d)", argIndex))
+		args = append(args, *filter.ProductID)
+		argIndex++
+	}
+
+	if filter.DateFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("gr.received_at::date >= $%d", argIndex))
+		args = append(args, filter.DateFrom.Format("2006-01-02"))
+		argIndex++
+	}
+
+	if filter.DateTo != nil {
+		conditions = append(conditions, fmt.Sprintf("gr.received_at::date <= $%d", argIndex))
+		args = append(args, filter.DateTo.Format("2006-01-02"))
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM goods_receipts gr `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count goods receipts: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		%s
+		%s
+		ORDER BY gr.received_at DESC, gr.receipt_id DESC
+		LIMIT $%d OFFSET $%d
+	`, goodsReceiptColumns, goodsReceiptJoins, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var receipts []models.GoodsReceipt
+	if err = r.db.Select(&receipts, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list goods receipts: %w", err)
+	}
+
+	return receipts, total, nil
+}
+
+func (r *costingRepository) GetReceipt(id int64) (*models.GoodsReceipt, error) {
+	var receipt models.GoodsReceipt
+	err := r.db.Get(&receipt, `SELECT `+goodsReceiptColumns+goodsReceiptJoins+` WHERE gr.receipt_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("goods receipt not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goods receipt: %w", err)
+	}
+
+	receipt.Items = []models.GoodsReceiptItem{}
+	err = r.db.Select(&receipt.Items, `
+		SELECT gri.item_id, gri.receipt_id, gri.product_id, p.product_code, p.name AS product_name,
+			gri.purchase_order_detail_id, gri.quantity, gri.unit_cost, gri.total_cost, gri.stock_before,
+			gri.cost_before, gri.cost_after
+		FROM goods_receipt_items gri
+		JOIN products p ON p.product_id = gri.product_id
+		WHERE gri.receipt_id = $1
+		ORDER BY gri.item_id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goods receipt items: %w", err)
+	}
+
+	return &receipt, nil
+}
+
+// CreateReceipt receives goods into stock in one database transaction. Each
+// product's cost moves to the weighted average of its stock on hand and the
+// goods received, purchase order lines count what was received against
+// them, and the order is marked received once every line is in. The
+// GoodsReceived event posts the receipt to the ledger.
+func (r *costingRepository) CreateReceipt(receipt *models.GoodsReceipt) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if receipt.PurchaseOrderID != nil {
+		var order struct {
+			OutletID   int64  `db:"outlet_id"`
+			SupplierID int64  `db:"supplier_id"`
+			PONumber   string `db:"po_number"`
+			Status     string `db:"status"`
+		}
+		err = tx.Get(&order, `
+			SELECT outlet_id, supplier_id, po_number, status FROM purchase_orders
+			WHERE po_id = $1 AND deleted_at IS NULL
+			FOR UPDATE
+		`, *receipt.PurchaseOrderID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("purchase order not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get purchase order: %w", err)
+		}
+		if order.Status != "sent" && order.Status != "confirmed" {
+			return fmt.Errorf("purchase order %s is %s and cannot be received against", order.PONumber, order.Status)
+		}
+		if order.OutletID != receipt.OutletID {
+			return fmt.Errorf("purchase order %s is for another outlet", order.PONumber)
+		}
+		if receipt.SupplierID == nil {
+			receipt.SupplierID = &order.SupplierID
+		} else if *receipt.SupplierID != order.SupplierID {
+			return fmt.Errorf("purchase order %s is from another supplier", order.PONumber)
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO goods_receipts (receipt_number, outlet_id, supplier_id, purchase_order_id, supplier_reference,
+			notes, created_by)
+		VALUES ('GR' || to_char(CURRENT_DATE, 'YYYYMMDD') || '-' || LPAD(nextval('goods_receipt_number_seq')::text, 4, '0'),
+			$1, $2, $3, $4, $5, $6)
+		RETURNING receipt_id, receipt_number, received_at, created_at
+	`, receipt.OutletID, receipt.SupplierID, receipt.PurchaseOrderID, receipt.SupplierReference, receipt.Notes,
+		receipt.CreatedBy).Scan(&receipt.ReceiptID, &receipt.ReceiptNumber, &receipt.ReceivedAt, &receipt.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create goods receipt: %w", err)
+	}
+
+	receipt.TotalCost = decimal.Zero
+	for i := range receipt.Items {
+		item := &receipt.Items[i]
+		item.ReceiptID = receipt.ReceiptID
+
+		item.UnitCost = item.UnitCost.Round(2)
+		item.TotalCost = item.UnitCost.Mul(decimal.NewFromInt(int64(item.Quantity))).Round(2)
+		product, err := receiveStock(tx, item.ProductID, item.Quantity, item.UnitCost)
+		if err != nil {
+			return err
+		}
+		item.ProductCode = product.ProductCode
+		item.ProductName = product.Name
+		item.StockBefore = product.Stock
+		item.CostBefore = product.CostPrice
+		item.CostAfter = product.CostAfter
+
+		if item.PurchaseOrderDetailID != nil {
+			if receipt.PurchaseOrderID == nil {
+				return fmt.Errorf("purchase_order_id is required to receive against a purchase order line")
+			}
+			result, err := tx.Exec(`
+				UPDATE purchase_order_details
+				SET received_quantity = COALESCE(received_quantity, 0) + $2, updated_at = CURRENT_TIMESTAMP
+				WHERE detail_id = $1 AND purchase_order_id = $3 AND product_id = $4 AND deleted_at IS NULL
+			`, *item.PurchaseOrderDetailID, item.Quantity, *receipt.PurchaseOrderID, item.ProductID)
+			if err != nil {
+				return fmt.Errorf("failed to update purchase order line: %w", err)
+			}
+			if rows, _ := result.RowsAffected(); rows == 0 {
+				return fmt.Errorf("purchase order line %d is not for %s", *item.PurchaseOrderDetailID, product.Name)
+			}
+		}
+
+		err = tx.QueryRow(`
+			INSERT INTO goods_receipt_items (receipt_id, product_id, purchase_order_detail_id, quantity, unit_cost,
+				total_cost, stock_before, cost_before, cost_after)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING item_id
+		`, item.ReceiptID, item.ProductID, item.PurchaseOrderDetailID, item.Quantity, item.UnitCost,
+			item.TotalCost, item.StockBefore, item.CostBefore, item.CostAfter).Scan(&item.ItemID)
+		if err != nil {
+			return fmt.Errorf("failed to add goods receipt item: %w", err)
+		}
+
+		receipt.TotalCost = receipt.TotalCost.Add(item.TotalCost)
+	}
+
+	_, err = tx.Exec(`UPDATE goods_receipts SET total_cost = $2 WHERE receipt_id = $1`, receipt.ReceiptID, receipt.TotalCost)
+	if err != nil {
+		return fmt.Errorf("failed to update goods receipt total: %w", err)
+	}
+
+	if receipt.PurchaseOrderID != nil {
+		_, err = tx.Exec(`
+			UPDATE purchase_orders
+			SET status = 'received', actual_delivery_date = CURRENT_DATE, updated_at = CURRENT_TIMESTAMP
+			WHERE po_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM purchase_order_details
+				WHERE purchase_order_id = $1 AND deleted_at IS NULL AND COALESCE(received_quantity, 0) < quantity
+			)
+		`, *receipt.PurchaseOrderID)
+		if err != nil {
+			return fmt.Errorf("failed to update purchase order: %w", err)
+		}
+	}
+
+	err = appendEvent(tx, events.GoodsReceived, "goods_receipt", receipt.ReceiptID, &receipt.OutletID, events.GoodsReceivedPayload{
+		ReceiptID:       receipt.ReceiptID,
+		ReceiptNumber:   receipt.ReceiptNumber,
+		OutletID:        receipt.OutletID,
+		SupplierID:      receipt.SupplierID,
+		PurchaseOrderID: receipt.PurchaseOrderID,
+		TotalCost:       receipt.TotalCost.StringFixed(2),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// receivedProduct is a product's stock and cost before goods came in, and
+// its cost after
+type receivedProduct struct {
+	ProductCode string          `db:"product_code"`
+	Name        string          `db:"name"`
+	Stock       int             `db:"stock_quantity"`
+	CostPrice   decimal.Decimal `db:"cost_price"`
+	CostAfter   decimal.Decimal `db:"-"`
+}
+
+// receiveStock adds quantity units bought at unitCost to a product's stock
+// and moves its cost price to the weighted average
+func receiveStock(tx *sqlx.Tx, productID int64, quantity int, unitCost decimal.Decimal) (*receivedProduct, error) {
+	var product receivedProduct
+	err := tx.Get(&product, `
+		SELECT product_code, name, COALESCE(stock_quantity, 0) AS stock_quantity, COALESCE(cost_price, 0) AS cost_price
+		FROM products WHERE product_id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`, productID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("product %d not found", productID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	product.CostAfter = costing.WeightedAverage(decimal.NewFromInt(int64(product.Stock)), product.CostPrice,
+		decimal.NewFromInt(int64(quantity)), unitCost)
+	_, err = tx.Exec(`UPDATE products SET cost_price = $2 WHERE product_id = $1`, productID, product.CostAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update product cost: %w", err)
+	}
+	if err = adjustStock(tx, productID, quantity, "add"); err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (r *costingRepository) ListTransactionMargins(filter *models.MarginFilter, offset, limit int) ([]models.DocumentMargin, int64, error) {
+	conditions := []string{marginTransactions, "t.transaction_date::date >= $1", "t.transaction_date::date <= $2"}
+	args := []interface{}{filter.DateFrom.Format("2006-01-02"), filter.DateTo.Format("2006-01-02")}
+	argIndex := 3
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("t.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.ProductID != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM transaction_details pd WHERE pd.transaction_id = t.transaction_id AND pd.product_id = $%d AND pd.deleted_at IS NULL)", argIndex))
+		args = append(args, *filter.ProductID)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM transactions t `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT t.transaction_id AS document_id, t.transaction_number AS document_number, t.outlet_id,
+			c.name AS customer_name, t.transaction_date AS date,
+			COALESCE(SUM(l.revenue), 0) AS revenue, COALESCE(SUM(l.cost), 0) AS cost
+		FROM transactions t
+		LEFT JOIN customers c ON c.customer_id = t.customer_id
+		LEFT JOIN (%s) l ON l.transaction_id = t.transaction_id
+		%s
+		GROUP BY t.transaction_id, c.name
+		ORDER BY t.transaction_date DESC, t.transaction_id DESC
+		LIMIT $%d OFFSET $%d
+	`, transactionMarginLines, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var margins []models.DocumentMargin
+	if err = r.db.Select(&margins, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list transaction margins: %w", err)
+	}
+
+	return margins, total, nil
+}
+
+func (r *costingRepository) GetTransactionMargin(transactionID int64) (*models.DocumentMargin, error) {
+	var margin models.DocumentMargin
+	err := r.db.Get(&margin, `
+		SELECT t.transaction_id AS document_id, t.transaction_number AS document_number, t.outlet_id,
+			c.name AS customer_name, t.transaction_date AS date, 0 AS revenue, 0 AS cost
+		FROM transactions t
+		LEFT JOIN customers c ON c.customer_id = t.customer_id
+		WHERE t.transaction_id = $1 AND t.deleted_at IS NULL
+	`, transactionID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("transaction not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	margin.Lines = []models.MarginLine{}
+	err = r.db.Select(&margin.Lines, `
+		SELECT detail_id, product_id, service_id, item_name, quantity, unit_cost, revenue, cost
+		FROM (`+transactionMarginLines+`) l
+		WHERE l.transaction_id = $1
+		ORDER BY l.detail_id
+	`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction margin lines: %w", err)
+	}
+
+	return &margin, nil
+}
+
+// GetServiceJobMargin costs the lines on a service job at the unit cost
+// each recorded when it was added
+func (r *costingRepository) GetServiceJobMargin(serviceJobID int64) (*models.DocumentMargin, error) {
+	var margin models.DocumentMargin
+	err := r.db.Get(&margin, `
+		SELECT sj.job_id AS document_id, sj.job_number AS document_number, sj.outlet_id,
+			c.name AS customer_name, sj.created_at AS date, 0 AS revenue, 0 AS cost
+		FROM service_jobs sj
+		LEFT JOIN customers c ON c.customer_id = sj.customer_id
+		WHERE sj.job_id = $1 AND sj.deleted_at IS NULL
+	`, serviceJobID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("service job not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service job: %w", err)
+	}
+
+	margin.Lines = []models.MarginLine{}
+	err = r.db.Select(&margin.Lines, `
+		SELECT sd.detail_id, sd.product_id, sd.service_id, COALESCE(p.name, s.name, '') AS item_name,
+			sd.quantity, sd.unit_cost,
+			COALESCE(sd.net_amount, sd.total_price) - COALESCE(sd.discount_amount, 0) AS revenue,
+			ROUND(sd.quantity * COALESCE(sd.unit_cost, 0), 2) AS cost
+		FROM service_details sd
+		LEFT JOIN products p ON p.product_id = sd.product_id
+		LEFT JOIN services s ON s.service_id = sd.service_id
+		WHERE sd.service_job_id = $1 AND sd.deleted_at IS NULL
+		ORDER BY sd.detail_id
+	`, serviceJobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service job margin lines: %w", err)
+	}
+
+	return &margin, nil
+}
+
+// GetProductMargins totals each product's sales over a period against the
+// cost they were sold at
+func (r *costingRepository) GetProductMargins(filter *models.MarginFilter) ([]models.ProductMargin, error) {
+	conditions := []string{marginTransactions, "t.transaction_date::date >= $1", "t.transaction_date::date <= $2"}
+	args := []interface{}{filter.DateFrom.Format("2006-01-02"), filter.DateTo.Format("2006-01-02")}
+	argIndex := 3
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("t.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.ProductID != nil {
+		conditions = append(conditions, fmt.Sprintf("l.product_id = $%d", argIndex))
+		args = append(args, *filter.ProductID)
+		argIndex++
+	}
+
+	var margins []models.ProductMargin
+	err := r.db.Select(&margins, `
+		SELECT p.product_id, p.product_code, p.name, COALESCE(p.cost_price, 0) AS cost_price,
+			SUM(l.quantity) AS quantity, SUM(l.revenue) AS revenue, SUM(l.cost) AS cost
+		FROM (`+transactionMarginLines+`) l
+		JOIN transactions t ON t.transaction_id = l.transaction_id
+		JOIN products p ON p.product_id = l.product_id
+		WHERE `+strings.Join(conditions, " AND ")+`
+		GROUP BY p.product_id
+		ORDER BY SUM(l.revenue) - SUM(l.cost) DESC, p.product_id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product margins: %w", err)
+	}
+
+	return margins, nil
+}
+
+// GetOutletMargins totals each outlet's sales over a period against their
+// cost
+func (r *costingRepository) GetOutletMargins(filter *models.MarginFilter) ([]models.OutletMargin, error) {
+	conditions := []string{marginTransactions, "t.transaction_date::date >= $1", "t.transaction_date::date <= $2"}
+	args := []interface{}{filter.DateFrom.Format("2006-01-02"), filter.DateTo.Format("2006-01-02")}
+	argIndex := 3
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("t.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	var margins []models.OutletMargin
+	err := r.db.Select(&margins, `
+		SELECT o.outlet_id, o.name AS outlet_name, COUNT(DISTINCT t.transaction_id) AS transaction_count,
+			COALESCE(SUM(l.revenue) FILTER (WHERE l.service_id IS NOT NULL), 0) AS service_revenue,
+			COALESCE(SUM(l.revenue) FILTER (WHERE l.service_id IS NULL AND l.product_id IS NOT NULL), 0) AS parts_revenue,
+			COALESCE(SUM(l.revenue), 0) AS revenue, COALESCE(SUM(l.cost), 0) AS cost
+		FROM transactions t
+		JOIN outlets o ON o.outlet_id = t.outlet_id
+		LEFT JOIN (`+transactionMarginLines+`) l ON l.transaction_id = t.transaction_id
+		WHERE `+strings.Join(conditions, " AND ")+`
+		GROUP BY o.outlet_id
+		ORDER BY o.name, o.outlet_id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outlet margins: %w", err)
+	}
+
+	return margins, nil
+}
@@ -30,9 +30,11 @@ func NewCustomerRepository(db *sqlx.DB) CustomerRepository {
 func (r *customerRepository) Create(customer *models.Customer) error {
 	query := `
 		INSERT INTO customers (customer_code, name, email, phone, address, city, province, 
-							  postal_code, date_of_birth, gender, customer_type, notes, is_active)
+							  postal_code, date_of_birth, gender, customer_type, notification_channel, language,
+							  notes, is_active)
 		VALUES (:customer_code, :name, :email, :phone, :address, :city, :province, 
-				:postal_code, :date_of_birth, :gender, :customer_type, :notes, :is_active)
+				:postal_code, :date_of_birth, :gender, :customer_type, :notification_channel, :language,
+				:notes, :is_active)
 	`
 	
 	result, err := r.db.NamedExec(query, customer)
@@ -52,7 +54,8 @@ func (r *customerRepository) Create(customer *models.Customer) error {
 func (r *customerRepository) GetByID(id int64) (*models.Customer, error) {
 	query := `
 		SELECT id, customer_code, name, email, phone, address, city, province, 
-			   postal_code, date_of_birth, gender, customer_type, loyalty_points, notes, 
+			   postal_code, date_of_birth, gender, customer_type, loyalty_points, 
+			   notification_channel, language, notes, 
 			   is_active, created_at, updated_at
 		FROM customers 
 		WHERE id = ?
@@ -70,7 +73,8 @@ func (r *customerRepository) GetByID(id int64) (*models.Customer, error) {
 func (r *customerRepository) GetByCustomerCode(code string) (*models.Customer, error) {
 	query := `
 		SELECT id, customer_code, name, email, phone, address, city, province, 
-			   postal_code, date_of_birth, gender, customer_type, loyalty_points, notes, 
+			   postal_code, date_of_birth, gender, customer_type, loyalty_points, 
+			   notification_channel, language, notes, 
 			   is_active, created_at, updated_at
 		FROM customers 
 		WHERE customer_code = ?
@@ -91,6 +95,7 @@ func (r *customerRepository) Update(id int64, customer *models.Customer) error {
 		SET name = :name, email = :email, phone = :phone, address = :address, 
 			city = :city, province = :province, postal_code = :postal_code, 
 			date_of_birth = :date_of_birth, gender = :gender, customer_type = :customer_type, 
+			notification_channel = :notification_channel, language = :language,
 			notes = :notes, is_active = :is_active
 		WHERE id = :id
 	`
@@ -136,7 +141,8 @@ func (r *customerRepository) List(offset, limit int, search string) ([]models.Cu
 	// Get customers
 	query := fmt.Sprintf(`
 		SELECT id, customer_code, name, email, phone, address, city, province, 
-			   postal_code, date_of_birth, gender, customer_type, loyalty_points, notes, 
+			   postal_code, date_of_birth, gender, customer_type, loyalty_points, 
+			   notification_channel, language, notes, 
 			   is_active, created_at, updated_at
 		FROM customers %s
 		ORDER BY created_at DESC
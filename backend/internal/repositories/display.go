@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"fmt"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DisplayRepository interface defines outlet display token operations
+type DisplayRepository interface {
+	Create(token *models.OutletDisplayToken) error
+	GetByID(id int64) (*models.OutletDisplayToken, error)
+	GetActiveByTokenHash(tokenHash string) (*models.OutletDisplayToken, error)
+	ListByOutlet(outletID *int64) ([]models.OutletDisplayToken, error)
+	Revoke(id int64) error
+	TouchLastSeen(id int64) error
+}
+
+type displayRepository struct {
+	db *sqlx.DB
+}
+
+// NewDisplayRepository creates a new display repository
+func NewDisplayRepository(db *sqlx.DB) DisplayRepository {
+	return &displayRepository{db: db}
+}
+
+func (r *displayRepository) Create(token *models.OutletDisplayToken) error {
+	query := `
+		INSERT INTO outlet_display_tokens (outlet_id, name, token_hash, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING display_token_id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query, token.OutletID, token.Name, token.TokenHash, token.IsActive, token.CreatedBy).
+		Scan(&token.DisplayTokenID, &token.CreatedAt, &token.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create display token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *displayRepository) GetByID(id int64) (*models.OutletDisplayToken, error) {
+	query := `
+		SELECT dt.display_token_id, dt.outlet_id, o.name as outlet_name, dt.name,
+			   dt.is_active, dt.last_seen_at, dt.created_at, dt.updated_at, dt.created_by
+		FROM outlet_display_tokens dt
+		JOIN outlets o ON dt.outlet_id = o.outlet_id
+		WHERE dt.display_token_id = $1 AND dt.deleted_at IS NULL
+	`
+
+	var token models.OutletDisplayToken
+	err := r.db.Get(&token, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get display token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// GetActiveByTokenHash finds an active display by the hash of its token;
+// the token itself is not stored
+func (r *displayRepository) GetActiveByTokenHash(tokenHash string) (*models.OutletDisplayToken, error) {
+	query := `
+		SELECT dt.display_token_id, dt.outlet_id, o.name as outlet_name, dt.name,
+			   dt.is_active, dt.last_seen_at, dt.created_at, dt.updated_at, dt.created_by
+		FROM outlet_display_tokens dt
+		JOIN outlets o ON dt.outlet_id = o.outlet_id
+		WHERE dt.token_hash = $1 AND dt.is_active = TRUE AND dt.deleted_at IS NULL
+			  AND o.is_active = TRUE AND o.deleted_at IS NULL
+	`
+
+	var displayToken models.OutletDisplayToken
+	err := r.db.Get(&displayToken, query, tokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get display token: %w", err)
+	}
+
+	return &displayToken, nil
+}
+
+func (r *displayRepository) ListByOutlet(outletID *int64) ([]models.OutletDisplayToken, error) {
+	query := `
+		SELECT dt.display_token_id, dt.outlet_id, o.name as outlet_name, dt.name,
+			   dt.is_active, dt.last_seen_at, dt.created_at, dt.updated_at, dt.created_by
+		FROM outlet_display_tokens dt
+		JOIN outlets o ON dt.outlet_id = o.outlet_id
+		WHERE dt.deleted_at IS NULL AND ($1::BIGINT IS NULL OR dt.outlet_id = $1)
+		ORDER BY dt.outlet_id, dt.created_at DESC
+	`
+
+	var tokens []models.OutletDisplayToken
+	err := r.db.Select(&tokens, query, outletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list display tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (r *displayRepository) Revoke(id int64) error {
+	query := `
+		UPDATE outlet_display_tokens
+		SET is_active = FALSE, deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE display_token_id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke display token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("display token not found")
+	}
+
+	return nil
+}
+
+func (r *displayRepository) TouchLastSeen(id int64) error {
+	query := `UPDATE outlet_display_tokens SET last_seen_at = CURRENT_TIMESTAMP WHERE display_token_id = $1`
+
+	_, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to update display token last seen: %w", err)
+	}
+
+	return nil
+}
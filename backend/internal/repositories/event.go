@@ -0,0 +1,249 @@
+package repositories
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// EventRepository interface defines domain event outbox operations
+type EventRepository interface {
+	GetByID(id int64) (*models.DomainEvent, error)
+	ClaimDue(limit int, staleAfter time.Duration) ([]models.DomainEvent, error)
+	GetDeliveredSubscribers(eventID int64) ([]string, error)
+	RecordDelivery(eventID int64, subscriber string) error
+	MarkProcessed(id int64) error
+	MarkFailed(id int64, lastError string, nextAttemptAt *time.Time) error
+	Requeue(id int64) error
+	List(filter *models.DomainEventFilter, offset, limit int) ([]models.DomainEvent, int64, error)
+	PurgeProcessed(before time.Time) (int64, error)
+}
+
+type eventRepository struct {
+	db *sqlx.DB
+}
+
+// NewEventRepository creates a new domain event repository
+func NewEventRepository(db *sqlx.DB) EventRepository {
+	return &eventRepository{db: db}
+}
+
+const domainEventColumns = `
+	event_id, event_type, aggregate_type, aggregate_id, outlet_id, payload, status, attempts,
+	max_attempts, next_attempt_at, last_error, processed_at, occurred_at, created_at, updated_at
+`
+
+// appendEvent records a domain event in the outbox as part of tx, so the
+// event exists if and only if the change it describes is committed
+func appendEvent(tx *sqlx.Tx, eventType, aggregateType string, aggregateID int64, outletID *int64, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO domain_events (event_type, aggregate_type, aggregate_id, outlet_id, payload)
+		VALUES ($1, $2, $3, $4, $5)
+	`, eventType, aggregateType, aggregateID, outletID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to record %s event: %w", eventType, err)
+	}
+
+	return nil
+}
+
+func (r *eventRepository) GetByID(id int64) (*models.DomainEvent, error) {
+	query := `SELECT ` + domainEventColumns + ` FROM domain_events WHERE event_id = $1`
+
+	var event models.DomainEvent
+	err := r.db.Get(&event, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain event: %w", err)
+	}
+
+	return &event, nil
+}
+
+// ClaimDue locks due events for dispatch in the order they occurred. Events
+// left in processing longer than staleAfter, e.g. by a crashed process, are
+// claimed again.
+func (r *eventRepository) ClaimDue(limit int, staleAfter time.Duration) ([]models.DomainEvent, error) {
+	query := `
+		UPDATE domain_events
+		SET status = 'processing', attempts = attempts + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE event_id IN (
+			SELECT event_id FROM domain_events
+			WHERE (status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP)
+				  OR (status = 'processing' AND updated_at <= $2)
+			ORDER BY event_id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + domainEventColumns
+
+	var events []models.DomainEvent
+	err := r.db.Select(&events, query, limit, time.Now().Add(-staleAfter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim domain events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) GetDeliveredSubscribers(eventID int64) ([]string, error) {
+	query := `SELECT subscriber FROM domain_event_deliveries WHERE event_id = $1 ORDER BY subscriber`
+
+	var subscribers []string
+	err := r.db.Select(&subscribers, query, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event deliveries: %w", err)
+	}
+
+	return subscribers, nil
+}
+
+func (r *eventRepository) RecordDelivery(eventID int64, subscriber string) error {
+	query := `
+		INSERT INTO domain_event_deliveries (event_id, subscriber)
+		VALUES ($1, $2)
+		ON CONFLICT (event_id, subscriber) DO NOTHING
+	`
+
+	_, err := r.db.Exec(query, eventID, subscriber)
+	if err != nil {
+		return fmt.Errorf("failed to record event delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (r *eventRepository) MarkProcessed(id int64) error {
+	query := `
+		UPDATE domain_events
+		SET status = 'processed', processed_at = CURRENT_TIMESTAMP, last_error = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE event_id = $1
+	`
+
+	_, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark domain event processed: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed dispatch, scheduling a retry at nextAttemptAt
+// or giving up when it is nil
+func (r *eventRepository) MarkFailed(id int64, lastError string, nextAttemptAt *time.Time) error {
+	query := `
+		UPDATE domain_events
+		SET status = CASE WHEN $3::TIMESTAMP IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($3, next_attempt_at), last_error = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE event_id = $1
+	`
+
+	_, err := r.db.Exec(query, id, lastError, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark domain event failed: %w", err)
+	}
+
+	return nil
+}
+
+// Requeue schedules a failed event for immediate dispatch, allowing one more attempt
+func (r *eventRepository) Requeue(id int64) error {
+	query := `
+		UPDATE domain_events
+		SET status = 'pending', next_attempt_at = CURRENT_TIMESTAMP,
+			max_attempts = GREATEST(max_attempts, attempts + 1), updated_at = CURRENT_TIMESTAMP
+		WHERE event_id = $1 AND status = 'failed'
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to requeue domain event: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("failed domain event not found")
+	}
+
+	return nil
+}
+
+func (r *eventRepository) List(filter *models.DomainEventFilter, offset, limit int) ([]models.DomainEvent, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.EventType != "" {
+		conditions = append(conditions, fmt.Sprintf("event_type = $%d", argIndex))
+		args = append(args, filter.EventType)
+		argIndex++
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, filter.Status)
+		argIndex++
+	}
+
+	if filter.AggregateType != "" {
+		conditions = append(conditions, fmt.Sprintf("aggregate_type = $%d", argIndex))
+		args = append(args, filter.AggregateType)
+		argIndex++
+	}
+
+	if filter.AggregateID != nil {
+		conditions = append(conditions, fmt.Sprintf("aggregate_id = $%d", argIndex))
+		args = append(args, *filter.AggregateID)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM domain_events `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count domain events: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM domain_events %s ORDER BY event_id DESC LIMIT $%d OFFSET $%d`,
+		domainEventColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var events []models.DomainEvent
+	err = r.db.Select(&events, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list domain events: %w", err)
+	}
+
+	return events, total, nil
+}
+
+// PurgeProcessed deletes processed events older than the cutoff along with
+// their delivery records
+func (r *eventRepository) PurgeProcessed(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM domain_events WHERE status = 'processed' AND processed_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge domain events: %w", err)
+	}
+
+	return result.RowsAffected()
+}
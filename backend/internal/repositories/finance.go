@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// FinanceRepository interface defines receivable, payable and cash summary maintenance operations
+type FinanceRepository interface {
+	MarkOverdueReceivables(asOf time.Time) (int64, error)
+	MarkOverduePayables(asOf time.Time) (int64, error)
+	SummarizeDailyCash(date time.Time) (int64, error)
+}
+
+type financeRepository struct {
+	db *sqlx.DB
+}
+
+// NewFinanceRepository creates a new finance repository
+func NewFinanceRepository(db *sqlx.DB) FinanceRepository {
+	return &financeRepository{db: db}
+}
+
+// MarkOverdueReceivables flags unpaid receivables whose due date is before asOf
+func (r *financeRepository) MarkOverdueReceivables(asOf time.Time) (int64, error) {
+	query := `
+		UPDATE accounts_receivables
+		SET status = 'overdue', updated_at = CURRENT_TIMESTAMP
+		WHERE due_date < $1::date AND status IN ('outstanding', 'partial') AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, asOf.Format("2006-01-02"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark overdue receivables: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// MarkOverduePayables flags unpaid payables whose due date is before asOf
+func (r *financeRepository) MarkOverduePayables(asOf time.Time) (int64, error) {
+	query := `
+		UPDATE accounts_payables
+		SET status = 'overdue', updated_at = CURRENT_TIMESTAMP
+		WHERE due_date < $1::date AND status IN ('outstanding', 'partial') AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, asOf.Format("2006-01-02"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark overdue payables: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// SummarizeDailyCash writes a provisional cash summary for the date for
+// every active outlet whose cash is not closed through it yet. The day opens
+// with the last closed balance plus the flows of the open days in between.
+// Re-running it for the same date recomputes the summary; closing the day
+// makes it final.
+func (r *financeRepository) SummarizeDailyCash(date time.Time) (int64, error) {
+	query := `
+		INSERT INTO daily_cash_summaries (outlet_id, summary_date, opening_balance, total_inflow, total_outflow, closing_balance)
+		SELECT o.outlet_id, $1::date,
+			COALESCE(prev.closing_balance, 0) + COALESCE(flows.carried, 0),
+			COALESCE(flows.inflow, 0),
+			COALESCE(flows.outflow, 0),
+			COALESCE(prev.closing_balance, 0) + COALESCE(flows.carried, 0) + COALESCE(flows.inflow, 0) - COALESCE(flows.outflow, 0)
+		FROM outlets o
+		LEFT JOIN LATERAL (
+			SELECT s.summary_date, s.closing_balance
+			FROM daily_cash_summaries s
+			WHERE s.outlet_id = o.outlet_id AND s.status = 'closed' AND s.deleted_at IS NULL
+			ORDER BY s.summary_date DESC
+			LIMIT 1
+		) prev ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT
+				SUM(CASE WHEN cf.flow_type = 'inflow' THEN cf.amount ELSE -cf.amount END) FILTER (WHERE cf.transaction_date < $1::date) AS carried,
+				SUM(cf.amount) FILTER (WHERE cf.transaction_date = $1::date AND cf.flow_type = 'inflow') AS inflow,
+				SUM(cf.amount) FILTER (WHERE cf.transaction_date = $1::date AND cf.flow_type = 'outflow') AS outflow
+			FROM cash_flows cf
+			WHERE cf.outlet_id = o.outlet_id AND cf.deleted_at IS NULL AND cf.transaction_date <= $1::date
+				AND cf.transaction_date > COALESCE(prev.summary_date, DATE '-infinity')
+		) flows ON TRUE
+		WHERE o.deleted_at IS NULL AND (prev.summary_date IS NULL OR prev.summary_date < $1::date)
+		ON CONFLICT (outlet_id, summary_date) DO UPDATE
+		SET opening_balance = EXCLUDED.opening_balance,
+			total_inflow = EXCLUDED.total_inflow,
+			total_outflow = EXCLUDED.total_outflow,
+			closing_balance = EXCLUDED.closing_balance,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE daily_cash_summaries.status = 'open'
+	`
+
+	result, err := r.db.Exec(query, date.Format("2006-01-02"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to summarize daily cash: %w", err)
+	}
+
+	return result.RowsAffected()
+}
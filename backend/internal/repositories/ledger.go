@@ -0,0 +1,971 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/ledger"
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// LedgerSource identifies an operational document that posts to the ledger
+type LedgerSource struct {
+	SourceType string `db:"source_type"`
+	SourceID   int64  `db:"source_id"`
+}
+
+// LedgerRepository interface defines chart of accounts, journal and ledger report operations
+type LedgerRepository interface {
+	ListAccounts(includeInactive bool) ([]models.GLAccount, error)
+	GetAccountByID(id int64) (*models.GLAccount, error)
+	CreateAccount(account *models.GLAccount) error
+	UpdateAccount(account *models.GLAccount) error
+	DeleteAccount(id int64) error
+
+	PostEntry(entry *ledger.Entry) (int64, bool, error)
+	GetEntryByID(id int64) (*models.JournalEntry, error)
+	GetEntryBySource(sourceType string, sourceID int64) (*models.JournalEntry, error)
+	ListEntries(filter *models.JournalEntryFilter, offset, limit int) ([]models.JournalEntry, int64, error)
+
+	GetSalesDocument(transactionID int64) (*ledger.SalesDocument, error)
+	GetPaymentDocument(paymentID int64) (*ledger.PaymentDocument, error)
+	GetVehiclePurchaseDocument(purchaseID int64) (*ledger.VehiclePurchaseDocument, error)
+	GetVehicleSaleDocument(saleID int64) (*ledger.VehicleSaleDocument, error)
+	GetVoidDocument(transactionID int64) (*ledger.VoidDocument, error)
+	GetPaymentReversalDocument(paymentID int64) (*ledger.PaymentDocument, error)
+	GetSalesReturnDocument(returnID int64) (*ledger.SalesReturnDocument, error)
+	GetRefundDocument(refundID int64) (*ledger.RefundDocument, error)
+	GetCreditNoteDocument(creditNoteID int64) (*ledger.CreditNoteDocument, error)
+	GetCreditNoteApplicationDocument(applicationID int64) (*ledger.CreditNoteDocument, error)
+	GetStoredValueDocument(entryID int64) (*ledger.StoredValueDocument, error)
+	GetGoodsReceiptDocument(receiptID int64) (*ledger.GoodsReceiptDocument, error)
+	ListUnpostedSources() ([]LedgerSource, error)
+
+	GetTrialBalance(outletID *int64, from, to time.Time) ([]models.TrialBalanceRow, error)
+	GetAccountOpeningBalance(accountID int64, outletID *int64, before time.Time) (decimal.Decimal, error)
+	GetAccountPostings(accountID int64, outletID *int64, from, to time.Time) ([]models.GeneralLedgerLine, error)
+}
+
+type ledgerRepository struct {
+	db *sqlx.DB
+}
+
+// NewLedgerRepository creates a new ledger repository
+func NewLedgerRepository(db *sqlx.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+const glAccountColumns = `
+	account_id, code, name, account_type, normal_balance, parent_id, COALESCE(description, '') AS description,
+	is_system, is_active, created_at, updated_at, deleted_at, created_by
+`
+
+const journalEntryColumns = `
+	e.entry_id, e.entry_number, e.outlet_id, e.entry_date, e.description, e.source_type, e.source_id,
+	e.source_number, e.total_debit, e.total_credit, e.created_at, e.created_by
+`
+
+func (r *ledgerRepository) ListAccounts(includeInactive bool) ([]models.GLAccount, error) {
+	query := fmt.Sprintf(`SELECT %s FROM gl_accounts WHERE deleted_at IS NULL`, glAccountColumns)
+	if !includeInactive {
+		query += ` AND is_active = TRUE`
+	}
+	query += ` ORDER BY code`
+
+	var accounts []models.GLAccount
+	if err := r.db.Select(&accounts, query); err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+func (r *ledgerRepository) GetAccountByID(id int64) (*models.GLAccount, error) {
+	query := fmt.Sprintf(`SELECT %s FROM gl_accounts WHERE account_id = $1 AND deleted_at IS NULL`, glAccountColumns)
+
+	var account models.GLAccount
+	if err := r.db.Get(&account, query, id); err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	return &account, nil
+}
+
+func (r *ledgerRepository) CreateAccount(account *models.GLAccount) error {
+	query := `
+		INSERT INTO gl_accounts (code, name, account_type, normal_balance, parent_id, description, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING account_id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query, account.Code, account.Name, account.AccountType, account.NormalBalance,
+		account.ParentID, account.Description, account.IsActive, account.CreatedBy).
+		Scan(&account.AccountID, &account.CreatedAt, &account.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create account: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ledgerRepository) UpdateAccount(account *models.GLAccount) error {
+	query := `
+		UPDATE gl_accounts
+		SET name = $2, parent_id = $3, description = $4, is_active = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, account.AccountID, account.Name, account.ParentID, account.Description, account.IsActive)
+	if err != nil {
+		return fmt.Errorf("failed to update account: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("account not found")
+	}
+
+	return nil
+}
+
+// DeleteAccount soft deletes an account that is not used by the posting
+// rules and has never been posted to
+func (r *ledgerRepository) DeleteAccount(id int64) error {
+	query := `
+		UPDATE gl_accounts
+		SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $1 AND deleted_at IS NULL AND is_system = FALSE
+			AND NOT EXISTS (SELECT 1 FROM gl_journal_lines l WHERE l.account_id = gl_accounts.account_id)
+			AND NOT EXISTS (SELECT 1 FROM gl_accounts c WHERE c.parent_id = gl_accounts.account_id AND c.deleted_at IS NULL)
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("account not found, is a system account, has sub-accounts or has postings")
+	}
+
+	return nil
+}
+
+// PostEntry writes a validated journal entry with its lines. Entries for an
+// operational document are posted once: if the document already has an
+// entry, its ID is returned with created set to false.
+func (r *ledgerRepository) PostEntry(entry *ledger.Entry) (int64, bool, error) {
+	if err := entry.Validate(); err != nil {
+		return 0, false, err
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	accountIDs, err := resolveAccounts(tx, entry.Lines)
+	if err != nil {
+		return 0, false, err
+	}
+
+	debit, credit := entry.Totals()
+
+	var entryID int64
+	err = tx.QueryRow(`
+		INSERT INTO gl_journal_entries (entry_number, outlet_id, entry_date, description, source_type,
+			source_id, source_number, total_debit, total_credit, created_by)
+		VALUES ('JE' || to_char($2::date, 'YYYYMM') || '-' || LPAD(nextval('gl_journal_entry_number_seq')::text, 6, '0'),
+			$1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9)
+		ON CONFLICT (source_type, source_id) WHERE source_type <> 'manual' DO NOTHING
+		RETURNING entry_id
+	`, entry.OutletID, entry.Date.Format("2006-01-02"), entry.Description, entry.SourceType,
+		entry.SourceID, entry.SourceNumber, debit, credit, entry.CreatedBy).Scan(&entryID)
+	if err == sql.ErrNoRows {
+		// The document was posted by an earlier delivery
+		existing, err := r.GetEntryBySource(entry.SourceType, *entry.SourceID)
+		if err != nil {
+			return 0, false, err
+		}
+		return existing.EntryID, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	for i, line := range entry.Lines {
+		_, err = tx.Exec(`
+			INSERT INTO gl_journal_lines (entry_id, account_id, description, debit, credit)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+		`, entryID, accountIDs[i], line.Description, line.Debit, line.Credit)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to create journal line: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to commit journal entry: %w", err)
+	}
+
+	return entryID, true, nil
+}
+
+// resolveAccounts maps each line to an active account ID, looking up lines
+// that name their account by code
+func resolveAccounts(tx *sqlx.Tx, lines []ledger.Line) ([]int64, error) {
+	var codes []string
+	var ids []int64
+	for _, line := range lines {
+		if line.AccountID != 0 {
+			ids = append(ids, line.AccountID)
+		} else {
+			codes = append(codes, line.AccountCode)
+		}
+	}
+
+	var accounts []struct {
+		AccountID int64  `db:"account_id"`
+		Code      string `db:"code"`
+	}
+	err := tx.Select(&accounts, `
+		SELECT account_id, code FROM gl_accounts
+		WHERE (code = ANY($1) OR account_id = ANY($2)) AND is_active = TRUE AND deleted_at IS NULL
+	`, pq.StringArray(codes), pq.Int64Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve accounts: %w", err)
+	}
+
+	byCode := make(map[string]int64, len(accounts))
+	byID := make(map[int64]bool, len(accounts))
+	for _, account := range accounts {
+		byCode[account.Code] = account.AccountID
+		byID[account.AccountID] = true
+	}
+
+	resolved := make([]int64, len(lines))
+	for i, line := range lines {
+		if line.AccountID != 0 {
+			if !byID[line.AccountID] {
+				return nil, fmt.Errorf("line %d: account %d not found or inactive", i+1, line.AccountID)
+			}
+			resolved[i] = line.AccountID
+			continue
+		}
+
+		id, ok := byCode[line.AccountCode]
+		if !ok {
+			return nil, fmt.Errorf("line %d: account %s not found or inactive", i+1, line.AccountCode)
+		}
+		resolved[i] = id
+	}
+
+	return resolved, nil
+}
+
+func (r *ledgerRepository) GetEntryByID(id int64) (*models.JournalEntry, error) {
+	return r.getEntry(`e.entry_id = $1`, id)
+}
+
+func (r *ledgerRepository) GetEntryBySource(sourceType string, sourceID int64) (*models.JournalEntry, error) {
+	return r.getEntry(`e.source_type = $1 AND e.source_id = $2`, sourceType, sourceID)
+}
+
+func (r *ledgerRepository) getEntry(condition string, args ...interface{}) (*models.JournalEntry, error) {
+	query := fmt.Sprintf(`SELECT %s FROM gl_journal_entries e WHERE %s`, journalEntryColumns, condition)
+
+	var entry models.JournalEntry
+	if err := r.db.Get(&entry, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get journal entry: %w", err)
+	}
+
+	err := r.db.Select(&entry.Lines, `
+		SELECT l.line_id, l.entry_id, l.account_id, a.code AS account_code, a.name AS account_name,
+			l.description, l.debit, l.credit
+		FROM gl_journal_lines l
+		JOIN gl_accounts a ON a.account_id = l.account_id
+		WHERE l.entry_id = $1
+		ORDER BY l.line_id
+	`, entry.EntryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal lines: %w", err)
+	}
+
+	return &entry, nil
+}
+
+func (r *ledgerRepository) ListEntries(filter *models.JournalEntryFilter, offset, limit int) ([]models.JournalEntry, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("e.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.SourceType != "" {
+		conditions = append(conditions, fmt.Sprintf("e.source_type = $%d", argIndex))
+		args = append(args, filter.SourceType)
+		argIndex++
+	}
+
+	if filter.AccountID != nil {
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM gl_journal_lines l WHERE l.entry_id = e.entry_id AND l.account_id = $%d)", argIndex))
+		args = append(args, *filter.AccountID)
+		argIndex++
+	}
+
+	if filter.DateFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("e.entry_date >= $%d", argIndex))
+		args = append(args, filter.DateFrom.Format("2006-01-02"))
+		argIndex++
+	}
+
+	if filter.DateTo != nil {
+		conditions = append(conditions, fmt.Sprintf("e.entry_date <= $%d", argIndex))
+		args = append(args, filter.DateTo.Format("2006-01-02"))
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM gl_journal_entries e `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count journal entries: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM gl_journal_entries e %s ORDER BY e.entry_date DESC, e.entry_id DESC LIMIT $%d OFFSET $%d`,
+		journalEntryColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var entries []models.JournalEntry
+	err = r.db.Select(&entries, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list journal entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// GetSalesDocument loads a transaction with its line totals grouped by
+// revenue kind and the cost of the products on it. Lines with neither a
+// product nor a service count towards the transaction type's own revenue.
+func (r *ledgerRepository) GetSalesDocument(transactionID int64) (*ledger.SalesDocument, error) {
+	var row struct {
+		TransactionID     int64           `db:"transaction_id"`
+		TransactionNumber string          `db:"transaction_number"`
+		TransactionType   string          `db:"transaction_type"`
+		OutletID          int64           `db:"outlet_id"`
+		TransactionDate   time.Time       `db:"transaction_date"`
+		DiscountAmount    decimal.Decimal `db:"discount_amount"`
+		TaxAmount         decimal.Decimal `db:"tax_amount"`
+		TotalAmount       decimal.Decimal `db:"total_amount"`
+		ServiceAmount     decimal.Decimal `db:"service_amount"`
+		PartsAmount       decimal.Decimal `db:"parts_amount"`
+		OtherAmount       decimal.Decimal `db:"other_amount"`
+		PartsCost         decimal.Decimal `db:"parts_cost"`
+	}
+	err := r.db.Get(&row, `
+		SELECT t.transaction_id, t.transaction_number, t.transaction_type, t.outlet_id, t.transaction_date,
+			t.discount_amount, t.tax_amount, t.total_amount,
+			COALESCE(SUM(d.net_amount) FILTER (WHERE d.service_id IS NOT NULL), 0) AS service_amount,
+			COALESCE(SUM(d.net_amount) FILTER (WHERE d.service_id IS NULL AND d.product_id IS NOT NULL), 0) AS parts_amount,
+			COALESCE(SUM(d.net_amount) FILTER (WHERE d.service_id IS NULL AND d.product_id IS NULL), 0) AS other_amount,
+			COALESCE(SUM(ROUND(d.quantity * COALESCE(d.unit_cost, 0), 2)) FILTER (WHERE d.product_id IS NOT NULL), 0) AS parts_cost
+		FROM transactions t
+		LEFT JOIN transaction_details d ON d.transaction_id = t.transaction_id AND d.deleted_at IS NULL
+		WHERE t.transaction_id = $1 AND t.deleted_at IS NULL
+		GROUP BY t.transaction_id
+	`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction for posting: %w", err)
+	}
+
+	doc := &ledger.SalesDocument{
+		TransactionID:     row.TransactionID,
+		TransactionNumber: row.TransactionNumber,
+		TransactionType:   row.TransactionType,
+		OutletID:          row.OutletID,
+		Date:              row.TransactionDate,
+		ServiceAmount:     row.ServiceAmount,
+		PartsAmount:       row.PartsAmount,
+		DiscountAmount:    row.DiscountAmount,
+		TaxAmount:         row.TaxAmount,
+		TotalAmount:       row.TotalAmount,
+		PartsCost:         row.PartsCost,
+	}
+
+	switch row.TransactionType {
+	case "sparepart_sale":
+		doc.PartsAmount = doc.PartsAmount.Add(row.OtherAmount)
+	case "vehicle_sale":
+		doc.VehicleAmount = row.OtherAmount
+	default:
+		doc.ServiceAmount = doc.ServiceAmount.Add(row.OtherAmount)
+	}
+
+	return doc, nil
+}
+
+// GetPaymentDocument loads a payment, including one deleted since, so a
+// payment reversed before it was posted still posts alongside its reversal
+func (r *ledgerRepository) GetPaymentDocument(paymentID int64) (*ledger.PaymentDocument, error) {
+	var row struct {
+		PaymentID         int64           `db:"payment_id"`
+		PaymentNumber     string          `db:"payment_number"`
+		Amount            decimal.Decimal `db:"amount"`
+		PaymentDate       time.Time       `db:"payment_date"`
+		TransactionNumber string          `db:"transaction_number"`
+		TransactionType   string          `db:"transaction_type"`
+		OutletID          int64           `db:"outlet_id"`
+		MethodType        string          `db:"method_type"`
+	}
+	err := r.db.Get(&row, `
+		SELECT p.payment_id, p.payment_number, p.amount, p.payment_date,
+			t.transaction_number, t.transaction_type, t.outlet_id, pm.type AS method_type
+		FROM payments p
+		JOIN transactions t ON t.transaction_id = p.transaction_id
+		JOIN payment_methods pm ON pm.method_id = p.payment_method_id
+		WHERE p.payment_id = $1
+	`, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment for posting: %w", err)
+	}
+
+	return &ledger.PaymentDocument{
+		PaymentID:         row.PaymentID,
+		PaymentNumber:     row.PaymentNumber,
+		TransactionNumber: row.TransactionNumber,
+		TransactionType:   row.TransactionType,
+		OutletID:          row.OutletID,
+		Date:              row.PaymentDate,
+		Amount:            row.Amount,
+		MethodType:        row.MethodType,
+	}, nil
+}
+
+func (r *ledgerRepository) GetVehiclePurchaseDocument(purchaseID int64) (*ledger.VehiclePurchaseDocument, error) {
+	var row struct {
+		PurchaseID    int64           `db:"purchase_id"`
+		OutletID      int64           `db:"outlet_id"`
+		PurchaseDate  time.Time       `db:"purchase_date"`
+		PurchasePrice decimal.Decimal `db:"purchase_price"`
+		PaymentMethod string          `db:"payment_method"`
+		PlateNumber   string          `db:"plate_number"`
+	}
+	err := r.db.Get(&row, `
+		SELECT vp.purchase_id, vp.outlet_id, vp.purchase_date, vp.purchase_price,
+			COALESCE(vp.payment_method, 'cash') AS payment_method, COALESCE(vi.plate_number, '') AS plate_number
+		FROM vehicle_purchases vp
+		LEFT JOIN vehicle_inventory vi ON vi.vehicle_purchase_id = vp.purchase_id AND vi.deleted_at IS NULL
+		WHERE vp.purchase_id = $1 AND vp.deleted_at IS NULL AND vp.status = 'completed'
+		LIMIT 1
+	`, purchaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vehicle purchase for posting: %w", err)
+	}
+
+	return &ledger.VehiclePurchaseDocument{
+		PurchaseID:    row.PurchaseID,
+		OutletID:      row.OutletID,
+		Date:          row.PurchaseDate,
+		PlateNumber:   row.PlateNumber,
+		PurchasePrice: row.PurchasePrice,
+		PaymentMethod: row.PaymentMethod,
+	}, nil
+}
+
+func (r *ledgerRepository) GetVehicleSaleDocument(saleID int64) (*ledger.VehicleSaleDocument, error) {
+	var row struct {
+		SaleID           int64           `db:"sale_id"`
+		OutletID         int64           `db:"outlet_id"`
+		SaleDate         time.Time       `db:"sale_date"`
+		SellingPrice     decimal.Decimal `db:"selling_price"`
+		PaymentType      string          `db:"payment_type"`
+		DownPayment      decimal.Decimal `db:"down_payment"`
+		FinancingAmount  decimal.Decimal `db:"financing_amount"`
+		CommissionAmount decimal.Decimal `db:"commission_amount"`
+		PurchasePrice    decimal.Decimal `db:"purchase_price"`
+		PlateNumber      string          `db:"plate_number"`
+	}
+	err := r.db.Get(&row, `
+		SELECT vs.sale_id, vs.outlet_id, vs.sale_date, vs.selling_price, vs.payment_type,
+			COALESCE(vs.down_payment, 0) AS down_payment, COALESCE(vs.financing_amount, 0) AS financing_amount,
+			COALESCE(vs.commission_amount, 0) AS commission_amount, vi.purchase_price, vi.plate_number
+		FROM vehicle_sales vs
+		JOIN vehicle_inventory vi ON vi.inventory_id = vs.inventory_id
+		WHERE vs.sale_id = $1 AND vs.deleted_at IS NULL
+	`, saleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vehicle sale for posting: %w", err)
+	}
+
+	return &ledger.VehicleSaleDocument{
+		SaleID:           row.SaleID,
+		OutletID:         row.OutletID,
+		Date:             row.SaleDate,
+		PlateNumber:      row.PlateNumber,
+		SellingPrice:     row.SellingPrice,
+		PurchasePrice:    row.PurchasePrice,
+		PaymentType:      row.PaymentType,
+		DownPayment:      row.DownPayment,
+		FinancingAmount:  row.FinancingAmount,
+		CommissionAmount: row.CommissionAmount,
+	}, nil
+}
+
+// GetVoidDocument loads a voided transaction with what was still sold when
+// it was voided: its sales document less the returns made before, and the
+// parts cost of the products not returned
+func (r *ledgerRepository) GetVoidDocument(transactionID int64) (*ledger.VoidDocument, error) {
+	sale, err := r.GetSalesDocument(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var row struct {
+		VoidedAt            time.Time       `db:"voided_at"`
+		Subtotal            decimal.Decimal `db:"subtotal"`
+		Discount            decimal.Decimal `db:"discount"`
+		Tax                 decimal.Decimal `db:"tax"`
+		Total               decimal.Decimal `db:"total"`
+		PartsCost           decimal.Decimal `db:"parts_cost"`
+		CreditReversed      decimal.Decimal `db:"credit_reversed"`
+		PointsReversed      decimal.Decimal `db:"points_reversed"`
+		StoredValueReversed decimal.Decimal `db:"stored_value_reversed"`
+	}
+	err = r.db.Get(&row, `
+		SELECT t.voided_at,
+			COALESCE((SELECT SUM(subtotal_amount) FROM sales_returns WHERE transaction_id = t.transaction_id), 0) AS subtotal,
+			COALESCE((SELECT SUM(discount_amount) FROM sales_returns WHERE transaction_id = t.transaction_id), 0) AS discount,
+			COALESCE((SELECT SUM(tax_amount) FROM sales_returns WHERE transaction_id = t.transaction_id), 0) AS tax,
+			COALESCE((SELECT SUM(total_amount) FROM sales_returns WHERE transaction_id = t.transaction_id), 0) AS total,
+			COALESCE((
+				SELECT SUM(ROUND((d.quantity - COALESCE((SELECT SUM(ri.quantity) FROM sales_return_items ri
+					WHERE ri.transaction_detail_id = d.detail_id), 0)) * COALESCE(d.unit_cost, 0), 2))
+				FROM transaction_details d
+				WHERE d.transaction_id = t.transaction_id AND d.product_id IS NOT NULL AND d.deleted_at IS NULL
+			), 0) AS parts_cost,
+			COALESCE((SELECT SUM(amount) FROM credit_note_applications
+				WHERE transaction_id = t.transaction_id AND reversed_at IS NOT NULL), 0) AS credit_reversed,
+			COALESCE((SELECT SUM(amount) FROM loyalty_point_entries
+				WHERE transaction_id = t.transaction_id AND entry_type = 'redeem_reversal'
+					AND payment_id IS NULL AND refund_id IS NULL), 0) AS points_reversed,
+			COALESCE((SELECT SUM(amount) FROM stored_value_entries
+				WHERE transaction_id = t.transaction_id AND entry_type = 'redeem_reversal'
+					AND payment_id IS NULL AND refund_id IS NULL), 0) AS stored_value_reversed
+		FROM transactions t
+		WHERE t.transaction_id = $1 AND t.voided_at IS NOT NULL
+	`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get void for posting: %w", err)
+	}
+
+	sale.PartsAmount = sale.PartsAmount.Sub(row.Subtotal)
+	sale.DiscountAmount = sale.DiscountAmount.Sub(row.Discount)
+	sale.TaxAmount = sale.TaxAmount.Sub(row.Tax)
+	sale.TotalAmount = sale.TotalAmount.Sub(row.Total)
+	sale.PartsCost = row.PartsCost
+
+	return &ledger.VoidDocument{
+		Sale:                *sale,
+		Date:                row.VoidedAt,
+		CreditReversed:      row.CreditReversed,
+		PointsReversed:      row.PointsReversed,
+		StoredValueReversed: row.StoredValueReversed,
+	}, nil
+}
+
+// GetPaymentReversalDocument loads a deleted payment, dated when it was
+// deleted
+func (r *ledgerRepository) GetPaymentReversalDocument(paymentID int64) (*ledger.PaymentDocument, error) {
+	doc, err := r.GetPaymentDocument(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.Get(&doc.Date, `
+		SELECT deleted_at FROM payments WHERE payment_id = $1 AND deleted_at IS NOT NULL
+	`, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment reversal for posting: %w", err)
+	}
+
+	return doc, nil
+}
+
+func (r *ledgerRepository) GetSalesReturnDocument(returnID int64) (*ledger.SalesReturnDocument, error) {
+	var row struct {
+		ReturnID       int64           `db:"return_id"`
+		ReturnNumber   string          `db:"return_number"`
+		OutletID       int64           `db:"outlet_id"`
+		ReturnDate     time.Time       `db:"return_date"`
+		SubtotalAmount decimal.Decimal `db:"subtotal_amount"`
+		DiscountAmount decimal.Decimal `db:"discount_amount"`
+		TaxAmount      decimal.Decimal `db:"tax_amount"`
+		TotalAmount    decimal.Decimal `db:"total_amount"`
+		CostAmount     decimal.Decimal `db:"cost_amount"`
+		Restock        bool            `db:"restock"`
+	}
+	err := r.db.Get(&row, `
+		SELECT return_id, return_number, outlet_id, return_date, subtotal_amount, discount_amount,
+			tax_amount, total_amount, cost_amount, restock
+		FROM sales_returns
+		WHERE return_id = $1
+	`, returnID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sales return for posting: %w", err)
+	}
+
+	return &ledger.SalesReturnDocument{
+		ReturnID:       row.ReturnID,
+		ReturnNumber:   row.ReturnNumber,
+		OutletID:       row.OutletID,
+		Date:           row.ReturnDate,
+		SubtotalAmount: row.SubtotalAmount,
+		DiscountAmount: row.DiscountAmount,
+		TaxAmount:      row.TaxAmount,
+		TotalAmount:    row.TotalAmount,
+		CostAmount:     row.CostAmount,
+		Restock:        row.Restock,
+	}, nil
+}
+
+func (r *ledgerRepository) GetRefundDocument(refundID int64) (*ledger.RefundDocument, error) {
+	var row struct {
+		RefundID          int64           `db:"refund_id"`
+		RefundNumber      string          `db:"refund_number"`
+		TransactionNumber string          `db:"transaction_number"`
+		OutletID          int64           `db:"outlet_id"`
+		RefundDate        time.Time       `db:"refund_date"`
+		Amount            decimal.Decimal `db:"amount"`
+		MethodType        string          `db:"method_type"`
+	}
+	err := r.db.Get(&row, `
+		SELECT rf.refund_id, rf.refund_number, t.transaction_number, t.outlet_id, rf.refund_date, rf.amount,
+			pm.type AS method_type
+		FROM refunds rf
+		JOIN transactions t ON t.transaction_id = rf.transaction_id
+		JOIN payment_methods pm ON pm.method_id = rf.payment_method_id
+		WHERE rf.refund_id = $1
+	`, refundID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refund for posting: %w", err)
+	}
+
+	return &ledger.RefundDocument{
+		RefundID:          row.RefundID,
+		RefundNumber:      row.RefundNumber,
+		TransactionNumber: row.TransactionNumber,
+		OutletID:          row.OutletID,
+		Date:              row.RefundDate,
+		Amount:            row.Amount,
+		MethodType:        row.MethodType,
+	}, nil
+}
+
+// creditNoteRow is a credit note or one of its applications as posted
+type creditNoteRow struct {
+	ID                int64           `db:"id"`
+	CreditNoteNumber  string          `db:"credit_note_number"`
+	TransactionNumber string          `db:"transaction_number"`
+	OutletID          int64           `db:"outlet_id"`
+	Date              time.Time       `db:"date"`
+	Amount            decimal.Decimal `db:"amount"`
+}
+
+func (row creditNoteRow) document() *ledger.CreditNoteDocument {
+	return &ledger.CreditNoteDocument{
+		ID:                row.ID,
+		CreditNoteNumber:  row.CreditNoteNumber,
+		TransactionNumber: row.TransactionNumber,
+		OutletID:          row.OutletID,
+		Date:              row.Date,
+		Amount:            row.Amount,
+	}
+}
+
+func (r *ledgerRepository) GetCreditNoteDocument(creditNoteID int64) (*ledger.CreditNoteDocument, error) {
+	var row creditNoteRow
+	err := r.db.Get(&row, `
+		SELECT cn.credit_note_id AS id, cn.credit_note_number, t.transaction_number, cn.outlet_id,
+			cn.issue_date AS date, cn.amount
+		FROM credit_notes cn
+		JOIN transactions t ON t.transaction_id = cn.transaction_id
+		WHERE cn.credit_note_id = $1
+	`, creditNoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit note for posting: %w", err)
+	}
+
+	return row.document(), nil
+}
+
+// GetCreditNoteApplicationDocument loads an application of store credit,
+// posted at the outlet of the transaction it paid
+func (r *ledgerRepository) GetCreditNoteApplicationDocument(applicationID int64) (*ledger.CreditNoteDocument, error) {
+	var row creditNoteRow
+	err := r.db.Get(&row, `
+		SELECT a.application_id AS id, cn.credit_note_number, t.transaction_number, t.outlet_id,
+			a.applied_at AS date, a.amount
+		FROM credit_note_applications a
+		JOIN credit_notes cn ON cn.credit_note_id = a.credit_note_id
+		JOIN transactions t ON t.transaction_id = a.transaction_id
+		WHERE a.application_id = $1
+	`, applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit note application for posting: %w", err)
+	}
+
+	return row.document(), nil
+}
+
+// GetStoredValueDocument loads a posting to a deposit or voucher with the
+// method a top-up was paid with
+func (r *ledgerRepository) GetStoredValueDocument(entryID int64) (*ledger.StoredValueDocument, error) {
+	var row struct {
+		EntryID    int64           `db:"entry_id"`
+		EntryType  string          `db:"entry_type"`
+		AccountID  int64           `db:"account_id"`
+		Code       *string         `db:"code"`
+		OutletID   int64           `db:"outlet_id"`
+		CreatedAt  time.Time       `db:"created_at"`
+		Amount     decimal.Decimal `db:"amount"`
+		MethodType *string         `db:"method_type"`
+	}
+	err := r.db.Get(&row, `
+		SELECT e.entry_id, e.entry_type, a.account_id, a.code, e.outlet_id, e.created_at, e.amount,
+			pm.type AS method_type
+		FROM stored_value_entries e
+		JOIN stored_value_accounts a ON a.account_id = e.account_id
+		LEFT JOIN payment_methods pm ON pm.method_id = e.payment_method_id
+		WHERE e.entry_id = $1
+	`, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored value entry for posting: %w", err)
+	}
+
+	doc := &ledger.StoredValueDocument{
+		EntryID:   row.EntryID,
+		EntryType: row.EntryType,
+		Reference: fmt.Sprintf("deposit #%d", row.AccountID),
+		OutletID:  row.OutletID,
+		Date:      row.CreatedAt,
+		Amount:    row.Amount.Abs(),
+	}
+	if row.Code != nil {
+		doc.Reference = "voucher " + *row.Code
+	}
+	if row.MethodType != nil {
+		doc.MethodType = *row.MethodType
+	}
+
+	return doc, nil
+}
+
+// GetGoodsReceiptDocument loads a goods receipt for posting
+func (r *ledgerRepository) GetGoodsReceiptDocument(receiptID int64) (*ledger.GoodsReceiptDocument, error) {
+	var row struct {
+		ReceiptID     int64           `db:"receipt_id"`
+		ReceiptNumber string          `db:"receipt_number"`
+		OutletID      int64           `db:"outlet_id"`
+		ReceivedAt    time.Time       `db:"received_at"`
+		TotalCost     decimal.Decimal `db:"total_cost"`
+	}
+	err := r.db.Get(&row, `
+		SELECT receipt_id, receipt_number, outlet_id, received_at, total_cost
+		FROM goods_receipts
+		WHERE receipt_id = $1
+	`, receiptID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goods receipt for posting: %w", err)
+	}
+
+	return &ledger.GoodsReceiptDocument{
+		ReceiptID:     row.ReceiptID,
+		ReceiptNumber: row.ReceiptNumber,
+		OutletID:      row.OutletID,
+		Date:          row.ReceivedAt,
+		TotalCost:     row.TotalCost,
+	}, nil
+}
+
+// ListUnpostedSources returns documents without a journal entry, with
+// transactions before the payments, returns and refunds made against them,
+// then voids, the top-ups and lapses of deposits and vouchers, and goods
+// receipts
+func (r *ledgerRepository) ListUnpostedSources() ([]LedgerSource, error) {
+	query := `
+		SELECT source_type, source_id FROM (
+			SELECT 1 AS sort_order, 'transaction' AS source_type, t.transaction_id AS source_id
+			FROM transactions t
+			WHERE t.deleted_at IS NULL AND t.total_amount > 0
+				AND NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'transaction' AND e.source_id = t.transaction_id)
+			UNION ALL
+			SELECT 2, 'payment', p.payment_id
+			FROM payments p
+			WHERE p.amount > 0
+				AND NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'payment' AND e.source_id = p.payment_id)
+			UNION ALL
+			SELECT 3, 'vehicle_purchase', vp.purchase_id
+			FROM vehicle_purchases vp
+			WHERE vp.deleted_at IS NULL AND vp.status = 'completed' AND vp.purchase_price > 0
+				AND NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'vehicle_purchase' AND e.source_id = vp.purchase_id)
+			UNION ALL
+			SELECT 4, 'vehicle_sale', vs.sale_id
+			FROM vehicle_sales vs
+			WHERE vs.deleted_at IS NULL AND vs.selling_price > 0
+				AND NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'vehicle_sale' AND e.source_id = vs.sale_id)
+			UNION ALL
+			SELECT 5, 'payment_reversal', p.payment_id
+			FROM payments p
+			WHERE p.deleted_at IS NOT NULL AND p.amount > 0
+				AND NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'payment_reversal' AND e.source_id = p.payment_id)
+			UNION ALL
+			SELECT 6, 'sales_return', sr.return_id
+			FROM sales_returns sr
+			WHERE sr.total_amount > 0
+				AND NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'sales_return' AND e.source_id = sr.return_id)
+			UNION ALL
+			SELECT 7, 'refund', rf.refund_id
+			FROM refunds rf
+			WHERE NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'refund' AND e.source_id = rf.refund_id)
+			UNION ALL
+			SELECT 8, 'credit_note', cn.credit_note_id
+			FROM credit_notes cn
+			WHERE NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'credit_note' AND e.source_id = cn.credit_note_id)
+			UNION ALL
+			SELECT 9, 'credit_note_application', a.application_id
+			FROM credit_note_applications a
+			WHERE NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'credit_note_application' AND e.source_id = a.application_id)
+			UNION ALL
+			SELECT 10, 'transaction_void', t.transaction_id
+			FROM transactions t
+			WHERE t.voided_at IS NOT NULL AND t.deleted_at IS NULL
+				AND NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'transaction_void' AND e.source_id = t.transaction_id)
+			UNION ALL
+			SELECT 11, 'stored_value_entry', sv.entry_id
+			FROM stored_value_entries sv
+			WHERE sv.entry_type IN ('top_up', 'expire', 'expire_reversal')
+				AND NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'stored_value_entry' AND e.source_id = sv.entry_id)
+			UNION ALL
+			SELECT 12, 'goods_receipt', gr.receipt_id
+			FROM goods_receipts gr
+			WHERE gr.total_cost > 0
+				AND NOT EXISTS (SELECT 1 FROM gl_journal_entries e WHERE e.source_type = 'goods_receipt' AND e.source_id = gr.receipt_id)
+		) unposted
+		ORDER BY sort_order, source_id
+	`
+
+	var sources []LedgerSource
+	if err := r.db.Select(&sources, query); err != nil {
+		return nil, fmt.Errorf("failed to list unposted documents: %w", err)
+	}
+
+	return sources, nil
+}
+
+// GetTrialBalance returns every account posted to up to the end date with
+// its balance before the period, its period movements and its closing
+// balance, both balances signed in the account's normal direction
+func (r *ledgerRepository) GetTrialBalance(outletID *int64, from, to time.Time) ([]models.TrialBalanceRow, error) {
+	args := []interface{}{from.Format("2006-01-02"), to.Format("2006-01-02")}
+	outletCondition := ""
+	if outletID != nil {
+		outletCondition = "AND e.outlet_id = $3"
+		args = append(args, *outletID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT account_id, code, name, account_type, normal_balance,
+			CASE WHEN normal_balance = 'debit' THEN opening_net ELSE -opening_net END AS opening_balance,
+			period_debit, period_credit,
+			CASE WHEN normal_balance = 'debit' THEN opening_net + period_debit - period_credit
+				ELSE -opening_net - period_debit + period_credit END AS closing_balance
+		FROM (
+			SELECT a.account_id, a.code, a.name, a.account_type, a.normal_balance,
+				COALESCE(SUM(l.debit - l.credit) FILTER (WHERE e.entry_date < $1::date), 0) AS opening_net,
+				COALESCE(SUM(l.debit) FILTER (WHERE e.entry_date >= $1::date), 0) AS period_debit,
+				COALESCE(SUM(l.credit) FILTER (WHERE e.entry_date >= $1::date), 0) AS period_credit
+			FROM gl_accounts a
+			JOIN gl_journal_lines l ON l.account_id = a.account_id
+			JOIN gl_journal_entries e ON e.entry_id = l.entry_id
+			WHERE e.entry_date <= $2::date %s
+			GROUP BY a.account_id, a.code, a.name, a.account_type, a.normal_balance
+		) balances
+		ORDER BY code
+	`, outletCondition)
+
+	var rows []models.TrialBalanceRow
+	if err := r.db.Select(&rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get trial balance: %w", err)
+	}
+
+	return rows, nil
+}
+
+// GetAccountOpeningBalance returns an account's net debit balance before a date
+func (r *ledgerRepository) GetAccountOpeningBalance(accountID int64, outletID *int64, before time.Time) (decimal.Decimal, error) {
+	query := `
+		SELECT COALESCE(SUM(l.debit - l.credit), 0)
+		FROM gl_journal_lines l
+		JOIN gl_journal_entries e ON e.entry_id = l.entry_id
+		WHERE l.account_id = $1 AND e.entry_date < $2::date
+	`
+	args := []interface{}{accountID, before.Format("2006-01-02")}
+	if outletID != nil {
+		query += ` AND e.outlet_id = $3`
+		args = append(args, *outletID)
+	}
+
+	var balance decimal.Decimal
+	if err := r.db.Get(&balance, query, args...); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+
+	return balance, nil
+}
+
+// GetAccountPostings returns the lines posted to an account within a period
+// in posting order
+func (r *ledgerRepository) GetAccountPostings(accountID int64, outletID *int64, from, to time.Time) ([]models.GeneralLedgerLine, error) {
+	query := `
+		SELECT e.entry_id, e.entry_number, e.entry_date, e.outlet_id,
+			COALESCE(l.description, e.description) AS description, e.source_type, e.source_number,
+			l.debit, l.credit
+		FROM gl_journal_lines l
+		JOIN gl_journal_entries e ON e.entry_id = l.entry_id
+		WHERE l.account_id = $1 AND e.entry_date BETWEEN $2::date AND $3::date
+	`
+	args := []interface{}{accountID, from.Format("2006-01-02"), to.Format("2006-01-02")}
+	if outletID != nil {
+		query += ` AND e.outlet_id = $4`
+		args = append(args, *outletID)
+	}
+	query += ` ORDER BY e.entry_date, e.entry_id, l.line_id`
+
+	var lines []models.GeneralLedgerLine
+	if err := r.db.Select(&lines, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get account postings: %w", err)
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,795 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// loyaltyLot selects the entries that add points which can expire. Every
+// other entry uses up points, oldest lots first.
+const loyaltyLot = `(e.entry_type IN ('earn', 'earn_reversal') OR (e.entry_type = 'adjust' AND e.points > 0))`
+
+// LoyaltyRepository defines data access for the loyalty program and the
+// customer points ledger
+type LoyaltyRepository interface {
+	GetSettings() (*models.LoyaltySettings, error)
+	UpdateSettings(settings *models.LoyaltySettings) error
+	ListRules() ([]models.LoyaltyEarnRule, error)
+	GetRule(id int64) (*models.LoyaltyEarnRule, error)
+	GetRuleByType(transactionType string) (*models.LoyaltyEarnRule, error)
+	CreateRule(rule *models.LoyaltyEarnRule) error
+	UpdateRule(rule *models.LoyaltyEarnRule) error
+	DeleteRule(id int64) error
+	GetAccount(customerID int64, at time.Time) (*models.LoyaltyAccount, error)
+	ListEntries(customerID int64, offset, limit int) ([]models.LoyaltyPointEntry, int64, error)
+	Adjust(entry *models.LoyaltyPointEntry) error
+	SyncTransaction(transactionID int64) (int, error)
+	ExpirePoints(at time.Time) (*models.LoyaltyExpiryResult, error)
+}
+
+type loyaltyRepository struct {
+	db *sqlx.DB
+}
+
+// NewLoyaltyRepository creates a new loyalty repository
+func NewLoyaltyRepository(db *sqlx.DB) LoyaltyRepository {
+	return &loyaltyRepository{db: db}
+}
+
+func (r *loyaltyRepository) GetSettings() (*models.LoyaltySettings, error) {
+	return getLoyaltySettings(r.db)
+}
+
+func getLoyaltySettings(q sqlx.Queryer) (*models.LoyaltySettings, error) {
+	var settings models.LoyaltySettings
+	err := sqlx.Get(q, &settings, `
+		SELECT is_enabled, point_value, min_redeem_points, expiry_months, updated_at, updated_by
+		FROM loyalty_settings
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+func (r *loyaltyRepository) UpdateSettings(settings *models.LoyaltySettings) error {
+	err := r.db.QueryRow(`
+		UPDATE loyalty_settings
+		SET is_enabled = $1, point_value = $2, min_redeem_points = $3, expiry_months = $4,
+			updated_at = CURRENT_TIMESTAMP, updated_by = $5
+		RETURNING updated_at
+	`, settings.IsEnabled, settings.PointValue, settings.MinRedeemPoints, settings.ExpiryMonths, settings.UpdatedBy).
+		Scan(&settings.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update loyalty settings: %w", err)
+	}
+
+	return nil
+}
+
+const loyaltyRuleColumns = `
+	rule_id, transaction_type, spend_amount, points, min_amount, is_active, created_at, updated_at, created_by
+`
+
+func (r *loyaltyRepository) ListRules() ([]models.LoyaltyEarnRule, error) {
+	rules := []models.LoyaltyEarnRule{}
+	err := r.db.Select(&rules, `SELECT `+loyaltyRuleColumns+` FROM loyalty_earn_rules ORDER BY transaction_type`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list loyalty earn rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+func (r *loyaltyRepository) GetRule(id int64) (*models.LoyaltyEarnRule, error) {
+	var rule models.LoyaltyEarnRule
+	err := r.db.Get(&rule, `SELECT `+loyaltyRuleColumns+` FROM loyalty_earn_rules WHERE rule_id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty earn rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// GetRuleByType returns the rule of a transaction type, or nil when it has
+// none
+func (r *loyaltyRepository) GetRuleByType(transactionType string) (*models.LoyaltyEarnRule, error) {
+	return getLoyaltyRule(r.db, transactionType)
+}
+
+func getLoyaltyRule(q sqlx.Queryer, transactionType string) (*models.LoyaltyEarnRule, error) {
+	var rule models.LoyaltyEarnRule
+	err := sqlx.Get(q, &rule, `SELECT `+loyaltyRuleColumns+` FROM loyalty_earn_rules WHERE transaction_type = $1`, transactionType)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty earn rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+func (r *loyaltyRepository) CreateRule(rule *models.LoyaltyEarnRule) error {
+	err := r.db.QueryRow(`
+		INSERT INTO loyalty_earn_rules (transaction_type, spend_amount, points, min_amount, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING rule_id, created_at, updated_at
+	`, rule.TransactionType, rule.SpendAmount, rule.Points, rule.MinAmount, rule.IsActive, rule.CreatedBy).
+		Scan(&rule.RuleID, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create loyalty earn rule: %w", err)
+	}
+
+	return nil
+}
+
+func (r *loyaltyRepository) UpdateRule(rule *models.LoyaltyEarnRule) error {
+	err := r.db.QueryRow(`
+		UPDATE loyalty_earn_rules
+		SET transaction_type = $2, spend_amount = $3, points = $4, min_amount = $5, is_active = $6,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE rule_id = $1
+		RETURNING updated_at
+	`, rule.RuleID, rule.TransactionType, rule.SpendAmount, rule.Points, rule.MinAmount, rule.IsActive).
+		Scan(&rule.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("loyalty earn rule not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update loyalty earn rule: %w", err)
+	}
+
+	return nil
+}
+
+func (r *loyaltyRepository) DeleteRule(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM loyalty_earn_rules WHERE rule_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete loyalty earn rule: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("loyalty earn rule not found")
+	}
+
+	return nil
+}
+
+// GetAccount returns a customer's balance with the points that lapse next.
+// Points used up are taken from the oldest lots, so the next lot to expire
+// is the oldest one not used up yet.
+func (r *loyaltyRepository) GetAccount(customerID int64, at time.Time) (*models.LoyaltyAccount, error) {
+	var account models.LoyaltyAccount
+	err := r.db.Get(&account, `
+		SELECT c.customer_id, c.name AS customer_name, c.loyalty_points AS balance,
+			COALESCE(SUM(e.points) FILTER (WHERE e.entry_type IN ('earn', 'earn_reversal')), 0) AS earned,
+			-COALESCE(SUM(e.points) FILTER (WHERE e.entry_type IN ('redeem', 'redeem_reversal')), 0) AS redeemed,
+			-COALESCE(SUM(e.points) FILTER (WHERE e.entry_type = 'expire'), 0) AS expired
+		FROM customers c
+		LEFT JOIN loyalty_point_entries e ON e.customer_id = c.customer_id
+		WHERE c.customer_id = $1 AND c.deleted_at IS NULL
+		GROUP BY c.customer_id, c.name, c.loyalty_points
+	`, customerID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("customer not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty account: %w", err)
+	}
+
+	var used int
+	err = r.db.Get(&used, `
+		SELECT -COALESCE(SUM(e.points), 0) FROM loyalty_point_entries e
+		WHERE e.customer_id = $1 AND NOT `+loyaltyLot, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get used loyalty points: %w", err)
+	}
+
+	var lots []struct {
+		ExpiresAt time.Time `db:"expires_at"`
+		Points    int       `db:"points"`
+	}
+	err = r.db.Select(&lots, `
+		SELECT e.expires_at, SUM(e.points) AS points FROM loyalty_point_entries e
+		WHERE e.customer_id = $1 AND e.expires_at IS NOT NULL AND `+loyaltyLot+`
+		GROUP BY e.expires_at
+		ORDER BY e.expires_at
+	`, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty point lots: %w", err)
+	}
+
+	for _, lot := range lots {
+		left := lot.Points - used
+		used -= lot.Points
+		if used < 0 {
+			used = 0
+		}
+		if left <= 0 || !lot.ExpiresAt.After(at) {
+			continue
+		}
+		if left > account.Balance {
+			left = account.Balance
+		}
+		if left > 0 {
+			expiresAt := lot.ExpiresAt
+			account.NextExpiry = &expiresAt
+			account.ExpiringPoints = left
+		}
+		break
+	}
+
+	return &account, nil
+}
+
+// ListEntries returns a customer's points statement, newest first, with the
+// balance after each entry
+func (r *loyaltyRepository) ListEntries(customerID int64, offset, limit int) ([]models.LoyaltyPointEntry, int64, error) {
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM loyalty_point_entries WHERE customer_id = $1`, customerID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count loyalty point entries: %w", err)
+	}
+
+	entries := []models.LoyaltyPointEntry{}
+	err = r.db.Select(&entries, `
+		SELECT e.entry_id, e.customer_id, e.entry_type, e.points, e.amount, e.transaction_id,
+			t.transaction_number, e.payment_id, e.refund_id, e.expires_at, e.description,
+			SUM(e.points) OVER (ORDER BY e.created_at, e.entry_id) AS balance,
+			e.created_at, e.created_by
+		FROM loyalty_point_entries e
+		LEFT JOIN transactions t ON t.transaction_id = e.transaction_id
+		WHERE e.customer_id = $1
+		ORDER BY e.created_at DESC, e.entry_id DESC
+		LIMIT $2 OFFSET $3
+	`, customerID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list loyalty point entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// Adjust adds or takes off points by hand. Added points expire like earned
+// ones; taking off more than the balance is refused.
+func (r *loyaltyRepository) Adjust(entry *models.LoyaltyPointEntry) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	balance, err := lockLoyaltyCustomer(tx, entry.CustomerID)
+	if err != nil {
+		return err
+	}
+	if balance+entry.Points < 0 {
+		return fmt.Errorf("customer has %d points; %d cannot be taken off", balance, -entry.Points)
+	}
+
+	entry.EntryType = "adjust"
+	if entry.Points > 0 {
+		settings, err := getLoyaltySettings(tx)
+		if err != nil {
+			return err
+		}
+		entry.ExpiresAt = loyaltyExpiry(settings, time.Now())
+	}
+	if err = insertLoyaltyEntry(tx, entry, nil); err != nil {
+		return err
+	}
+	if _, err = refreshLoyaltyBalance(tx, entry.CustomerID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// loyaltyRate is the earn rule and tier multiplier a transaction earned its
+// points at. Later changes to a transaction are settled at the same rate.
+type loyaltyRate struct {
+	SpendAmount decimal.Decimal `db:"rate_amount"`
+	Points      int             `db:"rate_points"`
+	Multiplier  decimal.Decimal `db:"rate_multiplier"`
+	ExpiresAt   *time.Time      `db:"expires_at"`
+}
+
+// SyncTransaction brings the points a transaction earned in line with what
+// it earns now and returns the points posted. A fully paid transaction earns
+// on what was paid other than with points; anything else earns nothing, so
+// payment reversals, refunds, returns and voids take earned points back.
+// Running it again posts nothing.
+func (r *loyaltyRepository) SyncTransaction(transactionID int64) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var transaction struct {
+		TransactionNumber string `db:"transaction_number"`
+		TransactionType   string `db:"transaction_type"`
+		CustomerID        *int64 `db:"customer_id"`
+		PaymentStatus     string `db:"payment_status"`
+	}
+	err = tx.Get(&transaction, `
+		SELECT transaction_number, transaction_type, customer_id, payment_status
+		FROM transactions WHERE transaction_id = $1 FOR UPDATE
+	`, transactionID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("transaction not found")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if transaction.CustomerID == nil {
+		return 0, nil
+	}
+	customerID := *transaction.CustomerID
+
+	if _, err = lockLoyaltyCustomer(tx, customerID); err != nil {
+		return 0, err
+	}
+
+	var earned int
+	err = tx.Get(&earned, `
+		SELECT COALESCE(SUM(points), 0) FROM loyalty_point_entries
+		WHERE transaction_id = $1 AND entry_type IN ('earn', 'earn_reversal')
+	`, transactionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get earned loyalty points: %w", err)
+	}
+
+	var rate *loyaltyRate
+	var last loyaltyRate
+	err = tx.Get(&last, `
+		SELECT rate_amount, rate_points, COALESCE(rate_multiplier, 1) AS rate_multiplier, expires_at
+		FROM loyalty_point_entries
+		WHERE transaction_id = $1 AND entry_type = 'earn'
+		ORDER BY entry_id DESC LIMIT 1
+	`, transactionID)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get loyalty earn rate: %w", err)
+	}
+	if err == nil {
+		rate = &last
+	}
+
+	settings, err := getLoyaltySettings(tx)
+	if err != nil {
+		return 0, err
+	}
+	rule, err := getLoyaltyRule(tx, transaction.TransactionType)
+	if err != nil {
+		return 0, err
+	}
+	if rate == nil && settings.IsEnabled && rule != nil && rule.IsActive {
+		multiplier, err := membershipMultiplier(tx, customerID)
+		if err != nil {
+			return 0, err
+		}
+		rate = &loyaltyRate{SpendAmount: rule.SpendAmount, Points: rule.Points, Multiplier: multiplier}
+	}
+
+	// What was paid for the transaction other than with points
+	eligible := decimal.Zero
+	if transaction.PaymentStatus == "paid" && rate != nil {
+		balance, err := transactionBalance(tx, transactionID)
+		if err != nil {
+			return 0, err
+		}
+		_, pointsValue, err := redeemedOnTransaction(tx, transactionID)
+		if err != nil {
+			return 0, err
+		}
+		eligible = decimal.Min(balance.NetAmount, balance.NetPaid).Sub(pointsValue)
+		if rule != nil && eligible.LessThan(rule.MinAmount) {
+			eligible = decimal.Zero
+		}
+	}
+
+	target := 0
+	if eligible.IsPositive() {
+		points := eligible.Div(rate.SpendAmount).Floor().Mul(decimal.NewFromInt(int64(rate.Points)))
+		target = int(points.Mul(rate.Multiplier).Floor().IntPart())
+	}
+
+	diff := target - earned
+	if diff == 0 {
+		return 0, nil
+	}
+
+	entry := &models.LoyaltyPointEntry{
+		CustomerID:    customerID,
+		Points:        diff,
+		TransactionID: &transactionID,
+	}
+	var description string
+	if diff > 0 {
+		entry.EntryType = "earn"
+		entry.Amount = eligible
+		entry.ExpiresAt = loyaltyExpiry(settings, time.Now())
+		description = fmt.Sprintf("Earned on %s", transaction.TransactionNumber)
+	} else {
+		entry.EntryType = "earn_reversal"
+		entry.ExpiresAt = rate.ExpiresAt
+		description = fmt.Sprintf("Reversed on %s", transaction.TransactionNumber)
+	}
+	entry.Description = &description
+
+	if err = insertLoyaltyEntry(tx, entry, rate); err != nil {
+		return 0, err
+	}
+	if _, err = refreshLoyaltyBalance(tx, customerID); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return diff, nil
+}
+
+// ExpirePoints expires every lot that lapsed by at and has not been used up.
+// Each customer is expired under their own lock.
+func (r *loyaltyRepository) ExpirePoints(at time.Time) (*models.LoyaltyExpiryResult, error) {
+	var customerIDs []int64
+	err := r.db.Select(&customerIDs, `
+		SELECT e.customer_id FROM loyalty_point_entries e
+		GROUP BY e.customer_id
+		HAVING COALESCE(SUM(e.points) FILTER (WHERE `+loyaltyLot+` AND e.expires_at <= $1), 0)
+			+ COALESCE(SUM(e.points) FILTER (WHERE NOT `+loyaltyLot+`), 0) > 0
+			AND SUM(e.points) > 0
+	`, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expiring loyalty points: %w", err)
+	}
+
+	result := &models.LoyaltyExpiryResult{}
+	for _, customerID := range customerIDs {
+		points, err := r.expireCustomer(customerID, at)
+		if err != nil {
+			return result, err
+		}
+		if points > 0 {
+			result.Customers++
+			result.Points += int64(points)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *loyaltyRepository) expireCustomer(customerID int64, at time.Time) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	balance, err := lockLoyaltyCustomer(tx, customerID)
+	if err != nil {
+		return 0, err
+	}
+
+	var expiring int
+	err = tx.Get(&expiring, `
+		SELECT COALESCE(SUM(e.points) FILTER (WHERE `+loyaltyLot+` AND e.expires_at <= $2), 0)
+			+ COALESCE(SUM(e.points) FILTER (WHERE NOT `+loyaltyLot+`), 0)
+		FROM loyalty_point_entries e
+		WHERE e.customer_id = $1
+	`, customerID, at)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expiring loyalty points: %w", err)
+	}
+	if expiring > balance {
+		expiring = balance
+	}
+	if expiring <= 0 {
+		return 0, nil
+	}
+
+	description := fmt.Sprintf("Expired on %s", at.Format("2006-01-02"))
+	entry := &models.LoyaltyPointEntry{
+		CustomerID:  customerID,
+		EntryType:   "expire",
+		Points:      -expiring,
+		Description: &description,
+	}
+	if err = insertLoyaltyEntry(tx, entry, nil); err != nil {
+		return 0, err
+	}
+	if _, err = refreshLoyaltyBalance(tx, customerID); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return expiring, nil
+}
+
+// lockLoyaltyCustomer locks a customer's points for a posting and returns
+// the balance
+func lockLoyaltyCustomer(tx *sqlx.Tx, customerID int64) (int, error) {
+	var balance int
+	err := tx.Get(&balance, `
+		SELECT loyalty_points FROM customers WHERE customer_id = $1 FOR UPDATE
+	`, customerID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("customer not found")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get customer loyalty points: %w", err)
+	}
+
+	return balance, nil
+}
+
+// insertLoyaltyEntry posts an entry, with the earn rate for earned points
+func insertLoyaltyEntry(tx *sqlx.Tx, entry *models.LoyaltyPointEntry, rate *loyaltyRate) error {
+	var rateAmount, rateMultiplier *decimal.Decimal
+	var ratePoints *int
+	if rate != nil {
+		rateAmount = &rate.SpendAmount
+		ratePoints = &rate.Points
+		rateMultiplier = &rate.Multiplier
+	}
+
+	err := tx.QueryRow(`
+		INSERT INTO loyalty_point_entries (customer_id, entry_type, points, amount, transaction_id, payment_id,
+			refund_id, rate_amount, rate_points, rate_multiplier, expires_at, description, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING entry_id, created_at
+	`, entry.CustomerID, entry.EntryType, entry.Points, entry.Amount, entry.TransactionID, entry.PaymentID,
+		entry.RefundID, rateAmount, ratePoints, rateMultiplier, entry.ExpiresAt, entry.Description, entry.CreatedBy).
+		Scan(&entry.EntryID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to post loyalty points: %w", err)
+	}
+
+	return nil
+}
+
+// refreshLoyaltyBalance sets the customer's balance to the sum of their
+// ledger. Every posting goes through here.
+func refreshLoyaltyBalance(tx *sqlx.Tx, customerID int64) (int, error) {
+	var balance int
+	err := tx.QueryRow(`
+		UPDATE customers
+		SET loyalty_points = (SELECT COALESCE(SUM(points), 0) FROM loyalty_point_entries WHERE customer_id = $1),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE customer_id = $1
+		RETURNING loyalty_points
+	`, customerID).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update customer loyalty points: %w", err)
+	}
+
+	return balance, nil
+}
+
+// loyaltyExpiry returns when points posted at t expire, or nil when they
+// never do
+func loyaltyExpiry(settings *models.LoyaltySettings, t time.Time) *time.Time {
+	if settings.ExpiryMonths <= 0 {
+		return nil
+	}
+	expiresAt := t.AddDate(0, settings.ExpiryMonths, 0)
+	return &expiresAt
+}
+
+// redeemedOnTransaction returns the points still spent on a transaction and
+// the Rupiah they paid, net of points given back
+func redeemedOnTransaction(q sqlx.Queryer, transactionID int64) (int, decimal.Decimal, error) {
+	var redeemed struct {
+		Points int             `db:"points"`
+		Value  decimal.Decimal `db:"value"`
+	}
+	err := sqlx.Get(q, &redeemed, `
+		SELECT -COALESCE(SUM(points), 0) AS points,
+			COALESCE(SUM(CASE WHEN entry_type = 'redeem' THEN amount ELSE -amount END), 0) AS value
+		FROM loyalty_point_entries
+		WHERE transaction_id = $1 AND entry_type IN ('redeem', 'redeem_reversal')
+	`, transactionID)
+	if err != nil {
+		return 0, decimal.Zero, fmt.Errorf("failed to get redeemed loyalty points: %w", err)
+	}
+
+	return redeemed.Points, redeemed.Value, nil
+}
+
+// redeemPoints spends the customer's points on a payment taken with the
+// points method. The amount has to be a whole number of points.
+func redeemPoints(tx *sqlx.Tx, payment *models.Payment, transaction *paymentTransaction) error {
+	settings, err := getLoyaltySettings(tx)
+	if err != nil {
+		return err
+	}
+	if !settings.IsEnabled {
+		return fmt.Errorf("loyalty points cannot be redeemed while the program is disabled")
+	}
+	if transaction.CustomerID == nil {
+		return fmt.Errorf("points can only pay for a customer's transaction")
+	}
+
+	amount := decimal.NewFromFloat(payment.Amount).Round(2)
+	points := amount.Div(settings.PointValue)
+	if !points.Equal(points.Floor()) {
+		return fmt.Errorf("points pay in multiples of %s", settings.PointValue.StringFixed(2))
+	}
+	redeem := int(points.IntPart())
+	if redeem <= 0 {
+		return fmt.Errorf("points redeemed must be greater than zero")
+	}
+	if redeem < settings.MinRedeemPoints {
+		return fmt.Errorf("at least %d points have to be redeemed at once", settings.MinRedeemPoints)
+	}
+
+	balance, err := lockLoyaltyCustomer(tx, *transaction.CustomerID)
+	if err != nil {
+		return err
+	}
+	if redeem > balance {
+		return fmt.Errorf("customer has %d points; %d are needed", balance, redeem)
+	}
+
+	description := fmt.Sprintf("Redeemed on %s", transaction.TransactionNumber)
+	entry := &models.LoyaltyPointEntry{
+		CustomerID:    *transaction.CustomerID,
+		EntryType:     "redeem",
+		Points:        -redeem,
+		Amount:        amount,
+		TransactionID: &payment.TransactionID,
+		PaymentID:     &payment.ID,
+		Description:   &description,
+		CreatedBy:     payment.CreatedBy,
+	}
+	if err = insertLoyaltyEntry(tx, entry, nil); err != nil {
+		return err
+	}
+	_, err = refreshLoyaltyBalance(tx, *transaction.CustomerID)
+	return err
+}
+
+// restorePaymentPoints gives back the points a reversed payment spent,
+// less any already given back by refunds
+func restorePaymentPoints(tx *sqlx.Tx, paymentID, transactionID int64, transactionNumber string, userID int64) error {
+	var redeemed models.LoyaltyPointEntry
+	err := tx.Get(&redeemed, `
+		SELECT customer_id, points, amount FROM loyalty_point_entries
+		WHERE payment_id = $1 AND entry_type = 'redeem'
+	`, paymentID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get redeemed loyalty points: %w", err)
+	}
+
+	if _, err = lockLoyaltyCustomer(tx, redeemed.CustomerID); err != nil {
+		return err
+	}
+	spent, value, err := redeemedOnTransaction(tx, transactionID)
+	if err != nil {
+		return err
+	}
+
+	points := -redeemed.Points
+	amount := redeemed.Amount
+	if points > spent {
+		points, amount = spent, value
+	}
+	if points <= 0 {
+		return nil
+	}
+
+	description := fmt.Sprintf("Payment on %s reversed", transactionNumber)
+	entry := &models.LoyaltyPointEntry{
+		CustomerID:    redeemed.CustomerID,
+		EntryType:     "redeem_reversal",
+		Points:        points,
+		Amount:        amount,
+		TransactionID: &transactionID,
+		PaymentID:     &paymentID,
+		Description:   &description,
+		CreatedBy:     &userID,
+	}
+	if err = insertLoyaltyEntry(tx, entry, nil); err != nil {
+		return err
+	}
+	_, err = refreshLoyaltyBalance(tx, redeemed.CustomerID)
+	return err
+}
+
+// refundPoints gives back points for a refund to the points method, at the
+// rate they were redeemed on the transaction
+func refundPoints(tx *sqlx.Tx, refund *models.Refund, transaction *salesTransaction) error {
+	if transaction.CustomerID == nil {
+		return fmt.Errorf("points can only be refunded to a customer")
+	}
+	if _, err := lockLoyaltyCustomer(tx, *transaction.CustomerID); err != nil {
+		return err
+	}
+
+	spent, value, err := redeemedOnTransaction(tx, transaction.TransactionID)
+	if err != nil {
+		return err
+	}
+	if spent <= 0 || refund.Amount.GreaterThan(value) {
+		return fmt.Errorf("only %s of transaction %s was paid with points", value.StringFixed(2), transaction.TransactionNumber)
+	}
+
+	points := spent
+	if refund.Amount.LessThan(value) {
+		points = int(refund.Amount.Mul(decimal.NewFromInt(int64(spent))).Div(value).Round(0).IntPart())
+	}
+	if points <= 0 {
+		return fmt.Errorf("refund of %s is less than one point", refund.Amount.StringFixed(2))
+	}
+
+	description := fmt.Sprintf("Refund %s", refund.RefundNumber)
+	entry := &models.LoyaltyPointEntry{
+		CustomerID:    *transaction.CustomerID,
+		EntryType:     "redeem_reversal",
+		Points:        points,
+		Amount:        refund.Amount,
+		TransactionID: &transaction.TransactionID,
+		RefundID:      &refund.RefundID,
+		Description:   &description,
+		CreatedBy:     refund.CreatedBy,
+	}
+	if err = insertLoyaltyEntry(tx, entry, nil); err != nil {
+		return err
+	}
+	_, err = refreshLoyaltyBalance(tx, *transaction.CustomerID)
+	return err
+}
+
+// restoreTransactionPoints gives back every point still spent on a voided
+// transaction and returns the points and the Rupiah they had paid
+func restoreTransactionPoints(tx *sqlx.Tx, transaction *salesTransaction, userID int64) (int, decimal.Decimal, error) {
+	if transaction.CustomerID == nil {
+		return 0, decimal.Zero, nil
+	}
+	if _, err := lockLoyaltyCustomer(tx, *transaction.CustomerID); err != nil {
+		return 0, decimal.Zero, err
+	}
+
+	spent, value, err := redeemedOnTransaction(tx, transaction.TransactionID)
+	if err != nil || spent <= 0 {
+		return 0, decimal.Zero, err
+	}
+
+	description := fmt.Sprintf("Void of %s", transaction.TransactionNumber)
+	entry := &models.LoyaltyPointEntry{
+		CustomerID:    *transaction.CustomerID,
+		EntryType:     "redeem_reversal",
+		Points:        spent,
+		Amount:        value,
+		TransactionID: &transaction.TransactionID,
+		Description:   &description,
+		CreatedBy:     &userID,
+	}
+	if err = insertLoyaltyEntry(tx, entry, nil); err != nil {
+		return 0, decimal.Zero, err
+	}
+	if _, err = refreshLoyaltyBalance(tx, *transaction.CustomerID); err != nil {
+		return 0, decimal.Zero, err
+	}
+
+	return spent, value, nil
+}
@@ -0,0 +1,381 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// membershipSpend is what customer $1 spent on paid invoices since $2, less
+// returns. Voided, refunded and unpaid invoices count for nothing, and
+// vehicles bought from the customer are not spend.
+const membershipSpend = `
+	SELECT COALESCE(SUM(t.total_amount - COALESCE((
+		SELECT SUM(sr.total_amount) FROM sales_returns sr WHERE sr.transaction_id = t.transaction_id
+	), 0)), 0)
+	FROM transactions t
+	WHERE t.customer_id = $1 AND t.transaction_date >= $2 AND t.deleted_at IS NULL
+		AND t.payment_status = 'paid' AND t.transaction_type IN ('service', 'sparepart_sale', 'vehicle_sale')
+`
+
+// MembershipRepository defines data access for membership tiers and the tier
+// each customer is on
+type MembershipRepository interface {
+	ListTiers() ([]models.MembershipTier, error)
+	GetTier(id int64) (*models.MembershipTier, error)
+	GetTierByName(name string) (*models.MembershipTier, error)
+	CreateTier(tier *models.MembershipTier) error
+	UpdateTier(tier *models.MembershipTier) error
+	DeleteTier(id int64) error
+	GetCustomerTier(customerID int64) (*models.MembershipTier, error)
+	GetCustomerMembership(customerID int64, since time.Time) (*models.CustomerMembership, error)
+	ListHistory(customerID int64, offset, limit int) ([]models.CustomerTierChange, int64, error)
+	EvaluateCustomer(customerID int64, since time.Time, allowDowngrade bool) (*models.CustomerTierChange, error)
+	EvaluateAll(since time.Time) (*models.MembershipEvaluationResult, error)
+}
+
+type membershipRepository struct {
+	db *sqlx.DB
+}
+
+// NewMembershipRepository creates a new membership repository
+func NewMembershipRepository(db *sqlx.DB) MembershipRepository {
+	return &membershipRepository{db: db}
+}
+
+const membershipTierColumns = `
+	mt.tier_id, mt.name, mt.min_spend, mt.discount_percent, mt.point_multiplier, mt.priority_queue,
+	mt.is_active, mt.created_at, mt.updated_at, mt.created_by,
+	(SELECT COUNT(*) FROM customers c WHERE c.membership_tier_id = mt.tier_id AND c.deleted_at IS NULL) AS members
+`
+
+func (r *membershipRepository) ListTiers() ([]models.MembershipTier, error) {
+	tiers := []models.MembershipTier{}
+	err := r.db.Select(&tiers, `SELECT `+membershipTierColumns+` FROM membership_tiers mt ORDER BY mt.min_spend, mt.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list membership tiers: %w", err)
+	}
+
+	return tiers, nil
+}
+
+func (r *membershipRepository) GetTier(id int64) (*models.MembershipTier, error) {
+	var tier models.MembershipTier
+	err := r.db.Get(&tier, `SELECT `+membershipTierColumns+` FROM membership_tiers mt WHERE mt.tier_id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get membership tier: %w", err)
+	}
+
+	return &tier, nil
+}
+
+// GetTierByName returns the tier with a name, or nil when there is none
+func (r *membershipRepository) GetTierByName(name string) (*models.MembershipTier, error) {
+	var tier models.MembershipTier
+	err := r.db.Get(&tier, `SELECT `+membershipTierColumns+` FROM membership_tiers mt WHERE LOWER(mt.name) = LOWER($1)`, name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get membership tier: %w", err)
+	}
+
+	return &tier, nil
+}
+
+func (r *membershipRepository) CreateTier(tier *models.MembershipTier) error {
+	err := r.db.QueryRow(`
+		INSERT INTO membership_tiers (name, min_spend, discount_percent, point_multiplier, priority_queue, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING tier_id, created_at, updated_at
+	`, tier.Name, tier.MinSpend, tier.DiscountPercent, tier.PointMultiplier, tier.PriorityQueue, tier.IsActive, tier.CreatedBy).
+		Scan(&tier.TierID, &tier.CreatedAt, &tier.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create membership tier: %w", err)
+	}
+
+	return nil
+}
+
+func (r *membershipRepository) UpdateTier(tier *models.MembershipTier) error {
+	err := r.db.QueryRow(`
+		UPDATE membership_tiers
+		SET name = $2, min_spend = $3, discount_percent = $4, point_multiplier = $5, priority_queue = $6,
+			is_active = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE tier_id = $1
+		RETURNING updated_at
+	`, tier.TierID, tier.Name, tier.MinSpend, tier.DiscountPercent, tier.PointMultiplier, tier.PriorityQueue, tier.IsActive).
+		Scan(&tier.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("membership tier not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update membership tier: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTier removes a tier nobody was ever on. Tiers with members or
+// history are deactivated instead.
+func (r *membershipRepository) DeleteTier(id int64) error {
+	var used bool
+	err := r.db.Get(&used, `
+		SELECT EXISTS (SELECT 1 FROM customers WHERE membership_tier_id = $1)
+			OR EXISTS (SELECT 1 FROM customer_tier_history WHERE from_tier_id = $1 OR to_tier_id = $1)
+			OR EXISTS (SELECT 1 FROM transactions WHERE membership_tier_id = $1)
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to check membership tier use: %w", err)
+	}
+	if used {
+		return fmt.Errorf("membership tier has members or history; deactivate it instead")
+	}
+
+	result, err := r.db.Exec(`DELETE FROM membership_tiers WHERE tier_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete membership tier: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("membership tier not found")
+	}
+
+	return nil
+}
+
+// GetCustomerTier returns the tier whose benefits a customer gets, or nil
+// when they are on none or their tier was deactivated
+func (r *membershipRepository) GetCustomerTier(customerID int64) (*models.MembershipTier, error) {
+	return getCustomerTier(r.db, customerID)
+}
+
+func getCustomerTier(q sqlx.Queryer, customerID int64) (*models.MembershipTier, error) {
+	var tier models.MembershipTier
+	err := sqlx.Get(q, &tier, `
+		SELECT `+membershipTierColumns+` FROM membership_tiers mt
+		JOIN customers c ON c.membership_tier_id = mt.tier_id
+		WHERE c.customer_id = $1 AND mt.is_active
+	`, customerID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer membership tier: %w", err)
+	}
+
+	return &tier, nil
+}
+
+// GetCustomerMembership returns a customer's tier with their spend since
+// then, worked out now rather than at the last evaluation
+func (r *membershipRepository) GetCustomerMembership(customerID int64, since time.Time) (*models.CustomerMembership, error) {
+	var membership models.CustomerMembership
+	err := r.db.Get(&membership, `
+		SELECT c.customer_id, c.name AS customer_name, mt.tier_id, mt.name AS tier_name,
+			CASE WHEN mt.is_active THEN mt.discount_percent ELSE 0 END AS discount_percent,
+			CASE WHEN mt.is_active THEN mt.point_multiplier ELSE 1 END AS point_multiplier,
+			COALESCE(mt.is_active AND mt.priority_queue, FALSE) AS priority_queue,
+			c.membership_evaluated_at AS evaluated_at,
+			(`+membershipSpend+`) AS rolling_spend
+		FROM customers c
+		LEFT JOIN membership_tiers mt ON mt.tier_id = c.membership_tier_id
+		WHERE c.customer_id = $1 AND c.deleted_at IS NULL
+	`, customerID, since)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("customer not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer membership: %w", err)
+	}
+	return &membership, nil
+}
+
+// ListHistory returns a customer's tier changes, newest first
+func (r *membershipRepository) ListHistory(customerID int64, offset, limit int) ([]models.CustomerTierChange, int64, error) {
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM customer_tier_history WHERE customer_id = $1`, customerID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count customer tier history: %w", err)
+	}
+
+	history := []models.CustomerTierChange{}
+	err = r.db.Select(&history, `
+		SELECT h.history_id, h.customer_id, h.from_tier_id, ft.name AS from_tier_name,
+			h.to_tier_id, tt.name AS to_tier_name, h.change_type, h.rolling_spend, h.changed_at
+		FROM customer_tier_history h
+		LEFT JOIN membership_tiers ft ON ft.tier_id = h.from_tier_id
+		LEFT JOIN membership_tiers tt ON tt.tier_id = h.to_tier_id
+		WHERE h.customer_id = $1
+		ORDER BY h.changed_at DESC, h.history_id DESC
+		LIMIT $2 OFFSET $3
+	`, customerID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list customer tier history: %w", err)
+	}
+
+	return history, total, nil
+}
+
+// EvaluateCustomer moves a customer to the highest active tier their spend
+// since reaches and returns the change, or nil when the tier stays. Without
+// allowDowngrade a customer is only ever moved up; the spend is recorded
+// either way.
+func (r *membershipRepository) EvaluateCustomer(customerID int64, since time.Time, allowDowngrade bool) (*models.CustomerTierChange, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current struct {
+		TierID   *int64           `db:"membership_tier_id"`
+		MinSpend *decimal.Decimal `db:"min_spend"`
+		IsActive *bool            `db:"is_active"`
+	}
+	err = tx.Get(&current, `
+		SELECT c.membership_tier_id, mt.min_spend, mt.is_active
+		FROM customers c
+		LEFT JOIN membership_tiers mt ON mt.tier_id = c.membership_tier_id
+		WHERE c.customer_id = $1
+		FOR UPDATE OF c
+	`, customerID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("customer not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer membership: %w", err)
+	}
+
+	var spend decimal.Decimal
+	if err = tx.Get(&spend, membershipSpend, customerID, since); err != nil {
+		return nil, fmt.Errorf("failed to get customer spend: %w", err)
+	}
+
+	var qualified struct {
+		TierID   *int64           `db:"tier_id"`
+		MinSpend *decimal.Decimal `db:"min_spend"`
+	}
+	err = tx.Get(&qualified, `
+		SELECT tier_id, min_spend FROM membership_tiers
+		WHERE is_active AND min_spend <= $1
+		ORDER BY min_spend DESC LIMIT 1
+	`, spend)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get qualifying membership tier: %w", err)
+	}
+
+	// Tiers rank by the spend they need. A deactivated tier ranks below
+	// every active one, so its members move off it.
+	var change *models.CustomerTierChange
+	if !sameTier(current.TierID, qualified.TierID) {
+		changeType := "upgrade"
+		if current.TierID != nil && current.IsActive != nil && *current.IsActive &&
+			(qualified.TierID == nil || qualified.MinSpend.LessThan(*current.MinSpend)) {
+			changeType = "downgrade"
+		}
+		if changeType == "upgrade" || allowDowngrade {
+			change = &models.CustomerTierChange{
+				CustomerID:   customerID,
+				FromTierID:   current.TierID,
+				ToTierID:     qualified.TierID,
+				ChangeType:   changeType,
+				RollingSpend: spend,
+			}
+		}
+	}
+
+	tierID := current.TierID
+	if change != nil {
+		tierID = change.ToTierID
+	}
+	_, err = tx.Exec(`
+		UPDATE customers
+		SET membership_tier_id = $2, membership_spend = $3, membership_evaluated_at = CURRENT_TIMESTAMP
+		WHERE customer_id = $1
+	`, customerID, tierID, spend)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update customer membership: %w", err)
+	}
+
+	if change != nil {
+		err = tx.QueryRow(`
+			INSERT INTO customer_tier_history (customer_id, from_tier_id, to_tier_id, change_type, rolling_spend)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING history_id, changed_at
+		`, change.CustomerID, change.FromTierID, change.ToTierID, change.ChangeType, change.RollingSpend).
+			Scan(&change.HistoryID, &change.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record tier change: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return change, nil
+}
+
+// EvaluateAll re-evaluates every customer who is on a tier, had spend at the
+// last evaluation or spent since, moving them up or down. Each customer is
+// evaluated under their own lock.
+func (r *membershipRepository) EvaluateAll(since time.Time) (*models.MembershipEvaluationResult, error) {
+	var customerIDs []int64
+	err := r.db.Select(&customerIDs, `
+		SELECT c.customer_id FROM customers c
+		WHERE c.deleted_at IS NULL
+			AND (c.membership_tier_id IS NOT NULL OR c.membership_spend > 0 OR EXISTS (
+				SELECT 1 FROM transactions t
+				WHERE t.customer_id = c.customer_id AND t.transaction_date >= $1 AND t.deleted_at IS NULL
+			))
+		ORDER BY c.customer_id
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find customers to evaluate: %w", err)
+	}
+
+	result := &models.MembershipEvaluationResult{}
+	for _, customerID := range customerIDs {
+		change, err := r.EvaluateCustomer(customerID, since, true)
+		if err != nil {
+			return result, err
+		}
+		result.Customers++
+		if change == nil {
+			continue
+		}
+		if change.ChangeType == "upgrade" {
+			result.Upgraded++
+		} else {
+			result.Downgraded++
+		}
+	}
+
+	return result, nil
+}
+
+func sameTier(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// membershipMultiplier returns the loyalty point multiplier of a customer's
+// tier, 1 when they get no tier benefits
+func membershipMultiplier(q sqlx.Queryer, customerID int64) (decimal.Decimal, error) {
+	tier, err := getCustomerTier(q, customerID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if tier == nil {
+		return decimal.NewFromInt(1), nil
+	}
+
+	return tier.PointMultiplier, nil
+}
@@ -0,0 +1,292 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NotificationRepository interface defines notification outbox operations
+type NotificationRepository interface {
+	Create(notification *models.Notification) error
+	GetByID(id int64) (*models.Notification, error)
+	ClaimDue(limit int, staleAfter time.Duration) ([]models.Notification, error)
+	MarkSent(id int64) error
+	MarkFailed(id int64, lastError string, nextAttemptAt *time.Time) error
+	Requeue(id int64) error
+	List(filter *models.NotificationFilter, offset, limit int) ([]models.Notification, int64, error)
+	ListInbox(userID int64, unreadOnly bool, offset, limit int) ([]models.Notification, int64, error)
+	MarkRead(id int64, userID int64) error
+	GetCustomerContact(customerID int64) (*models.NotificationContact, error)
+	PurgeSent(before time.Time) (int64, error)
+}
+
+type notificationRepository struct {
+	db *sqlx.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *sqlx.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+const notificationColumns = `
+	notification_id, channel, recipient, customer_id, user_id, outlet_id, template, language,
+	COALESCE(subject, '') as subject, body, reference_type, reference_id, status, attempts,
+	max_attempts, next_attempt_at, last_error, sent_at, read_at, created_at, updated_at
+`
+
+func (r *notificationRepository) Create(notification *models.Notification) error {
+	query := `
+		INSERT INTO notifications (channel, recipient, customer_id, user_id, outlet_id, template, language,
+								   subject, body, reference_type, reference_id, status, max_attempts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING notification_id, next_attempt_at, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query, notification.Channel, notification.Recipient, notification.CustomerID,
+		notification.UserID, notification.OutletID, notification.Template, notification.Language,
+		notification.Subject, notification.Body, notification.ReferenceType, notification.ReferenceID,
+		notification.Status, notification.MaxAttempts).
+		Scan(&notification.NotificationID, &notification.NextAttemptAt, &notification.CreatedAt, &notification.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return nil
+}
+
+func (r *notificationRepository) GetByID(id int64) (*models.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE notification_id = $1 AND deleted_at IS NULL`
+
+	var notification models.Notification
+	err := r.db.Get(&notification, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification: %w", err)
+	}
+
+	return &notification, nil
+}
+
+// ClaimDue locks due notifications for delivery so concurrent dispatchers
+// never send the same message twice. Notifications left in sending longer
+// than staleAfter, e.g. by a crashed process, are claimed again.
+func (r *notificationRepository) ClaimDue(limit int, staleAfter time.Duration) ([]models.Notification, error) {
+	query := `
+		UPDATE notifications
+		SET status = 'sending', attempts = attempts + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE notification_id IN (
+			SELECT notification_id FROM notifications
+			WHERE deleted_at IS NULL
+				  AND ((status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP)
+					   OR (status = 'sending' AND updated_at <= $2))
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + notificationColumns
+
+	var notifications []models.Notification
+	err := r.db.Select(&notifications, query, limit, time.Now().Add(-staleAfter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (r *notificationRepository) MarkSent(id int64) error {
+	query := `
+		UPDATE notifications
+		SET status = 'sent', sent_at = CURRENT_TIMESTAMP, last_error = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE notification_id = $1
+	`
+
+	_, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification sent: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt, scheduling a retry at nextAttemptAt or
+// giving up when it is nil
+func (r *notificationRepository) MarkFailed(id int64, lastError string, nextAttemptAt *time.Time) error {
+	query := `
+		UPDATE notifications
+		SET status = CASE WHEN $3::TIMESTAMP IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($3, next_attempt_at), last_error = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE notification_id = $1
+	`
+
+	_, err := r.db.Exec(query, id, lastError, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification failed: %w", err)
+	}
+
+	return nil
+}
+
+// Requeue schedules a failed notification for immediate delivery, allowing one more attempt
+func (r *notificationRepository) Requeue(id int64) error {
+	query := `
+		UPDATE notifications
+		SET status = 'pending', next_attempt_at = CURRENT_TIMESTAMP,
+			max_attempts = GREATEST(max_attempts, attempts + 1), updated_at = CURRENT_TIMESTAMP
+		WHERE notification_id = $1 AND status = 'failed' AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to requeue notification: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("failed notification not found")
+	}
+
+	return nil
+}
+
+func (r *notificationRepository) List(filter *models.NotificationFilter, offset, limit int) ([]models.Notification, int64, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, filter.Status)
+		argIndex++
+	}
+
+	if filter.Channel != "" {
+		conditions = append(conditions, fmt.Sprintf("channel = $%d", argIndex))
+		args = append(args, filter.Channel)
+		argIndex++
+	}
+
+	if filter.CustomerID != nil {
+		conditions = append(conditions, fmt.Sprintf("customer_id = $%d", argIndex))
+		args = append(args, *filter.CustomerID)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM notifications `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM notifications %s ORDER BY created_at DESC LIMIT $%d OFFSET $%d`,
+		notificationColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var notifications []models.Notification
+	err = r.db.Select(&notifications, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	return notifications, total, nil
+}
+
+func (r *notificationRepository) ListInbox(userID int64, unreadOnly bool, offset, limit int) ([]models.Notification, int64, error) {
+	whereClause := `WHERE user_id = $1 AND channel = 'in_app' AND status = 'sent' AND deleted_at IS NULL`
+	if unreadOnly {
+		whereClause += ` AND read_at IS NULL`
+	}
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM notifications `+whereClause, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count inbox notifications: %w", err)
+	}
+
+	query := `SELECT ` + notificationColumns + ` FROM notifications ` + whereClause + ` ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+
+	var notifications []models.Notification
+	err = r.db.Select(&notifications, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list inbox notifications: %w", err)
+	}
+
+	return notifications, total, nil
+}
+
+func (r *notificationRepository) MarkRead(id int64, userID int64) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+		WHERE notification_id = $1 AND user_id = $2 AND channel = 'in_app' AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("notification not found")
+	}
+
+	return nil
+}
+
+func (r *notificationRepository) GetCustomerContact(customerID int64) (*models.NotificationContact, error) {
+	query := `
+		SELECT customer_id, name, phone, email,
+			   COALESCE(notification_channel, 'whatsapp') as notification_channel,
+			   COALESCE(language, 'id') as language
+		FROM customers
+		WHERE customer_id = $1 AND deleted_at IS NULL
+	`
+
+	var contact models.NotificationContact
+	err := r.db.Get(&contact, query, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer contact: %w", err)
+	}
+
+	return &contact, nil
+}
+
+// PurgeSent deletes sent notifications older than the cutoff. In-app
+// notifications are kept until they have been read, since they are the
+// staff inbox.
+func (r *notificationRepository) PurgeSent(before time.Time) (int64, error) {
+	query := `
+		DELETE FROM notifications
+		WHERE status = 'sent' AND sent_at < $1
+			AND (channel <> 'in_app' OR read_at IS NOT NULL)
+	`
+
+	result, err := r.db.Exec(query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge notifications: %w", err)
+	}
+
+	return result.RowsAffected()
+}
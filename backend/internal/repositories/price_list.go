@@ -0,0 +1,491 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// PriceListRepository defines data access for price lists, their items and
+// customers, price resolution and price history
+type PriceListRepository interface {
+	List(filter *models.PriceListFilter, offset, limit int) ([]models.PriceList, int64, error)
+	GetByID(id int64) (*models.PriceList, error)
+	GetByCode(code string) (*models.PriceList, error)
+	Create(priceList *models.PriceList) error
+	Update(priceList *models.PriceList) error
+	ListItems(priceListID int64) ([]models.PriceListItem, error)
+	SetItem(item *models.PriceListItem, userID int64) error
+	DeleteItem(priceListID, itemID, userID int64) error
+	AssignCustomer(priceListID, customerID, userID int64) error
+	UnassignCustomer(priceListID, customerID int64) error
+	Resolve(customerID *int64, outletID int64, productID, serviceID *int64, at time.Time) (*models.ResolvedPrice, error)
+	ListHistory(filter *models.PriceHistoryFilter, offset, limit int) ([]models.PriceHistory, int64, error)
+	RecordPriceChange(productID, serviceID *int64, oldPrice, newPrice *decimal.Decimal, changedBy *int64) error
+}
+
+type priceListRepository struct {
+	db *sqlx.DB
+}
+
+// NewPriceListRepository creates a new price list repository
+func NewPriceListRepository(db *sqlx.DB) PriceListRepository {
+	return &priceListRepository{db: db}
+}
+
+const priceListColumns = `
+	pl.price_list_id, pl.code, pl.name, pl.description, pl.customer_type, pl.outlet_id, pl.valid_from,
+	pl.valid_to, pl.priority, pl.is_active,
+	(SELECT COUNT(*) FROM price_list_items pli WHERE pli.price_list_id = pl.price_list_id) AS item_count,
+	pl.created_at, pl.updated_at, pl.created_by
+`
+
+const priceListItemColumns = `
+	pli.item_id, pli.price_list_id, pli.product_id, pli.service_id,
+	COALESCE(p.product_code, s.service_code, '') AS item_code, COALESCE(p.name, s.name, '') AS item_name,
+	COALESCE(p.selling_price, s.standard_price, 0) AS list_price, pli.price, pli.updated_at, pli.updated_by
+`
+
+const priceListItemJoins = `
+	FROM price_list_items pli
+	LEFT JOIN products p ON p.product_id = pli.product_id
+	LEFT JOIN services s ON s.service_id = pli.service_id
+`
+
+// priceListApplies is the condition for a price list applying to a sale to
+// customer $1 at outlet $2 at time $3. A walk-in sale has no customer, so
+// only lists without a customer type or customers apply to it.
+const priceListApplies = `
+	pl.is_active = TRUE
+	AND (pl.valid_from IS NULL OR pl.valid_from <= $3)
+	AND (pl.valid_to IS NULL OR pl.valid_to > $3)
+	AND (pl.outlet_id IS NULL OR pl.outlet_id = $2)
+	AND (pl.customer_type IS NULL OR pl.customer_type = (SELECT customer_type FROM customers WHERE customer_id = $1))
+	AND (NOT EXISTS (SELECT 1 FROM price_list_customers plc WHERE plc.price_list_id = pl.price_list_id)
+		OR EXISTS (SELECT 1 FROM price_list_customers plc WHERE plc.price_list_id = pl.price_list_id AND plc.customer_id = $1))
+`
+
+// priceListSpecificity ranks applicable lists: negotiated for the customer,
+// then for their customer type, then for the outlet
+const priceListSpecificity = `
+	(CASE WHEN EXISTS (SELECT 1 FROM price_list_customers plc WHERE plc.price_list_id = pl.price_list_id) THEN 4 ELSE 0 END
+		+ CASE WHEN pl.customer_type IS NOT NULL THEN 2 ELSE 0 END
+		+ CASE WHEN pl.outlet_id IS NOT NULL THEN 1 ELSE 0 END)
+`
+
+func (r *priceListRepository) List(filter *models.PriceListFilter, offset, limit int) ([]models.PriceList, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("(pl.outlet_id IS NULL OR pl.outlet_id = $%d)", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.CustomerType != "" {
+		conditions = append(conditions, fmt.Sprintf("pl.customer_type = $%d", argIndex))
+		args = append(args, filter.CustomerType)
+		argIndex++
+	}
+
+	if filter.CustomerID != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM price_list_customers plc WHERE plc.price_list_id = pl.price_list_id AND plc.customer_id = $%d)", argIndex))
+		args = append(args, *filter.CustomerID)
+		argIndex++
+	}
+
+	if filter.ActiveOnly {
+		conditions = append(conditions, "pl.is_active = TRUE")
+	}
+
+	if filter.Search != "" {
+		conditions = append(conditions, fmt.Sprintf("(pl.name ILIKE $%d OR pl.code ILIKE $%d)", argIndex, argIndex))
+		args = append(args, "%"+filter.Search+"%")
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM price_lists pl `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count price lists: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM price_lists pl
+		%s
+		ORDER BY pl.priority DESC, pl.name, pl.price_list_id
+		LIMIT $%d OFFSET $%d
+	`, priceListColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var priceLists []models.PriceList
+	if err = r.db.Select(&priceLists, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list price lists: %w", err)
+	}
+
+	return priceLists, total, nil
+}
+
+func (r *priceListRepository) GetByID(id int64) (*models.PriceList, error) {
+	return r.get(`pl.price_list_id = $1`, id)
+}
+
+// GetByCode looks a price list up by its code, ignoring case
+func (r *priceListRepository) GetByCode(code string) (*models.PriceList, error) {
+	return r.get(`UPPER(pl.code) = UPPER($1)`, code)
+}
+
+func (r *priceListRepository) get(condition string, arg interface{}) (*models.PriceList, error) {
+	var priceList models.PriceList
+	err := r.db.Get(&priceList, `SELECT `+priceListColumns+` FROM price_lists pl WHERE `+condition, arg)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("price list not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price list: %w", err)
+	}
+
+	priceList.Customers = []models.PriceListCustomer{}
+	err = r.db.Select(&priceList.Customers, `
+		SELECT plc.customer_id, c.customer_code, c.name AS customer_name, plc.created_at
+		FROM price_list_customers plc
+		JOIN customers c ON c.customer_id = plc.customer_id
+		WHERE plc.price_list_id = $1
+		ORDER BY c.name
+	`, priceList.PriceListID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price list customers: %w", err)
+	}
+
+	return &priceList, nil
+}
+
+func (r *priceListRepository) Create(priceList *models.PriceList) error {
+	err := r.db.QueryRow(`
+		INSERT INTO price_lists (code, name, description, customer_type, outlet_id, valid_from, valid_to,
+			priority, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING price_list_id, created_at, updated_at
+	`, priceList.Code, priceList.Name, priceList.Description, priceList.CustomerType, priceList.OutletID,
+		priceList.ValidFrom, priceList.ValidTo, priceList.Priority, priceList.IsActive, priceList.CreatedBy).
+		Scan(&priceList.PriceListID, &priceList.CreatedAt, &priceList.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create price list: %w", err)
+	}
+
+	return nil
+}
+
+func (r *priceListRepository) Update(priceList *models.PriceList) error {
+	result, err := r.db.Exec(`
+		UPDATE price_lists
+		SET code = $2, name = $3, description = $4, customer_type = $5, outlet_id = $6, valid_from = $7,
+			valid_to = $8, priority = $9, is_active = $10, updated_at = CURRENT_TIMESTAMP
+		WHERE price_list_id = $1
+	`, priceList.PriceListID, priceList.Code, priceList.Name, priceList.Description, priceList.CustomerType,
+		priceList.OutletID, priceList.ValidFrom, priceList.ValidTo, priceList.Priority, priceList.IsActive)
+	if err != nil {
+		return fmt.Errorf("failed to update price list: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("price list not found")
+	}
+
+	return nil
+}
+
+func (r *priceListRepository) ListItems(priceListID int64) ([]models.PriceListItem, error) {
+	var items []models.PriceListItem
+	err := r.db.Select(&items, `
+		SELECT `+priceListItemColumns+priceListItemJoins+`
+		WHERE pli.price_list_id = $1
+		ORDER BY item_name, pli.item_id
+	`, priceListID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price list items: %w", err)
+	}
+
+	return items, nil
+}
+
+// SetItem puts an item on a price list or changes its price there, and
+// records the change in the item's price history
+func (r *priceListRepository) SetItem(item *models.PriceListItem, userID int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existing struct {
+		ItemID int64           `db:"item_id"`
+		Price  decimal.Decimal `db:"price"`
+	}
+	err = tx.Get(&existing, `
+		SELECT item_id, price FROM price_list_items
+		WHERE price_list_id = $1 AND (product_id = $2 OR service_id = $3)
+		FOR UPDATE
+	`, item.PriceListID, item.ProductID, item.ServiceID)
+	switch {
+	case err == sql.ErrNoRows:
+		err = tx.QueryRow(`
+			INSERT INTO price_list_items (price_list_id, product_id, service_id, price, updated_by)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING item_id
+		`, item.PriceListID, item.ProductID, item.ServiceID, item.Price, userID).Scan(&item.ItemID)
+		if err != nil {
+			return fmt.Errorf("failed to add price list item: %w", err)
+		}
+		if err = insertPriceHistory(tx, item.ProductID, item.ServiceID, &item.PriceListID, nil, &item.Price, &userID); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get price list item: %w", err)
+	default:
+		item.ItemID = existing.ItemID
+		if existing.Price.Equal(item.Price) {
+			return nil
+		}
+		_, err = tx.Exec(`
+			UPDATE price_list_items SET price = $2, updated_at = CURRENT_TIMESTAMP, updated_by = $3
+			WHERE item_id = $1
+		`, item.ItemID, item.Price, userID)
+		if err != nil {
+			return fmt.Errorf("failed to update price list item: %w", err)
+		}
+		if err = insertPriceHistory(tx, item.ProductID, item.ServiceID, &item.PriceListID, &existing.Price, &item.Price, &userID); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteItem takes an item off a price list, recording it in the item's
+// price history
+func (r *priceListRepository) DeleteItem(priceListID, itemID, userID int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var item models.PriceListItem
+	err = tx.Get(&item, `
+		DELETE FROM price_list_items WHERE item_id = $1 AND price_list_id = $2
+		RETURNING item_id, price_list_id, product_id, service_id, price
+	`, itemID, priceListID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("price list item not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete price list item: %w", err)
+	}
+
+	if err = insertPriceHistory(tx, item.ProductID, item.ServiceID, &priceListID, &item.Price, nil, &userID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *priceListRepository) AssignCustomer(priceListID, customerID, userID int64) error {
+	_, err := r.db.Exec(`
+		INSERT INTO price_list_customers (price_list_id, customer_id, created_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (price_list_id, customer_id) DO NOTHING
+	`, priceListID, customerID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to assign customer to price list: %w", err)
+	}
+
+	return nil
+}
+
+func (r *priceListRepository) UnassignCustomer(priceListID, customerID int64) error {
+	result, err := r.db.Exec(`
+		DELETE FROM price_list_customers WHERE price_list_id = $1 AND customer_id = $2
+	`, priceListID, customerID)
+	if err != nil {
+		return fmt.Errorf("failed to remove customer from price list: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("customer is not on the price list")
+	}
+
+	return nil
+}
+
+// Resolve returns the price an item sells at for a customer at an outlet:
+// its price on the most specific applicable list with the highest priority,
+// or its own price when no list has it
+func (r *priceListRepository) Resolve(customerID *int64, outletID int64, productID, serviceID *int64, at time.Time) (*models.ResolvedPrice, error) {
+	resolved := &models.ResolvedPrice{ProductID: productID, ServiceID: serviceID}
+
+	var err error
+	switch {
+	case productID != nil:
+		err = r.db.Get(&resolved.ListPrice, `
+			SELECT selling_price FROM products WHERE product_id = $1 AND deleted_at IS NULL
+		`, *productID)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product not found")
+		}
+	case serviceID != nil:
+		err = r.db.Get(&resolved.ListPrice, `
+			SELECT standard_price FROM services WHERE service_id = $1 AND deleted_at IS NULL
+		`, *serviceID)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("service not found")
+		}
+	default:
+		return nil, fmt.Errorf("either product or service must be specified")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list price: %w", err)
+	}
+	resolved.Price = resolved.ListPrice
+
+	var listed struct {
+		PriceListID int64           `db:"price_list_id"`
+		Name        string          `db:"name"`
+		Price       decimal.Decimal `db:"price"`
+	}
+	err = r.db.Get(&listed, `
+		SELECT pl.price_list_id, pl.name, pli.price
+		FROM price_list_items pli
+		JOIN price_lists pl ON pl.price_list_id = pli.price_list_id
+		WHERE (pli.product_id = $4 OR pli.service_id = $5) AND `+priceListApplies+`
+		ORDER BY `+priceListSpecificity+` DESC, pl.priority DESC, pl.price_list_id DESC
+		LIMIT 1
+	`, customerID, outletID, at, productID, serviceID)
+	if err == sql.ErrNoRows {
+		return resolved, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve price: %w", err)
+	}
+
+	resolved.Price = listed.Price
+	resolved.PriceListID = &listed.PriceListID
+	resolved.PriceListName = &listed.Name
+
+	return resolved, nil
+}
+
+func (r *priceListRepository) ListHistory(filter *models.PriceHistoryFilter, offset, limit int) ([]models.PriceHistory, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.ProductID != nil {
+		conditions = append(conditions, fmt.Sprintf("ph.product_id = $%d", argIndex))
+		args = append(args, *filter.ProductID)
+		argIndex++
+	}
+
+	if filter.ServiceID != nil {
+		conditions = append(conditions, fmt.Sprintf("ph.service_id = $%d", argIndex))
+		args = append(args, *filter.ServiceID)
+		argIndex++
+	}
+
+	if filter.PriceListID != nil {
+		conditions = append(conditions, fmt.Sprintf("ph.price_list_id = $%d", argIndex))
+		args = append(args, *filter.PriceListID)
+		argIndex++
+	}
+
+	if filter.DateFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("ph.changed_at::date >= $%d", argIndex))
+		args = append(args, filter.DateFrom.Format("2006-01-02"))
+		argIndex++
+	}
+
+	if filter.DateTo != nil {
+		conditions = append(conditions, fmt.Sprintf("ph.changed_at::date <= $%d", argIndex))
+		args = append(args, filter.DateTo.Format("2006-01-02"))
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM price_history ph `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count price history: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT ph.history_id, ph.product_id, ph.service_id, COALESCE(p.name, s.name, '') AS item_name,
+			ph.price_list_id, pl.name AS price_list_name, ph.old_price, ph.new_price, ph.changed_at,
+			ph.changed_by, u.full_name AS changed_by_name
+		FROM price_history ph
+		LEFT JOIN products p ON p.product_id = ph.product_id
+		LEFT JOIN services s ON s.service_id = ph.service_id
+		LEFT JOIN price_lists pl ON pl.price_list_id = ph.price_list_id
+		LEFT JOIN users u ON u.user_id = ph.changed_by
+		%s
+		ORDER BY ph.changed_at DESC, ph.history_id DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var history []models.PriceHistory
+	if err = r.db.Select(&history, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list price history: %w", err)
+	}
+
+	return history, total, nil
+}
+
+// RecordPriceChange records a change to an item's own price
+func (r *priceListRepository) RecordPriceChange(productID, serviceID *int64, oldPrice, newPrice *decimal.Decimal, changedBy *int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = insertPriceHistory(tx, productID, serviceID, nil, oldPrice, newPrice, changedBy); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func insertPriceHistory(tx *sqlx.Tx, productID, serviceID, priceListID *int64, oldPrice, newPrice *decimal.Decimal, changedBy *int64) error {
+	_, err := tx.Exec(`
+		INSERT INTO price_history (product_id, service_id, price_list_id, old_price, new_price, changed_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, productID, serviceID, priceListID, oldPrice, newPrice, changedBy)
+	if err != nil {
+		return fmt.Errorf("failed to record price history: %w", err)
+	}
+
+	return nil
+}
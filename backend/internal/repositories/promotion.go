@@ -0,0 +1,500 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PromotionRepository defines data access for promotions, coupons and their
+// redemptions
+type PromotionRepository interface {
+	List(filter *models.PromotionFilter, offset, limit int) ([]models.Promotion, int64, error)
+	GetByID(id int64) (*models.Promotion, error)
+	Create(promotion *models.Promotion) error
+	Update(promotion *models.Promotion) error
+	ListApplicable(outletID int64, at time.Time) ([]models.Promotion, error)
+	CountCustomerRedemptions(promotionID, customerID int64) (int, error)
+	ListCoupons(promotionID int64) ([]models.Coupon, error)
+	GetCouponByID(id int64) (*models.Coupon, error)
+	GetCouponByCode(code string) (*models.Coupon, error)
+	CreateCoupon(coupon *models.Coupon) error
+	UpdateCoupon(id int64, isActive bool) error
+	ListRedemptions(filter *models.RedemptionFilter, offset, limit int) ([]models.PromotionRedemption, int64, error)
+	GetTransactionRedemptions(transactionID int64) ([]models.PromotionRedemption, error)
+	GetItemCategories(productID, serviceID *int64) (productCategoryID, serviceCategoryID *int64, err error)
+	GetServiceJobCoupon(serviceJobID int64) (*string, error)
+	SetServiceJobCoupon(serviceJobID int64, code *string) error
+}
+
+type promotionRepository struct {
+	db *sqlx.DB
+}
+
+// NewPromotionRepository creates a new promotion repository
+func NewPromotionRepository(db *sqlx.DB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+const promotionColumns = `
+	p.promotion_id, p.name, p.description, p.discount_type, p.discount_value, p.scope, p.min_spend,
+	p.max_discount, p.product_category_id, p.service_category_id, p.outlet_id, p.starts_at, p.ends_at,
+	p.usage_limit, p.per_customer_limit, p.requires_coupon, p.priority, p.is_active,
+	(SELECT COUNT(*) FROM promotion_redemptions pr
+		WHERE pr.promotion_id = p.promotion_id AND pr.reversed_at IS NULL) AS usage_count,
+	p.created_at, p.updated_at, p.created_by
+`
+
+const couponColumns = `
+	c.coupon_id, c.promotion_id, c.code, c.usage_limit,
+	(SELECT COUNT(*) FROM promotion_redemptions pr
+		WHERE pr.coupon_id = c.coupon_id AND pr.reversed_at IS NULL) AS usage_count,
+	c.expires_at, c.is_active, c.created_at, c.created_by
+`
+
+const redemptionColumns = `
+	pr.redemption_id, pr.promotion_id, p.name AS promotion_name, pr.coupon_id, c.code AS coupon_code,
+	pr.transaction_id, t.transaction_number, pr.outlet_id, pr.customer_id, pr.discount_amount,
+	pr.created_at, pr.reversed_at
+`
+
+const redemptionJoins = `
+	FROM promotion_redemptions pr
+	JOIN promotions p ON p.promotion_id = pr.promotion_id
+	JOIN transactions t ON t.transaction_id = pr.transaction_id
+	LEFT JOIN coupons c ON c.coupon_id = pr.coupon_id
+`
+
+func (r *promotionRepository) List(filter *models.PromotionFilter, offset, limit int) ([]models.Promotion, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("(p.outlet_id IS NULL OR p.outlet_id = $%d)", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.ActiveOnly {
+		conditions = append(conditions, "p.is_active = TRUE")
+	}
+
+	if filter.RequiresCoupon != nil {
+		conditions = append(conditions, fmt.Sprintf("p.requires_coupon = $%d", argIndex))
+		args = append(args, *filter.RequiresCoupon)
+		argIndex++
+	}
+
+	if filter.Search != "" {
+		conditions = append(conditions, fmt.Sprintf("p.name ILIKE $%d", argIndex))
+		args = append(args, "%"+filter.Search+"%")
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM promotions p `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count promotions: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM promotions p
+		%s
+		ORDER BY p.priority DESC, p.promotion_id DESC
+		LIMIT $%d OFFSET $%d
+	`, promotionColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var promotions []models.Promotion
+	if err = r.db.Select(&promotions, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list promotions: %w", err)
+	}
+
+	return promotions, total, nil
+}
+
+func (r *promotionRepository) GetByID(id int64) (*models.Promotion, error) {
+	var promotion models.Promotion
+	err := r.db.Get(&promotion, `SELECT `+promotionColumns+` FROM promotions p WHERE p.promotion_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("promotion not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promotion: %w", err)
+	}
+
+	return &promotion, nil
+}
+
+func (r *promotionRepository) Create(promotion *models.Promotion) error {
+	err := r.db.QueryRow(`
+		INSERT INTO promotions (name, description, discount_type, discount_value, scope, min_spend, max_discount,
+			product_category_id, service_category_id, outlet_id, starts_at, ends_at, usage_limit,
+			per_customer_limit, requires_coupon, priority, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		RETURNING promotion_id, created_at, updated_at
+	`, promotion.Name, promotion.Description, promotion.DiscountType, promotion.DiscountValue, promotion.Scope,
+		promotion.MinSpend, promotion.MaxDiscount, promotion.ProductCategoryID, promotion.ServiceCategoryID,
+		promotion.OutletID, promotion.StartsAt, promotion.EndsAt, promotion.UsageLimit, promotion.PerCustomerLimit,
+		promotion.RequiresCoupon, promotion.Priority, promotion.IsActive, promotion.CreatedBy).
+		Scan(&promotion.PromotionID, &promotion.CreatedAt, &promotion.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create promotion: %w", err)
+	}
+
+	return nil
+}
+
+func (r *promotionRepository) Update(promotion *models.Promotion) error {
+	result, err := r.db.Exec(`
+		UPDATE promotions
+		SET name = $2, description = $3, discount_type = $4, discount_value = $5, scope = $6, min_spend = $7,
+			max_discount = $8, product_category_id = $9, service_category_id = $10, outlet_id = $11,
+			starts_at = $12, ends_at = $13, usage_limit = $14, per_customer_limit = $15, requires_coupon = $16,
+			priority = $17, is_active = $18, updated_at = CURRENT_TIMESTAMP
+		WHERE promotion_id = $1
+	`, promotion.PromotionID, promotion.Name, promotion.Description, promotion.DiscountType,
+		promotion.DiscountValue, promotion.Scope, promotion.MinSpend, promotion.MaxDiscount,
+		promotion.ProductCategoryID, promotion.ServiceCategoryID, promotion.OutletID, promotion.StartsAt,
+		promotion.EndsAt, promotion.UsageLimit, promotion.PerCustomerLimit, promotion.RequiresCoupon,
+		promotion.Priority, promotion.IsActive)
+	if err != nil {
+		return fmt.Errorf("failed to update promotion: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("promotion not found")
+	}
+
+	return nil
+}
+
+// ListApplicable returns the automatic promotions running at an outlet,
+// highest priority first, leaving out those that reached their usage limit
+func (r *promotionRepository) ListApplicable(outletID int64, at time.Time) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	err := r.db.Select(&promotions, `
+		SELECT * FROM (
+			SELECT `+promotionColumns+`
+			FROM promotions p
+			WHERE p.is_active = TRUE AND p.requires_coupon = FALSE
+				AND (p.outlet_id IS NULL OR p.outlet_id = $1)
+				AND (p.starts_at IS NULL OR p.starts_at <= $2)
+				AND (p.ends_at IS NULL OR p.ends_at > $2)
+		) p
+		WHERE p.usage_limit IS NULL OR p.usage_count < p.usage_limit
+		ORDER BY p.priority DESC, p.promotion_id
+	`, outletID, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applicable promotions: %w", err)
+	}
+
+	return promotions, nil
+}
+
+func (r *promotionRepository) CountCustomerRedemptions(promotionID, customerID int64) (int, error) {
+	var count int
+	err := r.db.Get(&count, `
+		SELECT COUNT(*) FROM promotion_redemptions
+		WHERE promotion_id = $1 AND customer_id = $2 AND reversed_at IS NULL
+	`, promotionID, customerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count customer redemptions: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *promotionRepository) ListCoupons(promotionID int64) ([]models.Coupon, error) {
+	var coupons []models.Coupon
+	err := r.db.Select(&coupons, `
+		SELECT `+couponColumns+` FROM coupons c WHERE c.promotion_id = $1 ORDER BY c.coupon_id
+	`, promotionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coupons: %w", err)
+	}
+
+	return coupons, nil
+}
+
+func (r *promotionRepository) GetCouponByID(id int64) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.Get(&coupon, `SELECT `+couponColumns+` FROM coupons c WHERE c.coupon_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("coupon not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	return &coupon, nil
+}
+
+// GetCouponByCode looks a coupon up by its code, ignoring case
+func (r *promotionRepository) GetCouponByCode(code string) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.Get(&coupon, `SELECT `+couponColumns+` FROM coupons c WHERE UPPER(c.code) = UPPER($1)`, code)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("coupon not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	return &coupon, nil
+}
+
+func (r *promotionRepository) CreateCoupon(coupon *models.Coupon) error {
+	err := r.db.QueryRow(`
+		INSERT INTO coupons (promotion_id, code, usage_limit, expires_at, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING coupon_id, created_at
+	`, coupon.PromotionID, coupon.Code, coupon.UsageLimit, coupon.ExpiresAt, coupon.IsActive, coupon.CreatedBy).
+		Scan(&coupon.CouponID, &coupon.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create coupon: %w", err)
+	}
+
+	return nil
+}
+
+func (r *promotionRepository) UpdateCoupon(id int64, isActive bool) error {
+	result, err := r.db.Exec(`UPDATE coupons SET is_active = $2 WHERE coupon_id = $1`, id, isActive)
+	if err != nil {
+		return fmt.Errorf("failed to update coupon: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("coupon not found")
+	}
+
+	return nil
+}
+
+func (r *promotionRepository) ListRedemptions(filter *models.RedemptionFilter, offset, limit int) ([]models.PromotionRedemption, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.PromotionID != nil {
+		conditions = append(conditions, fmt.Sprintf("pr.promotion_id = $%d", argIndex))
+		args = append(args, *filter.PromotionID)
+		argIndex++
+	}
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("pr.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.CustomerID != nil {
+		conditions = append(conditions, fmt.Sprintf("pr.customer_id = $%d", argIndex))
+		args = append(args, *filter.CustomerID)
+		argIndex++
+	}
+
+	if filter.DateFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("pr.created_at::date >= $%d", argIndex))
+		args = append(args, filter.DateFrom.Format("2006-01-02"))
+		argIndex++
+	}
+
+	if filter.DateTo != nil {
+		conditions = append(conditions, fmt.Sprintf("pr.created_at::date <= $%d", argIndex))
+		args = append(args, filter.DateTo.Format("2006-01-02"))
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM promotion_redemptions pr `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count redemptions: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		%s
+		%s
+		ORDER BY pr.created_at DESC, pr.redemption_id DESC
+		LIMIT $%d OFFSET $%d
+	`, redemptionColumns, redemptionJoins, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var redemptions []models.PromotionRedemption
+	if err = r.db.Select(&redemptions, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list redemptions: %w", err)
+	}
+
+	return redemptions, total, nil
+}
+
+func (r *promotionRepository) GetTransactionRedemptions(transactionID int64) ([]models.PromotionRedemption, error) {
+	var redemptions []models.PromotionRedemption
+	err := r.db.Select(&redemptions, `
+		SELECT `+redemptionColumns+redemptionJoins+`
+		WHERE pr.transaction_id = $1
+		ORDER BY pr.redemption_id
+	`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction redemptions: %w", err)
+	}
+
+	return redemptions, nil
+}
+
+// GetItemCategories returns the category of a line's product and of its
+// service
+func (r *promotionRepository) GetItemCategories(productID, serviceID *int64) (productCategoryID, serviceCategoryID *int64, err error) {
+	if productID != nil {
+		err = r.db.Get(&productCategoryID, `SELECT category_id FROM products WHERE product_id = $1`, *productID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("failed to get product category: %w", err)
+		}
+	}
+	if serviceID != nil {
+		err = r.db.Get(&serviceCategoryID, `SELECT category_id FROM services WHERE service_id = $1`, *serviceID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("failed to get service category: %w", err)
+		}
+	}
+
+	return productCategoryID, serviceCategoryID, nil
+}
+
+func (r *promotionRepository) GetServiceJobCoupon(serviceJobID int64) (*string, error) {
+	var code *string
+	err := r.db.Get(&code, `SELECT coupon_code FROM service_jobs WHERE job_id = $1`, serviceJobID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("service job not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service job coupon: %w", err)
+	}
+
+	return code, nil
+}
+
+func (r *promotionRepository) SetServiceJobCoupon(serviceJobID int64, code *string) error {
+	result, err := r.db.Exec(`
+		UPDATE service_jobs SET coupon_code = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE job_id = $1 AND deleted_at IS NULL
+	`, serviceJobID, code)
+	if err != nil {
+		return fmt.Errorf("failed to set service job coupon: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("service job not found")
+	}
+
+	return nil
+}
+
+// insertRedemptions records the promotions applied to a new transaction.
+// Usage limits are checked again with the promotion and coupon locked, so
+// two tills cannot both take the last use.
+func insertRedemptions(tx *sqlx.Tx, transaction *models.Transaction) error {
+	for i := range transaction.Promotions {
+		redemption := &transaction.Promotions[i]
+
+		var promotion struct {
+			Name             string `db:"name"`
+			UsageLimit       *int   `db:"usage_limit"`
+			PerCustomerLimit *int   `db:"per_customer_limit"`
+		}
+		err := tx.Get(&promotion, `
+			SELECT name, usage_limit, per_customer_limit FROM promotions WHERE promotion_id = $1 FOR UPDATE
+		`, redemption.PromotionID)
+		if err != nil {
+			return fmt.Errorf("failed to lock promotion: %w", err)
+		}
+
+		if promotion.UsageLimit != nil {
+			var used int
+			err = tx.Get(&used, `
+				SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND reversed_at IS NULL
+			`, redemption.PromotionID)
+			if err != nil {
+				return fmt.Errorf("failed to count redemptions: %w", err)
+			}
+			if used >= *promotion.UsageLimit {
+				return fmt.Errorf("promotion %s has reached its usage limit", promotion.Name)
+			}
+		}
+
+		if promotion.PerCustomerLimit != nil && transaction.CustomerID != nil {
+			var used int
+			err = tx.Get(&used, `
+				SELECT COUNT(*) FROM promotion_redemptions
+				WHERE promotion_id = $1 AND customer_id = $2 AND reversed_at IS NULL
+			`, redemption.PromotionID, *transaction.CustomerID)
+			if err != nil {
+				return fmt.Errorf("failed to count customer redemptions: %w", err)
+			}
+			if used >= *promotion.PerCustomerLimit {
+				return fmt.Errorf("customer has already used promotion %s", promotion.Name)
+			}
+		}
+
+		if redemption.CouponID != nil {
+			var usageLimit *int
+			err = tx.Get(&usageLimit, `SELECT usage_limit FROM coupons WHERE coupon_id = $1 FOR UPDATE`, *redemption.CouponID)
+			if err != nil {
+				return fmt.Errorf("failed to lock coupon: %w", err)
+			}
+			if usageLimit != nil {
+				var used int
+				err = tx.Get(&used, `
+					SELECT COUNT(*) FROM promotion_redemptions WHERE coupon_id = $1 AND reversed_at IS NULL
+				`, *redemption.CouponID)
+				if err != nil {
+					return fmt.Errorf("failed to count coupon redemptions: %w", err)
+				}
+				if used >= *usageLimit {
+					return fmt.Errorf("coupon has already been used")
+				}
+			}
+		}
+
+		redemption.PromotionName = promotion.Name
+		redemption.TransactionID = transaction.ID
+		redemption.TransactionNumber = transaction.TransactionNumber
+		redemption.OutletID = transaction.OutletID
+		redemption.CustomerID = transaction.CustomerID
+		err = tx.QueryRow(`
+			INSERT INTO promotion_redemptions (promotion_id, coupon_id, transaction_id, outlet_id, customer_id,
+				discount_amount)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING redemption_id, created_at
+		`, redemption.PromotionID, redemption.CouponID, redemption.TransactionID, redemption.OutletID,
+			redemption.CustomerID, redemption.DiscountAmount).Scan(&redemption.RedemptionID, &redemption.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record promotion redemption: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// reverseRedemptions gives a voided transaction's promotion and coupon uses
+// back
+func reverseRedemptions(tx *sqlx.Tx, transactionID int64) error {
+	_, err := tx.Exec(`
+		UPDATE promotion_redemptions SET reversed_at = CURRENT_TIMESTAMP
+		WHERE transaction_id = $1 AND reversed_at IS NULL
+	`, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to reverse promotion redemptions: %w", err)
+	}
+
+	return nil
+}
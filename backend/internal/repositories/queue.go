@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QueueRepository interface defines service queue operations
+type QueueRepository interface {
+	GetActiveJobs(outletID int64) ([]models.QueueEntry, error)
+	GetActiveJobLines(outletID int64) ([]models.QueueJobLine, error)
+	GetJobOutletID(jobID int64) (int64, error)
+	GetActiveTechnicianIDs(outletID int64) ([]int64, error)
+	UpdateEstimates(jobID int64, estimatedStart, estimatedCompletion time.Time) error
+}
+
+type queueRepository struct {
+	db *sqlx.DB
+}
+
+// NewQueueRepository creates a new queue repository
+func NewQueueRepository(db *sqlx.DB) QueueRepository {
+	return &queueRepository{db: db}
+}
+
+func (r *queueRepository) GetActiveJobs(outletID int64) ([]models.QueueEntry, error) {
+	query := `
+		SELECT sj.job_id, sj.job_number, sj.outlet_id, sj.queue_number, sj.priority,
+			   sj.status, sj.technician_id, sj.created_at as arrived_at, sj.started_at,
+			   COALESCE(c.name, '') as customer_name,
+			   COALESCE(mt.is_active AND mt.priority_queue, FALSE) as priority_member,
+			   COALESCE(cv.vehicle_number, '') as vehicle_number
+		FROM service_jobs sj
+		LEFT JOIN customers c ON sj.customer_id = c.customer_id
+		LEFT JOIN membership_tiers mt ON c.membership_tier_id = mt.tier_id
+		LEFT JOIN customer_vehicles cv ON sj.vehicle_id = cv.vehicle_id
+		WHERE sj.outlet_id = $1 AND sj.status IN ('pending', 'in_progress')
+			  AND sj.deleted_at IS NULL
+		ORDER BY sj.created_at ASC, sj.queue_number ASC
+	`
+
+	var entries []models.QueueEntry
+	err := r.db.Select(&entries, query, outletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active queue jobs: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetActiveJobLines returns the services on an outlet's queued jobs, which
+// set how long each job takes
+func (r *queueRepository) GetActiveJobLines(outletID int64) ([]models.QueueJobLine, error) {
+	query := `
+		SELECT sd.service_job_id, COALESCE(s.estimated_duration, 0) as estimated_duration, sd.quantity
+		FROM service_details sd
+		JOIN services s ON sd.service_id = s.service_id
+		JOIN service_jobs sj ON sd.service_job_id = sj.job_id
+		WHERE sj.outlet_id = $1 AND sj.status IN ('pending', 'in_progress')
+			  AND sj.deleted_at IS NULL AND sd.deleted_at IS NULL
+		ORDER BY sd.service_job_id
+	`
+
+	var lines []models.QueueJobLine
+	err := r.db.Select(&lines, query, outletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active queue job lines: %w", err)
+	}
+
+	return lines, nil
+}
+
+func (r *queueRepository) GetJobOutletID(jobID int64) (int64, error) {
+	query := `SELECT outlet_id FROM service_jobs WHERE job_id = $1 AND deleted_at IS NULL`
+
+	var outletID int64
+	err := r.db.Get(&outletID, query, jobID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get service job outlet: %w", err)
+	}
+
+	return outletID, nil
+}
+
+func (r *queueRepository) GetActiveTechnicianIDs(outletID int64) ([]int64, error) {
+	query := `
+		SELECT u.user_id
+		FROM users u
+		JOIN roles r ON u.role_id = r.role_id
+		WHERE r.name = 'Technician' AND u.outlet_id = $1
+			  AND u.is_active = TRUE AND u.deleted_at IS NULL
+		ORDER BY u.user_id
+	`
+
+	var ids []int64
+	err := r.db.Select(&ids, query, outletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active technicians: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *queueRepository) UpdateEstimates(jobID int64, estimatedStart, estimatedCompletion time.Time) error {
+	query := `
+		UPDATE service_jobs
+		SET estimated_start = $2, estimated_completion = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE job_id = $1 AND deleted_at IS NULL
+	`
+
+	_, err := r.db.Exec(query, jobID, estimatedStart, estimatedCompletion)
+	if err != nil {
+		return fmt.Errorf("failed to update queue estimates: %w", err)
+	}
+
+	return nil
+}
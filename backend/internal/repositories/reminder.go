@@ -0,0 +1,482 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ReminderRepository interface defines service interval and vehicle reminder operations
+type ReminderRepository interface {
+	ListIntervals() ([]models.ServiceInterval, error)
+	GetInterval(id int64) (*models.ServiceInterval, error)
+	CreateInterval(interval *models.ServiceInterval) error
+	UpdateInterval(interval *models.ServiceInterval) error
+	DeleteInterval(id int64) error
+	GetJobIntervals(jobID int64) ([]models.ServiceInterval, error)
+
+	GetJobVehicleSchedule(jobID int64) (*models.VehicleServiceSchedule, error)
+	UpdateVehicleSchedule(schedule *models.VehicleServiceSchedule) error
+	RecordOdometer(vehicleID, odometer int64) error
+
+	GenerateServiceReminders(today time.Time, windowDays, windowKm int) ([]int64, error)
+	GenerateDocumentReminders(today time.Time, reminderType string, windowDays int) ([]int64, error)
+	GetByID(id int64) (*models.VehicleReminder, error)
+	GetByIDs(ids []int64) ([]models.VehicleReminder, error)
+	List(filter *models.ReminderFilter, offset, limit int) ([]models.VehicleReminder, int64, error)
+	MarkNotified(id int64) error
+	UpdateStatus(id int64, status string, notes string, userID int64) error
+	RenewDocument(reminder *models.VehicleReminder, expiry time.Time, notes string, userID int64) error
+}
+
+// documentExpiryColumns maps document reminder types to their expiry column
+var documentExpiryColumns = map[string]string{
+	models.ReminderTypeInsurance:    "insurance_expiry",
+	models.ReminderTypeRegistration: "registration_expiry",
+}
+
+// reminderOutlet picks the outlet that works a vehicle's reminder: the one
+// that last serviced it, else the one the customer last came to. Reminders
+// for customers never seen at an outlet have none and are on every outlet's
+// list.
+const reminderOutlet = `COALESCE(
+			(SELECT sj.outlet_id FROM service_jobs sj
+			 WHERE sj.vehicle_id = cv.vehicle_id AND sj.deleted_at IS NULL
+			 ORDER BY sj.created_at DESC LIMIT 1),
+			(SELECT t.outlet_id FROM transactions t
+			 WHERE t.customer_id = cv.customer_id AND t.deleted_at IS NULL
+			 ORDER BY t.transaction_date DESC, t.transaction_id DESC LIMIT 1))`
+
+type reminderRepository struct {
+	db *sqlx.DB
+}
+
+// NewReminderRepository creates a new reminder repository
+func NewReminderRepository(db *sqlx.DB) ReminderRepository {
+	return &reminderRepository{db: db}
+}
+
+const serviceIntervalColumns = `
+	si.interval_id, si.service_id, s.name as service_name, si.service_category_id,
+	sc.name as category_name, si.interval_days, si.interval_km, si.is_active,
+	si.created_at, si.updated_at, si.created_by
+`
+
+func (r *reminderRepository) ListIntervals() ([]models.ServiceInterval, error) {
+	query := `
+		SELECT ` + serviceIntervalColumns + `
+		FROM service_intervals si
+		LEFT JOIN services s ON si.service_id = s.service_id
+		LEFT JOIN service_categories sc ON si.service_category_id = sc.service_category_id
+		WHERE si.deleted_at IS NULL
+		ORDER BY sc.name NULLS LAST, s.name NULLS FIRST
+	`
+
+	var intervals []models.ServiceInterval
+	err := r.db.Select(&intervals, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service intervals: %w", err)
+	}
+
+	return intervals, nil
+}
+
+func (r *reminderRepository) GetInterval(id int64) (*models.ServiceInterval, error) {
+	query := `
+		SELECT ` + serviceIntervalColumns + `
+		FROM service_intervals si
+		LEFT JOIN services s ON si.service_id = s.service_id
+		LEFT JOIN service_categories sc ON si.service_category_id = sc.service_category_id
+		WHERE si.interval_id = $1 AND si.deleted_at IS NULL
+	`
+
+	var interval models.ServiceInterval
+	err := r.db.Get(&interval, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service interval: %w", err)
+	}
+
+	return &interval, nil
+}
+
+func (r *reminderRepository) CreateInterval(interval *models.ServiceInterval) error {
+	query := `
+		INSERT INTO service_intervals (service_id, service_category_id, interval_days, interval_km, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING interval_id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query, interval.ServiceID, interval.ServiceCategoryID, interval.IntervalDays,
+		interval.IntervalKm, interval.IsActive, interval.CreatedBy).
+		Scan(&interval.IntervalID, &interval.CreatedAt, &interval.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create service interval: %w", err)
+	}
+
+	return nil
+}
+
+func (r *reminderRepository) UpdateInterval(interval *models.ServiceInterval) error {
+	query := `
+		UPDATE service_intervals
+		SET interval_days = $2, interval_km = $3, is_active = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE interval_id = $1 AND deleted_at IS NULL
+	`
+
+	_, err := r.db.Exec(query, interval.IntervalID, interval.IntervalDays, interval.IntervalKm, interval.IsActive)
+	if err != nil {
+		return fmt.Errorf("failed to update service interval: %w", err)
+	}
+
+	return nil
+}
+
+func (r *reminderRepository) DeleteInterval(id int64) error {
+	query := `UPDATE service_intervals SET deleted_at = CURRENT_TIMESTAMP WHERE interval_id = $1 AND deleted_at IS NULL`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete service interval: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("service interval not found")
+	}
+
+	return nil
+}
+
+// GetJobIntervals returns the interval that applies to each service line of a
+// job, preferring a service-specific interval over its category's
+func (r *reminderRepository) GetJobIntervals(jobID int64) ([]models.ServiceInterval, error) {
+	query := `
+		SELECT COALESCE(ss.interval_id, sc.interval_id) as interval_id,
+			   s.service_id, s.name as service_name, s.category_id as service_category_id,
+			   CASE WHEN ss.interval_id IS NOT NULL THEN ss.interval_days ELSE sc.interval_days END as interval_days,
+			   CASE WHEN ss.interval_id IS NOT NULL THEN ss.interval_km ELSE sc.interval_km END as interval_km,
+			   TRUE as is_active
+		FROM service_details sd
+		JOIN services s ON sd.service_id = s.service_id
+		LEFT JOIN service_intervals ss ON ss.service_id = s.service_id
+			  AND ss.is_active = TRUE AND ss.deleted_at IS NULL
+		LEFT JOIN service_intervals sc ON sc.service_category_id = s.category_id AND sc.service_id IS NULL
+			  AND sc.is_active = TRUE AND sc.deleted_at IS NULL
+		WHERE sd.service_job_id = $1 AND sd.deleted_at IS NULL
+			  AND (ss.interval_id IS NOT NULL OR sc.interval_id IS NOT NULL)
+	`
+
+	var intervals []models.ServiceInterval
+	err := r.db.Select(&intervals, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job service intervals: %w", err)
+	}
+
+	return intervals, nil
+}
+
+func (r *reminderRepository) GetJobVehicleSchedule(jobID int64) (*models.VehicleServiceSchedule, error) {
+	query := `
+		SELECT cv.vehicle_id, sj.odometer, COALESCE(cv.mileage, 0) as mileage,
+			   cv.last_service_date, cv.next_service_date, cv.next_service_mileage
+		FROM service_jobs sj
+		JOIN customer_vehicles cv ON sj.vehicle_id = cv.vehicle_id
+		WHERE sj.job_id = $1 AND sj.deleted_at IS NULL
+	`
+
+	var schedule models.VehicleServiceSchedule
+	err := r.db.Get(&schedule, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vehicle service schedule: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+// UpdateVehicleSchedule stores the vehicle's new service dates and closes any
+// open service reminder, since the vehicle has just been serviced
+func (r *reminderRepository) UpdateVehicleSchedule(schedule *models.VehicleServiceSchedule) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE customer_vehicles
+		SET mileage = GREATEST(COALESCE(mileage, 0), $2), last_service_date = $3,
+			next_service_date = $4, next_service_mileage = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE vehicle_id = $1
+	`, schedule.VehicleID, schedule.Mileage, schedule.LastServiceDate, schedule.NextServiceDate, schedule.NextServiceMileage)
+	if err != nil {
+		return fmt.Errorf("failed to update vehicle service schedule: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE vehicle_reminders
+		SET status = 'completed', updated_at = CURRENT_TIMESTAMP
+		WHERE vehicle_id = $1 AND reminder_type = $2 AND deleted_at IS NULL
+			  AND status IN ('pending', 'notified', 'contacted')
+	`, schedule.VehicleID, models.ReminderTypeService)
+	if err != nil {
+		return fmt.Errorf("failed to complete service reminders: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RecordOdometer keeps the highest odometer reading seen for a vehicle, so
+// mileage-based reminders come due between services. The service schedule
+// is left alone.
+func (r *reminderRepository) RecordOdometer(vehicleID, odometer int64) error {
+	_, err := r.db.Exec(`
+		UPDATE customer_vehicles
+		SET mileage = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE vehicle_id = $1 AND COALESCE(mileage, 0) < $2
+	`, vehicleID, odometer)
+	if err != nil {
+		return fmt.Errorf("failed to record odometer: %w", err)
+	}
+
+	return nil
+}
+
+// GenerateServiceReminders opens a reminder for every vehicle due by date or
+// mileage that has no reminder for the same due point yet
+func (r *reminderRepository) GenerateServiceReminders(today time.Time, windowDays, windowKm int) ([]int64, error) {
+	query := `
+		INSERT INTO vehicle_reminders (vehicle_id, customer_id, outlet_id, reminder_type, due_date, due_mileage)
+		SELECT cv.vehicle_id, cv.customer_id, ` + reminderOutlet + `,
+			   $4, cv.next_service_date, cv.next_service_mileage
+		FROM customer_vehicles cv
+		WHERE cv.is_active = TRUE AND cv.deleted_at IS NULL
+			  AND ((cv.next_service_date IS NOT NULL AND cv.next_service_date <= $1::DATE + $2::INTEGER)
+				   OR (cv.next_service_mileage IS NOT NULL AND cv.mileage >= cv.next_service_mileage - $3))
+			  AND NOT EXISTS (
+				  SELECT 1 FROM vehicle_reminders vr
+				  WHERE vr.vehicle_id = cv.vehicle_id AND vr.reminder_type = $4 AND vr.deleted_at IS NULL
+						AND vr.due_date IS NOT DISTINCT FROM cv.next_service_date
+						AND vr.due_mileage IS NOT DISTINCT FROM cv.next_service_mileage
+			  )
+		ON CONFLICT (vehicle_id, reminder_type) WHERE deleted_at IS NULL AND status IN ('pending', 'notified', 'contacted')
+		DO NOTHING
+		RETURNING reminder_id
+	`
+
+	var ids []int64
+	err := r.db.Select(&ids, query, today, windowDays, windowKm, models.ReminderTypeService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate service reminders: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GenerateDocumentReminders opens a reminder for every vehicle whose document
+// of the given type expires within the window and has no reminder for that date yet
+func (r *reminderRepository) GenerateDocumentReminders(today time.Time, reminderType string, windowDays int) ([]int64, error) {
+	column, ok := documentExpiryColumns[reminderType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported document reminder type: %s", reminderType)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO vehicle_reminders (vehicle_id, customer_id, outlet_id, reminder_type, due_date)
+		SELECT cv.vehicle_id, cv.customer_id, ` + reminderOutlet + `,
+			   $3, cv.%[1]s
+		FROM customer_vehicles cv
+		WHERE cv.is_active = TRUE AND cv.deleted_at IS NULL
+			  AND cv.%[1]s IS NOT NULL AND cv.%[1]s <= $1::DATE + $2::INTEGER
+			  AND NOT EXISTS (
+				  SELECT 1 FROM vehicle_reminders vr
+				  WHERE vr.vehicle_id = cv.vehicle_id AND vr.reminder_type = $3 AND vr.deleted_at IS NULL
+						AND vr.due_date = cv.%[1]s
+			  )
+		ON CONFLICT (vehicle_id, reminder_type) WHERE deleted_at IS NULL AND status IN ('pending', 'notified', 'contacted')
+		DO NOTHING
+		RETURNING reminder_id
+	`, column)
+
+	var ids []int64
+	err := r.db.Select(&ids, query, today, windowDays, reminderType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s reminders: %w", reminderType, err)
+	}
+
+	return ids, nil
+}
+
+const vehicleReminderSelect = `
+	SELECT vr.reminder_id, vr.vehicle_id, vr.customer_id, vr.outlet_id, vr.reminder_type,
+		   vr.due_date, vr.due_mileage, vr.status, vr.notified_at, vr.handled_at, vr.handled_by, vr.notes,
+		   cv.vehicle_number, cv.brand as vehicle_brand, cv.model as vehicle_model,
+		   COALESCE(cv.mileage, 0) as mileage, c.name as customer_name, c.phone as customer_phone,
+		   vr.created_at, vr.updated_at
+	FROM vehicle_reminders vr
+	JOIN customer_vehicles cv ON vr.vehicle_id = cv.vehicle_id
+	JOIN customers c ON vr.customer_id = c.customer_id
+`
+
+func (r *reminderRepository) GetByID(id int64) (*models.VehicleReminder, error) {
+	var reminder models.VehicleReminder
+	err := r.db.Get(&reminder, vehicleReminderSelect+` WHERE vr.reminder_id = $1 AND vr.deleted_at IS NULL`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminder: %w", err)
+	}
+
+	return &reminder, nil
+}
+
+func (r *reminderRepository) GetByIDs(ids []int64) ([]models.VehicleReminder, error) {
+	if len(ids) == 0 {
+		return []models.VehicleReminder{}, nil
+	}
+
+	query, args, err := sqlx.In(vehicleReminderSelect+` WHERE vr.reminder_id IN (?) ORDER BY vr.reminder_id`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build reminder query: %w", err)
+	}
+
+	var reminders []models.VehicleReminder
+	err = r.db.Select(&reminders, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
+func (r *reminderRepository) List(filter *models.ReminderFilter, offset, limit int) ([]models.VehicleReminder, int64, error) {
+	conditions := []string{"vr.deleted_at IS NULL"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("(vr.outlet_id = $%d OR vr.outlet_id IS NULL)", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.ReminderType != "" {
+		conditions = append(conditions, fmt.Sprintf("vr.reminder_type = $%d", argIndex))
+		args = append(args, filter.ReminderType)
+		argIndex++
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("vr.status = $%d", argIndex))
+		args = append(args, filter.Status)
+		argIndex++
+	} else {
+		conditions = append(conditions, "vr.status IN ('pending', 'notified', 'contacted')")
+	}
+
+	if filter.DueWithin != nil {
+		conditions = append(conditions, fmt.Sprintf("(vr.due_date IS NULL OR vr.due_date <= CURRENT_DATE + $%d::INTEGER)", argIndex))
+		args = append(args, *filter.DueWithin)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM vehicle_reminders vr %s`, whereClause)
+
+	var total int64
+	err := r.db.Get(&total, countQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count reminders: %w", err)
+	}
+
+	query := fmt.Sprintf(`%s %s ORDER BY vr.due_date ASC NULLS LAST, vr.reminder_id ASC LIMIT $%d OFFSET $%d`,
+		vehicleReminderSelect, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var reminders []models.VehicleReminder
+	err = r.db.Select(&reminders, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reminders: %w", err)
+	}
+
+	return reminders, total, nil
+}
+
+func (r *reminderRepository) MarkNotified(id int64) error {
+	query := `
+		UPDATE vehicle_reminders
+		SET status = CASE WHEN status = 'pending' THEN 'notified' ELSE status END,
+			notified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE reminder_id = $1
+	`
+
+	_, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark reminder notified: %w", err)
+	}
+
+	return nil
+}
+
+func (r *reminderRepository) UpdateStatus(id int64, status string, notes string, userID int64) error {
+	query := `
+		UPDATE vehicle_reminders
+		SET status = $2, notes = COALESCE(NULLIF($3, ''), notes), handled_at = CURRENT_TIMESTAMP,
+			handled_by = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE reminder_id = $1 AND deleted_at IS NULL
+	`
+
+	_, err := r.db.Exec(query, id, status, notes, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update reminder status: %w", err)
+	}
+
+	return nil
+}
+
+// RenewDocument closes a document reminder and stores the new expiry date on the vehicle
+func (r *reminderRepository) RenewDocument(reminder *models.VehicleReminder, expiry time.Time, notes string, userID int64) error {
+	column, ok := documentExpiryColumns[reminder.ReminderType]
+	if !ok {
+		return fmt.Errorf("unsupported document reminder type: %s", reminder.ReminderType)
+	}
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(fmt.Sprintf(`
+		UPDATE customer_vehicles SET %s = $2, updated_at = CURRENT_TIMESTAMP WHERE vehicle_id = $1
+	`, column), reminder.VehicleID, expiry)
+	if err != nil {
+		return fmt.Errorf("failed to update vehicle %s: %w", column, err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE vehicle_reminders
+		SET status = 'renewed', notes = COALESCE(NULLIF($2, ''), notes), handled_at = CURRENT_TIMESTAMP,
+			handled_by = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE reminder_id = $1
+	`, reminder.ReminderID, notes, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update reminder status: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
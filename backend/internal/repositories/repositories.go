@@ -18,6 +18,28 @@ type Repositories struct {
 	Transaction     TransactionRepository
 	Payment         PaymentRepository
 	VehicleTrading  VehicleTradingRepository
+	Queue           QueueRepository
+	Display         DisplayRepository
+	Tracking        TrackingRepository
+	Reminder        ReminderRepository
+	Notification    NotificationRepository
+	Event           EventRepository
+	Webhook         WebhookRepository
+	Scheduler       SchedulerRepository
+	Finance         FinanceRepository
+	Ledger          LedgerRepository
+	Cash            CashRepository
+	Shift           ShiftRepository
+	Return          ReturnRepository
+	Tax             TaxRepository
+	Promotion       PromotionRepository
+	Approval        ApprovalRepository
+	Loyalty         LoyaltyRepository
+	Membership      MembershipRepository
+	StoredValue     StoredValueRepository
+	ServicePackage  ServicePackageRepository
+	PriceList       PriceListRepository
+	Costing         CostingRepository
 }
 
 // New creates a new repositories instance
@@ -35,5 +57,27 @@ func New(db *sqlx.DB) *Repositories {
 		Transaction:    NewTransactionRepository(db),
 		Payment:        NewPaymentRepository(db),
 		VehicleTrading: NewVehicleTradingRepository(db),
+		Queue:          NewQueueRepository(db),
+		Display:        NewDisplayRepository(db),
+		Tracking:       NewTrackingRepository(db),
+		Reminder:       NewReminderRepository(db),
+		Notification:   NewNotificationRepository(db),
+		Event:          NewEventRepository(db),
+		Webhook:        NewWebhookRepository(db),
+		Scheduler:      NewSchedulerRepository(db),
+		Finance:        NewFinanceRepository(db),
+		Ledger:         NewLedgerRepository(db),
+		Cash:           NewCashRepository(db),
+		Shift:          NewShiftRepository(db),
+		Return:         NewReturnRepository(db),
+		Tax:            NewTaxRepository(db),
+		Promotion:      NewPromotionRepository(db),
+		Approval:       NewApprovalRepository(db),
+		Loyalty:        NewLoyaltyRepository(db),
+		Membership:     NewMembershipRepository(db),
+		StoredValue:    NewStoredValueRepository(db),
+		ServicePackage: NewServicePackageRepository(db),
+		PriceList:      NewPriceListRepository(db),
+		Costing:        NewCostingRepository(db),
 	}
 }
\ No newline at end of file
@@ -0,0 +1,971 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"flutter-bengkel/internal/events"
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// ReturnRepository interface defines sales return, refund and credit note
+// operations
+type ReturnRepository interface {
+	CreateReturn(ret *models.SalesReturn, req *models.CreateSalesReturnRequest, shiftID *int64) error
+	GetReturnByID(id int64) (*models.SalesReturn, error)
+	ListReturns(filter *models.SalesReturnFilter, offset, limit int) ([]models.SalesReturn, int64, error)
+
+	CreateRefund(refund *models.Refund) error
+	GetRefundByID(id int64) (*models.Refund, error)
+	ListRefunds(filter *models.RefundFilter, offset, limit int) ([]models.Refund, int64, error)
+
+	GetCreditNoteByID(id int64) (*models.CreditNote, error)
+	ListCreditNotes(filter *models.CreditNoteFilter, offset, limit int) ([]models.CreditNote, int64, error)
+	ApplyCreditNote(creditNoteID, transactionID int64, amount decimal.Decimal, appliedBy int64) (*models.CreditNoteApplication, error)
+
+	GetBalance(transactionID int64) (*models.TransactionBalance, error)
+}
+
+type returnRepository struct {
+	db *sqlx.DB
+}
+
+// NewReturnRepository creates a new return repository
+func NewReturnRepository(db *sqlx.DB) ReturnRepository {
+	return &returnRepository{db: db}
+}
+
+// salesTransaction is the part of a transaction returns and refunds work on
+type salesTransaction struct {
+	TransactionID     int64           `db:"transaction_id"`
+	TransactionNumber string          `db:"transaction_number"`
+	TransactionType   string          `db:"transaction_type"`
+	OutletID          int64           `db:"outlet_id"`
+	CustomerID        *int64          `db:"customer_id"`
+	SubtotalAmount    decimal.Decimal `db:"subtotal_amount"`
+	DiscountAmount    decimal.Decimal `db:"discount_amount"`
+	TaxAmount         decimal.Decimal `db:"tax_amount"`
+	TotalAmount       decimal.Decimal `db:"total_amount"`
+	PaymentStatus     string          `db:"payment_status"`
+	ApprovalStatus    string          `db:"approval_status"`
+}
+
+// lockSalesTransaction locks a transaction for a change to what it is owed
+// or what was paid towards it. Payments take the same lock.
+func lockSalesTransaction(tx *sqlx.Tx, transactionID int64) (*salesTransaction, error) {
+	var transaction salesTransaction
+	err := tx.Get(&transaction, `
+		SELECT transaction_id, transaction_number, transaction_type, outlet_id, customer_id,
+			subtotal_amount, discount_amount, tax_amount, total_amount, payment_status, approval_status
+		FROM transactions WHERE transaction_id = $1 AND deleted_at IS NULL FOR UPDATE
+	`, transactionID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("transaction not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if transaction.PaymentStatus == "cancelled" || transaction.PaymentStatus == "void" {
+		return nil, fmt.Errorf("transaction %s is %s", transaction.TransactionNumber, transaction.PaymentStatus)
+	}
+	if transaction.TransactionType == "vehicle_purchase" {
+		return nil, fmt.Errorf("vehicle purchase transactions cannot be returned or refunded")
+	}
+
+	return &transaction, nil
+}
+
+// transactionBalance computes what a transaction is owed after returns and
+// what has been paid towards it after refunds and store credit
+func transactionBalance(q sqlx.Queryer, transactionID int64) (*models.TransactionBalance, error) {
+	var balance models.TransactionBalance
+	err := sqlx.Get(q, &balance, `
+		SELECT t.transaction_id, t.transaction_number, t.outlet_id, t.customer_id, t.total_amount, t.payment_status,
+			COALESCE((SELECT SUM(total_amount) FROM sales_returns WHERE transaction_id = t.transaction_id), 0) AS returned_amount,
+			COALESCE((SELECT SUM(amount) FROM payments WHERE transaction_id = t.transaction_id AND deleted_at IS NULL), 0) AS paid_amount,
+			COALESCE((SELECT SUM(amount) FROM refunds WHERE transaction_id = t.transaction_id), 0) AS refunded_amount,
+			COALESCE((SELECT SUM(amount) FROM credit_notes WHERE transaction_id = t.transaction_id), 0) AS credit_issued,
+			COALESCE((SELECT SUM(amount) FROM credit_note_applications WHERE transaction_id = t.transaction_id AND reversed_at IS NULL), 0) AS credit_applied
+		FROM transactions t
+		WHERE t.transaction_id = $1 AND t.deleted_at IS NULL
+	`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction balance: %w", err)
+	}
+
+	balance.NetAmount = balance.TotalAmount.Sub(balance.ReturnedAmount)
+	balance.NetPaid = balance.PaidAmount.Sub(balance.RefundedAmount).Sub(balance.CreditIssued).Add(balance.CreditApplied)
+	balance.Outstanding = balance.NetAmount.Sub(balance.NetPaid)
+
+	return &balance, nil
+}
+
+// balancePaymentStatus derives a transaction's payment status from its balance
+func balancePaymentStatus(balance *models.TransactionBalance) string {
+	switch {
+	case balance.PaymentStatus == "cancelled" || balance.PaymentStatus == "void":
+		return balance.PaymentStatus
+	case balance.ReturnedAmount.IsPositive() && !balance.NetAmount.IsPositive():
+		return "refunded"
+	case balance.NetPaid.GreaterThanOrEqual(balance.NetAmount):
+		return "paid"
+	case balance.NetPaid.IsPositive():
+		return "partial"
+	default:
+		return "pending"
+	}
+}
+
+// updatePaymentStatus recalculates a transaction's payment status from its
+// payments, refunds, returns and store credit. Every change to any of them
+// goes through here, under the transaction lock.
+func updatePaymentStatus(tx *sqlx.Tx, transactionID int64) (*models.TransactionBalance, error) {
+	balance, err := transactionBalance(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := balancePaymentStatus(balance)
+	if status != balance.PaymentStatus {
+		_, err = tx.Exec(`
+			UPDATE transactions SET payment_status = $2, updated_at = CURRENT_TIMESTAMP WHERE transaction_id = $1
+		`, transactionID, status)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update payment status: %w", err)
+		}
+		balance.PaymentStatus = status
+	}
+
+	return balance, nil
+}
+
+// insertRefund records a refund and the cash paid out for it as part of tx
+func insertRefund(tx *sqlx.Tx, refund *models.Refund, transaction *salesTransaction) error {
+	if refund.ShiftID != nil {
+		if err := lockOpenShift(tx, *refund.ShiftID, transaction.OutletID); err != nil {
+			return err
+		}
+	}
+
+	err := tx.QueryRow(`
+		INSERT INTO refunds (refund_number, transaction_id, payment_id, return_id, payment_method_id, amount,
+			reason, refund_date, reference_number, notes, shift_id, created_by)
+		VALUES ('RF' || to_char(CURRENT_DATE, 'YYYYMMDD') || '-' || LPAD(nextval('refund_number_seq')::text, 4, '0'),
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING refund_id, refund_number, created_at
+	`, refund.TransactionID, refund.PaymentID, refund.ReturnID, refund.PaymentMethodID, refund.Amount,
+		refund.Reason, refund.RefundDate, refund.ReferenceNumber, refund.Notes, refund.ShiftID, refund.CreatedBy).
+		Scan(&refund.RefundID, &refund.RefundNumber, &refund.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refund: %w", err)
+	}
+	refund.TransactionNumber = transaction.TransactionNumber
+	refund.OutletID = transaction.OutletID
+
+	// Refunds to points or stored value go back to what the customer holds
+	methodType, err := paymentMethodType(tx, refund.PaymentMethodID)
+	if err != nil {
+		return err
+	}
+	switch methodType {
+	case "loyalty_points":
+		return refundPoints(tx, refund, transaction)
+	case "stored_value":
+		return refundStoredValue(tx, refund, transaction)
+	}
+
+	referenceType := "refund"
+	return recordCashFlow(tx, &models.CashFlow{
+		OutletID:        transaction.OutletID,
+		FlowType:        "outflow",
+		Category:        "refund",
+		Amount:          refund.Amount,
+		Description:     fmt.Sprintf("Refund %s for %s", refund.RefundNumber, transaction.TransactionNumber),
+		ReferenceType:   &referenceType,
+		ReferenceID:     &refund.RefundID,
+		PaymentMethodID: &refund.PaymentMethodID,
+		ShiftID:         refund.ShiftID,
+		TransactionDate: refund.RefundDate,
+		CreatedBy:       refund.CreatedBy,
+	})
+}
+
+func appendRefundIssued(tx *sqlx.Tx, refund *models.Refund, transaction *salesTransaction, status string) error {
+	return appendEvent(tx, events.RefundIssued, "refund", refund.RefundID, &transaction.OutletID, events.RefundIssuedPayload{
+		RefundID:          refund.RefundID,
+		RefundNumber:      refund.RefundNumber,
+		TransactionID:     transaction.TransactionID,
+		TransactionNumber: transaction.TransactionNumber,
+		PaymentID:         refund.PaymentID,
+		ReturnID:          refund.ReturnID,
+		OutletID:          transaction.OutletID,
+		CustomerID:        transaction.CustomerID,
+		PaymentMethodID:   refund.PaymentMethodID,
+		Amount:            refund.Amount.StringFixed(2),
+		Reason:            refund.Reason,
+		PaymentStatus:     status,
+	})
+}
+
+// returnableLine is a transaction line with what is left to return of it
+type returnableLine struct {
+	DetailID         int64           `db:"detail_id"`
+	ProductID        *int64          `db:"product_id"`
+	ProductName      string          `db:"product_name"`
+	Quantity         decimal.Decimal `db:"quantity"`
+	UnitPrice        decimal.Decimal `db:"unit_price"`
+	CostPrice        decimal.Decimal `db:"cost_price"`
+	TaxCodeID        *int64          `db:"tax_code_id"`
+	TaxRate          decimal.Decimal `db:"tax_rate"`
+	NetAmount        decimal.Decimal `db:"net_amount"`
+	DiscountAmount   decimal.Decimal `db:"discount_amount"`
+	TaxAmount        decimal.Decimal `db:"tax_amount"`
+	StockTaken       bool            `db:"stock_taken"`
+	ReturnedQuantity decimal.Decimal `db:"returned_quantity"`
+	ReturnedNet      decimal.Decimal `db:"returned_net"`
+	ReturnedDiscount decimal.Decimal `db:"returned_discount"`
+	ReturnedTax      decimal.Decimal `db:"returned_tax"`
+}
+
+// share is the part of a line amount that goes with the returned quantity.
+// Returning the last units of a line takes what is left of it.
+func (l *returnableLine) share(amount, returned, quantity decimal.Decimal) decimal.Decimal {
+	if quantity.Equal(l.Quantity.Sub(l.ReturnedQuantity)) {
+		return amount.Sub(returned)
+	}
+	return amount.Mul(quantity).Div(l.Quantity).Round(2)
+}
+
+// CreateReturn records returned items, restocks them and settles what the
+// customer paid for them by refund or credit note, in one database
+// transaction. Each item takes its share of its line's value, discount and
+// tax, so it is refunded at the rate it was taxed at; the last return of a
+// transaction takes what is left so the shares add up exactly.
+func (r *returnRepository) CreateReturn(ret *models.SalesReturn, req *models.CreateSalesReturnRequest, shiftID *int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	transaction, err := lockSalesTransaction(tx, req.TransactionID)
+	if err != nil {
+		return err
+	}
+	if transaction.TransactionType == "vehicle_sale" {
+		return fmt.Errorf("vehicle sales cannot be returned here")
+	}
+
+	before, err := transactionBalance(tx, transaction.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	ret.Items = nil
+	ret.SubtotalAmount = decimal.Zero
+	ret.DiscountAmount = decimal.Zero
+	ret.TaxAmount = decimal.Zero
+	ret.CostAmount = decimal.Zero
+	// Only lines the sale took from stock go back to it
+	stockTaken := make(map[int64]bool)
+	for _, item := range req.Items {
+		var line returnableLine
+		err = tx.Get(&line, `
+			SELECT d.detail_id, d.product_id, COALESCE(p.name, '') AS product_name, d.quantity, d.unit_price,
+				COALESCE(d.unit_cost, p.cost_price, 0) AS cost_price, d.tax_code_id, d.tax_rate, d.net_amount, d.discount_amount,
+				d.tax_amount, d.stock_taken, COALESCE(ri.quantity, 0) AS returned_quantity, COALESCE(ri.net, 0) AS returned_net,
+				COALESCE(ri.discount, 0) AS returned_discount, COALESCE(ri.tax, 0) AS returned_tax
+			FROM transaction_details d
+			LEFT JOIN products p ON p.product_id = d.product_id
+			LEFT JOIN (
+				SELECT transaction_detail_id, SUM(quantity) AS quantity, SUM(net_amount) AS net,
+					SUM(discount_amount) AS discount, SUM(tax_amount) AS tax
+				FROM sales_return_items GROUP BY transaction_detail_id
+			) ri ON ri.transaction_detail_id = d.detail_id
+			WHERE d.detail_id = $1 AND d.transaction_id = $2 AND d.deleted_at IS NULL
+		`, item.TransactionDetailID, transaction.TransactionID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("line %d is not part of transaction %s", item.TransactionDetailID, transaction.TransactionNumber)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get transaction line: %w", err)
+		}
+		if line.ProductID == nil {
+			return fmt.Errorf("line %d is not a sparepart and cannot be returned", line.DetailID)
+		}
+		if item.Quantity.GreaterThan(line.Quantity.Sub(line.ReturnedQuantity)) {
+			return fmt.Errorf("only %s of %s can still be returned", line.Quantity.Sub(line.ReturnedQuantity).String(), line.ProductName)
+		}
+
+		returned := models.SalesReturnItem{
+			TransactionDetailID: line.DetailID,
+			ProductID:           *line.ProductID,
+			ProductName:         line.ProductName,
+			Quantity:            item.Quantity,
+			UnitPrice:           line.UnitPrice,
+			TotalPrice:          item.Quantity.Mul(line.UnitPrice).Round(2),
+			TaxCodeID:           line.TaxCodeID,
+			TaxRate:             line.TaxRate,
+			NetAmount:           line.share(line.NetAmount, line.ReturnedNet, item.Quantity),
+			DiscountAmount:      line.share(line.DiscountAmount, line.ReturnedDiscount, item.Quantity),
+			TaxAmount:           line.share(line.TaxAmount, line.ReturnedTax, item.Quantity),
+			UnitCost:            line.CostPrice,
+		}
+		ret.Items = append(ret.Items, returned)
+		stockTaken[line.DetailID] = line.StockTaken
+		ret.SubtotalAmount = ret.SubtotalAmount.Add(returned.NetAmount)
+		ret.DiscountAmount = ret.DiscountAmount.Add(returned.DiscountAmount)
+		ret.TaxAmount = ret.TaxAmount.Add(returned.TaxAmount)
+		ret.CostAmount = ret.CostAmount.Add(item.Quantity.Mul(line.CostPrice).Round(2))
+	}
+
+	var prior struct {
+		Subtotal decimal.Decimal `db:"subtotal"`
+		Discount decimal.Decimal `db:"discount"`
+		Tax      decimal.Decimal `db:"tax"`
+		Total    decimal.Decimal `db:"total"`
+	}
+	err = tx.Get(&prior, `
+		SELECT COALESCE(SUM(subtotal_amount), 0) AS subtotal, COALESCE(SUM(discount_amount), 0) AS discount,
+			COALESCE(SUM(tax_amount), 0) AS tax, COALESCE(SUM(total_amount), 0) AS total
+		FROM sales_returns WHERE transaction_id = $1
+	`, transaction.TransactionID)
+	if err != nil {
+		return fmt.Errorf("failed to get previous returns: %w", err)
+	}
+
+	// Lines of earlier transactions were given proportional shares of the
+	// header, which may be a cent off once everything is returned
+	if prior.Subtotal.Add(ret.SubtotalAmount).GreaterThanOrEqual(transaction.SubtotalAmount) {
+		ret.DiscountAmount = transaction.DiscountAmount.Sub(prior.Discount)
+		ret.TaxAmount = transaction.TaxAmount.Sub(prior.Tax)
+	}
+	ret.TotalAmount = ret.SubtotalAmount.Sub(ret.DiscountAmount).Add(ret.TaxAmount)
+	if remaining := transaction.TotalAmount.Sub(prior.Total); ret.TotalAmount.GreaterThan(remaining) {
+		ret.TotalAmount = remaining
+	}
+
+	// What the customer paid beyond the new amount owed goes back to them
+	settleAmount := before.NetPaid.Sub(before.NetAmount.Sub(ret.TotalAmount))
+	if settleAmount.GreaterThan(ret.TotalAmount) {
+		settleAmount = ret.TotalAmount
+	}
+	ret.Settlement = "none"
+	if settleAmount.IsPositive() {
+		switch req.Settlement {
+		case "refund":
+			if req.PaymentMethodID == nil {
+				return fmt.Errorf("payment method is required for a refund")
+			}
+		case "credit_note":
+			if transaction.CustomerID == nil {
+				return fmt.Errorf("a credit note needs a transaction with a customer")
+			}
+		default:
+			return fmt.Errorf("%s was paid for the returned items; settle it by refund or credit_note", settleAmount.StringFixed(2))
+		}
+		ret.Settlement = req.Settlement
+	}
+
+	ret.TransactionID = transaction.TransactionID
+	ret.TransactionNumber = transaction.TransactionNumber
+	ret.OutletID = transaction.OutletID
+	ret.CustomerID = transaction.CustomerID
+	err = tx.QueryRow(`
+		INSERT INTO sales_returns (return_number, transaction_id, outlet_id, customer_id, reason, restock,
+			subtotal_amount, discount_amount, tax_amount, total_amount, cost_amount, settlement, return_date,
+			notes, created_by)
+		VALUES ('RT' || to_char(CURRENT_DATE, 'YYYYMMDD') || '-' || LPAD(nextval('sales_return_number_seq')::text, 4, '0'),
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING return_id, return_number, created_at
+	`, ret.TransactionID, ret.OutletID, ret.CustomerID, ret.Reason, ret.Restock, ret.SubtotalAmount,
+		ret.DiscountAmount, ret.TaxAmount, ret.TotalAmount, ret.CostAmount, ret.Settlement, ret.ReturnDate,
+		ret.Notes, ret.CreatedBy).Scan(&ret.ReturnID, &ret.ReturnNumber, &ret.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create return: %w", err)
+	}
+
+	for i := range ret.Items {
+		item := &ret.Items[i]
+		item.ReturnID = ret.ReturnID
+		err = tx.QueryRow(`
+			INSERT INTO sales_return_items (return_id, transaction_detail_id, product_id, quantity, unit_price,
+				total_price, tax_code_id, tax_rate, net_amount, discount_amount, tax_amount, unit_cost)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			RETURNING return_item_id
+		`, item.ReturnID, item.TransactionDetailID, item.ProductID, item.Quantity, item.UnitPrice,
+			item.TotalPrice, item.TaxCodeID, item.TaxRate, item.NetAmount, item.DiscountAmount, item.TaxAmount,
+			item.UnitCost).Scan(&item.ReturnItemID)
+		if err != nil {
+			return fmt.Errorf("failed to create return item: %w", err)
+		}
+
+		if ret.Restock && stockTaken[item.TransactionDetailID] {
+			if err = adjustStock(tx, item.ProductID, int(item.Quantity.IntPart()), "add"); err != nil {
+				return fmt.Errorf("failed to restock product: %w", err)
+			}
+		}
+	}
+
+	switch ret.Settlement {
+	case "refund":
+		ret.Refund = &models.Refund{
+			TransactionID:   transaction.TransactionID,
+			ReturnID:        &ret.ReturnID,
+			PaymentMethodID: *req.PaymentMethodID,
+			Amount:          settleAmount,
+			Reason:          ret.Reason,
+			RefundDate:      ret.ReturnDate,
+			ShiftID:         shiftID,
+			CreatedBy:       ret.CreatedBy,
+		}
+		if err = insertRefund(tx, ret.Refund, transaction); err != nil {
+			return err
+		}
+	case "credit_note":
+		ret.CreditNote = &models.CreditNote{
+			CustomerID:        *transaction.CustomerID,
+			OutletID:          transaction.OutletID,
+			TransactionID:     transaction.TransactionID,
+			TransactionNumber: transaction.TransactionNumber,
+			ReturnID:          &ret.ReturnID,
+			Amount:            settleAmount,
+			RemainingAmount:   settleAmount,
+			Status:            "open",
+			Reason:            ret.Reason,
+			IssueDate:         ret.ReturnDate,
+			CreatedBy:         ret.CreatedBy,
+		}
+		err = tx.QueryRow(`
+			INSERT INTO credit_notes (credit_note_number, customer_id, outlet_id, transaction_id, return_id, amount,
+				remaining_amount, reason, issue_date, created_by)
+			VALUES ('CN' || to_char(CURRENT_DATE, 'YYYYMMDD') || '-' || LPAD(nextval('credit_note_number_seq')::text, 4, '0'),
+				$1, $2, $3, $4, $5, $5, $6, $7, $8)
+			RETURNING credit_note_id, credit_note_number, created_at, updated_at
+		`, ret.CreditNote.CustomerID, ret.CreditNote.OutletID, ret.CreditNote.TransactionID, ret.CreditNote.ReturnID,
+			ret.CreditNote.Amount, ret.CreditNote.Reason, ret.CreditNote.IssueDate, ret.CreditNote.CreatedBy).
+			Scan(&ret.CreditNote.CreditNoteID, &ret.CreditNote.CreditNoteNumber, &ret.CreditNote.CreatedAt, &ret.CreditNote.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create credit note: %w", err)
+		}
+	}
+
+	balance, err := updatePaymentStatus(tx, transaction.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	err = appendEvent(tx, events.SalesReturned, "sales_return", ret.ReturnID, &ret.OutletID, events.SalesReturnedPayload{
+		ReturnID:          ret.ReturnID,
+		ReturnNumber:      ret.ReturnNumber,
+		TransactionID:     ret.TransactionID,
+		TransactionNumber: ret.TransactionNumber,
+		OutletID:          ret.OutletID,
+		CustomerID:        ret.CustomerID,
+		TotalAmount:       ret.TotalAmount.StringFixed(2),
+		Restock:           ret.Restock,
+		Settlement:        ret.Settlement,
+		PaymentStatus:     balance.PaymentStatus,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ret.Refund != nil {
+		if err = appendRefundIssued(tx, ret.Refund, transaction, balance.PaymentStatus); err != nil {
+			return err
+		}
+	}
+	if ret.CreditNote != nil {
+		note := ret.CreditNote
+		err = appendEvent(tx, events.CreditNoteIssued, "credit_note", note.CreditNoteID, &note.OutletID, events.CreditNoteIssuedPayload{
+			CreditNoteID:     note.CreditNoteID,
+			CreditNoteNumber: note.CreditNoteNumber,
+			CustomerID:       note.CustomerID,
+			OutletID:         note.OutletID,
+			TransactionID:    note.TransactionID,
+			ReturnID:         note.ReturnID,
+			Amount:           note.Amount.StringFixed(2),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+const salesReturnColumns = `
+	sr.return_id, sr.return_number, sr.transaction_id, t.transaction_number, sr.outlet_id, sr.customer_id,
+	sr.reason, sr.restock, sr.subtotal_amount, sr.discount_amount, sr.tax_amount, sr.total_amount,
+	sr.cost_amount, sr.settlement, sr.return_date, sr.notes, sr.created_at, sr.created_by
+`
+
+func (r *returnRepository) GetReturnByID(id int64) (*models.SalesReturn, error) {
+	var ret models.SalesReturn
+	err := r.db.Get(&ret, `
+		SELECT `+salesReturnColumns+`
+		FROM sales_returns sr
+		JOIN transactions t ON t.transaction_id = sr.transaction_id
+		WHERE sr.return_id = $1
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get return: %w", err)
+	}
+
+	err = r.db.Select(&ret.Items, `
+		SELECT ri.return_item_id, ri.return_id, ri.transaction_detail_id, ri.product_id, p.name AS product_name,
+			ri.quantity, ri.unit_price, ri.total_price, ri.tax_code_id, ri.tax_rate, ri.net_amount,
+			ri.discount_amount, ri.tax_amount, ri.unit_cost
+		FROM sales_return_items ri
+		JOIN products p ON p.product_id = ri.product_id
+		WHERE ri.return_id = $1
+		ORDER BY ri.return_item_id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get return items: %w", err)
+	}
+
+	switch ret.Settlement {
+	case "refund":
+		var refundID int64
+		if err = r.db.Get(&refundID, `SELECT refund_id FROM refunds WHERE return_id = $1`, id); err == nil {
+			ret.Refund, err = r.GetRefundByID(refundID)
+		}
+	case "credit_note":
+		var creditNoteID int64
+		if err = r.db.Get(&creditNoteID, `SELECT credit_note_id FROM credit_notes WHERE return_id = $1`, id); err == nil {
+			ret.CreditNote, err = r.GetCreditNoteByID(creditNoteID)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get return settlement: %w", err)
+	}
+
+	return &ret, nil
+}
+
+func (r *returnRepository) ListReturns(filter *models.SalesReturnFilter, offset, limit int) ([]models.SalesReturn, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("sr.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.TransactionID != nil {
+		conditions = append(conditions, fmt.Sprintf("sr.transaction_id = $%d", argIndex))
+		args = append(args, *filter.TransactionID)
+		argIndex++
+	}
+
+	if filter.CustomerID != nil {
+		conditions = append(conditions, fmt.Sprintf("sr.customer_id = $%d", argIndex))
+		args = append(args, *filter.CustomerID)
+		argIndex++
+	}
+
+	if filter.DateFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("sr.return_date::date >= $%d", argIndex))
+		args = append(args, filter.DateFrom.Format("2006-01-02"))
+		argIndex++
+	}
+
+	if filter.DateTo != nil {
+		conditions = append(conditions, fmt.Sprintf("sr.return_date::date <= $%d", argIndex))
+		args = append(args, filter.DateTo.Format("2006-01-02"))
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM sales_returns sr `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count returns: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM sales_returns sr
+		JOIN transactions t ON t.transaction_id = sr.transaction_id
+		%s
+		ORDER BY sr.return_date DESC, sr.return_id DESC
+		LIMIT $%d OFFSET $%d
+	`, salesReturnColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var returns []models.SalesReturn
+	err = r.db.Select(&returns, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list returns: %w", err)
+	}
+
+	return returns, total, nil
+}
+
+// CreateRefund pays money back against a transaction, optionally against
+// one of its payments. A refund can never exceed what the customer has
+// paid, net of earlier refunds and store credit.
+func (r *returnRepository) CreateRefund(refund *models.Refund) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	transaction, err := lockSalesTransaction(tx, refund.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	if refund.PaymentID != nil {
+		var payment struct {
+			TransactionID   int64           `db:"transaction_id"`
+			PaymentMethodID int64           `db:"payment_method_id"`
+			Amount          decimal.Decimal `db:"amount"`
+			Refunded        decimal.Decimal `db:"refunded"`
+		}
+		err = tx.Get(&payment, `
+			SELECT p.transaction_id, p.payment_method_id, p.amount,
+				COALESCE((SELECT SUM(rf.amount) FROM refunds rf WHERE rf.payment_id = p.payment_id), 0) AS refunded
+			FROM payments p
+			WHERE p.payment_id = $1 AND p.deleted_at IS NULL
+		`, *refund.PaymentID)
+		if err != nil || payment.TransactionID != transaction.TransactionID {
+			return fmt.Errorf("payment is not part of transaction %s", transaction.TransactionNumber)
+		}
+		if refund.Amount.GreaterThan(payment.Amount.Sub(payment.Refunded)) {
+			return fmt.Errorf("only %s of the payment can still be refunded", payment.Amount.Sub(payment.Refunded).StringFixed(2))
+		}
+		if refund.PaymentMethodID == 0 {
+			refund.PaymentMethodID = payment.PaymentMethodID
+		}
+	}
+	if refund.PaymentMethodID == 0 {
+		return fmt.Errorf("payment method is required")
+	}
+
+	balance, err := transactionBalance(tx, transaction.TransactionID)
+	if err != nil {
+		return err
+	}
+	if refund.Amount.GreaterThan(balance.NetPaid) {
+		return fmt.Errorf("only %s has been paid and can be refunded", balance.NetPaid.StringFixed(2))
+	}
+
+	if err = insertRefund(tx, refund, transaction); err != nil {
+		return err
+	}
+
+	balance, err = updatePaymentStatus(tx, transaction.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	if err = appendRefundIssued(tx, refund, transaction, balance.PaymentStatus); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+const refundColumns = `
+	rf.refund_id, rf.refund_number, rf.transaction_id, t.transaction_number, t.outlet_id, rf.payment_id, rf.return_id,
+	rf.payment_method_id, pm.name AS payment_method_name, rf.amount, rf.reason, rf.refund_date,
+	rf.reference_number, rf.notes, rf.shift_id, rf.created_at, rf.created_by
+`
+
+func (r *returnRepository) GetRefundByID(id int64) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.Get(&refund, `
+		SELECT `+refundColumns+`
+		FROM refunds rf
+		JOIN transactions t ON t.transaction_id = rf.transaction_id
+		JOIN payment_methods pm ON pm.method_id = rf.payment_method_id
+		WHERE rf.refund_id = $1
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refund: %w", err)
+	}
+
+	return &refund, nil
+}
+
+func (r *returnRepository) ListRefunds(filter *models.RefundFilter, offset, limit int) ([]models.Refund, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("t.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.TransactionID != nil {
+		conditions = append(conditions, fmt.Sprintf("rf.transaction_id = $%d", argIndex))
+		args = append(args, *filter.TransactionID)
+		argIndex++
+	}
+
+	if filter.PaymentID != nil {
+		conditions = append(conditions, fmt.Sprintf("rf.payment_id = $%d", argIndex))
+		args = append(args, *filter.PaymentID)
+		argIndex++
+	}
+
+	if filter.DateFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("rf.refund_date::date >= $%d", argIndex))
+		args = append(args, filter.DateFrom.Format("2006-01-02"))
+		argIndex++
+	}
+
+	if filter.DateTo != nil {
+		conditions = append(conditions, fmt.Sprintf("rf.refund_date::date <= $%d", argIndex))
+		args = append(args, filter.DateTo.Format("2006-01-02"))
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `
+		SELECT COUNT(*) FROM refunds rf JOIN transactions t ON t.transaction_id = rf.transaction_id `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count refunds: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM refunds rf
+		JOIN transactions t ON t.transaction_id = rf.transaction_id
+		JOIN payment_methods pm ON pm.method_id = rf.payment_method_id
+		%s
+		ORDER BY rf.refund_date DESC, rf.refund_id DESC
+		LIMIT $%d OFFSET $%d
+	`, refundColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var refunds []models.Refund
+	err = r.db.Select(&refunds, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	return refunds, total, nil
+}
+
+const creditNoteColumns = `
+	cn.credit_note_id, cn.credit_note_number, cn.customer_id, c.name AS customer_name, cn.outlet_id,
+	cn.transaction_id, t.transaction_number, cn.return_id, cn.amount, cn.remaining_amount, cn.status,
+	cn.reason, cn.issue_date, cn.created_at, cn.updated_at, cn.created_by
+`
+
+func (r *returnRepository) GetCreditNoteByID(id int64) (*models.CreditNote, error) {
+	var note models.CreditNote
+	err := r.db.Get(&note, `
+		SELECT `+creditNoteColumns+`
+		FROM credit_notes cn
+		JOIN customers c ON c.customer_id = cn.customer_id
+		JOIN transactions t ON t.transaction_id = cn.transaction_id
+		WHERE cn.credit_note_id = $1
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit note: %w", err)
+	}
+
+	err = r.db.Select(&note.Applications, `
+		SELECT a.application_id, a.credit_note_id, a.transaction_id, t.transaction_number, a.amount,
+			a.applied_at, a.applied_by
+		FROM credit_note_applications a
+		JOIN transactions t ON t.transaction_id = a.transaction_id
+		WHERE a.credit_note_id = $1
+		ORDER BY a.applied_at
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit note applications: %w", err)
+	}
+
+	return &note, nil
+}
+
+func (r *returnRepository) ListCreditNotes(filter *models.CreditNoteFilter, offset, limit int) ([]models.CreditNote, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("cn.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.CustomerID != nil {
+		conditions = append(conditions, fmt.Sprintf("cn.customer_id = $%d", argIndex))
+		args = append(args, *filter.CustomerID)
+		argIndex++
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("cn.status = $%d", argIndex))
+		args = append(args, filter.Status)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM credit_notes cn `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count credit notes: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM credit_notes cn
+		JOIN customers c ON c.customer_id = cn.customer_id
+		JOIN transactions t ON t.transaction_id = cn.transaction_id
+		%s
+		ORDER BY cn.issue_date DESC, cn.credit_note_id DESC
+		LIMIT $%d OFFSET $%d
+	`, creditNoteColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var notes []models.CreditNote
+	err = r.db.Select(&notes, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list credit notes: %w", err)
+	}
+
+	return notes, total, nil
+}
+
+// ApplyCreditNote pays a transaction of the same customer with store credit.
+// A zero amount applies as much as the credit and the transaction allow.
+func (r *returnRepository) ApplyCreditNote(creditNoteID, transactionID int64, amount decimal.Decimal, appliedBy int64) (*models.CreditNoteApplication, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	transaction, err := lockSalesTransaction(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var note struct {
+		CreditNoteNumber string          `db:"credit_note_number"`
+		CustomerID       int64           `db:"customer_id"`
+		RemainingAmount  decimal.Decimal `db:"remaining_amount"`
+		Status           string          `db:"status"`
+	}
+	err = tx.Get(&note, `
+		SELECT credit_note_number, customer_id, remaining_amount, status
+		FROM credit_notes WHERE credit_note_id = $1 FOR UPDATE
+	`, creditNoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit note: %w", err)
+	}
+	if note.Status != "open" || !note.RemainingAmount.IsPositive() {
+		return nil, fmt.Errorf("credit note %s has been used up", note.CreditNoteNumber)
+	}
+	if transaction.ApprovalStatus != "approved" {
+		return nil, fmt.Errorf("transaction %s is not approved", transaction.TransactionNumber)
+	}
+	if transaction.CustomerID == nil || *transaction.CustomerID != note.CustomerID {
+		return nil, fmt.Errorf("credit note %s belongs to another customer", note.CreditNoteNumber)
+	}
+
+	balance, err := transactionBalance(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if !balance.Outstanding.IsPositive() {
+		return nil, fmt.Errorf("transaction %s has nothing outstanding", transaction.TransactionNumber)
+	}
+
+	if amount.IsZero() {
+		amount = decimal.Min(note.RemainingAmount, balance.Outstanding)
+	}
+	if amount.GreaterThan(note.RemainingAmount) {
+		return nil, fmt.Errorf("only %s credit is left on %s", note.RemainingAmount.StringFixed(2), note.CreditNoteNumber)
+	}
+	if amount.GreaterThan(balance.Outstanding) {
+		return nil, fmt.Errorf("only %s is outstanding on %s", balance.Outstanding.StringFixed(2), transaction.TransactionNumber)
+	}
+
+	application := &models.CreditNoteApplication{
+		CreditNoteID:      creditNoteID,
+		TransactionID:     transactionID,
+		TransactionNumber: transaction.TransactionNumber,
+		Amount:            amount,
+		AppliedBy:         &appliedBy,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO credit_note_applications (credit_note_id, transaction_id, amount, applied_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING application_id, applied_at
+	`, creditNoteID, transactionID, amount, appliedBy).Scan(&application.ApplicationID, &application.AppliedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply credit note: %w", err)
+	}
+
+	remaining := note.RemainingAmount.Sub(amount)
+	_, err = tx.Exec(`
+		UPDATE credit_notes
+		SET remaining_amount = $2, status = CASE WHEN $2 = 0 THEN 'applied' ELSE 'open' END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE credit_note_id = $1
+	`, creditNoteID, remaining)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update credit note: %w", err)
+	}
+
+	balance, err = updatePaymentStatus(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = appendEvent(tx, events.CreditNoteApplied, "credit_note", creditNoteID, &transaction.OutletID, events.CreditNoteAppliedPayload{
+		ApplicationID:     application.ApplicationID,
+		CreditNoteID:      creditNoteID,
+		CreditNoteNumber:  note.CreditNoteNumber,
+		CustomerID:        note.CustomerID,
+		TransactionID:     transactionID,
+		TransactionNumber: transaction.TransactionNumber,
+		OutletID:          transaction.OutletID,
+		Amount:            amount.StringFixed(2),
+		RemainingCredit:   remaining.StringFixed(2),
+		PaymentStatus:     balance.PaymentStatus,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return application, nil
+}
+
+func (r *returnRepository) GetBalance(transactionID int64) (*models.TransactionBalance, error) {
+	return transactionBalance(r.db, transactionID)
+}
@@ -0,0 +1,194 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SchedulerRepository interface defines background job locking and run history operations
+type SchedulerRepository interface {
+	TryLock(ctx context.Context, key int64) (func(), bool, error)
+	StartRun(jobName string, scheduledFor time.Time, trigger, instance string) (int64, bool, error)
+	FinishRun(runID int64, status, summary, errorMessage string, duration time.Duration) error
+	GetLastRuns() (map[string]models.SchedulerRun, error)
+	ListRuns(filter *models.SchedulerRunFilter, offset, limit int) ([]models.SchedulerRun, int64, error)
+	PurgeRuns(before time.Time) (int64, error)
+}
+
+type schedulerRepository struct {
+	db *sqlx.DB
+}
+
+// NewSchedulerRepository creates a new scheduler repository
+func NewSchedulerRepository(db *sqlx.DB) SchedulerRepository {
+	return &schedulerRepository{db: db}
+}
+
+const schedulerRunColumns = `
+	run_id, job_name, trigger, scheduled_for, instance, status, summary, error,
+	started_at, finished_at, duration_ms
+`
+
+// TryLock takes a session-level advisory lock on a dedicated connection,
+// which stays checked out of the pool until the lock is released. The lock
+// is also released by Postgres if the process dies.
+func (r *schedulerRepository) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection for advisory lock: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		// The job's context may already be cancelled, so unlock without it
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			log.Printf("Warning: failed to release advisory lock %d: %v", key, err)
+			// Discard the connection so the session, and with it the lock, ends
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+
+	return release, true, nil
+}
+
+// StartRun records a run. Scheduled runs are unique per job and scheduled
+// time, so started is false when another replica already claimed the run.
+func (r *schedulerRepository) StartRun(jobName string, scheduledFor time.Time, trigger, instance string) (int64, bool, error) {
+	query := `
+		INSERT INTO scheduler_runs (job_name, trigger, scheduled_for, instance, status)
+		VALUES ($1, $2, $3, $4, 'running')
+		ON CONFLICT (job_name, scheduled_for) WHERE trigger = 'schedule' DO NOTHING
+		RETURNING run_id
+	`
+
+	var runID int64
+	err := r.db.QueryRow(query, jobName, trigger, scheduledFor, instance).Scan(&runID)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to start scheduler run: %w", err)
+	}
+
+	return runID, true, nil
+}
+
+func (r *schedulerRepository) FinishRun(runID int64, status, summary, errorMessage string, duration time.Duration) error {
+	query := `
+		UPDATE scheduler_runs
+		SET status = $2, summary = NULLIF($3, ''), error = NULLIF($4, ''),
+			finished_at = CURRENT_TIMESTAMP, duration_ms = $5
+		WHERE run_id = $1
+	`
+
+	result, err := r.db.Exec(query, runID, status, summary, errorMessage, duration.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to finish scheduler run: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("scheduler run not found")
+	}
+
+	return nil
+}
+
+// GetLastRuns returns the most recent run of every job, keyed by job name
+func (r *schedulerRepository) GetLastRuns() (map[string]models.SchedulerRun, error) {
+	query := fmt.Sprintf(`
+		SELECT DISTINCT ON (job_name) %s
+		FROM scheduler_runs
+		ORDER BY job_name, started_at DESC, run_id DESC
+	`, schedulerRunColumns)
+
+	var runs []models.SchedulerRun
+	if err := r.db.Select(&runs, query); err != nil {
+		return nil, fmt.Errorf("failed to get last scheduler runs: %w", err)
+	}
+
+	lastRuns := make(map[string]models.SchedulerRun, len(runs))
+	for _, run := range runs {
+		lastRuns[run.JobName] = run
+	}
+
+	return lastRuns, nil
+}
+
+func (r *schedulerRepository) ListRuns(filter *models.SchedulerRunFilter, offset, limit int) ([]models.SchedulerRun, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.JobName != "" {
+		conditions = append(conditions, fmt.Sprintf("job_name = $%d", argIndex))
+		args = append(args, filter.JobName)
+		argIndex++
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, filter.Status)
+		argIndex++
+	}
+
+	if filter.Trigger != "" {
+		conditions = append(conditions, fmt.Sprintf("trigger = $%d", argIndex))
+		args = append(args, filter.Trigger)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM scheduler_runs `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count scheduler runs: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM scheduler_runs %s ORDER BY started_at DESC, run_id DESC LIMIT $%d OFFSET $%d`,
+		schedulerRunColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var runs []models.SchedulerRun
+	err = r.db.Select(&runs, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list scheduler runs: %w", err)
+	}
+
+	return runs, total, nil
+}
+
+// PurgeRuns deletes finished runs started before the cutoff
+func (r *schedulerRepository) PurgeRuns(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM scheduler_runs WHERE status <> 'running' AND started_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge scheduler runs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
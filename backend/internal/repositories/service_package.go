@@ -0,0 +1,416 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ServicePackageRepository defines data access for service packages and the
+// packages sold on transactions and service jobs
+type ServicePackageRepository interface {
+	List(filter *models.ServicePackageFilter, offset, limit int) ([]models.ServicePackage, int64, error)
+	GetByID(id int64) (*models.ServicePackage, error)
+	GetByCode(code string) (*models.ServicePackage, error)
+	Create(pkg *models.ServicePackage) error
+	Update(pkg *models.ServicePackage) error
+	ListComponents(packageID int64) ([]models.ServicePackageComponent, error)
+	GetTransactionPackages(transactionID int64) ([]models.DocumentPackage, error)
+	GetServiceJobPackages(serviceJobID int64) ([]models.DocumentPackage, error)
+	AddToServiceJob(pkg *models.DocumentPackage, details []models.ServiceDetail, userID int64) error
+	RemoveFromServiceJob(serviceJobID, id int64) error
+	GetSales(filter *models.PackageSalesFilter) ([]models.PackageSales, error)
+}
+
+type servicePackageRepository struct {
+	db *sqlx.DB
+}
+
+// NewServicePackageRepository creates a new service package repository
+func NewServicePackageRepository(db *sqlx.DB) ServicePackageRepository {
+	return &servicePackageRepository{db: db}
+}
+
+const servicePackageColumns = `
+	sp.package_id, sp.package_code, sp.name, sp.description, sp.pricing_type, sp.package_price,
+	sp.discount_percent, sp.outlet_id, sp.is_active, sp.created_at, sp.updated_at, sp.created_by
+`
+
+const documentPackageJoins = `
+	JOIN service_packages sp ON sp.package_id = dp.package_id
+`
+
+func (r *servicePackageRepository) List(filter *models.ServicePackageFilter, offset, limit int) ([]models.ServicePackage, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("(sp.outlet_id IS NULL OR sp.outlet_id = $%d)", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.ActiveOnly {
+		conditions = append(conditions, "sp.is_active = TRUE")
+	}
+
+	if filter.Search != "" {
+		conditions = append(conditions, fmt.Sprintf("(sp.name ILIKE $%d OR sp.package_code ILIKE $%d)", argIndex, argIndex))
+		args = append(args, "%"+filter.Search+"%")
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM service_packages sp `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count service packages: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM service_packages sp
+		%s
+		ORDER BY sp.name, sp.package_id
+		LIMIT $%d OFFSET $%d
+	`, servicePackageColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var packages []models.ServicePackage
+	if err = r.db.Select(&packages, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list service packages: %w", err)
+	}
+
+	return packages, total, nil
+}
+
+func (r *servicePackageRepository) GetByID(id int64) (*models.ServicePackage, error) {
+	return r.get(`sp.package_id = $1`, id)
+}
+
+// GetByCode looks a package up by its code, ignoring case
+func (r *servicePackageRepository) GetByCode(code string) (*models.ServicePackage, error) {
+	return r.get(`UPPER(sp.package_code) = UPPER($1)`, code)
+}
+
+func (r *servicePackageRepository) get(condition string, arg interface{}) (*models.ServicePackage, error) {
+	var pkg models.ServicePackage
+	err := r.db.Get(&pkg, `SELECT `+servicePackageColumns+` FROM service_packages sp WHERE `+condition, arg)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("service package not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service package: %w", err)
+	}
+
+	if pkg.Components, err = r.ListComponents(pkg.PackageID); err != nil {
+		return nil, err
+	}
+
+	return &pkg, nil
+}
+
+// Create adds a package with its components
+func (r *servicePackageRepository) Create(pkg *models.ServicePackage) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO service_packages (package_code, name, description, pricing_type, package_price,
+			discount_percent, outlet_id, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING package_id, created_at, updated_at
+	`, pkg.PackageCode, pkg.Name, pkg.Description, pkg.PricingType, pkg.PackagePrice, pkg.DiscountPercent,
+		pkg.OutletID, pkg.IsActive, pkg.CreatedBy).
+		Scan(&pkg.PackageID, &pkg.CreatedAt, &pkg.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create service package: %w", err)
+	}
+
+	if err = insertPackageComponents(tx, pkg); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Update changes a package and replaces its components. Packages already
+// sold keep the lines and prices they were sold with.
+func (r *servicePackageRepository) Update(pkg *models.ServicePackage) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE service_packages
+		SET package_code = $2, name = $3, description = $4, pricing_type = $5, package_price = $6,
+			discount_percent = $7, outlet_id = $8, is_active = $9, updated_at = CURRENT_TIMESTAMP
+		WHERE package_id = $1
+	`, pkg.PackageID, pkg.PackageCode, pkg.Name, pkg.Description, pkg.PricingType, pkg.PackagePrice,
+		pkg.DiscountPercent, pkg.OutletID, pkg.IsActive)
+	if err != nil {
+		return fmt.Errorf("failed to update service package: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("service package not found")
+	}
+
+	if _, err = tx.Exec(`DELETE FROM service_package_components WHERE package_id = $1`, pkg.PackageID); err != nil {
+		return fmt.Errorf("failed to replace package components: %w", err)
+	}
+	if err = insertPackageComponents(tx, pkg); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func insertPackageComponents(tx *sqlx.Tx, pkg *models.ServicePackage) error {
+	for i := range pkg.Components {
+		component := &pkg.Components[i]
+		component.PackageID = pkg.PackageID
+		component.SortOrder = i
+		err := tx.QueryRow(`
+			INSERT INTO service_package_components (package_id, product_id, service_id, quantity, sort_order)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING component_id
+		`, component.PackageID, component.ProductID, component.ServiceID, component.Quantity, component.SortOrder).
+			Scan(&component.ComponentID)
+		if err != nil {
+			return fmt.Errorf("failed to add package component: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListComponents returns a package's components at their current list prices
+func (r *servicePackageRepository) ListComponents(packageID int64) ([]models.ServicePackageComponent, error) {
+	var components []models.ServicePackageComponent
+	err := r.db.Select(&components, `
+		SELECT c.component_id, c.package_id, c.product_id, c.service_id,
+			COALESCE(p.product_code, s.service_code) AS item_code, COALESCE(p.name, s.name) AS item_name,
+			c.quantity, COALESCE(p.selling_price, s.standard_price, 0) AS list_price, c.sort_order
+		FROM service_package_components c
+		LEFT JOIN products p ON p.product_id = c.product_id
+		LEFT JOIN services s ON s.service_id = c.service_id
+		WHERE c.package_id = $1
+		ORDER BY c.sort_order, c.component_id
+	`, packageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list package components: %w", err)
+	}
+
+	return components, nil
+}
+
+func (r *servicePackageRepository) GetTransactionPackages(transactionID int64) ([]models.DocumentPackage, error) {
+	var packages []models.DocumentPackage
+	err := r.db.Select(&packages, `
+		SELECT dp.transaction_package_id AS document_package_id, dp.transaction_id AS document_id,
+			dp.package_id, sp.package_code, sp.name AS package_name, dp.quantity, dp.list_amount,
+			dp.package_amount, dp.created_at
+		FROM transaction_packages dp
+		`+documentPackageJoins+`
+		WHERE dp.transaction_id = $1
+		ORDER BY dp.transaction_package_id
+	`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction packages: %w", err)
+	}
+
+	return packages, nil
+}
+
+func (r *servicePackageRepository) GetServiceJobPackages(serviceJobID int64) ([]models.DocumentPackage, error) {
+	var packages []models.DocumentPackage
+	err := r.db.Select(&packages, `
+		SELECT dp.service_job_package_id AS document_package_id, dp.service_job_id AS document_id,
+			dp.package_id, sp.package_code, sp.name AS package_name, dp.quantity, dp.list_amount,
+			dp.package_amount, dp.created_at
+		FROM service_job_packages dp
+		`+documentPackageJoins+`
+		WHERE dp.service_job_id = $1
+		ORDER BY dp.service_job_package_id
+	`, serviceJobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service job packages: %w", err)
+	}
+
+	return packages, nil
+}
+
+// AddToServiceJob puts a package on a service job together with its
+// component lines. The lines are priced by the package, so they need no
+// approval. Like any other part on the job, its products leave stock when
+// the job is invoiced.
+func (r *servicePackageRepository) AddToServiceJob(pkg *models.DocumentPackage, details []models.ServiceDetail, userID int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO service_job_packages (service_job_id, package_id, quantity, list_amount, package_amount, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING service_job_package_id, created_at
+	`, pkg.DocumentID, pkg.PackageID, pkg.Quantity, pkg.ListAmount, pkg.PackageAmount, userID).
+		Scan(&pkg.DocumentPackageID, &pkg.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add package to service job: %w", err)
+	}
+
+	for i := range details {
+		detail := &details[i]
+		detail.ServiceJobID = pkg.DocumentID
+		detail.PackageLineID = &pkg.DocumentPackageID
+		err = tx.QueryRow(`
+			INSERT INTO service_details (service_job_id, product_id, service_id, quantity, unit_price, total_price,
+				net_amount, notes, approval_status, service_job_package_id, list_price, created_by, unit_cost)
+			VALUES ($1, $2, $3, $4, $5, $6, $6, $7, 'approved', $8, $9, $10,
+				(SELECT cost_price FROM products WHERE product_id = $2))
+			RETURNING detail_id, unit_cost, created_at, updated_at
+		`, detail.ServiceJobID, detail.ProductID, detail.ServiceID, detail.Quantity, detail.UnitPrice,
+			detail.TotalPrice, detail.Notes, detail.PackageLineID, detail.ListPrice, userID).
+			Scan(&detail.ID, &detail.UnitCost, &detail.CreatedAt, &detail.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to add package line: %w", err)
+		}
+		detail.ApprovalStatus = "approved"
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveFromServiceJob takes a package and its component lines off a
+// service job
+func (r *servicePackageRepository) RemoveFromServiceJob(serviceJobID, id int64) error {
+	result, err := r.db.Exec(`
+		DELETE FROM service_job_packages WHERE service_job_package_id = $1 AND service_job_id = $2
+	`, id, serviceJobID)
+	if err != nil {
+		return fmt.Errorf("failed to remove package from service job: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("service job package not found")
+	}
+
+	return nil
+}
+
+// GetSales totals the packages sold over a period with what each
+// component's lines were allocated, leaving out voided transactions
+func (r *servicePackageRepository) GetSales(filter *models.PackageSalesFilter) ([]models.PackageSales, error) {
+	conditions := []string{"t.voided_at IS NULL", "t.transaction_date::date >= $1", "t.transaction_date::date <= $2"}
+	args := []interface{}{filter.DateFrom.Format("2006-01-02"), filter.DateTo.Format("2006-01-02")}
+	argIndex := 3
+
+	if filter.PackageID != nil {
+		conditions = append(conditions, fmt.Sprintf("tp.package_id = $%d", argIndex))
+		args = append(args, *filter.PackageID)
+		argIndex++
+	}
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("t.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var sales []models.PackageSales
+	err := r.db.Select(&sales, `
+		SELECT tp.package_id, sp.package_code, sp.name AS package_name, SUM(tp.quantity) AS quantity,
+			SUM(tp.list_amount) AS list_amount, SUM(tp.package_amount) AS package_amount
+		FROM transaction_packages tp
+		JOIN transactions t ON t.transaction_id = tp.transaction_id
+		JOIN service_packages sp ON sp.package_id = tp.package_id
+		`+whereClause+`
+		GROUP BY tp.package_id, sp.package_code, sp.name
+		ORDER BY package_amount DESC, tp.package_id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package sales: %w", err)
+	}
+
+	var components []models.PackageComponentSales
+	err = r.db.Select(&components, `
+		SELECT tp.package_id, td.product_id, td.service_id, COALESCE(p.name, s.name, '') AS item_name,
+			SUM(td.quantity) AS quantity, SUM(td.quantity * COALESCE(td.list_price, 0)) AS list_amount,
+			SUM(td.total_price) AS allocated_amount
+		FROM transaction_details td
+		JOIN transaction_packages tp ON tp.transaction_package_id = td.transaction_package_id
+		JOIN transactions t ON t.transaction_id = tp.transaction_id
+		LEFT JOIN products p ON p.product_id = td.product_id
+		LEFT JOIN services s ON s.service_id = td.service_id
+		`+whereClause+`
+		GROUP BY tp.package_id, td.product_id, td.service_id, p.name, s.name
+		ORDER BY tp.package_id, allocated_amount DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package component sales: %w", err)
+	}
+
+	for i := range sales {
+		sales[i].Components = []models.PackageComponentSales{}
+		for _, component := range components {
+			if component.PackageID == sales[i].PackageID {
+				sales[i].Components = append(sales[i].Components, component)
+			}
+		}
+	}
+
+	return sales, nil
+}
+
+// insertTransactionPackages records the packages sold on a new transaction
+// and links their component lines to them
+func insertTransactionPackages(tx *sqlx.Tx, transaction *models.Transaction) error {
+	for i := range transaction.Packages {
+		pkg := &transaction.Packages[i]
+		pkg.DocumentID = transaction.ID
+		err := tx.QueryRow(`
+			INSERT INTO transaction_packages (transaction_id, package_id, quantity, list_amount, package_amount)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING transaction_package_id, created_at
+		`, pkg.DocumentID, pkg.PackageID, pkg.Quantity, pkg.ListAmount, pkg.PackageAmount).
+			Scan(&pkg.DocumentPackageID, &pkg.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record transaction package: %w", err)
+		}
+	}
+
+	for i := range transaction.Details {
+		detail := &transaction.Details[i]
+		if detail.PackageIndex != nil {
+			detail.PackageLineID = &transaction.Packages[*detail.PackageIndex].DocumentPackageID
+		}
+	}
+
+	return nil
+}
@@ -1,11 +1,15 @@
 package repositories
 
 import (
+	"database/sql"
 	"fmt"
+	"math"
 
+	"flutter-bengkel/internal/events"
 	"flutter-bengkel/internal/models"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
 )
 
 // Service Repository
@@ -31,9 +35,9 @@ func NewServiceRepository(db *sqlx.DB) ServiceRepository {
 func (r *serviceRepository) Create(service *models.Service) error {
 	query := `
 		INSERT INTO services (service_code, name, description, category_id, 
-							  standard_price, estimated_duration, is_active)
+							  standard_price, estimated_duration, tax_code_id, is_active)
 		VALUES (:service_code, :name, :description, :category_id, 
-				:standard_price, :estimated_duration, :is_active)
+				:standard_price, :estimated_duration, :tax_code_id, :is_active)
 	`
 	
 	result, err := r.db.NamedExec(query, service)
@@ -53,7 +57,7 @@ func (r *serviceRepository) Create(service *models.Service) error {
 func (r *serviceRepository) GetByID(id int64) (*models.Service, error) {
 	query := `
 		SELECT s.id, s.service_code, s.name, s.description, s.category_id, 
-			   s.standard_price, s.estimated_duration, s.is_active, s.created_at, s.updated_at,
+			   s.standard_price, s.estimated_duration, s.tax_code_id, s.is_active, s.created_at, s.updated_at,
 			   sc.id as "category.id", sc.name as "category.name", 
 			   sc.description as "category.description"
 		FROM services s
@@ -73,7 +77,7 @@ func (r *serviceRepository) GetByID(id int64) (*models.Service, error) {
 func (r *serviceRepository) GetByServiceCode(code string) (*models.Service, error) {
 	query := `
 		SELECT s.id, s.service_code, s.name, s.description, s.category_id, 
-			   s.standard_price, s.estimated_duration, s.is_active, s.created_at, s.updated_at,
+			   s.standard_price, s.estimated_duration, s.tax_code_id, s.is_active, s.created_at, s.updated_at,
 			   sc.id as "category.id", sc.name as "category.name", 
 			   sc.description as "category.description"
 		FROM services s
@@ -151,7 +155,7 @@ func (r *serviceRepository) List(offset, limit int, categoryID *int64, search st
 	// Get services
 	query := fmt.Sprintf(`
 		SELECT s.id, s.service_code, s.name, s.description, s.category_id, 
-			   s.standard_price, s.estimated_duration, s.is_active, s.created_at, s.updated_at,
+			   s.standard_price, s.estimated_duration, s.tax_code_id, s.is_active, s.created_at, s.updated_at,
 			   sc.id as "category.id", sc.name as "category.name", 
 			   sc.description as "category.description"
 		FROM services s
@@ -209,7 +213,7 @@ type ProductRepository interface {
 	Update(id int64, product *models.Product) error
 	Delete(id int64) error
 	List(offset, limit int, categoryID *int64, supplierID *int64, search string) ([]models.Product, int64, error)
-	UpdateStock(id int64, quantity int, operation string) error // operation: "add" or "subtract"
+	UpdateStock(id int64, quantity int, operation string, unitCost decimal.Decimal) error // operation: "add" or "subtract"
 	GetLowStockProducts(outletID *int64) ([]models.Product, error)
 	ListCategories() ([]models.Category, error)
 	ListSuppliers() ([]models.Supplier, error)
@@ -230,11 +234,11 @@ func (r *productRepository) Create(product *models.Product) error {
 		INSERT INTO products (product_code, name, description, category_id, unit_type_id, 
 							  supplier_id, cost_price, selling_price, stock_quantity, 
 							  min_stock_level, max_stock_level, has_serial_number, 
-							  is_service, is_active)
+							  is_service, tax_code_id, is_active)
 		VALUES (:product_code, :name, :description, :category_id, :unit_type_id, 
 				:supplier_id, :cost_price, :selling_price, :stock_quantity, 
 				:min_stock_level, :max_stock_level, :has_serial_number, 
-				:is_service, :is_active)
+				:is_service, :tax_code_id, :is_active)
 	`
 	
 	result, err := r.db.NamedExec(query, product)
@@ -256,7 +260,7 @@ func (r *productRepository) GetByID(id int64) (*models.Product, error) {
 		SELECT p.id, p.product_code, p.name, p.description, p.category_id, p.unit_type_id,
 			   p.supplier_id, p.cost_price, p.selling_price, p.stock_quantity, 
 			   p.min_stock_level, p.max_stock_level, p.has_serial_number, 
-			   p.is_service, p.is_active, p.created_at, p.updated_at,
+			   p.is_service, p.tax_code_id, p.is_active, p.created_at, p.updated_at,
 			   c.id as "category.id", c.name as "category.name",
 			   ut.id as "unit_type.id", ut.name as "unit_type.name", 
 			   ut.abbreviation as "unit_type.abbreviation",
@@ -283,7 +287,7 @@ func (r *productRepository) GetByProductCode(code string) (*models.Product, erro
 		SELECT p.id, p.product_code, p.name, p.description, p.category_id, p.unit_type_id,
 			   p.supplier_id, p.cost_price, p.selling_price, p.stock_quantity, 
 			   p.min_stock_level, p.max_stock_level, p.has_serial_number, 
-			   p.is_service, p.is_active, p.created_at, p.updated_at,
+			   p.is_service, p.tax_code_id, p.is_active, p.created_at, p.updated_at,
 			   c.id as "category.id", c.name as "category.name",
 			   ut.id as "unit_type.id", ut.name as "unit_type.name", 
 			   ut.abbreviation as "unit_type.abbreviation",
@@ -377,7 +381,7 @@ func (r *productRepository) List(offset, limit int, categoryID *int64, supplierI
 		SELECT p.id, p.product_code, p.name, p.description, p.category_id, p.unit_type_id,
 			   p.supplier_id, p.cost_price, p.selling_price, p.stock_quantity, 
 			   p.min_stock_level, p.max_stock_level, p.has_serial_number, 
-			   p.is_service, p.is_active, p.created_at, p.updated_at,
+			   p.is_service, p.tax_code_id, p.is_active, p.created_at, p.updated_at,
 			   c.id as "category.id", c.name as "category.name",
 			   ut.id as "unit_type.id", ut.name as "unit_type.name", 
 			   ut.abbreviation as "unit_type.abbreviation",
@@ -403,27 +407,92 @@ func (r *productRepository) List(offset, limit int, categoryID *int64, supplierI
 	return products, total, nil
 }
 
-func (r *productRepository) UpdateStock(id int64, quantity int, operation string) error {
+// UpdateStock adjusts a product's stock and records a StockLow event when a
+// subtraction takes it to or below its minimum level. Stock added at
+// unitCost moves the product's cost to the weighted average, as a goods
+// receipt would.
+func (r *productRepository) UpdateStock(id int64, quantity int, operation string, unitCost decimal.Decimal) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	if operation == "add" {
+		_, err = receiveStock(tx, id, quantity, unitCost)
+	} else {
+		err = adjustStock(tx, id, quantity, operation)
+	}
+	if err != nil {
+		return err
+	}
+	
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	
+	return nil
+}
+
+// adjustStock adds to or subtracts from a product's stock within tx. Stock
+// cannot go below zero; only the movement that crosses the minimum level
+// raises a StockLow event.
+func adjustStock(tx *sqlx.Tx, id int64, quantity int, operation string) error {
 	var query string
 	
 	switch operation {
 	case "add":
-		query = `UPDATE products SET stock_quantity = stock_quantity + ? WHERE id = ?`
+		query = `UPDATE products SET stock_quantity = stock_quantity + $1, updated_at = CURRENT_TIMESTAMP
+				 WHERE product_id = $2 AND deleted_at IS NULL`
 	case "subtract":
-		query = `UPDATE products SET stock_quantity = stock_quantity - ? WHERE id = ? AND stock_quantity >= ?`
+		query = `UPDATE products SET stock_quantity = stock_quantity - $1, updated_at = CURRENT_TIMESTAMP
+				 WHERE product_id = $2 AND deleted_at IS NULL AND stock_quantity >= $1`
 	default:
 		return fmt.Errorf("invalid operation: %s", operation)
 	}
 	
-	if operation == "subtract" {
-		_, err := r.db.Exec(query, quantity, id, quantity)
-		if err != nil {
-			return fmt.Errorf("failed to update stock (insufficient stock): %w", err)
+	var product events.StockLowPayload
+	err := tx.QueryRow(query+` RETURNING product_id, product_code, name, stock_quantity, min_stock_level`, quantity, id).
+		Scan(&product.ProductID, &product.ProductCode, &product.Name, &product.StockQuantity, &product.MinStockLevel)
+	if err == sql.ErrNoRows {
+		if operation == "subtract" {
+			return fmt.Errorf("failed to update stock: product not found or insufficient stock")
 		}
-	} else {
-		_, err := r.db.Exec(query, quantity, id)
+		return fmt.Errorf("failed to update stock: product not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update stock: %w", err)
+	}
+	
+	if operation == "subtract" && product.StockQuantity <= product.MinStockLevel &&
+		product.StockQuantity+quantity > product.MinStockLevel {
+		if err := appendEvent(tx, events.StockLow, "product", product.ProductID, nil, product); err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+// takeStock deducts every product sold on a transaction from stock, loose
+// or as a package component, and marks the lines it took. A service job's
+// parts are on its invoice as lines of their own, so they are taken here
+// too. Stock is counted in whole units, so a product line cannot sell a
+// fraction of one.
+func takeStock(tx *sqlx.Tx, transaction *models.Transaction) error {
+	for _, detail := range transaction.Details {
+		if detail.ProductID == nil {
+			continue
+		}
+		if detail.Quantity != math.Trunc(detail.Quantity) {
+			return fmt.Errorf("product %d: quantity must be a whole number", *detail.ProductID)
+		}
+		if err := adjustStock(tx, *detail.ProductID, int(detail.Quantity), "subtract"); err != nil {
+			return fmt.Errorf("product %d: %w", *detail.ProductID, err)
+		}
+		_, err := tx.Exec(`UPDATE transaction_details SET stock_taken = true WHERE detail_id = $1`, detail.ID)
 		if err != nil {
-			return fmt.Errorf("failed to update stock: %w", err)
+			return fmt.Errorf("failed to mark stock taken: %w", err)
 		}
 	}
 	
@@ -435,7 +504,7 @@ func (r *productRepository) GetLowStockProducts(outletID *int64) ([]models.Produ
 		SELECT p.id, p.product_code, p.name, p.description, p.category_id, p.unit_type_id,
 			   p.supplier_id, p.cost_price, p.selling_price, p.stock_quantity, 
 			   p.min_stock_level, p.max_stock_level, p.has_serial_number, 
-			   p.is_service, p.is_active, p.created_at, p.updated_at,
+			   p.is_service, p.tax_code_id, p.is_active, p.created_at, p.updated_at,
 			   c.id as "category.id", c.name as "category.name",
 			   ut.id as "unit_type.id", ut.name as "unit_type.name", 
 			   ut.abbreviation as "unit_type.abbreviation",
@@ -0,0 +1,426 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// ShiftRepository interface defines cashier shift operations
+type ShiftRepository interface {
+	Open(shift *models.CashierShift) error
+	GetByID(id int64) (*models.CashierShift, error)
+	GetOpenByUser(userID int64) (*models.CashierShift, error)
+	List(filter *models.ShiftFilter, offset, limit int) ([]models.CashierShift, int64, error)
+	Close(shiftID, closedBy int64, req *models.CloseShiftRequest) error
+	Approve(shiftID, approvedBy int64, notes string) error
+	GetReport(shiftID int64) (*models.ShiftReport, error)
+}
+
+type shiftRepository struct {
+	db *sqlx.DB
+}
+
+// NewShiftRepository creates a new shift repository
+func NewShiftRepository(db *sqlx.DB) ShiftRepository {
+	return &shiftRepository{db: db}
+}
+
+const cashierShiftColumns = `
+	s.shift_id, s.shift_number, s.outlet_id, s.user_id, u.full_name AS user_name, s.status,
+	s.opening_float, s.opened_at, s.opening_notes, s.expected_cash, s.counted_cash, s.cash_variance,
+	s.closed_at, s.closed_by, s.closing_notes, s.approved_at, s.approved_by, s.approval_notes,
+	s.created_at, s.updated_at
+`
+
+// Open starts a shift for the user. The partial unique index on open shifts
+// guarantees a user never has two.
+func (r *shiftRepository) Open(shift *models.CashierShift) error {
+	err := r.db.QueryRow(`
+		INSERT INTO cashier_shifts (shift_number, outlet_id, user_id, opening_float, opening_notes)
+		VALUES ('SH' || to_char(CURRENT_DATE, 'YYYYMMDD') || '-' || LPAD(nextval('cashier_shift_number_seq')::text, 4, '0'),
+			$1, $2, $3, $4)
+		ON CONFLICT (user_id) WHERE status = 'open' DO NOTHING
+		RETURNING shift_id, shift_number, status, opened_at, created_at, updated_at
+	`, shift.OutletID, shift.UserID, shift.OpeningFloat, shift.OpeningNotes).
+		Scan(&shift.ShiftID, &shift.ShiftNumber, &shift.Status, &shift.OpenedAt, &shift.CreatedAt, &shift.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("user already has an open shift")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open shift: %w", err)
+	}
+
+	return nil
+}
+
+func (r *shiftRepository) GetByID(id int64) (*models.CashierShift, error) {
+	var shift models.CashierShift
+	err := r.db.Get(&shift, `
+		SELECT `+cashierShiftColumns+`
+		FROM cashier_shifts s
+		JOIN users u ON s.user_id = u.user_id
+		WHERE s.shift_id = $1
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shift: %w", err)
+	}
+
+	return &shift, nil
+}
+
+func (r *shiftRepository) GetOpenByUser(userID int64) (*models.CashierShift, error) {
+	var shift models.CashierShift
+	err := r.db.Get(&shift, `
+		SELECT `+cashierShiftColumns+`
+		FROM cashier_shifts s
+		JOIN users u ON s.user_id = u.user_id
+		WHERE s.user_id = $1 AND s.status = 'open'
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open shift: %w", err)
+	}
+
+	return &shift, nil
+}
+
+func (r *shiftRepository) List(filter *models.ShiftFilter, offset, limit int) ([]models.CashierShift, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("s.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.UserID != nil {
+		conditions = append(conditions, fmt.Sprintf("s.user_id = $%d", argIndex))
+		args = append(args, *filter.UserID)
+		argIndex++
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("s.status = $%d", argIndex))
+		args = append(args, filter.Status)
+		argIndex++
+	}
+
+	if filter.DateFrom != nil {
+		conditions = append(conditions, fmt.Sprintf("s.opened_at::date >= $%d", argIndex))
+		args = append(args, filter.DateFrom.Format("2006-01-02"))
+		argIndex++
+	}
+
+	if filter.DateTo != nil {
+		conditions = append(conditions, fmt.Sprintf("s.opened_at::date <= $%d", argIndex))
+		args = append(args, filter.DateTo.Format("2006-01-02"))
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM cashier_shifts s `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count shifts: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM cashier_shifts s
+		JOIN users u ON s.user_id = u.user_id
+		%s
+		ORDER BY s.opened_at DESC, s.shift_id DESC
+		LIMIT $%d OFFSET $%d
+	`, cashierShiftColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var shifts []models.CashierShift
+	err = r.db.Select(&shifts, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list shifts: %w", err)
+	}
+
+	return shifts, total, nil
+}
+
+// lockOpenShift share-locks an open shift so it cannot be closed while money
+// is being recorded into it, and checks it belongs to the outlet
+func lockOpenShift(tx *sqlx.Tx, shiftID, outletID int64) error {
+	var shiftOutletID int64
+	err := tx.Get(&shiftOutletID, `
+		SELECT outlet_id FROM cashier_shifts WHERE shift_id = $1 AND status = 'open' FOR SHARE
+	`, shiftID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("cashier shift is not open")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get cashier shift: %w", err)
+	}
+	if shiftOutletID != outletID {
+		return fmt.Errorf("cashier shift belongs to another outlet")
+	}
+
+	return nil
+}
+
+// shiftMethodTotal is the net amount a shift took in one payment method
+type shiftMethodTotal struct {
+	PaymentMethodID   int64           `db:"payment_method_id"`
+	PaymentMethodName string          `db:"payment_method_name"`
+	PaymentMethodType string          `db:"payment_method_type"`
+	PaymentCount      int             `db:"payment_count"`
+	Inflow            decimal.Decimal `db:"inflow"`
+	Outflow           decimal.Decimal `db:"outflow"`
+}
+
+// shiftMethodTotals sums the cash flows recorded in a shift per payment method
+func shiftMethodTotals(q sqlx.Queryer, shiftID int64) ([]shiftMethodTotal, error) {
+	var totals []shiftMethodTotal
+	err := sqlx.Select(q, &totals, `
+		SELECT pm.method_id AS payment_method_id, pm.name AS payment_method_name, pm.type AS payment_method_type,
+			COUNT(*) FILTER (WHERE cf.flow_type = 'inflow') AS payment_count,
+			COALESCE(SUM(cf.amount) FILTER (WHERE cf.flow_type = 'inflow'), 0) AS inflow,
+			COALESCE(SUM(cf.amount) FILTER (WHERE cf.flow_type = 'outflow'), 0) AS outflow
+		FROM cash_flows cf
+		JOIN payment_methods pm ON cf.payment_method_id = pm.method_id
+		WHERE cf.shift_id = $1 AND cf.deleted_at IS NULL
+		GROUP BY pm.method_id, pm.name, pm.type
+		ORDER BY pm.name
+	`, shiftID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum shift cash flows: %w", err)
+	}
+
+	return totals, nil
+}
+
+// Close reconciles the shift against what was counted. Cash-type methods are
+// reconciled together against the drawer count; every other method used in
+// the shift, or counted, gets its own tender line.
+func (r *shiftRepository) Close(shiftID, closedBy int64, req *models.CloseShiftRequest) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var shift struct {
+		Status       string          `db:"status"`
+		OpeningFloat decimal.Decimal `db:"opening_float"`
+	}
+	err = tx.Get(&shift, `SELECT status, opening_float FROM cashier_shifts WHERE shift_id = $1 FOR UPDATE`, shiftID)
+	if err != nil {
+		return fmt.Errorf("failed to get shift: %w", err)
+	}
+	if shift.Status != "open" {
+		return fmt.Errorf("shift is already %s", shift.Status)
+	}
+
+	totals, err := shiftMethodTotals(tx, shiftID)
+	if err != nil {
+		return err
+	}
+
+	expectedCash := shift.OpeningFloat
+	tenders := make(map[int64]*models.ShiftTender)
+	var order []int64
+	for _, total := range totals {
+		if total.PaymentMethodType == "cash" {
+			expectedCash = expectedCash.Add(total.Inflow).Sub(total.Outflow)
+			continue
+		}
+		tenders[total.PaymentMethodID] = &models.ShiftTender{
+			PaymentMethodID: total.PaymentMethodID,
+			PaymentCount:    total.PaymentCount,
+			ExpectedAmount:  total.Inflow.Sub(total.Outflow),
+		}
+		order = append(order, total.PaymentMethodID)
+	}
+
+	for _, count := range req.Tenders {
+		tender, ok := tenders[count.PaymentMethodID]
+		if !ok {
+			var methodType string
+			err = tx.Get(&methodType, `SELECT type FROM payment_methods WHERE method_id = $1`, count.PaymentMethodID)
+			if err != nil {
+				return fmt.Errorf("payment method %d not found", count.PaymentMethodID)
+			}
+			if methodType == "cash" {
+				return fmt.Errorf("cash must be counted by denomination")
+			}
+			tender = &models.ShiftTender{PaymentMethodID: count.PaymentMethodID}
+			tenders[count.PaymentMethodID] = tender
+			order = append(order, count.PaymentMethodID)
+		}
+		tender.CountedAmount = count.CountedAmount
+	}
+
+	countedCash := decimal.Zero
+	for _, denomination := range req.Denominations {
+		_, err = tx.Exec(`
+			INSERT INTO cashier_shift_denominations (shift_id, denomination, quantity)
+			VALUES ($1, $2, $3)
+		`, shiftID, denomination.Denomination, denomination.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to record denomination count: %w", err)
+		}
+		countedCash = countedCash.Add(denomination.Denomination.Mul(decimal.NewFromInt(int64(denomination.Quantity))))
+	}
+
+	for _, methodID := range order {
+		tender := tenders[methodID]
+		_, err = tx.Exec(`
+			INSERT INTO cashier_shift_tenders (shift_id, payment_method_id, payment_count, expected_amount,
+				counted_amount, variance)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, shiftID, methodID, tender.PaymentCount, tender.ExpectedAmount, tender.CountedAmount,
+			tender.CountedAmount.Sub(tender.ExpectedAmount))
+		if err != nil {
+			return fmt.Errorf("failed to record tender count: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE cashier_shifts
+		SET status = 'closed', expected_cash = $2, counted_cash = $3, cash_variance = $4,
+			closed_at = CURRENT_TIMESTAMP, closed_by = $5, closing_notes = NULLIF($6, ''),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE shift_id = $1
+	`, shiftID, expectedCash, countedCash, countedCash.Sub(expectedCash), closedBy, req.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to close shift: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Approve signs off a closed shift
+func (r *shiftRepository) Approve(shiftID, approvedBy int64, notes string) error {
+	result, err := r.db.Exec(`
+		UPDATE cashier_shifts
+		SET status = 'approved', approved_at = CURRENT_TIMESTAMP, approved_by = $2,
+			approval_notes = NULLIF($3, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE shift_id = $1 AND status = 'closed'
+	`, shiftID, approvedBy, notes)
+	if err != nil {
+		return fmt.Errorf("failed to approve shift: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("only closed shifts can be approved")
+	}
+
+	return nil
+}
+
+// GetReport builds the shift report. Open shifts show the running expected
+// amounts; closed shifts show what was counted at close.
+func (r *shiftRepository) GetReport(shiftID int64) (*models.ShiftReport, error) {
+	shift, err := r.GetByID(shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ShiftReport{
+		Shift:         *shift,
+		Denominations: []models.ShiftDenomination{},
+		Tenders:       []models.ShiftTender{},
+		Payments:      []models.ShiftPayment{},
+	}
+
+	totals, err := shiftMethodTotals(r.db, shiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Cash.OpeningFloat = shift.OpeningFloat
+	var liveTenders []models.ShiftTender
+	for _, total := range totals {
+		net := total.Inflow.Sub(total.Outflow)
+		report.TotalCollected = report.TotalCollected.Add(net)
+		if total.PaymentMethodType == "cash" {
+			report.Cash.CashIn = report.Cash.CashIn.Add(total.Inflow)
+			report.Cash.CashOut = report.Cash.CashOut.Add(total.Outflow)
+			continue
+		}
+		liveTenders = append(liveTenders, models.ShiftTender{
+			PaymentMethodID:   total.PaymentMethodID,
+			PaymentMethodName: total.PaymentMethodName,
+			PaymentMethodType: total.PaymentMethodType,
+			PaymentCount:      total.PaymentCount,
+			ExpectedAmount:    net,
+		})
+	}
+	report.Cash.Expected = shift.OpeningFloat.Add(report.Cash.CashIn).Sub(report.Cash.CashOut)
+
+	if shift.Status == "open" {
+		if liveTenders != nil {
+			report.Tenders = liveTenders
+		}
+	} else {
+		if shift.ExpectedCash != nil {
+			report.Cash.Expected = *shift.ExpectedCash
+		}
+		report.Cash.Counted = shift.CountedCash
+		report.Cash.Variance = shift.CashVariance
+		if shift.CashVariance != nil {
+			report.TotalVariance = *shift.CashVariance
+		}
+
+		err = r.db.Select(&report.Denominations, `
+			SELECT denomination, quantity, denomination * quantity AS amount
+			FROM cashier_shift_denominations
+			WHERE shift_id = $1
+			ORDER BY denomination DESC
+		`, shiftID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get shift denominations: %w", err)
+		}
+
+		err = r.db.Select(&report.Tenders, `
+			SELECT t.payment_method_id, pm.name AS payment_method_name, pm.type AS payment_method_type,
+				t.payment_count, t.expected_amount, t.counted_amount, t.variance
+			FROM cashier_shift_tenders t
+			JOIN payment_methods pm ON t.payment_method_id = pm.method_id
+			WHERE t.shift_id = $1
+			ORDER BY pm.name
+		`, shiftID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get shift tenders: %w", err)
+		}
+		for _, tender := range report.Tenders {
+			report.TotalVariance = report.TotalVariance.Add(tender.Variance)
+		}
+	}
+
+	err = r.db.Select(&report.Payments, `
+		SELECT p.payment_id, p.payment_number, t.transaction_number, pm.name AS payment_method_name,
+			pm.type AS payment_method_type, p.amount, p.payment_date
+		FROM payments p
+		JOIN transactions t ON p.transaction_id = t.transaction_id
+		JOIN payment_methods pm ON p.payment_method_id = pm.method_id
+		WHERE p.shift_id = $1 AND p.deleted_at IS NULL
+		ORDER BY p.payment_date, p.payment_id
+	`, shiftID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shift payments: %w", err)
+	}
+
+	return report, nil
+}
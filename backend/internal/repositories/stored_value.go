@@ -0,0 +1,788 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/events"
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// StoredValueRepository defines data access for customer deposits, gift
+// vouchers and their ledger
+type StoredValueRepository interface {
+	List(filter *models.StoredValueFilter, offset, limit int) ([]models.StoredValueAccount, int64, error)
+	GetByID(id int64) (*models.StoredValueAccount, error)
+	GetByCode(code string) (*models.StoredValueAccount, error)
+	GetDeposit(customerID int64) (*models.StoredValueAccount, error)
+	OpenDeposit(customerID, outletID, userID int64) (*models.StoredValueAccount, error)
+	IssueVoucher(account *models.StoredValueAccount, entry *models.StoredValueEntry) error
+	TopUp(entry *models.StoredValueEntry, expiresAt *time.Time) error
+	ListEntries(accountID int64, offset, limit int) ([]models.StoredValueEntry, int64, error)
+	Expire(at time.Time) (*models.StoredValueExpiryResult, error)
+}
+
+type storedValueRepository struct {
+	db *sqlx.DB
+}
+
+// NewStoredValueRepository creates a new stored value repository
+func NewStoredValueRepository(db *sqlx.DB) StoredValueRepository {
+	return &storedValueRepository{db: db}
+}
+
+const storedValueAccountColumns = `
+	a.account_id, a.account_type, a.customer_id, c.name AS customer_name, a.code, a.single_use, a.balance,
+	a.status, a.expires_at, a.outlet_id, a.notes,
+	COALESCE(s.top_ups, 0) AS top_ups, COALESCE(s.redeemed, 0) AS redeemed, COALESCE(s.expired, 0) AS expired,
+	a.created_at, a.updated_at, a.created_by
+`
+
+const storedValueAccountFrom = `
+	FROM stored_value_accounts a
+	LEFT JOIN customers c ON c.customer_id = a.customer_id
+	LEFT JOIN LATERAL (
+		SELECT SUM(e.amount) FILTER (WHERE e.entry_type = 'top_up') AS top_ups,
+			-SUM(e.amount) FILTER (WHERE e.entry_type IN ('redeem', 'redeem_reversal')) AS redeemed,
+			-SUM(e.amount) FILTER (WHERE e.entry_type IN ('expire', 'expire_reversal')) AS expired
+		FROM stored_value_entries e
+		WHERE e.account_id = a.account_id
+	) s ON TRUE
+`
+
+func (r *storedValueRepository) List(filter *models.StoredValueFilter, offset, limit int) ([]models.StoredValueAccount, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.AccountType != "" {
+		conditions = append(conditions, fmt.Sprintf("a.account_type = $%d", argIndex))
+		args = append(args, filter.AccountType)
+		argIndex++
+	}
+
+	if filter.CustomerID != nil {
+		conditions = append(conditions, fmt.Sprintf("a.customer_id = $%d", argIndex))
+		args = append(args, *filter.CustomerID)
+		argIndex++
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("a.status = $%d", argIndex))
+		args = append(args, filter.Status)
+		argIndex++
+	}
+
+	if filter.Search != "" {
+		conditions = append(conditions, fmt.Sprintf("(a.code ILIKE $%d OR c.name ILIKE $%d)", argIndex, argIndex))
+		args = append(args, "%"+filter.Search+"%")
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `
+		SELECT COUNT(*) FROM stored_value_accounts a
+		LEFT JOIN customers c ON c.customer_id = a.customer_id
+	`+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count stored value accounts: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		%s
+		%s
+		ORDER BY a.created_at DESC, a.account_id DESC
+		LIMIT $%d OFFSET $%d
+	`, storedValueAccountColumns, storedValueAccountFrom, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	accounts := []models.StoredValueAccount{}
+	if err = r.db.Select(&accounts, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list stored value accounts: %w", err)
+	}
+
+	return accounts, total, nil
+}
+
+func (r *storedValueRepository) GetByID(id int64) (*models.StoredValueAccount, error) {
+	account, err := r.get("a.account_id = $1", id)
+	if err == nil && account == nil {
+		return nil, fmt.Errorf("stored value account not found")
+	}
+	return account, err
+}
+
+func (r *storedValueRepository) GetByCode(code string) (*models.StoredValueAccount, error) {
+	account, err := r.get("UPPER(a.code) = UPPER($1)", code)
+	if err == nil && account == nil {
+		return nil, fmt.Errorf("voucher not found")
+	}
+	return account, err
+}
+
+// GetDeposit returns a customer's deposit, or nil when they have none
+func (r *storedValueRepository) GetDeposit(customerID int64) (*models.StoredValueAccount, error) {
+	return r.get("a.customer_id = $1 AND a.account_type = 'deposit'", customerID)
+}
+
+func (r *storedValueRepository) get(condition string, args ...interface{}) (*models.StoredValueAccount, error) {
+	var account models.StoredValueAccount
+	err := r.db.Get(&account, `SELECT `+storedValueAccountColumns+storedValueAccountFrom+` WHERE `+condition, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored value account: %w", err)
+	}
+
+	return &account, nil
+}
+
+// OpenDeposit returns a customer's deposit, opening an empty one at the
+// outlet when they have none yet
+func (r *storedValueRepository) OpenDeposit(customerID, outletID, userID int64) (*models.StoredValueAccount, error) {
+	_, err := r.db.Exec(`
+		INSERT INTO stored_value_accounts (account_type, customer_id, outlet_id, created_by)
+		SELECT 'deposit', customer_id, $2, $3 FROM customers WHERE customer_id = $1 AND deleted_at IS NULL
+		ON CONFLICT (customer_id) WHERE account_type = 'deposit' DO NOTHING
+	`, customerID, outletID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open deposit: %w", err)
+	}
+
+	account, err := r.GetDeposit(customerID)
+	if err == nil && account == nil {
+		return nil, fmt.Errorf("customer not found")
+	}
+	return account, err
+}
+
+// IssueVoucher sells a voucher: the voucher and its first top-up are
+// written together
+func (r *storedValueRepository) IssueVoucher(account *models.StoredValueAccount, entry *models.StoredValueEntry) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO stored_value_accounts (account_type, customer_id, code, single_use, expires_at, outlet_id,
+			notes, created_by)
+		VALUES ('voucher', $1, $2, $3, $4, $5, $6, $7)
+		RETURNING account_id, status, created_at, updated_at
+	`, account.CustomerID, account.Code, account.SingleUse, account.ExpiresAt, account.OutletID, account.Notes,
+		account.CreatedBy).Scan(&account.AccountID, &account.Status, &account.CreatedAt, &account.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to issue voucher: %w", err)
+	}
+	account.AccountType = "voucher"
+
+	entry.AccountID = account.AccountID
+	if err = insertTopUp(tx, account, entry); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// TopUp adds value to a deposit or multi-use voucher, moving its expiry
+// when one is given
+func (r *storedValueRepository) TopUp(entry *models.StoredValueEntry, expiresAt *time.Time) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	account, err := lockStoredValueAccount(tx, "account_id = $1", entry.AccountID)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return fmt.Errorf("stored value account not found")
+	}
+	if account.SingleUse {
+		return fmt.Errorf("single-use vouchers cannot be topped up")
+	}
+	if account.Status != "active" || storedValueLapsed(account, time.Now()) {
+		return fmt.Errorf("%s has expired", storedValueLabel(account))
+	}
+
+	if expiresAt != nil {
+		_, err = tx.Exec(`
+			UPDATE stored_value_accounts SET expires_at = $2, updated_at = CURRENT_TIMESTAMP WHERE account_id = $1
+		`, account.AccountID, expiresAt)
+		if err != nil {
+			return fmt.Errorf("failed to update stored value expiry: %w", err)
+		}
+	}
+
+	if err = insertTopUp(tx, account, entry); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListEntries returns an account's statement, newest first, with the
+// balance after each entry
+func (r *storedValueRepository) ListEntries(accountID int64, offset, limit int) ([]models.StoredValueEntry, int64, error) {
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM stored_value_entries WHERE account_id = $1`, accountID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count stored value entries: %w", err)
+	}
+
+	entries := []models.StoredValueEntry{}
+	err = r.db.Select(&entries, `
+		SELECT e.entry_id, e.account_id, e.entry_type, e.amount, e.outlet_id, e.transaction_id,
+			t.transaction_number, e.payment_id, e.refund_id, e.payment_method_id, e.shift_id, e.description,
+			SUM(e.amount) OVER (ORDER BY e.created_at, e.entry_id) AS balance,
+			e.created_at, e.created_by
+		FROM stored_value_entries e
+		LEFT JOIN transactions t ON t.transaction_id = e.transaction_id
+		WHERE e.account_id = $1
+		ORDER BY e.created_at DESC, e.entry_id DESC
+		LIMIT $2 OFFSET $3
+	`, accountID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list stored value entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// Expire lapses every account past its expiry that still holds value or is
+// still active. Each account is expired under its own lock.
+func (r *storedValueRepository) Expire(at time.Time) (*models.StoredValueExpiryResult, error) {
+	var accountIDs []int64
+	err := r.db.Select(&accountIDs, `
+		SELECT account_id FROM stored_value_accounts
+		WHERE expires_at <= $1 AND (balance > 0 OR status = 'active')
+		ORDER BY account_id
+	`, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expiring stored value: %w", err)
+	}
+
+	result := &models.StoredValueExpiryResult{}
+	for _, accountID := range accountIDs {
+		amount, err := r.expireAccount(accountID, at)
+		if err != nil {
+			return result, err
+		}
+		if amount.IsPositive() {
+			result.Accounts++
+			result.Amount = result.Amount.Add(amount)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *storedValueRepository) expireAccount(accountID int64, at time.Time) (decimal.Decimal, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	account, err := lockStoredValueAccount(tx, "account_id = $1", accountID)
+	if err != nil || account == nil || !storedValueLapsed(account, at) {
+		return decimal.Zero, err
+	}
+
+	lapsed := account.Balance
+	if lapsed.IsPositive() {
+		description := fmt.Sprintf("Expired on %s", at.Format("2006-01-02"))
+		entry := &models.StoredValueEntry{
+			AccountID:   accountID,
+			EntryType:   "expire",
+			Amount:      lapsed.Neg(),
+			OutletID:    account.OutletID,
+			Description: &description,
+		}
+		if err = postStoredValue(tx, account, entry); err != nil {
+			return decimal.Zero, err
+		}
+	}
+	if err = setStoredValueStatus(tx, accountID, "expired"); err != nil {
+		return decimal.Zero, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return lapsed, nil
+}
+
+// lockStoredValueAccount locks the account matching condition for a
+// posting, or returns nil when there is none
+func lockStoredValueAccount(tx *sqlx.Tx, condition string, args ...interface{}) (*models.StoredValueAccount, error) {
+	var account models.StoredValueAccount
+	err := tx.Get(&account, `
+		SELECT account_id, account_type, customer_id, code, single_use, balance, status, expires_at, outlet_id
+		FROM stored_value_accounts
+		WHERE `+condition+` FOR UPDATE
+	`, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored value account: %w", err)
+	}
+
+	return &account, nil
+}
+
+// storedValueLapsed reports whether an account's expiry has passed by at
+func storedValueLapsed(account *models.StoredValueAccount, at time.Time) bool {
+	return account.ExpiresAt != nil && !account.ExpiresAt.After(at)
+}
+
+// storedValueLabel names an account in messages and descriptions
+func storedValueLabel(account *models.StoredValueAccount) string {
+	if account.Code != nil {
+		return "voucher " + *account.Code
+	}
+	return fmt.Sprintf("deposit #%d", account.AccountID)
+}
+
+// postStoredValue posts an entry, brings the account's balance to the sum
+// of its ledger and records the StoredValuePosted event. Every posting goes
+// through here.
+func postStoredValue(tx *sqlx.Tx, account *models.StoredValueAccount, entry *models.StoredValueEntry) error {
+	err := tx.QueryRow(`
+		INSERT INTO stored_value_entries (account_id, entry_type, amount, outlet_id, transaction_id, payment_id,
+			refund_id, payment_method_id, shift_id, description, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING entry_id, created_at
+	`, entry.AccountID, entry.EntryType, entry.Amount, entry.OutletID, entry.TransactionID, entry.PaymentID,
+		entry.RefundID, entry.PaymentMethodID, entry.ShiftID, entry.Description, entry.CreatedBy).
+		Scan(&entry.EntryID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to post stored value: %w", err)
+	}
+
+	err = tx.QueryRow(`
+		UPDATE stored_value_accounts
+		SET balance = (SELECT COALESCE(SUM(amount), 0) FROM stored_value_entries WHERE account_id = $1),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $1
+		RETURNING balance
+	`, entry.AccountID).Scan(&account.Balance)
+	if err != nil {
+		return fmt.Errorf("failed to update stored value balance: %w", err)
+	}
+	entry.Balance = account.Balance
+
+	return appendEvent(tx, events.StoredValuePosted, "stored_value_account", account.AccountID, &entry.OutletID,
+		events.StoredValuePostedPayload{
+			EntryID:       entry.EntryID,
+			AccountID:     account.AccountID,
+			AccountType:   account.AccountType,
+			Code:          account.Code,
+			CustomerID:    account.CustomerID,
+			EntryType:     entry.EntryType,
+			OutletID:      entry.OutletID,
+			TransactionID: entry.TransactionID,
+			Amount:        entry.Amount.StringFixed(2),
+			Balance:       account.Balance.StringFixed(2),
+		})
+}
+
+func setStoredValueStatus(tx *sqlx.Tx, accountID int64, status string) error {
+	_, err := tx.Exec(`
+		UPDATE stored_value_accounts SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE account_id = $1
+	`, accountID, status)
+	if err != nil {
+		return fmt.Errorf("failed to update stored value status: %w", err)
+	}
+
+	return nil
+}
+
+// insertTopUp posts value paid in for an account and the money it brought
+// into the drawer. Points and stored value cannot pay for stored value.
+func insertTopUp(tx *sqlx.Tx, account *models.StoredValueAccount, entry *models.StoredValueEntry) error {
+	if !entry.Amount.IsPositive() {
+		return fmt.Errorf("amount must be greater than 0")
+	}
+	if entry.PaymentMethodID == nil {
+		return fmt.Errorf("payment method is required")
+	}
+	methodType, err := paymentMethodType(tx, *entry.PaymentMethodID)
+	if err != nil {
+		return err
+	}
+	if methodType == "loyalty_points" || methodType == "stored_value" {
+		return fmt.Errorf("stored value has to be paid for with money")
+	}
+	if entry.ShiftID != nil {
+		if err = lockOpenShift(tx, *entry.ShiftID, entry.OutletID); err != nil {
+			return err
+		}
+	}
+
+	entry.EntryType = "top_up"
+	if entry.Description == nil {
+		description := "Top-up of " + storedValueLabel(account)
+		entry.Description = &description
+	}
+	if err = postStoredValue(tx, account, entry); err != nil {
+		return err
+	}
+
+	referenceType := "stored_value_entry"
+	return recordCashFlow(tx, &models.CashFlow{
+		OutletID:        entry.OutletID,
+		FlowType:        "inflow",
+		Category:        "deposit",
+		Amount:          entry.Amount,
+		Description:     *entry.Description,
+		ReferenceType:   &referenceType,
+		ReferenceID:     &entry.EntryID,
+		PaymentMethodID: entry.PaymentMethodID,
+		ShiftID:         entry.ShiftID,
+		TransactionDate: entry.CreatedAt,
+		CreatedBy:       entry.CreatedBy,
+	})
+}
+
+// storedValueSpent is what a transaction still has spent from one account,
+// net of redemptions given back
+type storedValueSpent struct {
+	AccountID int64           `db:"account_id"`
+	Amount    decimal.Decimal `db:"amount"`
+}
+
+// spentOnTransaction returns the accounts a transaction is still paid from,
+// the most recently redeemed first
+func spentOnTransaction(q sqlx.Queryer, transactionID int64) ([]storedValueSpent, error) {
+	var spent []storedValueSpent
+	err := sqlx.Select(q, &spent, `
+		SELECT account_id, -SUM(amount) AS amount
+		FROM stored_value_entries
+		WHERE transaction_id = $1 AND entry_type IN ('redeem', 'redeem_reversal')
+		GROUP BY account_id
+		HAVING SUM(amount) < 0
+		ORDER BY MAX(entry_id) DESC
+	`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redeemed stored value: %w", err)
+	}
+
+	return spent, nil
+}
+
+// redeemStoredValue pays for a transaction from stored value. The payment's
+// reference number is the voucher code; without one the customer's deposit
+// pays. What a single-use voucher has left after the payment lapses.
+func redeemStoredValue(tx *sqlx.Tx, payment *models.Payment, transaction *paymentTransaction) error {
+	var account *models.StoredValueAccount
+	var err error
+	code := strings.TrimSpace(payment.ReferenceNumber)
+	if code != "" {
+		if account, err = lockStoredValueAccount(tx, "UPPER(code) = UPPER($1)", code); err != nil {
+			return err
+		}
+		if account == nil {
+			return fmt.Errorf("voucher %s not found", code)
+		}
+	} else {
+		if transaction.CustomerID == nil {
+			return fmt.Errorf("enter a voucher code; only a customer's transaction can be paid from their deposit")
+		}
+		if account, err = lockStoredValueAccount(tx, "customer_id = $1 AND account_type = 'deposit'", *transaction.CustomerID); err != nil {
+			return err
+		}
+		if account == nil {
+			return fmt.Errorf("customer has no deposit")
+		}
+	}
+
+	amount := decimal.NewFromFloat(payment.Amount).Round(2)
+	label := storedValueLabel(account)
+	switch {
+	case !amount.IsPositive():
+		return fmt.Errorf("amount redeemed from %s must be greater than zero", label)
+	case account.Status == "used":
+		return fmt.Errorf("%s has been used", label)
+	case account.Status == "expired" || storedValueLapsed(account, payment.PaymentDate):
+		return fmt.Errorf("%s has expired", label)
+	case amount.GreaterThan(account.Balance):
+		return fmt.Errorf("%s has %s left; %s is needed", label, account.Balance.StringFixed(2), amount.StringFixed(2))
+	}
+
+	description := fmt.Sprintf("Redeemed on %s", transaction.TransactionNumber)
+	entry := &models.StoredValueEntry{
+		AccountID:     account.AccountID,
+		EntryType:     "redeem",
+		Amount:        amount.Neg(),
+		OutletID:      transaction.OutletID,
+		TransactionID: &payment.TransactionID,
+		PaymentID:     &payment.ID,
+		Description:   &description,
+		CreatedBy:     payment.CreatedBy,
+	}
+	if err = postStoredValue(tx, account, entry); err != nil {
+		return err
+	}
+	if !account.SingleUse {
+		return nil
+	}
+
+	if account.Balance.IsPositive() {
+		description := fmt.Sprintf("Unused on %s", transaction.TransactionNumber)
+		lapse := &models.StoredValueEntry{
+			AccountID:     account.AccountID,
+			EntryType:     "expire",
+			Amount:        account.Balance.Neg(),
+			OutletID:      transaction.OutletID,
+			TransactionID: &payment.TransactionID,
+			PaymentID:     &payment.ID,
+			Description:   &description,
+			CreatedBy:     payment.CreatedBy,
+		}
+		if err = postStoredValue(tx, account, lapse); err != nil {
+			return err
+		}
+	}
+	return setStoredValueStatus(tx, account.AccountID, "used")
+}
+
+// restoreStoredValuePayment gives back what a reversed payment took from
+// stored value, less anything already given back by refunds, together with
+// what it let lapse from a single-use voucher
+func restoreStoredValuePayment(tx *sqlx.Tx, paymentID, transactionID, outletID int64, transactionNumber string, userID int64) error {
+	var redeemed models.StoredValueEntry
+	err := tx.Get(&redeemed, `
+		SELECT account_id, amount FROM stored_value_entries
+		WHERE payment_id = $1 AND entry_type = 'redeem'
+	`, paymentID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get redeemed stored value: %w", err)
+	}
+
+	account, err := lockStoredValueAccount(tx, "account_id = $1", redeemed.AccountID)
+	if err != nil {
+		return err
+	}
+	spent, err := spentOnTransaction(tx, transactionID)
+	if err != nil {
+		return err
+	}
+
+	amount := redeemed.Amount.Neg()
+	left := decimal.Zero
+	for _, s := range spent {
+		if s.AccountID == account.AccountID {
+			left = s.Amount
+		}
+	}
+	amount = decimal.Min(amount, left)
+
+	description := fmt.Sprintf("Payment on %s reversed", transactionNumber)
+	if amount.IsPositive() {
+		entry := &models.StoredValueEntry{
+			AccountID:     account.AccountID,
+			EntryType:     "redeem_reversal",
+			Amount:        amount,
+			OutletID:      outletID,
+			TransactionID: &transactionID,
+			PaymentID:     &paymentID,
+			Description:   &description,
+			CreatedBy:     &userID,
+		}
+		if err = postStoredValue(tx, account, entry); err != nil {
+			return err
+		}
+	}
+
+	var lapsed decimal.Decimal
+	err = tx.Get(&lapsed, `
+		SELECT COALESCE(SUM(amount), 0) FROM stored_value_entries
+		WHERE payment_id = $1 AND entry_type IN ('expire', 'expire_reversal')
+	`, paymentID)
+	if err != nil {
+		return fmt.Errorf("failed to get lapsed stored value: %w", err)
+	}
+	if lapsed.IsNegative() {
+		entry := &models.StoredValueEntry{
+			AccountID:     account.AccountID,
+			EntryType:     "expire_reversal",
+			Amount:        lapsed.Neg(),
+			OutletID:      outletID,
+			TransactionID: &transactionID,
+			PaymentID:     &paymentID,
+			Description:   &description,
+			CreatedBy:     &userID,
+		}
+		if err = postStoredValue(tx, account, entry); err != nil {
+			return err
+		}
+	}
+
+	return reopenStoredValue(tx, account)
+}
+
+// refundStoredValue pays a refund to the stored value method back into the
+// deposits and vouchers the transaction was paid from, the most recently
+// redeemed first. A used single-use voucher can be spent again.
+func refundStoredValue(tx *sqlx.Tx, refund *models.Refund, transaction *salesTransaction) error {
+	spent, err := spentOnTransaction(tx, transaction.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	total := decimal.Zero
+	for _, s := range spent {
+		total = total.Add(s.Amount)
+	}
+	if refund.Amount.GreaterThan(total) {
+		return fmt.Errorf("only %s of transaction %s was paid from deposits and vouchers", total.StringFixed(2), transaction.TransactionNumber)
+	}
+
+	description := fmt.Sprintf("Refund %s", refund.RefundNumber)
+	remaining := refund.Amount
+	for _, s := range spent {
+		if !remaining.IsPositive() {
+			break
+		}
+		account, err := lockStoredValueAccount(tx, "account_id = $1", s.AccountID)
+		if err != nil {
+			return err
+		}
+
+		amount := decimal.Min(remaining, s.Amount)
+		entry := &models.StoredValueEntry{
+			AccountID:     account.AccountID,
+			EntryType:     "redeem_reversal",
+			Amount:        amount,
+			OutletID:      transaction.OutletID,
+			TransactionID: &transaction.TransactionID,
+			RefundID:      &refund.RefundID,
+			Description:   &description,
+			CreatedBy:     refund.CreatedBy,
+		}
+		if err = postStoredValue(tx, account, entry); err != nil {
+			return err
+		}
+		if err = reopenStoredValue(tx, account); err != nil {
+			return err
+		}
+		remaining = remaining.Sub(amount)
+	}
+
+	return nil
+}
+
+// restoreTransactionStoredValue gives back everything a voided transaction
+// still has spent from stored value, and what its payments let lapse from
+// single-use vouchers, and returns the value given back
+func restoreTransactionStoredValue(tx *sqlx.Tx, transaction *salesTransaction, userID int64) (decimal.Decimal, error) {
+	spent, err := spentOnTransaction(tx, transaction.TransactionID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	var lapses []struct {
+		PaymentID int64           `db:"payment_id"`
+		AccountID int64           `db:"account_id"`
+		Amount    decimal.Decimal `db:"amount"`
+	}
+	err = tx.Select(&lapses, `
+		SELECT payment_id, account_id, -SUM(amount) AS amount
+		FROM stored_value_entries
+		WHERE transaction_id = $1 AND payment_id IS NOT NULL AND entry_type IN ('expire', 'expire_reversal')
+		GROUP BY payment_id, account_id
+		HAVING SUM(amount) < 0
+	`, transaction.TransactionID)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get lapsed stored value: %w", err)
+	}
+
+	description := fmt.Sprintf("Void of %s", transaction.TransactionNumber)
+	restored := decimal.Zero
+	for _, s := range spent {
+		account, err := lockStoredValueAccount(tx, "account_id = $1", s.AccountID)
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		entry := &models.StoredValueEntry{
+			AccountID:     account.AccountID,
+			EntryType:     "redeem_reversal",
+			Amount:        s.Amount,
+			OutletID:      transaction.OutletID,
+			TransactionID: &transaction.TransactionID,
+			Description:   &description,
+			CreatedBy:     &userID,
+		}
+		if err = postStoredValue(tx, account, entry); err != nil {
+			return decimal.Zero, err
+		}
+		if err = reopenStoredValue(tx, account); err != nil {
+			return decimal.Zero, err
+		}
+		restored = restored.Add(s.Amount)
+	}
+
+	for _, lapse := range lapses {
+		account, err := lockStoredValueAccount(tx, "account_id = $1", lapse.AccountID)
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		paymentID := lapse.PaymentID
+		entry := &models.StoredValueEntry{
+			AccountID:     account.AccountID,
+			EntryType:     "expire_reversal",
+			Amount:        lapse.Amount,
+			OutletID:      transaction.OutletID,
+			TransactionID: &transaction.TransactionID,
+			PaymentID:     &paymentID,
+			Description:   &description,
+			CreatedBy:     &userID,
+		}
+		if err = postStoredValue(tx, account, entry); err != nil {
+			return decimal.Zero, err
+		}
+		if err = reopenStoredValue(tx, account); err != nil {
+			return decimal.Zero, err
+		}
+	}
+
+	return restored, nil
+}
+
+// reopenStoredValue makes a used single-use voucher spendable again once
+// value has been given back to it
+func reopenStoredValue(tx *sqlx.Tx, account *models.StoredValueAccount) error {
+	if account.Status != "used" || !account.Balance.IsPositive() {
+		return nil
+	}
+	account.Status = "active"
+	return setStoredValueStatus(tx, account.AccountID, "active")
+}
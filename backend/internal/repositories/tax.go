@@ -0,0 +1,323 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// TaxRepository defines data access for tax codes, outlet tax settings and
+// tax summaries
+type TaxRepository interface {
+	ListCodes(activeOnly bool) ([]models.TaxCode, error)
+	GetCodeByID(id int64) (*models.TaxCode, error)
+	CreateCode(code *models.TaxCode) error
+	UpdateCode(code *models.TaxCode) error
+	AssignCode(taxCodeID int64, productIDs, serviceIDs []int64) (int64, error)
+	GetOutletSettings(outletID int64) (*models.OutletTaxSettings, error)
+	UpdateOutletSettings(settings *models.OutletTaxSettings) error
+	GetItemTaxCodeID(productID, serviceID *int64) (*int64, error)
+	UpdateServiceDetailTotals(detail *models.ServiceDetail) error
+	GetTransactionSummary(transactionID int64) ([]models.TaxSummaryLine, error)
+	GetServiceJobSummary(serviceJobID int64) ([]models.TaxSummaryLine, error)
+	GetReport(filter *models.TaxReportFilter) (*models.TaxReport, error)
+}
+
+type taxRepository struct {
+	db *sqlx.DB
+}
+
+// NewTaxRepository creates a new tax repository
+func NewTaxRepository(db *sqlx.DB) TaxRepository {
+	return &taxRepository{db: db}
+}
+
+const taxCodeColumns = `
+	tax_code_id, code, name, rate, description, is_active, created_at, updated_at, created_by
+`
+
+func (r *taxRepository) ListCodes(activeOnly bool) ([]models.TaxCode, error) {
+	query := `SELECT ` + taxCodeColumns + ` FROM tax_codes`
+	if activeOnly {
+		query += ` WHERE is_active = TRUE`
+	}
+	query += ` ORDER BY rate DESC, code`
+
+	var codes []models.TaxCode
+	if err := r.db.Select(&codes, query); err != nil {
+		return nil, fmt.Errorf("failed to list tax codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (r *taxRepository) GetCodeByID(id int64) (*models.TaxCode, error) {
+	var code models.TaxCode
+	err := r.db.Get(&code, `SELECT `+taxCodeColumns+` FROM tax_codes WHERE tax_code_id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("tax code not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax code: %w", err)
+	}
+
+	return &code, nil
+}
+
+func (r *taxRepository) CreateCode(code *models.TaxCode) error {
+	err := r.db.QueryRow(`
+		INSERT INTO tax_codes (code, name, rate, description, is_active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING tax_code_id, created_at, updated_at
+	`, code.Code, code.Name, code.Rate, code.Description, code.IsActive, code.CreatedBy).
+		Scan(&code.TaxCodeID, &code.CreatedAt, &code.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create tax code: %w", err)
+	}
+
+	return nil
+}
+
+func (r *taxRepository) UpdateCode(code *models.TaxCode) error {
+	result, err := r.db.Exec(`
+		UPDATE tax_codes
+		SET name = $2, rate = $3, description = $4, is_active = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE tax_code_id = $1
+	`, code.TaxCodeID, code.Name, code.Rate, code.Description, code.IsActive)
+	if err != nil {
+		return fmt.Errorf("failed to update tax code: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("tax code not found")
+	}
+
+	return nil
+}
+
+// AssignCode sets the tax code of products and services and returns how
+// many were updated
+func (r *taxRepository) AssignCode(taxCodeID int64, productIDs, serviceIDs []int64) (int64, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var updated int64
+	if len(productIDs) > 0 {
+		result, err := tx.Exec(`
+			UPDATE products SET tax_code_id = $1, updated_at = CURRENT_TIMESTAMP
+			WHERE product_id = ANY($2) AND deleted_at IS NULL
+		`, taxCodeID, pq.Array(productIDs))
+		if err != nil {
+			return 0, fmt.Errorf("failed to assign tax code to products: %w", err)
+		}
+		rows, _ := result.RowsAffected()
+		updated += rows
+	}
+
+	if len(serviceIDs) > 0 {
+		result, err := tx.Exec(`
+			UPDATE services SET tax_code_id = $1, updated_at = CURRENT_TIMESTAMP
+			WHERE service_id = ANY($2) AND deleted_at IS NULL
+		`, taxCodeID, pq.Array(serviceIDs))
+		if err != nil {
+			return 0, fmt.Errorf("failed to assign tax code to services: %w", err)
+		}
+		rows, _ := result.RowsAffected()
+		updated += rows
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updated, nil
+}
+
+func (r *taxRepository) GetOutletSettings(outletID int64) (*models.OutletTaxSettings, error) {
+	var settings models.OutletTaxSettings
+	err := r.db.Get(&settings, `
+		SELECT outlet_id, name AS outlet_name, prices_include_tax, default_tax_code_id
+		FROM outlets WHERE outlet_id = $1 AND deleted_at IS NULL
+	`, outletID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("outlet not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outlet tax settings: %w", err)
+	}
+
+	if settings.DefaultTaxCodeID != nil {
+		if settings.DefaultTaxCode, err = r.GetCodeByID(*settings.DefaultTaxCodeID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &settings, nil
+}
+
+func (r *taxRepository) UpdateOutletSettings(settings *models.OutletTaxSettings) error {
+	result, err := r.db.Exec(`
+		UPDATE outlets SET prices_include_tax = $2, default_tax_code_id = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE outlet_id = $1 AND deleted_at IS NULL
+	`, settings.OutletID, settings.PricesIncludeTax, settings.DefaultTaxCodeID)
+	if err != nil {
+		return fmt.Errorf("failed to update outlet tax settings: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("outlet not found")
+	}
+
+	return nil
+}
+
+// GetItemTaxCodeID returns the tax code assigned to a product or service,
+// nil when it has none. A line with a service is taxed as the service.
+func (r *taxRepository) GetItemTaxCodeID(productID, serviceID *int64) (*int64, error) {
+	var taxCodeID *int64
+	var err error
+	switch {
+	case serviceID != nil:
+		err = r.db.Get(&taxCodeID, `SELECT tax_code_id FROM services WHERE service_id = $1`, *serviceID)
+	case productID != nil:
+		err = r.db.Get(&taxCodeID, `SELECT tax_code_id FROM products WHERE product_id = $1`, *productID)
+	default:
+		return nil, nil
+	}
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item tax code: %w", err)
+	}
+
+	return taxCodeID, nil
+}
+
+// UpdateServiceDetailTotals stores the promotion and tax computed for a
+// service job line
+func (r *taxRepository) UpdateServiceDetailTotals(detail *models.ServiceDetail) error {
+	_, err := r.db.Exec(`
+		UPDATE service_details
+		SET tax_code_id = $2, tax_rate = $3, net_amount = $4, discount_amount = $5, tax_amount = $6,
+			promotion_id = $7, promotion_discount = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE detail_id = $1
+	`, detail.ID, detail.TaxCodeID, detail.TaxRate, detail.NetAmount, detail.DiscountAmount, detail.TaxAmount,
+		detail.PromotionID, detail.PromotionDiscount)
+	if err != nil {
+		return fmt.Errorf("failed to update service detail totals: %w", err)
+	}
+
+	return nil
+}
+
+const taxSummaryQuery = `
+	SELECT d.tax_code_id, COALESCE(tc.code, '') AS code, COALESCE(tc.name, 'No tax code') AS name,
+		d.tax_rate AS rate, SUM(d.net_amount - d.discount_amount) AS taxable_amount,
+		SUM(d.tax_amount) AS tax_amount
+	FROM %s d
+	LEFT JOIN tax_codes tc ON tc.tax_code_id = d.tax_code_id
+	WHERE d.%s = $1 AND d.deleted_at IS NULL
+	GROUP BY d.tax_code_id, tc.code, tc.name, d.tax_rate
+	ORDER BY d.tax_rate DESC, code
+`
+
+// GetTransactionSummary groups a transaction's lines by tax code, as shown
+// on its invoice
+func (r *taxRepository) GetTransactionSummary(transactionID int64) ([]models.TaxSummaryLine, error) {
+	var lines []models.TaxSummaryLine
+	err := r.db.Select(&lines, fmt.Sprintf(taxSummaryQuery, "transaction_details", "transaction_id"), transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction tax summary: %w", err)
+	}
+
+	return lines, nil
+}
+
+// GetServiceJobSummary groups a service job's lines by tax code
+func (r *taxRepository) GetServiceJobSummary(serviceJobID int64) ([]models.TaxSummaryLine, error) {
+	var lines []models.TaxSummaryLine
+	err := r.db.Select(&lines, fmt.Sprintf(taxSummaryQuery, "service_details", "service_job_id"), serviceJobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service job tax summary: %w", err)
+	}
+
+	return lines, nil
+}
+
+// GetReport totals the output tax of a period by tax code and rate. Sales
+// count on their transaction date, returns on their return date and voids
+// on the day they were voided, for what was still sold at the time, as in
+// the general ledger.
+func (r *taxRepository) GetReport(filter *models.TaxReportFilter) (*models.TaxReport, error) {
+	args := []interface{}{filter.DateFrom.Format("2006-01-02"), filter.DateTo.Format("2006-01-02")}
+	outletCondition := ""
+	if filter.OutletID != nil {
+		outletCondition = " AND t.outlet_id = $3"
+		args = append(args, *filter.OutletID)
+	}
+
+	query := `
+		SELECT x.tax_code_id, COALESCE(tc.code, '') AS code, COALESCE(tc.name, 'No tax code') AS name, x.rate,
+			SUM(x.sales_taxable) AS sales_taxable, SUM(x.sales_tax) AS sales_tax,
+			SUM(x.returns_taxable) AS returns_taxable, SUM(x.returns_tax) AS returns_tax,
+			SUM(x.voids_taxable) AS voids_taxable, SUM(x.voids_tax) AS voids_tax
+		FROM (
+			SELECT d.tax_code_id, d.tax_rate AS rate,
+				d.net_amount - d.discount_amount AS sales_taxable, d.tax_amount AS sales_tax,
+				0 AS returns_taxable, 0 AS returns_tax, 0 AS voids_taxable, 0 AS voids_tax
+			FROM transaction_details d
+			JOIN transactions t ON t.transaction_id = d.transaction_id
+			WHERE d.deleted_at IS NULL AND t.deleted_at IS NULL
+				AND t.transaction_date::date BETWEEN $1 AND $2` + outletCondition + `
+			UNION ALL
+			SELECT ri.tax_code_id, ri.tax_rate, 0, 0, ri.net_amount - ri.discount_amount, ri.tax_amount, 0, 0
+			FROM sales_return_items ri
+			JOIN sales_returns sr ON sr.return_id = ri.return_id
+			JOIN transactions t ON t.transaction_id = sr.transaction_id
+			WHERE sr.return_date::date BETWEEN $1 AND $2` + outletCondition + `
+			UNION ALL
+			SELECT d.tax_code_id, d.tax_rate, 0, 0, 0, 0,
+				d.net_amount - d.discount_amount - COALESCE(ret.taxable, 0), d.tax_amount - COALESCE(ret.tax, 0)
+			FROM transaction_details d
+			JOIN transactions t ON t.transaction_id = d.transaction_id
+			LEFT JOIN (
+				SELECT transaction_detail_id, SUM(net_amount - discount_amount) AS taxable, SUM(tax_amount) AS tax
+				FROM sales_return_items GROUP BY transaction_detail_id
+			) ret ON ret.transaction_detail_id = d.detail_id
+			WHERE d.deleted_at IS NULL AND t.voided_at::date BETWEEN $1 AND $2` + outletCondition + `
+		) x
+		LEFT JOIN tax_codes tc ON tc.tax_code_id = x.tax_code_id
+		GROUP BY x.tax_code_id, tc.code, tc.name, x.rate
+		ORDER BY x.rate DESC, code
+	`
+
+	var lines []models.TaxReportLine
+	if err := r.db.Select(&lines, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get tax report: %w", err)
+	}
+
+	report := &models.TaxReport{
+		OutletID:   filter.OutletID,
+		DateFrom:   filter.DateFrom.Format("2006-01-02"),
+		DateTo:     filter.DateTo.Format("2006-01-02"),
+		Lines:      lines,
+		NetTaxable: decimal.Zero,
+		NetTax:     decimal.Zero,
+	}
+	for i := range report.Lines {
+		line := &report.Lines[i]
+		line.NetTaxable = line.SalesTaxable.Sub(line.ReturnsTaxable).Sub(line.VoidsTaxable)
+		line.NetTax = line.SalesTax.Sub(line.ReturnsTax).Sub(line.VoidsTax)
+		report.NetTaxable = report.NetTaxable.Add(line.NetTaxable)
+		report.NetTax = report.NetTax.Add(line.NetTax)
+	}
+
+	return report, nil
+}
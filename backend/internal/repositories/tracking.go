@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"fmt"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TrackingRepository interface defines public service job tracking operations
+type TrackingRepository interface {
+	GetByToken(token string) (*models.JobTracking, error)
+	GetTimeline(jobID int64) ([]models.JobTrackingStatus, error)
+	SetToken(jobID int64, token *string) error
+}
+
+type trackingRepository struct {
+	db *sqlx.DB
+}
+
+// NewTrackingRepository creates a new tracking repository
+func NewTrackingRepository(db *sqlx.DB) TrackingRepository {
+	return &trackingRepository{db: db}
+}
+
+func (r *trackingRepository) GetByToken(token string) (*models.JobTracking, error) {
+	query := `
+		SELECT sj.job_id, sj.job_number, COALESCE(o.name, '') as outlet_name,
+			   COALESCE(cv.vehicle_number, '') as vehicle_number,
+			   COALESCE(cv.brand, '') as vehicle_brand, COALESCE(cv.model, '') as vehicle_model,
+			   sj.queue_number, sj.status, sj.created_at as received_at,
+			   sj.estimated_start, sj.estimated_completion, sj.actual_completion, sj.final_amount
+		FROM service_jobs sj
+		LEFT JOIN outlets o ON sj.outlet_id = o.outlet_id
+		LEFT JOIN customer_vehicles cv ON sj.vehicle_id = cv.vehicle_id
+		WHERE sj.tracking_token = $1 AND sj.deleted_at IS NULL
+	`
+
+	var tracking models.JobTracking
+	err := r.db.Get(&tracking, query, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracked service job: %w", err)
+	}
+
+	return &tracking, nil
+}
+
+func (r *trackingRepository) GetTimeline(jobID int64) ([]models.JobTrackingStatus, error) {
+	query := `
+		SELECT new_status as status, created_at as changed_at
+		FROM service_job_histories
+		WHERE service_job_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at ASC, history_id ASC
+	`
+
+	var timeline []models.JobTrackingStatus
+	err := r.db.Select(&timeline, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service job timeline: %w", err)
+	}
+
+	return timeline, nil
+}
+
+func (r *trackingRepository) SetToken(jobID int64, token *string) error {
+	query := `
+		UPDATE service_jobs
+		SET tracking_token = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE job_id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, jobID, token)
+	if err != nil {
+		return fmt.Errorf("failed to update tracking token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("service job not found")
+	}
+
+	return nil
+}
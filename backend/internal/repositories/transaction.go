@@ -1,11 +1,14 @@
 package repositories
 
 import (
+	"database/sql"
 	"fmt"
 
+	"flutter-bengkel/internal/events"
 	"flutter-bengkel/internal/models"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
 )
 
 // ServiceJob Repository
@@ -21,6 +24,8 @@ type ServiceJobRepository interface {
 	GenerateJobNumber() (string, error)
 	AddDetail(detail *models.ServiceDetail) error
 	GetDetails(serviceJobID int64) ([]models.ServiceDetail, error)
+	GetDetailByID(id int64) (*models.ServiceDetail, error)
+	GetInvoiceLines(serviceJobID int64) ([]models.ServiceDetail, error)
 	UpdateDetail(id int64, detail *models.ServiceDetail) error
 	DeleteDetail(id int64) error
 }
@@ -38,11 +43,11 @@ func (r *serviceJobRepository) Create(serviceJob *models.ServiceJob) error {
 		INSERT INTO service_jobs (job_number, customer_id, vehicle_id, outlet_id, technician_id,
 								 queue_number, priority, status, problem_description, 
 								 estimated_completion, total_amount, discount_amount, 
-								 tax_amount, final_amount, warranty_period_days, notes)
+								 tax_amount, final_amount, warranty_period_days, odometer, notes, tracking_token)
 		VALUES (:job_number, :customer_id, :vehicle_id, :outlet_id, :technician_id,
 				:queue_number, :priority, :status, :problem_description, 
 				:estimated_completion, :total_amount, :discount_amount, 
-				:tax_amount, :final_amount, :warranty_period_days, :notes)
+				:tax_amount, :final_amount, :warranty_period_days, :odometer, :notes, :tracking_token)
 	`
 	
 	result, err := r.db.NamedExec(query, serviceJob)
@@ -63,8 +68,9 @@ func (r *serviceJobRepository) GetByID(id int64) (*models.ServiceJob, error) {
 	query := `
 		SELECT sj.id, sj.job_number, sj.customer_id, sj.vehicle_id, sj.outlet_id, sj.technician_id,
 			   sj.queue_number, sj.priority, sj.status, sj.problem_description, 
-			   sj.estimated_completion, sj.actual_completion, sj.total_amount, sj.discount_amount, 
-			   sj.tax_amount, sj.final_amount, sj.warranty_period_days, sj.notes, 
+			   sj.estimated_start, sj.estimated_completion, sj.started_at, sj.actual_completion, 
+			   sj.total_amount, sj.discount_amount, sj.membership_discount,
+			   sj.tax_amount, sj.final_amount, sj.warranty_period_days, sj.odometer, sj.notes, sj.tracking_token, sj.coupon_code, 
 			   sj.created_at, sj.updated_at,
 			   c.id as "customer.id", c.customer_code as "customer.customer_code", 
 			   c.name as "customer.name", c.phone as "customer.phone",
@@ -93,8 +99,9 @@ func (r *serviceJobRepository) GetByJobNumber(jobNumber string) (*models.Service
 	query := `
 		SELECT sj.id, sj.job_number, sj.customer_id, sj.vehicle_id, sj.outlet_id, sj.technician_id,
 			   sj.queue_number, sj.priority, sj.status, sj.problem_description, 
-			   sj.estimated_completion, sj.actual_completion, sj.total_amount, sj.discount_amount, 
-			   sj.tax_amount, sj.final_amount, sj.warranty_period_days, sj.notes, 
+			   sj.estimated_start, sj.estimated_completion, sj.started_at, sj.actual_completion, 
+			   sj.total_amount, sj.discount_amount, sj.membership_discount,
+			   sj.tax_amount, sj.final_amount, sj.warranty_period_days, sj.odometer, sj.notes, sj.tracking_token, sj.coupon_code, 
 			   sj.created_at, sj.updated_at,
 			   c.id as "customer.id", c.customer_code as "customer.customer_code", 
 			   c.name as "customer.name", c.phone as "customer.phone",
@@ -124,9 +131,9 @@ func (r *serviceJobRepository) Update(id int64, serviceJob *models.ServiceJob) e
 		UPDATE service_jobs 
 		SET technician_id = :technician_id, priority = :priority, status = :status,
 			estimated_completion = :estimated_completion, actual_completion = :actual_completion,
-			total_amount = :total_amount, discount_amount = :discount_amount, 
+			total_amount = :total_amount, discount_amount = :discount_amount, membership_discount = :membership_discount,
 			tax_amount = :tax_amount, final_amount = :final_amount, 
-			warranty_period_days = :warranty_period_days, notes = :notes
+			warranty_period_days = :warranty_period_days, odometer = :odometer, notes = :notes
 		WHERE id = :id
 	`
 	
@@ -147,14 +154,26 @@ func (r *serviceJobRepository) UpdateStatus(id int64, status string, userID int6
 	defer tx.Rollback()
 	
 	// Get current status
-	var currentStatus string
-	err = tx.Get(&currentStatus, "SELECT status FROM service_jobs WHERE id = ?", id)
+	var current struct {
+		Status    string `db:"status"`
+		JobNumber string `db:"job_number"`
+		OutletID  int64  `db:"outlet_id"`
+	}
+	err = tx.Get(&current, "SELECT status, job_number, outlet_id FROM service_jobs WHERE job_id = $1 FOR UPDATE", id)
 	if err != nil {
 		return fmt.Errorf("failed to get current status: %w", err)
 	}
+	currentStatus := current.Status
 	
-	// Update status
-	_, err = tx.Exec("UPDATE service_jobs SET status = ? WHERE id = ?", status, id)
+	// Update status, stamping when work starts and finishes
+	_, err = tx.Exec(`
+		UPDATE service_jobs 
+		SET status = $2,
+			started_at = CASE WHEN $2 = 'in_progress' THEN COALESCE(started_at, CURRENT_TIMESTAMP) ELSE started_at END,
+			actual_completion = CASE WHEN $2 = 'completed' THEN CURRENT_TIMESTAMP ELSE actual_completion END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE job_id = $1
+	`, id, status)
 	if err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
@@ -162,12 +181,27 @@ func (r *serviceJobRepository) UpdateStatus(id int64, status string, userID int6
 	// Add history record
 	_, err = tx.Exec(`
 		INSERT INTO service_job_histories (service_job_id, user_id, previous_status, new_status, notes)
-		VALUES (?, ?, ?, ?, ?)
+		VALUES ($1, $2, $3, $4, $5)
 	`, id, userID, currentStatus, status, notes)
 	if err != nil {
 		return fmt.Errorf("failed to create history: %w", err)
 	}
 	
+	if status != currentStatus {
+		err = appendEvent(tx, events.ServiceJobStatusChanged, "service_job", id, &current.OutletID, events.ServiceJobStatusChangedPayload{
+			JobID:          id,
+			JobNumber:      current.JobNumber,
+			OutletID:       current.OutletID,
+			PreviousStatus: currentStatus,
+			Status:         status,
+			ChangedBy:      userID,
+			Notes:          notes,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -225,8 +259,9 @@ func (r *serviceJobRepository) List(offset, limit int, outletID *int64, status s
 	query := fmt.Sprintf(`
 		SELECT sj.id, sj.job_number, sj.customer_id, sj.vehicle_id, sj.outlet_id, sj.technician_id,
 			   sj.queue_number, sj.priority, sj.status, sj.problem_description, 
-			   sj.estimated_completion, sj.actual_completion, sj.total_amount, sj.discount_amount, 
-			   sj.tax_amount, sj.final_amount, sj.warranty_period_days, sj.notes, 
+			   sj.estimated_start, sj.estimated_completion, sj.started_at, sj.actual_completion, 
+			   sj.total_amount, sj.discount_amount, sj.membership_discount,
+			   sj.tax_amount, sj.final_amount, sj.warranty_period_days, sj.odometer, sj.notes, sj.tracking_token, sj.coupon_code, 
 			   sj.created_at, sj.updated_at,
 			   c.id as "customer.id", c.customer_code as "customer.customer_code", 
 			   c.name as "customer.name", c.phone as "customer.phone",
@@ -287,8 +322,10 @@ func (r *serviceJobRepository) GenerateJobNumber() (string, error) {
 
 func (r *serviceJobRepository) AddDetail(detail *models.ServiceDetail) error {
 	query := `
-		INSERT INTO service_details (service_job_id, product_id, service_id, quantity, unit_price, total_price, notes)
-		VALUES (:service_job_id, :product_id, :service_id, :quantity, :unit_price, :total_price, :notes)
+		INSERT INTO service_details (service_job_id, product_id, service_id, quantity, unit_price, total_price, 
+									 net_amount, notes, price_list_id, unit_cost)
+		VALUES (:service_job_id, :product_id, :service_id, :quantity, :unit_price, :total_price, 
+				:total_price, :notes, :price_list_id, (SELECT cost_price FROM products WHERE product_id = :product_id))
 	`
 	
 	result, err := r.db.NamedExec(query, detail)
@@ -308,7 +345,10 @@ func (r *serviceJobRepository) AddDetail(detail *models.ServiceDetail) error {
 func (r *serviceJobRepository) GetDetails(serviceJobID int64) ([]models.ServiceDetail, error) {
 	query := `
 		SELECT sd.id, sd.service_job_id, sd.product_id, sd.service_id, sd.quantity, 
-			   sd.unit_price, sd.total_price, sd.notes, sd.created_at,
+			   sd.unit_price, sd.total_price, sd.tax_code_id, sd.tax_rate, sd.net_amount, 
+			   sd.discount_amount, sd.tax_amount, sd.promotion_id, sd.promotion_discount, sd.approval_status, 
+			   sd.notes, sd.service_job_package_id, sd.list_price, sd.price_list_id, sd.unit_cost, 
+			   sd.created_at,
 			   p.id as "product.id", p.product_code as "product.product_code", 
 			   p.name as "product.name",
 			   s.id as "service.id", s.service_code as "service.service_code", 
@@ -329,10 +369,48 @@ func (r *serviceJobRepository) GetDetails(serviceJobID int64) ([]models.ServiceD
 	return details, nil
 }
 
+func (r *serviceJobRepository) GetDetailByID(id int64) (*models.ServiceDetail, error) {
+	var detail models.ServiceDetail
+	err := r.db.Get(&detail, `
+		SELECT detail_id AS id, service_job_id, product_id, service_id, quantity, unit_price, total_price, 
+			   approval_status, COALESCE(notes, '') AS notes, service_job_package_id, list_price, 
+			   price_list_id, unit_cost, created_at
+		FROM service_details WHERE detail_id = $1
+	`, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("service detail not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service detail: %w", err)
+	}
+	
+	return &detail, nil
+}
+
+// GetInvoiceLines returns the lines a service job's invoice sells, at the
+// prices agreed on the job
+func (r *serviceJobRepository) GetInvoiceLines(serviceJobID int64) ([]models.ServiceDetail, error) {
+	var details []models.ServiceDetail
+	err := r.db.Select(&details, `
+		SELECT detail_id AS id, service_job_id, product_id, service_id, quantity, unit_price, total_price, 
+			   tax_code_id, approval_status, COALESCE(notes, '') AS notes, service_job_package_id, list_price, 
+			   price_list_id, created_at
+		FROM service_details
+		WHERE service_job_id = $1 AND deleted_at IS NULL
+		ORDER BY detail_id
+	`, serviceJobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service job lines: %w", err)
+	}
+	
+	return details, nil
+}
+
 func (r *serviceJobRepository) UpdateDetail(id int64, detail *models.ServiceDetail) error {
 	query := `
 		UPDATE service_details 
-		SET quantity = :quantity, unit_price = :unit_price, total_price = :total_price, notes = :notes
+		SET quantity = :quantity, unit_price = :unit_price, total_price = :total_price, notes = :notes,
+			price_list_id = :price_list_id
 		WHERE id = :id
 	`
 	
@@ -362,7 +440,8 @@ type TransactionRepository interface {
 	GetByID(id int64) (*models.Transaction, error)
 	GetByTransactionNumber(transactionNumber string) (*models.Transaction, error)
 	Update(id int64, transaction *models.Transaction) error
-	Delete(id int64) error
+	Void(id int64, reason string, paymentMethodID, shiftID *int64, voidedBy int64) (*models.TransactionVoid, error)
+	GetExceptionReport(filter *models.ExceptionFilter) (*models.ExceptionReport, error)
 	List(offset, limit int, outletID *int64, transactionType string, search string) ([]models.Transaction, int64, error)
 	GenerateTransactionNumber(transactionType string) (string, error)
 	AddDetail(detail *models.TransactionDetail) error
@@ -380,27 +459,107 @@ func NewTransactionRepository(db *sqlx.DB) TransactionRepository {
 	return &transactionRepository{db: db}
 }
 
+// Create posts the transaction with its details and records a
+// TransactionPosted event in one database transaction
 func (r *transactionRepository) Create(transaction *models.Transaction) error {
-	query := `
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	// A service job is invoiced once, so its parts leave stock once. Locking
+	// the job keeps two invoices for it from being posted side by side.
+	if transaction.ServiceJobID != nil {
+		var invoiced bool
+		err = tx.QueryRow(`
+			SELECT EXISTS (SELECT 1 FROM transactions t WHERE t.service_job_id = sj.job_id AND t.voided_at IS NULL)
+			FROM service_jobs sj WHERE sj.job_id = $1 FOR UPDATE
+		`, *transaction.ServiceJobID).Scan(&invoiced)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("service job not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock service job: %w", err)
+		}
+		if invoiced {
+			return fmt.Errorf("service job has already been invoiced")
+		}
+	}
+	
+	err = tx.QueryRow(`
 		INSERT INTO transactions (transaction_number, transaction_type, customer_id, outlet_id, 
 								  user_id, service_job_id, subtotal_amount, discount_amount, 
-								  tax_amount, total_amount, payment_status, notes, transaction_date)
-		VALUES (:transaction_number, :transaction_type, :customer_id, :outlet_id, 
-				:user_id, :service_job_id, :subtotal_amount, :discount_amount, 
-				:tax_amount, :total_amount, :payment_status, :notes, :transaction_date)
-	`
-	
-	result, err := r.db.NamedExec(query, transaction)
+								  tax_amount, total_amount, prices_include_tax, membership_tier_id, membership_discount,
+								  payment_status, approval_status, notes, transaction_date, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $5)
+		RETURNING transaction_id, created_at, updated_at
+	`, transaction.TransactionNumber, transaction.TransactionType, transaction.CustomerID, transaction.OutletID,
+		transaction.UserID, transaction.ServiceJobID, transaction.SubtotalAmount, transaction.DiscountAmount,
+		transaction.TaxAmount, transaction.TotalAmount, transaction.PricesIncludeTax, transaction.MembershipTierID,
+		transaction.MembershipDiscount, transaction.PaymentStatus, transaction.ApprovalStatus, transaction.Notes, transaction.TransactionDate).Scan(&transaction.ID, &transaction.CreatedAt, &transaction.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create transaction: %w", err)
 	}
 	
-	id, err := result.LastInsertId()
+	if err = insertTransactionPackages(tx, transaction); err != nil {
+		return err
+	}
+	
+	for i := range transaction.Details {
+		detail := &transaction.Details[i]
+		detail.TransactionID = transaction.ID
+		err = tx.QueryRow(`
+			INSERT INTO transaction_details (transaction_id, product_id, service_id, description, 
+											 quantity, unit_price, total_price, tax_code_id, tax_rate, 
+											 net_amount, discount_amount, tax_amount, promotion_id, promotion_discount,
+											 transaction_package_id, list_price, price_list_id, unit_cost)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
+					(SELECT cost_price FROM products WHERE product_id = $2))
+			RETURNING detail_id, unit_cost, created_at, updated_at
+		`, detail.TransactionID, detail.ProductID, detail.ServiceID, detail.Description,
+			detail.Quantity, detail.UnitPrice, detail.TotalPrice, detail.TaxCodeID, detail.TaxRate,
+			detail.NetAmount, detail.DiscountAmount, detail.TaxAmount, detail.PromotionID,
+			detail.PromotionDiscount, detail.PackageLineID, detail.ListPrice, detail.PriceListID).
+			Scan(&detail.ID, &detail.UnitCost, &detail.CreatedAt, &detail.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create transaction detail: %w", err)
+		}
+	}
+	
+	if err = takeStock(tx, transaction); err != nil {
+		return err
+	}
+	
+	if err = insertRedemptions(tx, transaction); err != nil {
+		return err
+	}
+	
+	if err = insertApprovals(tx, transaction); err != nil {
+		return err
+	}
+	
+	err = appendEvent(tx, events.TransactionPosted, "transaction", transaction.ID, &transaction.OutletID, events.TransactionPostedPayload{
+		TransactionID:     transaction.ID,
+		TransactionNumber: transaction.TransactionNumber,
+		TransactionType:   transaction.TransactionType,
+		OutletID:          transaction.OutletID,
+		CustomerID:        transaction.CustomerID,
+		ServiceJobID:      transaction.ServiceJobID,
+		SubtotalAmount:    transaction.SubtotalAmount,
+		DiscountAmount:    transaction.DiscountAmount,
+		TaxAmount:         transaction.TaxAmount,
+		TotalAmount:       transaction.TotalAmount,
+		UserID:            transaction.UserID,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get transaction ID: %w", err)
+		return err
+	}
+	
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	
-	transaction.ID = id
 	return nil
 }
 
@@ -408,7 +567,7 @@ func (r *transactionRepository) GetByID(id int64) (*models.Transaction, error) {
 	query := `
 		SELECT t.id, t.transaction_number, t.transaction_type, t.customer_id, t.outlet_id, 
 			   t.user_id, t.service_job_id, t.subtotal_amount, t.discount_amount, 
-			   t.tax_amount, t.total_amount, t.payment_status, t.notes, 
+			   t.tax_amount, t.total_amount, t.prices_include_tax, t.membership_tier_id, t.membership_discount, t.payment_status, t.approval_status, t.notes, 
 			   t.transaction_date, t.created_at, t.updated_at,
 			   c.id as "customer.id", c.customer_code as "customer.customer_code", 
 			   c.name as "customer.name", c.phone as "customer.phone",
@@ -436,7 +595,7 @@ func (r *transactionRepository) GetByTransactionNumber(transactionNumber string)
 	query := `
 		SELECT t.id, t.transaction_number, t.transaction_type, t.customer_id, t.outlet_id, 
 			   t.user_id, t.service_job_id, t.subtotal_amount, t.discount_amount, 
-			   t.tax_amount, t.total_amount, t.payment_status, t.notes, 
+			   t.tax_amount, t.total_amount, t.prices_include_tax, t.membership_tier_id, t.membership_discount, t.payment_status, t.approval_status, t.notes, 
 			   t.transaction_date, t.created_at, t.updated_at,
 			   c.id as "customer.id", c.customer_code as "customer.customer_code", 
 			   c.name as "customer.name", c.phone as "customer.phone",
@@ -478,17 +637,6 @@ func (r *transactionRepository) Update(id int64, transaction *models.Transaction
 	return nil
 }
 
-func (r *transactionRepository) Delete(id int64) error {
-	query := `UPDATE transactions SET payment_status = 'cancelled' WHERE id = ?`
-	
-	_, err := r.db.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete transaction: %w", err)
-	}
-	
-	return nil
-}
-
 func (r *transactionRepository) List(offset, limit int, outletID *int64, transactionType string, search string) ([]models.Transaction, int64, error) {
 	whereClause := "WHERE t.payment_status != 'cancelled'"
 	args := []interface{}{}
@@ -527,7 +675,7 @@ func (r *transactionRepository) List(offset, limit int, outletID *int64, transac
 	query := fmt.Sprintf(`
 		SELECT t.id, t.transaction_number, t.transaction_type, t.customer_id, t.outlet_id, 
 			   t.user_id, t.service_job_id, t.subtotal_amount, t.discount_amount, 
-			   t.tax_amount, t.total_amount, t.payment_status, t.notes, 
+			   t.tax_amount, t.total_amount, t.prices_include_tax, t.membership_tier_id, t.membership_discount, t.payment_status, t.approval_status, t.notes, 
 			   t.transaction_date, t.created_at, t.updated_at,
 			   c.id as "customer.id", c.customer_code as "customer.customer_code", 
 			   c.name as "customer.name", c.phone as "customer.phone",
@@ -584,9 +732,10 @@ func (r *transactionRepository) GenerateTransactionNumber(transactionType string
 func (r *transactionRepository) AddDetail(detail *models.TransactionDetail) error {
 	query := `
 		INSERT INTO transaction_details (transaction_id, product_id, service_id, description, 
-										quantity, unit_price, total_price)
+										quantity, unit_price, total_price, net_amount, unit_cost)
 		VALUES (:transaction_id, :product_id, :service_id, :description, 
-				:quantity, :unit_price, :total_price)
+				:quantity, :unit_price, :total_price, :total_price,
+				(SELECT cost_price FROM products WHERE product_id = :product_id))
 	`
 	
 	result, err := r.db.NamedExec(query, detail)
@@ -606,7 +755,9 @@ func (r *transactionRepository) AddDetail(detail *models.TransactionDetail) erro
 func (r *transactionRepository) GetDetails(transactionID int64) ([]models.TransactionDetail, error) {
 	query := `
 		SELECT td.id, td.transaction_id, td.product_id, td.service_id, td.description,
-			   td.quantity, td.unit_price, td.total_price, td.created_at,
+			   td.quantity, td.unit_price, td.total_price, td.tax_code_id, td.tax_rate,
+			   td.net_amount, td.discount_amount, td.tax_amount, td.promotion_id, td.promotion_discount, 
+			   td.transaction_package_id, td.list_price, td.price_list_id, td.unit_cost, td.created_at,
 			   p.id as "product.id", p.product_code as "product.product_code", 
 			   p.name as "product.name",
 			   s.id as "service.id", s.service_code as "service.service_code", 
@@ -671,10 +822,13 @@ type PaymentRepository interface {
 	Create(payment *models.Payment) error
 	GetByID(id int64) (*models.Payment, error)
 	GetByTransactionID(transactionID int64) ([]models.Payment, error)
-	Delete(id int64) error
+	Delete(id int64, deletedBy int64) error
 	List(offset, limit int, transactionID *int64) ([]models.Payment, int64, error)
 	ListPaymentMethods() ([]models.PaymentMethod, error)
 	GeneratePaymentNumber() (string, error)
+	Checkout(checkout *models.Checkout, tenders []models.CheckoutTenderRequest) error
+	GetCheckoutByID(id int64) (*models.Checkout, error)
+	ListCheckouts(filter *models.CheckoutFilter, offset, limit int) ([]models.Checkout, int64, error)
 }
 
 type paymentRepository struct {
@@ -685,38 +839,197 @@ func NewPaymentRepository(db *sqlx.DB) PaymentRepository {
 	return &paymentRepository{db: db}
 }
 
+// Create records the payment, updates the transaction's payment status and
+// records a PaymentReceived event in one database transaction. The
+// transaction row is locked so concurrent payments cannot overpay it; what
+// is outstanding accounts for returns, refunds and store credit.
 func (r *paymentRepository) Create(payment *models.Payment) error {
-	query := `
-		INSERT INTO payments (payment_number, transaction_id, payment_method_id, amount, 
-							  payment_date, reference_number, notes)
-		VALUES (:payment_number, :transaction_id, :payment_method_id, :amount, 
-				:payment_date, :reference_number, :notes)
-	`
+	if payment.Amount <= 0 {
+		return fmt.Errorf("payment amount must be greater than zero")
+	}
 	
-	result, err := r.db.NamedExec(query, payment)
+	tx, err := r.db.Beginx()
 	if err != nil {
-		return fmt.Errorf("failed to create payment: %w", err)
+		return fmt.Errorf("failed to start transaction: %w", err)
 	}
+	defer tx.Rollback()
 	
-	id, err := result.LastInsertId()
+	transaction, err := lockPaymentTransaction(tx, payment.TransactionID)
+	if err != nil {
+		return err
+	}
+	
+	balance, err := transactionBalance(tx, payment.TransactionID)
 	if err != nil {
-		return fmt.Errorf("failed to get payment ID: %w", err)
+		return err
+	}
+	
+	if decimal.NewFromFloat(payment.Amount).GreaterThan(balance.Outstanding) {
+		return fmt.Errorf("payment amount exceeds remaining amount")
+	}
+	
+	payment.TenderedAmount = payment.Amount
+	payment.ChangeAmount = 0
+	if err = insertPayment(tx, payment, transaction); err != nil {
+		return err
+	}
+	
+	balance, err = updatePaymentStatus(tx, payment.TransactionID)
+	if err != nil {
+		return err
+	}
+	
+	if err = appendPaymentReceived(tx, payment, transaction, balance); err != nil {
+		return err
+	}
+	
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	
-	payment.ID = id
 	return nil
 }
 
+// paymentTransaction is the transaction a payment is taken against
+type paymentTransaction struct {
+	TransactionNumber string          `db:"transaction_number"`
+	OutletID          int64           `db:"outlet_id"`
+	CustomerID        *int64          `db:"customer_id"`
+	ServiceJobID      *int64          `db:"service_job_id"`
+	TotalAmount       decimal.Decimal `db:"total_amount"`
+	PaymentStatus     string          `db:"payment_status"`
+	ApprovalStatus    string          `db:"approval_status"`
+}
+
+// lockPaymentTransaction locks a transaction that can still be paid
+func lockPaymentTransaction(tx *sqlx.Tx, id int64) (*paymentTransaction, error) {
+	var transaction paymentTransaction
+	err := tx.Get(&transaction, `
+		SELECT transaction_number, outlet_id, customer_id, service_job_id, total_amount, payment_status,
+			approval_status
+		FROM transactions WHERE transaction_id = $1 AND deleted_at IS NULL FOR UPDATE
+	`, id)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("transaction not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if transaction.PaymentStatus == "cancelled" || transaction.PaymentStatus == "void" {
+		return nil, fmt.Errorf("transaction %s is %s", transaction.TransactionNumber, transaction.PaymentStatus)
+	}
+	switch transaction.ApprovalStatus {
+	case "pending":
+		return nil, fmt.Errorf("transaction %s is awaiting approval", transaction.TransactionNumber)
+	case "rejected":
+		return nil, fmt.Errorf("transaction %s was not approved and has to be voided", transaction.TransactionNumber)
+	}
+	
+	return &transaction, nil
+}
+
+// paymentMethodType returns the type of a payment method, which decides
+// whether a payment is money or a tender held for the customer
+func paymentMethodType(q sqlx.Queryer, methodID int64) (string, error) {
+	var methodType string
+	err := sqlx.Get(q, &methodType, `SELECT type FROM payment_methods WHERE method_id = $1`, methodID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("payment method %d not found", methodID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get payment method: %w", err)
+	}
+
+	return methodType, nil
+}
+
+// insertPayment records a payment against a locked transaction and the money
+// it brought in. Change handed back never reaches the drawer, so the cash
+// flow is the applied amount. Payments with points or stored value redeem
+// them instead.
+func insertPayment(tx *sqlx.Tx, payment *models.Payment, transaction *paymentTransaction) error {
+	if payment.ShiftID != nil {
+		if err := lockOpenShift(tx, *payment.ShiftID, transaction.OutletID); err != nil {
+			return err
+		}
+	}
+	
+	err := tx.QueryRow(`
+		INSERT INTO payments (payment_number, transaction_id, payment_method_id, amount, tendered_amount,
+							  change_amount, payment_date, reference_number, notes, shift_id, checkout_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING payment_id, created_at, updated_at
+	`, payment.PaymentNumber, payment.TransactionID, payment.PaymentMethodID, payment.Amount, payment.TenderedAmount,
+		payment.ChangeAmount, payment.PaymentDate, payment.ReferenceNumber, payment.Notes, payment.ShiftID,
+		payment.CheckoutID, payment.CreatedBy).
+		Scan(&payment.ID, &payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create payment: %w", err)
+	}
+	
+	// Points and stored value are spent from what the customer holds and
+	// never reach the drawer
+	methodType, err := paymentMethodType(tx, payment.PaymentMethodID)
+	if err != nil {
+		return err
+	}
+	switch methodType {
+	case "loyalty_points":
+		return redeemPoints(tx, payment, transaction)
+	case "stored_value":
+		return redeemStoredValue(tx, payment, transaction)
+	}
+	
+	referenceType := "payment"
+	return recordCashFlow(tx, &models.CashFlow{
+		OutletID:        transaction.OutletID,
+		FlowType:        "inflow",
+		Category:        "sales",
+		Amount:          decimal.NewFromFloat(payment.Amount),
+		Description:     fmt.Sprintf("Payment %s for %s", payment.PaymentNumber, transaction.TransactionNumber),
+		ReferenceType:   &referenceType,
+		ReferenceID:     &payment.ID,
+		PaymentMethodID: &payment.PaymentMethodID,
+		ShiftID:         payment.ShiftID,
+		TransactionDate: payment.PaymentDate,
+		CreatedBy:       payment.CreatedBy,
+	})
+}
+
+// appendPaymentReceived records the PaymentReceived event of a payment with
+// the transaction's balance after it
+func appendPaymentReceived(tx *sqlx.Tx, payment *models.Payment, transaction *paymentTransaction, balance *models.TransactionBalance) error {
+	return appendEvent(tx, events.PaymentReceived, "payment", payment.ID, &transaction.OutletID, events.PaymentReceivedPayload{
+		PaymentID:         payment.ID,
+		PaymentNumber:     payment.PaymentNumber,
+		PaymentMethodID:   payment.PaymentMethodID,
+		TransactionID:     payment.TransactionID,
+		TransactionNumber: transaction.TransactionNumber,
+		OutletID:          transaction.OutletID,
+		CustomerID:        transaction.CustomerID,
+		ServiceJobID:      transaction.ServiceJobID,
+		Amount:            payment.Amount,
+		Remaining:         balance.Outstanding.InexactFloat64(),
+		PaymentStatus:     balance.PaymentStatus,
+		CheckoutID:        payment.CheckoutID,
+	})
+}
+
+const paymentColumns = `
+	p.payment_id AS id, p.payment_number, p.transaction_id, p.payment_method_id, p.amount,
+	p.payment_date, COALESCE(p.reference_number, '') AS reference_number, COALESCE(p.notes, '') AS notes,
+	p.shift_id, p.checkout_id, p.tendered_amount, p.change_amount, p.created_by, p.created_at, p.updated_at,
+	pm.method_id AS "payment_method.id", pm.name AS "payment_method.name",
+	pm.type AS "payment_method.type"
+`
+
 func (r *paymentRepository) GetByID(id int64) (*models.Payment, error) {
-	query := `
-		SELECT p.id, p.payment_number, p.transaction_id, p.payment_method_id, p.amount,
-			   p.payment_date, p.reference_number, p.notes, p.created_at,
-			   pm.id as "payment_method.id", pm.name as "payment_method.name", 
-			   pm.type as "payment_method.type"
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM payments p
-		LEFT JOIN payment_methods pm ON p.payment_method_id = pm.id
-		WHERE p.id = ?
-	`
+		JOIN payment_methods pm ON p.payment_method_id = pm.method_id
+		WHERE p.payment_id = $1 AND p.deleted_at IS NULL
+	`, paymentColumns)
 	
 	var payment models.Payment
 	err := r.db.Get(&payment, query, id)
@@ -728,16 +1041,13 @@ func (r *paymentRepository) GetByID(id int64) (*models.Payment, error) {
 }
 
 func (r *paymentRepository) GetByTransactionID(transactionID int64) ([]models.Payment, error) {
-	query := `
-		SELECT p.id, p.payment_number, p.transaction_id, p.payment_method_id, p.amount,
-			   p.payment_date, p.reference_number, p.notes, p.created_at,
-			   pm.id as "payment_method.id", pm.name as "payment_method.name", 
-			   pm.type as "payment_method.type"
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM payments p
-		LEFT JOIN payment_methods pm ON p.payment_method_id = pm.id
-		WHERE p.transaction_id = ?
+		JOIN payment_methods pm ON p.payment_method_id = pm.method_id
+		WHERE p.transaction_id = $1 AND p.deleted_at IS NULL
 		ORDER BY p.payment_date DESC
-	`
+	`, paymentColumns)
 	
 	var payments []models.Payment
 	err := r.db.Select(&payments, query, transactionID)
@@ -748,24 +1058,139 @@ func (r *paymentRepository) GetByTransactionID(transactionID int64) ([]models.Pa
 	return payments, nil
 }
 
-func (r *paymentRepository) Delete(id int64) error {
-	query := `DELETE FROM payments WHERE id = ?`
+// Delete reverses a payment taken by mistake: the payment and its cash flow
+// are soft-deleted, points it spent are given back, the transaction's
+// payment status is recalculated and a PaymentReversed event is recorded.
+// Money already handed back, or taken in a shift that has since been
+// closed, is corrected with a refund instead.
+func (r *paymentRepository) Delete(id int64, deletedBy int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
 	
-	_, err := r.db.Exec(query, id)
+	var transactionID int64
+	err = tx.Get(&transactionID, `SELECT transaction_id FROM payments WHERE payment_id = $1 AND deleted_at IS NULL`, id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("payment not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get payment: %w", err)
+	}
+	
+	var transaction struct {
+		TransactionNumber string `db:"transaction_number"`
+		OutletID          int64  `db:"outlet_id"`
+		PaymentStatus     string `db:"payment_status"`
+	}
+	err = tx.Get(&transaction, `
+		SELECT transaction_number, outlet_id, payment_status
+		FROM transactions WHERE transaction_id = $1 FOR UPDATE
+	`, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if transaction.PaymentStatus == "void" {
+		return fmt.Errorf("transaction %s is void", transaction.TransactionNumber)
+	}
+	
+	var payment struct {
+		PaymentNumber   string          `db:"payment_number"`
+		PaymentMethodID int64           `db:"payment_method_id"`
+		Amount          decimal.Decimal `db:"amount"`
+		ShiftID         *int64          `db:"shift_id"`
+		Refunds         int             `db:"refunds"`
+	}
+	err = tx.Get(&payment, `
+		SELECT p.payment_number, p.payment_method_id, p.amount, p.shift_id,
+			(SELECT COUNT(*) FROM refunds rf WHERE rf.payment_id = p.payment_id) AS refunds
+		FROM payments p
+		WHERE p.payment_id = $1 AND p.deleted_at IS NULL FOR UPDATE OF p
+	`, id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("payment not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment.Refunds > 0 {
+		return fmt.Errorf("payment %s has refunds and cannot be deleted", payment.PaymentNumber)
+	}
+	if payment.ShiftID != nil {
+		if err = lockOpenShift(tx, *payment.ShiftID, transaction.OutletID); err != nil {
+			return fmt.Errorf("payment %s was taken in a closed shift; record a refund instead", payment.PaymentNumber)
+		}
+	}
+	
+	_, err = tx.Exec(`
+		UPDATE payments SET deleted_at = CURRENT_TIMESTAMP, deleted_by = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE payment_id = $1
+	`, id, deletedBy)
 	if err != nil {
 		return fmt.Errorf("failed to delete payment: %w", err)
 	}
 	
+	_, err = tx.Exec(`
+		UPDATE cash_flows SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE reference_type = 'payment' AND reference_id = $1 AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete payment cash flow: %w", err)
+	}
+	
+	methodType, err := paymentMethodType(tx, payment.PaymentMethodID)
+	if err != nil {
+		return err
+	}
+	switch methodType {
+	case "loyalty_points":
+		err = restorePaymentPoints(tx, id, transactionID, transaction.TransactionNumber, deletedBy)
+	case "stored_value":
+		err = restoreStoredValuePayment(tx, id, transactionID, transaction.OutletID, transaction.TransactionNumber, deletedBy)
+	}
+	if err != nil {
+		return err
+	}
+	
+	balance, err := updatePaymentStatus(tx, transactionID)
+	if err != nil {
+		return err
+	}
+	if balance.NetPaid.IsNegative() {
+		return fmt.Errorf("payment %s has been refunded or credited and cannot be deleted", payment.PaymentNumber)
+	}
+	
+	err = appendEvent(tx, events.PaymentReversed, "payment", id, &transaction.OutletID, events.PaymentReversedPayload{
+		PaymentID:         id,
+		PaymentNumber:     payment.PaymentNumber,
+		TransactionID:     transactionID,
+		TransactionNumber: transaction.TransactionNumber,
+		OutletID:          transaction.OutletID,
+		Amount:            payment.Amount.StringFixed(2),
+		PaymentStatus:     balance.PaymentStatus,
+		ReversedBy:        deletedBy,
+	})
+	if err != nil {
+		return err
+	}
+	
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	
 	return nil
 }
 
 func (r *paymentRepository) List(offset, limit int, transactionID *int64) ([]models.Payment, int64, error) {
-	whereClause := "WHERE 1=1"
+	whereClause := "WHERE p.deleted_at IS NULL"
 	args := []interface{}{}
+	argIndex := 1
 	
 	if transactionID != nil {
-		whereClause += " AND p.transaction_id = ?"
+		whereClause += fmt.Sprintf(" AND p.transaction_id = $%d", argIndex)
 		args = append(args, *transactionID)
+		argIndex++
 	}
 	
 	// Count total
@@ -783,16 +1208,13 @@ func (r *paymentRepository) List(offset, limit int, transactionID *int64) ([]mod
 	
 	// Get payments
 	query := fmt.Sprintf(`
-		SELECT p.id, p.payment_number, p.transaction_id, p.payment_method_id, p.amount,
-			   p.payment_date, p.reference_number, p.notes, p.created_at,
-			   pm.id as "payment_method.id", pm.name as "payment_method.name", 
-			   pm.type as "payment_method.type"
+		SELECT %s
 		FROM payments p
-		LEFT JOIN payment_methods pm ON p.payment_method_id = pm.id
+		JOIN payment_methods pm ON p.payment_method_id = pm.method_id
 		%s
 		ORDER BY p.payment_date DESC
-		LIMIT ? OFFSET ?
-	`, whereClause)
+		LIMIT $%d OFFSET $%d
+	`, paymentColumns, whereClause, argIndex, argIndex+1)
 	
 	args = append(args, limit, offset)
 	
@@ -807,9 +1229,10 @@ func (r *paymentRepository) List(offset, limit int, transactionID *int64) ([]mod
 
 func (r *paymentRepository) ListPaymentMethods() ([]models.PaymentMethod, error) {
 	query := `
-		SELECT id, name, type, account_number, bank_name, is_active, created_at, updated_at
+		SELECT method_id AS id, name, type, COALESCE(account_number, '') AS account_number,
+			   COALESCE(bank_name, '') AS bank_name, is_active, created_at, updated_at
 		FROM payment_methods 
-		WHERE is_active = true 
+		WHERE is_active = true AND deleted_at IS NULL
 		ORDER BY name
 	`
 	
@@ -823,10 +1246,16 @@ func (r *paymentRepository) ListPaymentMethods() ([]models.PaymentMethod, error)
 }
 
 func (r *paymentRepository) GeneratePaymentNumber() (string, error) {
+	return nextPaymentNumber(r.db)
+}
+
+// nextPaymentNumber numbers payments in the order they are taken; inside a
+// database transaction it counts the payments that transaction inserted
+func nextPaymentNumber(q sqlx.Queryer) (string, error) {
 	query := `SELECT COUNT(*) FROM payments WHERE payment_number LIKE 'PAY%'`
 	
 	var count int
-	err := r.db.Get(&count, query)
+	err := sqlx.Get(q, &count, query)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate payment number: %w", err)
 	}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"flutter-bengkel/internal/events"
 	"flutter-bengkel/internal/models"
 
 	"github.com/jmoiron/sqlx"
@@ -15,6 +16,7 @@ import (
 type VehicleTradingRepository interface {
 	// Vehicle Purchase
 	CreateVehiclePurchase(purchase *models.VehiclePurchase) error
+	RecordVehiclePurchase(purchase *models.VehiclePurchase, inventory *models.VehicleInventory) error
 	GetVehiclePurchaseByID(id int64) (*models.VehiclePurchase, error)
 	GetVehiclePurchases(offset, limit int) ([]*models.VehiclePurchase, int64, error)
 	UpdateVehiclePurchase(id int64, purchase *models.VehiclePurchase) error
@@ -89,6 +91,82 @@ func (r *vehicleTradingRepository) CreateVehiclePurchase(purchase *models.Vehicl
 	return nil
 }
 
+// RecordVehiclePurchase creates the purchase and the inventory record for
+// the bought vehicle and records a VehiclePurchased event in one database
+// transaction
+func (r *vehicleTradingRepository) RecordVehiclePurchase(purchase *models.VehiclePurchase, inventory *models.VehicleInventory) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	err = tx.QueryRow(`
+		INSERT INTO vehicle_purchases (customer_id, outlet_id, purchase_date, purchase_price, 
+			payment_method, notes, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING purchase_id, created_at, updated_at
+	`, purchase.CustomerID, purchase.OutletID, purchase.PurchaseDate, purchase.PurchasePrice,
+		purchase.PaymentMethod, purchase.Notes, purchase.Status, purchase.CreatedBy).
+		Scan(&purchase.PurchaseID, &purchase.CreatedAt, &purchase.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create vehicle purchase: %w", err)
+	}
+	
+	inventory.VehiclePurchaseID = purchase.PurchaseID
+	err = tx.QueryRow(`
+		INSERT INTO vehicle_inventory (vehicle_purchase_id, plate_number, brand, model, type,
+			production_year, chassis_number, engine_number, color, mileage, condition_rating,
+			purchase_price, estimated_selling_price, status, condition_notes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING inventory_id, created_at, updated_at
+	`, inventory.VehiclePurchaseID, inventory.PlateNumber,
+		inventory.Brand, inventory.Model, inventory.Type, inventory.ProductionYear,
+		inventory.ChassisNumber, inventory.EngineNumber, inventory.Color,
+		inventory.Mileage, inventory.ConditionRating, inventory.PurchasePrice,
+		inventory.EstimatedSellingPrice, inventory.Status, inventory.ConditionNotes,
+		inventory.CreatedBy).
+		Scan(&inventory.InventoryID, &inventory.CreatedAt, &inventory.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create vehicle inventory: %w", err)
+	}
+	
+	referenceType := "vehicle_purchase"
+	err = recordCashFlow(tx, &models.CashFlow{
+		OutletID:        purchase.OutletID,
+		FlowType:        "outflow",
+		Category:        "vehicle_purchase",
+		Amount:          purchase.PurchasePrice,
+		Description:     fmt.Sprintf("Vehicle purchase %s", inventory.PlateNumber),
+		ReferenceType:   &referenceType,
+		ReferenceID:     &purchase.PurchaseID,
+		TransactionDate: purchase.PurchaseDate,
+		CreatedBy:       purchase.CreatedBy,
+	})
+	if err != nil {
+		return err
+	}
+	
+	err = appendEvent(tx, events.VehiclePurchased, "vehicle_purchase", purchase.PurchaseID, &purchase.OutletID, events.VehiclePurchasedPayload{
+		PurchaseID:    purchase.PurchaseID,
+		InventoryID:   inventory.InventoryID,
+		CustomerID:    purchase.CustomerID,
+		OutletID:      purchase.OutletID,
+		PlateNumber:   inventory.PlateNumber,
+		PurchasePrice: purchase.PurchasePrice.String(),
+		PaymentMethod: purchase.PaymentMethod,
+	})
+	if err != nil {
+		return err
+	}
+	
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	
+	return nil
+}
+
 func (r *vehicleTradingRepository) GetVehiclePurchaseByID(id int64) (*models.VehiclePurchase, error) {
 	query := `
 		SELECT vp.purchase_id, vp.customer_id, vp.outlet_id, vp.purchase_date, 
@@ -465,21 +543,64 @@ func (r *vehicleTradingRepository) MarkAsSold(id int64, saleData *models.Vehicle
 	}
 	
 	// Create sale record
-	_, err = tx.Exec(`
+	err = tx.QueryRow(`
 		INSERT INTO vehicle_sales (inventory_id, customer_id, sales_person_id, outlet_id,
 			sale_date, selling_price, commission_rate, commission_amount, payment_type,
 			down_payment, financing_amount, financing_bank, financing_term_months,
 			status, notes, created_by)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING sale_id
 	`, id, saleData.CustomerID, saleData.SalesPersonID, saleData.OutletID,
 		saleData.SaleDate, saleData.SellingPrice, saleData.CommissionRate,
 		saleData.CommissionAmount, saleData.PaymentType, saleData.DownPayment,
 		saleData.FinancingAmount, saleData.FinancingBank, saleData.FinancingTermMonths,
-		saleData.Status, saleData.Notes, saleData.CreatedBy)
+		saleData.Status, saleData.Notes, saleData.CreatedBy).Scan(&saleData.SaleID)
 	if err != nil {
 		return fmt.Errorf("failed to create sale record: %w", err)
 	}
 	
+	// Cash sales are received in full; other payment types bring in the down
+	// payment now and the rest later
+	received := saleData.DownPayment
+	if saleData.PaymentType == "cash" {
+		received = saleData.SellingPrice
+	}
+	var plateNumber string
+	if err = tx.Get(&plateNumber, `SELECT plate_number FROM vehicle_inventory WHERE inventory_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to get vehicle inventory: %w", err)
+	}
+	referenceType := "vehicle_sale"
+	err = recordCashFlow(tx, &models.CashFlow{
+		OutletID:        saleData.OutletID,
+		FlowType:        "inflow",
+		Category:        "vehicle_sale",
+		Amount:          received,
+		Description:     fmt.Sprintf("Vehicle sale %s", plateNumber),
+		ReferenceType:   &referenceType,
+		ReferenceID:     &saleData.SaleID,
+		TransactionDate: saleData.SaleDate,
+		CreatedBy:       saleData.CreatedBy,
+	})
+	if err != nil {
+		return err
+	}
+	
+	// Commission and other follow-up work happens in VehicleSold subscribers
+	err = appendEvent(tx, events.VehicleSold, "vehicle_sale", saleData.SaleID, &saleData.OutletID, events.VehicleSoldPayload{
+		SaleID:           saleData.SaleID,
+		InventoryID:      id,
+		CustomerID:       saleData.CustomerID,
+		SalesPersonID:    saleData.SalesPersonID,
+		OutletID:         saleData.OutletID,
+		SellingPrice:     saleData.SellingPrice.String(),
+		CommissionRate:   saleData.CommissionRate.String(),
+		CommissionAmount: saleData.CommissionAmount.String(),
+		PaymentType:      saleData.PaymentType,
+	})
+	if err != nil {
+		return err
+	}
+	
 	return tx.Commit()
 }
 
@@ -562,8 +683,24 @@ func (r *vehicleTradingRepository) UpdateVehicleAssessment(id int64, assessment
 	return nil
 }
 
+// CreateSalesCommission records the commission for a sale. A sale only ever
+// earns one commission, so repeated calls for the same sale are no-ops.
 func (r *vehicleTradingRepository) CreateSalesCommission(commission *models.SalesCommission) error {
-	// Implementation for commission tracking
+	query := `
+		INSERT INTO sales_commissions (sale_id, sales_person_id, commission_rate, commission_amount,
+			payment_status, notes, created_by)
+		SELECT $1, $2, $3, $4, $5, $6, $7
+		WHERE NOT EXISTS (
+			SELECT 1 FROM sales_commissions WHERE sale_id = $1 AND deleted_at IS NULL
+		)
+	`
+	
+	_, err := r.db.Exec(query, commission.SaleID, commission.SalesPersonID, commission.CommissionRate,
+		commission.CommissionAmount, commission.PaymentStatus, commission.Notes, commission.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to create sales commission: %w", err)
+	}
+	
 	return nil
 }
 
@@ -0,0 +1,281 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/events"
+	"flutter-bengkel/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Void reverses a transaction in one database transaction: products the
+// sale took from stock and not already returned go back to it, store credit
+// applied to it goes back to its credit notes, points spent on it go back to
+// the customer, what the customer paid is refunded, and the transaction
+// keeps its number with status void. Nothing is deleted.
+func (r *transactionRepository) Void(id int64, reason string, paymentMethodID, shiftID *int64, voidedBy int64) (*models.TransactionVoid, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	transaction, err := lockSalesTransaction(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.TransactionType == "vehicle_sale" {
+		return nil, fmt.Errorf("vehicle sale transactions cannot be voided")
+	}
+
+	before, err := transactionBalance(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.TransactionVoid{
+		TransactionID:     transaction.TransactionID,
+		TransactionNumber: transaction.TransactionNumber,
+		OutletID:          transaction.OutletID,
+		TotalAmount:       transaction.TotalAmount,
+		Reason:            reason,
+		VoidedBy:          voidedBy,
+		CreditRestored:    decimal.Zero,
+	}
+
+	// Products still sold, net of returns, go back to stock if the sale took
+	// them out of it
+	var lines []struct {
+		ProductID int64           `db:"product_id"`
+		Quantity  decimal.Decimal `db:"quantity"`
+	}
+	err = tx.Select(&lines, `
+		SELECT d.product_id,
+			d.quantity - COALESCE((SELECT SUM(ri.quantity) FROM sales_return_items ri WHERE ri.transaction_detail_id = d.detail_id), 0) AS quantity
+		FROM transaction_details d
+		WHERE d.transaction_id = $1 AND d.product_id IS NOT NULL AND d.stock_taken AND d.deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction lines: %w", err)
+	}
+	for _, line := range lines {
+		if !line.Quantity.IsPositive() {
+			continue
+		}
+		if err = adjustStock(tx, line.ProductID, int(line.Quantity.IntPart()), "add"); err != nil {
+			return nil, fmt.Errorf("failed to restock product: %w", err)
+		}
+		result.RestockedItems++
+	}
+
+	// Store credit applied to the transaction goes back to the customer
+	var applications []struct {
+		ApplicationID int64           `db:"application_id"`
+		CreditNoteID  int64           `db:"credit_note_id"`
+		Amount        decimal.Decimal `db:"amount"`
+	}
+	err = tx.Select(&applications, `
+		SELECT application_id, credit_note_id, amount
+		FROM credit_note_applications
+		WHERE transaction_id = $1 AND reversed_at IS NULL
+		ORDER BY credit_note_id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit note applications: %w", err)
+	}
+	for _, application := range applications {
+		_, err = tx.Exec(`
+			UPDATE credit_notes
+			SET remaining_amount = remaining_amount + $2, status = 'open', updated_at = CURRENT_TIMESTAMP
+			WHERE credit_note_id = $1
+		`, application.CreditNoteID, application.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore credit note: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			UPDATE credit_note_applications SET reversed_at = CURRENT_TIMESTAMP WHERE application_id = $1
+		`, application.ApplicationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reverse credit note application: %w", err)
+		}
+		result.CreditRestored = result.CreditRestored.Add(application.Amount)
+	}
+
+	// Promotion and coupon uses are given back
+	if err = reverseRedemptions(tx, id); err != nil {
+		return nil, err
+	}
+
+	// Nothing is left to approve
+	if err = cancelApprovals(tx, id); err != nil {
+		return nil, err
+	}
+
+	// Points spent on the transaction go back to the customer's balance
+	var pointsValue decimal.Decimal
+	result.PointsRestored, pointsValue, err = restoreTransactionPoints(tx, transaction, voidedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deposits and vouchers the transaction was paid from are given back
+	result.StoredValueRestored, err = restoreTransactionStoredValue(tx, transaction, voidedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Money the customer paid is refunded
+	refundAmount := before.NetPaid.Sub(result.CreditRestored).Sub(pointsValue).Sub(result.StoredValueRestored)
+	if refundAmount.IsPositive() {
+		if paymentMethodID == nil {
+			var lastMethodID int64
+			err = tx.Get(&lastMethodID, `
+				SELECT p.payment_method_id FROM payments p
+				JOIN payment_methods pm ON pm.method_id = p.payment_method_id
+				WHERE p.transaction_id = $1 AND p.deleted_at IS NULL AND pm.type NOT IN ('loyalty_points', 'stored_value')
+				ORDER BY p.payment_date DESC, p.payment_id DESC LIMIT 1
+			`, id)
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("payment method is required for the refund")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get payment method: %w", err)
+			}
+			paymentMethodID = &lastMethodID
+		}
+
+		result.Refund = &models.Refund{
+			TransactionID:   id,
+			PaymentMethodID: *paymentMethodID,
+			Amount:          refundAmount,
+			Reason:          "Void: " + reason,
+			RefundDate:      time.Now(),
+			ShiftID:         shiftID,
+			CreatedBy:       &voidedBy,
+		}
+		if err = insertRefund(tx, result.Refund, transaction); err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.QueryRow(`
+		UPDATE transactions
+		SET payment_status = 'void', voided_at = CURRENT_TIMESTAMP, voided_by = $2, void_reason = $3,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE transaction_id = $1
+		RETURNING voided_at
+	`, id, voidedBy, reason).Scan(&result.VoidedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to void transaction: %w", err)
+	}
+
+	refunded := decimal.Zero
+	if result.Refund != nil {
+		refunded = result.Refund.Amount
+		if err = appendRefundIssued(tx, result.Refund, transaction, "void"); err != nil {
+			return nil, err
+		}
+	}
+
+	err = appendEvent(tx, events.TransactionVoided, "transaction", id, &transaction.OutletID, events.TransactionVoidedPayload{
+		TransactionID:     id,
+		TransactionNumber: transaction.TransactionNumber,
+		TransactionType:   transaction.TransactionType,
+		OutletID:          transaction.OutletID,
+		CustomerID:        transaction.CustomerID,
+		TotalAmount:       transaction.TotalAmount.StringFixed(2),
+		RefundedAmount:    refunded.StringFixed(2),
+		CreditRestored:    result.CreditRestored.StringFixed(2),
+		Reason:            reason,
+		VoidedBy:          voidedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetExceptionReport lists the voids, payment reversals, returns and refunds
+// of a period, newest first, with a count and total per kind
+func (r *transactionRepository) GetExceptionReport(filter *models.ExceptionFilter) (*models.ExceptionReport, error) {
+	conditions := []string{"x.occurred_at::date >= $1", "x.occurred_at::date <= $2"}
+	args := []interface{}{filter.DateFrom.Format("2006-01-02"), filter.DateTo.Format("2006-01-02")}
+	argIndex := 3
+
+	if filter.OutletID != nil {
+		conditions = append(conditions, fmt.Sprintf("x.outlet_id = $%d", argIndex))
+		args = append(args, *filter.OutletID)
+		argIndex++
+	}
+
+	if filter.Type != "" {
+		conditions = append(conditions, fmt.Sprintf("x.type = $%d", argIndex))
+		args = append(args, filter.Type)
+	}
+
+	query := `
+		SELECT x.*, u.full_name AS user_name FROM (
+			SELECT 'void' AS type, t.transaction_id AS reference_id, t.transaction_number AS reference_number,
+				t.transaction_id, t.transaction_number, t.outlet_id, t.total_amount AS amount,
+				t.void_reason AS reason, t.voided_by AS user_id, t.voided_at AS occurred_at
+			FROM transactions t
+			WHERE t.voided_at IS NOT NULL
+			UNION ALL
+			SELECT 'payment_reversal', p.payment_id, p.payment_number, t.transaction_id, t.transaction_number,
+				t.outlet_id, p.amount, NULL, p.deleted_by, p.deleted_at
+			FROM payments p
+			JOIN transactions t ON t.transaction_id = p.transaction_id
+			WHERE p.deleted_at IS NOT NULL
+			UNION ALL
+			SELECT 'sales_return', sr.return_id, sr.return_number, t.transaction_id, t.transaction_number,
+				sr.outlet_id, sr.total_amount, sr.reason, sr.created_by, sr.return_date
+			FROM sales_returns sr
+			JOIN transactions t ON t.transaction_id = sr.transaction_id
+			UNION ALL
+			SELECT 'refund', rf.refund_id, rf.refund_number, t.transaction_id, t.transaction_number,
+				t.outlet_id, rf.amount, rf.reason, rf.created_by, rf.refund_date
+			FROM refunds rf
+			JOIN transactions t ON t.transaction_id = rf.transaction_id
+		) x
+		LEFT JOIN users u ON u.user_id = x.user_id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY x.occurred_at DESC, x.type, x.reference_id DESC
+	`
+
+	var items []models.ExceptionItem
+	if err := r.db.Select(&items, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get exceptions: %w", err)
+	}
+
+	report := &models.ExceptionReport{
+		OutletID: filter.OutletID,
+		DateFrom: filter.DateFrom.Format("2006-01-02"),
+		DateTo:   filter.DateTo.Format("2006-01-02"),
+		Items:    items,
+	}
+
+	summaries := make(map[string]*models.ExceptionSummary)
+	for _, kind := range []string{"void", "payment_reversal", "sales_return", "refund"} {
+		report.Summary = append(report.Summary, models.ExceptionSummary{Type: kind, Amount: decimal.Zero})
+	}
+	for i := range report.Summary {
+		summaries[report.Summary[i].Type] = &report.Summary[i]
+	}
+	for _, item := range items {
+		summary := summaries[item.Type]
+		summary.Count++
+		summary.Amount = summary.Amount.Add(item.Amount)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,404 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WebhookRepository interface defines webhook subscription and delivery operations
+type WebhookRepository interface {
+	Create(subscription *models.WebhookSubscription) error
+	GetByID(id int64) (*models.WebhookSubscription, error)
+	List(offset, limit int) ([]models.WebhookSubscription, int64, error)
+	Update(subscription *models.WebhookSubscription) error
+	UpdateSecret(id int64, secret string) error
+	Delete(id int64) error
+
+	CreateDeliveriesForEvent(eventID int64, eventType string, payload []byte, maxAttempts int) (int64, error)
+	CreateDelivery(delivery *models.WebhookDelivery) error
+	GetDeliveryByID(id int64) (*models.WebhookDelivery, error)
+	GetDeliveryAttempts(deliveryID int64) ([]models.WebhookDeliveryAttempt, error)
+	ListDeliveries(filter *models.WebhookDeliveryFilter, offset, limit int) ([]models.WebhookDelivery, int64, error)
+	ClaimDueDeliveries(limit int, staleAfter time.Duration) ([]models.WebhookDelivery, error)
+	RecordAttempt(attempt *models.WebhookDeliveryAttempt) error
+	MarkDelivered(id int64, responseCode int) error
+	MarkFailed(id int64, responseCode *int, lastError string, nextAttemptAt *time.Time) error
+	Redeliver(id int64) error
+	PurgeDeliveries(before time.Time) (int64, error)
+}
+
+type webhookRepository struct {
+	db *sqlx.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *sqlx.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+const webhookSubscriptionColumns = `
+	subscription_id, name, url, event_types, secret, is_active, COALESCE(description, '') as description,
+	created_at, updated_at, deleted_at, created_by
+`
+
+const webhookDeliveryColumns = `
+	d.delivery_id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.max_attempts, d.next_attempt_at, d.response_code, d.last_error, d.delivered_at, d.created_at, d.updated_at
+`
+
+func (r *webhookRepository) Create(subscription *models.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (name, url, event_types, secret, is_active, description, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING subscription_id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query, subscription.Name, subscription.URL, subscription.EventTypes,
+		subscription.Secret, subscription.IsActive, subscription.Description, subscription.CreatedBy).
+		Scan(&subscription.SubscriptionID, &subscription.CreatedAt, &subscription.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) GetByID(id int64) (*models.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE subscription_id = $1 AND deleted_at IS NULL`
+
+	var subscription models.WebhookSubscription
+	err := r.db.Get(&subscription, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+func (r *webhookRepository) List(offset, limit int) ([]models.WebhookSubscription, int64, error) {
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM webhook_subscriptions WHERE deleted_at IS NULL`)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook subscriptions: %w", err)
+	}
+
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions
+		WHERE deleted_at IS NULL ORDER BY subscription_id LIMIT $1 OFFSET $2`
+
+	var subscriptions []models.WebhookSubscription
+	err = r.db.Select(&subscriptions, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	return subscriptions, total, nil
+}
+
+func (r *webhookRepository) Update(subscription *models.WebhookSubscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET name = $2, url = $3, event_types = $4, is_active = $5, description = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE subscription_id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, subscription.SubscriptionID, subscription.Name, subscription.URL,
+		subscription.EventTypes, subscription.IsActive, subscription.Description)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook subscription not found")
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) UpdateSecret(id int64, secret string) error {
+	query := `
+		UPDATE webhook_subscriptions SET secret = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE subscription_id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, id, secret)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook secret: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook subscription not found")
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) Delete(id int64) error {
+	query := `
+		UPDATE webhook_subscriptions SET deleted_at = CURRENT_TIMESTAMP, is_active = FALSE
+		WHERE subscription_id = $1 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook subscription not found")
+	}
+
+	return nil
+}
+
+// CreateDeliveriesForEvent queues the event for every active subscription to
+// its type. Deliveries that already exist are kept, so a redelivered domain
+// event never queues duplicates.
+func (r *webhookRepository) CreateDeliveriesForEvent(eventID int64, eventType string, payload []byte, maxAttempts int) (int64, error) {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, max_attempts)
+		SELECT subscription_id, $1, $2, $3, $4
+		FROM webhook_subscriptions
+		WHERE is_active = TRUE AND deleted_at IS NULL AND $2 = ANY(event_types)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+
+	result, err := r.db.Exec(query, eventID, eventType, string(payload), maxAttempts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *webhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, max_attempts)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING delivery_id, status, attempts, next_attempt_at, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query, delivery.SubscriptionID, delivery.EventID, delivery.EventType,
+		string(delivery.Payload), delivery.MaxAttempts).
+		Scan(&delivery.DeliveryID, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt,
+			&delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) GetDeliveryByID(id int64) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries d WHERE d.delivery_id = $1`
+
+	var delivery models.WebhookDelivery
+	err := r.db.Get(&delivery, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+func (r *webhookRepository) GetDeliveryAttempts(deliveryID int64) ([]models.WebhookDeliveryAttempt, error) {
+	query := `
+		SELECT attempt_id, delivery_id, response_code, response_body, error, duration_ms, attempted_at
+		FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY attempted_at, attempt_id
+	`
+
+	var attempts []models.WebhookDeliveryAttempt
+	err := r.db.Select(&attempts, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+func (r *webhookRepository) ListDeliveries(filter *models.WebhookDeliveryFilter, offset, limit int) ([]models.WebhookDelivery, int64, error) {
+	conditions := []string{"1=1"}
+	var args []interface{}
+	argIndex := 1
+
+	if filter.SubscriptionID != nil {
+		conditions = append(conditions, fmt.Sprintf("d.subscription_id = $%d", argIndex))
+		args = append(args, *filter.SubscriptionID)
+		argIndex++
+	}
+
+	if filter.EventType != "" {
+		conditions = append(conditions, fmt.Sprintf("d.event_type = $%d", argIndex))
+		args = append(args, filter.EventType)
+		argIndex++
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("d.status = $%d", argIndex))
+		args = append(args, filter.Status)
+		argIndex++
+	}
+
+	whereClause := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int64
+	err := r.db.Get(&total, `SELECT COUNT(*) FROM webhook_deliveries d `+whereClause, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM webhook_deliveries d %s ORDER BY d.delivery_id DESC LIMIT $%d OFFSET $%d`,
+		webhookDeliveryColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	var deliveries []models.WebhookDelivery
+	err = r.db.Select(&deliveries, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, total, nil
+}
+
+// ClaimDueDeliveries locks due deliveries for active subscriptions along with
+// their endpoint and secret. Deliveries left in sending longer than
+// staleAfter, e.g. by a crashed process, are claimed again.
+func (r *webhookRepository) ClaimDueDeliveries(limit int, staleAfter time.Duration) ([]models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET status = 'sending', attempts = d.attempts + 1, updated_at = CURRENT_TIMESTAMP
+		FROM webhook_subscriptions s
+		WHERE s.subscription_id = d.subscription_id
+			  AND d.delivery_id IN (
+				  SELECT wd.delivery_id FROM webhook_deliveries wd
+				  JOIN webhook_subscriptions ws ON ws.subscription_id = wd.subscription_id
+				  WHERE ws.is_active = TRUE AND ws.deleted_at IS NULL
+						AND ((wd.status = 'pending' AND wd.next_attempt_at <= CURRENT_TIMESTAMP)
+							 OR (wd.status = 'sending' AND wd.updated_at <= $2))
+				  ORDER BY wd.next_attempt_at
+				  LIMIT $1
+				  FOR UPDATE OF wd SKIP LOCKED
+			  )
+		RETURNING ` + webhookDeliveryColumns + `, s.url, s.secret`
+
+	var deliveries []models.WebhookDelivery
+	err := r.db.Select(&deliveries, query, limit, time.Now().Add(-staleAfter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) RecordAttempt(attempt *models.WebhookDeliveryAttempt) error {
+	query := `
+		INSERT INTO webhook_delivery_attempts (delivery_id, response_code, response_body, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING attempt_id, attempted_at
+	`
+
+	err := r.db.QueryRow(query, attempt.DeliveryID, attempt.ResponseCode, attempt.ResponseBody,
+		attempt.Error, attempt.DurationMs).Scan(&attempt.AttemptID, &attempt.AttemptedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) MarkDelivered(id int64, responseCode int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', response_code = $2, delivered_at = CURRENT_TIMESTAMP,
+			last_error = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE delivery_id = $1
+	`
+
+	_, err := r.db.Exec(query, id, responseCode)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivered: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt, scheduling a retry at nextAttemptAt or
+// giving up when it is nil
+func (r *webhookRepository) MarkFailed(id int64, responseCode *int, lastError string, nextAttemptAt *time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = CASE WHEN $4::TIMESTAMP IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($4, next_attempt_at), response_code = $2, last_error = $3,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE delivery_id = $1
+	`
+
+	_, err := r.db.Exec(query, id, responseCode, lastError, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook failed: %w", err)
+	}
+
+	return nil
+}
+
+// Redeliver schedules a finished delivery, failed or delivered, for
+// immediate delivery, allowing one more attempt
+func (r *webhookRepository) Redeliver(id int64) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', next_attempt_at = CURRENT_TIMESTAMP,
+			max_attempts = GREATEST(max_attempts, attempts + 1), updated_at = CURRENT_TIMESTAMP
+		WHERE delivery_id = $1 AND status IN ('failed', 'delivered')
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("finished webhook delivery not found")
+	}
+
+	return nil
+}
+
+// PurgeDeliveries deletes delivered or failed deliveries last updated before
+// the cutoff along with their attempt logs
+func (r *webhookRepository) PurgeDeliveries(before time.Time) (int64, error) {
+	query := `
+		DELETE FROM webhook_deliveries
+		WHERE status IN ('delivered', 'failed') AND updated_at < $1
+	`
+
+	result, err := r.db.Exec(query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge webhook deliveries: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	// A Friday, partway through a minute
+	from := time.Date(2024, 3, 15, 10, 30, 45, 0, jakarta)
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, jakarta)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{name: "every minute starts at the next minute", spec: "* * * * *", want: at(2024, 3, 15, 10, 31)},
+		{name: "minute step", spec: "*/15 * * * *", want: at(2024, 3, 15, 10, 45)},
+		{name: "value with a step runs to the maximum", spec: "5/20 * * * *", want: at(2024, 3, 15, 10, 45)},
+		{name: "list", spec: "5,50 * * * *", want: at(2024, 3, 15, 10, 50)},
+		{name: "range with a step", spec: "0 8-18/4 * * *", want: at(2024, 3, 15, 12, 0)},
+		{name: "later today", spec: "0 2 * * *", want: at(2024, 3, 16, 2, 0)},
+		{name: "strictly after the current minute", spec: "30 10 * * *", want: at(2024, 3, 16, 10, 30)},
+		{name: "weekday by name", spec: "0 9 * * mon", want: at(2024, 3, 18, 9, 0)},
+		{name: "7 is Sunday", spec: "0 0 * * 7", want: at(2024, 3, 17, 0, 0)},
+		{name: "day of month alone", spec: "0 0 13 * *", want: at(2024, 4, 13, 0, 0)},
+		{name: "day of month or weekday when both are set", spec: "0 0 16 * mon", want: at(2024, 3, 16, 0, 0)},
+		{name: "month range by name rolls into next year", spec: "0 0 1 jan-mar *", want: at(2025, 1, 1, 0, 0)},
+		{name: "leap day", spec: "0 0 29 2 *", want: at(2028, 2, 29, 0, 0)},
+		{name: "impossible date never runs", spec: "0 0 30 2 *", want: time.Time{}},
+		{name: "hourly", spec: "@hourly", want: at(2024, 3, 15, 11, 0)},
+		{name: "monthly", spec: "@monthly", want: at(2024, 4, 1, 0, 0)},
+		{name: "descriptors ignore case", spec: "@Daily", want: at(2024, 3, 16, 0, 0)},
+		{
+			name: "evaluated in the schedule's location",
+			spec: "0 2 * * *",
+			from: time.Date(2024, 3, 15, 18, 30, 0, 0, time.UTC),
+			want: at(2024, 3, 16, 2, 0),
+		},
+		{
+			name: "month end rolls over",
+			spec: "0 0 * * *",
+			from: at(2024, 12, 31, 23, 59),
+			want: at(2025, 1, 1, 0, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec, jakarta)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) error = %v", tt.spec, err)
+			}
+			if schedule.String() != tt.spec {
+				t.Errorf("String() = %q, want %q", schedule.String(), tt.spec)
+			}
+
+			start := tt.from
+			if start.IsZero() {
+				start = from
+			}
+			if got := schedule.Next(start); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", start, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{name: "too few fields", spec: "* * * *"},
+		{name: "too many fields", spec: "0 * * * * *"},
+		{name: "unknown descriptor", spec: "@fortnightly"},
+		{name: "minute out of range", spec: "60 * * * *"},
+		{name: "hour out of range", spec: "0 24 * * *"},
+		{name: "day of month below one", spec: "0 0 0 * *"},
+		{name: "month out of range", spec: "0 0 1 13 *"},
+		{name: "weekday out of range", spec: "0 0 * * 8"},
+		{name: "zero step", spec: "*/0 * * * *"},
+		{name: "backwards range", spec: "10-5 * * * *"},
+		{name: "not a number", spec: "a * * * *"},
+		{name: "unknown month name", spec: "0 0 1 foo *"},
+		{name: "names only where allowed", spec: "0 mon * * *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSchedule(tt.spec, time.UTC); err == nil {
+				t.Errorf("ParseSchedule(%q) error = nil, want an error", tt.spec)
+			}
+		})
+	}
+}
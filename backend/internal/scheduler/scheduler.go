@@ -0,0 +1,350 @@
+// Package scheduler runs periodic jobs on cron-style schedules.
+//
+// Every replica of the API runs the same scheduler. Before a job runs, the
+// replica takes a Postgres advisory lock for it and records the run for its
+// scheduled minute; a replica that cannot take the lock, or finds the minute
+// already recorded, skips it, so each scheduled run happens exactly once
+// across the cluster.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Run statuses recorded in the run history
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Run triggers recorded in the run history
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// defaultTimeout bounds a job that does not set its own timeout
+const defaultTimeout = 10 * time.Minute
+
+// Errors returned by the scheduler
+var (
+	ErrUnknownJob = errors.New("unknown scheduled job")
+	ErrStopped    = errors.New("scheduler is stopped")
+)
+
+// Job is a unit of periodic work. Run returns a short summary of what it
+// did for the run history.
+type Job struct {
+	Name        string
+	Spec        string
+	Description string
+	Timeout     time.Duration
+	Run         func(ctx context.Context) (string, error)
+}
+
+// Store persists run history and provides the cross-replica lock
+type Store interface {
+	// TryLock takes a session-level advisory lock without waiting and
+	// returns a function that releases it
+	TryLock(ctx context.Context, key int64) (release func(), acquired bool, err error)
+	// StartRun records a run for a job's scheduled time, reporting false if
+	// that run was already recorded
+	StartRun(jobName string, scheduledFor time.Time, trigger, instance string) (runID int64, started bool, err error)
+	// FinishRun records a run's outcome
+	FinishRun(runID int64, status, summary, errorMessage string, duration time.Duration) error
+}
+
+// JobInfo describes a registered job and its next scheduled run
+type JobInfo struct {
+	Name        string
+	Spec        string
+	Description string
+	NextRunAt   time.Time
+	Running     bool
+}
+
+type entry struct {
+	job      Job
+	schedule *Schedule
+	lockKey  int64
+
+	mu      sync.Mutex
+	next    time.Time
+	running bool
+}
+
+// Scheduler runs registered jobs on their schedules until stopped
+type Scheduler struct {
+	store    Store
+	location *time.Location
+	instance string
+
+	mu       sync.Mutex
+	entries  map[string]*entry
+	started  bool
+	stopping bool
+
+	stop      chan struct{}
+	loops     sync.WaitGroup
+	runs      sync.WaitGroup
+	runCtx    context.Context
+	cancelRun context.CancelFunc
+}
+
+// New creates a scheduler evaluating schedules in location
+func New(store Store, location *time.Location) *Scheduler {
+	if location == nil {
+		location = time.Local
+	}
+
+	hostname, _ := os.Hostname()
+	runCtx, cancelRun := context.WithCancel(context.Background())
+
+	return &Scheduler{
+		store:     store,
+		location:  location,
+		instance:  fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		entries:   make(map[string]*entry),
+		stop:      make(chan struct{}),
+		runCtx:    runCtx,
+		cancelRun: cancelRun,
+	}
+}
+
+// Register adds a job. Jobs must be registered before Start.
+func (s *Scheduler) Register(job Job) error {
+	schedule, err := ParseSchedule(job.Spec, s.location)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("cannot register %s after the scheduler has started", job.Name)
+	}
+	if _, exists := s.entries[job.Name]; exists {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+
+	s.entries[job.Name] = &entry{job: job, schedule: schedule, lockKey: lockKey(job.Name)}
+	return nil
+}
+
+// Start begins running jobs on their schedules
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	for _, e := range s.entries {
+		s.loops.Add(1)
+		go s.loop(e)
+	}
+}
+
+// Stop stops scheduling new runs and waits for running jobs to finish. If
+// ctx ends first, running jobs are cancelled and ctx's error is returned.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.stopping {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopping = true
+	close(s.stop)
+	s.mu.Unlock()
+
+	s.loops.Wait()
+
+	drained := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		s.cancelRun()
+		return nil
+	case <-ctx.Done():
+		s.cancelRun()
+		<-drained
+		return ctx.Err()
+	}
+}
+
+// RunNow starts a job immediately in the background, outside its schedule
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrUnknownJob
+	}
+
+	return s.launch(e, time.Now().Truncate(time.Second), TriggerManual)
+}
+
+// Jobs lists registered jobs ordered by name
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]JobInfo, 0, len(s.entries))
+	for _, e := range s.entries {
+		e.mu.Lock()
+		next := e.next
+		if next.IsZero() {
+			next = e.schedule.Next(time.Now())
+		}
+		jobs = append(jobs, JobInfo{
+			Name:        e.job.Name,
+			Spec:        e.job.Spec,
+			Description: e.job.Description,
+			NextRunAt:   next,
+			Running:     e.running,
+		})
+		e.mu.Unlock()
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+func (s *Scheduler) loop(e *entry) {
+	defer s.loops.Done()
+
+	for {
+		next := e.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("Warning: scheduled job %s never runs with schedule %q", e.job.Name, e.job.Spec)
+			return
+		}
+
+		e.mu.Lock()
+		e.next = next
+		e.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			if err := s.launch(e, next, TriggerSchedule); err != nil {
+				return
+			}
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// launch runs the job in the background unless the scheduler is stopping or
+// this replica is already running it. The run is counted while s.mu is
+// held, so Stop either waits for it or it never starts.
+func (s *Scheduler) launch(e *entry, scheduledFor time.Time, trigger string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopping {
+		return ErrStopped
+	}
+
+	e.mu.Lock()
+	if e.running {
+		e.mu.Unlock()
+		log.Printf("Warning: skipping %s run for %s, previous run still in progress", e.job.Name, scheduledFor.Format(time.RFC3339))
+		return nil
+	}
+	e.running = true
+	e.mu.Unlock()
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		defer func() {
+			e.mu.Lock()
+			e.running = false
+			e.mu.Unlock()
+		}()
+
+		s.execute(e, scheduledFor, trigger)
+	}()
+
+	return nil
+}
+
+func (s *Scheduler) execute(e *entry, scheduledFor time.Time, trigger string) {
+	release, acquired, err := s.store.TryLock(s.runCtx, e.lockKey)
+	if err != nil {
+		log.Printf("Warning: failed to lock scheduled job %s: %v", e.job.Name, err)
+		return
+	}
+	if !acquired {
+		// Another replica is running the job
+		return
+	}
+	defer release()
+
+	runID, started, err := s.store.StartRun(e.job.Name, scheduledFor, trigger, s.instance)
+	if err != nil {
+		log.Printf("Warning: failed to record %s run: %v", e.job.Name, err)
+		return
+	}
+	if !started {
+		// Another replica already ran this scheduled time
+		return
+	}
+
+	timeout := e.job.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(s.runCtx, timeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	summary, runErr := invoke(ctx, e.job.Run)
+	duration := time.Since(startedAt)
+
+	status, errorMessage := StatusSucceeded, ""
+	if runErr != nil {
+		status, errorMessage = StatusFailed, runErr.Error()
+		log.Printf("Warning: scheduled job %s failed after %s: %v", e.job.Name, duration.Round(time.Millisecond), runErr)
+	}
+
+	if err := s.store.FinishRun(runID, status, summary, errorMessage, duration); err != nil {
+		log.Printf("Warning: failed to record %s run result: %v", e.job.Name, err)
+	}
+}
+
+func invoke(ctx context.Context, run func(ctx context.Context) (string, error)) (summary string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+// lockKey derives a stable advisory lock key from a job name
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scheduler:" + name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryStore is a Store for a single replica
+type memoryStore struct {
+	mu   sync.Mutex
+	runs int64
+}
+
+func (m *memoryStore) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	return func() {}, true, nil
+}
+
+func (m *memoryStore) StartRun(jobName string, scheduledFor time.Time, trigger, instance string) (int64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs++
+	return m.runs, true, nil
+}
+
+func (m *memoryStore) FinishRun(runID int64, status, summary, errorMessage string, duration time.Duration) error {
+	return nil
+}
+
+func TestRunNow(t *testing.T) {
+	s := New(&memoryStore{}, time.UTC)
+	ran := make(chan struct{})
+	err := s.Register(Job{Name: "job", Spec: "@yearly", Run: func(ctx context.Context) (string, error) {
+		close(ran)
+		return "", nil
+	}})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if err := s.RunNow("missing"); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("RunNow(missing) error = %v, want %v", err, ErrUnknownJob)
+	}
+	if err := s.RunNow("job"); err != nil {
+		t.Fatalf("RunNow() error = %v", err)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	select {
+	case <-ran:
+	default:
+		t.Error("Stop() returned before the manual run finished")
+	}
+	if err := s.RunNow("job"); !errors.Is(err, ErrStopped) {
+		t.Errorf("RunNow() after Stop error = %v, want %v", err, ErrStopped)
+	}
+}
+
+func TestStopWaitsForRunsStartedAlongside(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		s := New(&memoryStore{}, time.UTC)
+		var stopped, late, cancelled atomic.Bool
+		err := s.Register(Job{Name: "job", Spec: "@yearly", Run: func(ctx context.Context) (string, error) {
+			if stopped.Load() {
+				late.Store(true)
+			}
+			if ctx.Err() != nil {
+				cancelled.Store(true)
+			}
+			return "", nil
+		}})
+		if err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for !errors.Is(s.RunNow("job"), ErrStopped) {
+				}
+			}()
+		}
+
+		if err := s.Stop(context.Background()); err != nil {
+			t.Fatalf("Stop() error = %v", err)
+		}
+		stopped.Store(true)
+		wg.Wait()
+
+		if late.Load() {
+			t.Fatal("a run started after Stop returned")
+		}
+		if cancelled.Load() {
+			t.Fatal("a run started with its context already cancelled")
+		}
+	}
+}
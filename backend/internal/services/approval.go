@@ -0,0 +1,338 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	approvalDiscount      = "discount"
+	approvalPriceOverride = "price_override"
+)
+
+// An approver's PIN locks for approvalPINLockout after this many wrong
+// PINs in a row
+const (
+	approvalPINMaxFailures = 5
+	approvalPINLockout     = 15 * time.Minute
+)
+
+var approvalPINPattern = regexp.MustCompile(`^[0-9]{4,8}$`)
+
+// ApprovalService interface defines approval threshold, approval request
+// and approver PIN operations
+type ApprovalService interface {
+	ListThresholds() ([]models.ApprovalThreshold, error)
+	SetThreshold(roleID int64, req *models.UpdateApprovalThresholdRequest, userID int64) (*models.ApprovalThreshold, error)
+	DeleteThreshold(roleID int64) error
+	List(page, limit int, filter *models.ApprovalFilter) ([]models.ApprovalRequest, *models.PaginationMeta, error)
+	GetByID(id int64) (*models.ApprovalRequest, error)
+	Decide(id int64, approve bool, notes string, userID int64, outletID *int64) (*models.ApprovalRequest, error)
+	DecideWithPIN(id int64, approve bool, req *models.ApprovalDecisionRequest, attemptedBy int64, outletID *int64) (*models.ApprovalRequest, error)
+	SetPIN(userID int64, req *models.SetApprovalPINRequest) error
+}
+
+type approvalService struct {
+	repos       *repositories.Repositories
+	serviceJobs ServiceJobService
+}
+
+// NewApprovalService creates a new approval service
+func NewApprovalService(repos *repositories.Repositories, serviceJobs ServiceJobService) ApprovalService {
+	return &approvalService{repos: repos, serviceJobs: serviceJobs}
+}
+
+func (s *approvalService) ListThresholds() ([]models.ApprovalThreshold, error) {
+	return s.repos.Approval.ListThresholds()
+}
+
+func (s *approvalService) SetThreshold(roleID int64, req *models.UpdateApprovalThresholdRequest, userID int64) (*models.ApprovalThreshold, error) {
+	hundred := decimal.NewFromInt(100)
+	if req.MaxDiscountPercent.IsNegative() || req.MaxDiscountPercent.GreaterThan(hundred) {
+		return nil, errors.New("max_discount_percent must be between 0 and 100")
+	}
+	if req.MaxPriceOverridePercent.IsNegative() || req.MaxPriceOverridePercent.GreaterThan(hundred) {
+		return nil, errors.New("max_price_override_percent must be between 0 and 100")
+	}
+	if _, err := s.repos.Role.GetByID(roleID); err != nil {
+		return nil, errors.New("role not found")
+	}
+
+	threshold := &models.ApprovalThreshold{
+		RoleID:                  roleID,
+		MaxDiscountPercent:      req.MaxDiscountPercent,
+		MaxPriceOverridePercent: req.MaxPriceOverridePercent,
+		UpdatedBy:               &userID,
+	}
+	if err := s.repos.Approval.SetThreshold(threshold); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Approval.GetThreshold(roleID)
+}
+
+// DeleteThreshold lifts a role's limits
+func (s *approvalService) DeleteThreshold(roleID int64) error {
+	return s.repos.Approval.DeleteThreshold(roleID)
+}
+
+func (s *approvalService) List(page, limit int, filter *models.ApprovalFilter) ([]models.ApprovalRequest, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	approvals, total, err := s.repos.Approval.List(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return approvals, paginationMeta(page, limit, total), nil
+}
+
+func (s *approvalService) GetByID(id int64) (*models.ApprovalRequest, error) {
+	return s.repos.Approval.GetByID(id)
+}
+
+// Decide approves or rejects a request in the app as the logged in user
+func (s *approvalService) Decide(id int64, approve bool, notes string, userID int64, outletID *int64) (*models.ApprovalRequest, error) {
+	approval, err := s.pendingApproval(id, outletID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repos.User.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	return s.decide(approval, approve, user, "in_app", notes)
+}
+
+// DecideWithPIN approves or rejects a request on the requester's terminal.
+// The approver is identified by username and PIN, and needs the approve
+// permission and access to the request's outlet. Every attempt is logged
+// against the user at the terminal, and wrong PINs lock the approver's PIN.
+func (s *approvalService) DecideWithPIN(id int64, approve bool, req *models.ApprovalDecisionRequest, attemptedBy int64, outletID *int64) (*models.ApprovalRequest, error) {
+	approval, err := s.pendingApproval(id, outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt := &models.ApprovalPINAttempt{
+		ApprovalID:  approval.ApprovalID,
+		Username:    strings.TrimSpace(req.Username),
+		AttemptedBy: attemptedBy,
+	}
+	user, err := s.repos.User.GetByUsername(attempt.Username)
+	if err != nil || !user.IsActive {
+		return nil, s.failPINAttempt(attempt)
+	}
+	attempt.ApproverID = &user.ID
+
+	matches := func(hash string) bool {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.PIN)) == nil
+	}
+	if err = s.repos.Approval.VerifyPIN(attempt, matches, approvalPINMaxFailures, approvalPINLockout); err != nil {
+		return nil, err
+	}
+	if !attempt.Succeeded {
+		return nil, pinAttemptError(attempt)
+	}
+
+	permissions, err := s.repos.Role.GetPermissionsByRoleID(user.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	allowed := false
+	for _, permission := range permissions {
+		if permission.Name == "approvals.approve" {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, errors.New("approver does not have permission to approve")
+	}
+	if user.OutletID != nil && *user.OutletID != approval.OutletID {
+		return nil, errors.New("approver does not work at this outlet")
+	}
+
+	return s.decide(approval, approve, user, "pin", req.Notes)
+}
+
+// failPINAttempt records an attempt for a username that is not an active
+// user and returns the error the terminal sees
+func (s *approvalService) failPINAttempt(attempt *models.ApprovalPINAttempt) error {
+	err := s.repos.Approval.VerifyPIN(attempt, nil, approvalPINMaxFailures, approvalPINLockout)
+	if err != nil {
+		return err
+	}
+
+	return pinAttemptError(attempt)
+}
+
+// pinAttemptError logs a failed PIN attempt and returns the error the
+// terminal sees
+func pinAttemptError(attempt *models.ApprovalPINAttempt) error {
+	log.Printf("Warning: wrong approval PIN for %q on approval request %d from user %d",
+		attempt.Username, attempt.ApprovalID, attempt.AttemptedBy)
+
+	if attempt.Locked {
+		return fmt.Errorf("approver PIN is locked after %d wrong attempts; try again later or approve in the app",
+			approvalPINMaxFailures)
+	}
+	return errors.New("invalid approver or PIN")
+}
+
+// pendingApproval returns a request that is still waiting, as seen by a
+// user restricted to an outlet
+func (s *approvalService) pendingApproval(id int64, outletID *int64) (*models.ApprovalRequest, error) {
+	approval, err := s.repos.Approval.GetByID(id)
+	if err != nil || (outletID != nil && approval.OutletID != *outletID) {
+		return nil, errors.New("approval request not found")
+	}
+	if approval.Status != "pending" {
+		return nil, fmt.Errorf("approval request is already %s", approval.Status)
+	}
+
+	return approval, nil
+}
+
+// decide records the decision. Nobody approves their own request, and an
+// approver whose role is itself limited can only approve within that limit.
+func (s *approvalService) decide(approval *models.ApprovalRequest, approve bool, approver *models.User, method, notes string) (*models.ApprovalRequest, error) {
+	if approver.ID == approval.RequestedBy {
+		return nil, errors.New("requests cannot be decided by the user who made them")
+	}
+
+	status := "rejected"
+	if approve {
+		status = "approved"
+		limits, err := s.repos.Approval.GetThreshold(approver.RoleID)
+		if err != nil {
+			return nil, err
+		}
+		if limits != nil {
+			limit := limits.MaxDiscountPercent
+			if approval.RequestType == approvalPriceOverride {
+				limit = limits.MaxPriceOverridePercent
+			}
+			if approval.Percent.GreaterThan(limit) {
+				return nil, fmt.Errorf("approver can only approve up to %s%%", limit.String())
+			}
+		}
+	}
+
+	if err := s.repos.Approval.Decide(approval.ApprovalID, status, approver.ID, method, strings.TrimSpace(notes)); err != nil {
+		return nil, err
+	}
+
+	// A rejected service job line is back at its list price
+	if !approve && approval.ServiceJobID != nil && approval.ServiceDetailID != nil {
+		if err := s.serviceJobs.CalculateTotal(*approval.ServiceJobID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repos.Approval.GetByID(approval.ApprovalID)
+}
+
+// SetPIN sets the PIN the logged in approver types on other users'
+// terminals. The account password confirms it.
+func (s *approvalService) SetPIN(userID int64, req *models.SetApprovalPINRequest) error {
+	if !approvalPINPattern.MatchString(req.PIN) {
+		return errors.New("PIN must be 4 to 8 digits")
+	}
+
+	user, err := s.repos.User.GetByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		return errors.New("password is incorrect")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.PIN), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash PIN")
+	}
+
+	return s.repos.Approval.SetPINHash(userID, string(hash))
+}
+
+// approvalLimits returns the threshold of a user's role, or nil when the
+// role is not limited
+func approvalLimits(repos *repositories.Repositories, userID int64) (*models.ApprovalThreshold, error) {
+	user, err := repos.User.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	return repos.Approval.GetThreshold(user.RoleID)
+}
+
+// priceOverrideApproval returns the request needed to sell below the list
+// price by more than the threshold allows, or nil
+func priceOverrideApproval(limits *models.ApprovalThreshold, listPrice, unitPrice float64) *models.ApprovalRequest {
+	if limits == nil || listPrice <= 0 {
+		return nil
+	}
+
+	list := decimal.NewFromFloat(listPrice).Round(2)
+	price := decimal.NewFromFloat(unitPrice).Round(2)
+	if !price.LessThan(list) {
+		return nil
+	}
+	percent := list.Sub(price).Div(list).Mul(decimal.NewFromInt(100)).Round(2)
+	if percent.LessThanOrEqual(limits.MaxPriceOverridePercent) {
+		return nil
+	}
+
+	return &models.ApprovalRequest{
+		RequestType:      approvalPriceOverride,
+		ListPrice:        &list,
+		RequestedPrice:   &price,
+		Percent:          percent,
+		ThresholdPercent: limits.MaxPriceOverridePercent,
+	}
+}
+
+// discountApproval returns the request needed for a discount larger than
+// the threshold allows, or nil
+func discountApproval(limits *models.ApprovalThreshold, subtotal, discount float64) *models.ApprovalRequest {
+	if limits == nil || discount <= 0 {
+		return nil
+	}
+
+	base := decimal.NewFromFloat(subtotal).Round(2)
+	amount := decimal.NewFromFloat(discount).Round(2)
+	percent := decimal.NewFromInt(100)
+	if base.IsPositive() {
+		percent = amount.Div(base).Mul(percent).Round(2)
+	}
+	if percent.LessThanOrEqual(limits.MaxDiscountPercent) {
+		return nil
+	}
+
+	return &models.ApprovalRequest{
+		RequestType:      approvalDiscount,
+		SubtotalAmount:   &base,
+		DiscountAmount:   &amount,
+		Percent:          percent,
+		ThresholdPercent: limits.MaxDiscountPercent,
+	}
+}
+
+// sameItem reports whether two optional product or service IDs match
+func sameItem(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
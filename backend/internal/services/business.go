@@ -5,6 +5,8 @@ import (
 
 	"flutter-bengkel/internal/models"
 	"flutter-bengkel/internal/repositories"
+
+	"github.com/shopspring/decimal"
 )
 
 // Customer Service
@@ -43,6 +45,12 @@ func (s *customerService) Create(req *models.Customer) (*models.Customer, error)
 	if req.CustomerType == "" {
 		req.CustomerType = "individual"
 	}
+	if req.NotificationChannel == "" {
+		req.NotificationChannel = "whatsapp"
+	}
+	if req.Language == "" {
+		req.Language = "id"
+	}
 	req.IsActive = true
 	req.LoyaltyPoints = 0
 
@@ -82,6 +90,14 @@ func (s *customerService) Update(id int64, req *models.Customer) (*models.Custom
 		}
 	}
 
+	// Keep contact preferences unless they are being changed
+	if req.NotificationChannel == "" {
+		req.NotificationChannel = existingCustomer.NotificationChannel
+	}
+	if req.Language == "" {
+		req.Language = existingCustomer.Language
+	}
+
 	if err := s.repos.Customer.Update(id, req); err != nil {
 		return nil, err
 	}
@@ -217,9 +233,9 @@ func (s *vehicleService) GetByCustomerID(customerID int64) ([]models.CustomerVeh
 
 // Service Service
 type ServiceService interface {
-	Create(req *models.Service) (*models.Service, error)
+	Create(req *models.Service, userID int64) (*models.Service, error)
 	GetByID(id int64) (*models.Service, error)
-	Update(id int64, req *models.Service) (*models.Service, error)
+	Update(id int64, req *models.Service, userID int64) (*models.Service, error)
 	Delete(id int64) error
 	List(page, limit int, categoryID *int64, search string) ([]models.Service, *models.PaginationMeta, error)
 	ListCategories() ([]models.ServiceCategory, error)
@@ -233,7 +249,7 @@ func NewServiceService(repos *repositories.Repositories) ServiceService {
 	return &serviceService{repos: repos}
 }
 
-func (s *serviceService) Create(req *models.Service) (*models.Service, error) {
+func (s *serviceService) Create(req *models.Service, userID int64) (*models.Service, error) {
 	// Generate service code if not provided
 	if req.ServiceCode == "" {
 		code, err := s.repos.Service.GenerateServiceCode()
@@ -255,6 +271,11 @@ func (s *serviceService) Create(req *models.Service) (*models.Service, error) {
 		return nil, err
 	}
 
+	// The first price starts the service's price history
+	if err := recordPriceChange(s.repos, nil, &req.ID, nil, req.StandardPrice, &userID); err != nil {
+		return nil, err
+	}
+
 	return s.repos.Service.GetByID(req.ID)
 }
 
@@ -262,7 +283,7 @@ func (s *serviceService) GetByID(id int64) (*models.Service, error) {
 	return s.repos.Service.GetByID(id)
 }
 
-func (s *serviceService) Update(id int64, req *models.Service) (*models.Service, error) {
+func (s *serviceService) Update(id int64, req *models.Service, userID int64) (*models.Service, error) {
 	// Get existing service
 	existingService, err := s.repos.Service.GetByID(id)
 	if err != nil {
@@ -280,6 +301,10 @@ func (s *serviceService) Update(id int64, req *models.Service) (*models.Service,
 		return nil, err
 	}
 
+	if err := recordPriceChange(s.repos, nil, &id, &existingService.StandardPrice, req.StandardPrice, &userID); err != nil {
+		return nil, err
+	}
+
 	return s.repos.Service.GetByID(id)
 }
 
@@ -315,12 +340,12 @@ func (s *serviceService) ListCategories() ([]models.ServiceCategory, error) {
 
 // Product Service
 type ProductService interface {
-	Create(req *models.Product) (*models.Product, error)
+	Create(req *models.Product, userID int64) (*models.Product, error)
 	GetByID(id int64) (*models.Product, error)
-	Update(id int64, req *models.Product) (*models.Product, error)
+	Update(id int64, req *models.Product, userID int64) (*models.Product, error)
 	Delete(id int64) error
 	List(page, limit int, categoryID *int64, supplierID *int64, search string) ([]models.Product, *models.PaginationMeta, error)
-	UpdateStock(id int64, quantity int, operation string) error
+	UpdateStock(id int64, quantity int, operation string, unitCost *decimal.Decimal) error
 	GetLowStockProducts(outletID *int64) ([]models.Product, error)
 	ListCategories() ([]models.Category, error)
 	ListSuppliers() ([]models.Supplier, error)
@@ -328,14 +353,15 @@ type ProductService interface {
 }
 
 type productService struct {
-	repos *repositories.Repositories
+	repos    *repositories.Repositories
+	eventBus EventService
 }
 
-func NewProductService(repos *repositories.Repositories) ProductService {
-	return &productService{repos: repos}
+func NewProductService(repos *repositories.Repositories, eventBus EventService) ProductService {
+	return &productService{repos: repos, eventBus: eventBus}
 }
 
-func (s *productService) Create(req *models.Product) (*models.Product, error) {
+func (s *productService) Create(req *models.Product, userID int64) (*models.Product, error) {
 	// Generate product code if not provided
 	if req.ProductCode == "" {
 		code, err := s.repos.Product.GenerateProductCode()
@@ -357,6 +383,11 @@ func (s *productService) Create(req *models.Product) (*models.Product, error) {
 		return nil, err
 	}
 
+	// The first price starts the product's price history
+	if err := recordPriceChange(s.repos, &req.ID, nil, nil, req.SellingPrice, &userID); err != nil {
+		return nil, err
+	}
+
 	return s.repos.Product.GetByID(req.ID)
 }
 
@@ -364,7 +395,7 @@ func (s *productService) GetByID(id int64) (*models.Product, error) {
 	return s.repos.Product.GetByID(id)
 }
 
-func (s *productService) Update(id int64, req *models.Product) (*models.Product, error) {
+func (s *productService) Update(id int64, req *models.Product, userID int64) (*models.Product, error) {
 	// Get existing product
 	existingProduct, err := s.repos.Product.GetByID(id)
 	if err != nil {
@@ -378,10 +409,17 @@ func (s *productService) Update(id int64, req *models.Product) (*models.Product,
 		}
 	}
 
+	// Cost follows the goods received; see the costing service
+	req.CostPrice = existingProduct.CostPrice
+
 	if err := s.repos.Product.Update(id, req); err != nil {
 		return nil, err
 	}
 
+	if err := recordPriceChange(s.repos, &id, nil, &existingProduct.SellingPrice, req.SellingPrice, &userID); err != nil {
+		return nil, err
+	}
+
 	return s.repos.Product.GetByID(id)
 }
 
@@ -411,7 +449,9 @@ func (s *productService) List(page, limit int, categoryID *int64, supplierID *in
 	return products, meta, nil
 }
 
-func (s *productService) UpdateStock(id int64, quantity int, operation string) error {
+// UpdateStock adds or subtracts stock by hand. Stock added needs what it
+// cost, since it moves the product's weighted average cost.
+func (s *productService) UpdateStock(id int64, quantity int, operation string, unitCost *decimal.Decimal) error {
 	if operation != "add" && operation != "subtract" {
 		return errors.New("invalid operation: must be 'add' or 'subtract'")
 	}
@@ -420,7 +460,20 @@ func (s *productService) UpdateStock(id int64, quantity int, operation string) e
 		return errors.New("quantity must be positive")
 	}
 
-	return s.repos.Product.UpdateStock(id, quantity, operation)
+	cost := decimal.Zero
+	if operation == "add" {
+		if unitCost == nil || unitCost.IsNegative() {
+			return errors.New("unit_cost is required to add stock and cannot be negative")
+		}
+		cost = unitCost.Round(2)
+	}
+
+	if err := s.repos.Product.UpdateStock(id, quantity, operation, cost); err != nil {
+		return err
+	}
+	s.eventBus.Wake()
+
+	return nil
 }
 
 func (s *productService) GetLowStockProducts(outletID *int64) ([]models.Product, error) {
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+)
+
+// CashService interface defines cash flow reporting, end-of-day closing and
+// supplier payments
+type CashService interface {
+	ListCashFlows(page, limit int, filter *models.CashFlowFilter) ([]models.CashFlow, *models.PaginationMeta, error)
+	ListDailySummaries(page, limit int, outletID *int64, status string) ([]models.DailyCashSummary, *models.PaginationMeta, error)
+	GetDay(outletID int64, date time.Time) (*models.DailyCashSummary, error)
+	CloseDay(outletID int64, date time.Time, userID int64, notes string) (*models.DailyCashSummary, error)
+
+	ListPayables(page, limit int, filter *models.AccountsPayableFilter) ([]models.AccountsPayable, *models.PaginationMeta, error)
+	GetPayable(id int64) (*models.AccountsPayable, error)
+	PaySupplier(payableID int64, req *models.CreatePayablePaymentRequest, outletID, userID int64) (*models.PayablePayment, error)
+}
+
+type cashService struct {
+	repos    *repositories.Repositories
+	location *time.Location
+}
+
+// NewCashService creates a new cash service
+func NewCashService(repos *repositories.Repositories, cfg *config.Config) CashService {
+	return &cashService{repos: repos, location: businessLocation(cfg)}
+}
+
+func (s *cashService) ListCashFlows(page, limit int, filter *models.CashFlowFilter) ([]models.CashFlow, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	flows, total, err := s.repos.Cash.ListCashFlows(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return flows, paginationMeta(page, limit, total), nil
+}
+
+func (s *cashService) ListDailySummaries(page, limit int, outletID *int64, status string) ([]models.DailyCashSummary, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	summaries, total, err := s.repos.Cash.ListDailySummaries(outletID, status, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return summaries, paginationMeta(page, limit, total), nil
+}
+
+// GetDay returns the final summary of a closed day, or the running position
+// of an open one
+func (s *cashService) GetDay(outletID int64, date time.Time) (*models.DailyCashSummary, error) {
+	if summary, err := s.repos.Cash.GetDailySummary(outletID, date); err == nil && summary.Status == "closed" {
+		return summary, nil
+	}
+
+	return s.repos.Cash.ComputeDay(outletID, date)
+}
+
+// CloseDay closes an outlet's cash for a day that has ended or is ending.
+// The closing balance becomes the next day's opening balance and no cash
+// flow can be recorded on or before the day afterwards.
+func (s *cashService) CloseDay(outletID int64, date time.Time, userID int64, notes string) (*models.DailyCashSummary, error) {
+	now := time.Now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if date.After(today) {
+		return nil, fmt.Errorf("cannot close a future date")
+	}
+
+	return s.repos.Cash.CloseDay(outletID, date, userID, notes)
+}
+
+func (s *cashService) ListPayables(page, limit int, filter *models.AccountsPayableFilter) ([]models.AccountsPayable, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	payables, total, err := s.repos.Cash.ListPayables(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return payables, paginationMeta(page, limit, total), nil
+}
+
+func (s *cashService) GetPayable(id int64) (*models.AccountsPayable, error) {
+	return s.repos.Cash.GetPayableByID(id)
+}
+
+// PaySupplier records a payment against a supplier payable
+func (s *cashService) PaySupplier(payableID int64, req *models.CreatePayablePaymentRequest, outletID, userID int64) (*models.PayablePayment, error) {
+	if !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+
+	payment := &models.PayablePayment{
+		AccountsPayableID: payableID,
+		PaymentMethodID:   req.PaymentMethodID,
+		Amount:            req.Amount.Round(2),
+		PaymentDate:       time.Now().In(s.location),
+		CreatedBy:         &userID,
+	}
+	if req.ReferenceNumber != "" {
+		payment.ReferenceNumber = &req.ReferenceNumber
+	}
+	if req.Notes != "" {
+		payment.Notes = &req.Notes
+	}
+
+	if err := s.repos.Cash.PaySupplier(payment, outletID); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"flutter-bengkel/internal/events"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/notifications"
+)
+
+// Checkout settles a transaction with one or more tenders at once, in the
+// cashier's open shift
+func (s *paymentService) Checkout(req *models.CreateCheckoutRequest, userID int64) (*models.Checkout, error) {
+	if req.TransactionID == 0 {
+		return nil, errors.New("transaction is required")
+	}
+	if len(req.Tenders) == 0 {
+		return nil, errors.New("at least one tender is required")
+	}
+
+	tenders := make([]models.CheckoutTenderRequest, len(req.Tenders))
+	for i, tender := range req.Tenders {
+		if tender.PaymentMethodID == 0 {
+			return nil, errors.New("payment method is required for every tender")
+		}
+		if !tender.Amount.IsPositive() {
+			return nil, errors.New("tender amount must be greater than zero")
+		}
+		tenders[i] = tender
+		tenders[i].Amount = tender.Amount.Round(2)
+	}
+
+	shiftID, err := s.paymentShift(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	checkout := &models.Checkout{
+		TransactionID: req.TransactionID,
+		ShiftID:       shiftID,
+		CreatedBy:     &userID,
+	}
+	if req.Notes != "" {
+		checkout.Notes = &req.Notes
+	}
+
+	if err := s.repos.Payment.Checkout(checkout, tenders); err != nil {
+		return nil, err
+	}
+	s.eventBus.Wake()
+
+	return s.repos.Payment.GetCheckoutByID(checkout.CheckoutID)
+}
+
+func (s *paymentService) GetCheckout(id int64) (*models.Checkout, error) {
+	return s.repos.Payment.GetCheckoutByID(id)
+}
+
+func (s *paymentService) ListCheckouts(page, limit int, filter *models.CheckoutFilter) ([]models.Checkout, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	checkouts, total, err := s.repos.Payment.ListCheckouts(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return checkouts, paginationMeta(page, limit, total), nil
+}
+
+// onCheckoutCompletedReceipt sends the customer one receipt listing every
+// tender of the checkout
+func (s *paymentService) onCheckoutCompletedReceipt(ctx context.Context, event events.Event) error {
+	var payload events.CheckoutCompletedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	if payload.CustomerID == nil {
+		return nil
+	}
+
+	checkout, err := s.repos.Payment.GetCheckoutByID(payload.CheckoutID)
+	if err != nil {
+		return err
+	}
+
+	tenders := make([]map[string]interface{}, len(checkout.Tenders))
+	for i, tender := range checkout.Tenders {
+		tenders[i] = map[string]interface{}{
+			"Method":   tender.PaymentMethodName,
+			"Tendered": tender.TenderedAmount.InexactFloat64(),
+			"Applied":  tender.AppliedAmount.InexactFloat64(),
+			"Change":   tender.ChangeAmount.InexactFloat64(),
+		}
+	}
+
+	data := map[string]interface{}{
+		"CheckoutNumber":    checkout.CheckoutNumber,
+		"TransactionNumber": checkout.TransactionNumber,
+		"Tenders":           tenders,
+		"Tendered":          checkout.TenderedAmount.InexactFloat64(),
+		"Applied":           checkout.AppliedAmount.InexactFloat64(),
+		"Change":            checkout.ChangeAmount.InexactFloat64(),
+		"Remaining":         checkout.Balance.Outstanding.InexactFloat64(),
+	}
+	return s.notifications.NotifyCustomer(*payload.CustomerID, &payload.OutletID,
+		notifications.TemplateCheckoutReceipt, data, "checkout", payload.CheckoutID)
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"flutter-bengkel/internal/costing"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+)
+
+// CostingService interface defines goods receipt and gross margin operations
+type CostingService interface {
+	ListReceipts(page, limit int, filter *models.GoodsReceiptFilter) ([]models.GoodsReceipt, *models.PaginationMeta, error)
+	GetReceipt(id int64, outletID *int64) (*models.GoodsReceipt, error)
+	CreateReceipt(req *models.GoodsReceiptRequest, outletID int64, userID int64) (*models.GoodsReceipt, error)
+
+	ListTransactionMargins(page, limit int, filter *models.MarginFilter) ([]models.DocumentMargin, *models.PaginationMeta, error)
+	GetTransactionMargin(transactionID int64, outletID *int64) (*models.DocumentMargin, error)
+	GetServiceJobMargin(serviceJobID int64, outletID *int64) (*models.DocumentMargin, error)
+	GetProductMargins(filter *models.MarginFilter) ([]models.ProductMargin, error)
+	GetOutletMargins(filter *models.MarginFilter) ([]models.OutletMargin, error)
+}
+
+type costingService struct {
+	repos    *repositories.Repositories
+	eventBus EventService
+}
+
+// NewCostingService creates a new costing service
+func NewCostingService(repos *repositories.Repositories, eventBus EventService) CostingService {
+	return &costingService{repos: repos, eventBus: eventBus}
+}
+
+func (s *costingService) ListReceipts(page, limit int, filter *models.GoodsReceiptFilter) ([]models.GoodsReceipt, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	receipts, total, err := s.repos.Costing.ListReceipts(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return receipts, paginationMeta(page, limit, total), nil
+}
+
+// GetReceipt returns a goods receipt; outlet users only see their own
+// outlet's receipts
+func (s *costingService) GetReceipt(id int64, outletID *int64) (*models.GoodsReceipt, error) {
+	receipt, err := s.repos.Costing.GetReceipt(id)
+	if err != nil {
+		return nil, err
+	}
+	if outletID != nil && receipt.OutletID != *outletID {
+		return nil, errors.New("goods receipt not found")
+	}
+
+	return receipt, nil
+}
+
+// CreateReceipt receives goods into an outlet's stock at what they cost and
+// moves each product's cost to its weighted average
+func (s *costingService) CreateReceipt(req *models.GoodsReceiptRequest, outletID int64, userID int64) (*models.GoodsReceipt, error) {
+	if len(req.Items) == 0 {
+		return nil, errors.New("a goods receipt needs at least one item")
+	}
+
+	receipt := &models.GoodsReceipt{
+		OutletID:        outletID,
+		SupplierID:      req.SupplierID,
+		PurchaseOrderID: req.PurchaseOrderID,
+		CreatedBy:       &userID,
+		Items:           make([]models.GoodsReceiptItem, len(req.Items)),
+	}
+	if reference := strings.TrimSpace(req.SupplierReference); reference != "" {
+		receipt.SupplierReference = &reference
+	}
+	if notes := strings.TrimSpace(req.Notes); notes != "" {
+		receipt.Notes = &notes
+	}
+
+	for i, item := range req.Items {
+		if item.ProductID == 0 {
+			return nil, errors.New("each item needs a product_id")
+		}
+		if item.Quantity <= 0 {
+			return nil, errors.New("item quantity must be greater than 0")
+		}
+		if item.UnitCost.IsNegative() {
+			return nil, errors.New("unit_cost cannot be negative")
+		}
+		receipt.Items[i] = models.GoodsReceiptItem{
+			ProductID:             item.ProductID,
+			PurchaseOrderDetailID: item.PurchaseOrderDetailID,
+			Quantity:              item.Quantity,
+			UnitCost:              item.UnitCost,
+		}
+	}
+
+	if err := s.repos.Costing.CreateReceipt(receipt); err != nil {
+		return nil, err
+	}
+	s.eventBus.Wake()
+
+	return s.repos.Costing.GetReceipt(receipt.ReceiptID)
+}
+
+func (s *costingService) ListTransactionMargins(page, limit int, filter *models.MarginFilter) ([]models.DocumentMargin, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	margins, total, err := s.repos.Costing.ListTransactionMargins(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range margins {
+		margin := costing.NewMargin(margins[i].Revenue, margins[i].Cost)
+		margins[i].GrossMargin = margin.Amount
+		margins[i].MarginPercent = margin.Percent
+	}
+
+	return margins, paginationMeta(page, limit, total), nil
+}
+
+// GetTransactionMargin returns a transaction's gross margin line by line,
+// net of returns
+func (s *costingService) GetTransactionMargin(transactionID int64, outletID *int64) (*models.DocumentMargin, error) {
+	margin, err := s.repos.Costing.GetTransactionMargin(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if outletID != nil && margin.OutletID != *outletID {
+		return nil, errors.New("transaction not found")
+	}
+	totalMargin(margin)
+
+	return margin, nil
+}
+
+// GetServiceJobMargin returns a service job's gross margin line by line
+func (s *costingService) GetServiceJobMargin(serviceJobID int64, outletID *int64) (*models.DocumentMargin, error) {
+	margin, err := s.repos.Costing.GetServiceJobMargin(serviceJobID)
+	if err != nil {
+		return nil, err
+	}
+	if outletID != nil && margin.OutletID != *outletID {
+		return nil, errors.New("service job not found")
+	}
+	totalMargin(margin)
+
+	return margin, nil
+}
+
+func (s *costingService) GetProductMargins(filter *models.MarginFilter) ([]models.ProductMargin, error) {
+	margins, err := s.repos.Costing.GetProductMargins(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range margins {
+		margin := costing.NewMargin(margins[i].Revenue, margins[i].Cost)
+		margins[i].GrossMargin = margin.Amount
+		margins[i].MarginPercent = margin.Percent
+	}
+
+	return margins, nil
+}
+
+func (s *costingService) GetOutletMargins(filter *models.MarginFilter) ([]models.OutletMargin, error) {
+	margins, err := s.repos.Costing.GetOutletMargins(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range margins {
+		margin := costing.NewMargin(margins[i].Revenue, margins[i].Cost)
+		margins[i].GrossMargin = margin.Amount
+		margins[i].MarginPercent = margin.Percent
+	}
+
+	return margins, nil
+}
+
+// totalMargin works out each line's margin and the document's from them
+func totalMargin(document *models.DocumentMargin) {
+	for i := range document.Lines {
+		line := &document.Lines[i]
+		margin := costing.NewMargin(line.Revenue, line.Cost)
+		line.GrossMargin = margin.Amount
+		line.MarginPercent = margin.Percent
+
+		document.Revenue = document.Revenue.Add(line.Revenue)
+		document.Cost = document.Cost.Add(line.Cost)
+	}
+
+	margin := costing.NewMargin(document.Revenue, document.Cost)
+	document.Revenue = margin.Revenue
+	document.Cost = margin.Cost
+	document.GrossMargin = margin.Amount
+	document.MarginPercent = margin.Percent
+}
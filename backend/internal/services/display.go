@@ -0,0 +1,154 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+	"flutter-bengkel/internal/utils"
+)
+
+// displayUpcomingLimit caps how many waiting jobs a display board shows
+const displayUpcomingLimit = 10
+
+// DisplayService interface defines waiting room display board operations
+type DisplayService interface {
+	CreateToken(req *models.CreateDisplayTokenRequest, outletID int64, userID int64) (*models.OutletDisplayToken, error)
+	ListTokens(outletID *int64) ([]models.OutletDisplayToken, error)
+	RevokeToken(id int64, outletID *int64) error
+	Authenticate(token string) (*models.OutletDisplayToken, error)
+	GetBoard(display *models.OutletDisplayToken) (*models.DisplayBoard, error)
+	BuildBoard(display *models.OutletDisplayToken, queue *models.OutletQueue) *models.DisplayBoard
+}
+
+type displayService struct {
+	repos *repositories.Repositories
+	queue QueueService
+}
+
+// NewDisplayService creates a new display service
+func NewDisplayService(repos *repositories.Repositories, queue QueueService) DisplayService {
+	return &displayService{
+		repos: repos,
+		queue: queue,
+	}
+}
+
+func (s *displayService) CreateToken(req *models.CreateDisplayTokenRequest, outletID int64, userID int64) (*models.OutletDisplayToken, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("display name is required")
+	}
+
+	token, err := utils.GenerateRandomString(48)
+	if err != nil {
+		return nil, errors.New("failed to generate display token")
+	}
+
+	display := &models.OutletDisplayToken{
+		OutletID:  outletID,
+		Name:      name,
+		TokenHash: hashDisplayToken(token),
+		IsActive:  true,
+		CreatedBy: &userID,
+	}
+
+	if err := s.repos.Display.Create(display); err != nil {
+		return nil, err
+	}
+
+	// Only the hash is stored, so this is the one time the token is shown
+	created, err := s.repos.Display.GetByID(display.DisplayTokenID)
+	if err != nil {
+		return nil, err
+	}
+	created.Token = token
+
+	return created, nil
+}
+
+func (s *displayService) ListTokens(outletID *int64) ([]models.OutletDisplayToken, error) {
+	return s.repos.Display.ListByOutlet(outletID)
+}
+
+func (s *displayService) RevokeToken(id int64, outletID *int64) error {
+	display, err := s.repos.Display.GetByID(id)
+	if err != nil {
+		return errors.New("display token not found")
+	}
+
+	// Outlet users may only revoke their own outlet's displays
+	if outletID != nil && display.OutletID != *outletID {
+		return errors.New("display token not found")
+	}
+
+	return s.repos.Display.Revoke(id)
+}
+
+func (s *displayService) Authenticate(token string) (*models.OutletDisplayToken, error) {
+	if token == "" {
+		return nil, errors.New("invalid display token")
+	}
+
+	display, err := s.repos.Display.GetActiveByTokenHash(hashDisplayToken(token))
+	if err != nil {
+		return nil, errors.New("invalid display token")
+	}
+
+	if err := s.repos.Display.TouchLastSeen(display.DisplayTokenID); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	return display, nil
+}
+
+func (s *displayService) GetBoard(display *models.OutletDisplayToken) (*models.DisplayBoard, error) {
+	queue, err := s.queue.GetOutletQueue(display.OutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.BuildBoard(display, queue), nil
+}
+
+// BuildBoard strips a queue snapshot down to what may be shown publicly
+func (s *displayService) BuildBoard(display *models.OutletDisplayToken, queue *models.OutletQueue) *models.DisplayBoard {
+	board := &models.DisplayBoard{
+		OutletName:  display.OutletName,
+		GeneratedAt: queue.GeneratedAt,
+		NowServing:  make([]models.DisplayBoardEntry, 0, len(queue.InProgress)),
+		Upcoming:    []models.DisplayBoardEntry{},
+	}
+
+	for _, entry := range queue.InProgress {
+		board.NowServing = append(board.NowServing, toDisplayBoardEntry(entry))
+	}
+
+	for i, entry := range queue.Pending {
+		if i >= displayUpcomingLimit {
+			break
+		}
+		board.Upcoming = append(board.Upcoming, toDisplayBoardEntry(entry))
+	}
+
+	return board
+}
+
+func toDisplayBoardEntry(entry models.QueueEntry) models.DisplayBoardEntry {
+	return models.DisplayBoardEntry{
+		QueueNumber:    entry.QueueNumber,
+		VehicleNumber:  utils.MaskPlateNumber(entry.VehicleNumber),
+		Status:         entry.Status,
+		EstimatedStart: entry.EstimatedStart,
+	}
+}
+
+// hashDisplayToken returns the SHA-256 hash a display token is stored by
+func hashDisplayToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/events"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+)
+
+const (
+	// eventBatchSize caps how many events one dispatch pass handles
+	eventBatchSize = 100
+	// eventHandlerTimeout bounds a single delivery of an event to its subscribers
+	eventHandlerTimeout = 30 * time.Second
+	// eventStaleAfter releases events stuck in processing by a crashed process
+	eventStaleAfter = 5 * time.Minute
+	// eventMaxBackoff caps the delay between retries
+	eventMaxBackoff = time.Hour
+)
+
+// EventService interface defines the domain event bus backed by the outbox.
+// Events are recorded by repositories in the same database transaction as
+// the change they describe; the dispatcher then delivers each one to its
+// subscribers at least once, retrying only the subscribers that failed.
+type EventService interface {
+	Subscribe(name string, handler events.Handler, types ...string)
+	Wake()
+
+	List(page, limit int, filter *models.DomainEventFilter) ([]models.DomainEvent, *models.PaginationMeta, error)
+	GetByID(id int64) (*models.DomainEvent, error)
+	Retry(id int64) (*models.DomainEvent, error)
+
+	DispatchDue() (int, error)
+	RunDispatcher(stop <-chan struct{})
+}
+
+type eventService struct {
+	repos *repositories.Repositories
+	cfg   *config.Config
+	bus   *events.Bus
+	wake  chan struct{}
+}
+
+// NewEventService creates a new domain event service
+func NewEventService(repos *repositories.Repositories, cfg *config.Config) EventService {
+	return &eventService{
+		repos: repos,
+		cfg:   cfg,
+		bus:   events.NewBus(),
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// Subscribe registers a named handler for the given event types
+func (s *eventService) Subscribe(name string, handler events.Handler, types ...string) {
+	s.bus.Subscribe(name, handler, types...)
+}
+
+// Wake asks the dispatcher to run now rather than wait for its next tick.
+// Services call it after committing a change that recorded events.
+func (s *eventService) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *eventService) List(page, limit int, filter *models.DomainEventFilter) ([]models.DomainEvent, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	items, total, err := s.repos.Event.List(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return items, paginationMeta(page, limit, total), nil
+}
+
+func (s *eventService) GetByID(id int64) (*models.DomainEvent, error) {
+	event, err := s.repos.Event.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	event.DeliveredTo, err = s.repos.Event.GetDeliveredSubscribers(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (s *eventService) Retry(id int64) (*models.DomainEvent, error) {
+	if err := s.repos.Event.Requeue(id); err != nil {
+		return nil, err
+	}
+	s.Wake()
+
+	return s.GetByID(id)
+}
+
+// DispatchDue delivers every event whose next attempt is due. An event is
+// processed once all of its subscribers have handled it; otherwise it is
+// retried with exponential backoff until max attempts is reached.
+func (s *eventService) DispatchDue() (int, error) {
+	due, err := s.repos.Event.ClaimDue(eventBatchSize, eventStaleAfter)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, event := range due {
+		if err := s.dispatch(event); err != nil {
+			var nextAttemptAt *time.Time
+			if event.Attempts < event.MaxAttempts {
+				next := time.Now().Add(s.retryBackoff(event.Attempts))
+				nextAttemptAt = &next
+			}
+			if err := s.repos.Event.MarkFailed(event.EventID, err.Error(), nextAttemptAt); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			continue
+		}
+
+		if err := s.repos.Event.MarkProcessed(event.EventID); err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+// dispatch hands the event to every subscriber that has not yet handled it
+// and records each success, returning the combined subscriber errors
+func (s *eventService) dispatch(event models.DomainEvent) error {
+	delivered, err := s.repos.Event.GetDeliveredSubscribers(event.EventID)
+	if err != nil {
+		return err
+	}
+
+	skip := make(map[string]bool, len(delivered))
+	for _, name := range delivered {
+		skip[name] = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), eventHandlerTimeout)
+	defer cancel()
+
+	results := s.bus.Deliver(ctx, events.Event{
+		ID:            event.EventID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		OutletID:      event.OutletID,
+		Payload:       json.RawMessage(event.Payload),
+		OccurredAt:    event.OccurredAt,
+	}, skip)
+
+	var failures []string
+	for name, err := range results {
+		if err == nil {
+			err = s.repos.Event.RecordDelivery(event.EventID, name)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// retryBackoff doubles the configured base delay after every failed attempt
+func (s *eventService) retryBackoff(attempts int) time.Duration {
+	backoff := time.Duration(s.cfg.Events.RetryBaseSeconds) * time.Second
+	for i := 1; i < attempts && backoff < eventMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > eventMaxBackoff {
+		backoff = eventMaxBackoff
+	}
+	return backoff
+}
+
+// RunDispatcher delivers due events on the configured interval, or as soon
+// as Wake is called, until stop is closed
+func (s *eventService) RunDispatcher(stop <-chan struct{}) {
+	interval := time.Duration(s.cfg.Events.DispatchIntervalSeconds) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			processed, err := s.DispatchDue()
+			if err != nil {
+				log.Printf("Warning: domain event dispatch failed: %v", err)
+			}
+			// Keep draining while full batches come back
+			if err != nil || processed < eventBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,467 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/events"
+	"flutter-bengkel/internal/ledger"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+
+	"github.com/shopspring/decimal"
+)
+
+// LedgerService interface defines the chart of accounts, journal posting and
+// ledger reports
+type LedgerService interface {
+	ListAccounts(includeInactive bool) ([]models.GLAccount, error)
+	CreateAccount(req *models.CreateGLAccountRequest, userID int64) (*models.GLAccount, error)
+	UpdateAccount(id int64, req *models.UpdateGLAccountRequest) (*models.GLAccount, error)
+	DeleteAccount(id int64) error
+
+	CreateEntry(req *models.CreateJournalEntryRequest, outletID, userID int64) (*models.JournalEntry, error)
+	GetEntry(id int64) (*models.JournalEntry, error)
+	ListEntries(page, limit int, filter *models.JournalEntryFilter) ([]models.JournalEntry, *models.PaginationMeta, error)
+
+	TrialBalance(outletID *int64, from, to time.Time) (*models.TrialBalance, error)
+	GeneralLedger(accountID int64, outletID *int64, from, to time.Time) (*models.GeneralLedger, error)
+
+	PostDocument(sourceType string, sourceID int64) error
+	Backfill() (*models.LedgerBackfillResult, error)
+}
+
+type ledgerService struct {
+	repos *repositories.Repositories
+}
+
+// NewLedgerService creates a new ledger service and subscribes it to the
+// events of documents that post to the ledger
+func NewLedgerService(repos *repositories.Repositories, eventBus EventService) LedgerService {
+	s := &ledgerService{repos: repos}
+
+	eventBus.Subscribe("ledger.posting", s.onDocumentEvent,
+		events.TransactionPosted, events.PaymentReceived, events.VehiclePurchased, events.VehicleSold,
+		events.TransactionVoided, events.PaymentReversed, events.SalesReturned, events.RefundIssued, events.CreditNoteIssued,
+		events.CreditNoteApplied, events.StoredValuePosted, events.GoodsReceived)
+
+	return s
+}
+
+var accountTypeNormalBalance = map[string]string{
+	"asset":     "debit",
+	"liability": "credit",
+	"equity":    "credit",
+	"revenue":   "credit",
+	"expense":   "debit",
+}
+
+func (s *ledgerService) ListAccounts(includeInactive bool) ([]models.GLAccount, error) {
+	return s.repos.Ledger.ListAccounts(includeInactive)
+}
+
+func (s *ledgerService) CreateAccount(req *models.CreateGLAccountRequest, userID int64) (*models.GLAccount, error) {
+	normal, ok := accountTypeNormalBalance[req.AccountType]
+	if !ok {
+		return nil, fmt.Errorf("invalid account type: %s", req.AccountType)
+	}
+	if req.NormalBalance != "" {
+		if req.NormalBalance != "debit" && req.NormalBalance != "credit" {
+			return nil, fmt.Errorf("normal balance must be debit or credit")
+		}
+		normal = req.NormalBalance
+	}
+
+	if req.ParentID != nil {
+		if err := s.checkParent(*req.ParentID, req.AccountType); err != nil {
+			return nil, err
+		}
+	}
+
+	account := &models.GLAccount{
+		Code:          strings.TrimSpace(req.Code),
+		Name:          req.Name,
+		AccountType:   req.AccountType,
+		NormalBalance: normal,
+		ParentID:      req.ParentID,
+		Description:   req.Description,
+		IsActive:      true,
+		CreatedBy:     &userID,
+	}
+
+	if err := s.repos.Ledger.CreateAccount(account); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (s *ledgerService) UpdateAccount(id int64, req *models.UpdateGLAccountRequest) (*models.GLAccount, error) {
+	account, err := s.repos.Ledger.GetAccountByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		account.Name = req.Name
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == id {
+			return nil, fmt.Errorf("an account cannot be its own parent")
+		}
+		if err := s.checkParent(*req.ParentID, account.AccountType); err != nil {
+			return nil, err
+		}
+		account.ParentID = req.ParentID
+	}
+	if req.Description != nil {
+		account.Description = *req.Description
+	}
+	if req.IsActive != nil {
+		if account.IsSystem && !*req.IsActive {
+			return nil, fmt.Errorf("system accounts cannot be deactivated")
+		}
+		account.IsActive = *req.IsActive
+	}
+
+	if err := s.repos.Ledger.UpdateAccount(account); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// checkParent ensures a parent account exists and has the same type
+func (s *ledgerService) checkParent(parentID int64, accountType string) error {
+	parent, err := s.repos.Ledger.GetAccountByID(parentID)
+	if err != nil {
+		return fmt.Errorf("parent account not found")
+	}
+	if parent.AccountType != accountType {
+		return fmt.Errorf("parent account must be a %s account", accountType)
+	}
+	return nil
+}
+
+func (s *ledgerService) DeleteAccount(id int64) error {
+	return s.repos.Ledger.DeleteAccount(id)
+}
+
+// CreateEntry posts a manual journal entry, used for opening balances,
+// adjustments and expenses not recorded elsewhere
+func (s *ledgerService) CreateEntry(req *models.CreateJournalEntryRequest, outletID, userID int64) (*models.JournalEntry, error) {
+	entryDate, err := time.Parse("2006-01-02", req.EntryDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry date, expected YYYY-MM-DD")
+	}
+
+	entry := &ledger.Entry{
+		OutletID:    outletID,
+		Date:        entryDate,
+		Description: req.Description,
+		SourceType:  ledger.SourceManual,
+		CreatedBy:   &userID,
+	}
+	for _, line := range req.Lines {
+		entry.Lines = append(entry.Lines, ledger.Line{
+			AccountID:   line.AccountID,
+			Description: line.Description,
+			Debit:       line.Debit,
+			Credit:      line.Credit,
+		})
+	}
+
+	entryID, _, err := s.repos.Ledger.PostEntry(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repos.Ledger.GetEntryByID(entryID)
+}
+
+func (s *ledgerService) GetEntry(id int64) (*models.JournalEntry, error) {
+	return s.repos.Ledger.GetEntryByID(id)
+}
+
+func (s *ledgerService) ListEntries(page, limit int, filter *models.JournalEntryFilter) ([]models.JournalEntry, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	entries, total, err := s.repos.Ledger.ListEntries(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entries, paginationMeta(page, limit, total), nil
+}
+
+// TrialBalance lists each account's closing balance on the debit or credit
+// side, with its opening balance and movements for the period
+func (s *ledgerService) TrialBalance(outletID *int64, from, to time.Time) (*models.TrialBalance, error) {
+	rows, err := s.repos.Ledger.GetTrialBalance(outletID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.TrialBalance{
+		OutletID: outletID,
+		DateFrom: from,
+		DateTo:   to,
+		Rows:     rows,
+	}
+
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		// Convert the normal-direction balance back to a debit or credit
+		net := row.ClosingBalance
+		if row.NormalBalance == "credit" {
+			net = net.Neg()
+		}
+		if net.IsPositive() {
+			row.ClosingDebit = net
+		} else {
+			row.ClosingCredit = net.Neg()
+		}
+
+		report.TotalDebit = report.TotalDebit.Add(row.ClosingDebit)
+		report.TotalCredit = report.TotalCredit.Add(row.ClosingCredit)
+	}
+	report.IsBalanced = report.TotalDebit.Equal(report.TotalCredit)
+
+	return report, nil
+}
+
+// GeneralLedger lists the postings to an account with a running balance in
+// the account's normal direction
+func (s *ledgerService) GeneralLedger(accountID int64, outletID *int64, from, to time.Time) (*models.GeneralLedger, error) {
+	account, err := s.repos.Ledger.GetAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	opening, err := s.repos.Ledger.GetAccountOpeningBalance(accountID, outletID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := s.repos.Ledger.GetAccountPostings(accountID, outletID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	sign := decimal.NewFromInt(1)
+	if account.NormalBalance == "credit" {
+		sign = sign.Neg()
+	}
+
+	report := &models.GeneralLedger{
+		Account:        *account,
+		OutletID:       outletID,
+		DateFrom:       from,
+		DateTo:         to,
+		OpeningBalance: opening.Mul(sign),
+		Lines:          lines,
+	}
+
+	balance := report.OpeningBalance
+	for i := range report.Lines {
+		line := &report.Lines[i]
+		balance = balance.Add(line.Debit.Sub(line.Credit).Mul(sign))
+		line.Balance = balance
+
+		report.TotalDebit = report.TotalDebit.Add(line.Debit)
+		report.TotalCredit = report.TotalCredit.Add(line.Credit)
+	}
+	report.ClosingBalance = balance
+
+	return report, nil
+}
+
+// PostDocument posts an operational document through its posting rule.
+// Documents that are already posted, or have nothing to post, are skipped.
+func (s *ledgerService) PostDocument(sourceType string, sourceID int64) error {
+	var entry *ledger.Entry
+	var err error
+
+	switch sourceType {
+	case ledger.SourceTransaction:
+		var doc *ledger.SalesDocument
+		if doc, err = s.repos.Ledger.GetSalesDocument(sourceID); err == nil {
+			entry, err = ledger.SalesEntry(*doc)
+		}
+	case ledger.SourcePayment:
+		var doc *ledger.PaymentDocument
+		if doc, err = s.repos.Ledger.GetPaymentDocument(sourceID); err == nil {
+			entry, err = ledger.PaymentEntry(*doc)
+		}
+	case ledger.SourceVehiclePurchase:
+		var doc *ledger.VehiclePurchaseDocument
+		if doc, err = s.repos.Ledger.GetVehiclePurchaseDocument(sourceID); err == nil {
+			entry, err = ledger.VehiclePurchaseEntry(*doc)
+		}
+	case ledger.SourceVehicleSale:
+		var doc *ledger.VehicleSaleDocument
+		if doc, err = s.repos.Ledger.GetVehicleSaleDocument(sourceID); err == nil {
+			entry, err = ledger.VehicleSaleEntry(*doc)
+		}
+	case ledger.SourceTransactionVoid:
+		var doc *ledger.VoidDocument
+		if doc, err = s.repos.Ledger.GetVoidDocument(sourceID); err == nil {
+			entry, err = ledger.TransactionVoidEntry(*doc)
+		}
+	case ledger.SourcePaymentReversal:
+		var doc *ledger.PaymentDocument
+		if doc, err = s.repos.Ledger.GetPaymentReversalDocument(sourceID); err == nil {
+			entry, err = ledger.PaymentReversalEntry(*doc)
+		}
+	case ledger.SourceSalesReturn:
+		var doc *ledger.SalesReturnDocument
+		if doc, err = s.repos.Ledger.GetSalesReturnDocument(sourceID); err == nil {
+			entry, err = ledger.SalesReturnEntry(*doc)
+		}
+	case ledger.SourceRefund:
+		var doc *ledger.RefundDocument
+		if doc, err = s.repos.Ledger.GetRefundDocument(sourceID); err == nil {
+			entry, err = ledger.RefundEntry(*doc)
+		}
+	case ledger.SourceCreditNote:
+		var doc *ledger.CreditNoteDocument
+		if doc, err = s.repos.Ledger.GetCreditNoteDocument(sourceID); err == nil {
+			entry, err = ledger.CreditNoteEntry(*doc)
+		}
+	case ledger.SourceCreditNoteApplication:
+		var doc *ledger.CreditNoteDocument
+		if doc, err = s.repos.Ledger.GetCreditNoteApplicationDocument(sourceID); err == nil {
+			entry, err = ledger.CreditNoteApplicationEntry(*doc)
+		}
+	case ledger.SourceStoredValueEntry:
+		var doc *ledger.StoredValueDocument
+		if doc, err = s.repos.Ledger.GetStoredValueDocument(sourceID); err == nil {
+			entry, err = ledger.StoredValueEntry(*doc)
+		}
+	case ledger.SourceGoodsReceipt:
+		var doc *ledger.GoodsReceiptDocument
+		if doc, err = s.repos.Ledger.GetGoodsReceiptDocument(sourceID); err == nil {
+			entry, err = ledger.GoodsReceiptEntry(*doc)
+		}
+	default:
+		return fmt.Errorf("unknown ledger source type: %s", sourceType)
+	}
+
+	if errors.Is(err, ledger.ErrEmptyEntry) || errors.Is(err, ledger.ErrTooFewLines) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _, err = s.repos.Ledger.PostEntry(entry)
+	return err
+}
+
+// Backfill posts every document that has no journal entry yet, such as
+// documents recorded before the ledger was introduced
+func (s *ledgerService) Backfill() (*models.LedgerBackfillResult, error) {
+	sources, err := s.repos.Ledger.ListUnpostedSources()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.LedgerBackfillResult{}
+	for _, source := range sources {
+		if err := s.PostDocument(source.SourceType, source.SourceID); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("%s %d: %v", source.SourceType, source.SourceID, err))
+			continue
+		}
+		result.Posted++
+	}
+
+	if result.Failed > 0 {
+		log.Printf("Ledger backfill: %d posted, %d failed", result.Posted, result.Failed)
+	}
+
+	return result, nil
+}
+
+// onDocumentEvent posts the document an event refers to
+func (s *ledgerService) onDocumentEvent(ctx context.Context, event events.Event) error {
+	switch event.Type {
+	case events.TransactionPosted:
+		var payload events.TransactionPostedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourceTransaction, payload.TransactionID)
+	case events.PaymentReceived:
+		var payload events.PaymentReceivedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourcePayment, payload.PaymentID)
+	case events.VehiclePurchased:
+		var payload events.VehiclePurchasedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourceVehiclePurchase, payload.PurchaseID)
+	case events.VehicleSold:
+		var payload events.VehicleSoldPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourceVehicleSale, payload.SaleID)
+	case events.TransactionVoided:
+		var payload events.TransactionVoidedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourceTransactionVoid, payload.TransactionID)
+	case events.PaymentReversed:
+		var payload events.PaymentReversedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourcePaymentReversal, payload.PaymentID)
+	case events.SalesReturned:
+		var payload events.SalesReturnedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourceSalesReturn, payload.ReturnID)
+	case events.RefundIssued:
+		var payload events.RefundIssuedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourceRefund, payload.RefundID)
+	case events.CreditNoteIssued:
+		var payload events.CreditNoteIssuedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourceCreditNote, payload.CreditNoteID)
+	case events.CreditNoteApplied:
+		var payload events.CreditNoteAppliedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourceCreditNoteApplication, payload.ApplicationID)
+	case events.StoredValuePosted:
+		var payload events.StoredValuePostedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourceStoredValueEntry, payload.EntryID)
+	case events.GoodsReceived:
+		var payload events.GoodsReceivedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		return s.PostDocument(ledger.SourceGoodsReceipt, payload.ReceiptID)
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/events"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+
+	"github.com/shopspring/decimal"
+)
+
+// loyaltyTransactionTypes are the transaction types that can earn points
+var loyaltyTransactionTypes = map[string]bool{
+	"service":        true,
+	"sparepart_sale": true,
+	"vehicle_sale":   true,
+}
+
+// LoyaltyService interface defines loyalty program settings, earn rules and
+// customer points operations
+type LoyaltyService interface {
+	GetSettings() (*models.LoyaltySettings, error)
+	UpdateSettings(req *models.UpdateLoyaltySettingsRequest, userID int64) (*models.LoyaltySettings, error)
+	ListRules() ([]models.LoyaltyEarnRule, error)
+	CreateRule(req *models.LoyaltyEarnRuleRequest, userID int64) (*models.LoyaltyEarnRule, error)
+	UpdateRule(id int64, req *models.LoyaltyEarnRuleRequest) (*models.LoyaltyEarnRule, error)
+	DeleteRule(id int64) error
+	GetAccount(customerID int64) (*models.LoyaltyAccount, error)
+	ListEntries(customerID int64, page, limit int) ([]models.LoyaltyPointEntry, *models.PaginationMeta, error)
+	Adjust(customerID int64, req *models.LoyaltyAdjustmentRequest, userID int64) (*models.LoyaltyAccount, error)
+}
+
+type loyaltyService struct {
+	repos *repositories.Repositories
+}
+
+// NewLoyaltyService creates the loyalty service and subscribes it to the
+// events that change what a transaction earns
+func NewLoyaltyService(repos *repositories.Repositories, eventBus EventService) LoyaltyService {
+	s := &loyaltyService{repos: repos}
+
+	eventBus.Subscribe("loyalty.earning", s.onTransactionSettled,
+		events.PaymentReceived, events.PaymentReversed, events.CreditNoteApplied,
+		events.SalesReturned, events.RefundIssued, events.TransactionVoided)
+
+	return s
+}
+
+func (s *loyaltyService) GetSettings() (*models.LoyaltySettings, error) {
+	return s.repos.Loyalty.GetSettings()
+}
+
+// UpdateSettings changes the program. A new expiry period applies to points
+// posted from now on.
+func (s *loyaltyService) UpdateSettings(req *models.UpdateLoyaltySettingsRequest, userID int64) (*models.LoyaltySettings, error) {
+	if !req.PointValue.IsPositive() {
+		return nil, errors.New("point_value must be greater than 0")
+	}
+	if req.MinRedeemPoints < 0 {
+		return nil, errors.New("min_redeem_points cannot be negative")
+	}
+	if req.ExpiryMonths < 0 {
+		return nil, errors.New("expiry_months cannot be negative")
+	}
+
+	settings := &models.LoyaltySettings{
+		IsEnabled:       req.IsEnabled,
+		PointValue:      req.PointValue.Round(2),
+		MinRedeemPoints: req.MinRedeemPoints,
+		ExpiryMonths:    req.ExpiryMonths,
+		UpdatedBy:       &userID,
+	}
+	if err := s.repos.Loyalty.UpdateSettings(settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (s *loyaltyService) ListRules() ([]models.LoyaltyEarnRule, error) {
+	return s.repos.Loyalty.ListRules()
+}
+
+func (s *loyaltyService) CreateRule(req *models.LoyaltyEarnRuleRequest, userID int64) (*models.LoyaltyEarnRule, error) {
+	rule := &models.LoyaltyEarnRule{IsActive: true, CreatedBy: &userID}
+	if err := s.applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Loyalty.CreateRule(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// UpdateRule changes an earn rule. Transactions that already earned keep
+// the rate they earned at.
+func (s *loyaltyService) UpdateRule(id int64, req *models.LoyaltyEarnRuleRequest) (*models.LoyaltyEarnRule, error) {
+	rule, err := s.repos.Loyalty.GetRule(id)
+	if err != nil {
+		return nil, errors.New("loyalty earn rule not found")
+	}
+	if err := s.applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Loyalty.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *loyaltyService) DeleteRule(id int64) error {
+	return s.repos.Loyalty.DeleteRule(id)
+}
+
+// applyRuleRequest validates an earn rule request onto a rule. Each
+// transaction type has one rule.
+func (s *loyaltyService) applyRuleRequest(rule *models.LoyaltyEarnRule, req *models.LoyaltyEarnRuleRequest) error {
+	transactionType := strings.TrimSpace(req.TransactionType)
+	if !loyaltyTransactionTypes[transactionType] {
+		return errors.New("transaction_type must be service, sparepart_sale or vehicle_sale")
+	}
+	if !req.SpendAmount.IsPositive() {
+		return errors.New("spend_amount must be greater than 0")
+	}
+	if req.Points <= 0 {
+		return errors.New("points must be greater than 0")
+	}
+	if req.MinAmount.IsNegative() {
+		return errors.New("min_amount cannot be negative")
+	}
+
+	existing, err := s.repos.Loyalty.GetRuleByType(transactionType)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.RuleID != rule.RuleID {
+		return fmt.Errorf("%s already has an earn rule", transactionType)
+	}
+
+	rule.TransactionType = transactionType
+	rule.SpendAmount = req.SpendAmount.Round(2)
+	rule.Points = req.Points
+	rule.MinAmount = req.MinAmount.Round(2)
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	return nil
+}
+
+// GetAccount returns a customer's balance, what it is worth when redeemed
+// and the points that expire next
+func (s *loyaltyService) GetAccount(customerID int64) (*models.LoyaltyAccount, error) {
+	account, err := s.repos.Loyalty.GetAccount(customerID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.repos.Loyalty.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+	if settings.IsEnabled && account.Balance > 0 {
+		account.RedeemableValue = settings.PointValue.Mul(decimal.NewFromInt(int64(account.Balance)))
+	}
+
+	return account, nil
+}
+
+func (s *loyaltyService) ListEntries(customerID int64, page, limit int) ([]models.LoyaltyPointEntry, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	entries, total, err := s.repos.Loyalty.ListEntries(customerID, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entries, paginationMeta(page, limit, total), nil
+}
+
+// Adjust adds or takes off points by hand, with the reason on the statement
+func (s *loyaltyService) Adjust(customerID int64, req *models.LoyaltyAdjustmentRequest, userID int64) (*models.LoyaltyAccount, error) {
+	if req.Points == 0 {
+		return nil, errors.New("points cannot be 0")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	entry := &models.LoyaltyPointEntry{
+		CustomerID:  customerID,
+		Points:      req.Points,
+		Description: &reason,
+		CreatedBy:   &userID,
+	}
+	if err := s.repos.Loyalty.Adjust(entry); err != nil {
+		return nil, err
+	}
+
+	return s.GetAccount(customerID)
+}
+
+// onTransactionSettled settles the points of the transaction an event
+// changed the payments of. Settling is idempotent, so redelivered events
+// post nothing.
+func (s *loyaltyService) onTransactionSettled(ctx context.Context, event events.Event) error {
+	var payload struct {
+		TransactionID int64 `json:"transaction_id"`
+	}
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	_, err := s.repos.Loyalty.SyncTransaction(payload.TransactionID)
+	return err
+}
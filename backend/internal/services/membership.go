@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/events"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+
+	"github.com/shopspring/decimal"
+)
+
+// membershipWindowMonths is how far back a customer's spend counts towards
+// their tier
+const membershipWindowMonths = 12
+
+// membershipDiscountTypes are the transaction types a tier discount applies
+// to. Vehicles are priced one by one and get none.
+var membershipDiscountTypes = map[string]bool{
+	"service":        true,
+	"sparepart_sale": true,
+}
+
+// MembershipService interface defines membership tier operations and the
+// evaluation of customers against them
+type MembershipService interface {
+	ListTiers() ([]models.MembershipTier, error)
+	CreateTier(req *models.MembershipTierRequest, userID int64) (*models.MembershipTier, error)
+	UpdateTier(id int64, req *models.MembershipTierRequest) (*models.MembershipTier, error)
+	DeleteTier(id int64) error
+	GetCustomerMembership(customerID int64) (*models.CustomerMembership, error)
+	ListHistory(customerID int64, page, limit int) ([]models.CustomerTierChange, *models.PaginationMeta, error)
+	EvaluateCustomer(customerID int64) (*models.CustomerMembership, error)
+	EvaluateAll() (*models.MembershipEvaluationResult, error)
+}
+
+type membershipService struct {
+	repos *repositories.Repositories
+}
+
+// NewMembershipService creates the membership service and subscribes it to
+// payments, which can lift a customer into a higher tier straight away
+func NewMembershipService(repos *repositories.Repositories, eventBus EventService) MembershipService {
+	s := &membershipService{repos: repos}
+
+	eventBus.Subscribe("membership.upgrade", s.onPaymentReceived, events.PaymentReceived)
+
+	return s
+}
+
+func (s *membershipService) ListTiers() ([]models.MembershipTier, error) {
+	return s.repos.Membership.ListTiers()
+}
+
+// CreateTier adds a tier. Customers reach it at the next evaluation or
+// payment.
+func (s *membershipService) CreateTier(req *models.MembershipTierRequest, userID int64) (*models.MembershipTier, error) {
+	tier := &models.MembershipTier{IsActive: true, CreatedBy: &userID}
+	if err := s.applyTierRequest(tier, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Membership.CreateTier(tier); err != nil {
+		return nil, err
+	}
+
+	return tier, nil
+}
+
+// UpdateTier changes a tier. New benefits apply to invoices from now on;
+// members move to match a new min_spend at the next evaluation.
+func (s *membershipService) UpdateTier(id int64, req *models.MembershipTierRequest) (*models.MembershipTier, error) {
+	tier, err := s.repos.Membership.GetTier(id)
+	if err != nil {
+		return nil, errors.New("membership tier not found")
+	}
+	if err := s.applyTierRequest(tier, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Membership.UpdateTier(tier); err != nil {
+		return nil, err
+	}
+
+	return tier, nil
+}
+
+func (s *membershipService) DeleteTier(id int64) error {
+	return s.repos.Membership.DeleteTier(id)
+}
+
+// applyTierRequest validates a tier request onto a tier. Tier names are
+// unique.
+func (s *membershipService) applyTierRequest(tier *models.MembershipTier, req *models.MembershipTierRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if req.MinSpend.IsNegative() {
+		return errors.New("min_spend cannot be negative")
+	}
+	if req.DiscountPercent.IsNegative() || req.DiscountPercent.GreaterThan(decimal.NewFromInt(100)) {
+		return errors.New("discount_percent must be between 0 and 100")
+	}
+	multiplier := req.PointMultiplier
+	if multiplier.IsZero() {
+		multiplier = decimal.NewFromInt(1)
+	}
+	if multiplier.LessThan(decimal.NewFromInt(1)) {
+		return errors.New("point_multiplier cannot be less than 1")
+	}
+
+	existing, err := s.repos.Membership.GetTierByName(name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.TierID != tier.TierID {
+		return fmt.Errorf("membership tier %s already exists", name)
+	}
+
+	tier.Name = name
+	tier.MinSpend = req.MinSpend.Round(2)
+	tier.DiscountPercent = req.DiscountPercent.Round(2)
+	tier.PointMultiplier = multiplier.Round(2)
+	tier.PriorityQueue = req.PriorityQueue
+	if req.IsActive != nil {
+		tier.IsActive = *req.IsActive
+	}
+
+	return nil
+}
+
+// GetCustomerMembership returns a customer's tier and benefits, their
+// rolling spend as of now and what they still need to spend for the next
+// tier up
+func (s *membershipService) GetCustomerMembership(customerID int64) (*models.CustomerMembership, error) {
+	membership, err := s.repos.Membership.GetCustomerMembership(customerID, membershipSince(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	tiers, err := s.repos.Membership.ListTiers()
+	if err != nil {
+		return nil, err
+	}
+
+	// The next tier is the lowest active one above the customer's tier, or
+	// the lowest active one when they are on none
+	var current *models.MembershipTier
+	for i := range tiers {
+		if membership.TierID != nil && tiers[i].TierID == *membership.TierID && tiers[i].IsActive {
+			current = &tiers[i]
+		}
+	}
+	for i := range tiers {
+		tier := &tiers[i]
+		if !tier.IsActive || tier == current || (current != nil && !tier.MinSpend.GreaterThan(current.MinSpend)) {
+			continue
+		}
+		membership.NextTier = tier
+		membership.SpendToNextTier = decimal.Max(tier.MinSpend.Sub(membership.RollingSpend), decimal.Zero)
+		break
+	}
+
+	return membership, nil
+}
+
+func (s *membershipService) ListHistory(customerID int64, page, limit int) ([]models.CustomerTierChange, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	history, total, err := s.repos.Membership.ListHistory(customerID, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return history, paginationMeta(page, limit, total), nil
+}
+
+// EvaluateCustomer re-evaluates one customer now, moving them up or down
+func (s *membershipService) EvaluateCustomer(customerID int64) (*models.CustomerMembership, error) {
+	if _, err := s.repos.Membership.EvaluateCustomer(customerID, membershipSince(time.Now()), true); err != nil {
+		return nil, err
+	}
+
+	return s.GetCustomerMembership(customerID)
+}
+
+// EvaluateAll re-evaluates every customer, as the nightly job does
+func (s *membershipService) EvaluateAll() (*models.MembershipEvaluationResult, error) {
+	return s.repos.Membership.EvaluateAll(membershipSince(time.Now()))
+}
+
+// onPaymentReceived moves the paying customer up as soon as their spend
+// reaches a higher tier. Downgrades wait for the nightly evaluation.
+func (s *membershipService) onPaymentReceived(ctx context.Context, event events.Event) error {
+	var payload events.PaymentReceivedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+	if payload.CustomerID == nil {
+		return nil
+	}
+
+	_, err := s.repos.Membership.EvaluateCustomer(*payload.CustomerID, membershipSince(time.Now()), false)
+	return err
+}
+
+// membershipSince returns the start of the rolling spend window ending at t
+func membershipSince(t time.Time) time.Time {
+	return t.AddDate(0, -membershipWindowMonths, 0)
+}
+
+// membershipDiscount returns the tier of an invoice's customer and the
+// discount it gives on what is left of the lines after promotions and the
+// entered discount. Customers without a tier, and invoice types tiers give
+// no discount on, get none.
+func membershipDiscount(repos *repositories.Repositories, customerID *int64, transactionType string, lines []taxLine, discount float64) (*int64, float64, error) {
+	if customerID == nil || !membershipDiscountTypes[transactionType] {
+		return nil, 0, nil
+	}
+
+	tier, err := repos.Membership.GetCustomerTier(*customerID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if tier == nil {
+		return nil, 0, nil
+	}
+
+	base := decimal.NewFromFloat(-discount)
+	for _, line := range lines {
+		base = base.Add(decimal.NewFromFloat(line.Amount - line.Discount))
+	}
+	if !base.IsPositive() {
+		return &tier.TierID, 0, nil
+	}
+
+	amount := base.Mul(tier.DiscountPercent).Div(decimal.NewFromInt(100)).Round(2)
+	return &tier.TierID, amount.InexactFloat64(), nil
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/notifications"
+	"flutter-bengkel/internal/repositories"
+)
+
+const (
+	// notificationBatchSize caps how many messages one dispatch pass sends
+	notificationBatchSize = 50
+	// notificationSendTimeout bounds a single delivery attempt
+	notificationSendTimeout = 30 * time.Second
+	// notificationStaleAfter releases messages stuck in sending by a crashed process
+	notificationStaleAfter = 5 * time.Minute
+	// notificationMaxBackoff caps the delay between retries
+	notificationMaxBackoff = 24 * time.Hour
+)
+
+// NotificationService interface defines templated customer and staff messaging
+type NotificationService interface {
+	NotifyCustomer(customerID int64, outletID *int64, template string, data map[string]interface{}, referenceType string, referenceID int64) error
+	NotifyUser(userID int64, outletID *int64, template string, data map[string]interface{}, referenceType string, referenceID int64) error
+	NotifyReminder(reminder *models.VehicleReminder) error
+
+	List(page, limit int, filter *models.NotificationFilter) ([]models.Notification, *models.PaginationMeta, error)
+	Retry(id int64) (*models.Notification, error)
+	Inbox(userID int64, unreadOnly bool, page, limit int) ([]models.Notification, *models.PaginationMeta, error)
+	MarkRead(id int64, userID int64) error
+
+	DispatchDue() (int, error)
+	RunDispatcher(stop <-chan struct{})
+}
+
+type notificationService struct {
+	repos     *repositories.Repositories
+	cfg       *config.Config
+	renderer  *notifications.Renderer
+	transport notifications.Transport
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(repos *repositories.Repositories, cfg *config.Config, renderer *notifications.Renderer, transport notifications.Transport) NotificationService {
+	return &notificationService{
+		repos:     repos,
+		cfg:       cfg,
+		renderer:  renderer,
+		transport: transport,
+	}
+}
+
+// NewNotificationTransport builds the configured development transport. In-app
+// messages are always delivered by storing them.
+func NewNotificationTransport(cfg *config.Config) notifications.Transport {
+	var fallback notifications.Transport = notifications.NewLogTransport()
+	if cfg.Notification.Transport == "file" {
+		fallback = notifications.NewFileTransport(cfg.Notification.FilePath)
+	}
+
+	router := notifications.NewRouter(fallback)
+	router.Register(notifications.ChannelInApp, notifications.NewInAppTransport())
+
+	return router
+}
+
+// NotifyCustomer queues a message over the customer's preferred channel and language
+func (s *notificationService) NotifyCustomer(customerID int64, outletID *int64, template string, data map[string]interface{}, referenceType string, referenceID int64) error {
+	contact, err := s.repos.Notification.GetCustomerContact(customerID)
+	if err != nil {
+		return err
+	}
+
+	channel := notifications.Channel(contact.Channel)
+	var recipient string
+	switch channel {
+	case notifications.ChannelWhatsApp, notifications.ChannelSMS:
+		recipient = contact.Phone
+	case notifications.ChannelEmail:
+		if contact.Email != nil {
+			recipient = *contact.Email
+		}
+	default:
+		// Customer opted out
+		return nil
+	}
+	if recipient == "" {
+		return fmt.Errorf("customer %d has no %s contact", customerID, channel)
+	}
+
+	if _, ok := data["CustomerName"]; !ok {
+		data["CustomerName"] = contact.Name
+	}
+
+	notification := &models.Notification{
+		Channel:    string(channel),
+		Recipient:  recipient,
+		CustomerID: &customerID,
+		OutletID:   outletID,
+		Language:   contact.Language,
+	}
+
+	return s.enqueue(notification, template, data, referenceType, referenceID)
+}
+
+// NotifyUser queues an in-app message for a staff member
+func (s *notificationService) NotifyUser(userID int64, outletID *int64, template string, data map[string]interface{}, referenceType string, referenceID int64) error {
+	notification := &models.Notification{
+		Channel:   string(notifications.ChannelInApp),
+		Recipient: strconv.FormatInt(userID, 10),
+		UserID:    &userID,
+		OutletID:  outletID,
+		Language:  s.cfg.Notification.DefaultLanguage,
+	}
+
+	return s.enqueue(notification, template, data, referenceType, referenceID)
+}
+
+// NotifyReminder sends a vehicle reminder to its owner
+func (s *notificationService) NotifyReminder(reminder *models.VehicleReminder) error {
+	templates := map[string]string{
+		models.ReminderTypeService:      notifications.TemplateReminderService,
+		models.ReminderTypeInsurance:    notifications.TemplateReminderInsurance,
+		models.ReminderTypeRegistration: notifications.TemplateReminderRegistration,
+	}
+
+	template, ok := templates[reminder.ReminderType]
+	if !ok {
+		return fmt.Errorf("no template for %s reminders", reminder.ReminderType)
+	}
+
+	data := map[string]interface{}{
+		"CustomerName":  reminder.CustomerName,
+		"VehicleNumber": reminder.VehicleNumber,
+		"VehicleBrand":  reminder.VehicleBrand,
+		"VehicleModel":  reminder.VehicleModel,
+		"DueDate":       reminder.DueDate,
+		"DueMileage":    reminder.DueMileage,
+	}
+
+	return s.NotifyCustomer(reminder.CustomerID, reminder.OutletID, template, data, "vehicle_reminder", reminder.ReminderID)
+}
+
+func (s *notificationService) enqueue(notification *models.Notification, template string, data map[string]interface{}, referenceType string, referenceID int64) error {
+	subject, body, err := s.renderer.Render(template, notification.Language, data)
+	if err != nil {
+		return err
+	}
+
+	notification.Template = template
+	notification.Subject = subject
+	notification.Body = body
+	notification.Status = "pending"
+	notification.MaxAttempts = s.cfg.Notification.MaxAttempts
+	if referenceType != "" {
+		notification.ReferenceType = &referenceType
+		notification.ReferenceID = &referenceID
+	}
+
+	return s.repos.Notification.Create(notification)
+}
+
+func (s *notificationService) List(page, limit int, filter *models.NotificationFilter) ([]models.Notification, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	items, total, err := s.repos.Notification.List(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return items, paginationMeta(page, limit, total), nil
+}
+
+func (s *notificationService) Retry(id int64) (*models.Notification, error) {
+	if err := s.repos.Notification.Requeue(id); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Notification.GetByID(id)
+}
+
+func (s *notificationService) Inbox(userID int64, unreadOnly bool, page, limit int) ([]models.Notification, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	items, total, err := s.repos.Notification.ListInbox(userID, unreadOnly, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return items, paginationMeta(page, limit, total), nil
+}
+
+func (s *notificationService) MarkRead(id int64, userID int64) error {
+	return s.repos.Notification.MarkRead(id, userID)
+}
+
+// DispatchDue sends every notification whose next attempt is due, retrying
+// failures with exponential backoff until max attempts is reached
+func (s *notificationService) DispatchDue() (int, error) {
+	due, err := s.repos.Notification.ClaimDue(notificationBatchSize, notificationStaleAfter)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, notification := range due {
+		ctx, cancel := context.WithTimeout(context.Background(), notificationSendTimeout)
+		err := s.transport.Send(ctx, notifications.Message{
+			ID:        notification.NotificationID,
+			Channel:   notifications.Channel(notification.Channel),
+			Recipient: notification.Recipient,
+			Subject:   notification.Subject,
+			Body:      notification.Body,
+		})
+		cancel()
+
+		if err == nil {
+			if err := s.repos.Notification.MarkSent(notification.NotificationID); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			sent++
+			continue
+		}
+
+		var nextAttemptAt *time.Time
+		if notification.Attempts < notification.MaxAttempts {
+			next := time.Now().Add(s.retryBackoff(notification.Attempts))
+			nextAttemptAt = &next
+		}
+		if err := s.repos.Notification.MarkFailed(notification.NotificationID, err.Error(), nextAttemptAt); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	return sent, nil
+}
+
+// retryBackoff doubles the configured base delay after every failed attempt
+func (s *notificationService) retryBackoff(attempts int) time.Duration {
+	backoff := time.Duration(s.cfg.Notification.RetryBaseSeconds) * time.Second
+	for i := 1; i < attempts && backoff < notificationMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > notificationMaxBackoff {
+		backoff = notificationMaxBackoff
+	}
+	return backoff
+}
+
+// RunDispatcher delivers due notifications on every configured interval until stop is closed
+func (s *notificationService) RunDispatcher(stop <-chan struct{}) {
+	interval := time.Duration(s.cfg.Notification.DispatchIntervalSeconds) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.DispatchDue(); err != nil {
+			log.Printf("Warning: notification dispatch failed: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// paginationMeta builds pagination metadata for a page of results
+func paginationMeta(page, limit int, total int64) *models.PaginationMeta {
+	totalPages := int(total) / limit
+	if int(total)%limit > 0 {
+		totalPages++
+	}
+
+	return &models.PaginationMeta{
+		CurrentPage: page,
+		PerPage:     limit,
+		Total:       total,
+		TotalPages:  totalPages,
+	}
+}
@@ -1,11 +1,21 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"log"
+	"strings"
 	"time"
 
+	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/events"
 	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/notifications"
+	"flutter-bengkel/internal/realtime"
 	"flutter-bengkel/internal/repositories"
+	"flutter-bengkel/internal/utils"
+
+	"github.com/shopspring/decimal"
 )
 
 // ServiceJob Service
@@ -16,19 +26,40 @@ type ServiceJobService interface {
 	UpdateStatus(id int64, status string, userID int64, notes string) error
 	Delete(id int64) error
 	List(page, limit int, outletID *int64, status string, search string) ([]models.ServiceJob, *models.PaginationMeta, error)
-	AddDetail(serviceJobID int64, detail *models.ServiceDetail) (*models.ServiceDetail, error)
+	AddDetail(serviceJobID int64, detail *models.ServiceDetail, userID int64) (*models.ServiceDetail, error)
 	GetDetails(serviceJobID int64) ([]models.ServiceDetail, error)
-	UpdateDetail(detailID int64, detail *models.ServiceDetail) error
+	UpdateDetail(detailID int64, detail *models.ServiceDetail, userID int64) error
 	DeleteDetail(detailID int64) error
 	CalculateTotal(serviceJobID int64) error
+	ApplyCoupon(id int64, code string, outletID *int64) (*models.ServiceJob, error)
+	RemoveCoupon(id int64, outletID *int64) (*models.ServiceJob, error)
+	AddPackage(id int64, req *models.AddServiceJobPackageRequest, outletID *int64, userID int64) (*models.ServiceJob, error)
+	RemovePackage(id, packageLineID int64, outletID *int64) (*models.ServiceJob, error)
 }
 
 type serviceJobService struct {
-	repos *repositories.Repositories
+	repos         *repositories.Repositories
+	queue         QueueService
+	reminders     ReminderService
+	notifications NotificationService
+	publisher     realtime.Publisher
+	eventBus      EventService
 }
 
-func NewServiceJobService(repos *repositories.Repositories) ServiceJobService {
-	return &serviceJobService{repos: repos}
+func NewServiceJobService(repos *repositories.Repositories, queue QueueService, reminders ReminderService, notifications NotificationService, publisher realtime.Publisher, eventBus EventService) ServiceJobService {
+	s := &serviceJobService{
+		repos:         repos,
+		queue:         queue,
+		reminders:     reminders,
+		notifications: notifications,
+		publisher:     publisher,
+		eventBus:      eventBus,
+	}
+
+	eventBus.Subscribe("service_job.service_schedule", s.onStatusChangedSchedule, events.ServiceJobStatusChanged)
+	eventBus.Subscribe("service_job.customer_notification", s.onStatusChangedNotify, events.ServiceJobStatusChanged)
+
+	return s
 }
 
 func (s *serviceJobService) Create(req *models.CreateServiceJobRequest, outletID int64, userID int64) (*models.ServiceJob, error) {
@@ -71,6 +102,12 @@ func (s *serviceJobService) Create(req *models.CreateServiceJobRequest, outletID
 		priority = "normal"
 	}
 
+	// Every job gets a customer tracking link up front
+	trackingToken, err := utils.GenerateRandomString(trackingTokenLength)
+	if err != nil {
+		return nil, errors.New("failed to generate tracking token")
+	}
+
 	serviceJob := &models.ServiceJob{
 		JobNumber:          jobNumber,
 		CustomerID:         req.CustomerID,
@@ -82,13 +119,41 @@ func (s *serviceJobService) Create(req *models.CreateServiceJobRequest, outletID
 		Status:             "pending",
 		ProblemDescription: req.ProblemDescription,
 		WarrantyPeriodDays: req.WarrantyPeriodDays,
+		Odometer:           req.Odometer,
 		Notes:              req.Notes,
+		TrackingToken:      &trackingToken,
 	}
 
 	if err := s.repos.ServiceJob.Create(serviceJob); err != nil {
 		return nil, err
 	}
 
+	// The odometer read at intake keeps the vehicle's mileage current
+	if serviceJob.Odometer != nil {
+		if err := s.repos.Reminder.RecordOdometer(serviceJob.VehicleID, *serviceJob.Odometer); err != nil {
+			log.Printf("Warning: failed to record odometer for vehicle %d: %v", serviceJob.VehicleID, err)
+		}
+	}
+
+	// Place the new job in the queue and refresh everyone's ETA
+	if err := s.queue.Recalculate(outletID); err != nil {
+		log.Printf("Warning: failed to recalculate queue for outlet %d: %v", outletID, err)
+	}
+
+	s.publisher.Publish(realtime.Event{
+		Type:       realtime.EventServiceJobCreated,
+		OutletID:   outletID,
+		Permission: "service_jobs.read",
+		Data: map[string]interface{}{
+			"job_id":       serviceJob.ID,
+			"job_number":   serviceJob.JobNumber,
+			"queue_number": serviceJob.QueueNumber,
+			"priority":     serviceJob.Priority,
+			"status":       serviceJob.Status,
+			"created_by":   userID,
+		},
+	})
+
 	return s.repos.ServiceJob.GetByID(serviceJob.ID)
 }
 
@@ -104,6 +169,18 @@ func (s *serviceJobService) GetByID(id int64) (*models.ServiceJob, error) {
 		serviceJob.Details = details
 	}
 
+	// Get tax summary
+	summary, err := s.repos.Tax.GetServiceJobSummary(id)
+	if err == nil {
+		serviceJob.TaxSummary = summary
+	}
+
+	// Get packages
+	packages, err := s.repos.ServicePackage.GetServiceJobPackages(id)
+	if err == nil {
+		serviceJob.Packages = packages
+	}
+
 	return serviceJob, nil
 }
 
@@ -129,18 +206,54 @@ func (s *serviceJobService) Update(id int64, req *models.UpdateServiceJobRequest
 		EstimatedCompletion: req.EstimatedCompletion,
 		ActualCompletion:    req.ActualCompletion,
 		WarrantyPeriodDays:  req.WarrantyPeriodDays,
+		Odometer:            req.Odometer,
 		Notes:               req.Notes,
 		// Keep existing totals - these should be calculated separately
-		TotalAmount:    existingServiceJob.TotalAmount,
-		DiscountAmount: existingServiceJob.DiscountAmount,
-		TaxAmount:      existingServiceJob.TaxAmount,
-		FinalAmount:    existingServiceJob.FinalAmount,
+		TotalAmount:        existingServiceJob.TotalAmount,
+		DiscountAmount:     existingServiceJob.DiscountAmount,
+		MembershipDiscount: existingServiceJob.MembershipDiscount,
+		TaxAmount:          existingServiceJob.TaxAmount,
+		FinalAmount:        existingServiceJob.FinalAmount,
 	}
 
 	if err := s.repos.ServiceJob.Update(id, serviceJob); err != nil {
 		return nil, err
 	}
 
+	if req.Odometer != nil {
+		if err := s.repos.Reminder.RecordOdometer(existingServiceJob.VehicleID, *req.Odometer); err != nil {
+			log.Printf("Warning: failed to record odometer for vehicle %d: %v", existingServiceJob.VehicleID, err)
+		}
+	}
+
+	// Priority or technician changes reshuffle the queue
+	if err := s.queue.Recalculate(existingServiceJob.OutletID); err != nil {
+		log.Printf("Warning: failed to recalculate queue for outlet %d: %v", existingServiceJob.OutletID, err)
+	}
+
+	if req.TechnicianID != nil && (existingServiceJob.TechnicianID == nil || *existingServiceJob.TechnicianID != *req.TechnicianID) {
+		data := map[string]interface{}{
+			"JobNumber":   existingServiceJob.JobNumber,
+			"QueueNumber": existingServiceJob.QueueNumber,
+		}
+		if err := s.notifications.NotifyUser(*req.TechnicianID, &existingServiceJob.OutletID,
+			notifications.TemplateTechnicianAssigned, data, "service_job", id); err != nil {
+			log.Printf("Warning: failed to notify technician %d: %v", *req.TechnicianID, err)
+		}
+
+		s.publisher.Publish(realtime.Event{
+			Type:       realtime.EventTechnicianAssigned,
+			OutletID:   existingServiceJob.OutletID,
+			Permission: "service_jobs.read",
+			Data: map[string]interface{}{
+				"job_id":                 id,
+				"job_number":             existingServiceJob.JobNumber,
+				"technician_id":          *req.TechnicianID,
+				"previous_technician_id": existingServiceJob.TechnicianID,
+			},
+		})
+	}
+
 	return s.repos.ServiceJob.GetByID(id)
 }
 
@@ -158,7 +271,92 @@ func (s *serviceJobService) UpdateStatus(id int64, status string, userID int64,
 		return errors.New("invalid status")
 	}
 
-	return s.repos.ServiceJob.UpdateStatus(id, status, userID, notes)
+	serviceJob, err := s.repos.ServiceJob.GetByID(id)
+	if err != nil {
+		return errors.New("service job not found")
+	}
+
+	if err := s.repos.ServiceJob.UpdateStatus(id, status, userID, notes); err != nil {
+		return err
+	}
+	s.eventBus.Wake()
+
+	s.publisher.Publish(realtime.Event{
+		Type:       realtime.EventServiceJobStatusChanged,
+		OutletID:   serviceJob.OutletID,
+		Permission: "service_jobs.read",
+		Data: map[string]interface{}{
+			"job_id":          id,
+			"job_number":      serviceJob.JobNumber,
+			"previous_status": serviceJob.Status,
+			"status":          status,
+			"changed_by":      userID,
+			"notes":           notes,
+		},
+	})
+
+	// Started, finished or cancelled jobs change the ETA of everything behind them
+	if err := s.queue.Recalculate(serviceJob.OutletID); err != nil {
+		log.Printf("Warning: failed to recalculate queue for outlet %d: %v", serviceJob.OutletID, err)
+	}
+
+	return nil
+}
+
+// onStatusChangedSchedule moves the vehicle's service schedule forward when
+// a job is completed
+func (s *serviceJobService) onStatusChangedSchedule(ctx context.Context, event events.Event) error {
+	var payload events.ServiceJobStatusChangedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	if payload.Status != "completed" || payload.PreviousStatus == "completed" {
+		return nil
+	}
+	return s.reminders.RecordServiceCompletion(payload.JobID)
+}
+
+// onStatusChangedNotify tells the customer their job moved to a new status
+func (s *serviceJobService) onStatusChangedNotify(ctx context.Context, event events.Event) error {
+	var payload events.ServiceJobStatusChangedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	if payload.Status == "pending" {
+		return nil
+	}
+	return s.notifyStatusChanged(payload.JobID, payload.Status)
+}
+
+// notifyStatusChanged queues the job status message for the customer
+func (s *serviceJobService) notifyStatusChanged(id int64, status string) error {
+	serviceJob, err := s.repos.ServiceJob.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"JobNumber":     serviceJob.JobNumber,
+		"Status":        status,
+		"VehicleNumber": "",
+		"OutletName":    "",
+		"FinalAmount":   serviceJob.FinalAmount,
+		"TrackingPath":  "",
+	}
+	if serviceJob.Vehicle != nil {
+		data["VehicleNumber"] = serviceJob.Vehicle.VehicleNumber
+	}
+	if serviceJob.Outlet != nil {
+		data["OutletName"] = serviceJob.Outlet.Name
+	}
+	if serviceJob.TrackingToken != nil {
+		data["TrackingPath"] = trackingPath(*serviceJob.TrackingToken)
+	}
+
+	return s.notifications.NotifyCustomer(serviceJob.CustomerID, &serviceJob.OutletID,
+		notifications.TemplateJobStatusChanged, data, "service_job", id)
 }
 
 func (s *serviceJobService) Delete(id int64) error {
@@ -187,41 +385,57 @@ func (s *serviceJobService) List(page, limit int, outletID *int64, status string
 	return serviceJobs, meta, nil
 }
 
-func (s *serviceJobService) AddDetail(serviceJobID int64, detail *models.ServiceDetail) (*models.ServiceDetail, error) {
+func (s *serviceJobService) AddDetail(serviceJobID int64, detail *models.ServiceDetail, userID int64) (*models.ServiceDetail, error) {
 	// Validate service job exists
-	if _, err := s.repos.ServiceJob.GetByID(serviceJobID); err != nil {
+	serviceJob, err := s.repos.ServiceJob.GetByID(serviceJobID)
+	if err != nil {
 		return nil, errors.New("service job not found")
 	}
 
-	// Validate product or service exists
-	if detail.ProductID != nil {
-		if _, err := s.repos.Product.GetByID(*detail.ProductID); err != nil {
-			return nil, errors.New("product not found")
-		}
-	}
-	if detail.ServiceID != nil {
-		if _, err := s.repos.Service.GetByID(*detail.ServiceID); err != nil {
-			return nil, errors.New("service not found")
-		}
-	}
-
 	// Either product or service must be specified
 	if detail.ProductID == nil && detail.ServiceID == nil {
 		return nil, errors.New("either product or service must be specified")
 	}
 
+	// Validate product or service exists
+	listPrice, priceListID, err := s.listPrice(serviceJob, detail.ProductID, detail.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A line entered without a price takes the customer's price
+	if detail.UnitPrice == 0 {
+		detail.UnitPrice = listPrice
+	}
+	detail.PriceListID = nil
+	if detail.UnitPrice == listPrice {
+		detail.PriceListID = priceListID
+	}
+
 	detail.ServiceJobID = serviceJobID
 	detail.TotalPrice = detail.Quantity * detail.UnitPrice
+	detail.ApprovalStatus = "approved"
 
 	if err := s.repos.ServiceJob.AddDetail(detail); err != nil {
 		return nil, err
 	}
 
+	if err := s.holdForApproval(serviceJob.OutletID, detail, listPrice, userID); err != nil {
+		return nil, err
+	}
+
 	// Recalculate totals
 	if err := s.CalculateTotal(serviceJobID); err != nil {
 		// Log error but don't fail the operation
 	}
 
+	// Added services extend the job's estimated duration
+	if detail.ServiceID != nil {
+		if err := s.queue.RecalculateForJob(serviceJobID); err != nil {
+			log.Printf("Warning: failed to recalculate queue for service job %d: %v", serviceJobID, err)
+		}
+	}
+
 	return detail, nil
 }
 
@@ -229,24 +443,104 @@ func (s *serviceJobService) GetDetails(serviceJobID int64) ([]models.ServiceDeta
 	return s.repos.ServiceJob.GetDetails(serviceJobID)
 }
 
-func (s *serviceJobService) UpdateDetail(detailID int64, detail *models.ServiceDetail) error {
+func (s *serviceJobService) UpdateDetail(detailID int64, detail *models.ServiceDetail, userID int64) error {
+	existing, err := s.repos.ServiceJob.GetDetailByID(detailID)
+	if err != nil {
+		return err
+	}
+	if existing.PackageLineID != nil {
+		return errors.New("line is priced by its package; remove the package instead")
+	}
+	serviceJob, err := s.repos.ServiceJob.GetByID(existing.ServiceJobID)
+	if err != nil {
+		return errors.New("service job not found")
+	}
+	listPrice, priceListID, err := s.listPrice(serviceJob, existing.ProductID, existing.ServiceID)
+	if err != nil {
+		return err
+	}
+
 	detail.TotalPrice = detail.Quantity * detail.UnitPrice
+	detail.PriceListID = nil
+	if detail.UnitPrice == listPrice {
+		detail.PriceListID = priceListID
+	}
 
 	if err := s.repos.ServiceJob.UpdateDetail(detailID, detail); err != nil {
 		return err
 	}
 
-	// Get the service job ID and recalculate totals
-	// This would require getting the detail first to get the service job ID
-	// For now, we'll skip the automatic recalculation
+	// The new price replaces any request made for the old one
+	detail.ID = detailID
+	detail.ServiceJobID = existing.ServiceJobID
+	detail.ProductID = existing.ProductID
+	detail.ServiceID = existing.ServiceID
+	detail.ApprovalStatus = existing.ApprovalStatus
+	if err := s.holdForApproval(serviceJob.OutletID, detail, listPrice, userID); err != nil {
+		return err
+	}
 
-	return nil
+	return s.CalculateTotal(existing.ServiceJobID)
 }
 
 func (s *serviceJobService) DeleteDetail(detailID int64) error {
+	existing, err := s.repos.ServiceJob.GetDetailByID(detailID)
+	if err != nil {
+		return err
+	}
+	if existing.PackageLineID != nil {
+		return errors.New("line is part of a package; remove the package instead")
+	}
+
+	// Withdraw a pending request for the line first
+	if err := s.repos.Approval.HoldServiceDetail(detailID, nil); err != nil {
+		return err
+	}
+
 	return s.repos.ServiceJob.DeleteDetail(detailID)
 }
 
+// listPrice returns the price a line's product or service sells at for the
+// job's customer at its outlet, and the price list it comes from
+func (s *serviceJobService) listPrice(serviceJob *models.ServiceJob, productID, serviceID *int64) (float64, *int64, error) {
+	resolved, err := resolvePrice(s.repos, &serviceJob.CustomerID, serviceJob.OutletID, productID, serviceID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resolved.Price.InexactFloat64(), resolved.PriceListID, nil
+}
+
+// holdForApproval holds a line priced below its list price by more than
+// the user's threshold until an approver decides, and releases a line that
+// no longer needs approval
+func (s *serviceJobService) holdForApproval(outletID int64, detail *models.ServiceDetail, listPrice float64, userID int64) error {
+	limits, err := approvalLimits(s.repos, userID)
+	if err != nil {
+		return err
+	}
+
+	approval := priceOverrideApproval(limits, listPrice, detail.UnitPrice)
+	if approval == nil {
+		if detail.ApprovalStatus == "approved" {
+			return nil
+		}
+		detail.ApprovalStatus = "approved"
+		return s.repos.Approval.HoldServiceDetail(detail.ID, nil)
+	}
+
+	approval.OutletID = outletID
+	approval.ServiceJobID = &detail.ServiceJobID
+	approval.ProductID = detail.ProductID
+	approval.ServiceID = detail.ServiceID
+	approval.RequestedBy = userID
+	if err := s.repos.Approval.HoldServiceDetail(detail.ID, approval); err != nil {
+		return err
+	}
+	detail.ApprovalStatus = "pending"
+
+	return nil
+}
+
 func (s *serviceJobService) CalculateTotal(serviceJobID int64) error {
 	// Get all details
 	details, err := s.repos.ServiceJob.GetDetails(serviceJobID)
@@ -254,41 +548,237 @@ func (s *serviceJobService) CalculateTotal(serviceJobID int64) error {
 		return err
 	}
 
-	// Calculate total
+	// Get current service job to preserve discount
+	serviceJob, err := s.repos.ServiceJob.GetByID(serviceJobID)
+	if err != nil {
+		return err
+	}
+
+	// Apply promotions, then tax the lines with the outlet's pricing; the
+	// discount is entered like the prices
 	var totalAmount float64
-	for _, detail := range details {
+	lines := make([]taxLine, len(details))
+	for i, detail := range details {
 		totalAmount += detail.TotalPrice
+		lines[i] = taxLine{
+			ProductID: detail.ProductID,
+			ServiceID: detail.ServiceID,
+			Amount:    detail.TotalPrice,
+		}
 	}
 
-	// Get current service job to preserve discount and tax
-	serviceJob, err := s.repos.ServiceJob.GetByID(serviceJobID)
+	// A coupon that stopped applying, say after a line was removed, is left
+	// on the job but gives no discount until it applies again
+	var couponCode string
+	if serviceJob.CouponCode != nil {
+		couponCode = *serviceJob.CouponCode
+	}
+	promoted, _, err := applyPromotions(s.repos, serviceJob.OutletID, &serviceJob.CustomerID, couponCode, lines)
+	if err != nil && couponCode != "" {
+		log.Printf("Warning: coupon not applied to service job %d: %v", serviceJobID, err)
+		promoted, _, err = applyPromotions(s.repos, serviceJob.OutletID, &serviceJob.CustomerID, "", lines)
+	}
 	if err != nil {
 		return err
 	}
 
-	// Update totals
+	// The customer's tier takes its discount off what is left after
+	// promotions and the discount
+	_, memberDiscount, err := membershipDiscount(s.repos, &serviceJob.CustomerID, "service", lines, serviceJob.DiscountAmount)
+	if err != nil {
+		return err
+	}
+
+	taxed, err := computeTax(s.repos, serviceJob.OutletID, lines, serviceJob.DiscountAmount+memberDiscount)
+	if err != nil {
+		return err
+	}
+
+	for i := range details {
+		detail := &details[i]
+		line := taxed.Lines[i]
+		detail.TaxCodeID = taxed.CodeIDs[i]
+		detail.TaxRate = taxed.Rates[i].InexactFloat64()
+		detail.NetAmount = line.Net.InexactFloat64()
+		detail.DiscountAmount = line.Discount.InexactFloat64()
+		detail.TaxAmount = line.Tax.InexactFloat64()
+		detail.PromotionID = promoted.PromotionIDs[i]
+		detail.PromotionDiscount = lines[i].Discount
+		if err := s.repos.Tax.UpdateServiceDetailTotals(detail); err != nil {
+			return err
+		}
+	}
+
+	// Update totals. The subtotal and discount stay as entered, so at
+	// tax-inclusive outlets the tax is part of them.
 	serviceJob.TotalAmount = totalAmount
-	serviceJob.FinalAmount = totalAmount - serviceJob.DiscountAmount + serviceJob.TaxAmount
+	serviceJob.MembershipDiscount = memberDiscount
+	serviceJob.TaxAmount = taxed.Tax.InexactFloat64()
+	serviceJob.FinalAmount = taxed.Total.InexactFloat64()
 
 	return s.repos.ServiceJob.Update(serviceJobID, serviceJob)
 }
 
+// ApplyCoupon puts a coupon on a service job and reprices it. The coupon has
+// to give a discount on the job's current lines; it is only used up once
+// the job is invoiced.
+func (s *serviceJobService) ApplyCoupon(id int64, code string, outletID *int64) (*models.ServiceJob, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil, errors.New("coupon_code is required")
+	}
+	if err := s.checkOutlet(id, outletID); err != nil {
+		return nil, err
+	}
+
+	serviceJob, err := s.repos.ServiceJob.GetByID(id)
+	if err != nil {
+		return nil, errors.New("service job not found")
+	}
+	details, err := s.repos.ServiceJob.GetDetails(id)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]taxLine, len(details))
+	for i, detail := range details {
+		lines[i] = taxLine{
+			ProductID: detail.ProductID,
+			ServiceID: detail.ServiceID,
+			Amount:    detail.TotalPrice,
+		}
+	}
+	if _, _, err := applyPromotions(s.repos, serviceJob.OutletID, &serviceJob.CustomerID, code, lines); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Promotion.SetServiceJobCoupon(id, &code); err != nil {
+		return nil, err
+	}
+	if err := s.CalculateTotal(id); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(id)
+}
+
+// RemoveCoupon takes the coupon off a service job and reprices it
+func (s *serviceJobService) RemoveCoupon(id int64, outletID *int64) (*models.ServiceJob, error) {
+	if err := s.checkOutlet(id, outletID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Promotion.SetServiceJobCoupon(id, nil); err != nil {
+		return nil, err
+	}
+	if err := s.CalculateTotal(id); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(id)
+}
+
+// AddPackage puts a package on a service job as its component lines, each
+// priced at its share of the package, and reprices the job
+func (s *serviceJobService) AddPackage(id int64, req *models.AddServiceJobPackageRequest, outletID *int64, userID int64) (*models.ServiceJob, error) {
+	if err := s.checkOutlet(id, outletID); err != nil {
+		return nil, err
+	}
+	serviceJob, err := s.repos.ServiceJob.GetByID(id)
+	if err != nil {
+		return nil, errors.New("service job not found")
+	}
+
+	quantity := req.Quantity
+	if quantity.IsZero() {
+		quantity = decimal.NewFromInt(1)
+	}
+	pkg, lines, err := expandPackage(s.repos, req.PackageID, serviceJob.OutletID, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg.DocumentID = id
+	details := make([]models.ServiceDetail, len(lines))
+	for i, line := range lines {
+		listPrice := line.ListPrice
+		details[i] = models.ServiceDetail{
+			ProductID:  line.ProductID,
+			ServiceID:  line.ServiceID,
+			Quantity:   line.Quantity,
+			UnitPrice:  line.UnitPrice,
+			TotalPrice: line.TotalPrice,
+			Notes:      pkg.PackageName,
+			ListPrice:  &listPrice,
+		}
+	}
+	if err := s.repos.ServicePackage.AddToServiceJob(pkg, details, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.CalculateTotal(id); err != nil {
+		return nil, err
+	}
+	if err := s.queue.RecalculateForJob(id); err != nil {
+		log.Printf("Warning: failed to recalculate queue for service job %d: %v", id, err)
+	}
+
+	return s.GetByID(id)
+}
+
+// RemovePackage takes a package and its component lines off a service job
+// and reprices it
+func (s *serviceJobService) RemovePackage(id, packageLineID int64, outletID *int64) (*models.ServiceJob, error) {
+	if err := s.checkOutlet(id, outletID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.ServicePackage.RemoveFromServiceJob(id, packageLineID); err != nil {
+		return nil, err
+	}
+
+	if err := s.CalculateTotal(id); err != nil {
+		return nil, err
+	}
+	if err := s.queue.RecalculateForJob(id); err != nil {
+		log.Printf("Warning: failed to recalculate queue for service job %d: %v", id, err)
+	}
+
+	return s.GetByID(id)
+}
+
+// checkOutlet ensures outlet users only change their own outlet's jobs
+func (s *serviceJobService) checkOutlet(id int64, outletID *int64) error {
+	jobOutletID, err := s.repos.Queue.GetJobOutletID(id)
+	if err != nil || (outletID != nil && jobOutletID != *outletID) {
+		return errors.New("service job not found")
+	}
+
+	return nil
+}
+
 // Transaction Service
 type TransactionService interface {
 	Create(req *models.CreateTransactionRequest, outletID int64, userID int64) (*models.Transaction, error)
 	GetByID(id int64) (*models.Transaction, error)
 	Update(id int64, req *models.Transaction) (*models.Transaction, error)
-	Delete(id int64) error
+	Void(id int64, req *models.VoidTransactionRequest, userID int64) (*models.TransactionVoid, error)
+	GetExceptionReport(filter *models.ExceptionFilter) (*models.ExceptionReport, error)
 	List(page, limit int, outletID *int64, transactionType string, search string) ([]models.Transaction, *models.PaginationMeta, error)
 	UpdatePaymentStatus(id int64, status string) error
 }
 
 type transactionService struct {
-	repos *repositories.Repositories
+	repos         *repositories.Repositories
+	eventBus      EventService
+	shiftRequired bool
 }
 
-func NewTransactionService(repos *repositories.Repositories) TransactionService {
-	return &transactionService{repos: repos}
+func NewTransactionService(repos *repositories.Repositories, cfg *config.Config, eventBus EventService) TransactionService {
+	return &transactionService{
+		repos:         repos,
+		eventBus:      eventBus,
+		shiftRequired: cfg.Shift.RequiredForPayments,
+	}
 }
 
 func (s *transactionService) Create(req *models.CreateTransactionRequest, outletID int64, userID int64) (*models.Transaction, error) {
@@ -312,64 +802,176 @@ func (s *transactionService) Create(req *models.CreateTransactionRequest, outlet
 		return nil, err
 	}
 
-	// Calculate subtotal from details
-	var subtotalAmount float64
-	for _, detail := range req.Details {
-		// Validate product or service
-		if detail.ProductID != nil {
-			if _, err := s.repos.Product.GetByID(*detail.ProductID); err != nil {
-				return nil, errors.New("product not found")
-			}
+	// Prices and discounts beyond the user's threshold hold the transaction
+	// for approval. Prices already approved on the service job stand.
+	limits, err := approvalLimits(s.repos, userID)
+	if err != nil {
+		return nil, err
+	}
+	var approvals []models.ApprovalRequest
+
+	// A service job's invoice sells the job's lines and packages, followed
+	// by anything else requested
+	var jobDetails []models.TransactionDetail
+	var jobPackages []models.DocumentPackage
+	if req.ServiceJobID != nil {
+		pending, err := s.repos.Approval.CountPendingForServiceJob(*req.ServiceJobID)
+		if err != nil {
+			return nil, err
+		}
+		if pending > 0 {
+			return nil, errors.New("service job has prices awaiting approval")
+		}
+		if jobDetails, jobPackages, err = serviceJobInvoiceDetails(s.repos, *req.ServiceJobID, req.Details); err != nil {
+			return nil, err
 		}
-		if detail.ServiceID != nil {
-			if _, err := s.repos.Service.GetByID(*detail.ServiceID); err != nil {
-				return nil, errors.New("service not found")
+	}
+
+	// Packages expand into their component lines, priced by the package
+	details, packages, err := expandTransactionDetails(s.repos, outletID, req.Details)
+	if err != nil {
+		return nil, err
+	}
+	for i := range details {
+		if details[i].PackageIndex != nil {
+			index := *details[i].PackageIndex + len(jobPackages)
+			details[i].PackageIndex = &index
+		}
+	}
+	jobLines := len(jobDetails)
+	details = append(jobDetails, details...)
+	packages = append(jobPackages, packages...)
+	if len(details) == 0 {
+		return nil, errors.New("transaction has no lines")
+	}
+
+	// Validate products and services, price them for the customer, then tax
+	// the lines
+	lines := make([]taxLine, len(details))
+	for i := range details {
+		detail := &details[i]
+
+		// Service job lines keep the prices agreed on the job, and package
+		// lines were checked and priced with their package
+		priced := i < jobLines || detail.PackageIndex != nil
+		var listPrice float64
+		if !priced && (detail.ProductID != nil || detail.ServiceID != nil) {
+			resolved, err := resolvePrice(s.repos, req.CustomerID, outletID, detail.ProductID, detail.ServiceID)
+			if err != nil {
+				return nil, err
+			}
+			listPrice = resolved.Price.InexactFloat64()
+
+			// A line entered without a price takes the customer's price
+			if detail.UnitPrice == 0 {
+				detail.UnitPrice = listPrice
+				detail.TotalPrice = detail.Quantity * detail.UnitPrice
 			}
+			if detail.UnitPrice == listPrice {
+				detail.PriceListID = resolved.PriceListID
+			}
+		}
+
+		lines[i] = taxLine{
+			ProductID: detail.ProductID,
+			ServiceID: detail.ServiceID,
+			TaxCodeID: detail.TaxCodeID,
+			Amount:    detail.TotalPrice,
+		}
+
+		if priced {
+			continue
 		}
 
-		totalPrice := detail.Quantity * detail.UnitPrice
-		subtotalAmount += totalPrice
+		if approval := priceOverrideApproval(limits, listPrice, detail.UnitPrice); approval != nil {
+			index := i
+			approval.DetailIndex = &index
+			approval.ProductID = detail.ProductID
+			approval.ServiceID = detail.ServiceID
+			approvals = append(approvals, *approval)
+		}
 	}
 
-	// Calculate total amount
-	totalAmount := subtotalAmount - req.DiscountAmount + req.TaxAmount
+	// A service job's coupon carries over to its invoice
+	couponCode := req.CouponCode
+	if couponCode == "" && req.ServiceJobID != nil {
+		code, err := s.repos.Promotion.GetServiceJobCoupon(*req.ServiceJobID)
+		if err != nil {
+			return nil, err
+		}
+		if code != nil {
+			couponCode = *code
+		}
+	}
+	promoted, redemptions, err := applyPromotions(s.repos, outletID, req.CustomerID, couponCode, lines)
+	if err != nil {
+		return nil, err
+	}
 
-	transaction := &models.Transaction{
-		TransactionNumber: transactionNumber,
-		TransactionType:   req.TransactionType,
-		CustomerID:        req.CustomerID,
-		OutletID:          outletID,
-		UserID:            userID,
-		ServiceJobID:      req.ServiceJobID,
-		SubtotalAmount:    subtotalAmount,
-		DiscountAmount:    req.DiscountAmount,
-		TaxAmount:         req.TaxAmount,
-		TotalAmount:       totalAmount,
-		PaymentStatus:     "pending",
-		Notes:             req.Notes,
-		TransactionDate:   time.Now(),
+	// Members get their tier's discount on top of the discount entered
+	tierID, memberDiscount, err := membershipDiscount(s.repos, req.CustomerID, req.TransactionType, lines, req.DiscountAmount)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.repos.Transaction.Create(transaction); err != nil {
+	taxed, err := computeTax(s.repos, outletID, lines, req.DiscountAmount+memberDiscount)
+	if err != nil {
 		return nil, err
 	}
 
-	// Add transaction details
-	for _, detailReq := range req.Details {
-		detail := &models.TransactionDetail{
-			TransactionID: transaction.ID,
-			ProductID:     detailReq.ProductID,
-			ServiceID:     detailReq.ServiceID,
-			Description:   detailReq.Description,
-			Quantity:      detailReq.Quantity,
-			UnitPrice:     detailReq.UnitPrice,
-			TotalPrice:    detailReq.Quantity * detailReq.UnitPrice,
-		}
+	// The discount is measured against what is left after promotions
+	var promotedTotal float64
+	for _, line := range lines {
+		promotedTotal += line.Amount - line.Discount
+	}
+	if approval := discountApproval(limits, promotedTotal, req.DiscountAmount); approval != nil {
+		approvals = append(approvals, *approval)
+	}
+	approvalStatus := "approved"
+	if len(approvals) > 0 {
+		approvalStatus = "pending"
+	}
 
-		if err := s.repos.Transaction.AddDetail(detail); err != nil {
-			return nil, err
-		}
+	transaction := &models.Transaction{
+		TransactionNumber:  transactionNumber,
+		TransactionType:    req.TransactionType,
+		CustomerID:         req.CustomerID,
+		OutletID:           outletID,
+		UserID:             userID,
+		ServiceJobID:       req.ServiceJobID,
+		SubtotalAmount:     taxed.Subtotal.InexactFloat64(),
+		DiscountAmount:     taxed.Discount.InexactFloat64(),
+		TaxAmount:          taxed.Tax.InexactFloat64(),
+		TotalAmount:        taxed.Total.InexactFloat64(),
+		PricesIncludeTax:   taxed.Inclusive,
+		MembershipTierID:   tierID,
+		MembershipDiscount: memberDiscount,
+		PaymentStatus:      "pending",
+		ApprovalStatus:     approvalStatus,
+		Notes:              req.Notes,
+		TransactionDate:    time.Now(),
+		Promotions:         redemptions,
+		Approvals:          approvals,
+		Packages:           packages,
+	}
+
+	for i, detail := range details {
+		line := taxed.Lines[i]
+		detail.TaxCodeID = taxed.CodeIDs[i]
+		detail.TaxRate = taxed.Rates[i].InexactFloat64()
+		detail.NetAmount = line.Net.InexactFloat64()
+		detail.DiscountAmount = line.Discount.InexactFloat64()
+		detail.TaxAmount = line.Tax.InexactFloat64()
+		detail.PromotionID = promoted.PromotionIDs[i]
+		detail.PromotionDiscount = lines[i].Discount
+		transaction.Details = append(transaction.Details, detail)
+	}
+
+	// Header, details and the TransactionPosted event are written together
+	if err := s.repos.Transaction.Create(transaction); err != nil {
+		return nil, err
 	}
+	s.eventBus.Wake()
 
 	return s.repos.Transaction.GetByID(transaction.ID)
 }
@@ -392,6 +994,30 @@ func (s *transactionService) GetByID(id int64) (*models.Transaction, error) {
 		transaction.Payments = payments
 	}
 
+	// Get tax summary
+	summary, err := s.repos.Tax.GetTransactionSummary(id)
+	if err == nil {
+		transaction.TaxSummary = summary
+	}
+
+	// Get promotions
+	redemptions, err := s.repos.Promotion.GetTransactionRedemptions(id)
+	if err == nil {
+		transaction.Promotions = redemptions
+	}
+
+	// Get approval requests
+	approvals, err := s.repos.Approval.ListForTransaction(id)
+	if err == nil {
+		transaction.Approvals = approvals
+	}
+
+	// Get packages
+	packages, err := s.repos.ServicePackage.GetTransactionPackages(id)
+	if err == nil {
+		transaction.Packages = packages
+	}
+
 	return transaction, nil
 }
 
@@ -403,8 +1029,49 @@ func (s *transactionService) Update(id int64, req *models.Transaction) (*models.
 	return s.repos.Transaction.GetByID(id)
 }
 
-func (s *transactionService) Delete(id int64) error {
-	return s.repos.Transaction.Delete(id)
+// Void reverses a transaction instead of deleting it. What the customer
+// paid is refunded out of the voiding user's open shift, which follows the
+// same rule as taking payments.
+func (s *transactionService) Void(id int64, req *models.VoidTransactionRequest, userID int64) (*models.TransactionVoid, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	balance, err := s.repos.Return.GetBalance(id)
+	if err != nil {
+		return nil, errors.New("transaction not found")
+	}
+
+	var shiftID *int64
+	if shift, err := s.repos.Shift.GetOpenByUser(userID); err == nil {
+		shiftID = &shift.ShiftID
+	} else if s.shiftRequired && balance.NetPaid.IsPositive() {
+		return nil, errors.New("open a cashier shift before voiding a paid transaction")
+	}
+
+	void, err := s.repos.Transaction.Void(id, reason, req.PaymentMethodID, shiftID, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.eventBus.Wake()
+
+	if void.Refund != nil {
+		if refund, err := s.repos.Return.GetRefundByID(void.Refund.RefundID); err == nil {
+			void.Refund = refund
+		}
+	}
+
+	return void, nil
+}
+
+// GetExceptionReport lists voids, payment reversals, returns and refunds
+func (s *transactionService) GetExceptionReport(filter *models.ExceptionFilter) (*models.ExceptionReport, error) {
+	if filter.DateTo.Before(filter.DateFrom) {
+		return nil, errors.New("date_to cannot be before date_from")
+	}
+
+	return s.repos.Transaction.GetExceptionReport(filter)
 }
 
 func (s *transactionService) List(page, limit int, outletID *int64, transactionType string, search string) ([]models.Transaction, *models.PaginationMeta, error) {
@@ -447,42 +1114,61 @@ func (s *transactionService) UpdatePaymentStatus(id int64, status string) error
 
 // Payment Service
 type PaymentService interface {
-	Create(req *models.CreatePaymentRequest) (*models.Payment, error)
+	Create(req *models.CreatePaymentRequest, userID int64) (*models.Payment, error)
 	GetByID(id int64) (*models.Payment, error)
-	Delete(id int64) error
+	Delete(id int64, userID int64) error
 	List(page, limit int, transactionID *int64) ([]models.Payment, *models.PaginationMeta, error)
 	GetByTransactionID(transactionID int64) ([]models.Payment, error)
 	ListPaymentMethods() ([]models.PaymentMethod, error)
+	Checkout(req *models.CreateCheckoutRequest, userID int64) (*models.Checkout, error)
+	GetCheckout(id int64) (*models.Checkout, error)
+	ListCheckouts(page, limit int, filter *models.CheckoutFilter) ([]models.Checkout, *models.PaginationMeta, error)
 }
 
 type paymentService struct {
-	repos *repositories.Repositories
+	repos         *repositories.Repositories
+	shiftRequired bool
+	notifications NotificationService
+	publisher     realtime.Publisher
+	eventBus      EventService
 }
 
-func NewPaymentService(repos *repositories.Repositories) PaymentService {
-	return &paymentService{repos: repos}
+func NewPaymentService(repos *repositories.Repositories, cfg *config.Config, notifications NotificationService, publisher realtime.Publisher, eventBus EventService) PaymentService {
+	s := &paymentService{
+		repos:         repos,
+		shiftRequired: cfg.Shift.RequiredForPayments,
+		notifications: notifications,
+		publisher:     publisher,
+		eventBus:      eventBus,
+	}
+
+	eventBus.Subscribe("payment.realtime", s.onPaymentReceivedPublish, events.PaymentReceived)
+	eventBus.Subscribe("payment.customer_receipt", s.onPaymentReceivedReceipt, events.PaymentReceived)
+	eventBus.Subscribe("checkout.customer_receipt", s.onCheckoutCompletedReceipt, events.CheckoutCompleted)
+
+	return s
 }
 
-func (s *paymentService) Create(req *models.CreatePaymentRequest) (*models.Payment, error) {
-	// Validate transaction exists
-	transaction, err := s.repos.Transaction.GetByID(req.TransactionID)
-	if err != nil {
-		return nil, errors.New("transaction not found")
+// paymentShift returns the open shift of the cashier taking a payment
+func (s *paymentService) paymentShift(userID int64) (*int64, error) {
+	if shift, err := s.repos.Shift.GetOpenByUser(userID); err == nil {
+		return &shift.ShiftID, nil
 	}
-
-	// Validate payment amount doesn't exceed remaining amount
-	existingPayments, err := s.repos.Payment.GetByTransactionID(req.TransactionID)
-	if err != nil {
-		return nil, err
+	if s.shiftRequired {
+		return nil, errors.New("open a cashier shift before taking payments")
 	}
+	return nil, nil
+}
 
-	var totalPaid float64
-	for _, payment := range existingPayments {
-		totalPaid += payment.Amount
+func (s *paymentService) Create(req *models.CreatePaymentRequest, userID int64) (*models.Payment, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("payment amount must be greater than zero")
 	}
 
-	if totalPaid+req.Amount > transaction.TotalAmount {
-		return nil, errors.New("payment amount exceeds remaining amount")
+	// Payments belong to the open shift of the cashier taking them
+	shiftID, err := s.paymentShift(userID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate payment number
@@ -499,36 +1185,80 @@ func (s *paymentService) Create(req *models.CreatePaymentRequest) (*models.Payme
 		PaymentDate:     time.Now(),
 		ReferenceNumber: req.ReferenceNumber,
 		Notes:           req.Notes,
+		ShiftID:         shiftID,
+		CreatedBy:       &userID,
 	}
 
+	// Checks the remaining amount, updates the transaction's payment status
+	// and records the PaymentReceived event under the transaction lock
 	if err := s.repos.Payment.Create(payment); err != nil {
 		return nil, err
 	}
+	s.eventBus.Wake()
 
-	// Update transaction payment status
-	newTotalPaid := totalPaid + req.Amount
-	var status string
-	if newTotalPaid >= transaction.TotalAmount {
-		status = "paid"
-	} else if newTotalPaid > 0 {
-		status = "partial"
-	} else {
-		status = "pending"
+	return s.repos.Payment.GetByID(payment.ID)
+}
+
+// onPaymentReceivedPublish pushes the payment to connected staff clients
+func (s *paymentService) onPaymentReceivedPublish(ctx context.Context, event events.Event) error {
+	var payload events.PaymentReceivedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
 	}
 
-	if err := s.repos.Transaction.UpdatePaymentStatus(req.TransactionID, status); err != nil {
-		// Log error but don't fail the payment creation
+	s.publisher.Publish(realtime.Event{
+		Type:       realtime.EventPaymentReceived,
+		OutletID:   payload.OutletID,
+		Permission: "transactions.read",
+		Data: map[string]interface{}{
+			"payment_id":         payload.PaymentID,
+			"payment_number":     payload.PaymentNumber,
+			"transaction_id":     payload.TransactionID,
+			"transaction_number": payload.TransactionNumber,
+			"service_job_id":     payload.ServiceJobID,
+			"amount":             payload.Amount,
+			"payment_status":     payload.PaymentStatus,
+		},
+	})
+
+	return nil
+}
+
+// onPaymentReceivedReceipt sends the customer a receipt. Tenders of a
+// checkout are covered by the checkout's receipt.
+func (s *paymentService) onPaymentReceivedReceipt(ctx context.Context, event events.Event) error {
+	var payload events.PaymentReceivedPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
 	}
 
-	return s.repos.Payment.GetByID(payment.ID)
+	if payload.CustomerID == nil || payload.CheckoutID != nil {
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"PaymentNumber":     payload.PaymentNumber,
+		"TransactionNumber": payload.TransactionNumber,
+		"Amount":            payload.Amount,
+		"Remaining":         payload.Remaining,
+	}
+	return s.notifications.NotifyCustomer(*payload.CustomerID, &payload.OutletID,
+		notifications.TemplatePaymentReceived, data, "payment", payload.PaymentID)
 }
 
 func (s *paymentService) GetByID(id int64) (*models.Payment, error) {
 	return s.repos.Payment.GetByID(id)
 }
 
-func (s *paymentService) Delete(id int64) error {
-	return s.repos.Payment.Delete(id)
+// Delete reverses a payment and recalculates the transaction's payment
+// status
+func (s *paymentService) Delete(id int64, userID int64) error {
+	if err := s.repos.Payment.Delete(id, userID); err != nil {
+		return err
+	}
+	s.eventBus.Wake()
+
+	return nil
 }
 
 func (s *paymentService) List(page, limit int, transactionID *int64) ([]models.Payment, *models.PaginationMeta, error) {
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceListService interface defines price list operations
+type PriceListService interface {
+	List(page, limit int, filter *models.PriceListFilter) ([]models.PriceList, *models.PaginationMeta, error)
+	GetByID(id int64) (*models.PriceList, error)
+	Create(req *models.PriceListRequest, userID int64) (*models.PriceList, error)
+	Update(id int64, req *models.PriceListRequest) (*models.PriceList, error)
+	ListItems(priceListID int64) ([]models.PriceListItem, error)
+	SetItem(priceListID int64, req *models.SetPriceListItemRequest, userID int64) (*models.PriceListItem, error)
+	DeleteItem(priceListID, itemID, userID int64) error
+	AssignCustomer(priceListID, customerID, userID int64) (*models.PriceList, error)
+	UnassignCustomer(priceListID, customerID int64) error
+	Resolve(customerID *int64, outletID int64, productID, serviceID *int64) (*models.ResolvedPrice, error)
+	ListHistory(page, limit int, filter *models.PriceHistoryFilter) ([]models.PriceHistory, *models.PaginationMeta, error)
+}
+
+type priceListService struct {
+	repos *repositories.Repositories
+}
+
+// NewPriceListService creates a new price list service
+func NewPriceListService(repos *repositories.Repositories) PriceListService {
+	return &priceListService{repos: repos}
+}
+
+func (s *priceListService) List(page, limit int, filter *models.PriceListFilter) ([]models.PriceList, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	priceLists, total, err := s.repos.PriceList.List(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return priceLists, paginationMeta(page, limit, total), nil
+}
+
+func (s *priceListService) GetByID(id int64) (*models.PriceList, error) {
+	return s.repos.PriceList.GetByID(id)
+}
+
+func (s *priceListService) Create(req *models.PriceListRequest, userID int64) (*models.PriceList, error) {
+	priceList := &models.PriceList{IsActive: true, CreatedBy: &userID}
+	if err := s.applyRequest(priceList, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.PriceList.Create(priceList); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(priceList.PriceListID)
+}
+
+// Update changes a price list. Lines already priced from it keep their
+// prices.
+func (s *priceListService) Update(id int64, req *models.PriceListRequest) (*models.PriceList, error) {
+	priceList, err := s.repos.PriceList.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(priceList, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.PriceList.Update(priceList); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(id)
+}
+
+// applyRequest validates a request and copies it onto a price list
+func (s *priceListService) applyRequest(priceList *models.PriceList, req *models.PriceListRequest) error {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if code == "" {
+		return errors.New("code is required")
+	}
+	if existing, err := s.repos.PriceList.GetByCode(code); err == nil && existing.PriceListID != priceList.PriceListID {
+		return errors.New("price list code already exists")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+
+	if req.CustomerType != nil && *req.CustomerType != "individual" && *req.CustomerType != "corporate" {
+		return errors.New("customer_type must be individual or corporate")
+	}
+	if req.ValidFrom != nil && req.ValidTo != nil && !req.ValidTo.After(*req.ValidFrom) {
+		return errors.New("valid_to must be after valid_from")
+	}
+
+	priceList.Code = code
+	priceList.Name = name
+	priceList.Description = nil
+	if description := strings.TrimSpace(req.Description); description != "" {
+		priceList.Description = &description
+	}
+	priceList.CustomerType = req.CustomerType
+	priceList.OutletID = req.OutletID
+	priceList.ValidFrom = req.ValidFrom
+	priceList.ValidTo = req.ValidTo
+	priceList.Priority = req.Priority
+	if req.IsActive != nil {
+		priceList.IsActive = *req.IsActive
+	}
+
+	return nil
+}
+
+func (s *priceListService) ListItems(priceListID int64) ([]models.PriceListItem, error) {
+	if _, err := s.repos.PriceList.GetByID(priceListID); err != nil {
+		return nil, err
+	}
+
+	return s.repos.PriceList.ListItems(priceListID)
+}
+
+// SetItem puts a product or service on a price list at a price, or changes
+// its price there
+func (s *priceListService) SetItem(priceListID int64, req *models.SetPriceListItemRequest, userID int64) (*models.PriceListItem, error) {
+	if _, err := s.repos.PriceList.GetByID(priceListID); err != nil {
+		return nil, err
+	}
+	if (req.ProductID == nil) == (req.ServiceID == nil) {
+		return nil, errors.New("either product_id or service_id is required")
+	}
+	if req.Price.IsNegative() {
+		return nil, errors.New("price cannot be negative")
+	}
+	if req.ProductID != nil {
+		if _, err := s.repos.Product.GetByID(*req.ProductID); err != nil {
+			return nil, errors.New("product not found")
+		}
+	}
+	if req.ServiceID != nil {
+		if _, err := s.repos.Service.GetByID(*req.ServiceID); err != nil {
+			return nil, errors.New("service not found")
+		}
+	}
+
+	item := &models.PriceListItem{
+		PriceListID: priceListID,
+		ProductID:   req.ProductID,
+		ServiceID:   req.ServiceID,
+		Price:       req.Price.Round(2),
+	}
+	if err := s.repos.PriceList.SetItem(item, userID); err != nil {
+		return nil, err
+	}
+
+	items, err := s.repos.PriceList.ListItems(priceListID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if items[i].ItemID == item.ItemID {
+			return &items[i], nil
+		}
+	}
+
+	return item, nil
+}
+
+func (s *priceListService) DeleteItem(priceListID, itemID, userID int64) error {
+	return s.repos.PriceList.DeleteItem(priceListID, itemID, userID)
+}
+
+// AssignCustomer negotiates a price list for a customer. A list with
+// customers applies to them only.
+func (s *priceListService) AssignCustomer(priceListID, customerID, userID int64) (*models.PriceList, error) {
+	if _, err := s.repos.PriceList.GetByID(priceListID); err != nil {
+		return nil, err
+	}
+	if _, err := s.repos.Customer.GetByID(customerID); err != nil {
+		return nil, errors.New("customer not found")
+	}
+
+	if err := s.repos.PriceList.AssignCustomer(priceListID, customerID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(priceListID)
+}
+
+func (s *priceListService) UnassignCustomer(priceListID, customerID int64) error {
+	return s.repos.PriceList.UnassignCustomer(priceListID, customerID)
+}
+
+func (s *priceListService) Resolve(customerID *int64, outletID int64, productID, serviceID *int64) (*models.ResolvedPrice, error) {
+	if (productID == nil) == (serviceID == nil) {
+		return nil, errors.New("either product_id or service_id is required")
+	}
+
+	return resolvePrice(s.repos, customerID, outletID, productID, serviceID)
+}
+
+func (s *priceListService) ListHistory(page, limit int, filter *models.PriceHistoryFilter) ([]models.PriceHistory, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	history, total, err := s.repos.PriceList.ListHistory(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return history, paginationMeta(page, limit, total), nil
+}
+
+// resolvePrice returns the price a product or service sells at now for a
+// customer at an outlet
+func resolvePrice(repos *repositories.Repositories, customerID *int64, outletID int64, productID, serviceID *int64) (*models.ResolvedPrice, error) {
+	return repos.PriceList.Resolve(customerID, outletID, productID, serviceID, time.Now())
+}
+
+// recordPriceChange records a change to a product's or service's own price
+func recordPriceChange(repos *repositories.Repositories, productID, serviceID *int64, oldPrice *float64, newPrice float64, userID *int64) error {
+	newValue := decimal.NewFromFloat(newPrice).Round(2)
+	var oldValue *decimal.Decimal
+	if oldPrice != nil {
+		value := decimal.NewFromFloat(*oldPrice).Round(2)
+		if value.Equal(newValue) {
+			return nil
+		}
+		oldValue = &value
+	}
+
+	return repos.PriceList.RecordPriceChange(productID, serviceID, oldValue, &newValue, userID)
+}
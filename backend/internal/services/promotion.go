@@ -0,0 +1,376 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/promotion"
+	"flutter-bengkel/internal/repositories"
+	"flutter-bengkel/internal/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+const couponCodeLength = 8
+
+// PromotionService interface defines promotion, coupon and redemption
+// operations
+type PromotionService interface {
+	List(page, limit int, filter *models.PromotionFilter) ([]models.Promotion, *models.PaginationMeta, error)
+	GetByID(id int64) (*models.Promotion, error)
+	Create(req *models.PromotionRequest, userID int64) (*models.Promotion, error)
+	Update(id int64, req *models.PromotionRequest) (*models.Promotion, error)
+	ListCoupons(promotionID int64) ([]models.Coupon, error)
+	GetCoupon(id int64) (*models.Coupon, error)
+	CreateCoupon(promotionID int64, req *models.CreateCouponRequest, userID int64) (*models.Coupon, error)
+	UpdateCoupon(id int64, req *models.UpdateCouponRequest) (*models.Coupon, error)
+	ListRedemptions(page, limit int, filter *models.RedemptionFilter) ([]models.PromotionRedemption, *models.PaginationMeta, error)
+}
+
+type promotionService struct {
+	repos *repositories.Repositories
+}
+
+// NewPromotionService creates a new promotion service
+func NewPromotionService(repos *repositories.Repositories) PromotionService {
+	return &promotionService{repos: repos}
+}
+
+func (s *promotionService) List(page, limit int, filter *models.PromotionFilter) ([]models.Promotion, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	promotions, total, err := s.repos.Promotion.List(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return promotions, paginationMeta(page, limit, total), nil
+}
+
+func (s *promotionService) GetByID(id int64) (*models.Promotion, error) {
+	return s.repos.Promotion.GetByID(id)
+}
+
+func (s *promotionService) Create(req *models.PromotionRequest, userID int64) (*models.Promotion, error) {
+	p := &models.Promotion{IsActive: true, CreatedBy: &userID}
+	if err := applyPromotionRequest(p, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Promotion.Create(p); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Promotion.GetByID(p.PromotionID)
+}
+
+// Update changes a promotion. Transactions already created keep the
+// discounts they were given.
+func (s *promotionService) Update(id int64, req *models.PromotionRequest) (*models.Promotion, error) {
+	p, err := s.repos.Promotion.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPromotionRequest(p, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Promotion.Update(p); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Promotion.GetByID(id)
+}
+
+// applyPromotionRequest validates a request and copies it onto a promotion
+func applyPromotionRequest(p *models.Promotion, req *models.PromotionRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+
+	switch req.DiscountType {
+	case promotion.TypePercentage:
+		if req.DiscountValue.GreaterThan(decimal.NewFromInt(100)) {
+			return errors.New("percentage cannot exceed 100")
+		}
+	case promotion.TypeFixed:
+	default:
+		return errors.New("discount_type must be percentage or fixed")
+	}
+	if !req.DiscountValue.IsPositive() {
+		return errors.New("discount_value must be greater than zero")
+	}
+
+	scope := req.Scope
+	if scope == "" {
+		scope = promotion.ScopeInvoice
+	}
+	if scope != promotion.ScopeLine && scope != promotion.ScopeInvoice {
+		return errors.New("scope must be line or invoice")
+	}
+
+	if req.MinSpend.IsNegative() {
+		return errors.New("min_spend cannot be negative")
+	}
+	if req.MaxDiscount != nil && !req.MaxDiscount.IsPositive() {
+		return errors.New("max_discount must be greater than zero")
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if req.UsageLimit != nil && *req.UsageLimit <= 0 {
+		return errors.New("usage_limit must be greater than zero")
+	}
+	if req.PerCustomerLimit != nil && *req.PerCustomerLimit <= 0 {
+		return errors.New("per_customer_limit must be greater than zero")
+	}
+
+	p.Name = name
+	p.Description = nil
+	if description := strings.TrimSpace(req.Description); description != "" {
+		p.Description = &description
+	}
+	p.DiscountType = req.DiscountType
+	p.DiscountValue = req.DiscountValue
+	p.Scope = scope
+	p.MinSpend = req.MinSpend
+	p.MaxDiscount = req.MaxDiscount
+	p.ProductCategoryID = req.ProductCategoryID
+	p.ServiceCategoryID = req.ServiceCategoryID
+	p.OutletID = req.OutletID
+	p.StartsAt = req.StartsAt
+	p.EndsAt = req.EndsAt
+	p.UsageLimit = req.UsageLimit
+	p.PerCustomerLimit = req.PerCustomerLimit
+	p.RequiresCoupon = req.RequiresCoupon
+	p.Priority = req.Priority
+	if req.IsActive != nil {
+		p.IsActive = *req.IsActive
+	}
+
+	return nil
+}
+
+func (s *promotionService) ListCoupons(promotionID int64) ([]models.Coupon, error) {
+	if _, err := s.repos.Promotion.GetByID(promotionID); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Promotion.ListCoupons(promotionID)
+}
+
+// CreateCoupon adds a code to a promotion that requires one. A random code
+// is generated when none is given.
+func (s *promotionService) CreateCoupon(promotionID int64, req *models.CreateCouponRequest, userID int64) (*models.Coupon, error) {
+	p, err := s.repos.Promotion.GetByID(promotionID)
+	if err != nil {
+		return nil, err
+	}
+	if !p.RequiresCoupon {
+		return nil, errors.New("promotion applies automatically and has no coupons")
+	}
+	if req.UsageLimit != nil && *req.UsageLimit <= 0 {
+		return nil, errors.New("usage_limit must be greater than zero")
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if code == "" {
+		if code, err = utils.GenerateRandomString(couponCodeLength); err != nil {
+			return nil, errors.New("failed to generate coupon code")
+		}
+		code = strings.ToUpper(code)
+	}
+	if _, err := s.repos.Promotion.GetCouponByCode(code); err == nil {
+		return nil, errors.New("coupon code already exists")
+	}
+
+	coupon := &models.Coupon{
+		PromotionID: promotionID,
+		Code:        code,
+		UsageLimit:  req.UsageLimit,
+		ExpiresAt:   req.ExpiresAt,
+		IsActive:    true,
+		CreatedBy:   &userID,
+	}
+	if err := s.repos.Promotion.CreateCoupon(coupon); err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (s *promotionService) GetCoupon(id int64) (*models.Coupon, error) {
+	return s.repos.Promotion.GetCouponByID(id)
+}
+
+func (s *promotionService) UpdateCoupon(id int64, req *models.UpdateCouponRequest) (*models.Coupon, error) {
+	if err := s.repos.Promotion.UpdateCoupon(id, req.IsActive); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Promotion.GetCouponByID(id)
+}
+
+func (s *promotionService) ListRedemptions(page, limit int, filter *models.RedemptionFilter) ([]models.PromotionRedemption, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	redemptions, total, err := s.repos.Promotion.ListRedemptions(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return redemptions, paginationMeta(page, limit, total), nil
+}
+
+// promotionRule turns a promotion into the rule the engine evaluates
+func promotionRule(p *models.Promotion) promotion.Rule {
+	return promotion.Rule{
+		PromotionID:       p.PromotionID,
+		DiscountType:      p.DiscountType,
+		Value:             p.DiscountValue,
+		Scope:             p.Scope,
+		MinSpend:          p.MinSpend,
+		MaxDiscount:       p.MaxDiscount,
+		ProductCategoryID: p.ProductCategoryID,
+		ServiceCategoryID: p.ServiceCategoryID,
+	}
+}
+
+// customerMayUse reports whether a promotion's per customer limit lets the
+// customer use it again. Limited promotions need a known customer.
+func customerMayUse(repos *repositories.Repositories, p *models.Promotion, customerID *int64) (bool, error) {
+	if p.PerCustomerLimit == nil {
+		return true, nil
+	}
+	if customerID == nil {
+		return false, nil
+	}
+
+	used, err := repos.Promotion.CountCustomerRedemptions(p.PromotionID, *customerID)
+	if err != nil {
+		return false, err
+	}
+	return used < *p.PerCustomerLimit, nil
+}
+
+// couponPromotion checks that a coupon can be used at an outlet now and
+// returns its promotion
+func couponPromotion(repos *repositories.Repositories, code string, outletID int64, customerID *int64) (*models.Coupon, *models.Promotion, error) {
+	coupon, err := repos.Promotion.GetCouponByCode(code)
+	if err != nil {
+		return nil, nil, fmt.Errorf("coupon %s not found", code)
+	}
+
+	now := time.Now()
+	if !coupon.IsActive {
+		return nil, nil, fmt.Errorf("coupon %s is disabled", coupon.Code)
+	}
+	if coupon.ExpiresAt != nil && !now.Before(*coupon.ExpiresAt) {
+		return nil, nil, fmt.Errorf("coupon %s has expired", coupon.Code)
+	}
+	if coupon.UsageLimit != nil && coupon.UsageCount >= *coupon.UsageLimit {
+		return nil, nil, fmt.Errorf("coupon %s has already been used", coupon.Code)
+	}
+
+	p, err := repos.Promotion.GetByID(coupon.PromotionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case !p.IsActive:
+		return nil, nil, fmt.Errorf("promotion %s is not active", p.Name)
+	case p.OutletID != nil && *p.OutletID != outletID:
+		return nil, nil, fmt.Errorf("coupon %s is not valid at this outlet", coupon.Code)
+	case p.StartsAt != nil && now.Before(*p.StartsAt):
+		return nil, nil, fmt.Errorf("promotion %s has not started", p.Name)
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return nil, nil, fmt.Errorf("promotion %s has ended", p.Name)
+	case p.UsageLimit != nil && p.UsageCount >= *p.UsageLimit:
+		return nil, nil, fmt.Errorf("promotion %s has reached its usage limit", p.Name)
+	}
+
+	ok, err := customerMayUse(repos, p, customerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		if customerID == nil {
+			return nil, nil, fmt.Errorf("coupon %s needs a customer", coupon.Code)
+		}
+		return nil, nil, fmt.Errorf("customer has already used promotion %s", p.Name)
+	}
+
+	return coupon, p, nil
+}
+
+// applyPromotions works out the promotions for a document and sets what
+// they take off each line. A coupon is applied first and must discount
+// something; automatic promotions follow by priority on the lines left.
+func applyPromotions(repos *repositories.Repositories, outletID int64, customerID *int64, couponCode string, lines []taxLine) (*promotion.Result, []models.PromotionRedemption, error) {
+	items := make([]promotion.Line, len(lines))
+	for i, line := range lines {
+		productCategoryID, serviceCategoryID, err := repos.Promotion.GetItemCategories(line.ProductID, line.ServiceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		items[i] = promotion.Line{
+			Amount:            decimal.NewFromFloat(line.Amount).Round(2),
+			ProductCategoryID: productCategoryID,
+			ServiceCategoryID: serviceCategoryID,
+		}
+	}
+
+	var rules []promotion.Rule
+	var coupon *models.Coupon
+	if code := strings.TrimSpace(couponCode); code != "" {
+		var p *models.Promotion
+		var err error
+		if coupon, p, err = couponPromotion(repos, code, outletID, customerID); err != nil {
+			return nil, nil, err
+		}
+		rule := promotionRule(p)
+		if !promotion.Check(rule, items) {
+			return nil, nil, fmt.Errorf("coupon %s does not apply to these items", coupon.Code)
+		}
+		rules = append(rules, rule)
+	}
+
+	automatic, err := repos.Promotion.ListApplicable(outletID, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range automatic {
+		if coupon != nil && automatic[i].PromotionID == coupon.PromotionID {
+			continue
+		}
+		ok, err := customerMayUse(repos, &automatic[i], customerID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			rules = append(rules, promotionRule(&automatic[i]))
+		}
+	}
+
+	result := promotion.Evaluate(rules, items)
+	for i := range lines {
+		lines[i].Discount = result.Discounts[i].InexactFloat64()
+	}
+
+	var redemptions []models.PromotionRedemption
+	for _, applied := range result.Applied {
+		redemption := models.PromotionRedemption{
+			PromotionID:    applied.PromotionID,
+			DiscountAmount: applied.Discount,
+		}
+		if coupon != nil && coupon.PromotionID == applied.PromotionID {
+			redemption.CouponID = &coupon.CouponID
+			redemption.CouponCode = &coupon.Code
+		}
+		redemptions = append(redemptions, redemption)
+	}
+
+	return result, redemptions, nil
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/realtime"
+	"flutter-bengkel/internal/repositories"
+)
+
+// QueueService interface defines priority-aware queue ordering and ETA estimation
+type QueueService interface {
+	GetOutletQueue(outletID int64) (*models.OutletQueue, error)
+	GetJobETA(jobID int64, outletID *int64) (*models.QueueEntry, error)
+	Recalculate(outletID int64) error
+	RecalculateForJob(jobID int64) error
+}
+
+type queueService struct {
+	repos     *repositories.Repositories
+	cfg       *config.Config
+	publisher realtime.Publisher
+}
+
+// NewQueueService creates a new queue service
+func NewQueueService(repos *repositories.Repositories, cfg *config.Config, publisher realtime.Publisher) QueueService {
+	return &queueService{
+		repos:     repos,
+		cfg:       cfg,
+		publisher: publisher,
+	}
+}
+
+// technicianSlot tracks when a technician (or an unassigned bay) becomes free
+type technicianSlot struct {
+	technicianID int64
+	freeAt       time.Time
+}
+
+func (s *queueService) GetOutletQueue(outletID int64) (*models.OutletQueue, error) {
+	entries, err := s.repos.Queue.GetActiveJobs(outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := s.repos.Queue.GetActiveJobLines(outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	technicianIDs, err := s.repos.Queue.GetActiveTechnicianIDs(outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := s.buildQueue(entries, lines, technicianIDs, time.Now())
+	queue.OutletID = outletID
+
+	return queue, nil
+}
+
+// GetJobETA returns a job's place in its outlet's queue. Users restricted
+// to an outlet only see its jobs.
+func (s *queueService) GetJobETA(jobID int64, outletID *int64) (*models.QueueEntry, error) {
+	jobOutletID, err := s.repos.Queue.GetJobOutletID(jobID)
+	if err != nil || (outletID != nil && jobOutletID != *outletID) {
+		return nil, errors.New("service job not found")
+	}
+
+	queue, err := s.GetOutletQueue(jobOutletID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entries := range [][]models.QueueEntry{queue.InProgress, queue.Pending} {
+		for i := range entries {
+			if entries[i].JobID == jobID {
+				return &entries[i], nil
+			}
+		}
+	}
+
+	return nil, errors.New("service job is not in the active queue")
+}
+
+func (s *queueService) Recalculate(outletID int64) error {
+	queue, err := s.GetOutletQueue(outletID)
+	if err != nil {
+		return err
+	}
+
+	for _, entries := range [][]models.QueueEntry{queue.InProgress, queue.Pending} {
+		for _, entry := range entries {
+			if err := s.repos.Queue.UpdateEstimates(entry.JobID, entry.EstimatedStart, entry.EstimatedCompletion); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.publisher.Publish(realtime.Event{
+		Type:       realtime.EventQueueUpdated,
+		OutletID:   outletID,
+		Permission: "service_jobs.read",
+		Data:       queue,
+	})
+
+	return nil
+}
+
+func (s *queueService) RecalculateForJob(jobID int64) error {
+	outletID, err := s.repos.Queue.GetJobOutletID(jobID)
+	if err != nil {
+		return err
+	}
+
+	return s.Recalculate(outletID)
+}
+
+// buildQueue orders pending jobs by priority, then members of priority tiers,
+// then arrival, and simulates technician availability to estimate when each
+// job starts and finishes
+func (s *queueService) buildQueue(entries []models.QueueEntry, lines []models.QueueJobLine, technicianIDs []int64, now time.Time) *models.OutletQueue {
+	now = now.Truncate(time.Minute)
+
+	// At least one bay is always available, even without registered technicians
+	slots := make([]*technicianSlot, 0, len(technicianIDs))
+	for _, id := range technicianIDs {
+		slots = append(slots, &technicianSlot{technicianID: id, freeAt: now})
+	}
+	if len(slots) == 0 {
+		slots = append(slots, &technicianSlot{freeAt: now})
+	}
+
+	queue := &models.OutletQueue{
+		Technicians: len(technicianIDs),
+		GeneratedAt: now,
+		InProgress:  []models.QueueEntry{},
+		Pending:     []models.QueueEntry{},
+	}
+
+	durations := jobDurations(lines)
+	var pending []models.QueueEntry
+	for _, entry := range entries {
+		entry.DurationMinutes = durations[entry.JobID]
+		if entry.DurationMinutes <= 0 {
+			entry.DurationMinutes = s.cfg.Queue.DefaultJobDurationMinutes
+		}
+
+		if entry.Status != "in_progress" {
+			pending = append(pending, entry)
+			continue
+		}
+
+		start := now
+		if entry.StartedAt != nil {
+			start = entry.StartedAt.Truncate(time.Minute)
+		}
+		end := start.Add(time.Duration(entry.DurationMinutes) * time.Minute)
+		// Overrunning jobs are assumed to finish now
+		if end.Before(now) {
+			end = now
+		}
+
+		slot := pickSlot(slots, entry.TechnicianID)
+		if end.After(slot.freeAt) {
+			slot.freeAt = end
+		}
+
+		entry.EstimatedStart = start
+		entry.EstimatedCompletion = end
+		queue.InProgress = append(queue.InProgress, entry)
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		ri, rj := priorityRank(pending[i].Priority), priorityRank(pending[j].Priority)
+		if ri != rj {
+			return ri < rj
+		}
+		if pending[i].PriorityMember != pending[j].PriorityMember {
+			return pending[i].PriorityMember
+		}
+		if !pending[i].ArrivedAt.Equal(pending[j].ArrivedAt) {
+			return pending[i].ArrivedAt.Before(pending[j].ArrivedAt)
+		}
+		return pending[i].QueueNumber < pending[j].QueueNumber
+	})
+
+	for i, entry := range pending {
+		slot := pickSlot(slots, entry.TechnicianID)
+
+		start := slot.freeAt
+		if start.Before(now) {
+			start = now
+		}
+		end := start.Add(time.Duration(entry.DurationMinutes) * time.Minute)
+		slot.freeAt = end
+
+		entry.Position = i + 1
+		entry.EstimatedStart = start
+		entry.EstimatedCompletion = end
+		entry.WaitMinutes = int(start.Sub(now).Minutes())
+		queue.Pending = append(queue.Pending, entry)
+	}
+
+	return queue
+}
+
+// jobDurations adds up how many minutes each job's services take. A service
+// takes its estimated duration for every unit, and part of a unit counts as
+// a whole one.
+func jobDurations(lines []models.QueueJobLine) map[int64]int {
+	durations := make(map[int64]int)
+	for _, line := range lines {
+		durations[line.JobID] += line.EstimatedDuration * int(math.Ceil(line.Quantity))
+	}
+	return durations
+}
+
+// pickSlot returns the assigned technician's slot, or the one that frees up first
+func pickSlot(slots []*technicianSlot, technicianID *int64) *technicianSlot {
+	if technicianID != nil {
+		for _, slot := range slots {
+			if slot.technicianID == *technicianID {
+				return slot
+			}
+		}
+	}
+
+	earliest := slots[0]
+	for _, slot := range slots[1:] {
+		if slot.freeAt.Before(earliest.freeAt) {
+			earliest = slot
+		}
+	}
+	return earliest
+}
+
+func priorityRank(priority string) int {
+	if rank, ok := models.QueuePriorityRank[priority]; ok {
+		return rank
+	}
+	return models.QueuePriorityRank["normal"]
+}
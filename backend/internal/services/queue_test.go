@@ -0,0 +1,223 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/models"
+)
+
+func TestBuildQueue(t *testing.T) {
+	now := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+	id := func(v int64) *int64 { return &v }
+
+	// schedule is a job's estimated start and completion in minutes from now
+	type schedule struct{ start, end int }
+
+	tests := []struct {
+		name        string
+		entries     []models.QueueEntry
+		lines       []models.QueueJobLine
+		technicians []int64
+		pending     []int64 // job IDs in queue order
+		want        map[int64]schedule
+	}{
+		{
+			name: "priority goes before arrival",
+			entries: []models.QueueEntry{
+				{JobID: 1, Priority: "normal", ArrivedAt: at(-60)},
+				{JobID: 2, Priority: "urgent", ArrivedAt: at(-30)},
+				{JobID: 3, Priority: "high", ArrivedAt: at(-50)},
+			},
+			lines: []models.QueueJobLine{
+				{JobID: 1, EstimatedDuration: 30, Quantity: 1},
+				{JobID: 2, EstimatedDuration: 60, Quantity: 1},
+				{JobID: 3, EstimatedDuration: 30, Quantity: 1},
+			},
+			pending: []int64{2, 3, 1},
+			want:    map[int64]schedule{2: {0, 60}, 3: {60, 90}, 1: {90, 120}},
+		},
+		{
+			name: "priority members go first within a priority",
+			entries: []models.QueueEntry{
+				{JobID: 1, Priority: "normal", ArrivedAt: at(-60)},
+				{JobID: 2, Priority: "normal", ArrivedAt: at(-40), PriorityMember: true},
+				{JobID: 3, Priority: "high", ArrivedAt: at(-20)},
+			},
+			lines: []models.QueueJobLine{
+				{JobID: 1, EstimatedDuration: 30, Quantity: 1},
+				{JobID: 2, EstimatedDuration: 30, Quantity: 1},
+				{JobID: 3, EstimatedDuration: 30, Quantity: 1},
+			},
+			pending: []int64{3, 2, 1},
+			want:    map[int64]schedule{3: {0, 30}, 2: {30, 60}, 1: {60, 90}},
+		},
+		{
+			name: "arrival then queue number break ties",
+			entries: []models.QueueEntry{
+				{JobID: 1, QueueNumber: 3, Priority: "normal", ArrivedAt: at(-30)},
+				{JobID: 2, QueueNumber: 2, Priority: "normal", ArrivedAt: at(-30)},
+				{JobID: 3, QueueNumber: 1, Priority: "normal", ArrivedAt: at(-10)},
+			},
+			lines: []models.QueueJobLine{
+				{JobID: 1, EstimatedDuration: 20, Quantity: 1},
+				{JobID: 2, EstimatedDuration: 20, Quantity: 1},
+				{JobID: 3, EstimatedDuration: 20, Quantity: 1},
+			},
+			pending: []int64{2, 1, 3},
+			want:    map[int64]schedule{2: {0, 20}, 1: {20, 40}, 3: {40, 60}},
+		},
+		{
+			name: "unknown priority counts as normal",
+			entries: []models.QueueEntry{
+				{JobID: 1, Priority: "low", ArrivedAt: at(-60)},
+				{JobID: 2, Priority: "", ArrivedAt: at(-10)},
+			},
+			lines: []models.QueueJobLine{
+				{JobID: 1, EstimatedDuration: 30, Quantity: 1},
+				{JobID: 2, EstimatedDuration: 30, Quantity: 1},
+			},
+			pending: []int64{2, 1},
+			want:    map[int64]schedule{2: {0, 30}, 1: {30, 60}},
+		},
+		{
+			name: "free technicians take the next job",
+			entries: []models.QueueEntry{
+				{JobID: 1, Priority: "normal", ArrivedAt: at(-30)},
+				{JobID: 2, Priority: "normal", ArrivedAt: at(-20)},
+				{JobID: 3, Priority: "normal", ArrivedAt: at(-10)},
+			},
+			lines: []models.QueueJobLine{
+				{JobID: 1, EstimatedDuration: 60, Quantity: 1},
+				{JobID: 2, EstimatedDuration: 30, Quantity: 1},
+				{JobID: 3, EstimatedDuration: 30, Quantity: 1},
+			},
+			technicians: []int64{10, 11},
+			pending:     []int64{1, 2, 3},
+			want:        map[int64]schedule{1: {0, 60}, 2: {0, 30}, 3: {30, 60}},
+		},
+		{
+			name: "assigned job waits for its technician",
+			entries: []models.QueueEntry{
+				{JobID: 1, Status: "in_progress", TechnicianID: id(10), StartedAt: timePtr(at(-30))},
+				{JobID: 2, Priority: "normal", TechnicianID: id(10), ArrivedAt: at(-20)},
+				{JobID: 3, Priority: "normal", ArrivedAt: at(-10)},
+			},
+			lines: []models.QueueJobLine{
+				{JobID: 1, EstimatedDuration: 60, Quantity: 1},
+				{JobID: 2, EstimatedDuration: 30, Quantity: 1},
+				{JobID: 3, EstimatedDuration: 30, Quantity: 1},
+			},
+			technicians: []int64{10, 11},
+			pending:     []int64{2, 3},
+			want:        map[int64]schedule{1: {-30, 30}, 2: {30, 60}, 3: {0, 30}},
+		},
+		{
+			name: "technician not at the outlet takes the first free bay",
+			entries: []models.QueueEntry{
+				{JobID: 1, Status: "in_progress", TechnicianID: id(10), StartedAt: timePtr(at(-10))},
+				{JobID: 2, Priority: "normal", TechnicianID: id(99), ArrivedAt: at(-5)},
+			},
+			lines: []models.QueueJobLine{
+				{JobID: 1, EstimatedDuration: 40, Quantity: 1},
+				{JobID: 2, EstimatedDuration: 15, Quantity: 1},
+			},
+			technicians: []int64{10, 11},
+			pending:     []int64{2},
+			want:        map[int64]schedule{1: {-10, 30}, 2: {0, 15}},
+		},
+		{
+			name: "overrunning job is expected to finish now",
+			entries: []models.QueueEntry{
+				{JobID: 1, Status: "in_progress", StartedAt: timePtr(at(-90))},
+				{JobID: 2, Priority: "normal", ArrivedAt: at(-60)},
+			},
+			lines: []models.QueueJobLine{
+				{JobID: 1, EstimatedDuration: 30, Quantity: 1},
+				{JobID: 2, EstimatedDuration: 30, Quantity: 1},
+			},
+			pending: []int64{2},
+			want:    map[int64]schedule{1: {-90, 0}, 2: {0, 30}},
+		},
+		{
+			name: "job in progress without a start time starts now",
+			entries: []models.QueueEntry{
+				{JobID: 1, Status: "in_progress"},
+				{JobID: 2, Priority: "normal", ArrivedAt: at(-60)},
+			},
+			lines: []models.QueueJobLine{
+				{JobID: 1, EstimatedDuration: 45, Quantity: 1},
+				{JobID: 2, EstimatedDuration: 30, Quantity: 1},
+			},
+			pending: []int64{2},
+			want:    map[int64]schedule{1: {0, 45}, 2: {45, 75}},
+		},
+		{
+			name: "services take their duration for every unit",
+			entries: []models.QueueEntry{
+				{JobID: 1, Priority: "normal", ArrivedAt: at(-30)},
+				{JobID: 2, Priority: "normal", ArrivedAt: at(-20)},
+			},
+			lines: []models.QueueJobLine{
+				{JobID: 1, EstimatedDuration: 30, Quantity: 2},
+				{JobID: 1, EstimatedDuration: 15, Quantity: 0.5},
+				{JobID: 2, EstimatedDuration: 20, Quantity: 1.5},
+			},
+			pending: []int64{1, 2},
+			want:    map[int64]schedule{1: {0, 75}, 2: {75, 115}},
+		},
+		{
+			name: "job without services takes the default duration",
+			entries: []models.QueueEntry{
+				{JobID: 1, Priority: "normal", ArrivedAt: at(-30)},
+				{JobID: 2, Priority: "normal", ArrivedAt: at(-20)},
+			},
+			lines:   []models.QueueJobLine{{JobID: 2, EstimatedDuration: 0, Quantity: 3}},
+			pending: []int64{1, 2},
+			want:    map[int64]schedule{1: {0, 60}, 2: {60, 120}},
+		},
+	}
+
+	s := &queueService{cfg: &config.Config{Queue: config.QueueConfig{DefaultJobDurationMinutes: 60}}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Seconds past the minute are dropped
+			queue := s.buildQueue(tt.entries, tt.lines, tt.technicians, now.Add(42*time.Second))
+
+			if len(queue.Pending) != len(tt.pending) {
+				t.Fatalf("got %d pending jobs, want %d", len(queue.Pending), len(tt.pending))
+			}
+			for i, jobID := range tt.pending {
+				entry := queue.Pending[i]
+				if entry.JobID != jobID {
+					t.Errorf("pending[%d] = job %d, want job %d", i, entry.JobID, jobID)
+				}
+				if entry.Position != i+1 {
+					t.Errorf("job %d position = %d, want %d", entry.JobID, entry.Position, i+1)
+				}
+				if want := tt.want[entry.JobID].start; entry.WaitMinutes != want {
+					t.Errorf("job %d wait = %d minutes, want %d", entry.JobID, entry.WaitMinutes, want)
+				}
+			}
+
+			for _, entry := range append(queue.InProgress, queue.Pending...) {
+				want, ok := tt.want[entry.JobID]
+				if !ok {
+					t.Errorf("unexpected job %d", entry.JobID)
+					continue
+				}
+				if !entry.EstimatedStart.Equal(at(want.start)) || !entry.EstimatedCompletion.Equal(at(want.end)) {
+					t.Errorf("job %d runs %s to %s, want %s to %s", entry.JobID,
+						entry.EstimatedStart.Format("15:04"), entry.EstimatedCompletion.Format("15:04"),
+						at(want.start).Format("15:04"), at(want.end).Format("15:04"))
+				}
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
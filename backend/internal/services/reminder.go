@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+)
+
+// ReminderNotifier delivers a reminder to the customer
+type ReminderNotifier interface {
+	NotifyReminder(reminder *models.VehicleReminder) error
+}
+
+// ReminderService interface defines service interval and vehicle reminder operations
+type ReminderService interface {
+	ListIntervals() ([]models.ServiceInterval, error)
+	CreateInterval(req *models.CreateServiceIntervalRequest, userID int64) (*models.ServiceInterval, error)
+	UpdateInterval(id int64, req *models.UpdateServiceIntervalRequest) (*models.ServiceInterval, error)
+	DeleteInterval(id int64) error
+
+	RecordServiceCompletion(jobID int64) error
+	Scan() (*models.ReminderScanResult, error)
+	List(page, limit int, filter *models.ReminderFilter) ([]models.VehicleReminder, *models.PaginationMeta, error)
+	UpdateStatus(id int64, req *models.UpdateReminderStatusRequest, outletID *int64, userID int64) (*models.VehicleReminder, error)
+}
+
+type reminderService struct {
+	repos    *repositories.Repositories
+	cfg      *config.Config
+	notifier ReminderNotifier
+}
+
+// NewReminderService creates a new reminder service
+func NewReminderService(repos *repositories.Repositories, cfg *config.Config, notifier ReminderNotifier) ReminderService {
+	return &reminderService{
+		repos:    repos,
+		cfg:      cfg,
+		notifier: notifier,
+	}
+}
+
+func (s *reminderService) ListIntervals() ([]models.ServiceInterval, error) {
+	return s.repos.Reminder.ListIntervals()
+}
+
+func (s *reminderService) CreateInterval(req *models.CreateServiceIntervalRequest, userID int64) (*models.ServiceInterval, error) {
+	if (req.ServiceID == nil) == (req.ServiceCategoryID == nil) {
+		return nil, errors.New("either service_id or service_category_id is required")
+	}
+	if err := validateInterval(req.IntervalDays, req.IntervalKm); err != nil {
+		return nil, err
+	}
+
+	interval := &models.ServiceInterval{
+		ServiceID:         req.ServiceID,
+		ServiceCategoryID: req.ServiceCategoryID,
+		IntervalDays:      req.IntervalDays,
+		IntervalKm:        req.IntervalKm,
+		IsActive:          true,
+		CreatedBy:         &userID,
+	}
+
+	if err := s.repos.Reminder.CreateInterval(interval); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Reminder.GetInterval(interval.IntervalID)
+}
+
+func (s *reminderService) UpdateInterval(id int64, req *models.UpdateServiceIntervalRequest) (*models.ServiceInterval, error) {
+	interval, err := s.repos.Reminder.GetInterval(id)
+	if err != nil {
+		return nil, errors.New("service interval not found")
+	}
+
+	if err := validateInterval(req.IntervalDays, req.IntervalKm); err != nil {
+		return nil, err
+	}
+
+	interval.IntervalDays = req.IntervalDays
+	interval.IntervalKm = req.IntervalKm
+	if req.IsActive != nil {
+		interval.IsActive = *req.IsActive
+	}
+
+	if err := s.repos.Reminder.UpdateInterval(interval); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Reminder.GetInterval(id)
+}
+
+func (s *reminderService) DeleteInterval(id int64) error {
+	return s.repos.Reminder.DeleteInterval(id)
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func validateInterval(days, km int) error {
+	if days < 0 || km < 0 {
+		return errors.New("interval cannot be negative")
+	}
+	if days == 0 && km == 0 {
+		return errors.New("interval_days or interval_km is required")
+	}
+	return nil
+}
+
+// RecordServiceCompletion updates the vehicle's last service date and odometer
+// and schedules the next service from the shortest interval among the job's services
+func (s *reminderService) RecordServiceCompletion(jobID int64) error {
+	schedule, err := s.repos.Reminder.GetJobVehicleSchedule(jobID)
+	if err != nil {
+		return err
+	}
+
+	intervals, err := s.repos.Reminder.GetJobIntervals(jobID)
+	if err != nil {
+		return err
+	}
+
+	intervalDays, intervalKm := s.cfg.Reminder.DefaultServiceIntervalDays, s.cfg.Reminder.DefaultServiceIntervalKm
+	if len(intervals) > 0 {
+		intervalDays, intervalKm = 0, 0
+		for _, interval := range intervals {
+			if interval.IntervalDays > 0 && (intervalDays == 0 || interval.IntervalDays < intervalDays) {
+				intervalDays = interval.IntervalDays
+			}
+			if interval.IntervalKm > 0 && (intervalKm == 0 || interval.IntervalKm < intervalKm) {
+				intervalKm = interval.IntervalKm
+			}
+		}
+	}
+
+	if schedule.Odometer != nil && *schedule.Odometer > schedule.Mileage {
+		schedule.Mileage = *schedule.Odometer
+	}
+
+	today := startOfDay(time.Now())
+	schedule.LastServiceDate = &today
+	schedule.NextServiceDate = nil
+	schedule.NextServiceMileage = nil
+
+	if intervalDays > 0 {
+		next := today.AddDate(0, 0, intervalDays)
+		schedule.NextServiceDate = &next
+	}
+	// Without a known odometer the mileage-based due point would be guesswork
+	if intervalKm > 0 && schedule.Mileage > 0 {
+		next := schedule.Mileage + int64(intervalKm)
+		schedule.NextServiceMileage = &next
+	}
+
+	return s.repos.Reminder.UpdateVehicleSchedule(schedule)
+}
+
+// Scan opens reminders for vehicles whose service, insurance or registration
+// is coming due and notifies their owners
+func (s *reminderService) Scan() (*models.ReminderScanResult, error) {
+	today := startOfDay(time.Now())
+
+	ids, err := s.repos.Reminder.GenerateServiceReminders(today, s.cfg.Reminder.ServiceDueWindowDays, s.cfg.Reminder.ServiceDueWindowKm)
+	if err != nil {
+		return nil, err
+	}
+
+	documentWindows := map[string]int{
+		models.ReminderTypeInsurance:    s.cfg.Reminder.InsuranceWindowDays,
+		models.ReminderTypeRegistration: s.cfg.Reminder.RegistrationWindowDays,
+	}
+	for reminderType, windowDays := range documentWindows {
+		documentIDs, err := s.repos.Reminder.GenerateDocumentReminders(today, reminderType, windowDays)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, documentIDs...)
+	}
+
+	result := &models.ReminderScanResult{Generated: len(ids)}
+
+	reminders, err := s.repos.Reminder.GetByIDs(ids)
+	if err != nil {
+		return result, err
+	}
+
+	for i := range reminders {
+		if err := s.notifier.NotifyReminder(&reminders[i]); err != nil {
+			log.Printf("Warning: failed to notify reminder %d: %v", reminders[i].ReminderID, err)
+			continue
+		}
+		if err := s.repos.Reminder.MarkNotified(reminders[i].ReminderID); err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		result.Notified++
+	}
+
+	return result, nil
+}
+
+func (s *reminderService) List(page, limit int, filter *models.ReminderFilter) ([]models.VehicleReminder, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	reminders, total, err := s.repos.Reminder.List(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reminders, paginationMeta(page, limit, total), nil
+}
+
+func (s *reminderService) UpdateStatus(id int64, req *models.UpdateReminderStatusRequest, outletID *int64, userID int64) (*models.VehicleReminder, error) {
+	reminder, err := s.repos.Reminder.GetByID(id)
+	if err != nil {
+		return nil, errors.New("reminder not found")
+	}
+
+	// Outlet users only work their own outlet's list, which includes
+	// reminders no outlet has taken on yet
+	if outletID != nil && reminder.OutletID != nil && *reminder.OutletID != *outletID {
+		return nil, errors.New("reminder not found")
+	}
+
+	if reminder.Status != "pending" && reminder.Status != "notified" && reminder.Status != "contacted" {
+		return nil, errors.New("reminder is already closed")
+	}
+
+	switch req.Status {
+	case "contacted", "dismissed":
+		err = s.repos.Reminder.UpdateStatus(id, req.Status, req.Notes, userID)
+	case "renewed":
+		if reminder.ReminderType == models.ReminderTypeService {
+			return nil, errors.New("service reminders are closed by completing a service job")
+		}
+
+		expiry, parseErr := time.Parse("2006-01-02", req.RenewedUntil)
+		if parseErr != nil {
+			return nil, errors.New("renewed_until must be a date in YYYY-MM-DD format")
+		}
+		if reminder.DueDate != nil && !expiry.After(*reminder.DueDate) {
+			return nil, errors.New("renewed_until must be after the current expiry date")
+		}
+
+		err = s.repos.Reminder.RenewDocument(reminder, expiry, req.Notes, userID)
+	default:
+		return nil, errors.New("invalid status")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repos.Reminder.GetByID(id)
+}
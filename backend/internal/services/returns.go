@@ -0,0 +1,210 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+)
+
+// ReturnService interface defines sales return, refund and credit note
+// operations
+type ReturnService interface {
+	CreateReturn(req *models.CreateSalesReturnRequest, userID int64) (*models.SalesReturn, error)
+	GetReturn(id int64) (*models.SalesReturn, error)
+	ListReturns(page, limit int, filter *models.SalesReturnFilter) ([]models.SalesReturn, *models.PaginationMeta, error)
+	CreateRefund(req *models.CreateRefundRequest, userID int64) (*models.Refund, error)
+	GetRefund(id int64) (*models.Refund, error)
+	ListRefunds(page, limit int, filter *models.RefundFilter) ([]models.Refund, *models.PaginationMeta, error)
+	GetCreditNote(id int64) (*models.CreditNote, error)
+	ListCreditNotes(page, limit int, filter *models.CreditNoteFilter) ([]models.CreditNote, *models.PaginationMeta, error)
+	ApplyCreditNote(creditNoteID int64, req *models.ApplyCreditNoteRequest, userID int64) (*models.CreditNote, error)
+	GetBalance(transactionID int64) (*models.TransactionBalance, error)
+}
+
+type returnService struct {
+	repos         *repositories.Repositories
+	eventBus      EventService
+	shiftRequired bool
+}
+
+// NewReturnService creates a new return service
+func NewReturnService(repos *repositories.Repositories, cfg *config.Config, eventBus EventService) ReturnService {
+	return &returnService{
+		repos:         repos,
+		eventBus:      eventBus,
+		shiftRequired: cfg.Shift.RequiredForPayments,
+	}
+}
+
+// refundShift returns the open shift cash refunds are paid out of. Refunds
+// follow the same shift rule as payments.
+func (s *returnService) refundShift(userID int64) (*int64, error) {
+	shift, err := s.repos.Shift.GetOpenByUser(userID)
+	if err == nil {
+		return &shift.ShiftID, nil
+	}
+	if s.shiftRequired {
+		return nil, errors.New("open a cashier shift before refunding")
+	}
+	return nil, nil
+}
+
+// CreateReturn returns sold spareparts, restocking them unless told not
+// to, and settles what the customer paid for them
+func (s *returnService) CreateReturn(req *models.CreateSalesReturnRequest, userID int64) (*models.SalesReturn, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, errors.New("reason is required")
+	}
+	if len(req.Items) == 0 {
+		return nil, errors.New("at least one item is required")
+	}
+
+	lines := make(map[int64]bool)
+	for _, item := range req.Items {
+		if lines[item.TransactionDetailID] {
+			return nil, errors.New("each transaction line can only be listed once")
+		}
+		lines[item.TransactionDetailID] = true
+
+		if !item.Quantity.IsPositive() {
+			return nil, errors.New("quantity must be greater than zero")
+		}
+		if !item.Quantity.IsInteger() {
+			return nil, errors.New("quantity must be a whole number")
+		}
+	}
+
+	var shiftID *int64
+	if req.Settlement == "refund" {
+		var err error
+		if shiftID, err = s.refundShift(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	ret := &models.SalesReturn{
+		Reason:     req.Reason,
+		Restock:    req.Restock == nil || *req.Restock,
+		ReturnDate: time.Now(),
+		CreatedBy:  &userID,
+	}
+	if req.Notes != "" {
+		ret.Notes = &req.Notes
+	}
+
+	if err := s.repos.Return.CreateReturn(ret, req, shiftID); err != nil {
+		return nil, err
+	}
+	s.eventBus.Wake()
+
+	return s.repos.Return.GetReturnByID(ret.ReturnID)
+}
+
+func (s *returnService) GetReturn(id int64) (*models.SalesReturn, error) {
+	return s.repos.Return.GetReturnByID(id)
+}
+
+func (s *returnService) ListReturns(page, limit int, filter *models.SalesReturnFilter) ([]models.SalesReturn, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	returns, total, err := s.repos.Return.ListReturns(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return returns, paginationMeta(page, limit, total), nil
+}
+
+// CreateRefund pays money back against a transaction or one of its payments
+func (s *returnService) CreateRefund(req *models.CreateRefundRequest, userID int64) (*models.Refund, error) {
+	if !req.Amount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, errors.New("reason is required")
+	}
+	if req.PaymentID == nil && req.PaymentMethodID == nil {
+		return nil, errors.New("payment or payment method is required")
+	}
+
+	shiftID, err := s.refundShift(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &models.Refund{
+		TransactionID: req.TransactionID,
+		PaymentID:     req.PaymentID,
+		Amount:        req.Amount.Round(2),
+		Reason:        req.Reason,
+		RefundDate:    time.Now(),
+		ShiftID:       shiftID,
+		CreatedBy:     &userID,
+	}
+	if req.PaymentMethodID != nil {
+		refund.PaymentMethodID = *req.PaymentMethodID
+	}
+	if req.ReferenceNumber != "" {
+		refund.ReferenceNumber = &req.ReferenceNumber
+	}
+	if req.Notes != "" {
+		refund.Notes = &req.Notes
+	}
+
+	if err := s.repos.Return.CreateRefund(refund); err != nil {
+		return nil, err
+	}
+	s.eventBus.Wake()
+
+	return s.repos.Return.GetRefundByID(refund.RefundID)
+}
+
+func (s *returnService) GetRefund(id int64) (*models.Refund, error) {
+	return s.repos.Return.GetRefundByID(id)
+}
+
+func (s *returnService) ListRefunds(page, limit int, filter *models.RefundFilter) ([]models.Refund, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	refunds, total, err := s.repos.Return.ListRefunds(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return refunds, paginationMeta(page, limit, total), nil
+}
+
+func (s *returnService) GetCreditNote(id int64) (*models.CreditNote, error) {
+	return s.repos.Return.GetCreditNoteByID(id)
+}
+
+func (s *returnService) ListCreditNotes(page, limit int, filter *models.CreditNoteFilter) ([]models.CreditNote, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	notes, total, err := s.repos.Return.ListCreditNotes(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return notes, paginationMeta(page, limit, total), nil
+}
+
+// ApplyCreditNote pays a transaction with the customer's store credit and
+// returns the credit note with what is left on it
+func (s *returnService) ApplyCreditNote(creditNoteID int64, req *models.ApplyCreditNoteRequest, userID int64) (*models.CreditNote, error) {
+	if req.Amount.IsNegative() {
+		return nil, errors.New("amount cannot be negative")
+	}
+
+	if _, err := s.repos.Return.ApplyCreditNote(creditNoteID, req.TransactionID, req.Amount.Round(2), userID); err != nil {
+		return nil, err
+	}
+	s.eventBus.Wake()
+
+	return s.repos.Return.GetCreditNoteByID(creditNoteID)
+}
+
+func (s *returnService) GetBalance(transactionID int64) (*models.TransactionBalance, error) {
+	return s.repos.Return.GetBalance(transactionID)
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+	"flutter-bengkel/internal/scheduler"
+)
+
+// SchedulerService interface defines the periodic background jobs and their run history
+type SchedulerService interface {
+	Start()
+	Stop(ctx context.Context) error
+
+	Jobs() ([]models.SchedulerJob, error)
+	RunNow(name string) error
+	Runs(page, limit int, filter *models.SchedulerRunFilter) ([]models.SchedulerRun, *models.PaginationMeta, error)
+}
+
+type schedulerService struct {
+	repos     *repositories.Repositories
+	cfg       *config.Config
+	reminders ReminderService
+	location  *time.Location
+	scheduler *scheduler.Scheduler
+}
+
+// businessLocation returns the timezone business days are counted in
+func businessLocation(cfg *config.Config) *time.Location {
+	location, err := time.LoadLocation(cfg.Scheduler.Timezone)
+	if err != nil {
+		log.Printf("Warning: unknown scheduler timezone %q, using local time: %v", cfg.Scheduler.Timezone, err)
+		return time.Local
+	}
+	return location
+}
+
+// NewSchedulerService creates the scheduler and registers the business jobs
+func NewSchedulerService(repos *repositories.Repositories, cfg *config.Config, reminders ReminderService) SchedulerService {
+	location := businessLocation(cfg)
+
+	s := &schedulerService{
+		repos:     repos,
+		cfg:       cfg,
+		reminders: reminders,
+		location:  location,
+		scheduler: scheduler.New(repos.Scheduler, location),
+	}
+
+	jobs := []scheduler.Job{
+		{
+			Name:        "reminders.scan",
+			Spec:        cfg.Scheduler.ReminderScanSchedule,
+			Description: "Open service, insurance and registration reminders and notify owners",
+			Timeout:     30 * time.Minute,
+			Run:         s.scanReminders,
+		},
+		{
+			Name:        "finance.mark_overdue",
+			Spec:        cfg.Scheduler.OverdueSchedule,
+			Description: "Mark unpaid receivables and payables past their due date as overdue",
+			Run:         s.markOverdue,
+		},
+		{
+			Name:        "cash.daily_summary",
+			Spec:        cfg.Scheduler.CashSummarySchedule,
+			Description: "Write the previous day's provisional cash summary for every outlet not yet closed",
+			Run:         s.closeCashSummaries,
+		},
+		{
+			Name:        "loyalty.expire_points",
+			Spec:        cfg.Scheduler.LoyaltyExpirySchedule,
+			Description: "Expire loyalty points past their expiry date that have not been redeemed",
+			Run:         s.expireLoyaltyPoints,
+		},
+		{
+			Name:        "membership.evaluate_tiers",
+			Spec:        cfg.Scheduler.MembershipSchedule,
+			Description: "Move customers up or down membership tiers by their rolling 12-month spend",
+			Run:         s.evaluateMembershipTiers,
+		},
+		{
+			Name:        "stored_value.expire",
+			Spec:        cfg.Scheduler.StoredValueSchedule,
+			Description: "Lapse what is left on deposits and vouchers past their expiry date",
+			Run:         s.expireStoredValue,
+		},
+		{
+			Name:        "maintenance.purge",
+			Spec:        cfg.Scheduler.PurgeSchedule,
+			Description: "Delete processed events, finished webhook deliveries, sent notifications and old job runs",
+			Run:         s.purge,
+		},
+	}
+	for _, job := range jobs {
+		if err := s.scheduler.Register(job); err != nil {
+			log.Fatalf("Failed to register scheduled job %s: %v", job.Name, err)
+		}
+	}
+
+	return s
+}
+
+// Start runs jobs on their schedules unless the scheduler is disabled
+func (s *schedulerService) Start() {
+	if !s.cfg.Scheduler.Enabled {
+		log.Println("Scheduler disabled, periodic jobs will only run on demand")
+		return
+	}
+	s.scheduler.Start()
+}
+
+// Stop stops scheduling and waits for running jobs until ctx ends
+func (s *schedulerService) Stop(ctx context.Context) error {
+	return s.scheduler.Stop(ctx)
+}
+
+func (s *schedulerService) Jobs() ([]models.SchedulerJob, error) {
+	lastRuns, err := s.repos.Scheduler.GetLastRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := s.scheduler.Jobs()
+	jobs := make([]models.SchedulerJob, 0, len(infos))
+	for _, info := range infos {
+		job := models.SchedulerJob{
+			Name:        info.Name,
+			Schedule:    info.Spec,
+			Description: info.Description,
+			NextRunAt:   info.NextRunAt,
+			Running:     info.Running,
+		}
+		if run, ok := lastRuns[info.Name]; ok {
+			job.LastRun = &run
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+func (s *schedulerService) RunNow(name string) error {
+	err := s.scheduler.RunNow(name)
+	if errors.Is(err, scheduler.ErrUnknownJob) {
+		return errors.New("scheduled job not found")
+	}
+	if errors.Is(err, scheduler.ErrStopped) {
+		return errors.New("scheduler is shutting down")
+	}
+	return err
+}
+
+func (s *schedulerService) Runs(page, limit int, filter *models.SchedulerRunFilter) ([]models.SchedulerRun, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	runs, total, err := s.repos.Scheduler.ListRuns(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return runs, paginationMeta(page, limit, total), nil
+}
+
+func (s *schedulerService) scanReminders(ctx context.Context) (string, error) {
+	result, err := s.reminders.Scan()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("generated %d reminders, notified %d", result.Generated, result.Notified), nil
+}
+
+func (s *schedulerService) markOverdue(ctx context.Context) (string, error) {
+	today := time.Now().In(s.location)
+
+	receivables, err := s.repos.Finance.MarkOverdueReceivables(today)
+	if err != nil {
+		return "", err
+	}
+
+	payables, err := s.repos.Finance.MarkOverduePayables(today)
+	if err != nil {
+		return fmt.Sprintf("%d receivables overdue", receivables), err
+	}
+
+	return fmt.Sprintf("%d receivables and %d payables overdue", receivables, payables), nil
+}
+
+func (s *schedulerService) closeCashSummaries(ctx context.Context) (string, error) {
+	yesterday := time.Now().In(s.location).AddDate(0, 0, -1)
+
+	outlets, err := s.repos.Finance.SummarizeDailyCash(yesterday)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("summarized %s for %d outlets", yesterday.Format("2006-01-02"), outlets), nil
+}
+
+func (s *schedulerService) expireLoyaltyPoints(ctx context.Context) (string, error) {
+	result, err := s.repos.Loyalty.ExpirePoints(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("expired %d points of %d customers", result.Points, result.Customers), nil
+}
+
+func (s *schedulerService) evaluateMembershipTiers(ctx context.Context) (string, error) {
+	result, err := s.repos.Membership.EvaluateAll(membershipSince(time.Now()))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("evaluated %d customers: %d upgraded, %d downgraded", result.Customers, result.Upgraded, result.Downgraded), nil
+}
+
+func (s *schedulerService) expireStoredValue(ctx context.Context) (string, error) {
+	result, err := s.repos.StoredValue.Expire(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("lapsed %s on %d deposits and vouchers", result.Amount.StringFixed(2), result.Accounts), nil
+}
+
+// purge removes outbox and history rows older than the retention period
+func (s *schedulerService) purge(ctx context.Context) (string, error) {
+	before := time.Now().AddDate(0, 0, -s.cfg.Scheduler.RetentionDays)
+
+	steps := []struct {
+		name  string
+		purge func(time.Time) (int64, error)
+	}{
+		{"domain events", s.repos.Event.PurgeProcessed},
+		{"webhook deliveries", s.repos.Webhook.PurgeDeliveries},
+		{"notifications", s.repos.Notification.PurgeSent},
+		{"job runs", s.repos.Scheduler.PurgeRuns},
+	}
+
+	summary := ""
+	for _, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		deleted, err := step.purge(before)
+		if err != nil {
+			return summary, err
+		}
+
+		if summary != "" {
+			summary += ", "
+		}
+		summary += fmt.Sprintf("%d %s", deleted, step.name)
+	}
+
+	return "deleted " + summary, nil
+}
@@ -0,0 +1,380 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"flutter-bengkel/internal/bundle"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+
+	"github.com/shopspring/decimal"
+)
+
+// ServicePackageService interface defines service package operations
+type ServicePackageService interface {
+	List(page, limit int, filter *models.ServicePackageFilter) ([]models.ServicePackage, *models.PaginationMeta, error)
+	GetByID(id int64) (*models.ServicePackage, error)
+	Create(req *models.ServicePackageRequest, userID int64) (*models.ServicePackage, error)
+	Update(id int64, req *models.ServicePackageRequest) (*models.ServicePackage, error)
+	GetSales(filter *models.PackageSalesFilter) ([]models.PackageSales, error)
+}
+
+type servicePackageService struct {
+	repos *repositories.Repositories
+}
+
+// NewServicePackageService creates a new service package service
+func NewServicePackageService(repos *repositories.Repositories) ServicePackageService {
+	return &servicePackageService{repos: repos}
+}
+
+func (s *servicePackageService) List(page, limit int, filter *models.ServicePackageFilter) ([]models.ServicePackage, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	packages, total, err := s.repos.ServicePackage.List(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range packages {
+		components, err := s.repos.ServicePackage.ListComponents(packages[i].PackageID)
+		if err != nil {
+			return nil, nil, err
+		}
+		packages[i].Components = components
+		pricePackage(&packages[i])
+	}
+
+	return packages, paginationMeta(page, limit, total), nil
+}
+
+func (s *servicePackageService) GetByID(id int64) (*models.ServicePackage, error) {
+	pkg, err := s.repos.ServicePackage.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	pricePackage(pkg)
+
+	return pkg, nil
+}
+
+func (s *servicePackageService) Create(req *models.ServicePackageRequest, userID int64) (*models.ServicePackage, error) {
+	pkg := &models.ServicePackage{IsActive: true, CreatedBy: &userID}
+	if err := s.applyRequest(pkg, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.ServicePackage.Create(pkg); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(pkg.PackageID)
+}
+
+// Update changes a package. Transactions and service jobs it was already
+// added to keep their lines and prices.
+func (s *servicePackageService) Update(id int64, req *models.ServicePackageRequest) (*models.ServicePackage, error) {
+	pkg, err := s.repos.ServicePackage.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(pkg, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.ServicePackage.Update(pkg); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(id)
+}
+
+// applyRequest validates a request and copies it onto a package
+func (s *servicePackageService) applyRequest(pkg *models.ServicePackage, req *models.ServicePackageRequest) error {
+	code := strings.ToUpper(strings.TrimSpace(req.PackageCode))
+	if code == "" {
+		return errors.New("package_code is required")
+	}
+	if existing, err := s.repos.ServicePackage.GetByCode(code); err == nil && existing.PackageID != pkg.PackageID {
+		return errors.New("package code already exists")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+
+	pricingType := req.PricingType
+	if pricingType == "" {
+		pricingType = bundle.PricingFixed
+	}
+	switch pricingType {
+	case bundle.PricingFixed:
+		if req.PackagePrice == nil || req.PackagePrice.IsNegative() {
+			return errors.New("package_price is required and cannot be negative")
+		}
+	case bundle.PricingDiscount:
+		if req.DiscountPercent == nil || !req.DiscountPercent.IsPositive() ||
+			req.DiscountPercent.GreaterThan(decimal.NewFromInt(100)) {
+			return errors.New("discount_percent must be greater than 0 and at most 100")
+		}
+	default:
+		return errors.New("pricing_type must be fixed or discount")
+	}
+
+	if len(req.Components) == 0 {
+		return errors.New("a package needs at least one component")
+	}
+	components := make([]models.ServicePackageComponent, len(req.Components))
+	for i, component := range req.Components {
+		if (component.ProductID == nil) == (component.ServiceID == nil) {
+			return errors.New("each component needs either product_id or service_id")
+		}
+		if !component.Quantity.IsPositive() {
+			return errors.New("component quantity must be greater than 0")
+		}
+		if component.ProductID != nil {
+			if _, err := s.repos.Product.GetByID(*component.ProductID); err != nil {
+				return errors.New("product not found")
+			}
+			// Products leave stock in whole units
+			if !component.Quantity.IsInteger() {
+				return errors.New("product quantity must be a whole number")
+			}
+		}
+		if component.ServiceID != nil {
+			if _, err := s.repos.Service.GetByID(*component.ServiceID); err != nil {
+				return errors.New("service not found")
+			}
+		}
+		components[i] = models.ServicePackageComponent{
+			ProductID: component.ProductID,
+			ServiceID: component.ServiceID,
+			Quantity:  component.Quantity,
+		}
+	}
+
+	pkg.PackageCode = code
+	pkg.Name = name
+	pkg.Description = nil
+	if description := strings.TrimSpace(req.Description); description != "" {
+		pkg.Description = &description
+	}
+	pkg.PricingType = pricingType
+	pkg.PackagePrice = nil
+	pkg.DiscountPercent = nil
+	if pricingType == bundle.PricingFixed {
+		pkg.PackagePrice = req.PackagePrice
+	} else {
+		pkg.DiscountPercent = req.DiscountPercent
+	}
+	pkg.OutletID = req.OutletID
+	if req.IsActive != nil {
+		pkg.IsActive = *req.IsActive
+	}
+	pkg.Components = components
+
+	return nil
+}
+
+// GetSales reports the packages sold over a period and how their revenue
+// was allocated to their components
+func (s *servicePackageService) GetSales(filter *models.PackageSalesFilter) ([]models.PackageSales, error) {
+	if filter.DateTo.Before(filter.DateFrom) {
+		return nil, errors.New("date_to cannot be before date_from")
+	}
+
+	return s.repos.ServicePackage.GetSales(filter)
+}
+
+// pricePackage works out what a package's components cost at today's list
+// prices and what one package sells for
+func pricePackage(pkg *models.ServicePackage) {
+	components := make([]bundle.Component, len(pkg.Components))
+	for i, component := range pkg.Components {
+		components[i] = bundle.Component{Quantity: component.Quantity, ListPrice: component.ListPrice}
+	}
+
+	var packagePrice, discountPercent decimal.Decimal
+	if pkg.PackagePrice != nil {
+		packagePrice = *pkg.PackagePrice
+	}
+	if pkg.DiscountPercent != nil {
+		discountPercent = *pkg.DiscountPercent
+	}
+
+	pkg.ListAmount = bundle.ListAmount(components)
+	pkg.Price = bundle.Price(pkg.PricingType, packagePrice, discountPercent, pkg.ListAmount)
+}
+
+// packageLine is one component line of a package added to a document,
+// priced at its share of the package
+type packageLine struct {
+	ProductID  *int64
+	ServiceID  *int64
+	Name       string
+	Quantity   float64
+	UnitPrice  float64
+	TotalPrice float64
+	ListPrice  float64
+}
+
+// expandPackage prices quantity packages at an outlet and splits them into
+// their component lines. Each line's share of the package amount is in
+// proportion to its list amount, so the shares add up to the package amount.
+func expandPackage(repos *repositories.Repositories, packageID, outletID int64, quantity decimal.Decimal) (*models.DocumentPackage, []packageLine, error) {
+	if !quantity.IsPositive() || !quantity.IsInteger() {
+		return nil, nil, errors.New("package quantity must be a whole number greater than 0")
+	}
+
+	pkg, err := repos.ServicePackage.GetByID(packageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !pkg.IsActive || (pkg.OutletID != nil && *pkg.OutletID != outletID) {
+		return nil, nil, errors.New("service package is not available at this outlet")
+	}
+	if len(pkg.Components) == 0 {
+		return nil, nil, errors.New("service package has no components")
+	}
+	pricePackage(pkg)
+
+	amount := pkg.Price.Mul(quantity).Round(2)
+	weights := make([]decimal.Decimal, len(pkg.Components))
+	for i, component := range pkg.Components {
+		weights[i] = component.Quantity.Mul(component.ListPrice)
+	}
+	shares := bundle.Allocate(amount, weights)
+
+	lines := make([]packageLine, len(pkg.Components))
+	for i, component := range pkg.Components {
+		lineQuantity := component.Quantity.Mul(quantity)
+		lines[i] = packageLine{
+			ProductID:  component.ProductID,
+			ServiceID:  component.ServiceID,
+			Name:       component.ItemName,
+			Quantity:   lineQuantity.InexactFloat64(),
+			UnitPrice:  shares[i].Div(lineQuantity).Round(2).InexactFloat64(),
+			TotalPrice: shares[i].InexactFloat64(),
+			ListPrice:  component.ListPrice.InexactFloat64(),
+		}
+	}
+
+	return &models.DocumentPackage{
+		PackageID:     pkg.PackageID,
+		PackageCode:   pkg.PackageCode,
+		PackageName:   pkg.Name,
+		Quantity:      quantity,
+		ListAmount:    pkg.ListAmount.Mul(quantity).Round(2),
+		PackageAmount: amount,
+	}, lines, nil
+}
+
+// expandTransactionDetails turns the lines requested for a transaction into
+// its lines, expanding each package into its component lines
+func expandTransactionDetails(repos *repositories.Repositories, outletID int64, requests []models.CreateTransactionDetailRequest) ([]models.TransactionDetail, []models.DocumentPackage, error) {
+	var details []models.TransactionDetail
+	var packages []models.DocumentPackage
+	for _, req := range requests {
+		if req.PackageID == nil {
+			details = append(details, models.TransactionDetail{
+				ProductID:   req.ProductID,
+				ServiceID:   req.ServiceID,
+				Description: req.Description,
+				Quantity:    req.Quantity,
+				UnitPrice:   req.UnitPrice,
+				TotalPrice:  req.Quantity * req.UnitPrice,
+				TaxCodeID:   req.TaxCodeID,
+			})
+			continue
+		}
+
+		pkg, lines, err := expandPackage(repos, *req.PackageID, outletID, decimal.NewFromFloat(req.Quantity))
+		if err != nil {
+			return nil, nil, err
+		}
+		description := req.Description
+		if description == "" {
+			description = pkg.PackageName
+		}
+
+		index := len(packages)
+		packages = append(packages, *pkg)
+		for _, line := range lines {
+			listPrice := line.ListPrice
+			details = append(details, models.TransactionDetail{
+				ProductID:    line.ProductID,
+				ServiceID:    line.ServiceID,
+				Description:  description,
+				Quantity:     line.Quantity,
+				UnitPrice:    line.UnitPrice,
+				TotalPrice:   line.TotalPrice,
+				TaxCodeID:    req.TaxCodeID,
+				ListPrice:    &listPrice,
+				PackageIndex: &index,
+			})
+		}
+	}
+
+	return details, packages, nil
+}
+
+// serviceJobInvoiceDetails returns a service job's lines and packages as
+// lines of its invoice, at the prices agreed on the job. The lines requested
+// may only add what the job does not already have, so nothing on the job is
+// sold, or taken from stock, twice.
+func serviceJobInvoiceDetails(repos *repositories.Repositories, serviceJobID int64, requests []models.CreateTransactionDetailRequest) ([]models.TransactionDetail, []models.DocumentPackage, error) {
+	jobLines, err := repos.ServiceJob.GetInvoiceLines(serviceJobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	jobPackages, err := repos.ServicePackage.GetServiceJobPackages(serviceJobID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, req := range requests {
+		if req.PackageID != nil {
+			for _, pkg := range jobPackages {
+				if pkg.PackageID == *req.PackageID {
+					return nil, nil, errors.New("the service job's packages are invoiced from the job and cannot be sent again")
+				}
+			}
+			continue
+		}
+		for _, line := range jobLines {
+			if line.PackageLineID == nil && sameItem(line.ProductID, req.ProductID) && sameItem(line.ServiceID, req.ServiceID) {
+				return nil, nil, errors.New("the service job's lines are invoiced from the job and cannot be sent again")
+			}
+		}
+	}
+
+	packages := make([]models.DocumentPackage, len(jobPackages))
+	indexes := make(map[int64]int, len(jobPackages))
+	for i, pkg := range jobPackages {
+		indexes[pkg.DocumentPackageID] = i
+		packages[i] = pkg
+	}
+
+	details := make([]models.TransactionDetail, len(jobLines))
+	for i, line := range jobLines {
+		details[i] = models.TransactionDetail{
+			ProductID:   line.ProductID,
+			ServiceID:   line.ServiceID,
+			Description: line.Notes,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			TotalPrice:  line.TotalPrice,
+			TaxCodeID:   line.TaxCodeID,
+			ListPrice:   line.ListPrice,
+			PriceListID: line.PriceListID,
+		}
+		if line.PackageLineID != nil {
+			if index, ok := indexes[*line.PackageLineID]; ok {
+				details[i].PackageIndex = &index
+			}
+		}
+	}
+
+	return details, packages, nil
+}
@@ -1,7 +1,11 @@
 package services
 
 import (
+	"log"
+
 	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/notifications"
+	"flutter-bengkel/internal/realtime"
 	"flutter-bengkel/internal/repositories"
 )
 
@@ -17,20 +21,76 @@ type Services struct {
 	Transaction    TransactionService
 	Payment        PaymentService
 	VehicleTrading VehicleTradingService
+	Queue          QueueService
+	Display        DisplayService
+	Tracking       TrackingService
+	Reminder       ReminderService
+	Notification   NotificationService
+	Event          EventService
+	Webhook        WebhookService
+	Scheduler      SchedulerService
+	Ledger         LedgerService
+	Cash           CashService
+	Shift          ShiftService
+	Return         ReturnService
+	Tax            TaxService
+	Promotion      PromotionService
+	Approval       ApprovalService
+	Loyalty        LoyaltyService
+	Membership     MembershipService
+	StoredValue    StoredValueService
+	ServicePackage ServicePackageService
+	PriceList      PriceListService
+	Costing        CostingService
+	Realtime       *realtime.Hub
 }
 
 // New creates a new services instance
 func New(repos *repositories.Repositories, cfg *config.Config) *Services {
+	hub := realtime.NewHub()
+	eventBus := NewEventService(repos, cfg)
+	queue := NewQueueService(repos, cfg, hub)
+
+	renderer, err := notifications.NewRenderer(cfg.Notification.DefaultLanguage)
+	if err != nil {
+		log.Fatal("Failed to load notification templates:", err)
+	}
+	notifier := NewNotificationService(repos, cfg, renderer, NewNotificationTransport(cfg))
+	reminders := NewReminderService(repos, cfg, notifier)
+	serviceJobs := NewServiceJobService(repos, queue, reminders, notifier, hub, eventBus)
+
 	return &Services{
 		Auth:           NewAuthService(repos, cfg),
 		User:           NewUserService(repos),
 		Customer:       NewCustomerService(repos),
 		Vehicle:        NewVehicleService(repos),
 		Service:        NewServiceService(repos),
-		Product:        NewProductService(repos),
-		ServiceJob:     NewServiceJobService(repos),
-		Transaction:    NewTransactionService(repos),
-		Payment:        NewPaymentService(repos),
-		VehicleTrading: NewVehicleTradingService(repos),
+		Product:        NewProductService(repos, eventBus),
+		ServiceJob:     serviceJobs,
+		Transaction:    NewTransactionService(repos, cfg, eventBus),
+		Payment:        NewPaymentService(repos, cfg, notifier, hub, eventBus),
+		VehicleTrading: NewVehicleTradingService(repos, eventBus),
+		Queue:          queue,
+		Display:        NewDisplayService(repos, queue),
+		Tracking:       NewTrackingService(repos, queue),
+		Reminder:       reminders,
+		Notification:   notifier,
+		Event:          eventBus,
+		Webhook:        NewWebhookService(repos, cfg, eventBus),
+		Scheduler:      NewSchedulerService(repos, cfg, reminders),
+		Ledger:         NewLedgerService(repos, eventBus),
+		Cash:           NewCashService(repos, cfg),
+		Shift:          NewShiftService(repos),
+		Return:         NewReturnService(repos, cfg, eventBus),
+		Tax:            NewTaxService(repos),
+		Promotion:      NewPromotionService(repos),
+		Approval:       NewApprovalService(repos, serviceJobs),
+		Loyalty:        NewLoyaltyService(repos, eventBus),
+		Membership:     NewMembershipService(repos, eventBus),
+		StoredValue:    NewStoredValueService(repos, cfg),
+		ServicePackage: NewServicePackageService(repos),
+		PriceList:      NewPriceListService(repos),
+		Costing:        NewCostingService(repos, eventBus),
+		Realtime:       hub,
 	}
 }
\ No newline at end of file
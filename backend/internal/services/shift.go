@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+)
+
+// ShiftService interface defines cashier shift operations
+type ShiftService interface {
+	Open(req *models.OpenShiftRequest, outletID, userID int64) (*models.CashierShift, error)
+	GetCurrent(userID int64) (*models.CashierShift, error)
+	GetByID(id int64) (*models.CashierShift, error)
+	List(page, limit int, filter *models.ShiftFilter) ([]models.CashierShift, *models.PaginationMeta, error)
+	Close(shiftID int64, req *models.CloseShiftRequest, userID int64) (*models.ShiftReport, error)
+	Approve(shiftID int64, req *models.ApproveShiftRequest, userID int64) (*models.CashierShift, error)
+	GetReport(shiftID int64) (*models.ShiftReport, error)
+}
+
+type shiftService struct {
+	repos *repositories.Repositories
+}
+
+// NewShiftService creates a new shift service
+func NewShiftService(repos *repositories.Repositories) ShiftService {
+	return &shiftService{repos: repos}
+}
+
+// Open starts a shift for the user with the float placed in the drawer
+func (s *shiftService) Open(req *models.OpenShiftRequest, outletID, userID int64) (*models.CashierShift, error) {
+	if req.OpeningFloat.IsNegative() {
+		return nil, errors.New("opening float cannot be negative")
+	}
+
+	shift := &models.CashierShift{
+		OutletID:     outletID,
+		UserID:       userID,
+		OpeningFloat: req.OpeningFloat.Round(2),
+	}
+	if req.Notes != "" {
+		shift.OpeningNotes = &req.Notes
+	}
+
+	if err := s.repos.Shift.Open(shift); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Shift.GetByID(shift.ShiftID)
+}
+
+func (s *shiftService) GetCurrent(userID int64) (*models.CashierShift, error) {
+	return s.repos.Shift.GetOpenByUser(userID)
+}
+
+func (s *shiftService) GetByID(id int64) (*models.CashierShift, error) {
+	return s.repos.Shift.GetByID(id)
+}
+
+func (s *shiftService) List(page, limit int, filter *models.ShiftFilter) ([]models.CashierShift, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	shifts, total, err := s.repos.Shift.List(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return shifts, paginationMeta(page, limit, total), nil
+}
+
+// Close records the counted drawer and returns the reconciled shift report
+func (s *shiftService) Close(shiftID int64, req *models.CloseShiftRequest, userID int64) (*models.ShiftReport, error) {
+	denominations := make(map[string]bool)
+	for _, count := range req.Denominations {
+		if !count.Denomination.IsPositive() {
+			return nil, errors.New("denomination must be greater than zero")
+		}
+		if count.Quantity < 0 {
+			return nil, errors.New("denomination quantity cannot be negative")
+		}
+		key := count.Denomination.StringFixed(2)
+		if denominations[key] {
+			return nil, fmt.Errorf("denomination %s is counted twice", key)
+		}
+		denominations[key] = true
+	}
+
+	methods := make(map[int64]bool)
+	for _, count := range req.Tenders {
+		if count.CountedAmount.IsNegative() {
+			return nil, errors.New("counted amount cannot be negative")
+		}
+		if methods[count.PaymentMethodID] {
+			return nil, fmt.Errorf("payment method %d is counted twice", count.PaymentMethodID)
+		}
+		methods[count.PaymentMethodID] = true
+	}
+
+	if err := s.repos.Shift.Close(shiftID, userID, req); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Shift.GetReport(shiftID)
+}
+
+// Approve signs off a closed shift. Cashiers cannot sign off their own.
+func (s *shiftService) Approve(shiftID int64, req *models.ApproveShiftRequest, userID int64) (*models.CashierShift, error) {
+	shift, err := s.repos.Shift.GetByID(shiftID)
+	if err != nil {
+		return nil, err
+	}
+	if shift.UserID == userID {
+		return nil, errors.New("cannot approve your own shift")
+	}
+
+	if err := s.repos.Shift.Approve(shiftID, userID, req.Notes); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Shift.GetByID(shiftID)
+}
+
+func (s *shiftService) GetReport(shiftID int64) (*models.ShiftReport, error) {
+	return s.repos.Shift.GetReport(shiftID)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+	"flutter-bengkel/internal/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// voucherCodeLength is the length of generated voucher codes. Vouchers are
+// worth money, so they are longer than coupon codes.
+const voucherCodeLength = 12
+
+// StoredValueService interface defines customer deposit and gift voucher
+// operations
+type StoredValueService interface {
+	List(page, limit int, filter *models.StoredValueFilter) ([]models.StoredValueAccount, *models.PaginationMeta, error)
+	GetByID(id int64) (*models.StoredValueAccount, error)
+	GetByCode(code string) (*models.StoredValueAccount, error)
+	GetDeposit(customerID int64) (*models.StoredValueAccount, error)
+	ListEntries(accountID int64, page, limit int) ([]models.StoredValueEntry, *models.PaginationMeta, error)
+	TopUp(accountID int64, req *models.StoredValueTopUpRequest, outletID, userID int64) (*models.StoredValueAccount, error)
+	TopUpDeposit(customerID int64, req *models.StoredValueTopUpRequest, outletID, userID int64) (*models.StoredValueAccount, error)
+	IssueVoucher(req *models.IssueVoucherRequest, outletID, userID int64) (*models.StoredValueAccount, error)
+}
+
+type storedValueService struct {
+	repos         *repositories.Repositories
+	shiftRequired bool
+}
+
+// NewStoredValueService creates a new stored value service
+func NewStoredValueService(repos *repositories.Repositories, cfg *config.Config) StoredValueService {
+	return &storedValueService{
+		repos:         repos,
+		shiftRequired: cfg.Shift.RequiredForPayments,
+	}
+}
+
+// topUpShift returns the open shift a top-up is paid into. Top-ups follow
+// the same shift rule as payments.
+func (s *storedValueService) topUpShift(userID int64) (*int64, error) {
+	shift, err := s.repos.Shift.GetOpenByUser(userID)
+	if err == nil {
+		return &shift.ShiftID, nil
+	}
+	if s.shiftRequired {
+		return nil, errors.New("open a cashier shift before selling stored value")
+	}
+	return nil, nil
+}
+
+func (s *storedValueService) List(page, limit int, filter *models.StoredValueFilter) ([]models.StoredValueAccount, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	accounts, total, err := s.repos.StoredValue.List(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return accounts, paginationMeta(page, limit, total), nil
+}
+
+func (s *storedValueService) GetByID(id int64) (*models.StoredValueAccount, error) {
+	return s.repos.StoredValue.GetByID(id)
+}
+
+func (s *storedValueService) GetByCode(code string) (*models.StoredValueAccount, error) {
+	return s.repos.StoredValue.GetByCode(strings.TrimSpace(code))
+}
+
+func (s *storedValueService) GetDeposit(customerID int64) (*models.StoredValueAccount, error) {
+	account, err := s.repos.StoredValue.GetDeposit(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, errors.New("customer has no deposit")
+	}
+
+	return account, nil
+}
+
+func (s *storedValueService) ListEntries(accountID int64, page, limit int) ([]models.StoredValueEntry, *models.PaginationMeta, error) {
+	if _, err := s.repos.StoredValue.GetByID(accountID); err != nil {
+		return nil, nil, err
+	}
+
+	offset := (page - 1) * limit
+	entries, total, err := s.repos.StoredValue.ListEntries(accountID, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entries, paginationMeta(page, limit, total), nil
+}
+
+// TopUp adds value paid in at the outlet to a deposit or multi-use voucher
+func (s *storedValueService) TopUp(accountID int64, req *models.StoredValueTopUpRequest, outletID, userID int64) (*models.StoredValueAccount, error) {
+	entry, err := s.topUpEntry(req.Amount.Round(2), req.PaymentMethodID, req.ExpiresAt, req.Notes, outletID, userID)
+	if err != nil {
+		return nil, err
+	}
+	entry.AccountID = accountID
+
+	if err := s.repos.StoredValue.TopUp(entry, req.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return s.repos.StoredValue.GetByID(accountID)
+}
+
+// TopUpDeposit adds value to a customer's deposit, opening it on their
+// first top-up
+func (s *storedValueService) TopUpDeposit(customerID int64, req *models.StoredValueTopUpRequest, outletID, userID int64) (*models.StoredValueAccount, error) {
+	entry, err := s.topUpEntry(req.Amount.Round(2), req.PaymentMethodID, req.ExpiresAt, req.Notes, outletID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.repos.StoredValue.OpenDeposit(customerID, outletID, userID)
+	if err != nil {
+		return nil, err
+	}
+	entry.AccountID = account.AccountID
+
+	if err := s.repos.StoredValue.TopUp(entry, req.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return s.repos.StoredValue.GetByID(account.AccountID)
+}
+
+// IssueVoucher sells a gift voucher for the amount paid. Without a code one
+// is generated; codes are unique regardless of case.
+func (s *storedValueService) IssueVoucher(req *models.IssueVoucherRequest, outletID, userID int64) (*models.StoredValueAccount, error) {
+	entry, err := s.topUpEntry(req.Amount.Round(2), req.PaymentMethodID, req.ExpiresAt, nil, outletID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if code == "" {
+		if code, err = utils.GenerateRandomString(voucherCodeLength); err != nil {
+			return nil, errors.New("failed to generate voucher code")
+		}
+		code = strings.ToUpper(code)
+	}
+	if _, err := s.repos.StoredValue.GetByCode(code); err == nil {
+		return nil, errors.New("voucher code already exists")
+	}
+
+	if req.CustomerID != nil {
+		if _, err := s.repos.Customer.GetByID(*req.CustomerID); err != nil {
+			return nil, errors.New("customer not found")
+		}
+	}
+
+	account := &models.StoredValueAccount{
+		CustomerID: req.CustomerID,
+		Code:       &code,
+		SingleUse:  req.SingleUse,
+		ExpiresAt:  req.ExpiresAt,
+		OutletID:   outletID,
+		Notes:      req.Notes,
+		CreatedBy:  &userID,
+	}
+	if err := s.repos.StoredValue.IssueVoucher(account, entry); err != nil {
+		return nil, err
+	}
+
+	return s.repos.StoredValue.GetByID(account.AccountID)
+}
+
+// topUpEntry validates a top-up and puts it in the cashier's open shift
+func (s *storedValueService) topUpEntry(amount decimal.Decimal, paymentMethodID int64, expiresAt *time.Time, notes *string, outletID, userID int64) (*models.StoredValueEntry, error) {
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be greater than 0")
+	}
+	if paymentMethodID == 0 {
+		return nil, errors.New("payment_method_id is required")
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	shiftID, err := s.topUpShift(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StoredValueEntry{
+		Amount:          amount,
+		OutletID:        outletID,
+		PaymentMethodID: &paymentMethodID,
+		ShiftID:         shiftID,
+		Description:     notes,
+		CreatedBy:       &userID,
+	}, nil
+}
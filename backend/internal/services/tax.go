@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+	"flutter-bengkel/internal/tax"
+
+	"github.com/shopspring/decimal"
+)
+
+// TaxService interface defines tax code, outlet tax setting and tax report
+// operations
+type TaxService interface {
+	ListCodes(activeOnly bool) ([]models.TaxCode, error)
+	GetCode(id int64) (*models.TaxCode, error)
+	CreateCode(req *models.CreateTaxCodeRequest, userID int64) (*models.TaxCode, error)
+	UpdateCode(id int64, req *models.UpdateTaxCodeRequest) (*models.TaxCode, error)
+	AssignCode(id int64, req *models.AssignTaxCodeRequest) (int64, error)
+	GetOutletSettings(outletID int64) (*models.OutletTaxSettings, error)
+	UpdateOutletSettings(outletID int64, req *models.UpdateOutletTaxSettingsRequest) (*models.OutletTaxSettings, error)
+	GetTransactionSummary(transactionID int64) ([]models.TaxSummaryLine, error)
+	GetReport(filter *models.TaxReportFilter) (*models.TaxReport, error)
+}
+
+type taxService struct {
+	repos *repositories.Repositories
+}
+
+// NewTaxService creates a new tax service
+func NewTaxService(repos *repositories.Repositories) TaxService {
+	return &taxService{repos: repos}
+}
+
+func validateTaxRate(rate decimal.Decimal) error {
+	if rate.IsNegative() || rate.GreaterThanOrEqual(decimal.NewFromInt(100)) {
+		return errors.New("rate must be at least 0 and below 100 percent")
+	}
+	return nil
+}
+
+func (s *taxService) ListCodes(activeOnly bool) ([]models.TaxCode, error) {
+	return s.repos.Tax.ListCodes(activeOnly)
+}
+
+func (s *taxService) GetCode(id int64) (*models.TaxCode, error) {
+	return s.repos.Tax.GetCodeByID(id)
+}
+
+func (s *taxService) CreateCode(req *models.CreateTaxCodeRequest, userID int64) (*models.TaxCode, error) {
+	code := &models.TaxCode{
+		Code:      strings.ToUpper(strings.TrimSpace(req.Code)),
+		Name:      strings.TrimSpace(req.Name),
+		Rate:      req.Rate,
+		IsActive:  true,
+		CreatedBy: &userID,
+	}
+	if code.Code == "" {
+		return nil, errors.New("code is required")
+	}
+	if code.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := validateTaxRate(code.Rate); err != nil {
+		return nil, err
+	}
+	if description := strings.TrimSpace(req.Description); description != "" {
+		code.Description = &description
+	}
+
+	if err := s.repos.Tax.CreateCode(code); err != nil {
+		return nil, err
+	}
+
+	return code, nil
+}
+
+// UpdateCode changes a tax code. Documents already created keep the rate
+// they were taxed at.
+func (s *taxService) UpdateCode(id int64, req *models.UpdateTaxCodeRequest) (*models.TaxCode, error) {
+	code, err := s.repos.Tax.GetCodeByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	code.Name = strings.TrimSpace(req.Name)
+	if code.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := validateTaxRate(req.Rate); err != nil {
+		return nil, err
+	}
+	code.Rate = req.Rate
+	code.IsActive = req.IsActive
+	code.Description = nil
+	if description := strings.TrimSpace(req.Description); description != "" {
+		code.Description = &description
+	}
+
+	if err := s.repos.Tax.UpdateCode(code); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Tax.GetCodeByID(id)
+}
+
+func (s *taxService) AssignCode(id int64, req *models.AssignTaxCodeRequest) (int64, error) {
+	if len(req.ProductIDs) == 0 && len(req.ServiceIDs) == 0 {
+		return 0, errors.New("at least one product or service is required")
+	}
+
+	code, err := s.repos.Tax.GetCodeByID(id)
+	if err != nil {
+		return 0, err
+	}
+	if !code.IsActive {
+		return 0, errors.New("tax code is inactive")
+	}
+
+	return s.repos.Tax.AssignCode(id, req.ProductIDs, req.ServiceIDs)
+}
+
+func (s *taxService) GetOutletSettings(outletID int64) (*models.OutletTaxSettings, error) {
+	return s.repos.Tax.GetOutletSettings(outletID)
+}
+
+// UpdateOutletSettings switches an outlet between tax-inclusive and
+// tax-exclusive prices. Existing transactions keep the pricing they were
+// created with.
+func (s *taxService) UpdateOutletSettings(outletID int64, req *models.UpdateOutletTaxSettingsRequest) (*models.OutletTaxSettings, error) {
+	if req.DefaultTaxCodeID != nil {
+		code, err := s.repos.Tax.GetCodeByID(*req.DefaultTaxCodeID)
+		if err != nil {
+			return nil, err
+		}
+		if !code.IsActive {
+			return nil, errors.New("tax code is inactive")
+		}
+	}
+
+	settings := &models.OutletTaxSettings{
+		OutletID:         outletID,
+		PricesIncludeTax: req.PricesIncludeTax,
+		DefaultTaxCodeID: req.DefaultTaxCodeID,
+	}
+	if err := s.repos.Tax.UpdateOutletSettings(settings); err != nil {
+		return nil, err
+	}
+
+	return s.repos.Tax.GetOutletSettings(outletID)
+}
+
+func (s *taxService) GetTransactionSummary(transactionID int64) ([]models.TaxSummaryLine, error) {
+	return s.repos.Tax.GetTransactionSummary(transactionID)
+}
+
+func (s *taxService) GetReport(filter *models.TaxReportFilter) (*models.TaxReport, error) {
+	return s.repos.Tax.GetReport(filter)
+}
+
+// taxLine is a document line to be taxed: its amount as entered, the tax
+// code picked on the line, if any, and what promotions took off it
+type taxLine struct {
+	ProductID *int64
+	ServiceID *int64
+	TaxCodeID *int64
+	Amount    float64
+	Discount  float64
+}
+
+// taxedDocument is a document taxed with its outlet's pricing, with the
+// tax code and rate applied to each line
+type taxedDocument struct {
+	Inclusive bool
+	CodeIDs   []*int64
+	Rates     []decimal.Decimal
+	*tax.Result
+}
+
+// computeTax resolves the tax code of each line, the one picked on the line
+// first, then the product's or service's, then the outlet default, and
+// taxes the document. Lines left without a code are not taxed.
+func computeTax(repos *repositories.Repositories, outletID int64, lines []taxLine, discount float64) (*taxedDocument, error) {
+	settings, err := repos.Tax.GetOutletSettings(outletID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &taxedDocument{
+		Inclusive: settings.PricesIncludeTax,
+		CodeIDs:   make([]*int64, len(lines)),
+		Rates:     make([]decimal.Decimal, len(lines)),
+	}
+	codes := make(map[int64]*models.TaxCode)
+	inputs := make([]tax.Line, len(lines))
+	for i, line := range lines {
+		codeID := line.TaxCodeID
+		if codeID == nil {
+			if codeID, err = repos.Tax.GetItemTaxCodeID(line.ProductID, line.ServiceID); err != nil {
+				return nil, err
+			}
+		}
+		if codeID == nil {
+			codeID = settings.DefaultTaxCodeID
+		}
+
+		rate := decimal.Zero
+		if codeID != nil {
+			code, ok := codes[*codeID]
+			if !ok {
+				if code, err = repos.Tax.GetCodeByID(*codeID); err != nil {
+					return nil, err
+				}
+				codes[*codeID] = code
+			}
+			if line.TaxCodeID != nil && !code.IsActive {
+				return nil, fmt.Errorf("tax code %s is inactive", code.Code)
+			}
+			rate = code.Rate
+		}
+
+		doc.CodeIDs[i] = codeID
+		doc.Rates[i] = rate
+		inputs[i] = tax.Line{
+			Amount:   decimal.NewFromFloat(line.Amount),
+			Rate:     rate,
+			Discount: decimal.NewFromFloat(line.Discount),
+		}
+	}
+
+	if doc.Result, err = tax.Compute(inputs, decimal.NewFromFloat(discount), doc.Inclusive); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
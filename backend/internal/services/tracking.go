@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+	"flutter-bengkel/internal/utils"
+)
+
+// trackingTokenLength is the hex length of a customer tracking token
+const trackingTokenLength = 48
+
+// TrackingService interface defines customer-facing service job tracking
+type TrackingService interface {
+	GetByToken(token string) (*models.JobTracking, error)
+	RegenerateToken(jobID int64, outletID *int64) (*models.JobTrackingLink, error)
+	RevokeToken(jobID int64, outletID *int64) error
+}
+
+type trackingService struct {
+	repos *repositories.Repositories
+	queue QueueService
+}
+
+// NewTrackingService creates a new tracking service
+func NewTrackingService(repos *repositories.Repositories, queue QueueService) TrackingService {
+	return &trackingService{
+		repos: repos,
+		queue: queue,
+	}
+}
+
+func (s *trackingService) GetByToken(token string) (*models.JobTracking, error) {
+	if token == "" {
+		return nil, errors.New("tracking link not found")
+	}
+
+	tracking, err := s.repos.Tracking.GetByToken(token)
+	if err != nil {
+		return nil, errors.New("tracking link not found")
+	}
+
+	timeline, err := s.repos.Tracking.GetTimeline(tracking.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Histories only record changes, so the job's arrival opens the timeline
+	if len(timeline) == 0 || timeline[0].Status != "pending" {
+		timeline = append([]models.JobTrackingStatus{{Status: "pending", ChangedAt: tracking.ReceivedAt}}, timeline...)
+	}
+	tracking.Timeline = timeline
+	tracking.VehicleNumber = utils.MaskPlateNumber(tracking.VehicleNumber)
+
+	// Active jobs get a live estimate instead of the last stored one
+	if tracking.Status == "pending" || tracking.Status == "in_progress" {
+		if eta, err := s.queue.GetJobETA(tracking.JobID, nil); err == nil {
+			tracking.EstimatedStart = &eta.EstimatedStart
+			tracking.EstimatedCompletion = &eta.EstimatedCompletion
+			if eta.Position > 0 {
+				tracking.QueuePosition = &eta.Position
+			}
+		}
+	}
+
+	return tracking, nil
+}
+
+func (s *trackingService) RegenerateToken(jobID int64, outletID *int64) (*models.JobTrackingLink, error) {
+	if err := s.checkOutlet(jobID, outletID); err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateRandomString(trackingTokenLength)
+	if err != nil {
+		return nil, errors.New("failed to generate tracking token")
+	}
+
+	if err := s.repos.Tracking.SetToken(jobID, &token); err != nil {
+		return nil, err
+	}
+
+	return &models.JobTrackingLink{
+		JobID:         jobID,
+		TrackingToken: token,
+		TrackingPath:  trackingPath(token),
+	}, nil
+}
+
+func (s *trackingService) RevokeToken(jobID int64, outletID *int64) error {
+	if err := s.checkOutlet(jobID, outletID); err != nil {
+		return err
+	}
+
+	return s.repos.Tracking.SetToken(jobID, nil)
+}
+
+// checkOutlet ensures outlet users only manage their own outlet's jobs
+func (s *trackingService) checkOutlet(jobID int64, outletID *int64) error {
+	jobOutletID, err := s.repos.Queue.GetJobOutletID(jobID)
+	if err != nil || (outletID != nil && jobOutletID != *outletID) {
+		return errors.New("service job not found")
+	}
+
+	return nil
+}
+
+// trackingPath returns the public API path of a tracking link
+func trackingPath(token string) string {
+	return "/api/v1/public/track/" + token
+}
@@ -1,10 +1,12 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"mime/multipart"
 	"time"
 
+	"flutter-bengkel/internal/events"
 	"flutter-bengkel/internal/models"
 	"flutter-bengkel/internal/repositories"
 
@@ -56,12 +58,17 @@ type VehicleTradingService interface {
 }
 
 type vehicleTradingService struct {
-	repos *repositories.Repositories
+	repos    *repositories.Repositories
+	eventBus EventService
 }
 
 // NewVehicleTradingService creates a new vehicle trading service
-func NewVehicleTradingService(repos *repositories.Repositories) VehicleTradingService {
-	return &vehicleTradingService{repos: repos}
+func NewVehicleTradingService(repos *repositories.Repositories, eventBus EventService) VehicleTradingService {
+	s := &vehicleTradingService{repos: repos, eventBus: eventBus}
+
+	eventBus.Subscribe("vehicle_sale.commission", s.onVehicleSoldCommission, events.VehicleSold)
+
+	return s
 }
 
 // Vehicle Purchase Operations
@@ -80,14 +87,8 @@ func (s *vehicleTradingService) CreateVehiclePurchase(req *models.CreateVehicleP
 		},
 	}
 
-	err := s.repos.VehicleTrading.CreateVehiclePurchase(purchase)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create vehicle purchase: %w", err)
-	}
-
-	// Create inventory record
+	// Inventory record for the bought vehicle
 	inventory := &models.VehicleInventory{
-		VehiclePurchaseID:     purchase.PurchaseID,
 		PlateNumber:           req.VehicleDetails.PlateNumber,
 		Brand:                 req.VehicleDetails.Brand,
 		Model:                 req.VehicleDetails.Model,
@@ -107,10 +108,11 @@ func (s *vehicleTradingService) CreateVehiclePurchase(req *models.CreateVehicleP
 		},
 	}
 
-	err = s.repos.VehicleTrading.CreateVehicleInventory(inventory)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create vehicle inventory: %w", err)
+	// Purchase, inventory and the VehiclePurchased event are written together
+	if err := s.repos.VehicleTrading.RecordVehiclePurchase(purchase, inventory); err != nil {
+		return nil, fmt.Errorf("failed to record vehicle purchase: %w", err)
 	}
+	s.eventBus.Wake()
 
 	return purchase, nil
 }
@@ -225,32 +227,45 @@ func (s *vehicleTradingService) CreateVehicleSale(req *models.CreateVehicleSaleR
 		},
 	}
 
-	// Mark vehicle as sold and create sale record in transaction
+	// Mark vehicle as sold, create the sale record and record the VehicleSold
+	// event in one transaction; the commission is created by its subscriber
 	err = s.repos.VehicleTrading.MarkAsSold(req.InventoryID, sale)
 	if err != nil {
 		return nil, fmt.Errorf("failed to record vehicle sale: %w", err)
 	}
+	s.eventBus.Wake()
+
+	return sale, nil
+}
+
+// onVehicleSoldCommission records the sales person's commission for a sale
+func (s *vehicleTradingService) onVehicleSoldCommission(ctx context.Context, event events.Event) error {
+	var payload events.VehicleSoldPayload
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	commissionRate, err := decimal.NewFromString(payload.CommissionRate)
+	if err != nil {
+		return fmt.Errorf("invalid commission rate: %w", err)
+	}
+	commissionAmount, err := decimal.NewFromString(payload.CommissionAmount)
+	if err != nil {
+		return fmt.Errorf("invalid commission amount: %w", err)
+	}
 
-	// Create commission record
 	commission := &models.SalesCommission{
-		SaleID:           sale.SaleID,
-		SalesPersonID:    salesPersonID,
+		SaleID:           payload.SaleID,
+		SalesPersonID:    payload.SalesPersonID,
 		CommissionRate:   commissionRate,
 		CommissionAmount: commissionAmount,
 		PaymentStatus:    "pending",
 		BaseModelWithSoftDelete: models.BaseModelWithSoftDelete{
-			CreatedBy: &salesPersonID,
+			CreatedBy: &payload.SalesPersonID,
 		},
 	}
 
-	err = s.repos.VehicleTrading.CreateSalesCommission(commission)
-	if err != nil {
-		// Log error but don't fail the sale
-		// This should be handled better in production with proper logging
-		fmt.Printf("Warning: failed to create commission record: %v\n", err)
-	}
-
-	return sale, nil
+	return s.repos.VehicleTrading.CreateSalesCommission(commission)
 }
 
 func (s *vehicleTradingService) GetVehicleSaleByID(id int64) (*models.VehicleSale, error) {
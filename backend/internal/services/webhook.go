@@ -0,0 +1,444 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"flutter-bengkel/internal/config"
+	"flutter-bengkel/internal/events"
+	"flutter-bengkel/internal/models"
+	"flutter-bengkel/internal/repositories"
+	"flutter-bengkel/internal/utils"
+	"flutter-bengkel/internal/webhooks"
+)
+
+const (
+	// webhookBatchSize caps how many deliveries one dispatch pass sends
+	webhookBatchSize = 50
+	// webhookStaleAfter releases deliveries stuck in sending by a crashed process
+	webhookStaleAfter = 5 * time.Minute
+	// webhookMaxBackoff caps the delay between retries
+	webhookMaxBackoff = 12 * time.Hour
+	// webhookSecretLength is the length of generated signing secrets
+	webhookSecretLength = 48
+	// webhookUserAgent identifies outgoing webhook requests
+	webhookUserAgent = "bengkel-webhooks/1.0"
+	// webhookPingEvent is sent by the test endpoint and is always deliverable
+	webhookPingEvent = "webhook.ping"
+)
+
+// WebhookEnvelope is the JSON body posted to subscribers
+type WebhookEnvelope struct {
+	EventID    *int64          `json:"event_id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	OutletID   *int64          `json:"outlet_id"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// WebhookService interface defines outgoing webhook subscriptions and delivery
+type WebhookService interface {
+	Create(req *models.CreateWebhookSubscriptionRequest, userID int64) (*models.WebhookSubscription, error)
+	GetByID(id int64) (*models.WebhookSubscription, error)
+	List(page, limit int) ([]models.WebhookSubscription, *models.PaginationMeta, error)
+	Update(id int64, req *models.UpdateWebhookSubscriptionRequest) (*models.WebhookSubscription, error)
+	RotateSecret(id int64) (*models.WebhookSubscription, error)
+	Delete(id int64) error
+	Ping(id int64) (*models.WebhookDelivery, error)
+
+	ListDeliveries(page, limit int, filter *models.WebhookDeliveryFilter) ([]models.WebhookDelivery, *models.PaginationMeta, error)
+	GetDelivery(id int64) (*models.WebhookDelivery, error)
+	Redeliver(id int64) (*models.WebhookDelivery, error)
+
+	DispatchDue() (int, error)
+	RunDispatcher(stop <-chan struct{})
+}
+
+type webhookService struct {
+	repos  *repositories.Repositories
+	cfg    *config.Config
+	sender *webhooks.Sender
+	wake   chan struct{}
+}
+
+// NewWebhookService creates a new webhook service and subscribes it to every
+// domain event type
+func NewWebhookService(repos *repositories.Repositories, cfg *config.Config, eventBus EventService) WebhookService {
+	s := &webhookService{
+		repos:  repos,
+		cfg:    cfg,
+		sender: webhooks.NewSender(time.Duration(cfg.Webhook.TimeoutSeconds)*time.Second, webhookUserAgent),
+		wake:   make(chan struct{}, 1),
+	}
+
+	eventBus.Subscribe("webhooks.fanout", s.onDomainEvent, events.Types...)
+
+	return s
+}
+
+// onDomainEvent queues the event for every subscription listening to its type
+func (s *webhookService) onDomainEvent(ctx context.Context, event events.Event) error {
+	body, err := json.Marshal(WebhookEnvelope{
+		EventID:    &event.ID,
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		OutletID:   event.OutletID,
+		Data:       event.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	queued, err := s.repos.Webhook.CreateDeliveriesForEvent(event.ID, event.Type, body, s.cfg.Webhook.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	if queued > 0 {
+		s.wakeDispatcher()
+	}
+
+	return nil
+}
+
+func (s *webhookService) Create(req *models.CreateWebhookSubscriptionRequest, userID int64) (*models.WebhookSubscription, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := utils.GenerateRandomString(webhookSecretLength)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = generated
+	}
+
+	subscription := &models.WebhookSubscription{
+		Name:        req.Name,
+		URL:         req.URL,
+		EventTypes:  req.EventTypes,
+		Secret:      secret,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		Description: req.Description,
+		CreatedBy:   &userID,
+	}
+
+	if err := s.repos.Webhook.Create(subscription); err != nil {
+		return nil, err
+	}
+
+	// The secret is shown once so the receiver can be configured
+	return subscription, nil
+}
+
+func (s *webhookService) GetByID(id int64) (*models.WebhookSubscription, error) {
+	subscription, err := s.repos.Webhook.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+func (s *webhookService) List(page, limit int) ([]models.WebhookSubscription, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	subscriptions, total, err := s.repos.Webhook.List(offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	return subscriptions, paginationMeta(page, limit, total), nil
+}
+
+func (s *webhookService) Update(id int64, req *models.UpdateWebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	subscription, err := s.repos.Webhook.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		subscription.Name = req.Name
+	}
+	if req.URL != "" {
+		if err := validateWebhookURL(req.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = req.URL
+	}
+	if req.EventTypes != nil {
+		if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+			return nil, err
+		}
+		subscription.EventTypes = req.EventTypes
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	if req.Description != nil {
+		subscription.Description = *req.Description
+	}
+
+	if err := s.repos.Webhook.Update(subscription); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(id)
+}
+
+// RotateSecret replaces the signing secret and returns it once
+func (s *webhookService) RotateSecret(id int64) (*models.WebhookSubscription, error) {
+	secret, err := utils.GenerateRandomString(webhookSecretLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	if err := s.repos.Webhook.UpdateSecret(id, secret); err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.repos.Webhook.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (s *webhookService) Delete(id int64) error {
+	return s.repos.Webhook.Delete(id)
+}
+
+// Ping queues a test delivery so receivers can check their signature handling
+func (s *webhookService) Ping(id int64) (*models.WebhookDelivery, error) {
+	subscription, err := s.repos.Webhook.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !subscription.IsActive {
+		return nil, errors.New("webhook subscription is inactive")
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"subscription_id": subscription.SubscriptionID,
+		"name":            subscription.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(WebhookEnvelope{
+		Type:       webhookPingEvent,
+		OccurredAt: time.Now(),
+		Data:       data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: subscription.SubscriptionID,
+		EventType:      webhookPingEvent,
+		Payload:        body,
+		MaxAttempts:    1,
+	}
+	if err := s.repos.Webhook.CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+	s.wakeDispatcher()
+
+	return delivery, nil
+}
+
+func (s *webhookService) ListDeliveries(page, limit int, filter *models.WebhookDeliveryFilter) ([]models.WebhookDelivery, *models.PaginationMeta, error) {
+	offset := (page - 1) * limit
+	deliveries, total, err := s.repos.Webhook.ListDeliveries(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return deliveries, paginationMeta(page, limit, total), nil
+}
+
+func (s *webhookService) GetDelivery(id int64) (*models.WebhookDelivery, error) {
+	delivery, err := s.repos.Webhook.GetDeliveryByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.AttemptLog, err = s.repos.Webhook.GetDeliveryAttempts(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// Redeliver sends a finished delivery again with the same payload
+func (s *webhookService) Redeliver(id int64) (*models.WebhookDelivery, error) {
+	if err := s.repos.Webhook.Redeliver(id); err != nil {
+		return nil, err
+	}
+	s.wakeDispatcher()
+
+	return s.GetDelivery(id)
+}
+
+// DispatchDue sends every delivery whose next attempt is due, retrying
+// failures with exponential backoff until max attempts is reached. Any 2xx
+// response counts as delivered.
+func (s *webhookService) DispatchDue() (int, error) {
+	due, err := s.repos.Webhook.ClaimDueDeliveries(webhookBatchSize, webhookStaleAfter)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, delivery := range due {
+		timeout := time.Duration(s.cfg.Webhook.TimeoutSeconds) * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		result, sendErr := s.sender.Send(ctx, webhooks.Request{
+			URL:        delivery.URL,
+			Secret:     delivery.Secret,
+			Event:      delivery.EventType,
+			DeliveryID: delivery.DeliveryID,
+			Body:       delivery.Payload,
+		})
+		cancel()
+
+		attempt := &models.WebhookDeliveryAttempt{DeliveryID: delivery.DeliveryID}
+		var responseCode *int
+		if result != nil {
+			attempt.DurationMs = int(result.Duration.Milliseconds())
+			if result.StatusCode != 0 {
+				code := result.StatusCode
+				responseCode = &code
+				attempt.ResponseCode = responseCode
+				attempt.ResponseBody = &result.Body
+			}
+		}
+
+		var failure string
+		switch {
+		case sendErr != nil:
+			failure = sendErr.Error()
+		case !result.Success():
+			failure = fmt.Sprintf("receiver responded with status %d", result.StatusCode)
+		}
+		if failure != "" {
+			attempt.Error = &failure
+		}
+
+		if err := s.repos.Webhook.RecordAttempt(attempt); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+
+		if failure == "" {
+			if err := s.repos.Webhook.MarkDelivered(delivery.DeliveryID, result.StatusCode); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			delivered++
+			continue
+		}
+
+		var nextAttemptAt *time.Time
+		if delivery.Attempts < delivery.MaxAttempts {
+			next := time.Now().Add(s.retryBackoff(delivery.Attempts))
+			nextAttemptAt = &next
+		}
+		if err := s.repos.Webhook.MarkFailed(delivery.DeliveryID, responseCode, failure, nextAttemptAt); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	return delivered, nil
+}
+
+// retryBackoff doubles the configured base delay after every failed attempt
+func (s *webhookService) retryBackoff(attempts int) time.Duration {
+	backoff := time.Duration(s.cfg.Webhook.RetryBaseSeconds) * time.Second
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	return backoff
+}
+
+func (s *webhookService) wakeDispatcher() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RunDispatcher sends due deliveries on the configured interval, or as soon
+// as new deliveries are queued, until stop is closed
+func (s *webhookService) RunDispatcher(stop <-chan struct{}) {
+	interval := time.Duration(s.cfg.Webhook.DispatchIntervalSeconds) * time.Second
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.DispatchDue(); err != nil {
+			log.Printf("Warning: webhook dispatch failed: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+func validateWebhookEventTypes(types []string) error {
+	if len(types) == 0 {
+		return errors.New("at least one event type is required")
+	}
+
+	for _, eventType := range types {
+		known := false
+		for _, candidate := range events.Types {
+			if eventType == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown event type: %s", eventType)
+		}
+	}
+	return nil
+}
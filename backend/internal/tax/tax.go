@@ -0,0 +1,151 @@
+// Package tax computes sales tax (PPN) line by line for prices entered
+// with or without tax.
+package tax
+
+import (
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Line is a document line as entered: quantity times unit price, with the
+// rate of its tax code in percent (11 for PPN 11%, 0 when exempt) and any
+// discount of its own, such as a promotion, entered the same way
+type Line struct {
+	Amount   decimal.Decimal
+	Rate     decimal.Decimal
+	Discount decimal.Decimal
+}
+
+// LineResult is a line split into what it is worth before tax, its share of
+// the document discount and its tax. Net - Discount + Tax is what the
+// customer pays for the line.
+type LineResult struct {
+	Net      decimal.Decimal
+	Discount decimal.Decimal
+	Tax      decimal.Decimal
+	Total    decimal.Decimal
+}
+
+// Result is a taxed document. Its totals are the sums of its lines, so
+// Subtotal - Discount + Tax = Total holds exactly.
+type Result struct {
+	Lines    []LineResult
+	Subtotal decimal.Decimal
+	Discount decimal.Decimal
+	Tax      decimal.Decimal
+	Total    decimal.Decimal
+}
+
+// Compute taxes a document. The discount is entered the same way as the
+// prices (including tax when inclusive is set) and is shared across the
+// lines in proportion to their amounts after their own discounts. Tax is
+// computed and rounded to the cent on each line: on the discounted amount
+// when prices exclude tax, and taken out of it when they include tax, so an
+// inclusive line always costs exactly its shelf price less its discounts.
+func Compute(lines []Line, discount decimal.Decimal, inclusive bool) (*Result, error) {
+	amounts := make([]decimal.Decimal, len(lines))
+	own := make([]decimal.Decimal, len(lines))
+	remaining := make([]decimal.Decimal, len(lines))
+	gross := decimal.Zero
+	for i, line := range lines {
+		if line.Amount.IsNegative() {
+			return nil, errors.New("line amount cannot be negative")
+		}
+		if line.Rate.IsNegative() {
+			return nil, errors.New("tax rate cannot be negative")
+		}
+		if line.Discount.IsNegative() {
+			return nil, errors.New("line discount cannot be negative")
+		}
+		amounts[i] = line.Amount.Round(2)
+		own[i] = line.Discount.Round(2)
+		if own[i].GreaterThan(amounts[i]) {
+			return nil, errors.New("line discount cannot exceed the line amount")
+		}
+		remaining[i] = amounts[i].Sub(own[i])
+		gross = gross.Add(remaining[i])
+	}
+	if discount.IsNegative() {
+		return nil, errors.New("discount cannot be negative")
+	}
+	if discount.GreaterThan(gross) {
+		return nil, errors.New("discount cannot exceed the subtotal")
+	}
+
+	shares := Allocate(discount.Round(2), remaining)
+
+	result := &Result{Lines: make([]LineResult, len(lines))}
+	for i, line := range lines {
+		var r LineResult
+		if inclusive {
+			r.Total = remaining[i].Sub(shares[i])
+			r.Tax = included(r.Total, line.Rate)
+			r.Net = amounts[i].Sub(included(amounts[i], line.Rate))
+			r.Discount = r.Net.Sub(r.Total.Sub(r.Tax))
+		} else {
+			r.Net = amounts[i]
+			r.Discount = own[i].Add(shares[i])
+			r.Tax = remaining[i].Sub(shares[i]).Mul(line.Rate).Div(hundred).Round(2)
+			r.Total = r.Net.Sub(r.Discount).Add(r.Tax)
+		}
+
+		result.Lines[i] = r
+		result.Subtotal = result.Subtotal.Add(r.Net)
+		result.Discount = result.Discount.Add(r.Discount)
+		result.Tax = result.Tax.Add(r.Tax)
+		result.Total = result.Total.Add(r.Total)
+	}
+
+	return result, nil
+}
+
+// included is the tax contained in a tax-inclusive amount
+func included(amount, rate decimal.Decimal) decimal.Decimal {
+	if rate.IsZero() {
+		return decimal.Zero
+	}
+	return amount.Mul(rate).Div(hundred.Add(rate)).Round(2)
+}
+
+// Allocate shares an amount across weights in proportion, to the cent. The
+// cents lost to rounding go to the largest remainders, so the shares always
+// add up to the amount.
+func Allocate(amount decimal.Decimal, weights []decimal.Decimal) []decimal.Decimal {
+	shares := make([]decimal.Decimal, len(weights))
+	total := decimal.Zero
+	for _, weight := range weights {
+		total = total.Add(weight)
+	}
+	if amount.IsZero() || !total.IsPositive() {
+		for i := range shares {
+			shares[i] = decimal.Zero
+		}
+		return shares
+	}
+
+	cent := decimal.New(1, -2)
+	remainders := make([]decimal.Decimal, len(weights))
+	allocated := decimal.Zero
+	for i, weight := range weights {
+		exact := amount.Mul(weight).Div(total)
+		shares[i] = exact.Truncate(2)
+		remainders[i] = exact.Sub(shares[i])
+		allocated = allocated.Add(shares[i])
+	}
+
+	for left := amount.Sub(allocated); left.IsPositive(); left = left.Sub(cent) {
+		largest := 0
+		for i := range remainders {
+			if remainders[i].GreaterThan(remainders[largest]) {
+				largest = i
+			}
+		}
+		shares[largest] = shares[largest].Add(cent)
+		remainders[largest] = decimal.NewFromInt(-1)
+	}
+
+	return shares
+}
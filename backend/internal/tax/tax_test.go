@@ -0,0 +1,177 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func d(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []Line
+		discount  string
+		inclusive bool
+		want      []LineResult
+	}{
+		{
+			name:  "exclusive price adds tax",
+			lines: []Line{{Amount: d("100000"), Rate: d("11")}},
+			want:  []LineResult{{Net: d("100000"), Discount: d("0"), Tax: d("11000"), Total: d("111000")}},
+		},
+		{
+			name:      "inclusive price takes tax out",
+			lines:     []Line{{Amount: d("111000"), Rate: d("11")}},
+			inclusive: true,
+			want:      []LineResult{{Net: d("100000"), Discount: d("0"), Tax: d("11000"), Total: d("111000")}},
+		},
+		{
+			name:      "inclusive discount is entered with tax",
+			lines:     []Line{{Amount: d("111000"), Rate: d("11")}},
+			discount:  "11100",
+			inclusive: true,
+			want:      []LineResult{{Net: d("100000"), Discount: d("10000"), Tax: d("9900"), Total: d("99900")}},
+		},
+		{
+			name:     "exclusive discount remainder goes to the largest remainder",
+			lines:    []Line{{Amount: d("100"), Rate: d("11")}, {Amount: d("200"), Rate: d("11")}},
+			discount: "100",
+			want: []LineResult{
+				{Net: d("100"), Discount: d("33.33"), Tax: d("7.33"), Total: d("74")},
+				{Net: d("200"), Discount: d("66.67"), Tax: d("14.67"), Total: d("148")},
+			},
+		},
+		{
+			name:  "exempt line carries no tax",
+			lines: []Line{{Amount: d("50000"), Rate: d("11")}, {Amount: d("20000"), Rate: d("0")}},
+			want: []LineResult{
+				{Net: d("50000"), Discount: d("0"), Tax: d("5500"), Total: d("55500")},
+				{Net: d("20000"), Discount: d("0"), Tax: d("0"), Total: d("20000")},
+			},
+		},
+		{
+			name:  "line discount is taxed after",
+			lines: []Line{{Amount: d("1000"), Rate: d("10"), Discount: d("100")}},
+			want:  []LineResult{{Net: d("1000"), Discount: d("100"), Tax: d("90"), Total: d("990")}},
+		},
+		{
+			name:      "inclusive line costs its shelf price to the cent",
+			lines:     []Line{{Amount: d("9999"), Rate: d("11")}, {Amount: d("1"), Rate: d("11")}},
+			discount:  "0.01",
+			inclusive: true,
+			want: []LineResult{
+				{Net: d("9008.11"), Discount: d("0.01"), Tax: d("990.89"), Total: d("9998.99")},
+				{Net: d("0.90"), Discount: d("0"), Tax: d("0.10"), Total: d("1")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discount := decimal.Zero
+			if tt.discount != "" {
+				discount = d(tt.discount)
+			}
+
+			result, err := Compute(tt.lines, discount, tt.inclusive)
+			if err != nil {
+				t.Fatalf("Compute() error = %v", err)
+			}
+			if len(result.Lines) != len(tt.want) {
+				t.Fatalf("Compute() returned %d lines, want %d", len(result.Lines), len(tt.want))
+			}
+
+			subtotal, lineDiscount, tax, total := decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero
+			for i, want := range tt.want {
+				got := result.Lines[i]
+				if !got.Net.Equal(want.Net) || !got.Discount.Equal(want.Discount) ||
+					!got.Tax.Equal(want.Tax) || !got.Total.Equal(want.Total) {
+					t.Errorf("line %d = %+v, want %+v", i, got, want)
+				}
+				if !got.Net.Sub(got.Discount).Add(got.Tax).Equal(got.Total) {
+					t.Errorf("line %d: net - discount + tax != total", i)
+				}
+				subtotal = subtotal.Add(got.Net)
+				lineDiscount = lineDiscount.Add(got.Discount)
+				tax = tax.Add(got.Tax)
+				total = total.Add(got.Total)
+			}
+
+			if !result.Subtotal.Equal(subtotal) || !result.Discount.Equal(lineDiscount) ||
+				!result.Tax.Equal(tax) || !result.Total.Equal(total) {
+				t.Errorf("totals %+v do not add up from the lines", result)
+			}
+			if !result.Subtotal.Sub(result.Discount).Add(result.Tax).Equal(result.Total) {
+				t.Errorf("subtotal - discount + tax != total")
+			}
+		})
+	}
+}
+
+func TestComputeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []Line
+		discount string
+	}{
+		{name: "negative amount", lines: []Line{{Amount: d("-1"), Rate: d("11")}}, discount: "0"},
+		{name: "negative rate", lines: []Line{{Amount: d("100"), Rate: d("-11")}}, discount: "0"},
+		{name: "negative line discount", lines: []Line{{Amount: d("100"), Rate: d("11"), Discount: d("-1")}}, discount: "0"},
+		{name: "line discount above amount", lines: []Line{{Amount: d("100"), Rate: d("11"), Discount: d("101")}}, discount: "0"},
+		{name: "negative discount", lines: []Line{{Amount: d("100"), Rate: d("11")}}, discount: "-1"},
+		{name: "discount above subtotal", lines: []Line{{Amount: d("100"), Rate: d("11"), Discount: d("50")}}, discount: "51"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compute(tt.lines, d(tt.discount), false); err == nil {
+				t.Error("Compute() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  string
+		weights []string
+		want    []string
+	}{
+		{name: "exact shares", amount: "10", weights: []string{"1", "3"}, want: []string{"2.5", "7.5"}},
+		{name: "tied remainders go to the first", amount: "100", weights: []string{"1", "1", "1"}, want: []string{"33.34", "33.33", "33.33"}},
+		{name: "each cent goes to a different line", amount: "0.02", weights: []string{"1", "1", "1"}, want: []string{"0.01", "0.01", "0"}},
+		{name: "largest remainder wins", amount: "1", weights: []string{"1", "2"}, want: []string{"0.33", "0.67"}},
+		{name: "zero amount", amount: "0", weights: []string{"1", "2"}, want: []string{"0", "0"}},
+		{name: "zero weights", amount: "5", weights: []string{"0", "0"}, want: []string{"0", "0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weights := make([]decimal.Decimal, len(tt.weights))
+			total := decimal.Zero
+			for i, weight := range tt.weights {
+				weights[i] = d(weight)
+				total = total.Add(weights[i])
+			}
+
+			shares := Allocate(d(tt.amount), weights)
+			sum := decimal.Zero
+			for i, share := range shares {
+				if !share.Equal(d(tt.want[i])) {
+					t.Errorf("share %d = %s, want %s", i, share, tt.want[i])
+				}
+				sum = sum.Add(share)
+			}
+			// Shares always add up to the amount unless there is nothing to
+			// share it by
+			if total.IsPositive() && !sum.Equal(d(tt.amount)) {
+				t.Errorf("shares add up to %s, want %s", sum, tt.amount)
+			}
+		})
+	}
+}
@@ -148,4 +148,31 @@ func CalculateTotalPages(total int64, limit int) int {
 		totalPages++
 	}
 	return totalPages
-}
\ No newline at end of file
+}
+
+// MaskPlateNumber hides the middle of a plate number for public display,
+// e.g. "B 1234 XYZ" becomes "B **34 XYZ"
+func MaskPlateNumber(plate string) string {
+	parts := strings.Fields(strings.ToUpper(plate))
+	if len(parts) == 0 {
+		return ""
+	}
+
+	// Plates without the usual "region number suffix" layout keep only the last 3 characters
+	if len(parts) != 3 {
+		joined := strings.Join(parts, "")
+		if len(joined) <= 3 {
+			return strings.Repeat("*", len(joined))
+		}
+		return strings.Repeat("*", len(joined)-3) + joined[len(joined)-3:]
+	}
+
+	number := parts[1]
+	if len(number) > 2 {
+		number = strings.Repeat("*", len(number)-2) + number[len(number)-2:]
+	} else {
+		number = strings.Repeat("*", len(number))
+	}
+
+	return parts[0] + " " + number + " " + parts[2]
+}
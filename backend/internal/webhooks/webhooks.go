@@ -0,0 +1,146 @@
+// Package webhooks signs, sends and verifies outgoing webhook requests.
+//
+// Every request carries the event type, delivery ID and a Unix timestamp in
+// headers, plus an HMAC-SHA256 signature of "<timestamp>.<body>" keyed with
+// the subscription secret. Receivers recompute the signature and reject
+// requests whose timestamp is too old to guard against replays.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request headers set on every webhook delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// signaturePrefix names the algorithm in the signature header
+const signaturePrefix = "sha256="
+
+// maxResponseBody caps how much of the receiver's response is kept
+const maxResponseBody = 4096
+
+// Verification errors
+var (
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredTimestamp = errors.New("webhook timestamp outside tolerance")
+)
+
+// Sign returns the signature header value for body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a received request's signature and timestamp headers against
+// body. A tolerance of zero skips the timestamp age check.
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration) error {
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		age := time.Since(time.Unix(sentAt, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrExpiredTimestamp
+		}
+	}
+
+	expected := Sign(secret, sentAt, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Request is a single webhook delivery attempt
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID int64
+	Body       []byte
+}
+
+// Result is the receiver's answer to a delivery attempt
+type Result struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+}
+
+// Success reports whether the receiver accepted the delivery
+func (r *Result) Success() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Sender posts signed webhook requests
+type Sender struct {
+	client    *http.Client
+	userAgent string
+}
+
+// NewSender creates a sender whose requests time out after timeout
+func NewSender(timeout time.Duration, userAgent string) *Sender {
+	return &Sender{
+		client:    &http.Client{Timeout: timeout},
+		userAgent: userAgent,
+	}
+}
+
+// Send signs and posts req. A non-nil error means no response was received;
+// non-2xx responses are returned as a Result for the caller to judge.
+func (s *Sender) Send(ctx context.Context, req Request) (*Result, error) {
+	timestamp := time.Now().Unix()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", s.userAgent)
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderDelivery, strconv.FormatInt(req.DeliveryID, 10))
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	started := time.Now()
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return &Result{Duration: time.Since(started)}, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+
+	return &Result{
+		StatusCode: resp.StatusCode,
+		Body:       strings.ToValidUTF8(string(body), ""),
+		Duration:   time.Since(started),
+	}, nil
+}
@@ -0,0 +1,9 @@
+-- Service Queue & ETA Tables (PostgreSQL with Soft Delete)
+
+-- Track when work actually started and the queue-estimated start time
+ALTER TABLE service_jobs ADD COLUMN started_at TIMESTAMP NULL;
+ALTER TABLE service_jobs ADD COLUMN estimated_start TIMESTAMP NULL;
+
+-- Create indexes for queue lookups
+CREATE INDEX idx_service_jobs_outlet_status ON service_jobs(outlet_id, status) WHERE deleted_at IS NULL;
+CREATE INDEX idx_service_jobs_priority ON service_jobs(priority) WHERE deleted_at IS NULL;
@@ -0,0 +1,31 @@
+-- Outlet Display Board Tables (PostgreSQL with Soft Delete)
+
+-- Access tokens for unauthenticated waiting room displays
+CREATE TABLE outlet_display_tokens (
+    display_token_id BIGSERIAL PRIMARY KEY,
+    outlet_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    is_active BOOLEAN DEFAULT TRUE,
+    last_seen_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    created_by INTEGER,
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id)
+);
+
+-- Display token permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('displays.read', 'View outlet display tokens', 'displays', 'read'),
+('displays.create', 'Create outlet display tokens', 'displays', 'create'),
+('displays.delete', 'Revoke outlet display tokens', 'displays', 'delete');
+
+-- Super Admin, Admin and Manager manage displays
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin', 'Manager') AND p.resource = 'displays';
+
+-- Create indexes for display lookups
+CREATE INDEX idx_outlet_display_tokens_outlet_id ON outlet_display_tokens(outlet_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_outlet_display_tokens_deleted_at ON outlet_display_tokens(deleted_at);
@@ -0,0 +1,8 @@
+-- Service Job Tracking Tables (PostgreSQL with Soft Delete)
+
+-- Unguessable token for the customer-facing tracking link, NULL when revoked
+ALTER TABLE service_jobs ADD COLUMN tracking_token VARCHAR(64) NULL;
+
+-- Create indexes for tracking lookups
+CREATE UNIQUE INDEX idx_service_jobs_tracking_token ON service_jobs(tracking_token) WHERE tracking_token IS NOT NULL;
+CREATE INDEX idx_service_job_histories_service_job_id ON service_job_histories(service_job_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,63 @@
+-- Service Reminder Tables (PostgreSQL with Soft Delete)
+
+-- Odometer reading at check-in and the mileage the next service is due at
+ALTER TABLE service_jobs ADD COLUMN odometer BIGINT NULL;
+ALTER TABLE customer_vehicles ADD COLUMN next_service_mileage BIGINT NULL;
+
+-- Service intervals per service, or per service category as a fallback
+CREATE TABLE service_intervals (
+    interval_id BIGSERIAL PRIMARY KEY,
+    service_id BIGINT NULL,
+    service_category_id BIGINT NULL,
+    interval_days INTEGER NOT NULL DEFAULT 0,
+    interval_km INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    created_by INTEGER,
+    FOREIGN KEY (service_id) REFERENCES services(service_id),
+    FOREIGN KEY (service_category_id) REFERENCES service_categories(service_category_id),
+    CHECK (service_id IS NOT NULL OR service_category_id IS NOT NULL),
+    CHECK (interval_days > 0 OR interval_km > 0)
+);
+
+-- Reminder worklist generated by the daily scan
+CREATE TABLE vehicle_reminders (
+    reminder_id BIGSERIAL PRIMARY KEY,
+    vehicle_id BIGINT NOT NULL,
+    customer_id BIGINT NOT NULL,
+    outlet_id BIGINT NULL,
+    reminder_type VARCHAR(20) NOT NULL,
+    due_date DATE NULL,
+    due_mileage BIGINT NULL,
+    status VARCHAR(20) CHECK (status IN ('pending', 'notified', 'contacted', 'completed', 'dismissed')) DEFAULT 'pending',
+    notified_at TIMESTAMP NULL,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    created_by INTEGER,
+    CONSTRAINT vehicle_reminders_reminder_type_check CHECK (reminder_type IN ('service')),
+    FOREIGN KEY (vehicle_id) REFERENCES customer_vehicles(vehicle_id) ON DELETE CASCADE,
+    FOREIGN KEY (customer_id) REFERENCES customers(customer_id) ON DELETE CASCADE,
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id)
+);
+
+-- Reminder permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('reminders.read', 'View vehicle reminders', 'reminders', 'read'),
+('reminders.update', 'Work vehicle reminders', 'reminders', 'update');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin', 'Manager', 'Customer Service') AND p.resource = 'reminders';
+
+-- Create indexes for reminder lookups
+CREATE UNIQUE INDEX idx_service_intervals_service_id ON service_intervals(service_id) WHERE deleted_at IS NULL AND service_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_service_intervals_category_id ON service_intervals(service_category_id) WHERE deleted_at IS NULL AND service_id IS NULL;
+CREATE INDEX idx_service_intervals_deleted_at ON service_intervals(deleted_at);
+CREATE UNIQUE INDEX idx_vehicle_reminders_open ON vehicle_reminders(vehicle_id, reminder_type) WHERE deleted_at IS NULL AND status IN ('pending', 'notified', 'contacted');
+CREATE INDEX idx_vehicle_reminders_outlet_status ON vehicle_reminders(outlet_id, status) WHERE deleted_at IS NULL;
+CREATE INDEX idx_vehicle_reminders_due_date ON vehicle_reminders(due_date) WHERE deleted_at IS NULL;
+CREATE INDEX idx_customer_vehicles_next_service_date ON customer_vehicles(next_service_date) WHERE deleted_at IS NULL;
@@ -0,0 +1,18 @@
+-- Vehicle Document Reminder Tables (PostgreSQL with Soft Delete)
+
+-- Insurance and registration (STNK) expiry reminders share the reminder worklist
+ALTER TABLE vehicle_reminders DROP CONSTRAINT vehicle_reminders_reminder_type_check;
+ALTER TABLE vehicle_reminders ADD CONSTRAINT vehicle_reminders_reminder_type_check
+    CHECK (reminder_type IN ('service', 'insurance', 'registration'));
+
+ALTER TABLE vehicle_reminders DROP CONSTRAINT vehicle_reminders_status_check;
+ALTER TABLE vehicle_reminders ADD CONSTRAINT vehicle_reminders_status_check
+    CHECK (status IN ('pending', 'notified', 'contacted', 'renewed', 'completed', 'dismissed'));
+
+-- Track who worked a reminder and when
+ALTER TABLE vehicle_reminders ADD COLUMN handled_at TIMESTAMP NULL;
+ALTER TABLE vehicle_reminders ADD COLUMN handled_by BIGINT NULL REFERENCES users(user_id);
+
+-- Create indexes for expiry scans
+CREATE INDEX idx_customer_vehicles_insurance_expiry ON customer_vehicles(insurance_expiry) WHERE deleted_at IS NULL;
+CREATE INDEX idx_customer_vehicles_registration_expiry ON customer_vehicles(registration_expiry) WHERE deleted_at IS NULL;
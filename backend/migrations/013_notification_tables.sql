@@ -0,0 +1,51 @@
+-- Notification Tables (PostgreSQL with Soft Delete)
+
+-- Customer contact preferences
+ALTER TABLE customers ADD COLUMN notification_channel VARCHAR(20) CHECK (notification_channel IN ('whatsapp', 'sms', 'email', 'none')) DEFAULT 'whatsapp';
+ALTER TABLE customers ADD COLUMN language VARCHAR(5) CHECK (language IN ('id', 'en')) DEFAULT 'id';
+
+-- Notification outbox, also serving as the in-app inbox for staff
+CREATE TABLE notifications (
+    notification_id BIGSERIAL PRIMARY KEY,
+    channel VARCHAR(20) CHECK (channel IN ('whatsapp', 'sms', 'email', 'in_app')) NOT NULL,
+    recipient VARCHAR(255) NOT NULL,
+    customer_id BIGINT NULL,
+    user_id BIGINT NULL,
+    outlet_id BIGINT NULL,
+    template VARCHAR(100) NOT NULL,
+    language VARCHAR(5) NOT NULL,
+    subject VARCHAR(255),
+    body TEXT NOT NULL,
+    reference_type VARCHAR(50),
+    reference_id BIGINT,
+    status VARCHAR(20) CHECK (status IN ('pending', 'sending', 'sent', 'failed')) DEFAULT 'pending',
+    attempts INTEGER DEFAULT 0,
+    max_attempts INTEGER DEFAULT 5,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    sent_at TIMESTAMP NULL,
+    read_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    created_by INTEGER,
+    FOREIGN KEY (customer_id) REFERENCES customers(customer_id) ON DELETE SET NULL,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id)
+);
+
+-- Notification permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('notifications.read', 'View notification outbox', 'notifications', 'read'),
+('notifications.update', 'Retry notifications', 'notifications', 'update');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin', 'Manager') AND p.resource = 'notifications';
+
+-- Create indexes for dispatch and inbox lookups
+CREATE INDEX idx_notifications_dispatch ON notifications(next_attempt_at) WHERE status IN ('pending', 'sending') AND deleted_at IS NULL;
+CREATE INDEX idx_notifications_user_id ON notifications(user_id, created_at) WHERE channel = 'in_app' AND deleted_at IS NULL;
+CREATE INDEX idx_notifications_customer_id ON notifications(customer_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_notifications_reference ON notifications(reference_type, reference_id);
+CREATE INDEX idx_notifications_deleted_at ON notifications(deleted_at);
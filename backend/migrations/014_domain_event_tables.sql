@@ -0,0 +1,47 @@
+-- Domain Event Outbox Tables (PostgreSQL)
+
+-- Events are written in the same database transaction as the change they
+-- describe and dispatched to in-process subscribers afterwards
+CREATE TABLE domain_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    outlet_id BIGINT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) CHECK (status IN ('pending', 'processing', 'processed', 'failed')) DEFAULT 'pending',
+    attempts INTEGER DEFAULT 0,
+    max_attempts INTEGER DEFAULT 10,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    processed_at TIMESTAMP NULL,
+    occurred_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id)
+);
+
+-- Subscribers that have handled an event, so retries only redeliver to the
+-- ones that failed
+CREATE TABLE domain_event_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL,
+    subscriber VARCHAR(100) NOT NULL,
+    delivered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (event_id) REFERENCES domain_events(event_id) ON DELETE CASCADE,
+    UNIQUE (event_id, subscriber)
+);
+
+-- Domain event permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('events.read', 'View domain event outbox', 'events', 'read'),
+('events.update', 'Retry domain events', 'events', 'update');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin') AND p.resource = 'events';
+
+-- Create indexes for dispatch and lookups
+CREATE INDEX idx_domain_events_dispatch ON domain_events(next_attempt_at) WHERE status IN ('pending', 'processing');
+CREATE INDEX idx_domain_events_type ON domain_events(event_type, occurred_at);
+CREATE INDEX idx_domain_events_aggregate ON domain_events(aggregate_type, aggregate_id);
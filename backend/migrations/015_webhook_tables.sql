@@ -0,0 +1,68 @@
+-- Webhook Tables (PostgreSQL with Soft Delete)
+
+-- Endpoints that receive signed domain events
+CREATE TABLE webhook_subscriptions (
+    subscription_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    event_types TEXT[] NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    created_by INTEGER
+);
+
+-- One delivery per subscription and event, retried until it succeeds or
+-- runs out of attempts
+CREATE TABLE webhook_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL,
+    event_id BIGINT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) CHECK (status IN ('pending', 'sending', 'delivered', 'failed')) DEFAULT 'pending',
+    attempts INTEGER DEFAULT 0,
+    max_attempts INTEGER DEFAULT 8,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    response_code INTEGER NULL,
+    last_error TEXT,
+    delivered_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(subscription_id) ON DELETE CASCADE,
+    FOREIGN KEY (event_id) REFERENCES domain_events(event_id) ON DELETE SET NULL,
+    UNIQUE (subscription_id, event_id)
+);
+
+-- Log of every request made for a delivery
+CREATE TABLE webhook_delivery_attempts (
+    attempt_id BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT NOT NULL,
+    response_code INTEGER NULL,
+    response_body TEXT,
+    error TEXT,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    attempted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(delivery_id) ON DELETE CASCADE
+);
+
+-- Webhook permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('webhooks.create', 'Create webhook subscriptions', 'webhooks', 'create'),
+('webhooks.read', 'View webhook subscriptions and deliveries', 'webhooks', 'read'),
+('webhooks.update', 'Update webhook subscriptions and redeliver', 'webhooks', 'update'),
+('webhooks.delete', 'Delete webhook subscriptions', 'webhooks', 'delete');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin') AND p.resource = 'webhooks';
+
+-- Create indexes for dispatch and delivery log lookups
+CREATE INDEX idx_webhook_subscriptions_event_types ON webhook_subscriptions USING GIN (event_types) WHERE is_active = TRUE AND deleted_at IS NULL;
+CREATE INDEX idx_webhook_deliveries_dispatch ON webhook_deliveries(next_attempt_at) WHERE status IN ('pending', 'sending');
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at);
+CREATE INDEX idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id, attempted_at);
+CREATE INDEX idx_webhook_subscriptions_deleted_at ON webhook_subscriptions(deleted_at);
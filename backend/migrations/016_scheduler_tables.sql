@@ -0,0 +1,31 @@
+-- Background Job Scheduler Tables (PostgreSQL)
+
+-- Run history for scheduled jobs. The unique index on scheduled runs lets
+-- only one replica claim each scheduled time of a job.
+CREATE TABLE scheduler_runs (
+    run_id BIGSERIAL PRIMARY KEY,
+    job_name VARCHAR(100) NOT NULL,
+    trigger VARCHAR(20) CHECK (trigger IN ('schedule', 'manual')) NOT NULL,
+    scheduled_for TIMESTAMP NOT NULL,
+    instance VARCHAR(255) NOT NULL,
+    status VARCHAR(20) CHECK (status IN ('running', 'succeeded', 'failed')) DEFAULT 'running',
+    summary TEXT,
+    error TEXT,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL,
+    duration_ms BIGINT NULL
+);
+
+-- Scheduler permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('scheduler.read', 'View scheduled jobs and run history', 'scheduler', 'read'),
+('scheduler.update', 'Run scheduled jobs on demand', 'scheduler', 'update');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin') AND p.resource = 'scheduler';
+
+-- Create indexes for run claims and history lookups
+CREATE UNIQUE INDEX idx_scheduler_runs_scheduled ON scheduler_runs(job_name, scheduled_for) WHERE trigger = 'schedule';
+CREATE INDEX idx_scheduler_runs_job ON scheduler_runs(job_name, started_at);
+CREATE INDEX idx_scheduler_runs_status ON scheduler_runs(status);
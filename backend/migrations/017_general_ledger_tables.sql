@@ -0,0 +1,145 @@
+-- General Ledger Tables (PostgreSQL with Soft Delete)
+
+-- Chart of accounts. System accounts are used by the automatic posting rules
+-- and cannot be deleted.
+CREATE TABLE gl_accounts (
+    account_id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(150) NOT NULL,
+    account_type VARCHAR(20) CHECK (account_type IN ('asset', 'liability', 'equity', 'revenue', 'expense')) NOT NULL,
+    normal_balance VARCHAR(10) CHECK (normal_balance IN ('debit', 'credit')) NOT NULL,
+    parent_id BIGINT NULL,
+    description TEXT,
+    is_system BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    created_by INTEGER,
+    FOREIGN KEY (parent_id) REFERENCES gl_accounts(account_id)
+);
+
+CREATE SEQUENCE gl_journal_entry_number_seq;
+
+-- Journal entries. Entries posted from an operational document carry its
+-- source type and ID, and each document is posted at most once.
+CREATE TABLE gl_journal_entries (
+    entry_id BIGSERIAL PRIMARY KEY,
+    entry_number VARCHAR(50) NOT NULL UNIQUE,
+    outlet_id BIGINT NOT NULL,
+    entry_date DATE NOT NULL,
+    description TEXT NOT NULL,
+    source_type VARCHAR(30) CHECK (source_type IN ('manual', 'transaction', 'payment', 'vehicle_purchase', 'vehicle_sale')) NOT NULL,
+    source_id BIGINT NULL,
+    source_number VARCHAR(50),
+    total_debit DECIMAL(15,2) NOT NULL,
+    total_credit DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by INTEGER,
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    CHECK (total_debit = total_credit AND total_debit > 0),
+    CHECK (source_type = 'manual' OR source_id IS NOT NULL)
+);
+
+-- Journal lines. Each line is either a debit or a credit.
+CREATE TABLE gl_journal_lines (
+    line_id BIGSERIAL PRIMARY KEY,
+    entry_id BIGINT NOT NULL,
+    account_id BIGINT NOT NULL,
+    description TEXT,
+    debit DECIMAL(15,2) NOT NULL DEFAULT 0,
+    credit DECIMAL(15,2) NOT NULL DEFAULT 0,
+    FOREIGN KEY (entry_id) REFERENCES gl_journal_entries(entry_id) ON DELETE CASCADE,
+    FOREIGN KEY (account_id) REFERENCES gl_accounts(account_id),
+    CHECK (debit >= 0 AND credit >= 0 AND (debit = 0) <> (credit = 0))
+);
+
+-- Reject any database transaction that leaves an entry's lines unbalanced or
+-- out of step with its header totals
+CREATE FUNCTION gl_check_entry_balanced() RETURNS TRIGGER AS $$
+DECLARE
+    v_entry_id BIGINT;
+    v_debit DECIMAL(15,2);
+    v_credit DECIMAL(15,2);
+    v_total_debit DECIMAL(15,2);
+BEGIN
+    IF TG_TABLE_NAME = 'gl_journal_entries' THEN
+        v_entry_id := NEW.entry_id;
+    ELSIF TG_OP = 'DELETE' THEN
+        v_entry_id := OLD.entry_id;
+    ELSE
+        v_entry_id := NEW.entry_id;
+    END IF;
+
+    SELECT total_debit INTO v_total_debit FROM gl_journal_entries WHERE entry_id = v_entry_id;
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    SELECT COALESCE(SUM(debit), 0), COALESCE(SUM(credit), 0) INTO v_debit, v_credit
+    FROM gl_journal_lines WHERE entry_id = v_entry_id;
+
+    IF v_debit <> v_credit OR v_debit <> v_total_debit THEN
+        RAISE EXCEPTION 'journal entry % is not balanced: debit %, credit %, header %',
+            v_entry_id, v_debit, v_credit, v_total_debit;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER gl_journal_entries_balanced
+AFTER INSERT OR UPDATE ON gl_journal_entries
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW EXECUTE FUNCTION gl_check_entry_balanced();
+
+CREATE CONSTRAINT TRIGGER gl_journal_lines_balanced
+AFTER INSERT OR UPDATE OR DELETE ON gl_journal_lines
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW EXECUTE FUNCTION gl_check_entry_balanced();
+
+-- Default chart of accounts
+INSERT INTO gl_accounts (code, name, account_type, normal_balance, is_system) VALUES
+('1100', 'Kas', 'asset', 'debit', TRUE),
+('1110', 'Bank', 'asset', 'debit', TRUE),
+('1120', 'Piutang Kartu Kredit', 'asset', 'debit', TRUE),
+('1130', 'Saldo Dompet Digital', 'asset', 'debit', TRUE),
+('1200', 'Piutang Usaha', 'asset', 'debit', TRUE),
+('1210', 'Piutang Pembiayaan Kendaraan', 'asset', 'debit', TRUE),
+('1300', 'Persediaan Suku Cadang', 'asset', 'debit', TRUE),
+('1310', 'Persediaan Kendaraan', 'asset', 'debit', TRUE),
+('1400', 'PPN Masukan', 'asset', 'debit', TRUE),
+('2100', 'Hutang Usaha', 'liability', 'credit', TRUE),
+('2200', 'PPN Keluaran', 'liability', 'credit', TRUE),
+('2300', 'Hutang Komisi Penjualan', 'liability', 'credit', TRUE),
+('3100', 'Modal Pemilik', 'equity', 'credit', TRUE),
+('3200', 'Laba Ditahan', 'equity', 'credit', TRUE),
+('4100', 'Pendapatan Jasa Servis', 'revenue', 'credit', TRUE),
+('4200', 'Penjualan Suku Cadang', 'revenue', 'credit', TRUE),
+('4300', 'Penjualan Kendaraan', 'revenue', 'credit', TRUE),
+('4900', 'Potongan Penjualan', 'revenue', 'debit', TRUE),
+('5100', 'HPP Suku Cadang', 'expense', 'debit', TRUE),
+('5200', 'HPP Kendaraan', 'expense', 'debit', TRUE),
+('6100', 'Beban Komisi Penjualan', 'expense', 'debit', TRUE);
+
+-- Ledger permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('ledger.create', 'Create accounts and manual journal entries', 'ledger', 'create'),
+('ledger.read', 'View chart of accounts, journals and ledger reports', 'ledger', 'read'),
+('ledger.update', 'Update accounts and repost documents', 'ledger', 'update'),
+('ledger.delete', 'Delete accounts', 'ledger', 'delete');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin') AND p.resource = 'ledger';
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'Manager' AND p.name = 'ledger.read';
+
+-- Create indexes for posting and reports
+CREATE UNIQUE INDEX idx_gl_journal_entries_source ON gl_journal_entries(source_type, source_id) WHERE source_type <> 'manual';
+CREATE INDEX idx_gl_journal_entries_outlet_date ON gl_journal_entries(outlet_id, entry_date);
+CREATE INDEX idx_gl_journal_lines_entry ON gl_journal_lines(entry_id);
+CREATE INDEX idx_gl_journal_lines_account ON gl_journal_lines(account_id);
+CREATE INDEX idx_gl_accounts_deleted_at ON gl_accounts(deleted_at);
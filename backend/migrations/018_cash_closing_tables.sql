@@ -0,0 +1,74 @@
+-- Cash Flow Recording and End-of-Day Closing (PostgreSQL with Soft Delete)
+
+-- Payment method of each cash flow, where the document has one
+ALTER TABLE cash_flows ADD COLUMN payment_method_id BIGINT NULL REFERENCES payment_methods(method_id);
+
+-- A closed day is locked: its summary is final and its cash flows cannot change
+ALTER TABLE daily_cash_summaries ADD COLUMN status VARCHAR(20) CHECK (status IN ('open', 'closed')) NOT NULL DEFAULT 'open';
+ALTER TABLE daily_cash_summaries ADD COLUMN closed_at TIMESTAMP NULL;
+ALTER TABLE daily_cash_summaries ADD COLUMN closed_by INTEGER NULL;
+ALTER TABLE daily_cash_summaries ADD COLUMN notes TEXT;
+
+-- Serialises cash flow writes with cash closings of the same outlet
+CREATE FUNCTION cash_lock_outlet(p_outlet_id BIGINT) RETURNS VOID AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('cash_flows'), p_outlet_id::INTEGER);
+END;
+$$ LANGUAGE plpgsql;
+
+-- A day is locked once it or any later day of the outlet is closed, so a
+-- closing balance that was carried forward can no longer change
+CREATE FUNCTION cash_assert_day_open(p_outlet_id BIGINT, p_day DATE) RETURNS VOID AS $$
+DECLARE
+    v_closed_through DATE;
+BEGIN
+    PERFORM cash_lock_outlet(p_outlet_id);
+
+    SELECT MAX(summary_date) INTO v_closed_through
+    FROM daily_cash_summaries
+    WHERE outlet_id = p_outlet_id AND status = 'closed' AND deleted_at IS NULL;
+
+    IF v_closed_through IS NOT NULL AND p_day <= v_closed_through THEN
+        RAISE EXCEPTION 'cash for outlet % is closed through %', p_outlet_id, v_closed_through;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Reject any change to the cash flows of a closed day
+CREATE FUNCTION cash_flows_check_day_open() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM cash_assert_day_open(OLD.outlet_id, OLD.transaction_date);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM cash_assert_day_open(NEW.outlet_id, NEW.transaction_date);
+        RETURN NEW;
+    END IF;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER cash_flows_day_open
+BEFORE INSERT OR UPDATE OR DELETE ON cash_flows
+FOR EACH ROW EXECUTE FUNCTION cash_flows_check_day_open();
+
+-- Cash permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('cash.read', 'View cash flows and daily cash summaries', 'cash', 'read'),
+('cash.close', 'Close the day''s cash for an outlet', 'cash', 'close'),
+('payables.read', 'View supplier payables', 'payables', 'read'),
+('payables.pay', 'Record payments to suppliers', 'payables', 'pay');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin', 'Manager') AND p.resource IN ('cash', 'payables');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'Cashier' AND p.name = 'cash.read';
+
+-- Create indexes for cash flow lookups
+CREATE UNIQUE INDEX idx_cash_flows_reference ON cash_flows(reference_type, reference_id) WHERE reference_type IS NOT NULL AND deleted_at IS NULL;
+CREATE INDEX idx_cash_flows_outlet_date ON cash_flows(outlet_id, transaction_date);
+CREATE INDEX idx_daily_summaries_status ON daily_cash_summaries(outlet_id, status, summary_date);
@@ -0,0 +1,81 @@
+-- Cashier Shift Tables (PostgreSQL)
+
+CREATE SEQUENCE cashier_shift_number_seq;
+
+-- Cashier shifts. A cashier has at most one open shift; payments taken while
+-- it is open belong to it. Cash-type payment methods are reconciled together
+-- against the counted drawer, other methods per method in cashier_shift_tenders.
+CREATE TABLE cashier_shifts (
+    shift_id BIGSERIAL PRIMARY KEY,
+    shift_number VARCHAR(50) NOT NULL UNIQUE,
+    outlet_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    status VARCHAR(20) CHECK (status IN ('open', 'closed', 'approved')) NOT NULL DEFAULT 'open',
+    opening_float DECIMAL(15,2) NOT NULL DEFAULT 0,
+    opened_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    opening_notes TEXT,
+    expected_cash DECIMAL(15,2) NULL,
+    counted_cash DECIMAL(15,2) NULL,
+    cash_variance DECIMAL(15,2) NULL,
+    closed_at TIMESTAMP NULL,
+    closed_by BIGINT NULL,
+    closing_notes TEXT,
+    approved_at TIMESTAMP NULL,
+    approved_by BIGINT NULL,
+    approval_notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id),
+    FOREIGN KEY (closed_by) REFERENCES users(user_id),
+    FOREIGN KEY (approved_by) REFERENCES users(user_id),
+    CHECK (opening_float >= 0)
+);
+
+-- Counted drawer by denomination at close
+CREATE TABLE cashier_shift_denominations (
+    shift_id BIGINT NOT NULL,
+    denomination DECIMAL(15,2) NOT NULL,
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (shift_id, denomination),
+    FOREIGN KEY (shift_id) REFERENCES cashier_shifts(shift_id) ON DELETE CASCADE,
+    CHECK (denomination > 0 AND quantity >= 0)
+);
+
+-- Expected and counted totals of non-cash payment methods at close
+CREATE TABLE cashier_shift_tenders (
+    shift_id BIGINT NOT NULL,
+    payment_method_id BIGINT NOT NULL,
+    payment_count INTEGER NOT NULL DEFAULT 0,
+    expected_amount DECIMAL(15,2) NOT NULL,
+    counted_amount DECIMAL(15,2) NOT NULL,
+    variance DECIMAL(15,2) NOT NULL,
+    PRIMARY KEY (shift_id, payment_method_id),
+    FOREIGN KEY (shift_id) REFERENCES cashier_shifts(shift_id) ON DELETE CASCADE,
+    FOREIGN KEY (payment_method_id) REFERENCES payment_methods(method_id)
+);
+
+-- Payments and the cash flows they record carry the shift they were taken in
+ALTER TABLE payments ADD COLUMN shift_id BIGINT NULL REFERENCES cashier_shifts(shift_id);
+ALTER TABLE cash_flows ADD COLUMN shift_id BIGINT NULL REFERENCES cashier_shifts(shift_id);
+
+-- Shift permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('shifts.open', 'Open and view own cashier shift', 'shifts', 'open'),
+('shifts.close', 'Close own cashier shift', 'shifts', 'close'),
+('shifts.read', 'View cashier shifts and shift reports', 'shifts', 'read'),
+('shifts.approve', 'Sign off closed cashier shifts', 'shifts', 'approve');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin', 'Manager') AND p.resource = 'shifts';
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'Cashier' AND p.name IN ('shifts.open', 'shifts.close');
+
+-- Create indexes for shift lookups
+CREATE UNIQUE INDEX idx_cashier_shifts_open_user ON cashier_shifts(user_id) WHERE status = 'open';
+CREATE INDEX idx_cashier_shifts_outlet_status ON cashier_shifts(outlet_id, status, opened_at);
+CREATE INDEX idx_payments_shift_id ON payments(shift_id);
+CREATE INDEX idx_cash_flows_shift_id ON cash_flows(shift_id);
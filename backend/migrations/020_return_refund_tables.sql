@@ -0,0 +1,147 @@
+-- Returns, Refunds and Credit Notes Tables (PostgreSQL)
+
+CREATE SEQUENCE sales_return_number_seq;
+CREATE SEQUENCE refund_number_seq;
+CREATE SEQUENCE credit_note_number_seq;
+
+-- A transaction whose items were all returned is 'refunded'
+ALTER TABLE transactions DROP CONSTRAINT transactions_payment_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_payment_status_check
+    CHECK (payment_status IN ('pending', 'partial', 'paid', 'refunded', 'cancelled'));
+
+-- Returns of sold spareparts. The returned value carries its share of the
+-- transaction's discount and tax, so it is exactly what the customer paid
+-- for the items.
+CREATE TABLE sales_returns (
+    return_id BIGSERIAL PRIMARY KEY,
+    return_number VARCHAR(50) NOT NULL UNIQUE,
+    transaction_id BIGINT NOT NULL,
+    outlet_id BIGINT NOT NULL,
+    customer_id BIGINT,
+    reason TEXT NOT NULL,
+    restock BOOLEAN NOT NULL DEFAULT TRUE,
+    subtotal_amount DECIMAL(15,2) NOT NULL,
+    discount_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    total_amount DECIMAL(15,2) NOT NULL,
+    cost_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    settlement VARCHAR(20) CHECK (settlement IN ('none', 'refund', 'credit_note')) NOT NULL DEFAULT 'none',
+    return_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(transaction_id),
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (customer_id) REFERENCES customers(customer_id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES users(user_id)
+);
+
+CREATE TABLE sales_return_items (
+    return_item_id BIGSERIAL PRIMARY KEY,
+    return_id BIGINT NOT NULL,
+    transaction_detail_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    quantity DECIMAL(10,3) NOT NULL,
+    unit_price DECIMAL(15,2) NOT NULL,
+    total_price DECIMAL(15,2) NOT NULL,
+    unit_cost DECIMAL(15,2) NOT NULL DEFAULT 0,
+    FOREIGN KEY (return_id) REFERENCES sales_returns(return_id) ON DELETE CASCADE,
+    FOREIGN KEY (transaction_detail_id) REFERENCES transaction_details(detail_id),
+    FOREIGN KEY (product_id) REFERENCES products(product_id),
+    CHECK (quantity > 0)
+);
+
+-- Money paid back to a customer, against a payment, a return or both
+CREATE TABLE refunds (
+    refund_id BIGSERIAL PRIMARY KEY,
+    refund_number VARCHAR(50) NOT NULL UNIQUE,
+    transaction_id BIGINT NOT NULL,
+    payment_id BIGINT,
+    return_id BIGINT,
+    payment_method_id BIGINT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    reason TEXT NOT NULL,
+    refund_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reference_number VARCHAR(100),
+    notes TEXT,
+    shift_id BIGINT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(transaction_id),
+    FOREIGN KEY (payment_id) REFERENCES payments(payment_id),
+    FOREIGN KEY (return_id) REFERENCES sales_returns(return_id),
+    FOREIGN KEY (payment_method_id) REFERENCES payment_methods(method_id),
+    FOREIGN KEY (shift_id) REFERENCES cashier_shifts(shift_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (amount > 0)
+);
+
+-- Store credit owed to a customer, applied to later transactions
+CREATE TABLE credit_notes (
+    credit_note_id BIGSERIAL PRIMARY KEY,
+    credit_note_number VARCHAR(50) NOT NULL UNIQUE,
+    customer_id BIGINT NOT NULL,
+    outlet_id BIGINT NOT NULL,
+    transaction_id BIGINT NOT NULL,
+    return_id BIGINT,
+    amount DECIMAL(15,2) NOT NULL,
+    remaining_amount DECIMAL(15,2) NOT NULL,
+    status VARCHAR(20) CHECK (status IN ('open', 'applied')) NOT NULL DEFAULT 'open',
+    reason TEXT NOT NULL,
+    issue_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (customer_id) REFERENCES customers(customer_id),
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions(transaction_id),
+    FOREIGN KEY (return_id) REFERENCES sales_returns(return_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (amount > 0 AND remaining_amount >= 0 AND remaining_amount <= amount)
+);
+
+CREATE TABLE credit_note_applications (
+    application_id BIGSERIAL PRIMARY KEY,
+    credit_note_id BIGINT NOT NULL,
+    transaction_id BIGINT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    applied_by BIGINT,
+    FOREIGN KEY (credit_note_id) REFERENCES credit_notes(credit_note_id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions(transaction_id),
+    FOREIGN KEY (applied_by) REFERENCES users(user_id),
+    CHECK (amount > 0)
+);
+
+-- Returns, refunds, credit notes and payment reversals post to the ledger
+ALTER TABLE gl_journal_entries DROP CONSTRAINT gl_journal_entries_source_type_check;
+ALTER TABLE gl_journal_entries ADD CONSTRAINT gl_journal_entries_source_type_check
+    CHECK (source_type IN ('manual', 'transaction', 'payment', 'vehicle_purchase', 'vehicle_sale',
+        'payment_reversal', 'sales_return', 'refund', 'credit_note', 'credit_note_application'));
+
+INSERT INTO gl_accounts (code, name, account_type, normal_balance, is_system) VALUES
+('2400', 'Titipan Kredit Pelanggan', 'liability', 'credit', TRUE);
+
+-- Return and refund permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('returns.read', 'View returns, refunds and credit notes', 'returns', 'read'),
+('returns.create', 'Record returns and refunds and apply credit notes', 'returns', 'create');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin', 'Manager') AND p.resource = 'returns';
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'Cashier' AND p.name = 'returns.read';
+
+-- Create indexes for return and refund lookups
+CREATE INDEX idx_sales_returns_transaction_id ON sales_returns(transaction_id);
+CREATE INDEX idx_sales_returns_outlet_date ON sales_returns(outlet_id, return_date);
+CREATE INDEX idx_sales_return_items_detail_id ON sales_return_items(transaction_detail_id);
+CREATE INDEX idx_refunds_transaction_id ON refunds(transaction_id);
+CREATE INDEX idx_refunds_payment_id ON refunds(payment_id);
+CREATE INDEX idx_refunds_shift_id ON refunds(shift_id);
+CREATE INDEX idx_credit_notes_customer_status ON credit_notes(customer_id, status);
+CREATE INDEX idx_credit_notes_transaction_id ON credit_notes(transaction_id);
+CREATE INDEX idx_credit_note_applications_transaction_id ON credit_note_applications(transaction_id);
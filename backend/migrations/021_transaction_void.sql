@@ -0,0 +1,35 @@
+-- Transaction Voids (PostgreSQL)
+
+-- Sales documents are voided, never deleted: the number stays in sequence
+-- and the document shows who voided it, when and why
+ALTER TABLE transactions ADD COLUMN voided_at TIMESTAMP NULL;
+ALTER TABLE transactions ADD COLUMN voided_by BIGINT REFERENCES users(user_id);
+ALTER TABLE transactions ADD COLUMN void_reason TEXT;
+
+ALTER TABLE transactions DROP CONSTRAINT transactions_payment_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_payment_status_check
+    CHECK (payment_status IN ('pending', 'partial', 'paid', 'refunded', 'cancelled', 'void'));
+
+-- Who reversed a payment, for the exceptions report
+ALTER TABLE payments ADD COLUMN deleted_by BIGINT REFERENCES users(user_id);
+
+-- Store credit applied to a voided transaction goes back to its credit note
+ALTER TABLE credit_note_applications ADD COLUMN reversed_at TIMESTAMP NULL;
+
+ALTER TABLE gl_journal_entries DROP CONSTRAINT gl_journal_entries_source_type_check;
+ALTER TABLE gl_journal_entries ADD CONSTRAINT gl_journal_entries_source_type_check
+    CHECK (source_type IN ('manual', 'transaction', 'payment', 'vehicle_purchase', 'vehicle_sale',
+        'payment_reversal', 'sales_return', 'refund', 'credit_note', 'credit_note_application',
+        'transaction_void'));
+
+-- Deleting transactions is replaced by voiding them
+INSERT INTO permissions (name, description, resource, action) VALUES
+('transactions.void', 'Void transactions', 'transactions', 'void'),
+('transactions.exceptions', 'View the exceptions report of voids, reversals, returns and refunds', 'transactions', 'exceptions');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin', 'Manager') AND p.name IN ('transactions.void', 'transactions.exceptions');
+
+CREATE INDEX idx_transactions_voided_at ON transactions(outlet_id, voided_at) WHERE voided_at IS NOT NULL;
+CREATE INDEX idx_payments_deleted_at ON payments(deleted_at) WHERE deleted_at IS NOT NULL;
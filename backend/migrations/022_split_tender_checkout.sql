@@ -0,0 +1,40 @@
+-- Split Tender Checkout (PostgreSQL)
+
+CREATE SEQUENCE payment_checkout_number_seq;
+
+-- A checkout settles a transaction with several tenders at once (cash, card,
+-- e-wallet). Each tender is recorded as a payment of the amount applied to
+-- the transaction; cash handed over above what was due is given back as
+-- change and never reaches the payments, the drawer or the ledger.
+CREATE TABLE payment_checkouts (
+    checkout_id BIGSERIAL PRIMARY KEY,
+    checkout_number VARCHAR(50) NOT NULL UNIQUE,
+    transaction_id BIGINT NOT NULL,
+    outlet_id BIGINT NOT NULL,
+    tendered_amount DECIMAL(15,2) NOT NULL,
+    applied_amount DECIMAL(15,2) NOT NULL,
+    change_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    shift_id BIGINT,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(transaction_id),
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (shift_id) REFERENCES cashier_shifts(shift_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (applied_amount > 0),
+    CHECK (change_amount >= 0),
+    CHECK (tendered_amount = applied_amount + change_amount)
+);
+
+-- What the customer handed over for each payment. Only cash tenders can
+-- exceed the applied amount.
+ALTER TABLE payments ADD COLUMN checkout_id BIGINT REFERENCES payment_checkouts(checkout_id);
+ALTER TABLE payments ADD COLUMN tendered_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN change_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+UPDATE payments SET tendered_amount = amount;
+
+CREATE INDEX idx_payment_checkouts_transaction ON payment_checkouts(transaction_id);
+CREATE INDEX idx_payment_checkouts_outlet_date ON payment_checkouts(outlet_id, created_at);
+CREATE INDEX idx_payments_checkout ON payments(checkout_id) WHERE checkout_id IS NOT NULL;
@@ -0,0 +1,91 @@
+-- Tax Codes and Line-Level Tax (PostgreSQL)
+
+-- Tax codes assignable to products and services. The rate is a percentage;
+-- exempt items use a code with rate 0 so they still show on tax reports.
+CREATE TABLE tax_codes (
+    tax_code_id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    rate DECIMAL(7,4) NOT NULL DEFAULT 0,
+    description TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (rate >= 0 AND rate < 100)
+);
+
+INSERT INTO tax_codes (code, name, rate, description) VALUES
+('PPN11', 'PPN 11%', 11, 'Pajak Pertambahan Nilai (value added tax)'),
+('EXEMPT', 'Tax exempt', 0, 'Not subject to PPN');
+
+-- Whether an outlet's prices include tax, and the tax code of items that
+-- have none of their own
+ALTER TABLE outlets ADD COLUMN prices_include_tax BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE outlets ADD COLUMN default_tax_code_id BIGINT REFERENCES tax_codes(tax_code_id);
+
+ALTER TABLE products ADD COLUMN tax_code_id BIGINT REFERENCES tax_codes(tax_code_id);
+ALTER TABLE services ADD COLUMN tax_code_id BIGINT REFERENCES tax_codes(tax_code_id);
+
+-- Transactions keep how their prices were entered. Each line records its
+-- tax code and rate at the time of sale, its value before tax, its share of
+-- the transaction discount and its tax; the header totals are their sums.
+ALTER TABLE transactions ADD COLUMN prices_include_tax BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE transaction_details ADD COLUMN tax_code_id BIGINT REFERENCES tax_codes(tax_code_id);
+ALTER TABLE transaction_details ADD COLUMN tax_rate DECIMAL(7,4) NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN net_amount DECIMAL(15,2);
+ALTER TABLE transaction_details ADD COLUMN discount_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN tax_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+-- Earlier transactions had their discount and tax typed on the header;
+-- their lines take proportional shares
+UPDATE transaction_details d
+SET net_amount = d.total_price,
+    discount_amount = ROUND(d.total_price * t.discount_amount / t.subtotal_amount, 2),
+    tax_amount = ROUND(d.total_price * t.tax_amount / t.subtotal_amount, 2)
+FROM transactions t
+WHERE t.transaction_id = d.transaction_id AND t.subtotal_amount > t.discount_amount;
+
+UPDATE transaction_details SET net_amount = total_price WHERE net_amount IS NULL;
+ALTER TABLE transaction_details ALTER COLUMN net_amount SET NOT NULL;
+
+ALTER TABLE service_details ADD COLUMN tax_code_id BIGINT REFERENCES tax_codes(tax_code_id);
+ALTER TABLE service_details ADD COLUMN tax_rate DECIMAL(7,4) NOT NULL DEFAULT 0;
+ALTER TABLE service_details ADD COLUMN net_amount DECIMAL(15,2);
+ALTER TABLE service_details ADD COLUMN discount_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE service_details ADD COLUMN tax_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+UPDATE service_details SET net_amount = total_price;
+ALTER TABLE service_details ALTER COLUMN net_amount SET NOT NULL;
+
+-- Returned items carry their line's tax code and their share of its value,
+-- discount and tax
+ALTER TABLE sales_return_items ADD COLUMN tax_code_id BIGINT REFERENCES tax_codes(tax_code_id);
+ALTER TABLE sales_return_items ADD COLUMN tax_rate DECIMAL(7,4) NOT NULL DEFAULT 0;
+ALTER TABLE sales_return_items ADD COLUMN net_amount DECIMAL(15,2);
+ALTER TABLE sales_return_items ADD COLUMN discount_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE sales_return_items ADD COLUMN tax_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+UPDATE sales_return_items ri
+SET net_amount = ri.total_price,
+    discount_amount = ROUND(ri.total_price * sr.discount_amount / sr.subtotal_amount, 2),
+    tax_amount = ROUND(ri.total_price * sr.tax_amount / sr.subtotal_amount, 2)
+FROM sales_returns sr
+WHERE sr.return_id = ri.return_id AND sr.subtotal_amount > 0;
+
+UPDATE sales_return_items SET net_amount = total_price WHERE net_amount IS NULL;
+ALTER TABLE sales_return_items ALTER COLUMN net_amount SET NOT NULL;
+
+INSERT INTO permissions (name, description, resource, action) VALUES
+('taxes.read', 'View tax codes, outlet tax settings and tax reports', 'taxes', 'read'),
+('taxes.manage', 'Manage tax codes, their assignment and outlet tax settings', 'taxes', 'manage');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE (r.name IN ('Super Admin', 'Admin') AND p.resource = 'taxes')
+   OR (r.name IN ('Manager', 'Cashier') AND p.name = 'taxes.read');
+
+CREATE INDEX idx_transaction_details_tax_code ON transaction_details(tax_code_id);
+CREATE INDEX idx_sales_return_items_tax_code ON sales_return_items(tax_code_id);
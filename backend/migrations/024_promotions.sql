@@ -0,0 +1,107 @@
+-- Promotions and Coupons (PostgreSQL)
+
+-- A promotion takes a percentage or a fixed amount off each line it covers
+-- (scope line) or off the lines it covers together (scope invoice). It
+-- covers every line unless limited to a product or service category, and
+-- only applies once those lines reach min_spend. Fixed amounts and min_spend
+-- are entered like the outlet's prices. Promotions that require a coupon
+-- only apply when one of their coupon codes is given.
+CREATE TABLE promotions (
+    promotion_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    discount_type VARCHAR(20) NOT NULL,
+    discount_value DECIMAL(15,2) NOT NULL,
+    scope VARCHAR(20) NOT NULL DEFAULT 'invoice',
+    min_spend DECIMAL(15,2) NOT NULL DEFAULT 0,
+    max_discount DECIMAL(15,2),
+    product_category_id BIGINT,
+    service_category_id BIGINT,
+    outlet_id BIGINT,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    usage_limit INTEGER,
+    per_customer_limit INTEGER,
+    requires_coupon BOOLEAN NOT NULL DEFAULT FALSE,
+    priority INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (product_category_id) REFERENCES categories(category_id),
+    FOREIGN KEY (service_category_id) REFERENCES service_categories(service_category_id),
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (discount_type IN ('percentage', 'fixed')),
+    CHECK (scope IN ('line', 'invoice')),
+    CHECK (discount_value > 0),
+    CHECK (discount_type = 'fixed' OR discount_value <= 100),
+    CHECK (min_spend >= 0),
+    CHECK (max_discount IS NULL OR max_discount > 0),
+    CHECK (ends_at IS NULL OR starts_at IS NULL OR ends_at > starts_at),
+    CHECK (usage_limit IS NULL OR usage_limit > 0),
+    CHECK (per_customer_limit IS NULL OR per_customer_limit > 0)
+);
+
+-- Codes customers present to unlock a promotion, each with its own usage
+-- limit (1 for single-use codes) and expiry
+CREATE TABLE coupons (
+    coupon_id BIGSERIAL PRIMARY KEY,
+    promotion_id BIGINT NOT NULL,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    usage_limit INTEGER,
+    expires_at TIMESTAMP,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (promotion_id) REFERENCES promotions(promotion_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (usage_limit IS NULL OR usage_limit > 0)
+);
+
+-- Each promotion applied to a transaction. Usage limits count redemptions
+-- that were not reversed by a void.
+CREATE TABLE promotion_redemptions (
+    redemption_id BIGSERIAL PRIMARY KEY,
+    promotion_id BIGINT NOT NULL,
+    coupon_id BIGINT,
+    transaction_id BIGINT NOT NULL,
+    outlet_id BIGINT NOT NULL,
+    customer_id BIGINT,
+    discount_amount DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reversed_at TIMESTAMP,
+    FOREIGN KEY (promotion_id) REFERENCES promotions(promotion_id),
+    FOREIGN KEY (coupon_id) REFERENCES coupons(coupon_id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions(transaction_id),
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (customer_id) REFERENCES customers(customer_id),
+    CHECK (discount_amount > 0)
+);
+
+-- The promotion applied to each line and what it took off, as entered like
+-- the prices. The line's discount_amount includes it.
+ALTER TABLE transaction_details ADD COLUMN promotion_id BIGINT REFERENCES promotions(promotion_id);
+ALTER TABLE transaction_details ADD COLUMN promotion_discount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE service_details ADD COLUMN promotion_id BIGINT REFERENCES promotions(promotion_id);
+ALTER TABLE service_details ADD COLUMN promotion_discount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+-- A coupon presented for a service job is used for its estimate and carried
+-- to its transaction
+ALTER TABLE service_jobs ADD COLUMN coupon_code VARCHAR(50);
+
+INSERT INTO permissions (name, description, resource, action) VALUES
+('promotions.read', 'View promotions, coupons and redemptions', 'promotions', 'read'),
+('promotions.manage', 'Manage promotions and coupons', 'promotions', 'manage');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE (r.name IN ('Super Admin', 'Admin', 'Manager') AND p.resource = 'promotions')
+   OR (r.name IN ('Cashier', 'Customer Service') AND p.name = 'promotions.read');
+
+CREATE INDEX idx_promotions_active ON promotions(is_active, requires_coupon);
+CREATE INDEX idx_coupons_promotion ON coupons(promotion_id);
+CREATE INDEX idx_promotion_redemptions_promotion ON promotion_redemptions(promotion_id) WHERE reversed_at IS NULL;
+CREATE INDEX idx_promotion_redemptions_coupon ON promotion_redemptions(coupon_id) WHERE reversed_at IS NULL;
+CREATE INDEX idx_promotion_redemptions_transaction ON promotion_redemptions(transaction_id);
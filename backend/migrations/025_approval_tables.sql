@@ -0,0 +1,85 @@
+-- Manager Approval Tables (PostgreSQL)
+
+-- How far a role may discount an invoice or price a line below its list
+-- price on its own. Roles without a row are not limited.
+CREATE TABLE approval_thresholds (
+    role_id BIGINT PRIMARY KEY,
+    max_discount_percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+    max_price_override_percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_by BIGINT,
+    FOREIGN KEY (role_id) REFERENCES roles(role_id) ON DELETE CASCADE,
+    FOREIGN KEY (updated_by) REFERENCES users(user_id),
+    CHECK (max_discount_percent BETWEEN 0 AND 100),
+    CHECK (max_price_override_percent BETWEEN 0 AND 100)
+);
+
+-- The PIN an approver types on a cashier's terminal to approve there
+ALTER TABLE users ADD COLUMN approval_pin_hash VARCHAR(255);
+
+-- Discounts and price overrides above the requesting user's threshold.
+-- The row is the approval log: who asked, who decided, how and when.
+CREATE TABLE approval_requests (
+    approval_id BIGSERIAL PRIMARY KEY,
+    request_type VARCHAR(20) CHECK (request_type IN ('discount', 'price_override')) NOT NULL,
+    status VARCHAR(20) CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')) NOT NULL DEFAULT 'pending',
+    outlet_id BIGINT NOT NULL,
+    transaction_id BIGINT,
+    transaction_detail_id BIGINT,
+    service_job_id BIGINT,
+    service_detail_id BIGINT,
+    product_id BIGINT,
+    service_id BIGINT,
+    list_price DECIMAL(15,2),
+    requested_price DECIMAL(15,2),
+    subtotal_amount DECIMAL(15,2),
+    discount_amount DECIMAL(15,2),
+    percent DECIMAL(7,2) NOT NULL,
+    threshold_percent DECIMAL(5,2) NOT NULL,
+    requested_by BIGINT NOT NULL,
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    decided_by BIGINT,
+    decided_at TIMESTAMP,
+    decision_method VARCHAR(10) CHECK (decision_method IN ('in_app', 'pin')),
+    decision_notes TEXT,
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions(transaction_id),
+    FOREIGN KEY (transaction_detail_id) REFERENCES transaction_details(detail_id),
+    FOREIGN KEY (service_job_id) REFERENCES service_jobs(job_id) ON DELETE CASCADE,
+    FOREIGN KEY (service_detail_id) REFERENCES service_details(detail_id) ON DELETE SET NULL,
+    FOREIGN KEY (product_id) REFERENCES products(product_id),
+    FOREIGN KEY (service_id) REFERENCES services(service_id),
+    FOREIGN KEY (requested_by) REFERENCES users(user_id),
+    FOREIGN KEY (decided_by) REFERENCES users(user_id),
+    CHECK (transaction_id IS NOT NULL OR service_job_id IS NOT NULL)
+);
+
+-- Transactions with a pending request cannot be paid; rejected ones have to
+-- be voided. Service job lines with a pending request hold up invoicing.
+ALTER TABLE transactions ADD COLUMN approval_status VARCHAR(20) NOT NULL DEFAULT 'approved'
+    CHECK (approval_status IN ('approved', 'pending', 'rejected'));
+ALTER TABLE service_details ADD COLUMN approval_status VARCHAR(20) NOT NULL DEFAULT 'approved'
+    CHECK (approval_status IN ('approved', 'pending', 'rejected'));
+
+INSERT INTO permissions (name, description, resource, action) VALUES
+('approvals.read', 'View approval requests and thresholds', 'approvals', 'read'),
+('approvals.approve', 'Approve or reject discounts and price overrides', 'approvals', 'approve'),
+('approvals.manage', 'Manage approval thresholds per role', 'approvals', 'manage');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE (r.name IN ('Super Admin', 'Admin') AND p.resource = 'approvals')
+   OR (r.name = 'Manager' AND p.name IN ('approvals.read', 'approvals.approve'))
+   OR (r.name IN ('Cashier', 'Customer Service', 'Technician') AND p.name = 'approvals.read');
+
+-- Front-line roles need a manager for anything beyond a small discount
+INSERT INTO approval_thresholds (role_id, max_discount_percent, max_price_override_percent)
+SELECT role_id, 5, 0 FROM roles WHERE name IN ('Cashier', 'Customer Service');
+
+INSERT INTO approval_thresholds (role_id, max_discount_percent, max_price_override_percent)
+SELECT role_id, 0, 0 FROM roles WHERE name = 'Technician';
+
+CREATE INDEX idx_approval_requests_status ON approval_requests(outlet_id, status, requested_at);
+CREATE INDEX idx_approval_requests_transaction ON approval_requests(transaction_id);
+CREATE INDEX idx_approval_requests_service_job ON approval_requests(service_job_id);
+CREATE INDEX idx_approval_requests_service_detail ON approval_requests(service_detail_id) WHERE status = 'pending';
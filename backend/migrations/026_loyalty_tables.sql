@@ -0,0 +1,98 @@
+-- Loyalty Points Tables (PostgreSQL)
+
+-- Program settings, one row. Redeemed points are worth point_value Rupiah
+-- each; earned points expire after expiry_months, or never when it is 0.
+CREATE TABLE loyalty_settings (
+    settings_id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (settings_id),
+    is_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    point_value DECIMAL(15,2) NOT NULL DEFAULT 100,
+    min_redeem_points INTEGER NOT NULL DEFAULT 0,
+    expiry_months INTEGER NOT NULL DEFAULT 12,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_by BIGINT,
+    FOREIGN KEY (updated_by) REFERENCES users(user_id),
+    CHECK (point_value > 0 AND min_redeem_points >= 0 AND expiry_months >= 0)
+);
+
+-- Points earned per spend_amount Rupiah paid, per transaction type.
+-- Transaction types without an active rule earn nothing.
+CREATE TABLE loyalty_earn_rules (
+    rule_id BIGSERIAL PRIMARY KEY,
+    transaction_type VARCHAR(20) CHECK (transaction_type IN ('service', 'sparepart_sale', 'vehicle_sale')) NOT NULL UNIQUE,
+    spend_amount DECIMAL(15,2) NOT NULL,
+    points INTEGER NOT NULL,
+    min_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (spend_amount > 0 AND points > 0 AND min_amount >= 0)
+);
+
+-- The points ledger. A customer's balance is the sum of their entries.
+-- Earned points and positive adjustments are lots that expire; redemptions,
+-- expiries and negative adjustments use up the oldest lots first.
+CREATE TABLE loyalty_point_entries (
+    entry_id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL,
+    entry_type VARCHAR(20) CHECK (entry_type IN ('earn', 'earn_reversal', 'redeem', 'redeem_reversal', 'expire', 'adjust')) NOT NULL,
+    points INTEGER NOT NULL,
+    amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    transaction_id BIGINT,
+    payment_id BIGINT,
+    refund_id BIGINT,
+    rate_amount DECIMAL(15,2),
+    rate_points INTEGER,
+    expires_at TIMESTAMP,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (customer_id) REFERENCES customers(customer_id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions(transaction_id),
+    FOREIGN KEY (payment_id) REFERENCES payments(payment_id),
+    FOREIGN KEY (refund_id) REFERENCES refunds(refund_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (points <> 0)
+);
+
+-- Points are a tender: redeeming them is a payment, booked as a discount
+ALTER TABLE payment_methods DROP CONSTRAINT payment_methods_type_check;
+ALTER TABLE payment_methods ADD CONSTRAINT payment_methods_type_check
+    CHECK (type IN ('cash', 'bank_transfer', 'credit_card', 'debit_card', 'e_wallet', 'check', 'loyalty_points'));
+
+INSERT INTO payment_methods (name, type, is_active) VALUES
+('Poin Loyalitas', 'loyalty_points', TRUE);
+
+INSERT INTO loyalty_settings (settings_id) VALUES (TRUE);
+
+-- Balances entered by hand before the ledger become its opening entries
+INSERT INTO loyalty_point_entries (customer_id, entry_type, points, expires_at, description)
+SELECT customer_id, 'adjust', loyalty_points, CURRENT_TIMESTAMP + INTERVAL '12 months', 'Opening balance'
+FROM customers WHERE COALESCE(loyalty_points, 0) <> 0;
+
+UPDATE customers SET loyalty_points = 0 WHERE loyalty_points IS NULL;
+ALTER TABLE customers ALTER COLUMN loyalty_points SET NOT NULL;
+
+INSERT INTO loyalty_earn_rules (transaction_type, spend_amount, points) VALUES
+('service', 10000, 1),
+('sparepart_sale', 10000, 1);
+
+-- Loyalty permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('loyalty.read', 'View loyalty balances, point statements and earn rules', 'loyalty', 'read'),
+('loyalty.adjust', 'Adjust customer loyalty points', 'loyalty', 'adjust'),
+('loyalty.manage', 'Manage loyalty settings and earn rules', 'loyalty', 'manage');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE (r.name IN ('Super Admin', 'Admin') AND p.resource = 'loyalty')
+   OR (r.name = 'Manager' AND p.name IN ('loyalty.read', 'loyalty.adjust'))
+   OR (r.name IN ('Cashier', 'Customer Service') AND p.name = 'loyalty.read');
+
+-- Create indexes for balances, statements and postings
+CREATE INDEX idx_loyalty_point_entries_customer ON loyalty_point_entries(customer_id, created_at);
+CREATE INDEX idx_loyalty_point_entries_transaction ON loyalty_point_entries(transaction_id);
+CREATE INDEX idx_loyalty_point_entries_expiry ON loyalty_point_entries(expires_at) WHERE expires_at IS NOT NULL;
+CREATE UNIQUE INDEX idx_loyalty_point_entries_payment ON loyalty_point_entries(payment_id, entry_type) WHERE payment_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_loyalty_point_entries_refund ON loyalty_point_entries(refund_id) WHERE refund_id IS NOT NULL;
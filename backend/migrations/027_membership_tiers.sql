@@ -0,0 +1,69 @@
+-- Membership Tier Tables (PostgreSQL)
+
+-- Tiers a customer reaches by what they spent over the last 12 months. The
+-- highest active tier whose min_spend is reached applies. Its members get
+-- discount_percent off service and sparepart invoices, earn loyalty points
+-- times point_multiplier and, with priority_queue, go ahead of other
+-- customers of the same job priority in the service queue.
+CREATE TABLE membership_tiers (
+    tier_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    min_spend DECIMAL(15,2) NOT NULL,
+    discount_percent DECIMAL(5,2) NOT NULL DEFAULT 0,
+    point_multiplier DECIMAL(5,2) NOT NULL DEFAULT 1,
+    priority_queue BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (min_spend >= 0 AND discount_percent >= 0 AND discount_percent <= 100 AND point_multiplier >= 1)
+);
+
+-- A customer's current tier and the rolling spend it was last evaluated on
+ALTER TABLE customers ADD COLUMN membership_tier_id BIGINT REFERENCES membership_tiers(tier_id);
+ALTER TABLE customers ADD COLUMN membership_spend DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE customers ADD COLUMN membership_evaluated_at TIMESTAMP;
+
+-- Every tier change of a customer. A customer without a tier has a NULL
+-- tier on that side of the change.
+CREATE TABLE customer_tier_history (
+    history_id BIGSERIAL PRIMARY KEY,
+    customer_id BIGINT NOT NULL,
+    from_tier_id BIGINT,
+    to_tier_id BIGINT,
+    change_type VARCHAR(20) CHECK (change_type IN ('upgrade', 'downgrade')) NOT NULL,
+    rolling_spend DECIMAL(15,2) NOT NULL,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (customer_id) REFERENCES customers(customer_id),
+    FOREIGN KEY (from_tier_id) REFERENCES membership_tiers(tier_id),
+    FOREIGN KEY (to_tier_id) REFERENCES membership_tiers(tier_id)
+);
+
+-- The tier discount given on an invoice, part of its discount_amount
+ALTER TABLE transactions ADD COLUMN membership_tier_id BIGINT REFERENCES membership_tiers(tier_id);
+ALTER TABLE transactions ADD COLUMN membership_discount DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE service_jobs ADD COLUMN membership_discount DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+-- Points are earned at the tier multiplier the transaction first earned at
+ALTER TABLE loyalty_point_entries ADD COLUMN rate_multiplier DECIMAL(5,2);
+
+INSERT INTO membership_tiers (name, min_spend, discount_percent, point_multiplier, priority_queue) VALUES
+('Silver', 5000000, 0, 1.25, FALSE),
+('Gold', 15000000, 5, 1.5, FALSE),
+('Platinum', 40000000, 10, 2, TRUE);
+
+-- Membership permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('memberships.read', 'View membership tiers and customer tier history', 'memberships', 'read'),
+('memberships.manage', 'Manage membership tiers and run tier evaluation', 'memberships', 'manage');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE (r.name IN ('Super Admin', 'Admin') AND p.resource = 'memberships')
+   OR (r.name IN ('Manager', 'Cashier', 'Customer Service') AND p.name = 'memberships.read');
+
+-- Create indexes for tier lookups, history and rolling spend
+CREATE INDEX idx_customers_membership_tier ON customers(membership_tier_id);
+CREATE INDEX idx_customer_tier_history_customer ON customer_tier_history(customer_id, changed_at);
+CREATE INDEX idx_transactions_customer_date ON transactions(customer_id, transaction_date);
@@ -0,0 +1,97 @@
+-- Stored Value Tables (PostgreSQL)
+
+-- Money held for customers until they spend it: a prepaid deposit per
+-- customer, typically for fleet service packages, and gift vouchers
+-- redeemed by code. Single-use vouchers are spent in one payment and what is
+-- left of them lapses; multi-use vouchers and deposits can be spent in parts
+-- and topped up. An account whose expires_at has passed lapses in full.
+CREATE TABLE stored_value_accounts (
+    account_id BIGSERIAL PRIMARY KEY,
+    account_type VARCHAR(20) CHECK (account_type IN ('deposit', 'voucher')) NOT NULL,
+    customer_id BIGINT,
+    code VARCHAR(30) UNIQUE,
+    single_use BOOLEAN NOT NULL DEFAULT FALSE,
+    balance DECIMAL(15,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) CHECK (status IN ('active', 'used', 'expired')) NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMP,
+    outlet_id BIGINT NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (customer_id) REFERENCES customers(customer_id),
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (balance >= 0),
+    CHECK (account_type = 'voucher' OR customer_id IS NOT NULL),
+    CHECK ((account_type = 'voucher') = (code IS NOT NULL)),
+    CHECK (account_type = 'voucher' OR NOT single_use)
+);
+
+-- The stored value ledger. An account's balance is the sum of its entries.
+-- Top-ups are paid into the drawer; redemptions pay for transactions and
+-- refunds to the stored value method are redemption reversals.
+CREATE TABLE stored_value_entries (
+    entry_id BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL,
+    entry_type VARCHAR(20) CHECK (entry_type IN ('top_up', 'redeem', 'redeem_reversal', 'expire', 'expire_reversal')) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    outlet_id BIGINT NOT NULL,
+    transaction_id BIGINT,
+    payment_id BIGINT,
+    refund_id BIGINT,
+    payment_method_id BIGINT,
+    shift_id BIGINT,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (account_id) REFERENCES stored_value_accounts(account_id),
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (transaction_id) REFERENCES transactions(transaction_id),
+    FOREIGN KEY (payment_id) REFERENCES payments(payment_id),
+    FOREIGN KEY (refund_id) REFERENCES refunds(refund_id),
+    FOREIGN KEY (payment_method_id) REFERENCES payment_methods(method_id),
+    FOREIGN KEY (shift_id) REFERENCES cashier_shifts(shift_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (amount <> 0)
+);
+
+-- Stored value is a tender: redeeming it settles a transaction out of the
+-- liability held for the customer
+ALTER TABLE payment_methods DROP CONSTRAINT payment_methods_type_check;
+ALTER TABLE payment_methods ADD CONSTRAINT payment_methods_type_check
+    CHECK (type IN ('cash', 'bank_transfer', 'credit_card', 'debit_card', 'e_wallet', 'check', 'loyalty_points',
+        'stored_value'));
+
+INSERT INTO payment_methods (name, type, is_active) VALUES
+('Deposit & Voucher', 'stored_value', TRUE);
+
+-- Top-ups are held as a liability until spent; lapsed balances are income
+INSERT INTO gl_accounts (code, name, account_type, normal_balance, is_system) VALUES
+('2500', 'Uang Muka & Voucher Pelanggan', 'liability', 'credit', TRUE),
+('4800', 'Pendapatan Saldo Kedaluwarsa', 'revenue', 'credit', TRUE);
+
+ALTER TABLE gl_journal_entries DROP CONSTRAINT gl_journal_entries_source_type_check;
+ALTER TABLE gl_journal_entries ADD CONSTRAINT gl_journal_entries_source_type_check
+    CHECK (source_type IN ('manual', 'transaction', 'payment', 'vehicle_purchase', 'vehicle_sale',
+        'payment_reversal', 'sales_return', 'refund', 'credit_note', 'credit_note_application',
+        'transaction_void', 'stored_value_entry'));
+
+-- Stored value permissions
+INSERT INTO permissions (name, description, resource, action) VALUES
+('stored_value.read', 'View deposits, vouchers and their statements', 'stored_value', 'read'),
+('stored_value.sell', 'Issue vouchers and top up deposits and vouchers', 'stored_value', 'sell');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE (r.name IN ('Super Admin', 'Admin', 'Manager', 'Cashier') AND p.resource = 'stored_value')
+   OR (r.name = 'Customer Service' AND p.name = 'stored_value.read');
+
+-- Create indexes for accounts, statements and postings
+CREATE UNIQUE INDEX idx_stored_value_accounts_deposit ON stored_value_accounts(customer_id) WHERE account_type = 'deposit';
+CREATE INDEX idx_stored_value_accounts_customer ON stored_value_accounts(customer_id);
+CREATE INDEX idx_stored_value_accounts_expiry ON stored_value_accounts(expires_at) WHERE expires_at IS NOT NULL;
+CREATE INDEX idx_stored_value_entries_account ON stored_value_entries(account_id, created_at);
+CREATE INDEX idx_stored_value_entries_transaction ON stored_value_entries(transaction_id);
+CREATE UNIQUE INDEX idx_stored_value_entries_payment ON stored_value_entries(payment_id, entry_type) WHERE payment_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_stored_value_entries_refund ON stored_value_entries(refund_id, account_id) WHERE refund_id IS NOT NULL;
@@ -0,0 +1,101 @@
+-- Service Packages (PostgreSQL)
+
+-- A package such as "Servis Berkala 10.000 km" sells several services and
+-- products together. It is priced at a fixed bundle price or at a
+-- percentage off what its components cost at their list prices, entered
+-- like the outlet's prices. Packages without an outlet are sold at every
+-- outlet.
+CREATE TABLE service_packages (
+    package_id BIGSERIAL PRIMARY KEY,
+    package_code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    pricing_type VARCHAR(20) NOT NULL DEFAULT 'fixed',
+    package_price DECIMAL(15,2),
+    discount_percent DECIMAL(5,2),
+    outlet_id BIGINT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (pricing_type IN ('fixed', 'discount')),
+    CHECK (pricing_type <> 'fixed' OR (package_price IS NOT NULL AND package_price >= 0)),
+    CHECK (pricing_type <> 'discount' OR (discount_percent IS NOT NULL AND discount_percent > 0 AND discount_percent <= 100))
+);
+
+-- The services and products in one package
+CREATE TABLE service_package_components (
+    component_id BIGSERIAL PRIMARY KEY,
+    package_id BIGINT NOT NULL,
+    product_id BIGINT,
+    service_id BIGINT,
+    quantity DECIMAL(10,3) NOT NULL DEFAULT 1,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (package_id) REFERENCES service_packages(package_id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(product_id),
+    FOREIGN KEY (service_id) REFERENCES services(service_id),
+    CHECK ((product_id IS NULL) <> (service_id IS NULL)),
+    CHECK (quantity > 0)
+);
+
+-- Each package sold on a transaction or put on a service job, with what its
+-- components cost at list prices and what the package was sold for. The
+-- package's component lines point at it.
+CREATE TABLE transaction_packages (
+    transaction_package_id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL,
+    package_id BIGINT NOT NULL,
+    quantity DECIMAL(10,3) NOT NULL,
+    list_amount DECIMAL(15,2) NOT NULL,
+    package_amount DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(transaction_id) ON DELETE CASCADE,
+    FOREIGN KEY (package_id) REFERENCES service_packages(package_id),
+    CHECK (quantity > 0)
+);
+
+CREATE TABLE service_job_packages (
+    service_job_package_id BIGSERIAL PRIMARY KEY,
+    service_job_id BIGINT NOT NULL,
+    package_id BIGINT NOT NULL,
+    quantity DECIMAL(10,3) NOT NULL,
+    list_amount DECIMAL(15,2) NOT NULL,
+    package_amount DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (service_job_id) REFERENCES service_jobs(job_id) ON DELETE CASCADE,
+    FOREIGN KEY (package_id) REFERENCES service_packages(package_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (quantity > 0)
+);
+
+-- A component line's unit price is its share of the package price; the
+-- component's own list price is kept beside it for reporting
+ALTER TABLE transaction_details ADD COLUMN transaction_package_id BIGINT
+    REFERENCES transaction_packages(transaction_package_id) ON DELETE CASCADE;
+ALTER TABLE transaction_details ADD COLUMN list_price DECIMAL(15,2);
+
+ALTER TABLE service_details ADD COLUMN service_job_package_id BIGINT
+    REFERENCES service_job_packages(service_job_package_id) ON DELETE CASCADE;
+ALTER TABLE service_details ADD COLUMN list_price DECIMAL(15,2);
+
+INSERT INTO permissions (name, description, resource, action) VALUES
+('packages.read', 'View service packages and package sales', 'packages', 'read'),
+('packages.manage', 'Manage service packages', 'packages', 'manage');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE (r.name IN ('Super Admin', 'Admin', 'Manager') AND p.resource = 'packages')
+   OR (r.name IN ('Technician', 'Cashier', 'Customer Service') AND p.name = 'packages.read');
+
+CREATE INDEX idx_service_packages_active ON service_packages(is_active, outlet_id);
+CREATE INDEX idx_service_package_components_package ON service_package_components(package_id, sort_order);
+CREATE INDEX idx_transaction_packages_transaction ON transaction_packages(transaction_id);
+CREATE INDEX idx_transaction_packages_package ON transaction_packages(package_id);
+CREATE INDEX idx_service_job_packages_job ON service_job_packages(service_job_id);
+CREATE INDEX idx_transaction_details_package ON transaction_details(transaction_package_id)
+    WHERE transaction_package_id IS NOT NULL;
+CREATE INDEX idx_service_details_package ON service_details(service_job_package_id)
+    WHERE service_job_package_id IS NOT NULL;
@@ -0,0 +1,101 @@
+-- Price Lists (PostgreSQL)
+
+-- A price list sets its own prices for some products and services, entered
+-- like the outlet's prices. It applies while it is valid to the sales that
+-- match all of its limits: an outlet, a customer type and, when customers
+-- are assigned to it, those customers only. The most specific list wins
+-- (assigned customers, then customer type, then outlet), then the highest
+-- priority. Items on no applicable list sell at their own price.
+CREATE TABLE price_lists (
+    price_list_id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    customer_type VARCHAR(20),
+    outlet_id BIGINT,
+    valid_from TIMESTAMP,
+    valid_to TIMESTAMP,
+    priority INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id),
+    CHECK (customer_type IS NULL OR customer_type IN ('individual', 'corporate')),
+    CHECK (valid_to IS NULL OR valid_from IS NULL OR valid_to > valid_from)
+);
+
+-- Customers a price list is negotiated for
+CREATE TABLE price_list_customers (
+    price_list_id BIGINT NOT NULL,
+    customer_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    PRIMARY KEY (price_list_id, customer_id),
+    FOREIGN KEY (price_list_id) REFERENCES price_lists(price_list_id) ON DELETE CASCADE,
+    FOREIGN KEY (customer_id) REFERENCES customers(customer_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id)
+);
+
+CREATE TABLE price_list_items (
+    item_id BIGSERIAL PRIMARY KEY,
+    price_list_id BIGINT NOT NULL,
+    product_id BIGINT,
+    service_id BIGINT,
+    price DECIMAL(15,2) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_by BIGINT,
+    FOREIGN KEY (price_list_id) REFERENCES price_lists(price_list_id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(product_id),
+    FOREIGN KEY (service_id) REFERENCES services(service_id),
+    FOREIGN KEY (updated_by) REFERENCES users(user_id),
+    CHECK ((product_id IS NULL) <> (service_id IS NULL)),
+    CHECK (price >= 0)
+);
+
+-- Every change to an item's own price or to its price on a list. new_price
+-- is empty when the item was taken off the list.
+CREATE TABLE price_history (
+    history_id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT,
+    service_id BIGINT,
+    price_list_id BIGINT,
+    old_price DECIMAL(15,2),
+    new_price DECIMAL(15,2),
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    changed_by BIGINT,
+    FOREIGN KEY (product_id) REFERENCES products(product_id),
+    FOREIGN KEY (service_id) REFERENCES services(service_id),
+    FOREIGN KEY (price_list_id) REFERENCES price_lists(price_list_id) ON DELETE CASCADE,
+    FOREIGN KEY (changed_by) REFERENCES users(user_id),
+    CHECK ((product_id IS NULL) <> (service_id IS NULL))
+);
+
+-- The price list a line's price was taken from
+ALTER TABLE transaction_details ADD COLUMN price_list_id BIGINT REFERENCES price_lists(price_list_id);
+ALTER TABLE service_details ADD COLUMN price_list_id BIGINT REFERENCES price_lists(price_list_id);
+
+-- Today's prices start the history
+INSERT INTO price_history (product_id, new_price, changed_at)
+SELECT product_id, selling_price, COALESCE(updated_at, CURRENT_TIMESTAMP) FROM products WHERE deleted_at IS NULL;
+INSERT INTO price_history (service_id, new_price, changed_at)
+SELECT service_id, standard_price, COALESCE(updated_at, CURRENT_TIMESTAMP) FROM services WHERE deleted_at IS NULL;
+
+INSERT INTO permissions (name, description, resource, action) VALUES
+('price_lists.read', 'View price lists, resolved prices and price history', 'price_lists', 'read'),
+('price_lists.manage', 'Manage price lists, their prices and customers', 'price_lists', 'manage');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE (r.name IN ('Super Admin', 'Admin', 'Manager') AND p.resource = 'price_lists')
+   OR (r.name IN ('Technician', 'Cashier', 'Customer Service') AND p.name = 'price_lists.read');
+
+CREATE INDEX idx_price_lists_active ON price_lists(is_active, outlet_id);
+CREATE INDEX idx_price_list_customers_customer ON price_list_customers(customer_id);
+CREATE UNIQUE INDEX idx_price_list_items_product ON price_list_items(price_list_id, product_id) WHERE product_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_price_list_items_service ON price_list_items(price_list_id, service_id) WHERE service_id IS NOT NULL;
+CREATE INDEX idx_price_list_items_product_lookup ON price_list_items(product_id) WHERE product_id IS NOT NULL;
+CREATE INDEX idx_price_list_items_service_lookup ON price_list_items(service_id) WHERE service_id IS NOT NULL;
+CREATE INDEX idx_price_history_product ON price_history(product_id, changed_at) WHERE product_id IS NOT NULL;
+CREATE INDEX idx_price_history_service ON price_history(service_id, changed_at) WHERE service_id IS NOT NULL;
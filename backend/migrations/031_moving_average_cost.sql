@@ -0,0 +1,75 @@
+-- Moving-Average Cost (PostgreSQL)
+
+CREATE SEQUENCE goods_receipt_number_seq;
+
+-- Goods received into stock. Each receipt moves the cost price of its
+-- products to the weighted average of the stock on hand at its old cost and
+-- the goods received at their unit cost, and posts them to parts inventory
+-- against payables.
+CREATE TABLE goods_receipts (
+    receipt_id BIGSERIAL PRIMARY KEY,
+    receipt_number VARCHAR(50) NOT NULL UNIQUE,
+    outlet_id BIGINT NOT NULL,
+    supplier_id BIGINT,
+    purchase_order_id BIGINT,
+    supplier_reference VARCHAR(100),
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    total_cost DECIMAL(15,2) NOT NULL DEFAULT 0,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_by BIGINT,
+    FOREIGN KEY (outlet_id) REFERENCES outlets(outlet_id),
+    FOREIGN KEY (supplier_id) REFERENCES suppliers(supplier_id),
+    FOREIGN KEY (purchase_order_id) REFERENCES purchase_orders(po_id),
+    FOREIGN KEY (created_by) REFERENCES users(user_id)
+);
+
+-- Each product received, with its stock and cost before and its cost after
+CREATE TABLE goods_receipt_items (
+    item_id BIGSERIAL PRIMARY KEY,
+    receipt_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    purchase_order_detail_id BIGINT,
+    quantity INTEGER NOT NULL,
+    unit_cost DECIMAL(15,2) NOT NULL,
+    total_cost DECIMAL(15,2) NOT NULL,
+    stock_before INTEGER NOT NULL,
+    cost_before DECIMAL(15,2) NOT NULL,
+    cost_after DECIMAL(15,2) NOT NULL,
+    FOREIGN KEY (receipt_id) REFERENCES goods_receipts(receipt_id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(product_id),
+    FOREIGN KEY (purchase_order_detail_id) REFERENCES purchase_order_details(detail_id),
+    CHECK (quantity > 0),
+    CHECK (unit_cost >= 0)
+);
+
+-- What one unit of a line's product cost when it was sold or used. Lines
+-- keep it so their margin does not move with later receipts.
+ALTER TABLE transaction_details ADD COLUMN unit_cost DECIMAL(15,2);
+ALTER TABLE service_details ADD COLUMN unit_cost DECIMAL(15,2);
+
+-- Lines sold before today take today's cost price
+UPDATE transaction_details d SET unit_cost = COALESCE(p.cost_price, 0)
+FROM products p WHERE p.product_id = d.product_id;
+UPDATE service_details d SET unit_cost = COALESCE(p.cost_price, 0)
+FROM products p WHERE p.product_id = d.product_id;
+
+ALTER TABLE gl_journal_entries DROP CONSTRAINT gl_journal_entries_source_type_check;
+ALTER TABLE gl_journal_entries ADD CONSTRAINT gl_journal_entries_source_type_check
+    CHECK (source_type IN ('manual', 'transaction', 'payment', 'vehicle_purchase', 'vehicle_sale',
+        'payment_reversal', 'sales_return', 'refund', 'credit_note', 'credit_note_application',
+        'transaction_void', 'stored_value_entry', 'goods_receipt'));
+
+INSERT INTO permissions (name, description, resource, action) VALUES
+('goods_receipts.read', 'View goods receipts', 'goods_receipts', 'read'),
+('goods_receipts.create', 'Receive goods into stock', 'goods_receipts', 'create'),
+('margins.read', 'View costs and gross margins', 'margins', 'read');
+
+INSERT INTO role_has_permissions (role_id, permission_id)
+SELECT r.role_id, p.permission_id FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('Super Admin', 'Admin', 'Manager') AND p.resource IN ('goods_receipts', 'margins');
+
+CREATE INDEX idx_goods_receipts_outlet ON goods_receipts(outlet_id, received_at);
+CREATE INDEX idx_goods_receipts_purchase_order ON goods_receipts(purchase_order_id) WHERE purchase_order_id IS NOT NULL;
+CREATE INDEX idx_goods_receipt_items_receipt ON goods_receipt_items(receipt_id);
+CREATE INDEX idx_goods_receipt_items_product ON goods_receipt_items(product_id);
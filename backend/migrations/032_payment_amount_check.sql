@@ -0,0 +1,5 @@
+-- Payment Amount Check (PostgreSQL)
+
+-- A payment only ever pays; money going back to the customer is a refund.
+-- Refunds, credit notes and their applications already hold to this.
+ALTER TABLE payments ADD CONSTRAINT payments_amount_check CHECK (amount > 0);
@@ -0,0 +1,9 @@
+-- Transaction Stock (PostgreSQL)
+
+-- Whether a line's product was taken out of stock when it was sold. Every
+-- product line is from now on; before, only package components were, so
+-- voids and returns only put back what a line actually took.
+ALTER TABLE transaction_details ADD COLUMN stock_taken BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE transaction_details SET stock_taken = true
+WHERE product_id IS NOT NULL AND transaction_package_id IS NOT NULL;
@@ -0,0 +1,24 @@
+-- Approval PIN Attempts (PostgreSQL)
+
+-- Wrong PINs in a row since the last correct one, and how long the PIN is
+-- locked once there are too many
+ALTER TABLE users ADD COLUMN approval_pin_failed_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN approval_pin_locked_until TIMESTAMP;
+
+-- Every PIN entered on a terminal, right or wrong, and who was logged in there
+CREATE TABLE approval_pin_attempts (
+    attempt_id BIGSERIAL PRIMARY KEY,
+    approval_id BIGINT NOT NULL,
+    approver_id BIGINT,
+    username VARCHAR(100) NOT NULL,
+    attempted_by BIGINT NOT NULL,
+    succeeded BOOLEAN NOT NULL,
+    locked BOOLEAN NOT NULL DEFAULT false,
+    attempted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (approval_id) REFERENCES approval_requests(approval_id),
+    FOREIGN KEY (approver_id) REFERENCES users(user_id),
+    FOREIGN KEY (attempted_by) REFERENCES users(user_id)
+);
+
+CREATE INDEX idx_approval_pin_attempts_approver ON approval_pin_attempts(approver_id, attempted_at);
+CREATE INDEX idx_approval_pin_attempts_attempted_by ON approval_pin_attempts(attempted_by, attempted_at);
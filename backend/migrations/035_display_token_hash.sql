@@ -0,0 +1,9 @@
+-- Display Token Hashes (PostgreSQL)
+
+-- Display tokens are bearer credentials, so only their SHA-256 hash is kept.
+-- Existing displays keep working: their tokens hash to the stored value.
+ALTER TABLE outlet_display_tokens ADD COLUMN token_hash VARCHAR(64);
+UPDATE outlet_display_tokens SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+ALTER TABLE outlet_display_tokens ALTER COLUMN token_hash SET NOT NULL;
+ALTER TABLE outlet_display_tokens ADD CONSTRAINT outlet_display_tokens_token_hash_key UNIQUE (token_hash);
+ALTER TABLE outlet_display_tokens DROP COLUMN token;